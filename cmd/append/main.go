@@ -24,7 +24,9 @@ Input schema:
   "path":     "<optional file path>",
   "target":   {<optional core.AgentQuery payload>},
   "target_dsl": "<optional Morfx DSL selector, such as class:Service>",
-  "content":  "<text to append>"
+  "content":  "<text to append>",
+  "format": <optional bool, run the language formatter over the result>,
+  "if_absent": <optional bool, skip targets that already hold equivalent content>
 }
 Exactly one of "source" or "path" must be provided. When "path" is set the
 file will be updated in place if the operation succeeds. "target" is optional;
@@ -48,6 +50,8 @@ type appendRequest struct {
 	Target    json.RawMessage `json:"target,omitempty"`
 	TargetDSL string          `json:"target_dsl,omitempty"`
 	Content   *string         `json:"content"`
+	Format    bool            `json:"format,omitempty"`
+	IfAbsent  bool            `json:"if_absent,omitempty"`
 }
 
 func main() {
@@ -97,8 +101,10 @@ func main() {
 	}
 
 	op := core.TransformOp{
-		Method:  "append",
-		Content: *req.Content,
+		Method:   "append",
+		Content:  *req.Content,
+		Format:   req.Format,
+		IfAbsent: req.IfAbsent,
 	}
 
 	if target, ok, err := core.ParseOptionalAgentQueryPayload(req.Target, req.TargetDSL); err != nil {
@@ -115,6 +121,7 @@ func main() {
 		_ = toolenv.WriteError(os.Stdout, "append operation failed", result.Error)
 		os.Exit(1)
 	}
+	result.Confidence = env.Score(req.Language, op.Method, src.Path, result.Confidence)
 
 	wroteFile, err := toolcmd.WriteModifiedSource(src.Path, src.FromFile, src.Code, result.Modified, src.Perm)
 	if err != nil {
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
+	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/db"
 	"github.com/oxhq/morfx/internal/toolenv"
 	"github.com/oxhq/morfx/mcp"
@@ -24,20 +26,41 @@ Input schema:
   "id": "<stage id>",          // optional; applies specific stage
   "all": <bool>,                // optional; apply every pending stage
   "latest": <bool>,             // optional; apply the most recent stage
-  "session_id": "<session id>" // optional filter when using database persistence
+  "changeset": "<changeset id>", // optional; apply every file of a changeset or none
+  "session_id": "<session id>", // optional filter when using database persistence
+  "hunks": [1, 3],              // optional with "id" or "latest"; apply only these
+                                //   1-based hunks and stage the rest as a new stage
+  "verify": {                    // optional; run after each stage is written
+    "command": "<shell command, such as go test ./pkg/...>",
+    "timeout": "<optional Go duration, default 5m>",
+    "dir": "<optional working directory, default the stage file's directory>"
+  }
 }
-Exactly one of "id", "all", or "latest" may be set. If none are provided the
-command defaults to "latest".
+Exactly one of "id", "all", "latest", or "changeset" may be set. If none are
+provided the command defaults to "latest". "hunks" can only be used with "id"
+or "latest". Stages that belong to a changeset are only applied through
+"changeset", which writes all of its files in one transaction. A file edited
+since it was staged is rebased first: the stage's transform is re-run on the
+new content, or its edit is 3-way merged, and only a conflict in both stops
+the apply (for a changeset, before any file is written). When the "verify"
+command exits non-zero or times out, that stage's or changeset's writes are
+rolled back, it is marked failed, later stages are not applied, and the tool
+exits with status 1 after writing the response.
 
 Output schema:
 {
   "content": [{"type": "text", "text": "<summary>"}],
   "applied": ["<stage ids>", ...],
   "structuredContent": {
-    "mode": "single|all|latest",
+    "mode": "single|all|latest|changeset",
     "applied": ["<stage ids>", ...],
-    "appliedCount": <int> // present only for mode "all"
-  }
+    "appliedCount": <int>, // present only for mode "all"
+    "remainder": "<id of the stage holding the hunks not applied>",
+    "rebased": {"<stage id>": "transform|merge"}, // stages whose file changed since staging
+    "changeset": "<changeset id>" // present only for mode "changeset"
+  },
+  "failed": "<stage or changeset id whose verification failed>",
+  "verification": {<core.VerifyResult of the failed stage>}
 }
 
 Flags:
@@ -46,10 +69,13 @@ Flags:
 `
 
 type applyRequest struct {
-	ID        string `json:"id,omitempty"`
-	All       bool   `json:"all,omitempty"`
-	Latest    bool   `json:"latest,omitempty"`
-	SessionID string `json:"session_id,omitempty"`
+	ID        string              `json:"id,omitempty"`
+	All       bool                `json:"all,omitempty"`
+	Latest    bool                `json:"latest,omitempty"`
+	Changeset string              `json:"changeset,omitempty"`
+	SessionID string              `json:"session_id,omitempty"`
+	Verify    *core.VerifyCommand `json:"verify,omitempty"`
+	Hunks     []int               `json:"hunks,omitempty"`
 }
 
 func main() {
@@ -82,6 +108,10 @@ func main() {
 		_ = toolenv.WriteError(os.Stdout, "invalid parameters", err)
 		os.Exit(1)
 	}
+	if err := req.Verify.Validate(); err != nil {
+		_ = toolenv.WriteError(os.Stdout, "invalid verify", err)
+		os.Exit(1)
+	}
 
 	cfg := mcp.DefaultConfig()
 	cfg.DatabaseURL = dbPath
@@ -101,14 +131,47 @@ func main() {
 
 	safety := mcp.NewSafetyManager(cfg.Safety)
 	staging := mcp.NewStagingManager(gormDB, cfg, safety)
+	// Stages whose file changed since staging are rebased by re-running
+	// their transform, so the providers are needed as well
+	if env, err := toolenv.NewEnvironment(); err == nil {
+		staging.SetProviders(env.Providers())
+	}
 
-	appliedIDs, err := applyStages(context.Background(), staging, gormDB, req, mode)
-	if err != nil {
+	verifications := make(map[string]*core.VerifyResult)
+	var remainder string
+	appliedIDs, err := applyStages(context.Background(), staging, gormDB, req, mode, verifications, &remainder)
+	failedID := ""
+	if errors.Is(err, core.ErrVerificationFailed) {
+		failedID = verificationFailure(verifications)
+	} else if err != nil {
 		_ = toolenv.WriteError(os.Stdout, "apply operation failed", err)
 		os.Exit(1)
 	}
 
+	rebased, err := fetchRebased(gormDB, appliedIDs)
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, "failed to load apply records", err)
+		os.Exit(1)
+	}
+
 	responseText := buildApplyMessage(mode, appliedIDs)
+	if remainder != "" {
+		responseText += fmt.Sprintf("\nRemaining hunks staged as %s", remainder)
+	}
+	for _, id := range appliedIDs {
+		if strategy, ok := rebased[id]; ok {
+			responseText += fmt.Sprintf("\n%s changed since staging; rebased by %s", id, strategy)
+		}
+		responseText += formatVerification(verifications[id])
+	}
+	if failedID != "" {
+		noun := "Stage"
+		if mode == "changeset" {
+			noun = "Changeset"
+		}
+		responseText += fmt.Sprintf("\n%s %s failed verification", noun, failedID)
+		responseText += formatVerification(verifications[failedID])
+	}
 
 	structured := map[string]any{"mode": mode}
 	if len(appliedIDs) > 0 {
@@ -117,6 +180,18 @@ func main() {
 	if mode == "all" {
 		structured["appliedCount"] = len(appliedIDs)
 	}
+	if len(verifications) > 0 {
+		structured["verification"] = verifications
+	}
+	if remainder != "" {
+		structured["remainder"] = remainder
+	}
+	if len(rebased) > 0 {
+		structured["rebased"] = rebased
+	}
+	if mode == "changeset" {
+		structured["changeset"] = req.Changeset
+	}
 
 	payload := map[string]any{
 		"content": []map[string]any{{
@@ -126,11 +201,46 @@ func main() {
 		"applied":           appliedIDs,
 		"structuredContent": structured,
 	}
+	if failedID != "" {
+		payload["failed"] = failedID
+		payload["verification"] = verifications[failedID]
+	}
 
 	if err := toolenv.WriteJSON(os.Stdout, payload); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to write output: %v\n", err)
 		os.Exit(1)
 	}
+	if failedID != "" {
+		os.Exit(1)
+	}
+}
+
+// verificationFailure returns the stage whose verify command failed. Apply
+// stops at the first failure, so there is at most one.
+func verificationFailure(verifications map[string]*core.VerifyResult) string {
+	for id, result := range verifications {
+		if !result.Passed {
+			return id
+		}
+	}
+	return ""
+}
+
+func formatVerification(result *core.VerifyResult) string {
+	if result == nil {
+		return ""
+	}
+	if result.Passed {
+		return fmt.Sprintf("\n✅ Verified: %s (%dms)", result.Command, result.DurationMs)
+	}
+	text := fmt.Sprintf("\n❌ Verification failed: %s", result.Summary())
+	if result.RolledBack {
+		text += "\n↩️  Changes rolled back."
+	}
+	if output := strings.TrimSpace(result.Output); output != "" {
+		text += "\n\n" + output
+	}
+	return text
 }
 
 func determineMode(req *applyRequest) (string, error) {
@@ -148,14 +258,23 @@ func determineMode(req *applyRequest) (string, error) {
 	if req.Latest {
 		candidates++
 	}
+	if req.Changeset != "" {
+		candidates++
+	}
 
 	if candidates > 1 {
-		return "", errors.New("specify only one of 'id', 'all', or 'latest'")
+		return "", errors.New("specify only one of 'id', 'all', 'latest', or 'changeset'")
+	}
+	if len(req.Hunks) > 0 && (req.All || req.Changeset != "") {
+		return "", errors.New("'hunks' can only be used with 'id' or 'latest'")
 	}
 
 	if req.ID != "" {
 		return "single", nil
 	}
+	if req.Changeset != "" {
+		return "changeset", nil
+	}
 	if req.All {
 		return "all", nil
 	}
@@ -164,17 +283,31 @@ func determineMode(req *applyRequest) (string, error) {
 	return "latest", nil
 }
 
-func applyStages(ctx context.Context, staging *mcp.StagingManager, gormDB *gorm.DB, req *applyRequest, mode string) ([]string, error) {
+func applyStages(ctx context.Context, staging *mcp.StagingManager, gormDB *gorm.DB, req *applyRequest, mode string, verifications map[string]*core.VerifyResult, remainder *string) ([]string, error) {
 	switch mode {
 	case "single":
 		if req.ID == "" {
 			return nil, errors.New("id is required for single mode")
 		}
-		if err := applyStage(ctx, staging, req.ID); err != nil {
+		if err := applyStageHunks(ctx, staging, req.ID, req, verifications, remainder); err != nil {
 			return nil, err
 		}
 		return []string{req.ID}, nil
 
+	case "changeset":
+		changeset, result, err := staging.ApplyChangeset(ctx, req.Changeset, req.Verify)
+		if result != nil {
+			verifications[req.Changeset] = result
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(changeset.Stages))
+		for _, stage := range changeset.Stages {
+			ids = append(ids, stage.ID)
+		}
+		return ids, nil
+
 	case "all":
 		ids, err := fetchPendingStageIDs(gormDB, req.SessionID)
 		if err != nil {
@@ -185,7 +318,7 @@ func applyStages(ctx context.Context, staging *mcp.StagingManager, gormDB *gorm.
 		}
 		var applied []string
 		for _, id := range ids {
-			if err := applyStage(ctx, staging, id); err != nil {
+			if err := applyStage(ctx, staging, id, req.Verify, verifications); err != nil {
 				return applied, err
 			}
 			applied = append(applied, id)
@@ -201,7 +334,7 @@ func applyStages(ctx context.Context, staging *mcp.StagingManager, gormDB *gorm.
 			return nil, errors.New("no pending stages available")
 		}
 		latestID := ids[0]
-		if err := applyStage(ctx, staging, latestID); err != nil {
+		if err := applyStageHunks(ctx, staging, latestID, req, verifications, remainder); err != nil {
 			return nil, err
 		}
 		return []string{latestID}, nil
@@ -211,11 +344,32 @@ func applyStages(ctx context.Context, staging *mcp.StagingManager, gormDB *gorm.
 	}
 }
 
-func applyStage(ctx context.Context, staging *mcp.StagingManager, stageID string) error {
+// applyStage applies one stage, running verify after the write when set and
+// recording its result in verifications.
+func applyStage(ctx context.Context, staging *mcp.StagingManager, stageID string, verify *core.VerifyCommand, verifications map[string]*core.VerifyResult) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	_, err := staging.ApplyStage(ctx, stageID, false)
+	_, result, err := staging.ApplyStageVerified(ctx, stageID, verify)
+	if result != nil {
+		verifications[stageID] = result
+	}
+	return err
+}
+
+// applyStageHunks applies one stage, or only req.Hunks of it when set,
+// recording the id of the stage left holding the other hunks in remainder.
+func applyStageHunks(ctx context.Context, staging *mcp.StagingManager, stageID string, req *applyRequest, verifications map[string]*core.VerifyResult, remainder *string) error {
+	if len(req.Hunks) == 0 {
+		return applyStage(ctx, staging, stageID, req.Verify, verifications)
+	}
+	_, rest, result, err := staging.ApplyStageHunks(ctx, stageID, req.Hunks, req.Verify)
+	if result != nil {
+		verifications[stageID] = result
+	}
+	if rest != nil {
+		*remainder = rest.ID
+	}
 	return err
 }
 
@@ -225,7 +379,7 @@ func fetchPendingStageIDs(gormDB *gorm.DB, sessionID string) ([]string, error) {
 	}
 
 	query := gormDB.Model(&models.Stage{}).
-		Where("status = ?", "pending")
+		Where("status = ? AND (changeset_id = '' OR changeset_id IS NULL)", "pending")
 	if sessionID != "" {
 		query = query.Where("session_id = ?", sessionID)
 	}
@@ -237,6 +391,22 @@ func fetchPendingStageIDs(gormDB *gorm.DB, sessionID string) ([]string, error) {
 	return ids, nil
 }
 
+// fetchRebased maps each of stageIDs that was rebased when applied to how.
+func fetchRebased(gormDB *gorm.DB, stageIDs []string) (map[string]string, error) {
+	rebased := make(map[string]string)
+	if len(stageIDs) == 0 {
+		return rebased, nil
+	}
+	var applies []models.Apply
+	if err := gormDB.Where("stage_id IN ? AND rebased <> ''", stageIDs).Find(&applies).Error; err != nil {
+		return nil, err
+	}
+	for _, apply := range applies {
+		rebased[apply.StageID] = apply.Rebased
+	}
+	return rebased, nil
+}
+
 func buildApplyMessage(mode string, applied []string) string {
 	switch mode {
 	case "single":
@@ -251,6 +421,8 @@ func buildApplyMessage(mode string, applied []string) string {
 		return fmt.Sprintf("Applied latest stage: %s", applied[0])
 	case "all":
 		return fmt.Sprintf("Applied %d stage(s)", len(applied))
+	case "changeset":
+		return fmt.Sprintf("Applied changeset: %d file(s)", len(applied))
 	default:
 		return "Apply operation completed"
 	}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/internal/toolcmd"
+	"github.com/oxhq/morfx/internal/toolenv"
+)
+
+const batchHelp = `Usage: batch [-h]
+
+Reads a JSON request from stdin and emits a JSON response to stdout.
+
+Input schema:
+{
+  "language": "<language id>",
+  "source":   "<optional source code>",
+  "path":     "<optional file path>",
+  "ops": [
+    {
+      "method":        "replace|delete|insert_before|insert_after|append|extract_function|ensure_import|remove_import|sort|add_annotation|remove_annotation|add_tag|remove_tag|rewrite_tag|set_docstring|annotate",
+      "target":        {<optional core.AgentQuery payload>},
+      "target_dsl":    "<optional Morfx DSL selector>",
+      "replacement":   "<replacement text for replace>",
+      "content":       "<content for insert and append, or docstring text for set_docstring>",
+      "import":        "<import for ensure_import>",
+      "function_name": "<name for extract_function>",
+      "sort_by":       "<optional name|visibility|kind|$capture for sort>",
+      "sort_order":    [<optional visibility or kind ranks for sort>],
+      "annotation":    "<annotation or tag template to add or rewrite, or name pattern to remove>",
+      "tag_key":       "<struct tag key for rewrite_tag>",
+      "type_hints":    {<parameter name or "return" to type, for annotate>},
+      "overlap":       "<optional outermost|innermost|error for replace and delete>",
+      "attached":      "<optional include|exclude doc comments and decorators for replace and delete>",
+      "if_absent":     <optional bool, skip inserts whose content already exists>,
+      "expect_matches": <optional count such as 1, or a range such as "1..5">
+    }
+  ],
+  "organize_imports": <optional bool, prune and add imports after the last step>,
+  "format": <optional bool, run the language formatter over the result>
+}
+Exactly one of "source" or "path" must be provided. Operations run in order
+on the in-memory source; each one resolves its target against the result of
+the previous one. When "path" is set the file is written once, after every
+operation succeeded.
+
+Output schema:
+{
+  "content":   [{"type": "text", "text": "<summary>"}],
+  "matches":   <int, summed over operations>,
+  "steps":     [{"method": "...", "matches": <int>, "confidence": <float>, "factors": [...], "changed": <bool>}],
+  "diff":      "<unified diff against the original>",
+  "confidence": {<core.ConfidenceScore, the weakest operation's score and factors>},
+  "modified":  "<modified source>",
+  "path":      "<optional original path>",
+  "applied":   <bool indicating file write>
+}`
+
+type batchRequest struct {
+	Language        string         `json:"language"`
+	Source          *string        `json:"source,omitempty"`
+	Path            *string        `json:"path,omitempty"`
+	Ops             []core.BatchOp `json:"ops"`
+	OrganizeImports bool           `json:"organize_imports,omitempty"`
+	Format          bool           `json:"format,omitempty"`
+}
+
+func main() {
+	var showHelp bool
+	flag.BoolVar(&showHelp, "h", false, "Show help message")
+	flag.BoolVar(&showHelp, "help", false, "Show help message")
+	flag.Usage = func() {
+		fmt.Print(batchHelp)
+	}
+	flag.Parse()
+	if showHelp {
+		flag.Usage()
+		os.Exit(0)
+	}
+
+	env, err := toolenv.NewEnvironment()
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, "failed to initialise environment", err)
+		os.Exit(1)
+	}
+
+	req, err := toolenv.ReadJSON[batchRequest](os.Stdin)
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, "invalid input", err)
+		os.Exit(1)
+	}
+
+	if strings.TrimSpace(req.Language) == "" {
+		_ = toolenv.WriteError(os.Stdout, "language is required", errors.New("missing language"))
+		os.Exit(1)
+	}
+
+	op, err := core.BatchTransformOp(req.Ops)
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, "invalid ops", err)
+		os.Exit(1)
+	}
+	op.OrganizeImports = req.OrganizeImports
+	op.Format = req.Format
+
+	src, err := toolenv.LoadSource(req.Source, req.Path)
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, "failed to resolve source", err)
+		os.Exit(1)
+	}
+
+	provider, err := env.Provider(req.Language)
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, "language provider not available", err)
+		os.Exit(1)
+	}
+
+	result := provider.Transform(src.Code, op)
+	if result.Error != nil {
+		_ = toolenv.WriteError(os.Stdout, "batch operation failed", result.Error)
+		os.Exit(1)
+	}
+	result.Confidence = env.Score(req.Language, op.Method, src.Path, result.Confidence)
+
+	wroteFile, err := toolcmd.WriteModifiedSource(src.Path, src.FromFile, src.Code, result.Modified, src.Perm)
+	if err != nil {
+		if writeErr := toolenv.WriteError(os.Stdout, "failed to write modified file", err); writeErr != nil {
+			fmt.Fprintf(os.Stderr, "failed to write error output: %v\n", writeErr)
+		}
+		os.Exit(1)
+	}
+
+	responseText := formatBatchResponse(result, src.Path, src.FromFile, wroteFile)
+
+	payload := map[string]any{
+		"content": []map[string]any{
+			{
+				"type": "text",
+				"text": responseText,
+			},
+		},
+		"matches":    result.MatchCount,
+		"steps":      result.Metadata["steps"],
+		"diff":       result.Diff,
+		"confidence": result.Confidence,
+		"modified":   result.Modified,
+	}
+
+	if src.FromFile {
+		payload["path"] = src.Path
+		payload["applied"] = wroteFile
+	}
+
+	if err := toolenv.WriteJSON(os.Stdout, payload); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func formatBatchResponse(result core.TransformResult, path string, fromFile bool, applied bool) string {
+	var builder strings.Builder
+	builder.WriteString("✅ Batch operation completed successfully\n\n")
+
+	if fromFile {
+		builder.WriteString(fmt.Sprintf("📄 File: %s\n", path))
+		if applied {
+			builder.WriteString("Changes written to disk.\n\n")
+		} else {
+			builder.WriteString("Preview only; file not modified.\n\n")
+		}
+	}
+
+	steps, _ := result.Metadata["steps"].([]map[string]any)
+	for i, step := range steps {
+		builder.WriteString(fmt.Sprintf("%d. %v: %v matches\n", i+1, step["method"], step["matches"]))
+	}
+	if strings.TrimSpace(result.Diff) != "" {
+		builder.WriteString("\nDiff:\n")
+		builder.WriteString(result.Diff)
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString("\nConfidence: ")
+	builder.WriteString(toolcmd.FormatConfidence(result.Confidence.Score))
+	builder.WriteString(fmt.Sprintf(" (%.1f%%)", result.Confidence.Score*100))
+
+	return builder.String()
+}
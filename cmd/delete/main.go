@@ -23,7 +23,10 @@ Input schema:
   "source":   "<optional source code>",
   "path":     "<optional file path>",
   "target":   {<optional core.AgentQuery payload>},
-  "target_dsl": "<optional Morfx DSL selector, such as func:Legacy*>"
+  "target_dsl": "<optional Morfx DSL selector, such as func:Legacy*>",
+  "format": <optional bool, run the language formatter over the result>,
+  "overlap": "<optional outermost|innermost|error, for nested or overlapping matches>",
+  "attached": "<optional include|exclude, cover doc comments, decorators, and trailing comments>"
 }
 Exactly one of "source" or "path" must be provided. When "path" is supplied
 the file will be read and, if changed, written back.
@@ -35,6 +38,7 @@ Output schema:
   "diff":      "<unified diff>",
   "confidence": {<core.ConfidenceScore>},
   "modified":  "<modified source>",
+  "dropped_matches": [<optional core.Match skipped as overlapping>],
   "path":      "<optional original path>",
   "applied":   <bool indicating file write>
 }`
@@ -45,6 +49,9 @@ type deleteRequest struct {
 	Path      *string         `json:"path,omitempty"`
 	Target    json.RawMessage `json:"target"`
 	TargetDSL string          `json:"target_dsl,omitempty"`
+	Format    bool            `json:"format,omitempty"`
+	Overlap   string          `json:"overlap,omitempty"`
+	Attached  string          `json:"attached,omitempty"`
 }
 
 func main() {
@@ -100,8 +107,11 @@ func main() {
 	}
 
 	op := core.TransformOp{
-		Method: "delete",
-		Target: target,
+		Method:   "delete",
+		Target:   target,
+		Format:   req.Format,
+		Overlap:  req.Overlap,
+		Attached: req.Attached,
 	}
 
 	result := provider.Transform(src.Code, op)
@@ -109,6 +119,7 @@ func main() {
 		_ = toolenv.WriteError(os.Stdout, "delete operation failed", result.Error)
 		os.Exit(1)
 	}
+	result.Confidence = env.Score(req.Language, op.Method, src.Path, result.Confidence)
 
 	wroteFile, err := toolcmd.WriteModifiedSource(src.Path, src.FromFile, src.Code, result.Modified, src.Perm)
 	if err != nil {
@@ -131,6 +142,10 @@ func main() {
 		"modified":   result.Modified,
 	}
 
+	if dropped, ok := result.Metadata["dropped_matches"]; ok {
+		payload["dropped_matches"] = dropped
+	}
+
 	if src.FromFile {
 		payload["path"] = src.Path
 		payload["applied"] = wroteFile
@@ -31,10 +31,17 @@ Input schema:
   "target": {<optional core.AgentQuery payload>},
   "target_dsl": "<optional Morfx DSL selector, such as func:Debug*>",
   "dry_run": <bool>,
-  "backup": <bool>
+  "backup": <bool>,
+  "format": <optional bool, run the language formatter over the result>,
+  "overlap": "<optional outermost|innermost|error, for nested or overlapping matches>",
+  "attached": "<optional include|exclude, cover doc comments, decorators, and trailing comments>",
+  "structural_diff": <optional bool, report node-level edits per file>,
+  "expect_matches": <optional count such as 1, or a range such as "1..5">
 }
 "path" must reference an accessible directory. When "dry_run" is true the
-filesystem is not modified.
+filesystem is not modified. When the total match count falls outside
+"expect_matches", nothing is written and the error lists every matched
+location.
 
 Output schema:
 {
@@ -49,11 +56,16 @@ Output schema:
 }`
 
 type fileDeleteRequest struct {
-	Scope     *core.FileScope `json:"scope"`
-	Target    json.RawMessage `json:"target"`
-	TargetDSL string          `json:"target_dsl,omitempty"`
-	DryRun    bool            `json:"dry_run"`
-	Backup    bool            `json:"backup"`
+	Scope          *core.FileScope `json:"scope"`
+	Target         json.RawMessage `json:"target"`
+	TargetDSL      string          `json:"target_dsl,omitempty"`
+	DryRun         bool            `json:"dry_run"`
+	Backup         bool            `json:"backup"`
+	Format         bool            `json:"format,omitempty"`
+	Overlap        string          `json:"overlap,omitempty"`
+	Attached       string          `json:"attached,omitempty"`
+	StructuralDiff bool            `json:"structural_diff,omitempty"`
+	ExpectMatches  core.MatchRange `json:"expect_matches,omitempty"`
 }
 
 func main() {
@@ -114,8 +126,13 @@ func main() {
 
 	op := core.FileTransformOp{
 		TransformOp: core.TransformOp{
-			Method: "delete",
-			Target: target,
+			Method:         "delete",
+			Target:         target,
+			Format:         req.Format,
+			Overlap:        req.Overlap,
+			Attached:       req.Attached,
+			StructuralDiff: req.StructuralDiff,
+			ExpectMatches:  req.ExpectMatches,
 		},
 		Scope:    *req.Scope,
 		DryRun:   req.DryRun,
@@ -32,10 +32,24 @@ Input schema:
   "target_dsl": "<optional Morfx DSL selector, such as func:* > call:os.Getenv>",
   "replacement": "<text to insert>",
   "dry_run": <bool>,
-  "backup": <bool>
+  "backup": <bool>,
+  "format": <optional bool, run the language formatter over the result>,
+  "overlap": "<optional outermost|innermost|error, for nested or overlapping matches>",
+  "attached": "<optional include|exclude, cover doc comments, decorators, and trailing comments>",
+  "structural_diff": <optional bool, report node-level edits per file>,
+  "expect_matches": <optional count such as 1, or a range such as "1..5">,
+  "verify": {
+    "command": "<shell command run after writing, such as go test ./pkg/...>",
+    "timeout": "<optional Go duration, default 5m>",
+    "dir": "<optional working directory, default scope.path>"
+  }
 }
 "path" must reference an accessible directory. When "dry_run" is true the
-filesystem is not modified.
+filesystem is not modified. When the total match count falls outside
+"expect_matches", nothing is written and the error lists every matched
+location. When the "verify" command exits non-zero or times out, the written
+files are rolled back, the command's output is returned in "verification",
+and the tool exits with status 1.
 
 Output schema:
 {
@@ -46,16 +60,23 @@ Output schema:
   "dry_run": <bool>,
   "errors": ["<issues>", ...],
   "transaction": "<optional transaction id>",
-  "details": [<core.FileTransformDetail objects>]
+  "details": [<core.FileTransformDetail objects>],
+  "verification": {<optional core.VerifyResult: command, passed, exit_code, output, rolled_back>}
 }`
 
 type fileReplaceRequest struct {
-	Scope       *core.FileScope `json:"scope"`
-	Target      json.RawMessage `json:"target"`
-	TargetDSL   string          `json:"target_dsl,omitempty"`
-	Replacement string          `json:"replacement"`
-	DryRun      bool            `json:"dry_run"`
-	Backup      bool            `json:"backup"`
+	Scope          *core.FileScope     `json:"scope"`
+	Target         json.RawMessage     `json:"target"`
+	TargetDSL      string              `json:"target_dsl,omitempty"`
+	Replacement    string              `json:"replacement"`
+	DryRun         bool                `json:"dry_run"`
+	Backup         bool                `json:"backup"`
+	Format         bool                `json:"format,omitempty"`
+	Overlap        string              `json:"overlap,omitempty"`
+	Attached       string              `json:"attached,omitempty"`
+	StructuralDiff bool                `json:"structural_diff,omitempty"`
+	ExpectMatches  core.MatchRange     `json:"expect_matches,omitempty"`
+	Verify         *core.VerifyCommand `json:"verify,omitempty"`
 }
 
 func main() {
@@ -113,6 +134,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := req.Verify.Validate(); err != nil {
+		_ = toolenv.WriteError(os.Stdout, "invalid verify", err)
+		os.Exit(1)
+	}
+
 	target, err := core.ParseAgentQueryPayload(req.Target, req.TargetDSL)
 	if err != nil {
 		_ = toolenv.WriteError(os.Stdout, "invalid target structure", err)
@@ -121,17 +147,27 @@ func main() {
 
 	op := core.FileTransformOp{
 		TransformOp: core.TransformOp{
-			Method:      "replace",
-			Target:      target,
-			Replacement: req.Replacement,
+			Method:         "replace",
+			Target:         target,
+			Replacement:    req.Replacement,
+			Format:         req.Format,
+			Overlap:        req.Overlap,
+			Attached:       req.Attached,
+			StructuralDiff: req.StructuralDiff,
+			ExpectMatches:  req.ExpectMatches,
 		},
 		Scope:    *req.Scope,
 		DryRun:   req.DryRun,
 		Backup:   req.Backup,
 		Parallel: true,
+		Verify:   req.Verify,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	timeout := 60 * time.Second
+	if req.Verify != nil {
+		timeout += req.Verify.TimeoutDuration()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	processor := env.FileProcessor()
@@ -156,11 +192,17 @@ func main() {
 		"transaction":     result.TransactionID,
 		"details":         result.Files,
 	}
+	if result.Verification != nil {
+		payload["verification"] = result.Verification
+	}
 
 	if err := toolenv.WriteJSON(os.Stdout, payload); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to write output: %v\n", err)
 		os.Exit(1)
 	}
+	if result.Verification != nil && !result.Verification.Passed {
+		os.Exit(1)
+	}
 }
 
 func formatFileReplaceResponse(result *core.FileTransformResult, dryRun bool) string {
@@ -199,6 +241,17 @@ func formatFileReplaceResponse(result *core.FileTransformResult, dryRun bool) st
 		}
 	}
 
+	if verification := result.Verification; verification != nil {
+		if verification.Passed {
+			builder.WriteString(fmt.Sprintf("\n✅ Verified: %s (%dms)\n", verification.Command, verification.DurationMs))
+		} else {
+			builder.WriteString(fmt.Sprintf("\n❌ Verification failed: %s\n", verification.Summary()))
+			if verification.RolledBack {
+				builder.WriteString("↩️  Changes rolled back.\n")
+			}
+		}
+	}
+
 	if dryRun {
 		builder.WriteString("\n⚠️  This was a dry run. No files were modified.\n")
 	}
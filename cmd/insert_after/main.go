@@ -24,7 +24,9 @@ Input schema:
   "path":     "<optional file path>",
   "target":   {<optional core.AgentQuery payload>},
   "target_dsl": "<optional Morfx DSL selector, such as func:Legacy*>",
-  "content":  "<text to insert after matches>"
+  "content":  "<text to insert after matches>",
+  "format": <optional bool, run the language formatter over the result>,
+  "if_absent": <optional bool, skip targets that already hold equivalent content>
 }
 Exactly one of "source" or "path" must be provided. When "path" is provided
 and the transformation succeeds the file is updated in place.
@@ -47,6 +49,8 @@ type insertAfterRequest struct {
 	Target    json.RawMessage `json:"target"`
 	TargetDSL string          `json:"target_dsl,omitempty"`
 	Content   string          `json:"content"`
+	Format    bool            `json:"format,omitempty"`
+	IfAbsent  bool            `json:"if_absent,omitempty"`
 }
 
 func main() {
@@ -106,9 +110,11 @@ func main() {
 	}
 
 	op := core.TransformOp{
-		Method:  "insert_after",
-		Target:  target,
-		Content: req.Content,
+		Method:   "insert_after",
+		Target:   target,
+		Content:  req.Content,
+		Format:   req.Format,
+		IfAbsent: req.IfAbsent,
 	}
 
 	result := provider.Transform(src.Code, op)
@@ -116,6 +122,7 @@ func main() {
 		_ = toolenv.WriteError(os.Stdout, "insert_after operation failed", result.Error)
 		os.Exit(1)
 	}
+	result.Confidence = env.Score(req.Language, op.Method, src.Path, result.Confidence)
 
 	wroteFile, err := toolcmd.WriteModifiedSource(src.Path, src.FromFile, src.Code, result.Modified, src.Perm)
 	if err != nil {
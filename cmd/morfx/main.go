@@ -8,6 +8,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 
+	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/internal/buildinfo"
 	"github.com/oxhq/morfx/mcp"
 )
@@ -41,6 +42,10 @@ providing code transformation capabilities with confidence scoring and staged op
 	debug              bool
 	autoApply          bool
 	autoApplyThreshold float64
+	typeCheck          bool
+	calibrate          bool
+	verify             bool
+	verifyAllow        []string
 )
 
 func init() {
@@ -54,6 +59,11 @@ func init() {
 	mcpCmd.Flags().BoolVar(&autoApply, "auto-apply", true, "Enable auto-apply for high confidence operations")
 	mcpCmd.Flags().
 		Float64Var(&autoApplyThreshold, "auto-threshold", 0.85, "Confidence threshold for auto-apply (0.0-1.0)")
+	mcpCmd.Flags().BoolVar(&typeCheck, "type-check", false, "Type-check modified Go packages before scoring")
+	mcpCmd.Flags().BoolVar(&calibrate, "calibrate", false, "Adjust confidence scores using apply/revert history")
+	mcpCmd.Flags().BoolVar(&verify, "verify", false, "Let clients run verify commands after writes")
+	mcpCmd.Flags().
+		StringSliceVar(&verifyAllow, "verify-allow", nil, "Verify commands or prefixes clients may run (default: any, with --verify)")
 
 	// Add commands to root
 	rootCmd.AddCommand(mcpCmd)
@@ -84,6 +94,9 @@ func runMCPServer(cmd *cobra.Command, args []string) {
 	config.Debug = debug
 	config.AutoApplyEnabled = autoApply
 	config.AutoApplyThreshold = autoApplyThreshold
+	config.TypeCheck = typeCheck
+	config.Calibrate = calibrate
+	config.Verify = core.VerifyPolicy{Enabled: verify, Allow: verifyAllow}
 
 	// Log startup info if debug enabled
 	if debug {
@@ -125,6 +138,10 @@ func resetFlags() {
 	dbURL = ""
 	autoApply = true
 	autoApplyThreshold = 0.85
+	typeCheck = false
+	calibrate = false
+	verify = false
+	verifyAllow = nil
 }
 
 // setupTestEnvironment sets up a test environment for integration tests
@@ -2,18 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/db"
 	"github.com/oxhq/morfx/internal/toolenv"
+	"github.com/oxhq/morfx/mcp"
+	"github.com/oxhq/morfx/models"
 )
 
-const recipeHelp = `Usage: recipe [-h]
+const recipeHelp = `Usage: recipe [--db path] [-h]
 
 Reads a Morfx recipe JSON document from stdin and emits a JSON response to stdout.
 
@@ -22,7 +27,9 @@ Input schema:
   "name": "repeatable transform name",
   "description": "optional description",
   "dry_run": true,
+  "stage": false,
   "min_confidence": 0.85,
+  "verify": {"command": "go test ./...", "timeout": "5m"},
   "steps": [
     {
       "name": "replace one target family",
@@ -40,13 +47,34 @@ Input schema:
   ]
 }
 
-Supported step methods: replace, delete, insert_before, insert_after, append.
+Supported step methods: replace, delete, insert_before, insert_after, append,
+add_annotation, remove_annotation, add_tag, remove_tag, rewrite_tag,
+set_docstring, annotate.
 Apply-mode recipes always run a dry-run preflight first and only mutate files
 when each step meets its min_confidence gate.
+The optional "verify" command runs once after the last applied step; a step
+may set its own, which runs right after that step. When either exits non-zero
+or times out, every applied step's transaction is rolled back, the recipe
+stops, the output reports "verification" (and "failed_step" for a step's
+command), and the tool exits with status 1.
+With "stage" set nothing is written: each step is previewed on top of the
+previous steps' edits and every edited file is staged in the database as one
+changeset, reported as "changeset". Apply it with the apply tool's
+"changeset" field, which writes all of its files or none. "stage" cannot be
+combined with "verify"; pass verify to apply instead.
+
+Flags:
+  --db <path>   Path to the Morfx SQLite database used by "stage"
+                (default ./.morfx/db/morfx.db)
+  -h, --help    Show this help message
 `
 
 func main() {
-	var showHelp bool
+	var (
+		dbPath   string
+		showHelp bool
+	)
+	flag.StringVar(&dbPath, "db", "./.morfx/db/morfx.db", "Path to the Morfx SQLite database")
 	flag.BoolVar(&showHelp, "h", false, "Show help message")
 	flag.BoolVar(&showHelp, "help", false, "Show help message")
 	flag.Usage = func() {
@@ -72,18 +100,26 @@ func main() {
 		writeErrorAndExit("invalid recipe scope", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second+core.RecipeVerifyTimeout(*req))
 	defer cancel()
 
 	result, err := core.ExecuteRecipe(ctx, env.FileProcessor(), *req)
-	if err != nil {
+	if err != nil && !(errors.Is(err, core.ErrVerificationFailed) && result != nil) {
 		writeErrorAndExit("recipe failed", err)
 	}
 
+	var changeset *models.Changeset
+	if req.Stage && !req.DryRun && len(result.Changes) > 0 {
+		changeset, err = stageRecipe(ctx, dbPath, req, result.Changes)
+		if err != nil {
+			writeErrorAndExit("failed to stage changeset", err)
+		}
+	}
+
 	payload := map[string]any{
 		"content": []map[string]any{{
 			"type": "text",
-			"text": formatRecipeResponse(result),
+			"text": formatRecipeResponse(result, changeset),
 		}},
 		"name":            result.Name,
 		"dry_run":         result.DryRun,
@@ -94,11 +130,49 @@ func main() {
 		"transaction_ids": result.TransactionIDs,
 		"steps":           result.Steps,
 	}
+	if changeset != nil {
+		payload["changeset"] = changeset.ID
+	}
+	if result.FailedStep != "" {
+		payload["failed_step"] = result.FailedStep
+	}
+	if result.Verification != nil {
+		payload["verification"] = result.Verification
+	}
 
 	if err := toolenv.WriteJSON(os.Stdout, payload); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to write output: %v\n", err)
 		os.Exit(1)
 	}
+	if result.Verification != nil {
+		os.Exit(1)
+	}
+}
+
+// stageRecipe stages a recipe's combined edits as one changeset in the
+// database at dbPath.
+func stageRecipe(ctx context.Context, dbPath string, recipe *core.Recipe, changes []core.FileChange) (*models.Changeset, error) {
+	cfg := mcp.DefaultConfig()
+	cfg.DatabaseURL = dbPath
+	cfg.Debug = false
+	cfg.LogWriter = io.Discard
+
+	gormDB, err := db.Connect(cfg.DatabaseURL, cfg.Debug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func() {
+		if sqlDB, err := gormDB.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+
+	staging := mcp.NewStagingManager(gormDB, cfg, mcp.NewSafetyManager(cfg.Safety))
+	description := "recipe " + recipe.Name
+	if recipe.Description != "" {
+		description += ": " + recipe.Description
+	}
+	return staging.CreateChangeset(ctx, "", "recipe", description, changes)
 }
 
 func writeErrorAndExit(message string, err error) {
@@ -129,7 +203,7 @@ func normalizeRecipeScopes(recipe *core.Recipe) error {
 	return nil
 }
 
-func formatRecipeResponse(result *core.RecipeResult) string {
+func formatRecipeResponse(result *core.RecipeResult, changeset *models.Changeset) string {
 	if result == nil {
 		return "Recipe returned no result"
 	}
@@ -139,10 +213,19 @@ func formatRecipeResponse(result *core.RecipeResult) string {
 	if result.DryRun {
 		mode = " [DRY RUN]"
 		modifiedLabel = "Files that would be modified"
+	} else if changeset != nil {
+		mode = " [STAGED]"
+		modifiedLabel = "Files that would be modified"
 	}
 
 	var builder strings.Builder
-	fmt.Fprintf(&builder, "Recipe %s completed%s\n\n", result.Name, mode)
+	if result.FailedStep != "" {
+		fmt.Fprintf(&builder, "Recipe %s stopped at step %s\n\n", result.Name, result.FailedStep)
+	} else if result.Verification != nil {
+		fmt.Fprintf(&builder, "Recipe %s failed verification and was rolled back\n\n", result.Name)
+	} else {
+		fmt.Fprintf(&builder, "Recipe %s completed%s\n\n", result.Name, mode)
+	}
 	fmt.Fprintf(&builder, "Steps run: %d\n", result.StepsRun)
 	fmt.Fprintf(&builder, "Files scanned: %d\n", result.FilesScanned)
 	fmt.Fprintf(&builder, "%s: %d\n", modifiedLabel, result.FilesModified)
@@ -179,6 +262,18 @@ func formatRecipeResponse(result *core.RecipeResult) string {
 		}
 	}
 
+	if verification := result.Verification; verification != nil {
+		fmt.Fprintf(&builder, "\nVerification failed: %s\n", verification.Summary())
+		if verification.RolledBack {
+			builder.WriteString("Step changes rolled back.\n")
+		}
+	}
+
+	if changeset != nil {
+		fmt.Fprintf(&builder, "\nStaged %d file(s) as changeset %s (confidence %.2f).\n",
+			len(changeset.Stages), changeset.ID, changeset.ConfidenceScore)
+	}
+
 	if result.DryRun {
 		builder.WriteString("\nThis was a dry run. No files were modified.\n")
 	}
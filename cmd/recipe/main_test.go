@@ -29,7 +29,7 @@ func TestFormatRecipeResponseIncludesAggregateCounts(t *testing.T) {
 		}},
 	}
 
-	text := formatRecipeResponse(result)
+	text := formatRecipeResponse(result, nil)
 
 	for _, want := range []string{
 		"Recipe rename-handlers completed [DRY RUN]",
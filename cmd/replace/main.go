@@ -24,7 +24,10 @@ Input schema:
   "path":     "<optional file path>",
   "target":   {<optional core.AgentQuery payload>},
   "target_dsl": "<optional Morfx DSL selector, such as func:Legacy*>",
-  "replacement": "<replacement text>"
+  "replacement": "<replacement text>",
+  "format": <optional bool, run the language formatter over the result>,
+  "overlap": "<optional outermost|innermost|error, for nested or overlapping matches>",
+  "attached": "<optional include|exclude, cover doc comments, decorators, and trailing comments>"
 }
 Exactly one of "source" or "path" must be provided. When "path" is set the
 file will be read and modified in place.
@@ -36,6 +39,7 @@ Output schema:
   "diff":      "<unified diff>",
   "confidence": {<core.ConfidenceScore>},
   "modified":  "<modified source>",
+  "dropped_matches": [<optional core.Match skipped as overlapping>],
   "path":      "<optional original path>",
   "applied":   <bool indicating file write>
 }`
@@ -47,6 +51,9 @@ type replaceRequest struct {
 	Target      json.RawMessage `json:"target"`
 	TargetDSL   string          `json:"target_dsl,omitempty"`
 	Replacement string          `json:"replacement"`
+	Format      bool            `json:"format,omitempty"`
+	Overlap     string          `json:"overlap,omitempty"`
+	Attached    string          `json:"attached,omitempty"`
 }
 
 func main() {
@@ -109,6 +116,9 @@ func main() {
 		Method:      "replace",
 		Target:      target,
 		Replacement: req.Replacement,
+		Format:      req.Format,
+		Overlap:     req.Overlap,
+		Attached:    req.Attached,
 	}
 
 	result := provider.Transform(src.Code, op)
@@ -116,6 +126,7 @@ func main() {
 		_ = toolenv.WriteError(os.Stdout, "replace operation failed", result.Error)
 		os.Exit(1)
 	}
+	result.Confidence = env.Score(req.Language, op.Method, src.Path, result.Confidence)
 
 	wroteFile, err := toolcmd.WriteModifiedSource(src.Path, src.FromFile, src.Code, result.Modified, src.Perm)
 	if err != nil {
@@ -140,6 +151,10 @@ func main() {
 		"modified":   result.Modified,
 	}
 
+	if dropped, ok := result.Metadata["dropped_matches"]; ok {
+		payload["dropped_matches"] = dropped
+	}
+
 	if src.FromFile {
 		payload["path"] = src.Path
 		payload["applied"] = wroteFile
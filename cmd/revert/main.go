@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/db"
+	"github.com/oxhq/morfx/internal/toolenv"
+	"github.com/oxhq/morfx/mcp"
+	"github.com/oxhq/morfx/mcp/types"
+)
+
+const revertHelp = `Usage: revert [--db path] [-h]
+
+Reads a JSON request from stdin and emits a JSON response to stdout.
+
+Input schema:
+{
+  "id": "<stage id>",          // optional; revert a specific applied stage
+  "session_id": "<session id>", // optional; revert every applied stage in the session
+  "last": <int>                 // optional; revert the last N applied stages,
+                                //   within "session_id" when it is set
+}
+Exactly one of "id", "session_id", or "last" selects the stages; "last" may be
+combined with "session_id". If none are provided the command reverts the most
+recently applied stage. Stages are reverted newest first. A file that still
+holds what the stage wrote gets its pre-apply content back; a file edited
+since is 3-way merged so later edits are kept. When the edits overlap, that
+stage is left applied, later stages are not reverted, and the tool exits with
+status 1 after writing the response.
+
+Output schema:
+{
+  "content": [{"type": "text", "text": "<summary>"}],
+  "reverted": ["<stage ids>", ...],
+  "structuredContent": {
+    "mode": "single|session|last",
+    "reverted": [{"stage_id": "...", "apply_id": "...", "file_path": "...", "merged": <bool>}, ...]
+  },
+  "failed": "<stage id that conflicted>",
+  "error": "<conflict description>"
+}
+
+Flags:
+  --db <path>   Path to the Morfx SQLite database (default ./.morfx/db/morfx.db)
+  -h, --help    Show this help message
+`
+
+type revertRequest struct {
+	ID        string `json:"id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Last      int    `json:"last,omitempty"`
+}
+
+func main() {
+	var (
+		dbPath   string
+		showHelp bool
+	)
+
+	flag.StringVar(&dbPath, "db", "./.morfx/db/morfx.db", "Path to the Morfx SQLite database")
+	flag.BoolVar(&showHelp, "h", false, "Show help message")
+	flag.BoolVar(&showHelp, "help", false, "Show help message")
+	flag.Usage = func() {
+		fmt.Print(revertHelp)
+	}
+	flag.Parse()
+
+	if showHelp {
+		flag.Usage()
+		os.Exit(0)
+	}
+
+	req, err := toolenv.ReadJSON[revertRequest](os.Stdin)
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, "invalid input", err)
+		os.Exit(1)
+	}
+
+	mode, err := determineMode(req)
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, "invalid parameters", err)
+		os.Exit(1)
+	}
+
+	cfg := mcp.DefaultConfig()
+	cfg.DatabaseURL = dbPath
+	cfg.Debug = false
+	cfg.LogWriter = io.Discard
+
+	gormDB, err := db.Connect(cfg.DatabaseURL, cfg.Debug)
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, "failed to connect to database", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if sqlDB, err := gormDB.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+
+	safety := mcp.NewSafetyManager(cfg.Safety)
+	staging := mcp.NewStagingManager(gormDB, cfg, safety)
+
+	reverted, failedID, err := revertStages(context.Background(), staging, req, mode)
+	conflict := errors.Is(err, core.ErrMergeConflict)
+	if err != nil && !conflict {
+		_ = toolenv.WriteError(os.Stdout, "revert operation failed", err)
+		os.Exit(1)
+	}
+
+	ids := make([]string, 0, len(reverted))
+	responseText := fmt.Sprintf("Reverted %d stage(s)", len(reverted))
+	for _, revert := range reverted {
+		ids = append(ids, revert.StageID)
+		how := "restored"
+		if revert.Merged {
+			how = "merged with later edits"
+		}
+		responseText += fmt.Sprintf("\n%s: %s (%s)", revert.StageID, revert.FilePath, how)
+	}
+	if conflict {
+		responseText += fmt.Sprintf("\nStage %s was not reverted: %v", failedID, err)
+	}
+
+	payload := map[string]any{
+		"content": []map[string]any{{
+			"type": "text",
+			"text": responseText,
+		}},
+		"reverted": ids,
+		"structuredContent": map[string]any{
+			"mode":     mode,
+			"reverted": reverted,
+		},
+	}
+	if conflict {
+		payload["failed"] = failedID
+		payload["error"] = err.Error()
+	}
+
+	if err := toolenv.WriteJSON(os.Stdout, payload); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write output: %v\n", err)
+		os.Exit(1)
+	}
+	if conflict {
+		os.Exit(1)
+	}
+}
+
+func determineMode(req *revertRequest) (string, error) {
+	if req == nil {
+		return "", errors.New("request cannot be nil")
+	}
+	if req.Last < 0 {
+		return "", errors.New("'last' must be positive")
+	}
+
+	switch {
+	case req.ID != "" && (req.SessionID != "" || req.Last > 0):
+		return "", errors.New("'id' cannot be combined with 'session_id' or 'last'")
+	case req.ID != "":
+		return "single", nil
+	case req.SessionID != "" && req.Last == 0:
+		return "session", nil
+	}
+
+	if req.Last == 0 {
+		req.Last = 1
+	}
+	return "last", nil
+}
+
+// revertStages reverts the selected stages newest first, stopping at the
+// first failure. It returns the stages reverted and, on failure, the stage
+// that failed.
+func revertStages(ctx context.Context, staging *mcp.StagingManager, req *revertRequest, mode string) ([]*types.StageRevert, string, error) {
+	var ids []string
+	switch mode {
+	case "single":
+		ids = []string{req.ID}
+	case "session", "last":
+		limit := 0
+		if mode == "last" {
+			limit = req.Last
+		}
+		stages, err := staging.ListRevertibleStages(req.SessionID, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(stages) == 0 {
+			return nil, "", errors.New("no applied stages to revert")
+		}
+		for _, stage := range stages {
+			ids = append(ids, stage.ID)
+		}
+	default:
+		return nil, "", fmt.Errorf("unsupported mode: %s", mode)
+	}
+
+	reverted := make([]*types.StageRevert, 0, len(ids))
+	for _, id := range ids {
+		revert, err := staging.RevertStage(ctx, id, "cli")
+		if err != nil {
+			return reverted, id, err
+		}
+		reverted = append(reverted, revert)
+	}
+	return reverted, "", nil
+}
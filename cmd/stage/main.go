@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gorm.io/gorm"
+
+	"github.com/oxhq/morfx/db"
+	"github.com/oxhq/morfx/internal/toolenv"
+	"github.com/oxhq/morfx/mcp"
+	"github.com/oxhq/morfx/models"
+)
+
+const stageHelp = `Usage: stage export|import [--db path] [-h]
+
+Reads a JSON request from stdin and emits a JSON response to stdout.
+
+stage export renders staged changes as a patch that ` + "`git apply`" + ` accepts,
+with a/ and b/ paths relative to "root" and full index lines.
+
+Input schema:
+{
+  "id": "<stage id>",          // optional; export one stage
+  "session_id": "<session id>", // optional; export the session's pending stages
+  "changeset": "<changeset id>", // optional; export every file of a changeset
+  "root": "<directory>",       // optional; paths are relative to it (default ".")
+  "output": "<file>"           // optional; also write the patch to this file
+}
+Exactly one of "id", "session_id", or "changeset" selects the stages. Session
+stages are exported oldest first.
+
+Output schema:
+{
+  "content": [{"type": "text", "text": "<summary>"}],
+  "patch": "<git patch>",
+  "structuredContent": {"mode": "single|session|changeset", "stages": ["<stage ids>", ...]}
+}
+
+stage import stages the changes a unified diff, plain or git's, makes to files
+under "root". Each file is patched from its current content; when any hunk
+does not match, nothing is staged. A patch touching one file becomes a stage,
+one touching several a changeset applied all-or-nothing. Imported stages are
+scored down for coming from outside Morfx and further when the patched file
+no longer parses. Renames, deletions, and binary patches are not supported.
+
+Input schema:
+{
+  "patch": "<unified diff>",   // exactly one of "patch" or "patch_file"
+  "patch_file": "<file>",
+  "root": "<directory>",       // optional; patch paths are relative to it (default ".")
+  "session_id": "<session id>" // optional; session to stage into
+}
+
+Output schema:
+{
+  "content": [{"type": "text", "text": "<summary>"}],
+  "stages": ["<stage ids>", ...],
+  "changeset": "<changeset id>", // present when several files were staged
+  "structuredContent": {"stages": [{"id": "...", "file_path": "...", "confidence": <float>, "level": "..."}, ...]}
+}
+
+Flags:
+  --db <path>   Path to the Morfx SQLite database (default ./.morfx/db/morfx.db)
+  -h, --help    Show this help message
+`
+
+type exportRequest struct {
+	ID          string `json:"id,omitempty"`
+	SessionID   string `json:"session_id,omitempty"`
+	ChangesetID string `json:"changeset,omitempty"`
+	Root        string `json:"root,omitempty"`
+	Output      string `json:"output,omitempty"`
+}
+
+type importRequest struct {
+	Patch     *string `json:"patch,omitempty"`
+	PatchFile *string `json:"patch_file,omitempty"`
+	Root      string  `json:"root,omitempty"`
+	SessionID string  `json:"session_id,omitempty"`
+}
+
+type importedStage struct {
+	ID         string  `json:"id"`
+	FilePath   string  `json:"file_path"`
+	Confidence float64 `json:"confidence"`
+	Level      string  `json:"level"`
+}
+
+func main() {
+	var (
+		dbPath   string
+		showHelp bool
+	)
+
+	flag.StringVar(&dbPath, "db", "./.morfx/db/morfx.db", "Path to the Morfx SQLite database")
+	flag.BoolVar(&showHelp, "h", false, "Show help message")
+	flag.BoolVar(&showHelp, "help", false, "Show help message")
+	flag.Usage = func() {
+		fmt.Print(stageHelp)
+	}
+
+	command := ""
+	args := os.Args[1:]
+	if len(args) > 0 && (args[0] == "export" || args[0] == "import") {
+		command, args = args[0], args[1:]
+	}
+	_ = flag.CommandLine.Parse(args)
+
+	if showHelp {
+		flag.Usage()
+		os.Exit(0)
+	}
+	if command == "" {
+		_ = toolenv.WriteError(os.Stdout, "invalid parameters", errors.New("expected 'export' or 'import'"))
+		os.Exit(1)
+	}
+
+	cfg := mcp.DefaultConfig()
+	cfg.DatabaseURL = dbPath
+	cfg.Debug = false
+	cfg.LogWriter = io.Discard
+
+	var run func(*gorm.DB, *mcp.StagingManager) (map[string]any, error)
+	switch command {
+	case "export":
+		req, err := toolenv.ReadJSON[exportRequest](os.Stdin)
+		if err != nil {
+			_ = toolenv.WriteError(os.Stdout, "invalid input", err)
+			os.Exit(1)
+		}
+		mode, err := determineExportMode(req)
+		if err != nil {
+			_ = toolenv.WriteError(os.Stdout, "invalid parameters", err)
+			os.Exit(1)
+		}
+		run = func(gormDB *gorm.DB, staging *mcp.StagingManager) (map[string]any, error) {
+			return exportStages(gormDB, staging, req, mode)
+		}
+	case "import":
+		req, err := toolenv.ReadJSON[importRequest](os.Stdin)
+		if err != nil {
+			_ = toolenv.WriteError(os.Stdout, "invalid input", err)
+			os.Exit(1)
+		}
+		patch, err := loadPatch(req)
+		if err != nil {
+			_ = toolenv.WriteError(os.Stdout, "invalid parameters", err)
+			os.Exit(1)
+		}
+		run = func(_ *gorm.DB, staging *mcp.StagingManager) (map[string]any, error) {
+			return importPatch(staging, req, patch)
+		}
+	}
+
+	gormDB, err := db.Connect(cfg.DatabaseURL, cfg.Debug)
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, "failed to connect to database", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if sqlDB, err := gormDB.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+
+	safety := mcp.NewSafetyManager(cfg.Safety)
+	staging := mcp.NewStagingManager(gormDB, cfg, safety)
+	// Providers let imports check that patched files still parse
+	if env, err := toolenv.NewEnvironment(); err == nil {
+		staging.SetProviders(env.Providers())
+	}
+
+	payload, err := run(gormDB, staging)
+	if err != nil {
+		_ = toolenv.WriteError(os.Stdout, command+" operation failed", err)
+		os.Exit(1)
+	}
+	if err := toolenv.WriteJSON(os.Stdout, payload); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func determineExportMode(req *exportRequest) (string, error) {
+	if req == nil {
+		return "", errors.New("request cannot be nil")
+	}
+
+	selectors := 0
+	mode := ""
+	if req.ID != "" {
+		selectors, mode = selectors+1, "single"
+	}
+	if req.SessionID != "" {
+		selectors, mode = selectors+1, "session"
+	}
+	if req.ChangesetID != "" {
+		selectors, mode = selectors+1, "changeset"
+	}
+	if selectors != 1 {
+		return "", errors.New("exactly one of 'id', 'session_id', or 'changeset' must be provided")
+	}
+	return mode, nil
+}
+
+func exportStages(gormDB *gorm.DB, staging *mcp.StagingManager, req *exportRequest, mode string) (map[string]any, error) {
+	var stages []models.Stage
+	switch mode {
+	case "single":
+		stage, err := staging.GetStage(req.ID)
+		if err != nil {
+			return nil, fmt.Errorf("stage %s: %w", req.ID, err)
+		}
+		stages = []models.Stage{*stage}
+	case "session":
+		err := gormDB.Where("session_id = ? AND status = ?", req.SessionID, "pending").
+			Order("created_at ASC").
+			Find(&stages).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(stages) == 0 {
+			return nil, fmt.Errorf("session %s has no pending stages", req.SessionID)
+		}
+	case "changeset":
+		changeset, err := staging.GetChangeset(req.ChangesetID)
+		if err != nil {
+			return nil, fmt.Errorf("changeset %s: %w", req.ChangesetID, err)
+		}
+		stages = changeset.Stages
+	}
+
+	root := req.Root
+	if root == "" {
+		root = "."
+	}
+	patch, err := mcp.ExportPatch(stages, root)
+	if err != nil {
+		return nil, err
+	}
+	if req.Output != "" {
+		if err := os.WriteFile(req.Output, []byte(patch), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", req.Output, err)
+		}
+	}
+
+	ids := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		ids = append(ids, stage.ID)
+	}
+	responseText := fmt.Sprintf("Exported %d stage(s)", len(stages))
+	if req.Output != "" {
+		responseText += " to " + req.Output
+	}
+	return map[string]any{
+		"content": []map[string]any{{
+			"type": "text",
+			"text": responseText,
+		}},
+		"patch": patch,
+		"structuredContent": map[string]any{
+			"mode":   mode,
+			"stages": ids,
+		},
+	}, nil
+}
+
+func loadPatch(req *importRequest) (string, error) {
+	if req == nil {
+		return "", errors.New("request cannot be nil")
+	}
+	if (req.Patch == nil) == (req.PatchFile == nil) {
+		return "", errors.New("exactly one of 'patch' or 'patch_file' must be provided")
+	}
+	if req.Patch != nil {
+		return *req.Patch, nil
+	}
+	content, err := os.ReadFile(*req.PatchFile)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", *req.PatchFile, err)
+	}
+	return string(content), nil
+}
+
+func importPatch(staging *mcp.StagingManager, req *importRequest, patch string) (map[string]any, error) {
+	root := req.Root
+	if root == "" {
+		root = "."
+	}
+	stages, changeset, err := staging.ImportPatch(context.Background(), req.SessionID, root, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(stages))
+	imported := make([]importedStage, 0, len(stages))
+	responseText := fmt.Sprintf("Staged %d file(s) from patch", len(stages))
+	if changeset != nil {
+		responseText += fmt.Sprintf(" as changeset %s", changeset.ID)
+	}
+	for _, stage := range stages {
+		ids = append(ids, stage.ID)
+		path := stageFilePath(stage)
+		imported = append(imported, importedStage{
+			ID:         stage.ID,
+			FilePath:   path,
+			Confidence: stage.ConfidenceScore,
+			Level:      stage.ConfidenceLevel,
+		})
+		responseText += fmt.Sprintf("\n%s: %s (confidence %.2f, %s)", stage.ID, path, stage.ConfidenceScore, stage.ConfidenceLevel)
+	}
+
+	payload := map[string]any{
+		"content": []map[string]any{{
+			"type": "text",
+			"text": responseText,
+		}},
+		"stages": ids,
+		"structuredContent": map[string]any{
+			"stages": imported,
+		},
+	}
+	if changeset != nil {
+		payload["changeset"] = changeset.ID
+	}
+	return payload, nil
+}
+
+// stageFilePath returns the file stage writes, recorded in its scope.
+func stageFilePath(stage models.Stage) string {
+	var scope struct {
+		FilePath string `json:"file_path"`
+	}
+	_ = json.Unmarshal(stage.ScopeAST, &scope)
+	return scope.FilePath
+}
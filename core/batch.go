@@ -0,0 +1,123 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BatchOp is the wire form of one step in a batch transform. Targets are
+// given either as an AgentQuery object or as a DSL selector.
+type BatchOp struct {
+	Method        string            `json:"method"`
+	Target        json.RawMessage   `json:"target,omitempty"`
+	TargetDSL     string            `json:"target_dsl,omitempty"`
+	Replacement   string            `json:"replacement,omitempty"`
+	Content       string            `json:"content,omitempty"`
+	Import        string            `json:"import,omitempty"`
+	FunctionName  string            `json:"function_name,omitempty"`
+	SortBy        string            `json:"sort_by,omitempty"`
+	SortOrder     []string          `json:"sort_order,omitempty"`
+	Annotation    string            `json:"annotation,omitempty"`
+	TagKey        string            `json:"tag_key,omitempty"`
+	TypeHints     map[string]string `json:"type_hints,omitempty"`
+	Format        bool              `json:"format,omitempty"`
+	Overlap       string            `json:"overlap,omitempty"`
+	IfAbsent      bool              `json:"if_absent,omitempty"`
+	Attached      string            `json:"attached,omitempty"`
+	ExpectMatches MatchRange        `json:"expect_matches,omitempty"`
+}
+
+// BatchTransformOp builds a batch TransformOp from its wire steps, checking
+// each step before anything runs.
+func BatchTransformOp(ops []BatchOp) (TransformOp, error) {
+	if len(ops) == 0 {
+		return TransformOp{}, fmt.Errorf("ops must contain at least one operation")
+	}
+
+	steps := make([]TransformOp, 0, len(ops))
+	for i, op := range ops {
+		step, err := op.TransformOp()
+		if err != nil {
+			return TransformOp{}, fmt.Errorf("ops[%d]: %w", i, err)
+		}
+		steps = append(steps, step)
+	}
+	return TransformOp{Method: "batch", Ops: steps}, nil
+}
+
+// TransformOp converts one wire step into the operation it describes.
+func (op BatchOp) TransformOp() (TransformOp, error) {
+	step := TransformOp{
+		Method:        op.Method,
+		Replacement:   op.Replacement,
+		Content:       op.Content,
+		FunctionName:  op.FunctionName,
+		SortBy:        op.SortBy,
+		SortOrder:     op.SortOrder,
+		Annotation:    op.Annotation,
+		TagKey:        op.TagKey,
+		TypeHints:     op.TypeHints,
+		Format:        op.Format,
+		Overlap:       op.Overlap,
+		IfAbsent:      op.IfAbsent,
+		Attached:      op.Attached,
+		ExpectMatches: op.ExpectMatches,
+	}
+	if err := op.ExpectMatches.Validate(); err != nil {
+		return TransformOp{}, err
+	}
+	if err := ValidateSortBy(op.SortBy); err != nil {
+		return TransformOp{}, err
+	}
+
+	switch op.Method {
+	case "replace":
+		if strings.TrimSpace(op.Replacement) == "" {
+			return TransformOp{}, fmt.Errorf("replacement is required")
+		}
+	case "insert_before", "insert_after", "append", "set_docstring":
+		if strings.TrimSpace(op.Content) == "" {
+			return TransformOp{}, fmt.Errorf("content is required")
+		}
+	case "annotate":
+		if len(op.TypeHints) == 0 {
+			return TransformOp{}, fmt.Errorf("type_hints is required")
+		}
+	case "ensure_import":
+		if strings.TrimSpace(op.Import) == "" {
+			return TransformOp{}, fmt.Errorf("import is required")
+		}
+		step.Content = op.Import
+		return step, nil
+	case "add_annotation", "add_tag", "remove_tag":
+		if strings.TrimSpace(op.Annotation) == "" {
+			return TransformOp{}, fmt.Errorf("annotation is required")
+		}
+	case "rewrite_tag":
+		if strings.TrimSpace(op.TagKey) == "" || strings.TrimSpace(op.Annotation) == "" {
+			return TransformOp{}, fmt.Errorf("tag_key and annotation are required")
+		}
+	case "delete", "extract_function", "remove_import", "sort", "remove_annotation":
+	case "":
+		return TransformOp{}, fmt.Errorf("method is required")
+	default:
+		return TransformOp{}, fmt.Errorf("unsupported method: %s", op.Method)
+	}
+
+	if op.Method == "append" {
+		target, _, err := ParseOptionalAgentQueryPayload(op.Target, op.TargetDSL)
+		if err != nil {
+			return TransformOp{}, fmt.Errorf("invalid target: %w", err)
+		}
+		step.Target = target
+		return step, nil
+	}
+
+	target, err := ParseAgentQueryPayload(op.Target, op.TargetDSL)
+	if err != nil {
+		return TransformOp{}, fmt.Errorf("invalid target: %w", err)
+	}
+	step.Target = target
+	return step, nil
+}
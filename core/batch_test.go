@@ -0,0 +1,78 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBatchTransformOpBuildsSteps(t *testing.T) {
+	op, err := BatchTransformOp([]BatchOp{
+		{Method: "replace", TargetDSL: "func:Old", Replacement: "func New() {}"},
+		{Method: "delete", Target: json.RawMessage(`{"type":"function","name":"Unused"}`)},
+		{Method: "append", Content: "func Tail() {}"},
+		{Method: "ensure_import", Import: "fmt"},
+		{Method: "sort", TargetDSL: "struct:S > field:*", SortBy: SortByVisibility, SortOrder: []string{"public", "private"}},
+	})
+	if err != nil {
+		t.Fatalf("BatchTransformOp returned error: %v", err)
+	}
+	if op.Method != "batch" || len(op.Ops) != 5 {
+		t.Fatalf("unexpected batch op: %+v", op)
+	}
+	if op.Ops[0].Target.Type != "func" || op.Ops[0].Target.Name != "Old" {
+		t.Fatalf("expected DSL target to be parsed, got %+v", op.Ops[0].Target)
+	}
+	if op.Ops[1].Target.Name != "Unused" {
+		t.Fatalf("expected object target to be parsed, got %+v", op.Ops[1].Target)
+	}
+	if op.Ops[2].Target.Type != "" {
+		t.Fatalf("expected untargeted append, got %+v", op.Ops[2].Target)
+	}
+	if op.Ops[3].Content != "fmt" {
+		t.Fatalf("expected import spec in content, got %+v", op.Ops[3])
+	}
+	if op.Ops[4].SortBy != SortByVisibility || len(op.Ops[4].SortOrder) != 2 {
+		t.Fatalf("expected sort key and order to carry over, got %+v", op.Ops[4])
+	}
+}
+
+func TestBatchTransformOpRejectsInvalidSteps(t *testing.T) {
+	cases := map[string][]BatchOp{
+		"at least one operation": nil,
+		"ops[0]: method is required": {
+			{TargetDSL: "func:Old"},
+		},
+		"ops[1]: unsupported method: batch": {
+			{Method: "delete", TargetDSL: "func:Old"},
+			{Method: "batch"},
+		},
+		"ops[0]: replacement is required": {
+			{Method: "replace", TargetDSL: "func:Old"},
+		},
+		"ops[0]: invalid target": {
+			{Method: "delete"},
+		},
+		"ops[0]: annotation is required": {
+			{Method: "add_annotation", TargetDSL: "field:*"},
+		},
+		"ops[0]: tag_key and annotation are required": {
+			{Method: "rewrite_tag", TargetDSL: "field:*", Annotation: `yaml:"${value}"`},
+		},
+		"ops[0]: type_hints is required": {
+			{Method: "annotate", TargetDSL: "def:load"},
+		},
+		"ops[0]: content is required": {
+			{Method: "set_docstring", TargetDSL: "def:load"},
+		},
+		"ops[0]: unknown sort key": {
+			{Method: "sort", TargetDSL: "struct:S > field:*", SortBy: "size"},
+		},
+	}
+	for want, ops := range cases {
+		_, err := BatchTransformOp(ops)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
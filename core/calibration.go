@@ -0,0 +1,302 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CalibrateEnv turns on calibrated scoring when set to a true value.
+const CalibrateEnv = "MORFX_CALIBRATE"
+
+// Calibration tuning. Bucket keep rates are smoothed toward the bucket's
+// midpoint with a prior worth calibrationPriorWeight samples; thresholds
+// are only suggested, and scores only adjusted, once at least
+// MinCalibrationSamples applies back them.
+const (
+	calibrationBucketWidth = 0.1
+	calibrationBuckets     = 10
+	calibrationPriorWeight = 4.0
+	MinCalibrationSamples  = 10
+	TargetKeepRate         = 0.95
+)
+
+// calibrationFactor names the factor Calibration.Score adds.
+const calibrationFactor = "calibration"
+
+// CalibrationSample is one applied stage and whether it was later reverted.
+type CalibrationSample struct {
+	Language    string
+	Operation   string
+	Score       float64
+	Factors     []string
+	AutoApplied bool
+	Reverted    bool
+}
+
+// CalibrationBucket holds the applies whose score fell in [Min, Max).
+type CalibrationBucket struct {
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Samples  int     `json:"samples"`
+	Reverted int     `json:"reverted"`
+	// Calibrated is the smoothed probability that an apply in this bucket
+	// is kept.
+	Calibrated float64 `json:"calibrated"`
+}
+
+// CalibrationGroup summarizes the applies for one language and operation,
+// or for all of them when both are empty.
+type CalibrationGroup struct {
+	Language     string              `json:"language,omitempty"`
+	Operation    string              `json:"operation,omitempty"`
+	Samples      int                 `json:"samples"`
+	Reverted     int                 `json:"reverted"`
+	AutoApplied  int                 `json:"auto_applied"`
+	AutoReverted int                 `json:"auto_reverted"`
+	KeepRate     float64             `json:"keep_rate"`
+	Buckets      []CalibrationBucket `json:"buckets"`
+	// SuggestedThreshold is the lowest bucket floor at which applies scoring
+	// at or above it were kept at TargetKeepRate, or nil without enough
+	// data.
+	SuggestedThreshold *float64 `json:"suggested_threshold"`
+}
+
+// FactorCalibration is the revert rate of applies carrying a factor, or a
+// combination of factors joined with "+".
+type FactorCalibration struct {
+	Name       string  `json:"name"`
+	Samples    int     `json:"samples"`
+	Reverted   int     `json:"reverted"`
+	RevertRate float64 `json:"revert_rate"`
+}
+
+// Calibration relates confidence scores to how often applies were later
+// reverted. It is a ConfidenceScorer: Score moves a score toward the keep
+// rate observed for its language, operation, and bucket.
+type Calibration struct {
+	Overall      CalibrationGroup    `json:"overall"`
+	Groups       []CalibrationGroup  `json:"groups"`
+	Factors      []FactorCalibration `json:"factors"`
+	Combinations []FactorCalibration `json:"factor_combinations"`
+}
+
+// BuildCalibration computes a Calibration from apply history.
+func BuildCalibration(samples []CalibrationSample) *Calibration {
+	calibration := &Calibration{
+		Overall:      calibrationGroup("", "", samples),
+		Groups:       []CalibrationGroup{},
+		Factors:      []FactorCalibration{},
+		Combinations: []FactorCalibration{},
+	}
+
+	type groupKey struct{ language, operation string }
+	grouped := make(map[groupKey][]CalibrationSample)
+	factors := make(map[string]*FactorCalibration)
+	combinations := make(map[string]*FactorCalibration)
+	count := func(stats map[string]*FactorCalibration, name string, reverted bool) {
+		stat, ok := stats[name]
+		if !ok {
+			stat = &FactorCalibration{Name: name}
+			stats[name] = stat
+		}
+		stat.Samples++
+		if reverted {
+			stat.Reverted++
+		}
+	}
+
+	for _, sample := range samples {
+		key := groupKey{sample.Language, sample.Operation}
+		grouped[key] = append(grouped[key], sample)
+
+		names := uniqueSorted(sample.Factors)
+		for _, name := range names {
+			count(factors, name, sample.Reverted)
+		}
+		combination := strings.Join(names, "+")
+		if combination == "" {
+			combination = "none"
+		}
+		count(combinations, combination, sample.Reverted)
+	}
+
+	for key, group := range grouped {
+		calibration.Groups = append(calibration.Groups, calibrationGroup(key.language, key.operation, group))
+	}
+	sort.Slice(calibration.Groups, func(i, j int) bool {
+		a, b := calibration.Groups[i], calibration.Groups[j]
+		if a.Language != b.Language {
+			return a.Language < b.Language
+		}
+		return a.Operation < b.Operation
+	})
+	calibration.Factors = factorRates(factors)
+	calibration.Combinations = factorRates(combinations)
+	return calibration
+}
+
+func calibrationGroup(language, operation string, samples []CalibrationSample) CalibrationGroup {
+	group := CalibrationGroup{Language: language, Operation: operation, Samples: len(samples)}
+	buckets := make([]CalibrationBucket, calibrationBuckets)
+	for i := range buckets {
+		buckets[i].Min = roundScore(float64(i) * calibrationBucketWidth)
+		buckets[i].Max = roundScore(float64(i+1) * calibrationBucketWidth)
+	}
+
+	for _, sample := range samples {
+		bucket := &buckets[calibrationBucket(sample.Score, len(buckets))]
+		bucket.Samples++
+		if sample.AutoApplied {
+			group.AutoApplied++
+		}
+		if sample.Reverted {
+			bucket.Reverted++
+			group.Reverted++
+			if sample.AutoApplied {
+				group.AutoReverted++
+			}
+		}
+	}
+	if group.Samples > 0 {
+		group.KeepRate = roundScore(float64(group.Samples-group.Reverted) / float64(group.Samples))
+	}
+
+	for i := range buckets {
+		bucket := &buckets[i]
+		nominal := (bucket.Min + bucket.Max) / 2
+		kept := float64(bucket.Samples - bucket.Reverted)
+		bucket.Calibrated = roundScore((kept + calibrationPriorWeight*nominal) / (float64(bucket.Samples) + calibrationPriorWeight))
+	}
+
+	// Pool buckets from the top down so each floor sees every apply that
+	// would pass a threshold set there.
+	pooled, pooledReverted := 0, 0
+	for i := len(buckets) - 1; i >= 0; i-- {
+		pooled += buckets[i].Samples
+		pooledReverted += buckets[i].Reverted
+		if pooled < MinCalibrationSamples {
+			continue
+		}
+		if float64(pooled-pooledReverted)/float64(pooled) >= TargetKeepRate {
+			threshold := buckets[i].Min
+			group.SuggestedThreshold = &threshold
+		}
+	}
+
+	for _, bucket := range buckets {
+		if bucket.Samples > 0 {
+			group.Buckets = append(group.Buckets, bucket)
+		}
+	}
+	if group.Buckets == nil {
+		group.Buckets = []CalibrationBucket{}
+	}
+	return group
+}
+
+func calibrationBucket(score float64, buckets int) int {
+	// The epsilon keeps scores like 0.3 out of the bucket below them
+	index := int(math.Floor(score/calibrationBucketWidth + 1e-9))
+	return max(0, min(buckets-1, index))
+}
+
+func factorRates(stats map[string]*FactorCalibration) []FactorCalibration {
+	rates := make([]FactorCalibration, 0, len(stats))
+	for _, stat := range stats {
+		stat.RevertRate = roundScore(float64(stat.Reverted) / float64(stat.Samples))
+		rates = append(rates, *stat)
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].RevertRate != rates[j].RevertRate {
+			return rates[i].RevertRate > rates[j].RevertRate
+		}
+		return rates[i].Name < rates[j].Name
+	})
+	return rates
+}
+
+func uniqueSorted(values []string) []string {
+	unique := slices.DeleteFunc(slices.Clone(values), func(value string) bool { return value == "" })
+	slices.Sort(unique)
+	return slices.Compact(unique)
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
+
+// Score moves confidence to the calibrated keep probability of its bucket,
+// taken from the language and operation's history when that has at least
+// MinCalibrationSamples applies in the bucket, otherwise from all history.
+// Scores in buckets without enough history are left alone.
+func (c *Calibration) Score(ctx ScoreContext, confidence ConfidenceScore) ConfidenceScore {
+	if c == nil {
+		return confidence
+	}
+	bucket, source, ok := c.lookup(ctx.Language, ctx.Method, confidence.Score)
+	if !ok {
+		return confidence
+	}
+	if math.Abs(bucket.Calibrated-confidence.Score) < 0.005 {
+		return confidence
+	}
+	impact := bucket.Calibrated - unclampedScore(confidence)
+	score := math.Max(0, math.Min(1, bucket.Calibrated))
+	factor := ConfidenceFactor{
+		Name:   calibrationFactor,
+		Impact: impact,
+		Reason: fmt.Sprintf("%d of %d %s applies scoring %.1f-%.1f were kept", bucket.Samples-bucket.Reverted, bucket.Samples, source, bucket.Min, bucket.Max),
+	}
+	return ConfidenceScore{
+		Score:   score,
+		Level:   confidenceLevel(score),
+		Factors: append(append([]ConfidenceFactor(nil), confidence.Factors...), factor),
+	}
+}
+
+// Uncalibrated undoes Calibration.Score, returning the score before
+// calibration moved it and the factors without the calibration factor.
+// History is bucketed on these, so calibration never feeds on itself.
+func Uncalibrated(confidence ConfidenceScore) ConfidenceScore {
+	factors := make([]ConfidenceFactor, 0, len(confidence.Factors))
+	for _, factor := range confidence.Factors {
+		if factor.Name == calibrationFactor {
+			confidence.Score -= factor.Impact
+			continue
+		}
+		factors = append(factors, factor)
+	}
+	if len(factors) == len(confidence.Factors) {
+		return confidence
+	}
+	score := math.Max(0, math.Min(1, confidence.Score))
+	return ConfidenceScore{Score: score, Level: confidenceLevel(score), Factors: factors}
+}
+
+func (c *Calibration) lookup(language, operation string, score float64) (CalibrationBucket, string, bool) {
+	for _, group := range c.Groups {
+		if group.Language != language || group.Operation != operation {
+			continue
+		}
+		if bucket, ok := group.bucket(score); ok {
+			return bucket, language + " " + operation, true
+		}
+	}
+	if bucket, ok := c.Overall.bucket(score); ok {
+		return bucket, "past", true
+	}
+	return CalibrationBucket{}, "", false
+}
+
+func (g CalibrationGroup) bucket(score float64) (CalibrationBucket, bool) {
+	index := calibrationBucket(score, calibrationBuckets)
+	for _, bucket := range g.Buckets {
+		if calibrationBucket(bucket.Min, calibrationBuckets) == index {
+			return bucket, bucket.Samples >= MinCalibrationSamples
+		}
+	}
+	return CalibrationBucket{}, false
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func calibrationSamples(language, operation string, score float64, kept, reverted int, factors ...string) []CalibrationSample {
+	var samples []CalibrationSample
+	for i := 0; i < kept+reverted; i++ {
+		samples = append(samples, CalibrationSample{
+			Language:  language,
+			Operation: operation,
+			Score:     score,
+			Factors:   factors,
+			Reverted:  i >= kept,
+		})
+	}
+	return samples
+}
+
+func TestBuildCalibrationSuggestsThresholds(t *testing.T) {
+	var samples []CalibrationSample
+	samples = append(samples, calibrationSamples("go", "replace", 0.95, 12, 0)...)
+	samples = append(samples, calibrationSamples("go", "replace", 0.8, 8, 0)...)
+	samples = append(samples, calibrationSamples("go", "replace", 0.7, 5, 5, "dangling_references")...)
+	samples = append(samples, calibrationSamples("python", "delete", 0.9, 3, 0)...)
+
+	calibration := BuildCalibration(samples)
+
+	if calibration.Overall.Samples != 33 || calibration.Overall.Reverted != 5 {
+		t.Fatalf("unexpected overall totals: %+v", calibration.Overall)
+	}
+	if len(calibration.Groups) != 2 {
+		t.Fatalf("expected two groups, got %+v", calibration.Groups)
+	}
+
+	goReplace := calibration.Groups[0]
+	if goReplace.Language != "go" || goReplace.Operation != "replace" {
+		t.Fatalf("expected groups sorted by language, got %+v", calibration.Groups)
+	}
+	if goReplace.SuggestedThreshold == nil || *goReplace.SuggestedThreshold != 0.8 {
+		t.Fatalf("expected a suggested threshold of 0.8, got %v", goReplace.SuggestedThreshold)
+	}
+	if python := calibration.Groups[1]; python.SuggestedThreshold != nil {
+		t.Fatalf("expected no threshold without enough samples, got %v", *python.SuggestedThreshold)
+	}
+
+	if len(goReplace.Buckets) != 3 || goReplace.Buckets[0].Min != 0.7 {
+		t.Fatalf("expected only populated buckets, got %+v", goReplace.Buckets)
+	}
+	// 5 kept plus a 0.75 prior worth 4 samples, over 14
+	if got := goReplace.Buckets[0].Calibrated; got != 0.571 {
+		t.Fatalf("expected calibrated keep rate 0.571, got %v", got)
+	}
+
+	if factor := calibration.Factors[0]; factor.Name != "dangling_references" || factor.RevertRate != 0.5 {
+		t.Fatalf("expected dangling_references to lead the revert rates, got %+v", calibration.Factors)
+	}
+	if len(calibration.Combinations) != 2 || calibration.Combinations[1].Name != "none" {
+		t.Fatalf("expected a combination for stages without factors, got %+v", calibration.Combinations)
+	}
+}
+
+func TestCalibrationBucketBoundaries(t *testing.T) {
+	for score, want := range map[float64]int{0: 0, 0.3: 3, 0.85: 8, 1: 9} {
+		if got := calibrationBucket(score, calibrationBuckets); got != want {
+			t.Fatalf("calibrationBucket(%v) = %d, want %d", score, got, want)
+		}
+	}
+}
+
+func TestCalibrationScore(t *testing.T) {
+	var samples []CalibrationSample
+	samples = append(samples, calibrationSamples("go", "replace", 0.9, 6, 6)...)
+	samples = append(samples, calibrationSamples("python", "replace", 0.7, 2, 0)...)
+	calibration := BuildCalibration(samples)
+
+	scored := calibration.Score(ScoreContext{Language: "go", Method: "replace"}, ConfidenceScore{Score: 0.92, Level: "high"})
+	if scored.Score >= 0.85 || scored.Level != "medium" {
+		t.Fatalf("expected calibration to pull the score below auto-apply, got %+v", scored)
+	}
+	if len(scored.Factors) != 1 || scored.Factors[0].Name != "calibration" {
+		t.Fatalf("expected a calibration factor, got %+v", scored.Factors)
+	}
+
+	// Python has its own group, but too few samples; all history backs 0.9
+	if fallback := calibration.Score(ScoreContext{Language: "python", Method: "replace"}, ConfidenceScore{Score: 0.95}); len(fallback.Factors) != 1 {
+		t.Fatalf("expected overall history to calibrate python, got %+v", fallback)
+	}
+
+	unscored := ConfidenceScore{Score: 0.7, Level: "medium"}
+	if got := calibration.Score(ScoreContext{Language: "go", Method: "replace"}, unscored); got.Score != 0.7 || len(got.Factors) != 0 {
+		t.Fatalf("expected scores without enough history to be left alone, got %+v", got)
+	}
+}
+
+func TestUncalibratedUndoesCalibration(t *testing.T) {
+	calibration := BuildCalibration(calibrationSamples("go", "replace", 0.9, 6, 6))
+	original := ConfidenceScore{Score: 0.92, Level: "high", Factors: []ConfidenceFactor{{Name: "exported_api", Impact: -0.08}}}
+	scored := calibration.Score(ScoreContext{Language: "go", Method: "replace"}, original)
+	if scored.Score == original.Score {
+		t.Fatalf("expected calibration to move the score, got %+v", scored)
+	}
+
+	got := Uncalibrated(scored)
+	if math.Abs(got.Score-original.Score) > 1e-9 || got.Level != "high" {
+		t.Fatalf("expected the pre-calibration score %.2f, got %+v", original.Score, got)
+	}
+	if len(got.Factors) != 1 || got.Factors[0].Name != "exported_api" {
+		t.Fatalf("expected the calibration factor to be dropped, got %+v", got.Factors)
+	}
+	if same := Uncalibrated(original); same.Score != original.Score || len(same.Factors) != 1 {
+		t.Fatalf("expected an uncalibrated score to be kept, got %+v", same)
+	}
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"slices"
+	"strings"
+)
+
+// FileChange is the combined edit a multi-file operation makes to one file,
+// ready to be staged as part of a changeset.
+type FileChange struct {
+	Path       string          `json:"path"`
+	Language   string          `json:"language"`
+	Original   string          `json:"-"`
+	Modified   string          `json:"-"`
+	Diff       string          `json:"diff,omitempty"`
+	Confidence ConfidenceScore `json:"confidence"`
+}
+
+// FileChanges lists the files result modified, in path order.
+func FileChanges(result *FileTransformResult) []FileChange {
+	if result == nil {
+		return nil
+	}
+	return MergeFileChanges(nil, result)
+}
+
+// MergeFileChanges folds the files result modified into changes. A file
+// already in changes keeps its first original and takes the new content,
+// with the lower of the two confidences, so the change spans every step.
+func MergeFileChanges(changes []FileChange, result *FileTransformResult) []FileChange {
+	for _, file := range result.Files {
+		if !file.Modified || file.Error != "" {
+			continue
+		}
+		index := slices.IndexFunc(changes, func(change FileChange) bool { return change.Path == file.FilePath })
+		if index < 0 {
+			changes = append(changes, FileChange{
+				Path:       file.FilePath,
+				Language:   file.Language,
+				Original:   file.OriginalContent,
+				Modified:   file.ModifiedContent,
+				Diff:       file.Diff,
+				Confidence: file.Confidence,
+			})
+			continue
+		}
+		change := &changes[index]
+		change.Modified = file.ModifiedContent
+		change.Diff = UnifiedDiff(change.Original, change.Modified)
+		if file.Confidence.Score < change.Confidence.Score {
+			change.Confidence = file.Confidence
+		}
+	}
+	slices.SortFunc(changes, func(a, b FileChange) int { return strings.Compare(a.Path, b.Path) })
+	return changes
+}
@@ -0,0 +1,30 @@
+package core
+
+import "testing"
+
+func TestMergeFileChanges(t *testing.T) {
+	first := &FileTransformResult{Files: []FileTransformDetail{
+		{FilePath: "b.go", Language: "go", Modified: true, OriginalContent: "b\n", ModifiedContent: "b1\n", Confidence: ConfidenceScore{Score: 0.9, Level: "high"}},
+		{FilePath: "a.go", Language: "go", Modified: true, OriginalContent: "a\n", ModifiedContent: "a1\n", Confidence: ConfidenceScore{Score: 0.95, Level: "high"}},
+		{FilePath: "c.go", Language: "go", MatchCount: 1},
+		{FilePath: "d.go", Language: "go", Modified: true, Error: "write failed"},
+	}}
+	second := &FileTransformResult{Files: []FileTransformDetail{
+		{FilePath: "a.go", Language: "go", Modified: true, OriginalContent: "a1\n", ModifiedContent: "a2\n", Confidence: ConfidenceScore{Score: 0.8, Level: "medium"}},
+	}}
+
+	changes := MergeFileChanges(FileChanges(first), second)
+	if len(changes) != 2 || changes[0].Path != "a.go" || changes[1].Path != "b.go" {
+		t.Fatalf("expected changes to a.go and b.go in path order, got %+v", changes)
+	}
+	a := changes[0]
+	if a.Original != "a\n" || a.Modified != "a2\n" {
+		t.Fatalf("expected a.go to span both steps, got %q -> %q", a.Original, a.Modified)
+	}
+	if a.Confidence.Score != 0.8 || a.Confidence.Level != "medium" {
+		t.Fatalf("expected the lower confidence, got %+v", a.Confidence)
+	}
+	if a.Diff != UnifiedDiff("a\n", "a2\n") {
+		t.Fatalf("expected the diff recomputed across steps, got %q", a.Diff)
+	}
+}
@@ -0,0 +1,250 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ConfidencePolicyEnv names a confidence policy file to load instead of
+// DefaultConfidencePolicyPath.
+const ConfidencePolicyEnv = "MORFX_CONFIDENCE_POLICY"
+
+// DefaultConfidencePolicyPath is the policy file loaded when present.
+const DefaultConfidencePolicyPath = ".morfx/confidence.json"
+
+// ConfidenceScorer rescores a transform's confidence once the file it
+// applies to is known. Providers score what they can see in the source; a
+// scorer adds what depends on where the file lives and what a team trusts.
+type ConfidenceScorer interface {
+	Score(ctx ScoreContext, confidence ConfidenceScore) ConfidenceScore
+}
+
+// ScoreContext describes the transform being scored.
+type ScoreContext struct {
+	Language string
+	Method   string
+	Path     string // empty for in-memory sources
+}
+
+// ConfidencePolicy is a declarative ConfidenceScorer read from a JSON file.
+// It shifts the score by the difference it makes to each factor, so an empty
+// policy leaves every score as the provider computed it.
+type ConfidencePolicy struct {
+	Baseline  *float64              `json:"baseline,omitempty"`  // starting score, 1.0 by default
+	Languages map[string]float64    `json:"languages,omitempty"` // per-language starting scores
+	Factors   map[string]FactorRule `json:"factors,omitempty"`   // keyed by factor name, such as wildcard_pattern
+	Paths     []PathRule            `json:"paths,omitempty"`     // applied in order; every matching rule counts
+}
+
+// FactorRule re-weights or disables one named confidence factor.
+type FactorRule struct {
+	Disabled bool     `json:"disabled,omitempty"` // drop the factor and its impact
+	Weight   *float64 `json:"weight,omitempty"`   // multiply the reported impact
+	Impact   *float64 `json:"impact,omitempty"`   // replace the reported impact
+}
+
+// PathRule adds a factor to transforms of files matching Pattern.
+type PathRule struct {
+	Pattern string  `json:"pattern"`          // doublestar glob, such as api/** or **/*_test.go
+	Impact  float64 `json:"impact"`           // -1.0 to 1.0
+	Name    string  `json:"name,omitempty"`   // factor name, path_policy by default
+	Reason  string  `json:"reason,omitempty"` // factor reason
+}
+
+// LoadConfidencePolicy reads and validates a policy file.
+func LoadConfidencePolicy(path string) (*ConfidencePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read confidence policy: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var policy ConfidencePolicy
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("parse confidence policy %s: %w", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("confidence policy %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// DiscoverConfidencePolicy loads the policy named by MORFX_CONFIDENCE_POLICY,
+// or DefaultConfidencePolicyPath under root when that file exists. It
+// returns nil without error when neither is set up.
+func DiscoverConfidencePolicy(root string) (*ConfidencePolicy, error) {
+	if path := strings.TrimSpace(os.Getenv(ConfidencePolicyEnv)); path != "" {
+		return LoadConfidencePolicy(path)
+	}
+	path := filepath.Join(root, DefaultConfidencePolicyPath)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("stat confidence policy: %w", err)
+	}
+	return LoadConfidencePolicy(path)
+}
+
+// Validate checks scores and impacts are in range and patterns are globs.
+func (p *ConfidencePolicy) Validate() error {
+	if p.Baseline != nil && !inRange(*p.Baseline, 0, 1) {
+		return fmt.Errorf("baseline %.2f is outside 0..1", *p.Baseline)
+	}
+	for language, baseline := range p.Languages {
+		if !inRange(baseline, 0, 1) {
+			return fmt.Errorf("baseline %.2f for %s is outside 0..1", baseline, language)
+		}
+	}
+	for name, rule := range p.Factors {
+		if rule.Weight != nil && *rule.Weight < 0 {
+			return fmt.Errorf("factor %s: weight must not be negative", name)
+		}
+		if rule.Impact != nil && !inRange(*rule.Impact, -1, 1) {
+			return fmt.Errorf("factor %s: impact %.2f is outside -1..1", name, *rule.Impact)
+		}
+	}
+	for i, rule := range p.Paths {
+		if strings.TrimSpace(rule.Pattern) == "" {
+			return fmt.Errorf("path rule %d: pattern is required", i+1)
+		}
+		if !doublestar.ValidatePattern(rule.Pattern) {
+			return fmt.Errorf("path rule %d: invalid pattern %q", i+1, rule.Pattern)
+		}
+		if !inRange(rule.Impact, -1, 1) {
+			return fmt.Errorf("path rule %d: impact %.2f is outside -1..1", i+1, rule.Impact)
+		}
+	}
+	return nil
+}
+
+// Score applies the policy's baseline, factor rules, and path rules to
+// confidence. They adjust the score before it was clamped, so a factor the
+// clamp absorbed is not taken off twice, and the result is clamped once.
+func (p *ConfidencePolicy) Score(ctx ScoreContext, confidence ConfidenceScore) ConfidenceScore {
+	if p == nil {
+		return confidence
+	}
+	score := unclampedScore(confidence)
+	factors := make([]ConfidenceFactor, 0, len(confidence.Factors)+1)
+
+	if baseline, ok := p.baseline(ctx.Language); ok && baseline != 1 {
+		impact := baseline - 1
+		score += impact
+		factors = append(factors, ConfidenceFactor{
+			Name:   "policy_baseline",
+			Impact: impact,
+			Reason: fmt.Sprintf("Confidence policy starts %s at %.2f", languageLabel(ctx.Language), baseline),
+		})
+	}
+
+	for _, factor := range confidence.Factors {
+		rule, ok := p.Factors[factor.Name]
+		switch {
+		case !ok:
+		case rule.Disabled:
+			score -= factor.Impact
+			continue
+		case rule.Impact != nil:
+			score += *rule.Impact - factor.Impact
+			factor.Impact = *rule.Impact
+		case rule.Weight != nil:
+			weighted := factor.Impact * *rule.Weight
+			score += weighted - factor.Impact
+			factor.Impact = weighted
+		}
+		factors = append(factors, factor)
+	}
+
+	if ctx.Path != "" {
+		for _, rule := range p.Paths {
+			if !matchesPathSuffix(rule.Pattern, ctx.Path) {
+				continue
+			}
+			name, reason := rule.Name, rule.Reason
+			if name == "" {
+				name = "path_policy"
+			}
+			if reason == "" {
+				reason = fmt.Sprintf("Path matches %s", rule.Pattern)
+			}
+			score += rule.Impact
+			factors = append(factors, ConfidenceFactor{Name: name, Impact: rule.Impact, Reason: reason})
+		}
+	}
+
+	score = math.Max(0, math.Min(1, score))
+	return ConfidenceScore{Score: score, Level: confidenceLevel(score), Factors: factors}
+}
+
+// unclampedScore recovers the score before it was clamped to 0..1.
+// Providers and scorers clamp once, after every factor is counted, so a
+// score is its factors' sum from 1.0 cut to 0..1 and the part the clamp
+// cut off is added back.
+func unclampedScore(confidence ConfidenceScore) float64 {
+	sum := 1.0
+	for _, factor := range confidence.Factors {
+		sum += factor.Impact
+	}
+	clamped := math.Max(0, math.Min(1, sum))
+	if clamped == sum {
+		return confidence.Score
+	}
+	return confidence.Score + sum - clamped
+}
+
+func (p *ConfidencePolicy) baseline(language string) (float64, bool) {
+	if baseline, ok := p.Languages[language]; ok {
+		return baseline, true
+	}
+	if p.Baseline != nil {
+		return *p.Baseline, true
+	}
+	return 0, false
+}
+
+// confidenceLevel buckets a score into high, medium, or low.
+func confidenceLevel(score float64) string {
+	switch {
+	case score < 0.5:
+		return "low"
+	case score < 0.8:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// matchesPathSuffix reports whether pattern matches path or any trailing
+// run of its segments, so internal/** matches /repo/pkg/internal/x.go.
+func matchesPathSuffix(pattern, path string) bool {
+	path = filepath.ToSlash(path)
+	for {
+		if ok, _ := doublestar.Match(pattern, path); ok {
+			return true
+		}
+		i := strings.Index(path, "/")
+		if i < 0 {
+			return false
+		}
+		path = path[i+1:]
+	}
+}
+
+func languageLabel(language string) string {
+	if language == "" {
+		return "every language"
+	}
+	return language
+}
+
+func inRange(value, low, high float64) bool {
+	return value >= low && value <= high
+}
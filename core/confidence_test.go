@@ -0,0 +1,244 @@
+package core
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func policyScore(t *testing.T, policy string, ctx ScoreContext, confidence ConfidenceScore) ConfidenceScore {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "confidence.json")
+	if err := os.WriteFile(path, []byte(policy), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	loaded, err := LoadConfidencePolicy(path)
+	if err != nil {
+		t.Fatalf("LoadConfidencePolicy: %v", err)
+	}
+	return loaded.Score(ctx, confidence)
+}
+
+func factorNames(confidence ConfidenceScore) []string {
+	names := make([]string, 0, len(confidence.Factors))
+	for _, factor := range confidence.Factors {
+		names = append(names, factor.Name)
+	}
+	return names
+}
+
+func TestConfidencePolicyScore(t *testing.T) {
+	provider := ConfidenceScore{
+		Score: 0.75,
+		Level: "medium",
+		Factors: []ConfidenceFactor{
+			{Name: "single_target", Impact: 0.1, Reason: "Only one target found, unambiguous"},
+			{Name: "wildcard_pattern", Impact: -0.15, Reason: "Wildcard patterns may match unintended targets"},
+			{Name: "exported_api", Impact: -0.2, Reason: "Modifying exported API"},
+		},
+	}
+	ctx := ScoreContext{Language: "go", Method: "replace", Path: "/repo/pkg/internal/cache/cache.go"}
+
+	tests := []struct {
+		name    string
+		policy  string
+		want    float64
+		level   string
+		factors string
+	}{
+		{
+			name:    "empty policy keeps the provider score",
+			policy:  `{}`,
+			want:    0.75,
+			level:   "medium",
+			factors: "single_target wildcard_pattern exported_api",
+		},
+		{
+			name:    "disabled factor gives back its impact",
+			policy:  `{"factors": {"wildcard_pattern": {"disabled": true}}}`,
+			want:    0.9,
+			level:   "high",
+			factors: "single_target exported_api",
+		},
+		{
+			name:    "weight scales the impact",
+			policy:  `{"factors": {"exported_api": {"weight": 0.5}}}`,
+			want:    0.85,
+			level:   "high",
+			factors: "single_target wildcard_pattern exported_api",
+		},
+		{
+			name:    "impact replaces the reported impact",
+			policy:  `{"factors": {"single_target": {"impact": 0}}}`,
+			want:    0.65,
+			level:   "medium",
+			factors: "single_target wildcard_pattern exported_api",
+		},
+		{
+			name:    "language baseline shifts the start",
+			policy:  `{"baseline": 0.9, "languages": {"go": 0.95, "php": 0.8}}`,
+			want:    0.7,
+			level:   "medium",
+			factors: "policy_baseline single_target wildcard_pattern exported_api",
+		},
+		{
+			name:    "path rules match any trailing part of the path",
+			policy:  `{"paths": [{"pattern": "internal/**", "impact": 0.1, "name": "internal_package"}, {"pattern": "api/**", "impact": -0.2}]}`,
+			want:    0.85,
+			level:   "high",
+			factors: "single_target wildcard_pattern exported_api internal_package",
+		},
+		{
+			name:    "score is clamped",
+			policy:  `{"paths": [{"pattern": "**/*.go", "impact": -1}]}`,
+			want:    0,
+			level:   "low",
+			factors: "single_target wildcard_pattern exported_api path_policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policyScore(t, tt.policy, ctx, provider)
+			if math.Abs(got.Score-tt.want) > 1e-9 || got.Level != tt.level {
+				t.Fatalf("Score = %.3f (%s), want %.3f (%s)", got.Score, got.Level, tt.want, tt.level)
+			}
+			if names := strings.Join(factorNames(got), " "); names != tt.factors {
+				t.Fatalf("factors = %q, want %q", names, tt.factors)
+			}
+		})
+	}
+}
+
+func TestConfidencePolicyScoresBeforeClamping(t *testing.T) {
+	// 1.0 + 0.1 was clamped to 1.0 by the provider
+	provider := ConfidenceScore{
+		Score:   1,
+		Level:   "high",
+		Factors: []ConfidenceFactor{{Name: "single_target", Impact: 0.1}},
+	}
+	ctx := ScoreContext{Language: "go", Method: "replace", Path: "/repo/api/handler.go"}
+
+	for policy, want := range map[string]float64{
+		`{"factors": {"single_target": {"disabled": true}}}`: 1,
+		`{"paths": [{"pattern": "api/**", "impact": -0.2}]}`: 0.9,
+		`{"baseline": 0.8}`: 0.9,
+	} {
+		if got := policyScore(t, policy, ctx, provider); math.Abs(got.Score-want) > 1e-9 {
+			t.Errorf("policy %s scored %.3f, want %.3f", policy, got.Score, want)
+		}
+	}
+}
+
+func TestConfidencePolicyPathRulesNeedAPath(t *testing.T) {
+	got := policyScore(t, `{"paths": [{"pattern": "**", "impact": -0.5}]}`,
+		ScoreContext{Language: "go", Method: "replace"},
+		ConfidenceScore{Score: 0.9, Level: "high"})
+	if got.Score != 0.9 || len(got.Factors) != 0 {
+		t.Fatalf("expected in-memory sources to skip path rules, got %+v", got)
+	}
+}
+
+func TestLoadConfidencePolicyRejectsInvalidPolicies(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":    `{"factor": {}}`,
+		"baseline range":   `{"baseline": 1.5}`,
+		"language range":   `{"languages": {"go": -0.1}}`,
+		"negative weight":  `{"factors": {"single_target": {"weight": -1}}}`,
+		"impact range":     `{"factors": {"single_target": {"impact": 2}}}`,
+		"missing pattern":  `{"paths": [{"impact": 0.1}]}`,
+		"invalid pattern":  `{"paths": [{"pattern": "api/[", "impact": 0.1}]}`,
+		"path impact":      `{"paths": [{"pattern": "api/**", "impact": -3}]}`,
+		"malformed policy": `{"paths": `,
+	}
+	for name, policy := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "confidence.json")
+			if err := os.WriteFile(path, []byte(policy), 0o644); err != nil {
+				t.Fatalf("write policy: %v", err)
+			}
+			if _, err := LoadConfidencePolicy(path); err == nil {
+				t.Fatalf("expected %s to be rejected", policy)
+			}
+		})
+	}
+}
+
+func TestDiscoverConfidencePolicy(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ConfidencePolicyEnv, "")
+
+	policy, err := DiscoverConfidencePolicy(root)
+	if err != nil || policy != nil {
+		t.Fatalf("expected no policy without a file, got %+v, %v", policy, err)
+	}
+
+	path := filepath.Join(root, DefaultConfidencePolicyPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(`{"baseline": 0.9}`), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	policy, err = DiscoverConfidencePolicy(root)
+	if err != nil || policy == nil || policy.Baseline == nil || *policy.Baseline != 0.9 {
+		t.Fatalf("expected the default policy file to load, got %+v, %v", policy, err)
+	}
+
+	override := filepath.Join(t.TempDir(), "team.json")
+	if err := os.WriteFile(override, []byte(`{"baseline": 0.8}`), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	t.Setenv(ConfidencePolicyEnv, override)
+	policy, err = DiscoverConfidencePolicy(root)
+	if err != nil || policy == nil || *policy.Baseline != 0.8 {
+		t.Fatalf("expected %s to take precedence, got %+v, %v", ConfidencePolicyEnv, policy, err)
+	}
+}
+
+func TestFileProcessorAppliesConfidenceScorer(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api/handler.go", "internal/store.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("package p"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	registry := &MockProviderRegistry{providers: map[string]Provider{
+		"go": &MockProvider{
+			language: "go",
+			transformResult: TransformResult{
+				Modified:   "package q",
+				MatchCount: 1,
+				Confidence: ConfidenceScore{Score: 0.8, Level: "high"},
+			},
+		},
+	}}
+	processor := NewFileProcessor(registry)
+	processor.SetConfidenceScorer(&ConfidencePolicy{Paths: []PathRule{{Pattern: "api/**", Impact: -0.3}}})
+
+	result, err := processor.TransformFiles(context.Background(), FileTransformOp{
+		TransformOp: TransformOp{Method: "replace", Target: AgentQuery{Type: "function", Name: "f"}},
+		Scope:       FileScope{Path: dir, Include: []string{"**/*.go"}, Language: "go"},
+		DryRun:      true,
+	})
+	if err != nil {
+		t.Fatalf("TransformFiles: %v", err)
+	}
+
+	scores := make(map[string]float64)
+	for _, file := range result.Files {
+		rel, _ := filepath.Rel(dir, file.FilePath)
+		scores[filepath.ToSlash(rel)] = file.Confidence.Score
+	}
+	if math.Abs(scores["api/handler.go"]-0.5) > 1e-9 || scores["internal/store.go"] != 0.8 {
+		t.Fatalf("expected only the api file to be rescored, got %v", scores)
+	}
+}
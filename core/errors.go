@@ -6,3 +6,19 @@ import "errors"
 // the current source. Batch file operations treat this as a no-op, not a
 // failure, because most files in a scope will legitimately have zero matches.
 var ErrNoMatchesFound = errors.New("no matches found for target")
+
+// ErrOverlappingTargets indicates that a transform matched nested or
+// overlapping nodes under the "error" overlap policy.
+var ErrOverlappingTargets = errors.New("overlapping targets")
+
+// ErrUnexpectedMatchCount indicates that a transform matched more or fewer
+// targets than its expect_matches guard allows.
+var ErrUnexpectedMatchCount = errors.New("unexpected match count")
+
+// ErrTypeCheckUnsupported indicates that a provider cannot type-check sources
+// for its language.
+var ErrTypeCheckUnsupported = errors.New("type checking is not supported")
+
+// ErrPackageScopeUnsupported indicates that a provider's language does not
+// scope names to packages, so references are matched by name alone.
+var ErrPackageScopeUnsupported = errors.New("package-scoped references are not supported")
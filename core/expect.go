@@ -0,0 +1,103 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MatchRange is the number of matches an operation expects: an exact count
+// such as "3" or an inclusive range such as "1..5". Either bound of a range
+// may be left out, as in "2..". JSON accepts both numbers and strings.
+type MatchRange string
+
+// UnmarshalJSON accepts a bare number as well as a string.
+func (r *MatchRange) UnmarshalJSON(data []byte) error {
+	var count int
+	if err := json.Unmarshal(data, &count); err == nil {
+		*r = MatchRange(strconv.Itoa(count))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("expect_matches must be a number or a range such as \"1..5\"")
+	}
+	*r = MatchRange(text)
+	return nil
+}
+
+// Bounds returns the inclusive limits of the range. An open upper bound is
+// returned as -1.
+func (r MatchRange) Bounds() (int, int, error) {
+	text := strings.TrimSpace(string(r))
+	if text == "" {
+		return 0, -1, nil
+	}
+
+	lowText, highText, isRange := strings.Cut(text, "..")
+	if !isRange {
+		count, err := parseMatchBound(text)
+		if err != nil {
+			return 0, 0, err
+		}
+		return count, count, nil
+	}
+
+	low, high := 0, -1
+	var err error
+	if strings.TrimSpace(lowText) != "" {
+		if low, err = parseMatchBound(lowText); err != nil {
+			return 0, 0, err
+		}
+	}
+	if strings.TrimSpace(highText) != "" {
+		if high, err = parseMatchBound(highText); err != nil {
+			return 0, 0, err
+		}
+		if high < low {
+			return 0, 0, fmt.Errorf("invalid expect_matches %q: upper bound is below lower bound", text)
+		}
+	}
+	return low, high, nil
+}
+
+func parseMatchBound(text string) (int, error) {
+	count, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("invalid expect_matches %q: want a count such as 1 or a range such as 1..5", text)
+	}
+	return count, nil
+}
+
+// Validate reports whether the range is well formed.
+func (r MatchRange) Validate() error {
+	_, _, err := r.Bounds()
+	return err
+}
+
+// Allows reports whether count is inside the range. An empty range allows
+// any count.
+func (r MatchRange) Allows(count int) bool {
+	low, high, err := r.Bounds()
+	if err != nil {
+		return false
+	}
+	return count >= low && (high < 0 || count <= high)
+}
+
+// Check returns ErrUnexpectedMatchCount, naming the matched locations, when
+// count is outside the range.
+func (r MatchRange) Check(count int, locations []string) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if r.Allows(count) {
+		return nil
+	}
+	err := fmt.Errorf("%w: expected %s, matched %d", ErrUnexpectedMatchCount, strings.TrimSpace(string(r)), count)
+	if len(locations) > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.Join(locations, ", "))
+	}
+	return err
+}
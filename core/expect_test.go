@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchRangeAllows(t *testing.T) {
+	cases := []struct {
+		expect MatchRange
+		count  int
+		want   bool
+	}{
+		{"", 7, true},
+		{"1", 1, true},
+		{"1", 4, false},
+		{"0", 0, true},
+		{"1..5", 3, true},
+		{"1..5", 0, false},
+		{"1..5", 6, false},
+		{"2..", 9, true},
+		{"2..", 1, false},
+		{"..2", 0, true},
+		{" 1 .. 2 ", 2, true},
+	}
+	for _, tc := range cases {
+		if got := tc.expect.Allows(tc.count); got != tc.want {
+			t.Errorf("MatchRange(%q).Allows(%d) = %v, want %v", tc.expect, tc.count, got, tc.want)
+		}
+	}
+}
+
+func TestMatchRangeValidate(t *testing.T) {
+	for _, bad := range []MatchRange{"x", "-1", "5..1", "1..x", "1...3"} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("MatchRange(%q).Validate() succeeded, want error", bad)
+		}
+	}
+}
+
+func TestMatchRangeUnmarshalJSON(t *testing.T) {
+	var op TransformOp
+	if err := json.Unmarshal([]byte(`{"method":"replace","expect_matches":2}`), &op); err != nil {
+		t.Fatalf("unmarshal number: %v", err)
+	}
+	if op.ExpectMatches != "2" {
+		t.Fatalf("expected \"2\", got %q", op.ExpectMatches)
+	}
+	if err := json.Unmarshal([]byte(`{"method":"replace","expect_matches":"1..5"}`), &op); err != nil {
+		t.Fatalf("unmarshal range: %v", err)
+	}
+	if op.ExpectMatches != "1..5" {
+		t.Fatalf("expected \"1..5\", got %q", op.ExpectMatches)
+	}
+	if err := json.Unmarshal([]byte(`{"method":"replace","expect_matches":true}`), &op); err == nil {
+		t.Fatal("expected an error for a boolean")
+	}
+}
+
+func TestMatchRangeCheckListsLocations(t *testing.T) {
+	err := MatchRange("1").Check(2, []string{"function A (line 1)", "function B (line 5)"})
+	if !errors.Is(err, ErrUnexpectedMatchCount) {
+		t.Fatalf("expected ErrUnexpectedMatchCount, got %v", err)
+	}
+	for _, want := range []string{"expected 1, matched 2", "function A (line 1)", "function B (line 5)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestFileProcessor_TransformFiles_ExpectMatches(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package main"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider := &MockProvider{
+		language: "go",
+		queryResult: QueryResult{Matches: []Match{
+			{Type: "function", Name: "Handler", Location: Location{Line: 3}},
+			{Type: "function", Name: "Helper", Location: Location{Line: 9}},
+		}},
+		transformResult: TransformResult{
+			Modified:   "package changed",
+			MatchCount: 2,
+			Confidence: ConfidenceScore{Score: 0.9, Level: "high"},
+		},
+	}
+	registry := &MockProviderRegistry{providers: map[string]Provider{"go": provider}}
+	processor := NewFileProcessorWithSafety(registry, false, DefaultAtomicConfig())
+
+	op := FileTransformOp{
+		TransformOp: TransformOp{
+			Method:        "replace",
+			Target:        AgentQuery{Type: "function", Name: "H*"},
+			Replacement:   "func X() {}",
+			ExpectMatches: "1..3",
+		},
+		Scope: FileScope{Path: dir, Include: []string{"*.go"}, Language: "go"},
+	}
+
+	_, err := processor.TransformFiles(context.Background(), op)
+	if !errors.Is(err, ErrUnexpectedMatchCount) {
+		t.Fatalf("expected ErrUnexpectedMatchCount, got %v", err)
+	}
+	for _, want := range []string{"matched 4", filepath.Join(dir, "a.go") + ":3 function Handler", filepath.Join(dir, "b.go") + ":9 function Helper"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	for _, name := range []string{"a.go", "b.go"} {
+		content, readErr := os.ReadFile(filepath.Join(dir, name))
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+		if string(content) != "package main" {
+			t.Fatalf("%s was written despite the failed guard: %q", name, content)
+		}
+	}
+
+	op.ExpectMatches = "4"
+	provider.transforms.Store(0)
+	result, err := processor.TransformFiles(context.Background(), op)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.TotalMatches != 4 || result.FilesModified != 2 {
+		t.Fatalf("expected 4 matches in 2 files, got %d in %d", result.TotalMatches, result.FilesModified)
+	}
+	// The guard counts matches in the pass that produces the edits
+	if calls := provider.transforms.Load(); calls != 2 {
+		t.Fatalf("expected each file to be transformed once, got %d transforms", calls)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "package changed" {
+		t.Fatalf("expected a.go to be rewritten, got %q", content)
+	}
+}
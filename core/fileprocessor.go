@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -41,6 +42,8 @@ type FileProcessor struct {
 	safetyEnabled bool
 	txLogDir      string
 	safety        FileSafety
+	scorer        ConfidenceScorer
+	typeCheck     bool
 }
 
 // NewFileProcessor creates a new file processor
@@ -89,6 +92,18 @@ func (fp *FileProcessor) SetSafety(safety FileSafety) {
 	fp.safety = safety
 }
 
+// SetConfidenceScorer configures a scorer that rescores each file's
+// confidence before safety checks and aggregation.
+func (fp *FileProcessor) SetConfidenceScorer(scorer ConfidenceScorer) {
+	fp.scorer = scorer
+}
+
+// SetTypeCheck enables type-checking each modified file before it is
+// scored, for providers that implement TypeChecker.
+func (fp *FileProcessor) SetTypeCheck(enabled bool) {
+	fp.typeCheck = enabled
+}
+
 // QueryFiles searches for code elements across multiple files
 func (fp *FileProcessor) QueryFiles(ctx context.Context, scope FileScope, query AgentQuery) ([]FileMatch, error) {
 	// Discover files
@@ -125,36 +140,29 @@ func (fp *FileProcessor) QueryFiles(ctx context.Context, scope FileScope, query
 	return allMatches, nil
 }
 
-// TransformFiles applies transformations across multiple files
+// TransformFiles applies transformations across multiple files. Every file
+// is transformed before any is written, so op.ExpectMatches is checked
+// against the match counts of the edits that would be written and fails with
+// ErrUnexpectedMatchCount before any file is backed up or touched.
 func (fp *FileProcessor) TransformFiles(ctx context.Context, op FileTransformOp) (*FileTransformResult, error) {
-	start := time.Now()
-
-	// Start transaction if safety enabled
-	var (
-		txManager *TransactionManager
-		tx        *TransactionLog
-		txActive  bool
-		txID      string
-	)
-
-	if fp.safetyEnabled && !op.DryRun {
-		txManager = NewTransactionManager(fp.txLogDir, fp.atomicWriter)
-		var err error
-		tx, err = txManager.BeginTransaction(fmt.Sprintf("Transform files: %s", op.TransformOp.Target.Type))
-		if err != nil {
-			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	expect := op.ExpectMatches
+	if expect != "" {
+		if err := expect.Validate(); err != nil {
+			return nil, err
 		}
-		txID = tx.ID
-		txActive = true
+		// The guard covers the whole scope, not each file
+		op.ExpectMatches = ""
+	}
 
-		// Ensure cleanup on any error
-		defer func() {
-			if txActive && txManager != nil {
-				securefs.IgnoreError(txManager.RollbackTransaction())
-			}
-		}()
+	if err := op.Verify.Validate(); err != nil {
+		return nil, err
+	}
+	if op.Verify != nil && !op.DryRun && !fp.safetyEnabled {
+		return nil, fmt.Errorf("verify needs a transaction to roll back; enable safety")
 	}
 
+	start := time.Now()
+
 	// Discover files
 	walkResults, err := fp.walker.Walk(ctx, op.Scope)
 	if err != nil {
@@ -187,39 +195,63 @@ func (fp *FileProcessor) TransformFiles(ctx context.Context, op FileTransformOp)
 		}
 	}
 
-	// Process files in parallel
-	resultChan := make(chan FileTransformDetail, len(filePaths))
-	var wg sync.WaitGroup
+	// Transform files in parallel without writing them
+	details := make([]FileTransformDetail, len(filePaths))
+	results := make([]*TransformResult, len(filePaths))
+	fp.forEachFile(len(filePaths), func(i int) {
+		details[i], results[i] = fp.transformFile(filePaths[i], op)
+	})
+	fp.checkReferences(ctx, op.Scope, details, results)
+	for i, result := range results {
+		if result != nil {
+			fp.scoreFileChange(filePaths[i], &details[i], result, op)
+		}
+	}
 
-	// Create semaphore for controlled parallelism
-	semaphore := make(chan struct{}, fp.workers)
+	var totalMatches int
+	for _, detail := range details {
+		totalMatches += detail.MatchCount
+	}
+	if expect != "" && !expect.Allows(totalMatches) {
+		return nil, expect.Check(totalMatches, fp.matchLocations(ctx, op, details))
+	}
 
-	for _, walkResult := range filePaths {
-		wg.Add(1)
-		go func(wr WalkResult) {
-			defer wg.Done()
-			semaphore <- struct{}{}        // Acquire
-			defer func() { <-semaphore }() // Release
+	// Start transaction if safety enabled
+	var (
+		txManager *TransactionManager
+		tx        *TransactionLog
+		txActive  bool
+		txID      string
+	)
+
+	if fp.safetyEnabled && !op.DryRun {
+		txManager = NewTransactionManager(fp.txLogDir, fp.atomicWriter)
+		tx, err = txManager.BeginTransaction(fmt.Sprintf("Transform files: %s", op.TransformOp.Target.Type))
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		txID = tx.ID
+		txActive = true
 
-			detail := fp.transformFile(wr, op, tx, txManager)
-			resultChan <- detail
-		}(walkResult)
+		// Ensure cleanup on any error
+		defer func() {
+			if txActive && txManager != nil {
+				securefs.IgnoreError(txManager.RollbackTransaction())
+			}
+		}()
 	}
 
-	// Collect results
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+	// Write the modified files in parallel
+	fp.forEachFile(len(details), func(i int) {
+		if details[i].Modified && details[i].Error == "" {
+			fp.writeFileChange(filePaths[i], &details[i], op, tx, txManager)
+		}
+	})
 
-	var details []FileTransformDetail
-	var totalMatches int
 	var filesModified int
 	var hasErrors bool
 
-	for detail := range resultChan {
-		details = append(details, detail)
-		totalMatches += detail.MatchCount
+	for _, detail := range details {
 		if detail.Modified {
 			filesModified++
 		}
@@ -235,11 +267,23 @@ func (fp *FileProcessor) TransformFiles(ctx context.Context, op FileTransformOp)
 	transformDuration := time.Since(transformStart)
 
 	// Handle transaction completion
+	var verification *VerifyResult
 	if fp.safetyEnabled && !op.DryRun && txManager != nil && tx != nil {
+		if op.Verify != nil && !hasErrors && filesModified > 0 {
+			result := op.Verify.Run(ctx, op.Scope.Path)
+			verification = &result
+			if !result.Passed {
+				hasErrors = true
+				walkErrors = append(walkErrors, fmt.Sprintf("%v: %s", ErrVerificationFailed, result.Summary()))
+			}
+		}
 		if hasErrors {
 			if err := txManager.RollbackTransaction(); err != nil {
 				return nil, fmt.Errorf("failed to rollback transaction: %w", err)
 			}
+			if verification != nil {
+				verification.RolledBack = true
+			}
 		} else {
 			if err := txManager.CommitTransaction(); err != nil {
 				return nil, fmt.Errorf("failed to commit transaction: %w", err)
@@ -261,10 +305,30 @@ func (fp *FileProcessor) TransformFiles(ctx context.Context, op FileTransformOp)
 		Files:             details,
 		Confidence:        overallConfidence,
 		TransactionID:     txID,
+		Verification:      verification,
 		Errors:            walkErrors,
 	}, nil
 }
 
+// forEachFile calls fn for every index below n, at most fp.workers at a time.
+func (fp *FileProcessor) forEachFile(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+
+	// Create semaphore for controlled parallelism
+	semaphore := make(chan struct{}, fp.workers)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}        // Acquire
+			defer func() { <-semaphore }() // Release
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
 // queryWorker processes files for queries in parallel
 func (fp *FileProcessor) queryWorker(
 	ctx context.Context,
@@ -334,14 +398,9 @@ func (fp *FileProcessor) queryFile(walkResult WalkResult, query AgentQuery) []Fi
 	return fileMatches
 }
 
-// transformFile applies transformation to a single file
-
-func (fp *FileProcessor) transformFile(
-	walkResult WalkResult,
-	op FileTransformOp,
-	tx *TransactionLog,
-	txManager *TransactionManager,
-) FileTransformDetail {
+// transformFile transforms a single file without writing it. It returns no
+// result when the file failed or had nothing to change.
+func (fp *FileProcessor) transformFile(walkResult WalkResult, op FileTransformOp) (FileTransformDetail, *TransformResult) {
 	detail := FileTransformDetail{
 		FilePath:     walkResult.Path,
 		Language:     walkResult.Language,
@@ -352,14 +411,17 @@ func (fp *FileProcessor) transformFile(
 	provider, exists := fp.providers.Get(walkResult.Language)
 	if !exists {
 		detail.Error = fmt.Sprintf("no provider for language: %s", walkResult.Language)
-		return detail
+		return detail, nil
 	}
 
 	// Read file content
 	content, err := securefs.ReadFile(walkResult.Path)
+	if overlay, ok := op.Overlay[walkResult.Path]; ok && op.DryRun {
+		content, err = []byte(overlay), nil
+	}
 	if err != nil {
 		detail.Error = fmt.Sprintf("failed to read file: %v", err)
-		return detail
+		return detail, nil
 	}
 
 	originalContent := string(content)
@@ -368,38 +430,73 @@ func (fp *FileProcessor) transformFile(
 	result := provider.Transform(originalContent, op.TransformOp)
 	if result.Error != nil {
 		if errors.Is(result.Error, ErrNoMatchesFound) {
-			return detail
+			return detail, nil
 		}
 		detail.Error = fmt.Sprintf("transformation failed: %v", result.Error)
-		return detail
+		return detail, nil
+	}
+
+	if checker, ok := provider.(TypeChecker); ok && fp.typeCheck {
+		detail.TypeErrors = ApplyTypeCheck(checker, walkResult.Path, originalContent, &result)
+	}
+	detail.OriginalContent = originalContent
+	return detail, &result
+}
+
+// scoreFileChange scores the result transformFile produced for a file, once
+// its references are checked, and records it on detail. A detail left
+// Modified carries the content writeFileChange writes.
+func (fp *FileProcessor) scoreFileChange(walkResult WalkResult, detail *FileTransformDetail, result *TransformResult, op FileTransformOp) {
+	originalContent := detail.OriginalContent
+	detail.OriginalContent = ""
+
+	if fp.scorer != nil {
+		result.Confidence = fp.scorer.Score(ScoreContext{
+			Language: walkResult.Language,
+			Method:   op.Method,
+			Path:     walkResult.Path,
+		}, result.Confidence)
 	}
 
 	detail.MatchCount = result.MatchCount
 	detail.Confidence = result.Confidence
 	detail.Diff = result.Diff
+	detail.StructuralDiff, _ = result.Metadata["structural_diff"].([]StructuralChange)
 
 	if fp.safety != nil && detail.MatchCount > 0 {
 		if err := fp.safety.ValidateFileChange(walkResult, result.Confidence); err != nil {
 			detail.Error = err.Error()
 			detail.MatchCount = 0 // Reset to avoid false positive in reporting
-			return detail
+			return
 		}
 	}
 
 	// Check if content actually changed
 	if result.Modified == originalContent {
-		return detail // No changes
+		return // No changes
 	}
 
 	detail.Modified = true
 	detail.ModifiedSize = int64(len(result.Modified))
+	detail.OriginalContent = originalContent
+	detail.ModifiedContent = result.Modified
+}
 
+// writeFileChange backs up and writes the content transformFile produced
+// for a file, recording failures on detail. Dry runs leave the file alone.
+func (fp *FileProcessor) writeFileChange(
+	walkResult WalkResult,
+	detail *FileTransformDetail,
+	op FileTransformOp,
+	tx *TransactionLog,
+	txManager *TransactionManager,
+) {
 	// Register operation in transaction if safety enabled
 	if fp.safetyEnabled && !op.DryRun && tx != nil && txManager != nil {
 		txOp, err := txManager.AddOperation("modify", walkResult.Path)
 		if err != nil {
 			detail.Error = fmt.Sprintf("failed to register transaction operation: %v", err)
-			return detail
+			return
 		}
 		detail.BackupPath = txOp.BackupPath
 	} else if op.Backup {
@@ -407,7 +504,7 @@ func (fp *FileProcessor) transformFile(
 		backupPath := walkResult.Path + ".bak"
 		if err := fp.createBackup(walkResult.Path, backupPath); err != nil {
 			detail.Error = fmt.Sprintf("failed to create backup: %v", err)
-			return detail
+			return
 		}
 		detail.BackupPath = backupPath
 	}
@@ -417,10 +514,10 @@ func (fp *FileProcessor) transformFile(
 		var writeErr error
 		if fp.safetyEnabled {
 			// Use atomic writer with locking
-			writeErr = fp.atomicWriter.WriteFile(walkResult.Path, result.Modified)
+			writeErr = fp.atomicWriter.WriteFile(walkResult.Path, detail.ModifiedContent)
 		} else {
 			// Use simple write
-			writeErr = fp.writeFile(walkResult.Path, result.Modified)
+			writeErr = fp.writeFile(walkResult.Path, detail.ModifiedContent)
 		}
 
 		if writeErr != nil {
@@ -432,19 +529,56 @@ func (fp *FileProcessor) transformFile(
 					detail.Error = fmt.Sprintf("%s; failed to record transaction error: %v", detail.Error, completeErr)
 				}
 			}
-			return detail
+			return
 		}
 
 		// Mark operation as completed in transaction
 		if fp.safetyEnabled && tx != nil && txManager != nil {
 			if err := txManager.CompleteOperation(walkResult.Path, nil); err != nil {
 				detail.Error = fmt.Sprintf("failed to complete transaction operation: %v", err)
-				return detail
+				return
 			}
 		}
 	}
+}
 
-	return detail
+// matchLocations lists where op's target matched in the files whose details
+// counted matches.
+func (fp *FileProcessor) matchLocations(ctx context.Context, op FileTransformOp, details []FileTransformDetail) []string {
+	counted := make(map[string]bool)
+	for _, file := range details {
+		if file.MatchCount > 0 {
+			counted[file.FilePath] = true
+		}
+	}
+
+	var locations []string
+	matches, err := fp.QueryFiles(ctx, op.Scope, op.Target)
+	if err == nil {
+		sort.SliceStable(matches, func(i, j int) bool {
+			if matches[i].FilePath != matches[j].FilePath {
+				return matches[i].FilePath < matches[j].FilePath
+			}
+			return matches[i].Location.Line < matches[j].Location.Line
+		})
+		for _, match := range matches {
+			if counted[match.FilePath] {
+				locations = append(locations, fmt.Sprintf("%s:%d %s %s", match.FilePath, match.Location.Line, match.Type, match.Name))
+			}
+		}
+	}
+	if len(locations) > 0 {
+		return locations
+	}
+
+	// Fall back to per-file counts when the query cannot place the matches
+	for _, file := range details {
+		if file.MatchCount > 0 {
+			locations = append(locations, fmt.Sprintf("%s (%d matches)", file.FilePath, file.MatchCount))
+		}
+	}
+	sort.Strings(locations)
+	return locations
 }
 
 // createBackup creates a backup copy of the file
@@ -622,6 +756,19 @@ func (fp *FileProcessor) SetTransactionLogDir(dir string) {
 	fp.txLogDir = dir
 }
 
+// RollbackTransaction undoes a transaction TransformFiles committed,
+// restoring every file it wrote from the transaction's backups.
+func (fp *FileProcessor) RollbackTransaction(txID string) error {
+	if !fp.safetyEnabled {
+		return fmt.Errorf("rollback requires safety (transactions) to be enabled")
+	}
+	txManager := NewTransactionManager(fp.txLogDir, fp.atomicWriter)
+	if _, err := txManager.ReopenTransaction(txID); err != nil {
+		return err
+	}
+	return txManager.RollbackTransaction()
+}
+
 // IsSafetyEnabled returns current safety status
 func (fp *FileProcessor) IsSafetyEnabled() bool {
 	return fp.safetyEnabled
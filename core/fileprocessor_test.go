@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -15,6 +16,7 @@ type MockProvider struct {
 	queryResult     QueryResult
 	transformResult TransformResult
 	delay           time.Duration // For simulating slow processing
+	transforms      atomic.Int64  // Transform calls made
 }
 
 func (m *MockProvider) Language() string {
@@ -26,6 +28,7 @@ func (m *MockProvider) Query(source string, query AgentQuery) QueryResult {
 }
 
 func (m *MockProvider) Transform(source string, op TransformOp) TransformResult {
+	m.transforms.Add(1)
 	// Simulate slow processing if delay is set
 	if m.delay > 0 {
 		time.Sleep(m.delay)
@@ -569,6 +572,49 @@ func TestFileProcessor_TransformFiles_DryRun(t *testing.T) {
 	}
 }
 
+func TestFileProcessor_TransformFiles_Overlay(t *testing.T) {
+	registry := &MockProviderRegistry{
+		providers: map[string]Provider{
+			"go": &MockProvider{
+				language: "go",
+				transformResult: TransformResult{
+					Modified:   "package main\nfunc last() {}",
+					MatchCount: 1,
+					Confidence: ConfidenceScore{Score: 0.9, Level: "high"},
+				},
+			},
+		},
+	}
+	processor := NewFileProcessor(registry)
+
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "test.go")
+	if err := os.WriteFile(testFile, []byte("package main\nfunc main() {}"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	result, err := processor.TransformFiles(context.Background(), FileTransformOp{
+		Scope:       FileScope{Path: tempDir, Include: []string{"*.go"}, Language: "go"},
+		TransformOp: TransformOp{Method: "replace", Target: AgentQuery{Type: "function", Name: "main"}, Replacement: "last"},
+		DryRun:      true,
+		Overlay:     map[string]string{testFile: "package main\nfunc staged() {}"},
+	})
+	if err != nil {
+		t.Fatalf("TransformFiles failed: %v", err)
+	}
+	if len(result.Files) != 1 {
+		t.Fatalf("Expected one file detail, got %d", len(result.Files))
+	}
+
+	detail := result.Files[0]
+	if detail.OriginalContent != "package main\nfunc staged() {}" {
+		t.Errorf("Expected the overlay to be transformed instead of the file, got %q", detail.OriginalContent)
+	}
+	if detail.ModifiedContent != "package main\nfunc last() {}" {
+		t.Errorf("Expected the transformed content, got %q", detail.ModifiedContent)
+	}
+}
+
 func TestFileProcessor_TransformFiles_NoMatches(t *testing.T) {
 	// Create mock provider that returns no matches
 	mockProvider := &MockProvider{
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// hunkContext is the number of unchanged lines around each hunk, matching
+// the unified diffs providers generate, so hunk numbers line up with the
+// "@@" sections reviewers see.
+const hunkContext = 3
+
+// DiffHunk is one "@@" section of the unified diff between two versions of
+// a file. Index is 1-based.
+type DiffHunk struct {
+	Index   int    `json:"index"`
+	Header  string `json:"header"`
+	Text    string `json:"text"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+// DiffHunks splits the change from original to modified into hunks.
+func DiffHunks(original, modified string) []DiffHunk {
+	if original == modified {
+		return nil
+	}
+	a, b := strings.Split(original, "\n"), strings.Split(modified, "\n")
+	groups := difflib.NewMatcher(a, b).GetGroupedOpCodes(hunkContext)
+
+	hunks := make([]DiffHunk, 0, len(groups))
+	for i, group := range groups {
+		first, last := group[0], group[len(group)-1]
+		hunk := DiffHunk{
+			Index:  i + 1,
+			Header: fmt.Sprintf("@@ -%s +%s @@", hunkRange(first.I1, last.I2), hunkRange(first.J1, last.J2)),
+		}
+		var text strings.Builder
+		text.WriteString(hunk.Header + "\n")
+		for _, op := range group {
+			if op.Tag == 'e' {
+				for _, line := range a[op.I1:op.I2] {
+					text.WriteString(" " + line + "\n")
+				}
+				continue
+			}
+			for _, line := range a[op.I1:op.I2] {
+				text.WriteString("-" + line + "\n")
+				hunk.Removed++
+			}
+			for _, line := range b[op.J1:op.J2] {
+				text.WriteString("+" + line + "\n")
+				hunk.Added++
+			}
+		}
+		hunk.Text = text.String()
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
+
+// UnifiedDiff renders the change from original to modified as a unified
+// diff, or "" when they are equal.
+func UnifiedDiff(original, modified string) string {
+	hunks := DiffHunks(original, modified)
+	if len(hunks) == 0 {
+		return ""
+	}
+	var diff strings.Builder
+	diff.WriteString("--- original\n+++ modified\n")
+	for _, hunk := range hunks {
+		diff.WriteString(hunk.Text)
+	}
+	return diff.String()
+}
+
+// ApplyHunks returns original with only the selected hunks of its change to
+// modified applied. Applying the remaining hunks to the result yields
+// modified.
+func ApplyHunks(original, modified string, selected []int) (string, error) {
+	a, b := strings.Split(original, "\n"), strings.Split(modified, "\n")
+	matcher := difflib.NewMatcher(a, b)
+	// GetGroupedOpCodes trims the cached opcodes in place, so keep a copy
+	ops := slices.Clone(matcher.GetOpCodes())
+	groups := matcher.GetGroupedOpCodes(hunkContext)
+	if original == modified {
+		groups = nil
+	}
+	for _, index := range selected {
+		if index < 1 || index > len(groups) {
+			return "", fmt.Errorf("hunk %d out of range: the change has %d hunk(s)", index, len(groups))
+		}
+	}
+
+	type span struct{ i1, i2, j1, j2 int }
+	chosen := make(map[span]bool)
+	for i, group := range groups {
+		if !slices.Contains(selected, i+1) {
+			continue
+		}
+		for _, op := range group {
+			if op.Tag != 'e' {
+				chosen[span{op.I1, op.I2, op.J1, op.J2}] = true
+			}
+		}
+	}
+
+	var lines []string
+	for _, op := range ops {
+		if op.Tag != 'e' && chosen[span{op.I1, op.I2, op.J1, op.J2}] {
+			lines = append(lines, b[op.J1:op.J2]...)
+			continue
+		}
+		lines = append(lines, a[op.I1:op.I2]...)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// hunkRange formats a 0-based half-open line range the way unified diff
+// headers do.
+func hunkRange(start, stop int) string {
+	beginning, length := start+1, stop-start
+	if length == 1 {
+		return fmt.Sprintf("%d", beginning)
+	}
+	if length == 0 {
+		beginning--
+	}
+	return fmt.Sprintf("%d,%d", beginning, length)
+}
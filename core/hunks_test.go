@@ -0,0 +1,57 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffHunksAndApplyHunks(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, "line "+string(rune('a'+i-1)))
+	}
+	original := strings.Join(lines, "\n") + "\n"
+	modified := strings.Replace(strings.Replace(original, "line b\n", "line B\n", 1), "line s\n", "line S\nline S2\n", 1)
+
+	hunks := DiffHunks(original, modified)
+	if len(hunks) != 2 {
+		t.Fatalf("expected two hunks, got %+v", hunks)
+	}
+	if hunks[0].Header != "@@ -1,5 +1,5 @@" || hunks[1].Added != 2 || hunks[1].Removed != 1 {
+		t.Fatalf("unexpected hunks: %+v", hunks)
+	}
+	if !strings.Contains(hunks[1].Text, "+line S2\n") {
+		t.Fatalf("expected the hunk text to list added lines, got %q", hunks[1].Text)
+	}
+
+	partial, err := ApplyHunks(original, modified, []int{2})
+	if err != nil {
+		t.Fatalf("ApplyHunks returned error: %v", err)
+	}
+	if !strings.Contains(partial, "line b\n") || !strings.Contains(partial, "line S2\n") {
+		t.Fatalf("expected only the second hunk applied, got %q", partial)
+	}
+	if rest := DiffHunks(partial, modified); len(rest) != 1 || rest[0].Header != hunks[0].Header {
+		t.Fatalf("expected the first hunk to remain, got %+v", rest)
+	}
+
+	if all, _ := ApplyHunks(original, modified, []int{1, 2}); all != modified {
+		t.Fatalf("expected every hunk to give modified, got %q", all)
+	}
+	if none, _ := ApplyHunks(original, modified, nil); none != original {
+		t.Fatalf("expected no hunks to give original, got %q", none)
+	}
+	if _, err := ApplyHunks(original, modified, []int{3}); err == nil || !strings.Contains(err.Error(), "2 hunk(s)") {
+		t.Fatalf("expected an out of range error, got %v", err)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := UnifiedDiff("a\n", "a\n"); diff != "" {
+		t.Fatalf("expected no diff for equal input, got %q", diff)
+	}
+	want := "--- original\n+++ modified\n@@ -1,2 +1,2 @@\n-a\n+b\n \n"
+	if diff := UnifiedDiff("a\n", "b\n"); diff != want {
+		t.Fatalf("UnifiedDiff = %q, want %q", diff, want)
+	}
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ErrMergeConflict is returned when both sides of a 3-way merge changed the
+// same lines differently.
+var ErrMergeConflict = errors.New("merge conflict")
+
+// MergeConflict is a range of base lines, 1-based and inclusive, that both
+// sides changed differently. An insertion between lines has End = Start-1.
+type MergeConflict struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func (c MergeConflict) String() string {
+	if c.End < c.Start {
+		return fmt.Sprintf("before line %d", c.Start)
+	}
+	if c.End == c.Start {
+		return fmt.Sprintf("line %d", c.Start)
+	}
+	return fmt.Sprintf("lines %d-%d", c.Start, c.End)
+}
+
+// mergeHunk replaces base lines [start, end) with lines.
+type mergeHunk struct {
+	start, end int
+	lines      []string
+	ours       bool
+}
+
+// Merge3 merges the changes ours and theirs each made to base, line by line.
+// Changes that touch or overlap are conflicts unless both sides made the
+// same change; conflicting regions keep ours and are listed in conflicts.
+func Merge3(base, ours, theirs string) (string, []MergeConflict) {
+	baseLines := splitLinesKeepEnds(base)
+	hunks := append(mergeHunks(baseLines, splitLinesKeepEnds(ours), true), mergeHunks(baseLines, splitLinesKeepEnds(theirs), false)...)
+	sort.SliceStable(hunks, func(i, j int) bool { return hunks[i].start < hunks[j].start })
+
+	var (
+		merged    strings.Builder
+		conflicts []MergeConflict
+		position  int
+	)
+	for i := 0; i < len(hunks); {
+		// Gather every hunk touching the region the first one starts
+		start, end := hunks[i].start, hunks[i].end
+		j := i + 1
+		for j < len(hunks) && hunks[j].start <= end {
+			end = max(end, hunks[j].end)
+			j++
+		}
+		cluster := hunks[i:j]
+		i = j
+
+		for _, line := range baseLines[position:start] {
+			merged.WriteString(line)
+		}
+		position = end
+
+		oursText, oursChanged := applyMergeHunks(baseLines, start, end, cluster, true)
+		theirsText, theirsChanged := applyMergeHunks(baseLines, start, end, cluster, false)
+		switch {
+		case !theirsChanged:
+			merged.WriteString(oursText)
+		case !oursChanged, oursText == theirsText:
+			merged.WriteString(theirsText)
+		default:
+			merged.WriteString(oursText)
+			conflicts = append(conflicts, MergeConflict{Start: start + 1, End: end})
+		}
+	}
+	for _, line := range baseLines[position:] {
+		merged.WriteString(line)
+	}
+	return merged.String(), conflicts
+}
+
+// mergeHunks lists the changes that turn base into other.
+func mergeHunks(base, other []string, ours bool) []mergeHunk {
+	var hunks []mergeHunk
+	for _, op := range difflib.NewMatcher(base, other).GetOpCodes() {
+		if op.Tag == 'e' {
+			continue
+		}
+		hunks = append(hunks, mergeHunk{start: op.I1, end: op.I2, lines: other[op.J1:op.J2], ours: ours})
+	}
+	return hunks
+}
+
+// applyMergeHunks returns base lines [start, end) with one side's hunks
+// applied, and whether that side changed anything there.
+func applyMergeHunks(base []string, start, end int, hunks []mergeHunk, ours bool) (string, bool) {
+	var text strings.Builder
+	position, changed := start, false
+	for _, hunk := range hunks {
+		if hunk.ours != ours {
+			continue
+		}
+		for _, line := range base[position:hunk.start] {
+			text.WriteString(line)
+		}
+		for _, line := range hunk.lines {
+			text.WriteString(line)
+		}
+		position, changed = hunk.end, true
+	}
+	for _, line := range base[position:end] {
+		text.WriteString(line)
+	}
+	return text.String(), changed
+}
+
+func splitLinesKeepEnds(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
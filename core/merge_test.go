@@ -0,0 +1,75 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+
+	tests := []struct {
+		name      string
+		ours      string
+		theirs    string
+		want      string
+		conflicts []MergeConflict
+	}{
+		{
+			name:   "separate changes",
+			ours:   "a\nB\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\nE\nf\n",
+			want:   "a\nB\nc\nd\nE\nf\n",
+		},
+		{
+			name:   "one side only",
+			ours:   base,
+			theirs: "a\nc\nd\ne\n",
+			want:   "a\nc\nd\ne\n",
+		},
+		{
+			name:   "same change",
+			ours:   "a\nX\nc\nd\ne\n",
+			theirs: "a\nX\nc\nd\ne\n",
+			want:   "a\nX\nc\nd\ne\n",
+		},
+		{
+			name:      "overlapping changes",
+			ours:      "a\nb\nC\nd\ne\n",
+			theirs:    "a\nb\nZ\nd\ne\n",
+			want:      "a\nb\nC\nd\ne\n",
+			conflicts: []MergeConflict{{Start: 3, End: 3}},
+		},
+		{
+			name:      "adjacent changes",
+			ours:      "a\nB\nc\nd\ne\n",
+			theirs:    "a\nb\nC\nd\ne\n",
+			want:      "a\nB\nc\nd\ne\n",
+			conflicts: []MergeConflict{{Start: 2, End: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := Merge3(base, tt.ours, tt.theirs)
+			if got != tt.want {
+				t.Fatalf("Merge3 = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(conflicts, tt.conflicts) {
+				t.Fatalf("conflicts = %+v, want %+v", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestMergeConflictString(t *testing.T) {
+	for conflict, want := range map[MergeConflict]string{
+		{Start: 3, End: 2}: "before line 3",
+		{Start: 3, End: 3}: "line 3",
+		{Start: 3, End: 5}: "lines 3-5",
+	} {
+		if got := conflict.String(); got != want {
+			t.Fatalf("String() = %q, want %q", got, want)
+		}
+	}
+}
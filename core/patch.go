@@ -0,0 +1,311 @@
+package core
+
+import (
+	"crypto/sha1" // #nosec G505 -- git object ids are SHA-1
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// devNull is the path a patch uses for the missing side of a created or
+// deleted file.
+const devNull = "/dev/null"
+
+// noNewline marks a patch line whose file ends without a newline.
+const noNewline = `\ No newline at end of file`
+
+// ErrPatchMismatch is returned when a patch hunk does not match the content
+// it is applied to.
+var ErrPatchMismatch = errors.New("patch does not apply")
+
+// FilePatch is the change a patch makes to one file. OldPath or NewPath is
+// "/dev/null" when the file is created or deleted.
+type FilePatch struct {
+	OldPath string      `json:"old_path"`
+	NewPath string      `json:"new_path"`
+	Hunks   []PatchHunk `json:"hunks"`
+}
+
+// PatchHunk is one "@@" section of a FilePatch. Starts are 1-based; Lines
+// keep their " ", "-", or "+" prefix and their line ending, which is missing
+// only on a last line without a newline.
+type PatchHunk struct {
+	OldStart int      `json:"old_start"`
+	OldLines int      `json:"old_lines"`
+	NewStart int      `json:"new_start"`
+	NewLines int      `json:"new_lines"`
+	Lines    []string `json:"lines"`
+}
+
+// Path returns the path the patch writes, or the deleted path.
+func (p FilePatch) Path() string {
+	if p.NewPath == devNull {
+		return p.OldPath
+	}
+	return p.NewPath
+}
+
+// Creates reports whether the patch creates its file.
+func (p FilePatch) Creates() bool { return p.OldPath == devNull }
+
+// Deletes reports whether the patch deletes its file.
+func (p FilePatch) Deletes() bool { return p.NewPath == devNull }
+
+// GitPatch renders the change from original to modified of the file at path,
+// a slash-separated path relative to the repository root, as a patch that
+// `git apply` accepts, with a/ and b/ paths and full index lines. An empty
+// original creates the file and an empty modified deletes it. It returns ""
+// when nothing changed.
+func GitPatch(path, original, modified string) string {
+	if original == modified {
+		return ""
+	}
+	oldPath, newPath := "a/"+path, "b/"+path
+	var patch strings.Builder
+	fmt.Fprintf(&patch, "diff --git %s %s\n", oldPath, newPath)
+	switch {
+	case original == "":
+		patch.WriteString("new file mode 100644\n")
+		fmt.Fprintf(&patch, "index %s..%s\n", strings.Repeat("0", 40), gitBlobID(modified))
+		oldPath = devNull
+	case modified == "":
+		patch.WriteString("deleted file mode 100644\n")
+		fmt.Fprintf(&patch, "index %s..%s\n", gitBlobID(original), strings.Repeat("0", 40))
+		newPath = devNull
+	default:
+		fmt.Fprintf(&patch, "index %s..%s 100644\n", gitBlobID(original), gitBlobID(modified))
+	}
+	fmt.Fprintf(&patch, "--- %s\n+++ %s\n", oldPath, newPath)
+
+	a, b := splitLinesKeepEnds(original), splitLinesKeepEnds(modified)
+	for _, group := range difflib.NewMatcher(a, b).GetGroupedOpCodes(hunkContext) {
+		first, last := group[0], group[len(group)-1]
+		fmt.Fprintf(&patch, "@@ -%s +%s @@\n", hunkRange(first.I1, last.I2), hunkRange(first.J1, last.J2))
+		for _, op := range group {
+			if op.Tag == 'e' {
+				writePatchLines(&patch, " ", a[op.I1:op.I2])
+				continue
+			}
+			writePatchLines(&patch, "-", a[op.I1:op.I2])
+			writePatchLines(&patch, "+", b[op.J1:op.J2])
+		}
+	}
+	return patch.String()
+}
+
+func writePatchLines(patch *strings.Builder, prefix string, lines []string) {
+	for _, line := range lines {
+		patch.WriteString(prefix + line)
+		if !strings.HasSuffix(line, "\n") {
+			patch.WriteString("\n" + noNewline + "\n")
+		}
+	}
+}
+
+// gitBlobID returns the id git gives a blob holding content.
+func gitBlobID(content string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("blob %d\x00%s", len(content), content))) // #nosec G401 -- git object ids are SHA-1
+	return fmt.Sprintf("%x", sum)
+}
+
+// ParsePatch reads the file patches of a unified diff, either plain `diff -u`
+// output or git's, whose a/ and b/ path prefixes are removed. Binary patches,
+// renames, and copies are rejected.
+func ParsePatch(text string) ([]FilePatch, error) {
+	lines := splitLinesKeepEnds(text)
+	var (
+		patches []FilePatch
+		current *FilePatch
+	)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = nil
+		case strings.HasPrefix(line, "Binary files ") || strings.HasPrefix(line, "GIT binary patch"):
+			return nil, fmt.Errorf("line %d: binary patches are not supported", i+1)
+		case strings.HasPrefix(line, "rename from ") || strings.HasPrefix(line, "copy from "):
+			return nil, fmt.Errorf("line %d: renamed and copied files are not supported", i+1)
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			patches = append(patches, FilePatch{
+				OldPath: patchPath(line[4:]),
+				NewPath: patchPath(strings.TrimRight(lines[i+1], "\r\n")[4:]),
+			})
+			current = &patches[len(patches)-1]
+			i++
+		case strings.HasPrefix(line, "@@ "):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: hunk outside a file patch", i+1)
+			}
+			hunk, next, err := parsePatchHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			current.Hunks = append(current.Hunks, hunk)
+			i = next - 1
+		}
+	}
+	if len(patches) == 0 {
+		return nil, errors.New("no file patches found")
+	}
+	for _, patch := range patches {
+		if patch.OldPath == devNull && patch.NewPath == devNull {
+			return nil, errors.New("file patch has no path")
+		}
+		if patch.OldPath != devNull && patch.NewPath != devNull && patch.OldPath != patch.NewPath {
+			return nil, fmt.Errorf("renaming %s to %s is not supported", patch.OldPath, patch.NewPath)
+		}
+	}
+	return patches, nil
+}
+
+// patchPath strips the timestamp `diff -u` appends and git's a/ or b/ prefix.
+func patchPath(field string) string {
+	if tab := strings.IndexByte(field, '\t'); tab >= 0 {
+		field = field[:tab]
+	}
+	field = strings.TrimSpace(field)
+	if field == devNull {
+		return field
+	}
+	if strings.HasPrefix(field, "a/") || strings.HasPrefix(field, "b/") {
+		return field[2:]
+	}
+	return field
+}
+
+// parsePatchHunk reads the hunk whose header is lines[start], returning it
+// and the index of the line after it.
+func parsePatchHunk(lines []string, start int) (PatchHunk, int, error) {
+	header := strings.TrimRight(lines[start], "\r\n")
+	var hunk PatchHunk
+	ranges := strings.Fields(strings.TrimPrefix(header, "@@ "))
+	if len(ranges) < 3 || ranges[2] != "@@" || !strings.HasPrefix(ranges[0], "-") || !strings.HasPrefix(ranges[1], "+") {
+		return hunk, 0, fmt.Errorf("line %d: malformed hunk header %q", start+1, header)
+	}
+	var err error
+	if hunk.OldStart, hunk.OldLines, err = parseHunkRange(ranges[0][1:]); err == nil {
+		hunk.NewStart, hunk.NewLines, err = parseHunkRange(ranges[1][1:])
+	}
+	if err != nil {
+		return hunk, 0, fmt.Errorf("line %d: malformed hunk header %q: %w", start+1, header, err)
+	}
+
+	oldLeft, newLeft := hunk.OldLines, hunk.NewLines
+	i := start + 1
+	for ; i < len(lines) && (oldLeft > 0 || newLeft > 0); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, `\`) {
+			continue
+		}
+		if line == "\n" || line == "\r\n" {
+			// Some tools strip the space from empty context lines
+			line = " " + line
+		}
+		switch line[0] {
+		case ' ':
+			oldLeft--
+			newLeft--
+		case '-':
+			oldLeft--
+		case '+':
+			newLeft--
+		default:
+			return hunk, 0, fmt.Errorf("line %d: unexpected line in hunk: %q", i+1, strings.TrimRight(line, "\r\n"))
+		}
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], `\`) {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		}
+		hunk.Lines = append(hunk.Lines, line)
+	}
+	if oldLeft != 0 || newLeft != 0 {
+		return hunk, 0, fmt.Errorf("line %d: hunk %q is truncated", start+1, header)
+	}
+	if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+		i++
+	}
+	return hunk, i, nil
+}
+
+func parseHunkRange(field string) (int, int, error) {
+	start, count, found := strings.Cut(field, ",")
+	first, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return first, 1, nil
+	}
+	length, err := strconv.Atoi(count)
+	return first, length, err
+}
+
+// ApplyPatch applies the hunks of patch to original in order. Like patch(1)
+// and `git apply`, a hunk whose context moved is looked for above and below
+// the line it names; a hunk whose lines are not found fails with
+// ErrPatchMismatch.
+func ApplyPatch(original string, patch FilePatch) (string, error) {
+	lines := splitLinesKeepEnds(original)
+	var (
+		result strings.Builder
+		cursor int
+	)
+	for index, hunk := range patch.Hunks {
+		var before, after []string
+		for _, line := range hunk.Lines {
+			if line[0] != '+' {
+				before = append(before, line[1:])
+			}
+			if line[0] != '-' {
+				after = append(after, line[1:])
+			}
+		}
+		expected := hunk.OldStart - 1
+		if hunk.OldLines == 0 {
+			expected = hunk.OldStart
+		}
+		at := findHunk(lines, before, cursor, expected)
+		if at < 0 {
+			return "", fmt.Errorf("%w: hunk %d of %s does not match at line %d", ErrPatchMismatch, index+1, patch.Path(), hunk.OldStart)
+		}
+		for _, line := range lines[cursor:at] {
+			result.WriteString(line)
+		}
+		for _, line := range after {
+			result.WriteString(line)
+		}
+		cursor = at + len(before)
+	}
+	for _, line := range lines[cursor:] {
+		result.WriteString(line)
+	}
+	return result.String(), nil
+}
+
+// findHunk returns where want appears in lines at or after from, nearest to
+// expected, or -1.
+func findHunk(lines, want []string, from, expected int) int {
+	matches := func(at int) bool {
+		if at < from || at+len(want) > len(lines) {
+			return false
+		}
+		for i, line := range want {
+			if lines[at+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+	for offset := 0; expected-offset >= from || expected+offset <= len(lines); offset++ {
+		if matches(expected - offset) {
+			return expected - offset
+		}
+		if offset > 0 && matches(expected+offset) {
+			return expected + offset
+		}
+	}
+	return -1
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGitPatch(t *testing.T) {
+	original := "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n\nfunc d() {}\nlast"
+	modified := "package main\n\nfunc a() { run() }\n\nfunc b() {}\n\nfunc c() {}\n\nfunc d() {}\nlast\n"
+
+	patch := GitPatch("pkg/main.go", original, modified)
+	for _, want := range []string{
+		"diff --git a/pkg/main.go b/pkg/main.go\n",
+		"index " + gitBlobID(original) + ".." + gitBlobID(modified) + " 100644\n",
+		"--- a/pkg/main.go\n+++ b/pkg/main.go\n",
+		"@@ -1,10 +1,10 @@\n package main\n \n-func a() {}\n+func a() { run() }\n",
+		"-last\n\\ No newline at end of file\n+last\n",
+	} {
+		if !strings.Contains(patch, want) {
+			t.Fatalf("expected patch to contain %q:\n%s", want, patch)
+		}
+	}
+
+	created := GitPatch("new.go", "", "package pkg\n")
+	if !strings.Contains(created, "new file mode 100644\nindex 0000000000000000000000000000000000000000..") ||
+		!strings.Contains(created, "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+package pkg\n") {
+		t.Fatalf("unexpected patch for a created file:\n%s", created)
+	}
+	if GitPatch("same.go", original, original) != "" {
+		t.Fatal("expected no patch for an unchanged file")
+	}
+	// Blob ids match `git hash-object`
+	if id := gitBlobID("package pkg\n"); id != "c1caffeb1fbeb31d432cbd6b3a8e3bcf5991e401" {
+		t.Fatalf("unexpected blob id %s", id)
+	}
+}
+
+func TestParsePatchRoundTrip(t *testing.T) {
+	original := "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n\nfunc d() {}\n\nfunc e() {}\nlast"
+	modified := "package main\n\nfunc a() { run() }\n\nfunc b() {}\n\nfunc c() {}\n\nfunc d() {}\n\nfunc e() { run() }\nlast\n"
+
+	patches, err := ParsePatch(GitPatch("main.go", original, modified) + GitPatch("new.go", "", "package pkg\n"))
+	if err != nil {
+		t.Fatalf("ParsePatch failed: %v", err)
+	}
+	if len(patches) != 2 || patches[0].Path() != "main.go" || len(patches[0].Hunks) != 2 || !patches[1].Creates() {
+		t.Fatalf("unexpected patches: %+v", patches)
+	}
+	if got, err := ApplyPatch(original, patches[0]); err != nil || got != modified {
+		t.Fatalf("expected the modified file, got %q (%v)", got, err)
+	}
+	if got, err := ApplyPatch("", patches[1]); err != nil || got != "package pkg\n" {
+		t.Fatalf("expected the created file, got %q (%v)", got, err)
+	}
+}
+
+func TestParsePatchPlainDiff(t *testing.T) {
+	patch := "--- main.go\t2026-10-18 10:00:00\n+++ main.go\t2026-10-18 10:05:00\n" +
+		"@@ -2,3 +2,3 @@\n" +
+		"\n" + // context line whose space was stripped
+		"-func a() {}\n" +
+		"+func a() { run() }\n" +
+		" \n"
+
+	patches, err := ParsePatch(patch)
+	if err != nil {
+		t.Fatalf("ParsePatch failed: %v", err)
+	}
+	if patches[0].OldPath != "main.go" || patches[0].NewPath != "main.go" {
+		t.Fatalf("expected timestamps stripped, got %+v", patches[0])
+	}
+
+	// The file gained two lines above the hunk since the diff was made
+	current := "// Package main.\n// Runs things.\npackage main\n\nfunc a() {}\n\nfunc b() {}\n"
+	got, err := ApplyPatch(current, patches[0])
+	if err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+	if got != "// Package main.\n// Runs things.\npackage main\n\nfunc a() { run() }\n\nfunc b() {}\n" {
+		t.Fatalf("expected the hunk applied at its new offset, got:\n%s", got)
+	}
+
+	if _, err := ApplyPatch("package main\n\nfunc z() {}\n", patches[0]); !errors.Is(err, ErrPatchMismatch) {
+		t.Fatalf("expected a mismatch, got %v", err)
+	}
+}
+
+func TestParsePatchRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"empty", "just some text\n", "no file patches found"},
+		{"binary", "diff --git a/x.png b/x.png\nBinary files a/x.png and b/x.png differ\n", "binary patches are not supported"},
+		{"rename", "diff --git a/a.go b/b.go\nrename from a.go\nrename to b.go\n", "renamed and copied files are not supported"},
+		{"truncated", "--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n-a\n", "is truncated"},
+		{"malformed header", "--- a/a.go\n+++ b/a.go\n@@ -x +1 @@\n", "malformed hunk header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePatch(tt.patch); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
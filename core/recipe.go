@@ -3,32 +3,48 @@ package core
 import (
 	"context"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 const DefaultRecipeMinConfidence = 0.85
 
 // Recipe is a named, repeatable transformation made from Morfx primitives.
 type Recipe struct {
-	Name          string       `json:"name"`
-	Description   string       `json:"description,omitempty"`
-	DryRun        bool         `json:"dry_run,omitempty"`
-	MinConfidence float64      `json:"min_confidence,omitempty"`
-	Steps         []RecipeStep `json:"steps"`
+	Name          string         `json:"name"`
+	Description   string         `json:"description,omitempty"`
+	DryRun        bool           `json:"dry_run,omitempty"`
+	Stage         bool           `json:"stage,omitempty"` // collect every step's edits in Changes instead of writing them
+	MinConfidence float64        `json:"min_confidence,omitempty"`
+	Verify        *VerifyCommand `json:"verify,omitempty"` // run once after the last applied step
+	Steps         []RecipeStep   `json:"steps"`
 }
 
 // RecipeStep defines one query plus transform operation over a file scope.
 type RecipeStep struct {
-	Name          string     `json:"name"`
-	Description   string     `json:"description,omitempty"`
-	Method        string     `json:"method"`
-	Scope         FileScope  `json:"scope"`
-	Target        AgentQuery `json:"target"`
-	TargetDSL     string     `json:"target_dsl,omitempty"`
-	Replacement   string     `json:"replacement,omitempty"`
-	Content       string     `json:"content,omitempty"`
-	MinConfidence float64    `json:"min_confidence,omitempty"`
-	Backup        bool       `json:"backup,omitempty"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
+	Method         string            `json:"method"`
+	Scope          FileScope         `json:"scope"`
+	Target         AgentQuery        `json:"target"`
+	TargetDSL      string            `json:"target_dsl,omitempty"`
+	Replacement    string            `json:"replacement,omitempty"`
+	Content        string            `json:"content,omitempty"`
+	Annotation     string            `json:"annotation,omitempty"`
+	TagKey         string            `json:"tag_key,omitempty"`
+	TypeHints      map[string]string `json:"type_hints,omitempty"`
+	MinConfidence  float64           `json:"min_confidence,omitempty"`
+	Backup         bool              `json:"backup,omitempty"`
+	Format         bool              `json:"format,omitempty"`
+	Overlap        string            `json:"overlap,omitempty"`
+	IfAbsent       bool              `json:"if_absent,omitempty"`
+	Attached       string            `json:"attached,omitempty"`
+	StructuralDiff bool              `json:"structural_diff,omitempty"`
+	ExpectMatches  MatchRange        `json:"expect_matches,omitempty"`
+	Verify         *VerifyCommand    `json:"verify,omitempty"`
 }
 
 // Rule is an alias for one reusable recipe step.
@@ -44,6 +60,9 @@ type RecipeResult struct {
 	TotalMatches   int                `json:"total_matches"`
 	TransactionIDs []string           `json:"transaction_ids,omitempty"`
 	Steps          []RecipeStepResult `json:"steps"`
+	FailedStep     string             `json:"failed_step,omitempty"`  // step whose verify command failed; empty for the recipe's
+	Verification   *VerifyResult      `json:"verification,omitempty"` // the failed verification
+	Changes        []FileChange       `json:"-"`                      // combined edits of a staged recipe, per file
 }
 
 // RecipeStepResult records the preflight/apply outcome for one recipe step.
@@ -61,6 +80,14 @@ type RecipeProcessor interface {
 	TransformFiles(context.Context, FileTransformOp) (*FileTransformResult, error)
 }
 
+// RecipeRollbacker is implemented by processors that write through
+// transactions and can undo one after it was committed. Applying a recipe
+// with a verify command needs one, to roll back every applied step.
+type RecipeRollbacker interface {
+	IsSafetyEnabled() bool
+	RollbackTransaction(txID string) error
+}
+
 // ValidateRecipe checks a recipe before execution.
 func ValidateRecipe(recipe Recipe) error {
 	if strings.TrimSpace(recipe.Name) == "" {
@@ -69,15 +96,31 @@ func ValidateRecipe(recipe Recipe) error {
 	if len(recipe.Steps) == 0 {
 		return fmt.Errorf("recipe must contain at least one step")
 	}
+	if err := recipe.Verify.Validate(); err != nil {
+		return err
+	}
+	if recipe.Stage && recipe.Verify != nil {
+		return fmt.Errorf("verify cannot be combined with stage: pass verify when applying the changeset")
+	}
 	for i, step := range recipe.Steps {
 		if err := validateRecipeStep(i, step); err != nil {
 			return err
 		}
+		if recipe.Stage && step.Verify != nil {
+			return fmt.Errorf("step %d verify cannot be combined with stage: pass verify when applying the changeset", i+1)
+		}
 	}
 	return nil
 }
 
 // ExecuteRecipe preflights every apply-mode step before mutating files.
+// A step's own verify command runs after that step; the recipe's runs once
+// after the last one, in its dir or the common parent of the step scopes.
+// When either fails, the transactions of the applied steps are rolled back
+// newest first, the recipe stops, and the partial result is returned with an
+// error wrapping ErrVerificationFailed. A staged recipe writes nothing: each
+// step is previewed on top of the previous steps' output and the combined
+// edits are returned in Changes.
 func ExecuteRecipe(ctx context.Context, processor RecipeProcessor, recipe Recipe) (*RecipeResult, error) {
 	if ctx == nil {
 		ctx = context.Background()
@@ -88,6 +131,10 @@ func ExecuteRecipe(ctx context.Context, processor RecipeProcessor, recipe Recipe
 	if err := ValidateRecipe(recipe); err != nil {
 		return nil, err
 	}
+	rollbacker, canRollback := processor.(RecipeRollbacker)
+	if recipeHasVerify(recipe) && !recipe.DryRun && !recipe.Stage && (!canRollback || !rollbacker.IsSafetyEnabled()) {
+		return nil, fmt.Errorf("recipe verify requires safety (transactions) to be enabled")
+	}
 
 	result := &RecipeResult{
 		Name:           recipe.Name,
@@ -95,10 +142,15 @@ func ExecuteRecipe(ctx context.Context, processor RecipeProcessor, recipe Recipe
 		TransactionIDs: make([]string, 0),
 		Steps:          make([]RecipeStepResult, 0, len(recipe.Steps)),
 	}
+	var overlay map[string]string
+	if recipe.Stage {
+		overlay = make(map[string]string)
+	}
 
 	for _, step := range recipe.Steps {
 		stepThreshold := recipeStepThreshold(recipe, step)
 		preflightOp := recipeStepOperation(step, true)
+		preflightOp.Overlay = maps.Clone(overlay)
 		preflight, err := processor.TransformFiles(ctx, preflightOp)
 		if err != nil {
 			return nil, fmt.Errorf("step %q preflight failed: %w", step.Name, err)
@@ -116,8 +168,14 @@ func ExecuteRecipe(ctx context.Context, processor RecipeProcessor, recipe Recipe
 		}
 
 		finalResult := preflight
-		if !recipe.DryRun {
+		if recipe.Stage {
+			result.Changes = MergeFileChanges(result.Changes, preflight)
+			for _, change := range result.Changes {
+				overlay[change.Path] = change.Modified
+			}
+		} else if !recipe.DryRun {
 			applyOp := recipeStepOperation(step, false)
+			applyOp.Verify = step.Verify
 			applied, err := processor.TransformFiles(ctx, applyOp)
 			if err != nil {
 				return nil, fmt.Errorf("step %q apply failed: %w", step.Name, err)
@@ -125,6 +183,14 @@ func ExecuteRecipe(ctx context.Context, processor RecipeProcessor, recipe Recipe
 			stepResult.DryRun = false
 			stepResult.AppliedResult = applied
 			finalResult = applied
+			if verification := applied.Verification; verification != nil && !verification.Passed {
+				result.Steps = append(result.Steps, stepResult)
+				result.FailedStep = step.Name
+				result.Verification = verification
+				// The processor rolled this step back; undo the earlier ones
+				rollbackRecipe(rollbacker, result.TransactionIDs, verification)
+				return result, fmt.Errorf("step %q %w: %s", step.Name, ErrVerificationFailed, verification.Summary())
+			}
 		}
 
 		result.StepsRun++
@@ -137,9 +203,79 @@ func ExecuteRecipe(ctx context.Context, processor RecipeProcessor, recipe Recipe
 		result.Steps = append(result.Steps, stepResult)
 	}
 
+	if recipe.Verify != nil && !recipe.DryRun && !recipe.Stage && result.FilesModified > 0 {
+		verification := recipe.Verify.Run(ctx, recipeVerifyDir(recipe))
+		if !verification.Passed {
+			verification.RolledBack = true
+			rollbackRecipe(rollbacker, result.TransactionIDs, &verification)
+			result.Verification = &verification
+			return result, fmt.Errorf("recipe %q %w: %s", recipe.Name, ErrVerificationFailed, verification.Summary())
+		}
+	}
+
 	return result, nil
 }
 
+// rollbackRecipe rolls back the committed transactions of a recipe's applied
+// steps, newest first, and records on verification whether it succeeded.
+func rollbackRecipe(rollbacker RecipeRollbacker, txIDs []string, verification *VerifyResult) {
+	var failed []string
+	for i := len(txIDs) - 1; i >= 0; i-- {
+		if err := rollbacker.RollbackTransaction(txIDs[i]); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", txIDs[i], err))
+		}
+	}
+	if len(failed) > 0 {
+		verification.RolledBack = false
+		verification.Error = "failed to roll back " + strings.Join(failed, "; ")
+	}
+}
+
+// recipeVerifyDir is where the recipe's verify command runs when it sets no
+// dir: the deepest directory holding every step's scope.
+func recipeVerifyDir(recipe Recipe) string {
+	var dir string
+	for i, step := range recipe.Steps {
+		path, err := filepath.Abs(step.Scope.Path)
+		if err != nil {
+			path = filepath.Clean(step.Scope.Path)
+		}
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			path = filepath.Dir(path)
+		}
+		if i == 0 {
+			dir = path
+			continue
+		}
+		for !withinDir(dir, path) {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	return dir
+}
+
+// withinDir reports whether path is dir or lies under it.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func recipeHasVerify(recipe Recipe) bool {
+	if recipe.Verify != nil {
+		return true
+	}
+	for _, step := range recipe.Steps {
+		if step.Verify != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func validateRecipeStep(index int, step RecipeStep) error {
 	prefix := fmt.Sprintf("step %d", index+1)
 	if strings.TrimSpace(step.Name) == "" {
@@ -164,10 +300,28 @@ func validateRecipeStep(index int, step RecipeStep) error {
 		if strings.TrimSpace(step.Replacement) == "" {
 			return fmt.Errorf("%s replacement is required", prefix)
 		}
-	case "insert_before", "insert_after", "append":
+	case "insert_before", "insert_after", "append", "set_docstring":
 		if strings.TrimSpace(step.Content) == "" {
 			return fmt.Errorf("%s content is required", prefix)
 		}
+	case "annotate":
+		if len(step.TypeHints) == 0 {
+			return fmt.Errorf("%s type_hints is required", prefix)
+		}
+	case "add_annotation", "add_tag", "remove_tag":
+		if strings.TrimSpace(step.Annotation) == "" {
+			return fmt.Errorf("%s annotation is required", prefix)
+		}
+	case "rewrite_tag":
+		if strings.TrimSpace(step.TagKey) == "" || strings.TrimSpace(step.Annotation) == "" {
+			return fmt.Errorf("%s tag_key and annotation are required", prefix)
+		}
+	}
+	if err := step.ExpectMatches.Validate(); err != nil {
+		return fmt.Errorf("%s %w", prefix, err)
+	}
+	if err := step.Verify.Validate(); err != nil {
+		return fmt.Errorf("%s %w", prefix, err)
 	}
 	if step.MinConfidence < 0 || step.MinConfidence > 1 {
 		return fmt.Errorf("%s min_confidence must be between 0 and 1", prefix)
@@ -177,7 +331,8 @@ func validateRecipeStep(index int, step RecipeStep) error {
 
 func isSupportedRecipeMethod(method string) bool {
 	switch method {
-	case "replace", "delete", "insert_before", "insert_after", "append":
+	case "replace", "delete", "insert_before", "insert_after", "append", "add_annotation", "remove_annotation",
+		"add_tag", "remove_tag", "rewrite_tag", "set_docstring", "annotate":
 		return true
 	default:
 		return false
@@ -195,10 +350,19 @@ func recipeStepOperation(step RecipeStep, dryRun bool) FileTransformOp {
 
 	return FileTransformOp{
 		TransformOp: TransformOp{
-			Method:      step.Method,
-			Target:      target,
-			Content:     step.Content,
-			Replacement: step.Replacement,
+			Method:         step.Method,
+			Target:         target,
+			Content:        step.Content,
+			Replacement:    step.Replacement,
+			Annotation:     step.Annotation,
+			TagKey:         step.TagKey,
+			TypeHints:      step.TypeHints,
+			Format:         step.Format,
+			Overlap:        step.Overlap,
+			IfAbsent:       step.IfAbsent,
+			Attached:       step.Attached,
+			StructuralDiff: step.StructuralDiff,
+			ExpectMatches:  step.ExpectMatches,
 		},
 		Scope:    step.Scope,
 		DryRun:   dryRun,
@@ -207,6 +371,21 @@ func recipeStepOperation(step RecipeStep, dryRun bool) FileTransformOp {
 	}
 }
 
+// RecipeVerifyTimeout is the time the recipe's verify commands may take
+// together, for callers that bound a whole recipe run.
+func RecipeVerifyTimeout(recipe Recipe) time.Duration {
+	var total time.Duration
+	if recipe.Verify != nil {
+		total += recipe.Verify.TimeoutDuration()
+	}
+	for _, step := range recipe.Steps {
+		if step.Verify != nil {
+			total += step.Verify.TimeoutDuration()
+		}
+	}
+	return total
+}
+
 func recipeStepThreshold(recipe Recipe, step RecipeStep) float64 {
 	if step.MinConfidence > 0 {
 		return step.MinConfidence
@@ -8,8 +8,16 @@ import (
 )
 
 type fakeRecipeProcessor struct {
-	results []*FileTransformResult
-	calls   []FileTransformOp
+	results    []*FileTransformResult
+	calls      []FileTransformOp
+	rolledBack []string
+}
+
+func (p *fakeRecipeProcessor) IsSafetyEnabled() bool { return true }
+
+func (p *fakeRecipeProcessor) RollbackTransaction(txID string) error {
+	p.rolledBack = append(p.rolledBack, txID)
+	return nil
 }
 
 func (p *fakeRecipeProcessor) TransformFiles(_ context.Context, op FileTransformOp) (*FileTransformResult, error) {
@@ -174,6 +182,86 @@ func TestValidateRecipeRejectsInvalidSteps(t *testing.T) {
 	}
 }
 
+func TestValidateRecipeRejectsInvalidExpectMatches(t *testing.T) {
+	err := ValidateRecipe(Recipe{
+		Name: "invalid",
+		Steps: []RecipeStep{{
+			Name:          "bad range",
+			Method:        "delete",
+			Scope:         FileScope{Path: ".", Language: "go"},
+			Target:        AgentQuery{Type: "function", Name: "Old"},
+			ExpectMatches: "3..1",
+		}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "step 1 invalid expect_matches") {
+		t.Fatalf("expected expect_matches validation error, got %v", err)
+	}
+}
+
+func TestValidateRecipeAnnotationSteps(t *testing.T) {
+	step := RecipeStep{
+		Name:      "tag fields",
+		Method:    "add_annotation",
+		Scope:     FileScope{Path: ".", Language: "go"},
+		TargetDSL: "field:*",
+	}
+	err := ValidateRecipe(Recipe{Name: "tags", Steps: []RecipeStep{step}})
+	if err == nil || !strings.Contains(err.Error(), "step 1 annotation is required") {
+		t.Fatalf("expected annotation validation error, got %v", err)
+	}
+
+	step.Annotation = `json:"${name|snake}"`
+	if err := ValidateRecipe(Recipe{Name: "tags", Steps: []RecipeStep{step}}); err != nil {
+		t.Fatalf("expected add_annotation step to validate, got %v", err)
+	}
+	if op := recipeStepOperation(step, true); op.Annotation != step.Annotation {
+		t.Fatalf("expected annotation to reach the operation, got %+v", op.TransformOp)
+	}
+}
+
+func TestValidateRecipeTagSteps(t *testing.T) {
+	step := RecipeStep{
+		Name:       "migrate json tags",
+		Method:     "rewrite_tag",
+		Scope:      FileScope{Path: ".", Language: "go"},
+		TargetDSL:  "field:* tag.json=*",
+		Annotation: `yaml:"${value}"`,
+	}
+	err := ValidateRecipe(Recipe{Name: "tags", Steps: []RecipeStep{step}})
+	if err == nil || !strings.Contains(err.Error(), "step 1 tag_key and annotation are required") {
+		t.Fatalf("expected tag_key validation error, got %v", err)
+	}
+
+	step.TagKey = "json"
+	if err := ValidateRecipe(Recipe{Name: "tags", Steps: []RecipeStep{step}}); err != nil {
+		t.Fatalf("expected rewrite_tag step to validate, got %v", err)
+	}
+	if op := recipeStepOperation(step, true); op.TagKey != "json" || op.Annotation != step.Annotation {
+		t.Fatalf("expected tag fields to reach the operation, got %+v", op.TransformOp)
+	}
+}
+
+func TestValidateRecipeTypeHintSteps(t *testing.T) {
+	step := RecipeStep{
+		Name:      "type loaders",
+		Method:    "annotate",
+		Scope:     FileScope{Path: ".", Language: "python"},
+		TargetDSL: "def:load_*",
+	}
+	err := ValidateRecipe(Recipe{Name: "typing", Steps: []RecipeStep{step}})
+	if err == nil || !strings.Contains(err.Error(), "step 1 type_hints is required") {
+		t.Fatalf("expected type_hints validation error, got %v", err)
+	}
+
+	step.TypeHints = map[string]string{"path": "str", "return": "bytes"}
+	if err := ValidateRecipe(Recipe{Name: "typing", Steps: []RecipeStep{step}}); err != nil {
+		t.Fatalf("expected annotate step to validate, got %v", err)
+	}
+	if op := recipeStepOperation(step, true); op.TypeHints["return"] != "bytes" {
+		t.Fatalf("expected type hints to reach the operation, got %+v", op.TransformOp)
+	}
+}
+
 func TestRecipeStepAcceptsTargetDSL(t *testing.T) {
 	processor := &fakeRecipeProcessor{
 		results: []*FileTransformResult{{
@@ -209,3 +297,80 @@ func TestRecipeStepAcceptsTargetDSL(t *testing.T) {
 		t.Fatalf("expected parsed structural target, got %+v", call.Target)
 	}
 }
+
+func TestExecuteRecipeStageChainsStepsWithoutApply(t *testing.T) {
+	processor := &fakeRecipeProcessor{
+		results: []*FileTransformResult{
+			{
+				FilesModified: 1,
+				TotalMatches:  1,
+				Confidence:    ConfidenceScore{Score: 0.96, Level: "high"},
+				Files: []FileTransformDetail{{
+					FilePath: "/repo/a.go", Modified: true, MatchCount: 1,
+					OriginalContent: "old\n", ModifiedContent: "mid\n",
+					Confidence: ConfidenceScore{Score: 0.96, Level: "high"},
+				}},
+			},
+			{
+				FilesModified: 1,
+				TotalMatches:  1,
+				Confidence:    ConfidenceScore{Score: 0.9, Level: "high"},
+				Files: []FileTransformDetail{{
+					FilePath: "/repo/a.go", Modified: true, MatchCount: 1,
+					OriginalContent: "mid\n", ModifiedContent: "new\n",
+					Confidence: ConfidenceScore{Score: 0.9, Level: "high"},
+				}},
+			},
+		},
+	}
+	step := RecipeStep{
+		Method:      "replace",
+		Scope:       FileScope{Path: "/repo"},
+		Target:      AgentQuery{Type: "function", Name: "Old"},
+		Replacement: "func New() {}",
+	}
+	first, second := step, step
+	first.Name, second.Name = "first", "second"
+
+	result, err := ExecuteRecipe(context.Background(), processor, Recipe{
+		Name:  "staged",
+		Stage: true,
+		Steps: []RecipeStep{first, second},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteRecipe returned error: %v", err)
+	}
+
+	if len(processor.calls) != 2 {
+		t.Fatalf("expected one preview per step and no apply, got %d calls", len(processor.calls))
+	}
+	for _, call := range processor.calls {
+		if !call.DryRun {
+			t.Fatal("staged recipes must not write files")
+		}
+	}
+	if got := processor.calls[1].Overlay["/repo/a.go"]; got != "mid\n" {
+		t.Fatalf("expected the second step to see the first step's edit, got %q", got)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].Original != "old\n" || result.Changes[0].Modified != "new\n" {
+		t.Fatalf("expected one combined change, got %+v", result.Changes)
+	}
+}
+
+func TestValidateRecipeRejectsStageWithVerify(t *testing.T) {
+	err := ValidateRecipe(Recipe{
+		Name:   "staged",
+		Stage:  true,
+		Verify: &VerifyCommand{Command: "go test ./..."},
+		Steps: []RecipeStep{{
+			Name:        "replace",
+			Method:      "replace",
+			Scope:       FileScope{Path: "."},
+			Target:      AgentQuery{Type: "function", Name: "Old"},
+			Replacement: "func New() {}",
+		}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined with stage") {
+		t.Fatalf("expected stage with verify to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,338 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/oxhq/morfx/internal/securefs"
+)
+
+// danglingImpactPerReference is the confidence lost for each remaining
+// reference to a deleted or changed declaration, down to maxDanglingImpact.
+const (
+	danglingImpactPerReference = -0.2
+	maxDanglingImpact          = -0.6
+)
+
+// referenceRootMarkers identify the root of a workspace when scanning for
+// references from a single file.
+var referenceRootMarkers = []string{".git", "go.mod", "package.json", "pyproject.toml", "composer.json"}
+
+// referenceExcludes are directories a reference scan never descends into.
+var referenceExcludes = []string{"**/.git", "**/node_modules", "**/vendor", "**/.morfx"}
+
+// referenceScanLimit caps the files one reference scan reads when its scope
+// sets no max_files, so an edit in a large repository stays responsive.
+const referenceScanLimit = 5000
+
+// ReferenceFinder is implemented by providers that can locate the
+// identifiers spelled like a name, ignoring comments, strings, and the
+// declarations of the name itself.
+type ReferenceFinder interface {
+	FindReferences(source, name string) []Location
+}
+
+// PackageReferenceFinder is implemented by providers whose names are scoped
+// to a package directory, as in Go. Files of the package use a name bare;
+// files elsewhere only see exported names, through the qualifier they
+// import the package under, so a common name such as New or Close in an
+// unrelated package is not taken for a reference.
+type PackageReferenceFinder interface {
+	// ReferencePackage returns the import path and name of the package the
+	// file at path, holding source, belongs to. The import path is empty
+	// when no other package can import it. Providers without packages
+	// return ErrPackageScopeUnsupported.
+	ReferencePackage(path, source string) (importPath, name string, err error)
+	// IsExported reports whether name is visible outside its package.
+	IsExported(name string) bool
+	// FindQualifiedReferences locates name in source, a file of another
+	// package, used through the package at importPath called packageName.
+	FindQualifiedReferences(source, importPath, packageName, name string) []Location
+}
+
+// DanglingReference is a remaining use of a name that an edit deleted,
+// renamed, or changed the signature of.
+type DanglingReference struct {
+	Name     string `json:"name"`
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func (r DanglingReference) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", r.FilePath, r.Line, r.Column, r.Name)
+}
+
+// ReferenceScope returns the scope a single-file edit is checked against:
+// the nearest enclosing directory holding .git, go.mod, package.json,
+// pyproject.toml, or composer.json, or the file's own directory.
+func ReferenceScope(path string) FileScope {
+	dir := filepath.Dir(path)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	root := dir
+	for candidate := dir; ; {
+		if hasReferenceRootMarker(candidate) {
+			root = candidate
+			break
+		}
+		parent := filepath.Dir(candidate)
+		if parent == candidate {
+			break
+		}
+		candidate = parent
+	}
+	return FileScope{Path: root}
+}
+
+func hasReferenceRootMarker(dir string) bool {
+	for _, marker := range referenceRootMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyReferenceCheck looks through the files in scope for references to
+// the declarations a transform deleted or changed the signature of, as
+// listed in result.Metadata["affected_names"]. The edited file at path is
+// read as modified. Any found lower confidence through a
+// dangling_references factor and are recorded under
+// Metadata["dangling_references"]. It returns the references found.
+func (fp *FileProcessor) ApplyReferenceCheck(ctx context.Context, scope FileScope, language, path string, result *TransformResult) []DanglingReference {
+	names := affectedNames(result)
+	if len(names) == 0 {
+		return nil
+	}
+	references := fp.findReferences(ctx, scope, language, names, map[string]string{path: result.Modified})
+	return applyDanglingReferences(result, names, references)
+}
+
+// checkReferences is ApplyReferenceCheck for every result of one
+// TransformFiles call. The scope is walked once per language for the names
+// all of its edits affected, with every edited file read as modified, and
+// each detail gets the references to its own names.
+func (fp *FileProcessor) checkReferences(ctx context.Context, scope FileScope, details []FileTransformDetail, results []*TransformResult) {
+	type languageEdits struct {
+		names  []string
+		edited map[string]string
+	}
+	byLanguage := make(map[string]*languageEdits)
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		edits := byLanguage[details[i].Language]
+		if edits == nil {
+			edits = &languageEdits{edited: make(map[string]string)}
+			byLanguage[details[i].Language] = edits
+		}
+		edits.edited[details[i].FilePath] = result.Modified
+		for _, name := range affectedNames(result) {
+			if !slices.Contains(edits.names, name) {
+				edits.names = append(edits.names, name)
+			}
+		}
+	}
+
+	for language, edits := range byLanguage {
+		if len(edits.names) == 0 {
+			continue
+		}
+		references := fp.findReferences(ctx, scope, language, edits.names, edits.edited)
+		for i, result := range results {
+			if result != nil && details[i].Language == language {
+				details[i].DanglingReferences = applyDanglingReferences(result, affectedNames(result), references)
+			}
+		}
+	}
+}
+
+// affectedNames returns the declarations result deleted or changed the
+// signature of.
+func affectedNames(result *TransformResult) []string {
+	if result == nil || result.Error != nil {
+		return nil
+	}
+	names, _ := result.Metadata["affected_names"].([]string)
+	return names
+}
+
+// referencePackage is the package an edited file belongs to.
+type referencePackage struct {
+	importPath, name string
+}
+
+// referencePackages returns the packages of the edited files keyed by their
+// absolute directory, or nil when the provider does not scope names to
+// packages.
+func referencePackages(finder PackageReferenceFinder, edited map[string]string) map[string]referencePackage {
+	if finder == nil {
+		return nil
+	}
+	packages := make(map[string]referencePackage, len(edited))
+	for path, content := range edited {
+		importPath, name, err := finder.ReferencePackage(path, content)
+		if errors.Is(err, ErrPackageScopeUnsupported) {
+			return nil
+		}
+		packages[filepath.Dir(absPath(path))] = referencePackage{importPath: importPath, name: name}
+	}
+	return packages
+}
+
+// findReferences walks scope once for references to names in files of
+// language, sorted by position. Files in edited, keyed by path, are read as
+// the content given, even when the scope leaves them out. For providers
+// that scope names to packages, files outside the edited packages are only
+// searched for qualified uses of exported names, and not read at all when
+// every name is unexported.
+func (fp *FileProcessor) findReferences(ctx context.Context, scope FileScope, language string, names []string, edited map[string]string) []DanglingReference {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	provider, ok := fp.providers.Get(language)
+	if !ok {
+		return nil
+	}
+	finder, ok := provider.(ReferenceFinder)
+	if !ok {
+		return nil
+	}
+	packageFinder, _ := provider.(PackageReferenceFinder)
+	packages := referencePackages(packageFinder, edited)
+	var exported []string
+	for _, name := range names {
+		if packages == nil || packageFinder.IsExported(name) {
+			exported = append(exported, name)
+		}
+	}
+	// Unexported names of one package never leave its directory
+	if packages != nil && len(exported) == 0 && len(packages) == 1 {
+		for dir := range packages {
+			scope = FileScope{Path: dir, MaxDepth: 1, FollowSymlinks: scope.FollowSymlinks}
+		}
+	}
+	if scope.MaxFiles == 0 {
+		scope.MaxFiles = referenceScanLimit
+	}
+	scope.Exclude = append(append([]string(nil), scope.Exclude...), referenceExcludes...)
+	walkResults, err := fp.walker.Walk(ctx, scope)
+	if err != nil {
+		return nil
+	}
+
+	var references []DanglingReference
+	add := func(filePath, name string, locations []Location) {
+		for _, location := range locations {
+			references = append(references, DanglingReference{
+				Name:     name,
+				FilePath: filePath,
+				Line:     location.Line,
+				Column:   location.Column,
+			})
+		}
+	}
+	find := func(filePath, source string) {
+		if _, inPackage := packages[filepath.Dir(absPath(filePath))]; packages == nil || inPackage {
+			for _, name := range names {
+				add(filePath, name, finder.FindReferences(source, name))
+			}
+			return
+		}
+		for _, pkg := range packages {
+			if pkg.importPath == "" {
+				continue
+			}
+			for _, name := range exported {
+				add(filePath, name, packageFinder.FindQualifiedReferences(source, pkg.importPath, pkg.name, name))
+			}
+		}
+	}
+
+	editedPaths := make(map[string]bool, len(edited))
+	for path, content := range edited {
+		editedPaths[absPath(path)] = true
+		find(path, content)
+	}
+	for walked := range walkResults {
+		if walked.Error != nil || walked.Language != language || editedPaths[absPath(walked.Path)] {
+			continue
+		}
+		if _, inPackage := packages[filepath.Dir(absPath(walked.Path))]; packages != nil && !inPackage && len(exported) == 0 {
+			continue
+		}
+		content, err := securefs.ReadFile(walked.Path)
+		if err != nil {
+			continue
+		}
+		find(walked.Path, string(content))
+	}
+
+	sort.Slice(references, func(i, j int) bool {
+		a, b := references[i], references[j]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return references
+}
+
+// applyDanglingReferences lowers result's confidence for those of
+// references that name one of names and records them under
+// Metadata["dangling_references"], returning them.
+func applyDanglingReferences(result *TransformResult, names []string, all []DanglingReference) []DanglingReference {
+	var references []DanglingReference
+	for _, reference := range all {
+		if slices.Contains(names, reference.Name) {
+			references = append(references, reference)
+		}
+	}
+	if len(references) == 0 {
+		return nil
+	}
+
+	listed := make([]string, 0, 5)
+	for _, reference := range references[:min(len(references), 5)] {
+		listed = append(listed, reference.String())
+	}
+	if len(references) > len(listed) {
+		listed = append(listed, fmt.Sprintf("and %d more", len(references)-len(listed)))
+	}
+	factor := ConfidenceFactor{
+		Name:   "dangling_references",
+		Impact: math.Max(maxDanglingImpact, danglingImpactPerReference*float64(len(references))),
+		Reason: fmt.Sprintf("%d reference(s) remain to %s: %s", len(references), strings.Join(names, ", "), strings.Join(listed, "; ")),
+	}
+	score := math.Max(0, math.Min(1, unclampedScore(result.Confidence)+factor.Impact))
+	result.Confidence = ConfidenceScore{
+		Score:   score,
+		Level:   confidenceLevel(score),
+		Factors: append(append([]ConfidenceFactor(nil), result.Confidence.Factors...), factor),
+	}
+	result.Metadata["dangling_references"] = references
+	return references
+}
+
+func absPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,236 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// referenceProvider deletes "func helper() {}" and finds references by
+// looking for calls.
+type referenceProvider struct {
+	MockProvider
+	finds atomic.Int64 // FindReferences calls made
+}
+
+func (p *referenceProvider) Transform(source string, op TransformOp) TransformResult {
+	if !strings.Contains(source, "func helper() {}") {
+		return TransformResult{Error: ErrNoMatchesFound}
+	}
+	return TransformResult{
+		Modified:   strings.Replace(source, "func helper() {}\n", "", 1),
+		MatchCount: 1,
+		Confidence: ConfidenceScore{Score: 1, Level: "high"},
+		Metadata:   map[string]any{"affected_names": []string{"helper"}},
+	}
+}
+
+func (p *referenceProvider) FindReferences(source, name string) []Location {
+	p.finds.Add(1)
+	var locations []Location
+	for i, line := range strings.Split(source, "\n") {
+		if column := strings.Index(line, name+"("); column >= 0 && !strings.HasPrefix(line, "func ") {
+			locations = append(locations, Location{Line: i + 1, Column: column + 1})
+		}
+	}
+	return locations
+}
+
+func TestFileProcessor_TransformFiles_ReportsDanglingReferences(t *testing.T) {
+	registry := &MockProviderRegistry{providers: map[string]Provider{
+		"go": &referenceProvider{MockProvider: MockProvider{language: "go"}},
+	}}
+	processor := NewFileProcessor(registry)
+	processor.EnableSafety(false)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"helper.go": "package main\n\nfunc helper() {}\n\nfunc run() {\n\thelper()\n}\n",
+		"main.go":   "package main\n\nfunc main() {\n\thelper()\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to seed %s: %v", name, err)
+		}
+	}
+
+	result, err := processor.TransformFiles(context.Background(), FileTransformOp{
+		TransformOp: TransformOp{Method: "delete", Target: AgentQuery{Type: "function", Name: "helper"}},
+		Scope:       FileScope{Path: dir, Include: []string{"*.go"}, Language: "go"},
+		DryRun:      true,
+	})
+	if err != nil {
+		t.Fatalf("TransformFiles returned error: %v", err)
+	}
+
+	var detail *FileTransformDetail
+	for i := range result.Files {
+		if filepath.Base(result.Files[i].FilePath) == "helper.go" {
+			detail = &result.Files[i]
+		}
+	}
+	if detail == nil {
+		t.Fatalf("expected a detail for helper.go, got %+v", result.Files)
+	}
+	if len(detail.DanglingReferences) != 2 {
+		t.Fatalf("expected references in both files, got %+v", detail.DanglingReferences)
+	}
+	if got := filepath.Base(detail.DanglingReferences[1].FilePath); got != "main.go" || detail.DanglingReferences[1].Line != 4 {
+		t.Fatalf("expected the call in main.go to be reported, got %+v", detail.DanglingReferences[1])
+	}
+
+	var factor *ConfidenceFactor
+	for i := range detail.Confidence.Factors {
+		if detail.Confidence.Factors[i].Name == "dangling_references" {
+			factor = &detail.Confidence.Factors[i]
+		}
+	}
+	if factor == nil || factor.Impact != 2*danglingImpactPerReference {
+		t.Fatalf("expected a dangling_references factor for two references, got %+v", detail.Confidence.Factors)
+	}
+	if detail.Confidence.Score >= 0.85 {
+		t.Fatalf("expected confidence below the auto-apply threshold, got %.2f", detail.Confidence.Score)
+	}
+}
+
+func TestFileProcessor_TransformFiles_ScansReferencesOnce(t *testing.T) {
+	provider := &referenceProvider{MockProvider: MockProvider{language: "go"}}
+	registry := &MockProviderRegistry{providers: map[string]Provider{"go": provider}}
+	processor := NewFileProcessor(registry)
+	processor.EnableSafety(false)
+
+	dir := t.TempDir()
+	files := map[string]string{"main.go": "package main\n\nfunc main() {\n\thelper()\n}\n"}
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		files[name] = "package main\n\nfunc helper() {}\n"
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to seed %s: %v", name, err)
+		}
+	}
+
+	result, err := processor.TransformFiles(context.Background(), FileTransformOp{
+		TransformOp: TransformOp{Method: "delete", Target: AgentQuery{Type: "function", Name: "helper"}},
+		Scope:       FileScope{Path: dir, Include: []string{"*.go"}, Language: "go"},
+		DryRun:      true,
+	})
+	if err != nil {
+		t.Fatalf("TransformFiles returned error: %v", err)
+	}
+	if result.FilesModified != 3 {
+		t.Fatalf("expected 3 edited files, got %d", result.FilesModified)
+	}
+	// One scan reads each of the four files once, however many were edited
+	if calls := provider.finds.Load(); calls != 4 {
+		t.Fatalf("expected 4 FindReferences calls, got %d", calls)
+	}
+	for _, file := range result.Files {
+		if file.Modified && (len(file.DanglingReferences) != 1 || filepath.Base(file.DanglingReferences[0].FilePath) != "main.go") {
+			t.Fatalf("expected %s to report the call in main.go, got %+v", file.FilePath, file.DanglingReferences)
+		}
+	}
+}
+
+func TestApplyReferenceCheckIgnoresUnaffectedEdits(t *testing.T) {
+	registry := &MockProviderRegistry{providers: map[string]Provider{
+		"go": &referenceProvider{MockProvider: MockProvider{language: "go"}},
+	}}
+	processor := NewFileProcessor(registry)
+
+	result := TransformResult{Modified: "package main\n", Confidence: ConfidenceScore{Score: 0.9, Level: "high"}}
+	if references := processor.ApplyReferenceCheck(context.Background(), FileScope{Path: t.TempDir()}, "go", "main.go", &result); references != nil {
+		t.Fatalf("expected no scan without affected names, got %+v", references)
+	}
+	if result.Confidence.Score != 0.9 || len(result.Confidence.Factors) != 0 {
+		t.Fatalf("expected confidence to be left alone, got %+v", result.Confidence)
+	}
+}
+
+func TestReferenceScopeFindsWorkspaceRoot(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "pkg", "util")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("failed to create dirs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/x\n"), 0o644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+
+	if got := ReferenceScope(filepath.Join(nested, "util.go")).Path; got != root {
+		t.Fatalf("ReferenceScope = %s, want %s", got, root)
+	}
+}
+
+// packageReferenceProvider scopes names to the directory of the file, like
+// Go: every directory is the package example.com/<dir> named after it.
+type packageReferenceProvider struct {
+	referenceProvider
+}
+
+func (p *packageReferenceProvider) ReferencePackage(path, source string) (string, string, error) {
+	name := filepath.Base(filepath.Dir(path))
+	return "example.com/" + name, name, nil
+}
+
+func (p *packageReferenceProvider) IsExported(name string) bool {
+	return name != "" && strings.ToUpper(name[:1]) == name[:1]
+}
+
+func (p *packageReferenceProvider) FindQualifiedReferences(source, importPath, packageName, name string) []Location {
+	if !strings.Contains(source, `"`+importPath+`"`) {
+		return nil
+	}
+	return p.FindReferences(source, packageName+"."+name)
+}
+
+func TestApplyReferenceCheckScopesNamesToPackages(t *testing.T) {
+	provider := &packageReferenceProvider{referenceProvider{MockProvider: MockProvider{language: "go"}}}
+	registry := &MockProviderRegistry{providers: map[string]Provider{"go": provider}}
+	processor := NewFileProcessor(registry)
+
+	root := t.TempDir()
+	files := map[string]string{
+		"lib/lib.go":   "package lib\n",
+		"lib/use.go":   "package lib\n\nfunc use() {\n\tNew()\n\thelper()\n}\n",
+		"app/main.go":  "package main\n\nimport \"example.com/lib\"\n\nfunc main() {\n\tlib.New()\n\thelper()\n}\n",
+		"other/run.go": "package other\n\nfunc run() {\n\tNew()\n\tClose()\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to seed %s: %v", name, err)
+		}
+	}
+	path := filepath.Join(root, "lib", "lib.go")
+	check := func(name string) []DanglingReference {
+		result := TransformResult{
+			Modified:   "package lib\n",
+			Confidence: ConfidenceScore{Score: 1, Level: "high"},
+			Metadata:   map[string]any{"affected_names": []string{name}},
+		}
+		return processor.ApplyReferenceCheck(context.Background(), FileScope{Path: root}, "go", path, &result)
+	}
+
+	// An exported name is found bare in its package and qualified elsewhere
+	exported := check("New")
+	if len(exported) != 2 || filepath.Base(exported[0].FilePath) != "main.go" || exported[0].Line != 6 || filepath.Base(exported[1].FilePath) != "use.go" {
+		t.Fatalf("expected lib.New in app and New in lib, got %+v", exported)
+	}
+
+	// An unexported name never leaves its package, so no other file is read
+	provider.finds.Store(0)
+	unexported := check("helper")
+	if len(unexported) != 1 || filepath.Base(unexported[0].FilePath) != "use.go" {
+		t.Fatalf("expected helper only in lib, got %+v", unexported)
+	}
+	if calls := provider.finds.Load(); calls != 2 {
+		t.Fatalf("expected only the two lib files to be searched, got %d FindReferences calls", calls)
+	}
+}
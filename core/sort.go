@@ -0,0 +1,26 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sort keys order the members a sort operation matched. A key starting with
+// "$" sorts by that DSL capture.
+const (
+	SortByName       = "name"
+	SortByVisibility = "visibility"
+	SortByKind       = "kind"
+)
+
+// ValidateSortBy checks a sort key before anything is parsed.
+func ValidateSortBy(sortBy string) error {
+	switch {
+	case sortBy == "", sortBy == SortByName, sortBy == SortByVisibility, sortBy == SortByKind:
+		return nil
+	case strings.HasPrefix(sortBy, "$") && len(sortBy) > 1:
+		return nil
+	default:
+		return fmt.Errorf("unknown sort key %q (want name, visibility, kind, or a $capture)", sortBy)
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// caseTransforms rewrite a value inside a ${var|transform} placeholder.
+var caseTransforms = map[string]func(string) string{
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"snake":  func(s string) string { return strings.Join(lowerWords(s), "_") },
+	"kebab":  func(s string) string { return strings.Join(lowerWords(s), "-") },
+	"camel":  camelCase,
+	"pascal": pascalCase,
+}
+
+// ExpandTemplate replaces ${var} and ${var|transform} placeholders in text
+// with values from vars. Transforms are lower, upper, snake, kebab, camel,
+// and pascal, and can be chained as ${name|snake|upper}.
+func ExpandTemplate(text string, vars map[string]string) (string, error) {
+	var out strings.Builder
+	for {
+		open := strings.Index(text, "${")
+		if open < 0 {
+			out.WriteString(text)
+			return out.String(), nil
+		}
+		closing := strings.Index(text[open:], "}")
+		if closing < 0 {
+			return "", fmt.Errorf("unterminated placeholder in %q", text)
+		}
+		out.WriteString(text[:open])
+
+		parts := strings.Split(text[open+2:open+closing], "|")
+		name := strings.TrimSpace(parts[0])
+		value, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("unknown placeholder variable %q", name)
+		}
+		for _, transform := range parts[1:] {
+			apply, ok := caseTransforms[strings.TrimSpace(transform)]
+			if !ok {
+				return "", fmt.Errorf("unknown case transform %q (want lower, upper, snake, kebab, camel, or pascal)", strings.TrimSpace(transform))
+			}
+			value = apply(value)
+		}
+		out.WriteString(value)
+		text = text[open+closing+1:]
+	}
+}
+
+// splitWords breaks an identifier into words at underscores, hyphens,
+// spaces, and case changes, keeping acronyms together: "HTTPServerID"
+// becomes HTTP, Server, ID.
+func splitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			words = append(words, string(runes[start:end]))
+		}
+		start = -1
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush(i)
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := runes[i-1]
+		switch {
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			flush(i)
+			start = i
+		case unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			flush(i)
+			start = i
+		}
+	}
+	flush(len(runes))
+	return words
+}
+
+func lowerWords(s string) []string {
+	words := splitWords(s)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return words
+}
+
+func camelCase(s string) string {
+	words := lowerWords(s)
+	for i := 1; i < len(words); i++ {
+		words[i] = capitalize(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+func pascalCase(s string) string {
+	words := lowerWords(s)
+	for i, word := range words {
+		words[i] = capitalize(word)
+	}
+	return strings.Join(words, "")
+}
+
+func capitalize(word string) string {
+	runes := []rune(word)
+	if len(runes) == 0 {
+		return word
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandTemplateCaseTransforms(t *testing.T) {
+	vars := map[string]string{"name": "HTTPServerID", "x": "userName"}
+	cases := map[string]string{
+		`json:"${name|snake}"`: `json:"http_server_id"`,
+		"${name|kebab}":        "http-server-id",
+		"${name|camel}":        "httpServerId",
+		"${x|pascal}":          "UserName",
+		"${x|snake|upper}":     "USER_NAME",
+		"${x}-${name|lower}":   "userName-httpserverid",
+		"no placeholders":      "no placeholders",
+	}
+	for text, want := range cases {
+		got, err := ExpandTemplate(text, vars)
+		if err != nil {
+			t.Fatalf("ExpandTemplate(%q) returned error: %v", text, err)
+		}
+		if got != want {
+			t.Errorf("ExpandTemplate(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestExpandTemplateRejectsUnknownNames(t *testing.T) {
+	cases := map[string]string{
+		"${missing}":    "unknown placeholder variable",
+		"${name|shout}": "unknown case transform",
+		"${name|snake":  "unterminated placeholder",
+	}
+	for text, want := range cases {
+		_, err := ExpandTemplate(text, map[string]string{"name": "Name"})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ExpandTemplate(%q) error = %v, want %q", text, err, want)
+		}
+	}
+}
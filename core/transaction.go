@@ -218,6 +218,27 @@ func (tm *TransactionManager) RollbackTransaction() error {
 	return nil
 }
 
+// ReopenTransaction makes a committed transaction current again, so
+// RollbackTransaction can undo it from its backups after the fact.
+func (tm *TransactionManager) ReopenTransaction(txID string) (*TransactionLog, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if tm.currentTx != nil {
+		return nil, fmt.Errorf("transaction already in progress: %s", tm.currentTx.ID)
+	}
+
+	tx, err := tm.LoadTransaction(txID)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Status != "committed" {
+		return nil, fmt.Errorf("transaction %s is %s, not committed", txID, tx.Status)
+	}
+
+	tm.currentTx = tx
+	return tx, nil
+}
+
 // rollbackOperation reverts a single operation
 func (tm *TransactionManager) rollbackOperation(op TransactionOperation) error {
 	switch op.Type {
@@ -327,6 +327,62 @@ func TestTransactionManager_RollbackTransaction(t *testing.T) {
 	}
 }
 
+func TestTransactionManager_ReopenTransaction(t *testing.T) {
+	tempDir := t.TempDir()
+	manager := NewTransactionManager(filepath.Join(tempDir, "tx_logs"), NewAtomicWriter(DefaultAtomicConfig()))
+
+	tx, err := manager.BeginTransaction("Test reopen")
+	if err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	testFile := filepath.Join(tempDir, "test.txt")
+	if err := os.WriteFile(testFile, []byte("original content"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if _, err := manager.AddOperation("modify", testFile); err != nil {
+		t.Fatalf("AddOperation failed: %v", err)
+	}
+	if err := os.WriteFile(testFile, []byte("modified content"), 0o644); err != nil {
+		t.Fatalf("Failed to modify test file: %v", err)
+	}
+	if err := manager.CompleteOperation(testFile, nil); err != nil {
+		t.Fatalf("CompleteOperation failed: %v", err)
+	}
+	if err := manager.CommitTransaction(); err != nil {
+		t.Fatalf("CommitTransaction failed: %v", err)
+	}
+
+	// A later manager can still undo the committed transaction
+	later := NewTransactionManager(filepath.Join(tempDir, "tx_logs"), NewAtomicWriter(DefaultAtomicConfig()))
+	if _, err := later.ReopenTransaction(tx.ID); err != nil {
+		t.Fatalf("ReopenTransaction failed: %v", err)
+	}
+	if err := later.RollbackTransaction(); err != nil {
+		t.Fatalf("RollbackTransaction failed: %v", err)
+	}
+
+	content, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read file after rollback: %v", err)
+	}
+	if string(content) != "original content" {
+		t.Errorf("Expected content 'original content', got '%s'", string(content))
+	}
+
+	loaded, err := later.LoadTransaction(tx.ID)
+	if err != nil {
+		t.Fatalf("LoadTransaction failed: %v", err)
+	}
+	if loaded.Status != "rolled_back" {
+		t.Errorf("Expected status 'rolled_back', got '%s'", loaded.Status)
+	}
+
+	// Only committed transactions can be reopened
+	if _, err := later.ReopenTransaction(tx.ID); err == nil {
+		t.Error("Expected reopening a rolled back transaction to fail")
+	}
+}
+
 func TestTransactionManager_LoadTransaction(t *testing.T) {
 	tempDir := t.TempDir()
 	logDir := filepath.Join(tempDir, "tx_logs")
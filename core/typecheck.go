@@ -0,0 +1,63 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TypeCheckEnv enables type-check validation when set to a true value.
+const TypeCheckEnv = "MORFX_TYPECHECK"
+
+// typeCheckImpact is the confidence lost when an edit introduces type errors.
+const typeCheckImpact = -0.4
+
+// TypeChecker is implemented by providers that can type-check a file in the
+// context of the package it belongs to. Syntax validation passes edits that
+// reference undefined names or break callers; a type check catches them.
+type TypeChecker interface {
+	// TypeCheck checks the package containing path with modified in place of
+	// original and returns the errors the edit introduced. Errors already
+	// present in original are not reported. An empty path checks the source
+	// on its own. Providers without a checker for the language return
+	// ErrTypeCheckUnsupported.
+	TypeCheck(path, original, modified string) ([]string, error)
+}
+
+// ApplyTypeCheck type-checks result.Modified and records the outcome on
+// result: a confidence factor, and the introduced errors under
+// Metadata["type_errors"]. It returns the introduced errors.
+func ApplyTypeCheck(checker TypeChecker, path, original string, result *TransformResult) []string {
+	if checker == nil || result.Error != nil || result.Modified == original {
+		return nil
+	}
+	typeErrors, err := checker.TypeCheck(path, original, result.Modified)
+	if errors.Is(err, ErrTypeCheckUnsupported) {
+		return nil
+	}
+
+	factor := ConfidenceFactor{Name: "type_checked", Reason: "Type check found no new errors"}
+	switch {
+	case err != nil:
+		factor = ConfidenceFactor{Name: "type_check_skipped", Reason: fmt.Sprintf("Type check could not run: %v", err)}
+	case len(typeErrors) > 0:
+		factor = ConfidenceFactor{
+			Name:   "type_check_failed",
+			Impact: typeCheckImpact,
+			Reason: fmt.Sprintf("Edit introduces %d type error(s): %s", len(typeErrors), strings.Join(typeErrors, "; ")),
+		}
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]any)
+		}
+		result.Metadata["type_errors"] = typeErrors
+	}
+
+	score := math.Max(0, math.Min(1, unclampedScore(result.Confidence)+factor.Impact))
+	result.Confidence = ConfidenceScore{
+		Score:   score,
+		Level:   confidenceLevel(score),
+		Factors: append(append([]ConfidenceFactor(nil), result.Confidence.Factors...), factor),
+	}
+	return typeErrors
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+type stubTypeChecker struct {
+	errors []string
+	err    error
+}
+
+func (s stubTypeChecker) TypeCheck(path, original, modified string) ([]string, error) {
+	return s.errors, s.err
+}
+
+type typeCheckingProvider struct {
+	MockProvider
+	stubTypeChecker
+}
+
+func TestApplyTypeCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		checker stubTypeChecker
+		score   float64
+		factor  string
+	}{
+		{name: "clean", checker: stubTypeChecker{}, score: 0.9, factor: "type_checked"},
+		{name: "new errors", checker: stubTypeChecker{errors: []string{"a.go:3:1: undefined: x"}}, score: 0.5, factor: "type_check_failed"},
+		{name: "checker failed", checker: stubTypeChecker{err: errors.New("boom")}, score: 0.9, factor: "type_check_skipped"},
+		{name: "unsupported", checker: stubTypeChecker{err: ErrTypeCheckUnsupported}, score: 0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := TransformResult{Modified: "package q", Confidence: ConfidenceScore{Score: 0.9, Level: "high"}}
+			got := ApplyTypeCheck(tt.checker, "a.go", "package p", &result)
+			if !slices.Equal(got, tt.checker.errors) {
+				t.Fatalf("ApplyTypeCheck = %q, want %q", got, tt.checker.errors)
+			}
+			if math.Abs(result.Confidence.Score-tt.score) > 1e-9 {
+				t.Fatalf("Score = %.2f, want %.2f", result.Confidence.Score, tt.score)
+			}
+			names := factorNames(result.Confidence)
+			if tt.factor == "" && len(names) != 0 || tt.factor != "" && !slices.Equal(names, []string{tt.factor}) {
+				t.Fatalf("factors = %q, want %q", names, tt.factor)
+			}
+			if _, ok := result.Metadata["type_errors"]; ok != (len(tt.checker.errors) > 0) {
+				t.Fatalf("type_errors metadata = %v", result.Metadata["type_errors"])
+			}
+		})
+	}
+}
+
+func TestFileProcessorTypeChecksWhenEnabled(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package p"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	registry := &MockProviderRegistry{providers: map[string]Provider{
+		"go": &typeCheckingProvider{
+			MockProvider: MockProvider{
+				language: "go",
+				transformResult: TransformResult{
+					Modified:   "package q",
+					MatchCount: 1,
+					Confidence: ConfidenceScore{Score: 0.9, Level: "high"},
+				},
+			},
+			stubTypeChecker: stubTypeChecker{errors: []string{"a.go:1:9: undefined: q"}},
+		},
+	}}
+	op := FileTransformOp{
+		TransformOp: TransformOp{Method: "replace", Target: AgentQuery{Type: "function", Name: "f"}},
+		Scope:       FileScope{Path: dir, Include: []string{"**/*.go"}, Language: "go"},
+		DryRun:      true,
+	}
+
+	for _, enabled := range []bool{false, true} {
+		processor := NewFileProcessor(registry)
+		processor.SetTypeCheck(enabled)
+		result, err := processor.TransformFiles(context.Background(), op)
+		if err != nil {
+			t.Fatalf("TransformFiles: %v", err)
+		}
+		file := result.Files[0]
+		if got := len(file.TypeErrors) > 0; got != enabled {
+			t.Fatalf("type check enabled=%v reported %q", enabled, file.TypeErrors)
+		}
+		if want := map[bool]float64{false: 0.9, true: 0.5}[enabled]; math.Abs(file.Confidence.Score-want) > 1e-9 {
+			t.Fatalf("type check enabled=%v scored %.2f, want %.2f", enabled, file.Confidence.Score, want)
+		}
+	}
+}
@@ -42,8 +42,25 @@ type QueryResult struct {
 type TransformOp struct {
 	Method      string     `json:"method"`                // replace, delete, insert_before, etc
 	Target      AgentQuery `json:"target"`                // what to find
-	Content     string     `json:"content,omitempty"`     // for insert/append
+	Content     string     `json:"content,omitempty"`     // for insert/append, and the text for set_docstring
 	Replacement string     `json:"replacement,omitempty"` // for replace
+
+	FunctionName    string            `json:"function_name,omitempty"`    // for extract_function
+	SortBy          string            `json:"sort_by,omitempty"`          // for sort: name (default), visibility, kind, or a $capture
+	SortOrder       []string          `json:"sort_order,omitempty"`       // for sort by visibility or kind: ranks in order, unlisted last
+	Annotation      string            `json:"annotation,omitempty"`       // for add_annotation: text such as @deprecated or json:"${name|snake}"; for remove_annotation: a name pattern
+	TagKey          string            `json:"tag_key,omitempty"`          // for rewrite_tag: the struct tag key to rewrite
+	TypeHints       map[string]string `json:"type_hints,omitempty"`       // for annotate: parameter name, or "return", to type
+	OrganizeImports bool              `json:"organize_imports,omitempty"` // prune unused and add missing imports afterwards
+	Format          bool              `json:"format,omitempty"`           // run the language formatter over the result
+	Overlap         string            `json:"overlap,omitempty"`          // outermost (default), innermost, or error when replace/delete targets nest
+	IfAbsent        bool              `json:"if_absent,omitempty"`        // skip insert/append where equivalent content already exists
+	Attached        string            `json:"attached,omitempty"`         // include or exclude doc comments, decorators, and trailing comments (default include for delete, exclude for replace)
+	StructuralDiff  bool              `json:"structural_diff,omitempty"`  // report node-level changes in Metadata["structural_diff"]
+
+	ExpectMatches MatchRange `json:"expect_matches,omitempty"` // abort unless the match count is in this range, e.g. "1" or "1..5"
+
+	Ops []TransformOp `json:"ops,omitempty"` // for batch, applied in order to the same source
 }
 
 // TransformResult from provider
@@ -56,6 +73,24 @@ type TransformResult struct {
 	Error      error           `json:"-"`
 }
 
+// StructuralDiffer is implemented by providers that can describe the
+// node-level edits between two versions of a source. Transforms report them
+// only when TransformOp.StructuralDiff asks; staging computes them through
+// this interface instead.
+type StructuralDiffer interface {
+	StructuralDiff(original, modified string) []StructuralChange
+}
+
+// StructuralChange is one node-level edit a transform made, reported in
+// TransformResult.Metadata["structural_diff"] alongside the line diff
+type StructuralChange struct {
+	Change  string `json:"change"`  // inserted, deleted, renamed, moved, reordered, or modified
+	Kind    string `json:"kind"`    // declaration kind: class, method, function, field, ... or file
+	Path    string `json:"path"`    // enclosing declarations, outermost first: "class Y > method X"
+	Line    uint32 `json:"line"`    // 1-based line in the modified source, or the original for deletions
+	Summary string `json:"summary"` // e.g. "renamed parameter a to b in function f"
+}
+
 // ConfidenceScore for transformations
 type ConfidenceScore struct {
 	Score   float64            `json:"score"` // 0.0 to 1.0
@@ -83,11 +118,16 @@ type FileScope struct {
 
 // FileTransformOp represents a file-based transformation operation
 type FileTransformOp struct {
-	TransformOp           // Embedded base operation
-	Scope       FileScope `json:"scope"`    // Files to operate on
-	DryRun      bool      `json:"dry_run"`  // Preview only, don't modify files
-	Backup      bool      `json:"backup"`   // Create .bak files before modifying
-	Parallel    bool      `json:"parallel"` // Use parallel processing
+	TransformOp                // Embedded base operation
+	Scope       FileScope      `json:"scope"`            // Files to operate on
+	DryRun      bool           `json:"dry_run"`          // Preview only, don't modify files
+	Backup      bool           `json:"backup"`           // Create .bak files before modifying
+	Parallel    bool           `json:"parallel"`         // Use parallel processing
+	Verify      *VerifyCommand `json:"verify,omitempty"` // Run after writing; a failure rolls the transaction back
+
+	// Overlay holds file contents, keyed by path, that dry runs transform
+	// instead of what is on disk, so staged steps build on earlier ones
+	Overlay map[string]string `json:"-"`
 }
 
 // CodeMatch represents a specific code element match with precise location
@@ -120,20 +160,26 @@ type FileTransformResult struct {
 	Files             []FileTransformDetail `json:"files"`                    // Per-file results
 	Confidence        ConfidenceScore       `json:"confidence"`               // Overall confidence
 	TransactionID     string                `json:"transaction_id,omitempty"` // Transaction ID for rollback
+	Verification      *VerifyResult         `json:"verification,omitempty"`   // Verify command outcome, when one ran
 	Errors            []string              `json:"errors,omitempty"`         // Non-fatal errors encountered during processing
 	Error             error                 `json:"-"`
 }
 
 // FileTransformDetail represents the transformation result for a single file
 type FileTransformDetail struct {
-	FilePath     string          `json:"file_path"`
-	Language     string          `json:"language"`
-	MatchCount   int             `json:"match_count"`
-	Modified     bool            `json:"modified"`
-	Diff         string          `json:"diff,omitempty"`
-	Confidence   ConfidenceScore `json:"confidence"`
-	Error        string          `json:"error,omitempty"`
-	BackupPath   string          `json:"backup_path,omitempty"`
-	OriginalSize int64           `json:"original_size"`
-	ModifiedSize int64           `json:"modified_size"`
+	FilePath           string              `json:"file_path"`
+	Language           string              `json:"language"`
+	MatchCount         int                 `json:"match_count"`
+	Modified           bool                `json:"modified"`
+	Diff               string              `json:"diff,omitempty"`
+	StructuralDiff     []StructuralChange  `json:"structural_diff,omitempty"`
+	TypeErrors         []string            `json:"type_errors,omitempty"`         // errors the edit introduced, when type checking is on
+	DanglingReferences []DanglingReference `json:"dangling_references,omitempty"` // remaining uses of deleted or changed declarations
+	Confidence         ConfidenceScore     `json:"confidence"`
+	Error              string              `json:"error,omitempty"`
+	BackupPath         string              `json:"backup_path,omitempty"`
+	OriginalSize       int64               `json:"original_size"`
+	ModifiedSize       int64               `json:"modified_size"`
+	OriginalContent    string              `json:"-"` // content before the edit, set when Modified
+	ModifiedContent    string              `json:"-"` // content after the edit, set when Modified
 }
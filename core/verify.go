@@ -0,0 +1,206 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultVerifyTimeout bounds a verify command that sets no timeout.
+const DefaultVerifyTimeout = 5 * time.Minute
+
+// maxVerifyOutput caps the captured output; the tail is kept because test
+// runners and compilers print their summary last.
+const maxVerifyOutput = 16 * 1024
+
+// VerifyEnv lets an MCP server run verify commands when set to a true value.
+const VerifyEnv = "MORFX_VERIFY"
+
+// VerifyAllowEnv lists the verify commands an MCP server accepts, separated
+// by commas; each entry matches itself or itself followed by arguments.
+const VerifyAllowEnv = "MORFX_VERIFY_ALLOW"
+
+// ErrVerificationFailed indicates that a verify command exited non-zero or
+// timed out after files were written, and the write was rolled back.
+var ErrVerificationFailed = errors.New("verification failed")
+
+// ErrVerifyNotAllowed indicates that a verify command was refused by the
+// VerifyPolicy of the process that would run it.
+var ErrVerifyNotAllowed = errors.New("verify command not allowed")
+
+// verifyShellSyntax is the shell syntax that could chain another command
+// onto an allowed prefix.
+const verifyShellSyntax = ";&|<>`$(){}\n\r"
+
+// VerifyPolicy decides which verify commands a client may have run. The zero
+// value refuses every command, so servers that take commands from agents
+// run none unless an operator opts in.
+type VerifyPolicy struct {
+	Enabled bool     `json:"enabled"`         // accept verify commands at all
+	Allow   []string `json:"allow,omitempty"` // commands or prefixes; any command when empty
+}
+
+// Check returns an error wrapping ErrVerifyNotAllowed when the policy
+// refuses v. A nil v is always allowed. An entry allows the command itself
+// and the command followed by arguments free of shell syntax.
+func (p VerifyPolicy) Check(v *VerifyCommand) error {
+	if v == nil {
+		return nil
+	}
+	if !p.Enabled {
+		return fmt.Errorf("%w: verify commands are disabled on this server", ErrVerifyNotAllowed)
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	command := strings.TrimSpace(v.Command)
+	for _, allowed := range p.Allow {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "" {
+			continue
+		}
+		if command == allowed {
+			return nil
+		}
+		if args, ok := strings.CutPrefix(command, allowed+" "); ok && !strings.ContainsAny(args, verifyShellSyntax) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q is not in the allowlist", ErrVerifyNotAllowed, command)
+}
+
+// VerifyCommand is a command run after a transform writes files, such as
+// "go test ./pkg/..." or "npm run typecheck". A non-zero exit rolls the
+// write back.
+type VerifyCommand struct {
+	Command string `json:"command"`           // run through sh -c, or cmd /C on Windows
+	Timeout string `json:"timeout,omitempty"` // Go duration such as "90s"; 5m by default
+	Dir     string `json:"dir,omitempty"`     // working directory; defaults to the scope or file directory
+}
+
+// VerifyResult records one run of a VerifyCommand.
+type VerifyResult struct {
+	Command    string `json:"command"`
+	Dir        string `json:"dir,omitempty"`
+	Passed     bool   `json:"passed"`
+	ExitCode   int    `json:"exit_code"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	Output     string `json:"output,omitempty"` // combined stdout and stderr, truncated to the last 16 KiB
+	DurationMs int64  `json:"duration_ms"`
+	RolledBack bool   `json:"rolled_back,omitempty"`
+	Error      string `json:"error,omitempty"` // why the command could not run or the rollback failed
+}
+
+// Validate checks the command is set and the timeout parses.
+func (v *VerifyCommand) Validate() error {
+	if v == nil {
+		return nil
+	}
+	if strings.TrimSpace(v.Command) == "" {
+		return fmt.Errorf("verify.command is required")
+	}
+	if _, err := v.timeout(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TimeoutDuration returns the command's timeout, or DefaultVerifyTimeout
+// when it is unset or invalid.
+func (v *VerifyCommand) TimeoutDuration() time.Duration {
+	if timeout, err := v.timeout(); err == nil {
+		return timeout
+	}
+	return DefaultVerifyTimeout
+}
+
+func (v *VerifyCommand) timeout() (time.Duration, error) {
+	if strings.TrimSpace(v.Timeout) == "" {
+		return DefaultVerifyTimeout, nil
+	}
+	timeout, err := time.ParseDuration(v.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("verify.timeout %q is not a positive duration", v.Timeout)
+	}
+	return timeout, nil
+}
+
+// Run executes the command in v.Dir, or defaultDir when v.Dir is empty.
+func (v *VerifyCommand) Run(ctx context.Context, defaultDir string) VerifyResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	dir := v.Dir
+	if dir == "" {
+		dir = defaultDir
+	}
+	result := VerifyResult{Command: v.Command, Dir: dir, ExitCode: -1}
+	timeout, err := v.timeout()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(runCtx, "cmd", "/C", v.Command)
+	} else {
+		cmd = exec.CommandContext(runCtx, "sh", "-c", v.Command)
+	}
+	cmd.Dir = dir
+	// Test runners leave children holding the output pipes after the shell
+	// is killed; stop waiting for them shortly after.
+	cmd.WaitDelay = 2 * time.Second
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	runErr := cmd.Run()
+	result.DurationMs = time.Since(start).Milliseconds()
+	result.Output = tailOutput(output.String())
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		result.Error = fmt.Sprintf("cancelled: %v", ctx.Err())
+	case runCtx.Err() != nil:
+		result.TimedOut = true
+		result.Error = fmt.Sprintf("timed out after %s", timeout)
+	case runErr == nil:
+		result.Passed = true
+		result.ExitCode = 0
+	case errors.As(runErr, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		result.Error = runErr.Error()
+	}
+	return result
+}
+
+// Summary describes the result in one line.
+func (r VerifyResult) Summary() string {
+	switch {
+	case r.Passed:
+		return fmt.Sprintf("%q passed", r.Command)
+	case r.TimedOut || r.ExitCode < 0:
+		return fmt.Sprintf("%q failed: %s", r.Command, r.Error)
+	default:
+		return fmt.Sprintf("%q exited with status %d", r.Command, r.ExitCode)
+	}
+}
+
+func tailOutput(output string) string {
+	if len(output) <= maxVerifyOutput {
+		return output
+	}
+	return "…" + output[len(output)-maxVerifyOutput:]
+}
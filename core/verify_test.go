@@ -0,0 +1,338 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func skipWithoutShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("verify tests use POSIX shell commands")
+	}
+}
+
+func TestVerifyCommandValidate(t *testing.T) {
+	var unset *VerifyCommand
+	if err := unset.Validate(); err != nil {
+		t.Fatalf("nil verify should be valid, got %v", err)
+	}
+	if err := (&VerifyCommand{Command: "  "}).Validate(); err == nil {
+		t.Fatal("expected an empty command to be rejected")
+	}
+	if err := (&VerifyCommand{Command: "true", Timeout: "soon"}).Validate(); err == nil {
+		t.Fatal("expected an unparsable timeout to be rejected")
+	}
+	if err := (&VerifyCommand{Command: "true", Timeout: "-1s"}).Validate(); err == nil {
+		t.Fatal("expected a negative timeout to be rejected")
+	}
+	if got := (&VerifyCommand{Command: "true"}).TimeoutDuration(); got != DefaultVerifyTimeout {
+		t.Fatalf("TimeoutDuration() = %s, want %s", got, DefaultVerifyTimeout)
+	}
+}
+
+func TestVerifyPolicyCheck(t *testing.T) {
+	allowed := VerifyPolicy{Enabled: true, Allow: []string{"go test", "make check"}}
+	tests := []struct {
+		name    string
+		policy  VerifyPolicy
+		command string
+		ok      bool
+	}{
+		{"disabled by default", VerifyPolicy{}, "go test ./...", false},
+		{"enabled without allowlist", VerifyPolicy{Enabled: true}, "npm test", true},
+		{"exact entry", allowed, "make check", true},
+		{"entry with arguments", allowed, "go test -run TestX ./pkg/...", true},
+		{"other command", allowed, "rm -rf .", false},
+		{"entry as a word prefix", allowed, "go testify", false},
+		{"chained command", allowed, "go test ./...; rm -rf .", false},
+		{"substituted command", allowed, "go test $(rm -rf .)", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(&VerifyCommand{Command: tt.command})
+			if tt.ok != (err == nil) {
+				t.Fatalf("Check(%q) = %v, want allowed %v", tt.command, err, tt.ok)
+			}
+			if err != nil && !errors.Is(err, ErrVerifyNotAllowed) {
+				t.Fatalf("expected ErrVerifyNotAllowed, got %v", err)
+			}
+		})
+	}
+	if err := (VerifyPolicy{}).Check(nil); err != nil {
+		t.Fatalf("expected no verify command to pass, got %v", err)
+	}
+}
+
+func TestVerifyCommandRun(t *testing.T) {
+	skipWithoutShell(t)
+	dir := t.TempDir()
+
+	passed := (&VerifyCommand{Command: "pwd"}).Run(context.Background(), dir)
+	if !passed.Passed || passed.ExitCode != 0 {
+		t.Fatalf("expected pwd to pass, got %+v", passed)
+	}
+	if resolved, _ := filepath.EvalSymlinks(dir); !strings.Contains(passed.Output, resolved) && !strings.Contains(passed.Output, dir) {
+		t.Fatalf("expected the command to run in %s, got output %q", dir, passed.Output)
+	}
+
+	failed := (&VerifyCommand{Command: "echo broken build; exit 3"}).Run(context.Background(), dir)
+	if failed.Passed || failed.ExitCode != 3 {
+		t.Fatalf("expected exit status 3, got %+v", failed)
+	}
+	if !strings.Contains(failed.Output, "broken build") {
+		t.Fatalf("expected output to be captured, got %q", failed.Output)
+	}
+	if summary := failed.Summary(); !strings.Contains(summary, "status 3") {
+		t.Fatalf("unexpected summary %q", summary)
+	}
+
+	timedOut := (&VerifyCommand{Command: "sleep 5", Timeout: "100ms"}).Run(context.Background(), dir)
+	if timedOut.Passed || !timedOut.TimedOut {
+		t.Fatalf("expected a timeout, got %+v", timedOut)
+	}
+}
+
+func TestTailOutputKeepsEnd(t *testing.T) {
+	output := strings.Repeat("x", maxVerifyOutput) + "FAIL summary"
+	tail := tailOutput(output)
+	if !strings.HasSuffix(tail, "FAIL summary") || len(tail) > maxVerifyOutput+len("…") {
+		t.Fatalf("expected the tail of the output to be kept, got %d bytes", len(tail))
+	}
+}
+
+func newVerifyProcessor(t *testing.T) (*FileProcessor, string, string) {
+	t.Helper()
+	registry := &MockProviderRegistry{
+		providers: map[string]Provider{
+			"go": &MockProvider{
+				language: "go",
+				transformResult: TransformResult{
+					Modified:   "package main\nfunc newMain() {}",
+					MatchCount: 1,
+					Confidence: ConfidenceScore{Score: 0.9, Level: "high"},
+				},
+			},
+		},
+	}
+	processor := NewFileProcessor(registry)
+	processor.SetTransactionLogDir(t.TempDir())
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte("package main\nfunc main() {}"), 0o644); err != nil {
+		t.Fatalf("failed to seed file: %v", err)
+	}
+	return processor, dir, path
+}
+
+func verifyTransformOp(dir string, verify *VerifyCommand) FileTransformOp {
+	return FileTransformOp{
+		Scope: FileScope{Path: dir, Include: []string{"*.go"}, Language: "go"},
+		TransformOp: TransformOp{
+			Method:      "replace",
+			Target:      AgentQuery{Type: "function", Name: "main"},
+			Replacement: "func newMain() {}",
+		},
+		Verify: verify,
+	}
+}
+
+func TestFileProcessor_TransformFiles_VerifyFailureRollsBack(t *testing.T) {
+	skipWithoutShell(t)
+	processor, dir, path := newVerifyProcessor(t)
+
+	// The command sees the written file before it fails.
+	verify := &VerifyCommand{Command: "grep -q newMain main.go && echo tests failed && exit 1"}
+	result, err := processor.TransformFiles(context.Background(), verifyTransformOp(dir, verify))
+	if err != nil {
+		t.Fatalf("TransformFiles returned error: %v", err)
+	}
+
+	verification := result.Verification
+	if verification == nil || verification.Passed || verification.ExitCode != 1 {
+		t.Fatalf("expected a failed verification, got %+v", verification)
+	}
+	if !verification.RolledBack {
+		t.Fatal("expected the verification to report a rollback")
+	}
+	if !strings.Contains(verification.Output, "tests failed") {
+		t.Fatalf("expected captured output, got %q", verification.Output)
+	}
+	if len(result.Errors) == 0 || !strings.Contains(result.Errors[len(result.Errors)-1], "verification failed") {
+		t.Fatalf("expected a verification error, got %v", result.Errors)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(content) != "package main\nfunc main() {}" {
+		t.Fatalf("expected the original content to be restored, got %q", content)
+	}
+}
+
+func TestFileProcessor_TransformFiles_VerifyPassCommits(t *testing.T) {
+	skipWithoutShell(t)
+	processor, dir, path := newVerifyProcessor(t)
+
+	result, err := processor.TransformFiles(context.Background(), verifyTransformOp(dir, &VerifyCommand{Command: "true"}))
+	if err != nil {
+		t.Fatalf("TransformFiles returned error: %v", err)
+	}
+	if result.Verification == nil || !result.Verification.Passed {
+		t.Fatalf("expected a passing verification, got %+v", result.Verification)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if !strings.Contains(string(content), "newMain") {
+		t.Fatalf("expected the change to be kept, got %q", content)
+	}
+}
+
+func TestFileProcessor_TransformFiles_VerifyRequiresSafety(t *testing.T) {
+	processor, dir, _ := newVerifyProcessor(t)
+	processor.EnableSafety(false)
+
+	if _, err := processor.TransformFiles(context.Background(), verifyTransformOp(dir, &VerifyCommand{Command: "true"})); err == nil {
+		t.Fatal("expected verify without safety to be rejected")
+	}
+}
+
+func TestExecuteRecipeStopsAtFailedVerification(t *testing.T) {
+	applied := func(txID string) *FileTransformResult {
+		return &FileTransformResult{
+			FilesModified: 1,
+			TotalMatches:  1,
+			Confidence:    ConfidenceScore{Score: 0.95, Level: "high"},
+			TransactionID: txID,
+		}
+	}
+	failed := &FileTransformResult{
+		FilesModified: 1,
+		TotalMatches:  1,
+		Confidence:    ConfidenceScore{Score: 0.95, Level: "high"},
+		Verification:  &VerifyResult{Command: "make check", ExitCode: 2, Output: "FAIL", RolledBack: true},
+	}
+	processor := &fakeRecipeProcessor{
+		results: []*FileTransformResult{applied(""), applied("tx1"), applied(""), applied("tx2"), applied(""), failed},
+	}
+
+	scope := FileScope{Path: ".", Include: []string{"**/*.go"}, Language: "go"}
+	recipe := Recipe{
+		Name:   "migrate",
+		Verify: &VerifyCommand{Command: "go test ./..."},
+		Steps: []RecipeStep{
+			{Name: "first", Method: "delete", Scope: scope, Target: AgentQuery{Type: "function", Name: "A"}},
+			{Name: "second", Method: "delete", Scope: scope, Target: AgentQuery{Type: "function", Name: "B"}},
+			{Name: "third", Method: "delete", Scope: scope, Target: AgentQuery{Type: "function", Name: "C"}, Verify: &VerifyCommand{Command: "make check"}},
+			{Name: "fourth", Method: "delete", Scope: scope, Target: AgentQuery{Type: "function", Name: "D"}},
+		},
+	}
+
+	result, err := ExecuteRecipe(context.Background(), processor, recipe)
+	if !errors.Is(err, ErrVerificationFailed) {
+		t.Fatalf("expected ErrVerificationFailed, got %v", err)
+	}
+	if result == nil || result.FailedStep != "third" || result.Verification != failed.Verification {
+		t.Fatalf("expected the third step to be reported as failed, got %+v", result)
+	}
+	if len(processor.calls) != 6 {
+		t.Fatalf("expected the fourth step not to run, got %d calls", len(processor.calls))
+	}
+	if processor.calls[1].Verify != nil || processor.calls[5].Verify.Command != "make check" {
+		t.Fatalf("expected only the step's own verify to run with it, got %+v, %+v", processor.calls[1].Verify, processor.calls[5].Verify)
+	}
+	if strings.Join(processor.rolledBack, " ") != "tx2 tx1" || !result.Verification.RolledBack {
+		t.Fatalf("expected the earlier steps to be rolled back newest first, got %v (%+v)", processor.rolledBack, result.Verification)
+	}
+}
+
+func TestExecuteRecipeVerifiesOnceAfterLastStep(t *testing.T) {
+	skipWithoutShell(t)
+	const original = "package main\nfunc main() {}"
+	run := func(t *testing.T, command string) (*RecipeResult, error, string) {
+		t.Helper()
+		processor, _, _ := newVerifyProcessor(t)
+		root := t.TempDir()
+		steps := make([]RecipeStep, 0, 2)
+		for _, name := range []string{"a", "b"} {
+			dir := filepath.Join(root, name)
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatalf("failed to create %s: %v", dir, err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(original), 0o644); err != nil {
+				t.Fatalf("failed to seed file: %v", err)
+			}
+			steps = append(steps, RecipeStep{
+				Name:        "rename in " + name,
+				Method:      "replace",
+				Scope:       FileScope{Path: dir, Include: []string{"*.go"}, Language: "go"},
+				Target:      AgentQuery{Type: "function", Name: "main"},
+				Replacement: "func newMain() {}",
+			})
+		}
+		result, err := ExecuteRecipe(context.Background(), processor, Recipe{
+			Name:   "migrate",
+			Verify: &VerifyCommand{Command: command},
+			Steps:  steps,
+		})
+		return result, err, root
+	}
+	read := func(root, name string) string {
+		content, _ := os.ReadFile(filepath.Join(root, name))
+		return string(content)
+	}
+
+	t.Run("runs once in the common parent", func(t *testing.T) {
+		result, err, root := run(t, "cat a/main.go b/main.go >> verify.log")
+		if err != nil || result.Verification != nil {
+			t.Fatalf("expected the recipe to pass, got %v, %+v", err, result)
+		}
+		edited := "package main\nfunc newMain() {}"
+		if runs := read(root, "verify.log"); runs != edited+edited {
+			t.Fatalf("expected one run after the last step, got %q", runs)
+		}
+	})
+
+	t.Run("rolls back every step", func(t *testing.T) {
+		result, err, root := run(t, "echo tests failed; exit 1")
+		if !errors.Is(err, ErrVerificationFailed) {
+			t.Fatalf("expected ErrVerificationFailed, got %v", err)
+		}
+		if result.FailedStep != "" || result.Verification == nil || !result.Verification.RolledBack || result.Verification.Error != "" {
+			t.Fatalf("expected a rolled back recipe verification, got %+v", result.Verification)
+		}
+		if len(result.TransactionIDs) != 2 {
+			t.Fatalf("expected a transaction per step, got %v", result.TransactionIDs)
+		}
+		if a, b := read(root, "a/main.go"), read(root, "b/main.go"); a != original || b != original {
+			t.Fatalf("expected the original content to be restored, got %q and %q", a, b)
+		}
+	})
+}
+
+func TestExecuteRecipeVerifyRequiresSafety(t *testing.T) {
+	processor, dir, _ := newVerifyProcessor(t)
+	processor.EnableSafety(false)
+
+	_, err := ExecuteRecipe(context.Background(), processor, Recipe{
+		Name:   "migrate",
+		Verify: &VerifyCommand{Command: "true"},
+		Steps: []RecipeStep{{
+			Name: "rename", Method: "replace", Scope: FileScope{Path: dir},
+			Target: AgentQuery{Type: "function", Name: "main"}, Replacement: "func newMain() {}",
+		}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "requires safety") {
+		t.Fatalf("expected verify without safety to be rejected, got %v", err)
+	}
+}
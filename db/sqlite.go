@@ -107,6 +107,7 @@ func Migrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&models.Stage{},
 		&models.Apply{},
+		&models.Changeset{},
 		&models.Session{},
 	)
 }
@@ -114,7 +114,7 @@ func TestConnect(t *testing.T) {
 				assert.Equal(t, 1, fkEnabled)
 
 				// Verify tables were created by migration
-				tables := []string{"stages", "applies", "sessions"}
+				tables := []string{"stages", "applies", "changesets", "sessions"}
 				for _, table := range tables {
 					assert.True(t, db.Migrator().HasTable(table), "Table %s should exist", table)
 				}
@@ -271,6 +271,7 @@ func TestMigrate(t *testing.T) {
 				// Verify all tables exist
 				assert.True(t, db.Migrator().HasTable(&models.Stage{}))
 				assert.True(t, db.Migrator().HasTable(&models.Apply{}))
+				assert.True(t, db.Migrator().HasTable(&models.Changeset{}))
 				assert.True(t, db.Migrator().HasTable(&models.Session{}))
 
 				// Verify table structure by creating sample records
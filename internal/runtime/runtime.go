@@ -2,6 +2,8 @@ package runtime
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/oxhq/morfx/core"
@@ -17,12 +19,22 @@ import (
 // Config controls shared runtime construction.
 type Config struct {
 	TransactionLogDir string
+	// PolicyRoot is where .morfx/confidence.json is looked for; defaults to
+	// the working directory. MORFX_CONFIDENCE_POLICY overrides it.
+	PolicyRoot string
+	// TypeCheck type-checks modified files for providers that support it.
+	// MORFX_TYPECHECK=1 turns it on as well.
+	TypeCheck bool
 }
 
 // Runtime contains the shared provider registry and file processor.
 type Runtime struct {
 	Providers     *providers.Registry
 	FileProcessor *core.FileProcessor
+	// Scorer is the confidence policy in effect, or nil when none is set up.
+	Scorer core.ConfidenceScorer
+	// TypeCheck reports whether type-check validation is on.
+	TypeCheck bool
 }
 
 // Build constructs the shared Morfx runtime used by MCP and standalone tools.
@@ -40,10 +52,33 @@ func Build(cfg Config) (*Runtime, error) {
 		fileProcessor.SetTransactionLogDir(logDir)
 	}
 
-	return &Runtime{
+	rt := &Runtime{
 		Providers:     registry,
 		FileProcessor: fileProcessor,
-	}, nil
+	}
+
+	policyRoot := cfg.PolicyRoot
+	if policyRoot == "" {
+		policyRoot = "."
+	}
+	policy, err := core.DiscoverConfidencePolicy(policyRoot)
+	if err != nil {
+		return nil, err
+	}
+	if policy != nil {
+		rt.Scorer = policy
+		fileProcessor.SetConfidenceScorer(policy)
+	}
+
+	rt.TypeCheck = cfg.TypeCheck || typeCheckFromEnv()
+	fileProcessor.SetTypeCheck(rt.TypeCheck)
+
+	return rt, nil
+}
+
+func typeCheckFromEnv() bool {
+	enabled, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv(core.TypeCheckEnv)))
+	return enabled
 }
 
 func registerBuiltInProviders(registry *providers.Registry) {
@@ -81,3 +116,19 @@ func (pa *providerAdapter) Query(source string, query core.AgentQuery) core.Quer
 func (pa *providerAdapter) Transform(source string, op core.TransformOp) core.TransformResult {
 	return pa.provider.Transform(source, op)
 }
+
+func (pa *providerAdapter) TypeCheck(path, original, modified string) ([]string, error) {
+	checker, ok := pa.provider.(core.TypeChecker)
+	if !ok {
+		return nil, core.ErrTypeCheckUnsupported
+	}
+	return checker.TypeCheck(path, original, modified)
+}
+
+func (pa *providerAdapter) FindReferences(source, name string) []core.Location {
+	finder, ok := pa.provider.(core.ReferenceFinder)
+	if !ok {
+		return nil
+	}
+	return finder.FindReferences(source, name)
+}
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/oxhq/morfx/core"
 )
 
 func TestBuildRegistersExpectedLanguages(t *testing.T) {
@@ -82,3 +84,63 @@ func TestBuildCreatesTransactionLogDir(t *testing.T) {
 		t.Fatalf("Stat(%q) error = %v", txDir, err)
 	}
 }
+
+func TestBuildLoadsConfidencePolicy(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("MORFX_CONFIDENCE_POLICY", "")
+
+	rt, err := Build(Config{PolicyRoot: root})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if rt.Scorer != nil {
+		t.Fatalf("Scorer = %v, want nil without a policy file", rt.Scorer)
+	}
+
+	policyPath := filepath.Join(root, ".morfx", "confidence.json")
+	if err := os.MkdirAll(filepath.Dir(policyPath), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(policyPath, []byte(`{"paths": [{"pattern": "api/**", "impact": -0.2}]}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	rt, err = Build(Config{PolicyRoot: root})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if rt.Scorer == nil {
+		t.Fatal("Scorer is nil, want the policy from .morfx/confidence.json")
+	}
+
+	if err := os.WriteFile(policyPath, []byte(`{"paths": [{"pattern": "api/[", "impact": -0.2}]}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := Build(Config{PolicyRoot: root}); err == nil {
+		t.Fatal("Build() succeeded with an invalid policy, want an error")
+	}
+}
+
+func TestBuildEnablesTypeCheck(t *testing.T) {
+	t.Setenv("MORFX_TYPECHECK", "")
+	rt, err := Build(Config{})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if rt.TypeCheck {
+		t.Fatal("TypeCheck = true, want off by default")
+	}
+
+	t.Setenv("MORFX_TYPECHECK", "1")
+	rt, err = Build(Config{})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if !rt.TypeCheck {
+		t.Fatal("TypeCheck = false, want MORFX_TYPECHECK=1 to enable it")
+	}
+
+	provider, _ := (&providerRegistryAdapter{registry: rt.Providers}).Get("go")
+	if _, ok := provider.(core.TypeChecker); !ok {
+		t.Fatal("Go provider adapter does not implement core.TypeChecker")
+	}
+}
@@ -12,6 +12,7 @@ import (
 type Environment struct {
 	providers     *providers.Registry
 	fileProcessor *core.FileProcessor
+	scorer        core.ConfidenceScorer
 }
 
 // NewEnvironment constructs an Environment with all built-in language providers registered.
@@ -24,6 +25,7 @@ func NewEnvironment() (*Environment, error) {
 	return &Environment{
 		providers:     rt.Providers,
 		fileProcessor: rt.FileProcessor,
+		scorer:        rt.Scorer,
 	}, nil
 }
 
@@ -45,3 +47,13 @@ func (env *Environment) Providers() *providers.Registry {
 func (env *Environment) FileProcessor() *core.FileProcessor {
 	return env.fileProcessor
 }
+
+// Score rescores a single-file transform's confidence with the confidence
+// policy in effect, as the file processor does for file-based tools. path is
+// empty for inline sources. Without a policy, confidence is returned as is.
+func (env *Environment) Score(language, method, path string, confidence core.ConfidenceScore) core.ConfidenceScore {
+	if env.scorer == nil {
+		return confidence
+	}
+	return env.scorer.Score(core.ScoreContext{Language: language, Method: method, Path: path}, confidence)
+}
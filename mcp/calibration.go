@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// calibrationRefresh is how long a calibrated scorer reuses the history it
+// last loaded.
+const calibrationRefresh = time.Minute
+
+// loadCalibration builds a calibration from every apply recorded in db and
+// the stage it applied. Stored scores may already be calibrated, so each is
+// taken as it was before calibration.
+func loadCalibration(db *gorm.DB) (*core.Calibration, error) {
+	var rows []struct {
+		Language          string
+		Operation         string
+		ConfidenceScore   float64
+		ConfidenceFactors []byte
+		AutoApplied       bool
+		Reverted          bool
+	}
+	err := db.Table("applies").
+		Select("stages.language, stages.operation, stages.confidence_score, stages.confidence_factors, applies.auto_applied, applies.reverted").
+		Joins("JOIN stages ON stages.id = applies.stage_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]core.CalibrationSample, 0, len(rows))
+	for _, row := range rows {
+		var factors []core.ConfidenceFactor
+		if len(row.ConfidenceFactors) > 0 {
+			_ = json.Unmarshal(row.ConfidenceFactors, &factors)
+		}
+		scored := core.Uncalibrated(core.ConfidenceScore{Score: row.ConfidenceScore, Factors: factors})
+		names := make([]string, 0, len(scored.Factors))
+		for _, factor := range scored.Factors {
+			names = append(names, factor.Name)
+		}
+		samples = append(samples, core.CalibrationSample{
+			Language:    row.Language,
+			Operation:   row.Operation,
+			Score:       scored.Score,
+			Factors:     names,
+			AutoApplied: row.AutoApplied,
+			Reverted:    row.Reverted,
+		})
+	}
+	return core.BuildCalibration(samples), nil
+}
+
+// calibratedScorer rescores confidence with next, then moves it toward the
+// keep rate apply history shows for scores like it.
+type calibratedScorer struct {
+	db   *gorm.DB
+	next core.ConfidenceScorer
+
+	mu          sync.Mutex
+	calibration *core.Calibration
+	loadedAt    time.Time
+}
+
+func newCalibratedScorer(db *gorm.DB, next core.ConfidenceScorer) *calibratedScorer {
+	return &calibratedScorer{db: db, next: next}
+}
+
+func (c *calibratedScorer) Score(ctx core.ScoreContext, confidence core.ConfidenceScore) core.ConfidenceScore {
+	if c.next != nil {
+		confidence = c.next.Score(ctx, confidence)
+	}
+	return c.current().Score(ctx, confidence)
+}
+
+// current returns the cached calibration, reloading it once it is older
+// than calibrationRefresh. A failed load keeps the previous calibration.
+func (c *calibratedScorer) current() *core.Calibration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calibration != nil && time.Since(c.loadedAt) < calibrationRefresh {
+		return c.calibration
+	}
+	if calibration, err := loadCalibration(c.db); err == nil {
+		c.calibration = calibration
+	}
+	c.loadedAt = time.Now()
+	return c.calibration
+}
+
+func calibrateFromEnv() bool {
+	enabled, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv(core.CalibrateEnv)))
+	return enabled
+}
+
+// generateConfidenceCalibration creates confidence calibration resource
+// content
+func (s *StdioServer) generateConfidenceCalibration() (*ResourceContent, error) {
+	payload := map[string]any{
+		"database":             s.db != nil,
+		"calibrated_scoring":   s.calibrated,
+		"auto_apply_threshold": s.config.AutoApplyThreshold,
+		"target_keep_rate":     core.TargetKeepRate,
+		"min_samples":          core.MinCalibrationSamples,
+	}
+
+	if s.db == nil {
+		payload["message"] = "Running in stateless mode - no apply history to calibrate from"
+	} else {
+		calibration, err := loadCalibration(s.db)
+		if err != nil {
+			return nil, WrapError(InternalError, "Failed to load apply history", err)
+		}
+		payload["overall"] = calibration.Overall
+		payload["groups"] = calibration.Groups
+		payload["factors"] = calibration.Factors
+		payload["factor_combinations"] = calibration.Combinations
+	}
+
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return nil, WrapError(InternalError, "Failed to marshal confidence calibration", err)
+	}
+
+	return &ResourceContent{
+		URI:      "morfx://confidence/calibration",
+		MimeType: "application/json",
+		Text:     string(data),
+	}, nil
+}
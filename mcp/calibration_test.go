@@ -0,0 +1,131 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/models"
+)
+
+func seedApplyHistory(t *testing.T, db *gorm.DB, score float64, kept, reverted int) {
+	t.Helper()
+	var count int64
+	db.Model(&models.Stage{}).Count(&count)
+	for i := 0; i < kept+reverted; i++ {
+		id := fmt.Sprintf("stg_%d", int(count)+i)
+		stage := models.Stage{
+			ID:                id,
+			Language:          "go",
+			Operation:         "replace",
+			ConfidenceScore:   score,
+			ConfidenceFactors: mustMarshalJSON([]core.ConfidenceFactor{{Name: "exported_api", Impact: -0.1}}),
+			Status:            "applied",
+		}
+		if err := db.Create(&stage).Error; err != nil {
+			t.Fatalf("failed to seed stage: %v", err)
+		}
+		apply := models.Apply{ID: "apl_" + id[4:], StageID: id, AutoApplied: true, Reverted: i >= kept}
+		if err := db.Create(&apply).Error; err != nil {
+			t.Fatalf("failed to seed apply: %v", err)
+		}
+	}
+}
+
+func TestConfidenceCalibrationResource(t *testing.T) {
+	server := createTestServer(t)
+	server.db = setupAsyncStagingDB(t)
+	seedApplyHistory(t, server.db, 0.9, 9, 3)
+
+	content, err := server.generateResourceContent("morfx://confidence/calibration")
+	if err != nil {
+		t.Fatalf("failed to read calibration: %v", err)
+	}
+
+	var payload struct {
+		Database bool                     `json:"database"`
+		Overall  core.CalibrationGroup    `json:"overall"`
+		Groups   []core.CalibrationGroup  `json:"groups"`
+		Factors  []core.FactorCalibration `json:"factors"`
+	}
+	if err := json.Unmarshal([]byte(content.Text), &payload); err != nil {
+		t.Fatalf("calibration is not JSON: %v", err)
+	}
+	if !payload.Database || payload.Overall.Samples != 12 || payload.Overall.AutoReverted != 3 {
+		t.Fatalf("unexpected overall calibration: %+v", payload)
+	}
+	if len(payload.Groups) != 1 || payload.Groups[0].Language != "go" || payload.Groups[0].SuggestedThreshold != nil {
+		t.Fatalf("expected one go group without a threshold at a 75%% keep rate, got %+v", payload.Groups)
+	}
+	if len(payload.Factors) != 1 || payload.Factors[0].Name != "exported_api" || payload.Factors[0].RevertRate != 0.25 {
+		t.Fatalf("expected exported_api revert rate from stored factors, got %+v", payload.Factors)
+	}
+}
+
+func TestLoadCalibrationUsesScoresBeforeCalibration(t *testing.T) {
+	db := setupAsyncStagingDB(t)
+	// Calibration moved a 0.9 score to 0.5 before it was staged
+	stage := models.Stage{
+		ID:              "stg_calibrated",
+		Language:        "go",
+		Operation:       "replace",
+		ConfidenceScore: 0.5,
+		ConfidenceFactors: mustMarshalJSON([]core.ConfidenceFactor{
+			{Name: "exported_api", Impact: -0.1},
+			{Name: "calibration", Impact: -0.4},
+		}),
+		Status: "applied",
+	}
+	if err := db.Create(&stage).Error; err != nil {
+		t.Fatalf("failed to seed stage: %v", err)
+	}
+	if err := db.Create(&models.Apply{ID: "apl_calibrated", StageID: stage.ID}).Error; err != nil {
+		t.Fatalf("failed to seed apply: %v", err)
+	}
+
+	calibration, err := loadCalibration(db)
+	if err != nil {
+		t.Fatalf("loadCalibration failed: %v", err)
+	}
+	if buckets := calibration.Overall.Buckets; len(buckets) != 1 || buckets[0].Min != 0.9 {
+		t.Fatalf("expected the apply in the 0.9 bucket, got %+v", buckets)
+	}
+	if len(calibration.Combinations) != 1 || calibration.Combinations[0].Name != "exported_api" {
+		t.Fatalf("expected factor combinations without calibration, got %+v", calibration.Combinations)
+	}
+}
+
+func TestConfidenceCalibrationResourceWithoutDatabase(t *testing.T) {
+	server := createTestServer(t)
+
+	content, err := server.generateResourceContent("morfx://confidence/calibration")
+	if err != nil {
+		t.Fatalf("failed to read calibration: %v", err)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(content.Text), &payload); err != nil {
+		t.Fatalf("calibration is not JSON: %v", err)
+	}
+	if payload["database"] != false || payload["message"] == nil {
+		t.Fatalf("expected a stateless notice, got %v", payload)
+	}
+}
+
+func TestCalibratedScorerChainsPolicy(t *testing.T) {
+	db := setupAsyncStagingDB(t)
+	seedApplyHistory(t, db, 0.9, 6, 6)
+
+	baseline := 0.95
+	scorer := newCalibratedScorer(db, &core.ConfidencePolicy{Baseline: &baseline})
+	scored := scorer.Score(core.ScoreContext{Language: "go", Method: "replace"}, core.ConfidenceScore{Score: 1, Level: "high"})
+
+	if len(scored.Factors) != 2 || scored.Factors[0].Name != "policy_baseline" || scored.Factors[1].Name != "calibration" {
+		t.Fatalf("expected the policy to run before calibration, got %+v", scored.Factors)
+	}
+	if scored.Score >= 0.85 {
+		t.Fatalf("expected a 50%% keep rate to block auto-apply, got %.2f", scored.Score)
+	}
+}
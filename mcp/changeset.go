@@ -0,0 +1,379 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/internal/securefs"
+	"github.com/oxhq/morfx/models"
+)
+
+// CreateChangeset stages every file change of one multi-file operation as a
+// pending stage of a new changeset. The changeset's confidence is that of its
+// least confident file.
+func (sm *StagingManager) CreateChangeset(ctx context.Context, sessionID, operation, description string, changes []core.FileChange) (*models.Changeset, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("no file changes to stage")
+	}
+
+	db := sm.db.WithContext(ctx)
+	if sessionID != "" && sm.config.MaxStagesPerSession > 0 {
+		var pendingCount int64
+		if err := db.Model(&models.Stage{}).
+			Where("session_id = ? AND status = ?", sessionID, "pending").
+			Count(&pendingCount).Error; err != nil {
+			return nil, fmt.Errorf("failed to check stage count: %w", err)
+		}
+		if pendingCount+int64(len(changes)) > int64(sm.config.MaxStagesPerSession) {
+			return nil, fmt.Errorf("session stage limit exceeded: %d + %d > %d", pendingCount, len(changes), sm.config.MaxStagesPerSession)
+		}
+	}
+
+	expiresAt := time.Now().Add(sm.config.StagingTTL)
+	changeset := &models.Changeset{
+		ID:          generateID("chg"),
+		SessionID:   sessionID,
+		Operation:   operation,
+		Description: description,
+		Status:      "pending",
+		ExpiresAt:   expiresAt,
+	}
+	digests := make(map[string]string, len(changes))
+	stages := make([]models.Stage, 0, len(changes))
+	for i, change := range changes {
+		if i == 0 || change.Confidence.Score < changeset.ConfidenceScore {
+			changeset.ConfidenceScore = change.Confidence.Score
+			changeset.ConfidenceLevel = change.Confidence.Level
+		}
+		stage := sm.fileChangeStage(sessionID, operation, change)
+		stage.ChangesetID = changeset.ID
+		stage.ExpiresAt = expiresAt
+		digests[change.Path] = stage.BaseDigest
+		stages = append(stages, stage)
+	}
+	changeset.Digests = mustMarshalJSON(digests)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(changeset).Error; err != nil {
+			return fmt.Errorf("failed to create changeset: %w", err)
+		}
+		if err := tx.Create(&stages).Error; err != nil {
+			return fmt.Errorf("failed to create changeset stages: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	changeset.Stages = stages
+	return changeset, ctx.Err()
+}
+
+// fileChangeStage builds the pending stage that writes change.
+func (sm *StagingManager) fileChangeStage(sessionID, operation string, change core.FileChange) models.Stage {
+	baseDigest := calculateSHA256(change.Original)
+	diff := change.Diff
+	if diff == "" {
+		diff = core.UnifiedDiff(change.Original, change.Modified)
+	}
+	scope := map[string]any{
+		"file_path":        change.Path,
+		"safety_validated": sm.safety != nil,
+		"file_size":        len(change.Modified),
+	}
+	if baseDigest != "" {
+		scope["original_hash"] = baseDigest
+	}
+	return models.Stage{
+		ID:        generateID("stg"),
+		SessionID: sessionID,
+		Language:  change.Language,
+		Operation: operation,
+
+		Original: change.Original,
+		Modified: change.Modified,
+		Diff:     diff,
+
+		BaseDigest:  baseDigest,
+		AfterDigest: calculateSHA256(change.Modified),
+
+		ConfidenceScore:   change.Confidence.Score,
+		ConfidenceLevel:   change.Confidence.Level,
+		ConfidenceFactors: mustMarshalJSON(change.Confidence.Factors),
+
+		ScopeAST: mustMarshalJSON(scope),
+		Status:   "pending",
+	}
+}
+
+// GetChangeset retrieves a changeset and its stages by ID
+func (sm *StagingManager) GetChangeset(id string) (*models.Changeset, error) {
+	var changeset models.Changeset
+	if err := sm.db.First(&changeset, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if err := sm.db.Where("changeset_id = ?", id).Order("id").Find(&changeset.Stages).Error; err != nil {
+		return nil, err
+	}
+	return &changeset, nil
+}
+
+// ApplyChangeset writes every file of a pending changeset inside one core
+// transaction, or none of them. Before anything is written, files changed
+// since staging are rebased like ApplyStage does, and a conflict in any of
+// them fails the whole changeset; a write failure, or a failing verify
+// command run afterwards, rolls every file back through
+// TransactionManager.RollbackTransaction. On a verify failure the changeset
+// and its stages are marked failed with the outcome stored on the changeset.
+func (sm *StagingManager) ApplyChangeset(ctx context.Context, changesetID string, verify *core.VerifyCommand) (*models.Changeset, *core.VerifyResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := verify.Validate(); err != nil {
+		return nil, nil, err
+	}
+	changeset, err := sm.GetChangeset(changesetID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("changeset not found: %w", err)
+	}
+	if changeset.Status != "pending" {
+		return nil, nil, fmt.Errorf("changeset already %s", changeset.Status)
+	}
+	if time.Now().After(changeset.ExpiresAt) {
+		_ = sm.setChangesetStatus(sm.db, changeset, "expired", nil)
+		return nil, nil, fmt.Errorf("changeset expired")
+	}
+	if len(changeset.Stages) == 0 {
+		return nil, nil, fmt.Errorf("changeset %s has no stages", changesetID)
+	}
+	if changeset.SessionID != "" && sm.config.MaxAppliesPerSession > 0 {
+		var applyCount int64
+		if err := sm.db.Model(&models.Apply{}).
+			Joins("JOIN stages ON applies.stage_id = stages.id").
+			Where("stages.session_id = ?", changeset.SessionID).
+			Count(&applyCount).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to check apply count: %w", err)
+		}
+		if applyCount+int64(len(changeset.Stages)) > int64(sm.config.MaxAppliesPerSession) {
+			return nil, nil, fmt.Errorf("session apply limit exceeded: %d + %d > %d", applyCount, len(changeset.Stages), sm.config.MaxAppliesPerSession)
+		}
+	}
+
+	paths, rebased, err := sm.checkChangeset(changeset)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	txManager, writer, cleanup, err := beginStageTransaction("Apply changeset " + changesetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanup()
+	for i, stage := range changeset.Stages {
+		path := paths[i]
+		if err := securefs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			_ = txManager.RollbackTransaction()
+			return nil, nil, fmt.Errorf("failed to create parent directory: %w", err)
+		}
+		opType := "modify"
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			opType = "create"
+		}
+		if _, err := txManager.AddOperation(opType, path); err != nil {
+			_ = txManager.RollbackTransaction()
+			return nil, nil, err
+		}
+		writeErr := writer.WriteFile(path, stage.Modified)
+		if err := txManager.CompleteOperation(path, writeErr); err != nil && writeErr == nil {
+			writeErr = err
+		}
+		if writeErr != nil {
+			_ = txManager.RollbackTransaction()
+			return nil, nil, fmt.Errorf("failed to write %s, changeset %s rolled back: %w", path, changesetID, writeErr)
+		}
+	}
+
+	var result *core.VerifyResult
+	if verify != nil {
+		outcome := verify.Run(ctx, commonDir(paths))
+		result = &outcome
+		if !result.Passed {
+			if err := txManager.RollbackTransaction(); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.RolledBack = true
+			}
+			if err := sm.setChangesetStatus(sm.db, changeset, "failed", result); err != nil {
+				return nil, result, fmt.Errorf("failed to record verification: %w", err)
+			}
+			return nil, result, fmt.Errorf("changeset %s %w: %s", changesetID, core.ErrVerificationFailed, result.Summary())
+		}
+	}
+
+	now := time.Now()
+	err = sm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range changeset.Stages {
+			stage := &changeset.Stages[i]
+			apply := &models.Apply{
+				ID:          generateID("apl"),
+				StageID:     stage.ID,
+				BaseDigest:  stage.BaseDigest,
+				AfterDigest: stage.AfterDigest,
+				AppliedBy:   "mcp",
+				Rebased:     rebased[stage.ID],
+			}
+			if apply.Rebased != "" {
+				if err := saveRebase(tx, stage); err != nil {
+					return fmt.Errorf("failed to update stage: %w", err)
+				}
+			}
+			if err := tx.Create(apply).Error; err != nil {
+				return fmt.Errorf("failed to create apply record: %w", err)
+			}
+			stage.Apply = apply
+		}
+		if len(rebased) > 0 {
+			if err := tx.Model(&models.Changeset{}).Where("id = ?", changeset.ID).Updates(map[string]any{
+				"digests":          changeset.Digests,
+				"confidence_score": changeset.ConfidenceScore,
+				"confidence_level": changeset.ConfidenceLevel,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to update changeset: %w", err)
+			}
+		}
+		changeset.AppliedAt = &now
+		if err := sm.setChangesetStatus(tx, changeset, "applied", result); err != nil {
+			return err
+		}
+		if changeset.SessionID != "" {
+			tx.Model(&models.Session{}).
+				Where("id = ?", changeset.SessionID).
+				Update("applies_count", gorm.Expr("applies_count + ?", len(changeset.Stages)))
+		}
+		return nil
+	})
+	if err != nil {
+		_ = txManager.RollbackTransaction()
+		return nil, nil, err
+	}
+	if err := txManager.CommitTransaction(); err != nil {
+		return nil, nil, err
+	}
+	return changeset, result, nil
+}
+
+// checkChangeset returns the file each stage of changeset writes and how the
+// stages of files changed since staging were rebased, by stage ID. It fails
+// before anything is written when a changed file cannot be rebased or the
+// safety limits reject the changeset.
+func (sm *StagingManager) checkChangeset(changeset *models.Changeset) ([]string, map[string]string, error) {
+	paths := make([]string, 0, len(changeset.Stages))
+	files := make([]SafetyFile, 0, len(changeset.Stages))
+	rebased := make(map[string]string)
+	digests := make(map[string]string, len(changeset.Stages))
+	var conflicts []string
+	for i := range changeset.Stages {
+		stage := &changeset.Stages[i]
+		path, err := stageFilePath(stage)
+		if err != nil {
+			return nil, nil, err
+		}
+		if path == "" {
+			return nil, nil, fmt.Errorf("stage %s has no file to write", stage.ID)
+		}
+		strategy, err := sm.rebaseStaleStage(stage)
+		if errors.Is(err, core.ErrMergeConflict) {
+			conflicts = append(conflicts, err.Error())
+		} else if err != nil {
+			return nil, nil, err
+		} else if strategy != "" {
+			rebased[stage.ID] = strategy
+		}
+		digests[path] = stage.BaseDigest
+		paths = append(paths, path)
+		files = append(files, SafetyFile{
+			Path:       path,
+			Size:       int64(len(stage.Modified)),
+			Confidence: stage.ConfidenceScore,
+		})
+	}
+	if len(conflicts) > 0 {
+		return nil, nil, fmt.Errorf("changeset %s cannot be applied: %w: %d file(s) changed since staging could not be rebased: %s",
+			changeset.ID, core.ErrMergeConflict, len(conflicts), strings.Join(conflicts, "; "))
+	}
+	if len(rebased) > 0 {
+		changeset.Digests = mustMarshalJSON(digests)
+		for _, stage := range changeset.Stages {
+			if stage.ConfidenceScore < changeset.ConfidenceScore {
+				changeset.ConfidenceScore = stage.ConfidenceScore
+				changeset.ConfidenceLevel = stage.ConfidenceLevel
+			}
+		}
+	}
+	if sm.safety != nil {
+		if err := sm.safety.ValidateOperation(&SafetyOperation{
+			Files:            files,
+			GlobalConfidence: changeset.ConfidenceScore,
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return paths, rebased, nil
+}
+
+// setChangesetStatus moves a changeset and its stages to status, storing
+// verification on the changeset when set.
+func (sm *StagingManager) setChangesetStatus(db *gorm.DB, changeset *models.Changeset, status string, verification *core.VerifyResult) error {
+	changeset.Status = status
+	updates := map[string]any{"status": status}
+	if changeset.AppliedAt != nil {
+		updates["applied_at"] = changeset.AppliedAt
+	}
+	if verification != nil {
+		changeset.Verification = mustMarshalJSON(verification)
+		updates["verification"] = changeset.Verification
+	}
+	if err := db.Model(&models.Changeset{}).Where("id = ?", changeset.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update changeset: %w", err)
+	}
+	delete(updates, "verification")
+	for i := range changeset.Stages {
+		changeset.Stages[i].Status = status
+		changeset.Stages[i].AppliedAt = changeset.AppliedAt
+	}
+	if err := db.Model(&models.Stage{}).Where("changeset_id = ?", changeset.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update changeset stages: %w", err)
+	}
+	return nil
+}
+
+// commonDir returns the deepest directory containing every path.
+func commonDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	dir := filepath.Dir(paths[0])
+	for _, path := range paths[1:] {
+		for !strings.HasPrefix(path, dir+string(filepath.Separator)) && filepath.Dir(dir) != dir {
+			dir = filepath.Dir(dir)
+		}
+	}
+	return dir
+}
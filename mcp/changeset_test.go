@@ -0,0 +1,191 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/models"
+)
+
+// stageTestChangeset writes two files and stages an edit to each as one
+// changeset.
+func stageTestChangeset(t *testing.T, sm *StagingManager) (*models.Changeset, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}
+	changes := make([]core.FileChange, 0, len(paths))
+	for _, path := range paths {
+		if err := os.WriteFile(path, []byte("package main\n\nfunc Old() {}\n"), 0o644); err != nil {
+			t.Fatalf("failed to seed file: %v", err)
+		}
+		changes = append(changes, core.FileChange{
+			Path:       path,
+			Language:   "go",
+			Original:   "package main\n\nfunc Old() {}\n",
+			Modified:   "package main\n\nfunc New() {}\n",
+			Confidence: core.ConfidenceScore{Score: 0.95, Level: "high"},
+		})
+	}
+	changes[1].Confidence = core.ConfidenceScore{Score: 0.9, Level: "high"}
+
+	changeset, err := sm.CreateChangeset(context.Background(), "changeset-session", "file_replace", "rename Old", changes)
+	if err != nil {
+		t.Fatalf("CreateChangeset failed: %v", err)
+	}
+	return changeset, paths
+}
+
+func TestApplyChangeset(t *testing.T) {
+	newManager := func(t *testing.T) *StagingManager {
+		return NewStagingManager(setupAsyncStagingDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+	}
+	assertFiles := func(t *testing.T, paths []string, want string) {
+		t.Helper()
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", path, err)
+			}
+			if !strings.Contains(string(content), want) {
+				t.Fatalf("expected %s to contain %q, got:\n%s", path, want, content)
+			}
+		}
+	}
+
+	t.Run("writes every file", func(t *testing.T) {
+		sm := newManager(t)
+		changeset, paths := stageTestChangeset(t, sm)
+		if len(changeset.Stages) != 2 || changeset.ConfidenceScore != 0.9 {
+			t.Fatalf("expected two stages at the lowest confidence, got %d at %.2f", len(changeset.Stages), changeset.ConfidenceScore)
+		}
+
+		applied, _, err := sm.ApplyChangeset(context.Background(), changeset.ID, nil)
+		if err != nil {
+			t.Fatalf("ApplyChangeset failed: %v", err)
+		}
+		assertFiles(t, paths, "func New()")
+
+		stored, err := sm.GetChangeset(changeset.ID)
+		if err != nil {
+			t.Fatalf("failed to load changeset: %v", err)
+		}
+		if stored.Status != "applied" || stored.AppliedAt == nil {
+			t.Fatalf("expected changeset applied, got %s", stored.Status)
+		}
+		for _, stage := range stored.Stages {
+			if stage.Status != "applied" {
+				t.Fatalf("expected stage %s applied, got %s", stage.ID, stage.Status)
+			}
+		}
+		var applies int64
+		sm.db.Model(&models.Apply{}).Count(&applies)
+		if applies != 2 || applied.Stages[0].Apply == nil {
+			t.Fatalf("expected an apply record per file, got %d", applies)
+		}
+	})
+
+	t.Run("rebases a changed file", func(t *testing.T) {
+		sm := newManager(t)
+		changeset, paths := stageTestChangeset(t, sm)
+		if err := os.WriteFile(paths[1], []byte("// Package main.\npackage main\n\nfunc Old() {}\n"), 0o644); err != nil {
+			t.Fatalf("failed to edit file: %v", err)
+		}
+
+		applied, _, err := sm.ApplyChangeset(context.Background(), changeset.ID, nil)
+		if err != nil {
+			t.Fatalf("ApplyChangeset failed: %v", err)
+		}
+		assertFiles(t, paths, "func New()")
+		assertFiles(t, paths[1:], "// Package main.")
+		for _, stage := range applied.Stages {
+			path, _ := stageFilePath(&stage)
+			want := map[string]string{paths[0]: "", paths[1]: "merge"}[path]
+			if stage.Apply.Rebased != want {
+				t.Fatalf("expected %s rebased %q, got %q", path, want, stage.Apply.Rebased)
+			}
+			stored, err := sm.GetStage(stage.ID)
+			if err != nil {
+				t.Fatalf("failed to load stage: %v", err)
+			}
+			if want != "" && !strings.HasPrefix(stored.Original, "// Package main.") {
+				t.Fatalf("expected the rebased original stored, got:\n%s", stored.Original)
+			}
+		}
+	})
+
+	t.Run("writes nothing when a changed file conflicts", func(t *testing.T) {
+		sm := newManager(t)
+		changeset, paths := stageTestChangeset(t, sm)
+		if err := os.WriteFile(paths[1], []byte("package main\n\nfunc Edited() {}\n"), 0o644); err != nil {
+			t.Fatalf("failed to edit file: %v", err)
+		}
+
+		_, _, err := sm.ApplyChangeset(context.Background(), changeset.ID, nil)
+		if !errors.Is(err, core.ErrMergeConflict) || !strings.Contains(err.Error(), paths[1]+": changed since staging at line 3") {
+			t.Fatalf("expected the edited file to block the changeset, got %v", err)
+		}
+		assertFiles(t, paths[:1], "func Old()")
+		if stored, _ := sm.GetChangeset(changeset.ID); stored.Status != "pending" {
+			t.Fatalf("expected changeset left pending, got %s", stored.Status)
+		}
+	})
+
+	t.Run("rolls every file back when verify fails", func(t *testing.T) {
+		sm := newManager(t)
+		changeset, paths := stageTestChangeset(t, sm)
+
+		_, result, err := sm.ApplyChangeset(context.Background(), changeset.ID, &core.VerifyCommand{Command: "exit 3"})
+		if !errors.Is(err, core.ErrVerificationFailed) {
+			t.Fatalf("expected verification failure, got %v", err)
+		}
+		if result == nil || result.Passed || !result.RolledBack {
+			t.Fatalf("expected a rolled back verification, got %+v", result)
+		}
+		assertFiles(t, paths, "func Old()")
+
+		stored, err := sm.GetChangeset(changeset.ID)
+		if err != nil {
+			t.Fatalf("failed to load changeset: %v", err)
+		}
+		if stored.Status != "failed" || len(stored.Verification) == 0 {
+			t.Fatalf("expected changeset failed with its verification, got %s", stored.Status)
+		}
+		for _, stage := range stored.Stages {
+			if stage.Status != "failed" {
+				t.Fatalf("expected stage %s failed, got %s", stage.ID, stage.Status)
+			}
+		}
+	})
+
+	t.Run("stages are not applied alone", func(t *testing.T) {
+		sm := newManager(t)
+		changeset, paths := stageTestChangeset(t, sm)
+
+		_, err := sm.ApplyStage(context.Background(), changeset.Stages[0].ID, false)
+		if err == nil || !strings.Contains(err.Error(), "part of changeset "+changeset.ID) {
+			t.Fatalf("expected the stage to be refused, got %v", err)
+		}
+		assertFiles(t, paths, "func Old()")
+	})
+}
+
+func TestCommonDir(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "repo")
+	paths := []string{
+		filepath.Join(root, "pkg", "a", "a.go"),
+		filepath.Join(root, "pkg", "b", "b.go"),
+		filepath.Join(root, "pkg", "a", "c.go"),
+	}
+	if got := commonDir(paths); got != filepath.Join(root, "pkg") {
+		t.Fatalf("expected the shared parent, got %s", got)
+	}
+	if got := commonDir(paths[:1]); got != filepath.Join(root, "pkg", "a") {
+		t.Fatalf("expected the file's directory, got %s", got)
+	}
+}
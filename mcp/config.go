@@ -4,8 +4,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/oxhq/morfx/core"
+
 	"github.com/oxhq/morfx/internal/securefs"
 )
 
@@ -18,6 +22,20 @@ type Config struct {
 	AutoApplyEnabled   bool
 	AutoApplyThreshold float64
 
+	// TypeCheck type-checks modified files before scoring, for languages
+	// whose provider supports it (Go). MORFX_TYPECHECK=1 turns it on as well.
+	TypeCheck bool
+
+	// Calibrate moves confidence scores toward the keep rate recorded in
+	// apply history for similar stages. MORFX_CALIBRATE=1 turns it on as
+	// well. It needs a database.
+	Calibrate bool
+
+	// Verify decides which verify commands clients may have run after a
+	// write. It refuses them all unless enabled; MORFX_VERIFY=1 enables it
+	// and MORFX_VERIFY_ALLOW adds comma-separated allowed commands.
+	Verify core.VerifyPolicy
+
 	// Staging
 	StagingTTL time.Duration
 
@@ -105,6 +123,20 @@ func DefaultConfig() Config {
 	}
 }
 
+// verifyPolicyFromEnv adds the MORFX_VERIFY and MORFX_VERIFY_ALLOW settings
+// to policy.
+func verifyPolicyFromEnv(policy core.VerifyPolicy) core.VerifyPolicy {
+	if enabled, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv(core.VerifyEnv))); enabled {
+		policy.Enabled = true
+	}
+	for _, allowed := range strings.Split(os.Getenv(core.VerifyAllowEnv), ",") {
+		if allowed = strings.TrimSpace(allowed); allowed != "" {
+			policy.Allow = append(policy.Allow, allowed)
+		}
+	}
+	return policy
+}
+
 // isDirectoryWritable checks if a directory is writable
 func isDirectoryWritable(path string) bool {
 	// Try to create a temporary file
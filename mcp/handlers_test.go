@@ -160,7 +160,7 @@ func TestHandleListTools(t *testing.T) {
 	expectedTools := []string{
 		"query", "file_query", "replace", "file_replace",
 		"delete", "file_delete", "insert_before", "insert_after",
-		"apply", "append", "recipe",
+		"apply", "revert", "append", "recipe", "extract_function", "ensure_import", "remove_import", "sort", "add_annotation", "remove_annotation", "edit_tags", "set_docstring", "annotate", "batch",
 	}
 
 	if len(tools) != len(expectedTools) {
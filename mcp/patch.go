@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/internal/securefs"
+	"github.com/oxhq/morfx/models"
+	"github.com/oxhq/morfx/providers"
+	"github.com/oxhq/morfx/providers/catalog"
+)
+
+// ExportPatch renders the changes of stages, in order, as one patch that
+// `git apply` accepts when run from root. Every stage must write a file
+// under root.
+func ExportPatch(stages []models.Stage, root string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+	var patch strings.Builder
+	for _, stage := range stages {
+		path, err := stageFilePath(&stage)
+		if err != nil {
+			return "", err
+		}
+		if path == "" {
+			return "", fmt.Errorf("stage %s has no file to export", stage.ID)
+		}
+		rel, err := relativePatchPath(absRoot, path)
+		if err != nil {
+			return "", fmt.Errorf("stage %s: %w", stage.ID, err)
+		}
+		patch.WriteString(core.GitPatch(rel, stage.Original, stage.Modified))
+	}
+	return patch.String(), nil
+}
+
+// relativePatchPath returns path relative to root with forward slashes, as
+// patches name files.
+func relativePatchPath(root, path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside %s", path, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// ImportPatch stages the changes a unified diff makes to files under root.
+// Each file patch is applied to the file's current content, so the import
+// fails without staging anything when one does not match. A patch touching
+// one file becomes a pending stage; one touching several becomes a changeset
+// so its files are applied together. Imported changes are scored down for
+// coming from outside Morfx and for leaving a file the language's provider
+// cannot parse. Deleting files is not supported.
+func (sm *StagingManager) ImportPatch(ctx context.Context, sessionID, root, patch string) ([]models.Stage, *models.Changeset, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	patches, err := core.ParsePatch(patch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid patch: %w", err)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+
+	changes := make([]core.FileChange, 0, len(patches))
+	for _, filePatch := range patches {
+		if filePatch.Deletes() {
+			return nil, nil, fmt.Errorf("%s: deleting files is not supported", filePatch.Path())
+		}
+		path := filepath.Join(absRoot, filepath.FromSlash(filePatch.Path()))
+		if _, err := relativePatchPath(absRoot, path); err != nil {
+			return nil, nil, err
+		}
+		current, err := securefs.ReadFile(path)
+		switch {
+		case os.IsNotExist(err) && !filePatch.Creates():
+			return nil, nil, fmt.Errorf("%s does not exist", filePatch.Path())
+		case err == nil && filePatch.Creates():
+			return nil, nil, fmt.Errorf("%s already exists", filePatch.Path())
+		case err != nil && !os.IsNotExist(err):
+			return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		modified, err := core.ApplyPatch(string(current), filePatch)
+		if err != nil {
+			return nil, nil, err
+		}
+		if modified == string(current) {
+			continue
+		}
+		language := "unknown"
+		if info, ok := catalog.LookupByExtension(filepath.Ext(path)); ok {
+			language = info.ID
+		}
+		changes = append(changes, core.FileChange{
+			Path:       path,
+			Language:   language,
+			Original:   string(current),
+			Modified:   modified,
+			Confidence: sm.scoreImport(language, modified),
+		})
+	}
+	if len(changes) == 0 {
+		return nil, nil, fmt.Errorf("patch changes nothing")
+	}
+
+	if len(changes) > 1 {
+		changeset, err := sm.CreateChangeset(ctx, sessionID, "import", fmt.Sprintf("imported patch over %d files", len(changes)), changes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return changeset.Stages, changeset, nil
+	}
+	stage := sm.fileChangeStage(sessionID, "import", changes[0])
+	if err := sm.CreateStage(ctx, &stage); err != nil {
+		return nil, nil, err
+	}
+	return []models.Stage{stage}, nil, nil
+}
+
+// sourceValidator is implemented by providers that can check syntax.
+type sourceValidator interface {
+	Validate(source string) providers.ValidationResult
+}
+
+// scoreImport scores a file change made by an imported patch.
+func (sm *StagingManager) scoreImport(language, modified string) core.ConfidenceScore {
+	factors := []core.ConfidenceFactor{{
+		Name:   "external_patch",
+		Impact: -0.15,
+		Reason: "change was produced outside Morfx",
+	}}
+	var validator sourceValidator
+	if sm.providers != nil {
+		if provider, ok := sm.providers.Get(language); ok {
+			validator, _ = provider.(sourceValidator)
+		}
+	}
+	switch {
+	case validator == nil:
+		factors = append(factors, core.ConfidenceFactor{
+			Name:   "unvalidated_language",
+			Impact: -0.1,
+			Reason: fmt.Sprintf("no provider checks %s syntax", language),
+		})
+	case !validator.Validate(modified).Valid:
+		factors = append(factors, core.ConfidenceFactor{
+			Name:   "syntax_errors",
+			Impact: -0.4,
+			Reason: "patched file does not parse",
+		})
+	}
+
+	score := 1.0
+	for _, factor := range factors {
+		score += factor.Impact
+	}
+	level := "low"
+	switch {
+	case score >= 0.8:
+		level = "high"
+	case score >= 0.5:
+		level = "medium"
+	}
+	return core.ConfidenceScore{Score: score, Level: level, Factors: factors}
+}
@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/models"
+	"github.com/oxhq/morfx/providers"
+	"github.com/oxhq/morfx/providers/golang"
+)
+
+func TestExportPatch(t *testing.T) {
+	root := t.TempDir()
+	stageAt := func(path, original, modified string) models.Stage {
+		return models.Stage{
+			ID:       "stg_" + filepath.Base(path),
+			Original: original,
+			Modified: modified,
+			ScopeAST: mustMarshalJSON(map[string]any{"file_path": path}),
+		}
+	}
+
+	t.Run("names files relative to root", func(t *testing.T) {
+		patch, err := ExportPatch([]models.Stage{
+			stageAt(filepath.Join(root, "pkg", "a.go"), "package pkg\n", "package pkg // a\n"),
+			stageAt(filepath.Join(root, "b.go"), "", "package main\n"),
+		}, root)
+		if err != nil {
+			t.Fatalf("ExportPatch failed: %v", err)
+		}
+		for _, want := range []string{
+			"diff --git a/pkg/a.go b/pkg/a.go\n",
+			"--- a/pkg/a.go\n+++ b/pkg/a.go\n",
+			"diff --git a/b.go b/b.go\nnew file mode 100644\n",
+			"--- /dev/null\n+++ b/b.go\n",
+		} {
+			if !strings.Contains(patch, want) {
+				t.Errorf("patch is missing %q:\n%s", want, patch)
+			}
+		}
+	})
+
+	t.Run("rejects files outside root", func(t *testing.T) {
+		outside := filepath.Join(filepath.Dir(root), "elsewhere.go")
+		_, err := ExportPatch([]models.Stage{stageAt(outside, "a\n", "b\n")}, root)
+		if err == nil || !strings.Contains(err.Error(), "is outside") {
+			t.Fatalf("expected outside-root error, got %v", err)
+		}
+	})
+}
+
+func TestImportPatch(t *testing.T) {
+	const (
+		mainGo  = "package main\n\nfunc main() {}\n"
+		helpGo  = "package main\n\nfunc help() {}\n"
+		patched = "package main\n\nfunc main() { help() }\n"
+	)
+
+	setup := func(t *testing.T) (*StagingManager, string) {
+		t.Helper()
+		registry := providers.NewRegistry()
+		registry.Register(golang.New())
+		sm := NewStagingManager(setupAsyncStagingDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+		sm.SetProviders(registry)
+
+		root := t.TempDir()
+		for name, content := range map[string]string{"main.go": mainGo, "help.go": helpGo} {
+			if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+				t.Fatalf("failed to write %s: %v", name, err)
+			}
+		}
+		return sm, root
+	}
+
+	t.Run("stages a single file", func(t *testing.T) {
+		sm, root := setup(t)
+		stages, changeset, err := sm.ImportPatch(context.Background(), "s1", root, core.GitPatch("main.go", mainGo, patched))
+		if err != nil {
+			t.Fatalf("ImportPatch failed: %v", err)
+		}
+		if changeset != nil || len(stages) != 1 {
+			t.Fatalf("expected one stage and no changeset, got %d stages, changeset %v", len(stages), changeset)
+		}
+		stage, err := sm.GetStage(stages[0].ID)
+		if err != nil {
+			t.Fatalf("GetStage failed: %v", err)
+		}
+		if stage.Operation != "import" || stage.Language != "go" || stage.Modified != patched {
+			t.Errorf("unexpected stage: operation %q, language %q, modified %q", stage.Operation, stage.Language, stage.Modified)
+		}
+		if stage.ConfidenceScore < 0.84 || stage.ConfidenceScore > 0.86 || stage.ConfidenceLevel != "high" {
+			t.Errorf("expected confidence 0.85 (high), got %.2f (%s)", stage.ConfidenceScore, stage.ConfidenceLevel)
+		}
+		if path, _ := stageFilePath(stage); path != filepath.Join(root, "main.go") {
+			t.Errorf("expected stage for main.go, got %q", path)
+		}
+	})
+
+	t.Run("scores down files that do not parse", func(t *testing.T) {
+		sm, root := setup(t)
+		stages, _, err := sm.ImportPatch(context.Background(), "s1", root, core.GitPatch("main.go", mainGo, "package main\n\nfunc main() {\n"))
+		if err != nil {
+			t.Fatalf("ImportPatch failed: %v", err)
+		}
+		if stages[0].ConfidenceLevel != "low" {
+			t.Errorf("expected low confidence, got %.2f (%s)", stages[0].ConfidenceScore, stages[0].ConfidenceLevel)
+		}
+	})
+
+	t.Run("stages several files as a changeset", func(t *testing.T) {
+		sm, root := setup(t)
+		patch := core.GitPatch("main.go", mainGo, patched) +
+			core.GitPatch("help.go", helpGo, "package main\n\nfunc help() { println() }\n") +
+			core.GitPatch("extra.go", "", "package main\n")
+		stages, changeset, err := sm.ImportPatch(context.Background(), "s1", root, patch)
+		if err != nil {
+			t.Fatalf("ImportPatch failed: %v", err)
+		}
+		if changeset == nil || len(stages) != 3 {
+			t.Fatalf("expected a changeset of 3 stages, got %d stages, changeset %v", len(stages), changeset)
+		}
+		stored, err := sm.GetChangeset(changeset.ID)
+		if err != nil {
+			t.Fatalf("GetChangeset failed: %v", err)
+		}
+		if stored.Operation != "import" || len(stored.Stages) != 3 {
+			t.Errorf("unexpected changeset: operation %q, %d stages", stored.Operation, len(stored.Stages))
+		}
+	})
+
+	t.Run("stages nothing when a file does not match", func(t *testing.T) {
+		sm, root := setup(t)
+		patch := core.GitPatch("main.go", mainGo, patched) +
+			core.GitPatch("help.go", "package main\n\nfunc other() {}\n", helpGo)
+		_, _, err := sm.ImportPatch(context.Background(), "s1", root, patch)
+		if !errors.Is(err, core.ErrPatchMismatch) {
+			t.Fatalf("expected ErrPatchMismatch, got %v", err)
+		}
+		pending, err := sm.ListPendingStages("s1")
+		if err != nil {
+			t.Fatalf("ListPendingStages failed: %v", err)
+		}
+		if len(pending) != 0 {
+			t.Errorf("expected no stages, got %d", len(pending))
+		}
+	})
+
+	t.Run("rejects creating an existing file", func(t *testing.T) {
+		sm, root := setup(t)
+		_, _, err := sm.ImportPatch(context.Background(), "s1", root, core.GitPatch("main.go", "", mainGo))
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Fatalf("expected already-exists error, got %v", err)
+		}
+	})
+}
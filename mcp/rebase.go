@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/internal/securefs"
+	"github.com/oxhq/morfx/models"
+)
+
+// Ways a stale stage is rebased, recorded on its apply
+const (
+	rebaseTransform = "transform"
+	rebaseMerge     = "merge"
+)
+
+// rebaseStaleStage rebases stage onto its file's current content when the
+// file changed after staging, returning how it was rebased, or "" when the
+// file is unchanged or the stage writes no file.
+func (sm *StagingManager) rebaseStaleStage(stage *models.Stage) (string, error) {
+	path, err := stageFilePath(stage)
+	if err != nil || path == "" {
+		return "", err
+	}
+	current, err := securefs.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if calculateSHA256(string(current)) == stage.BaseDigest {
+		return "", nil
+	}
+	return sm.rebaseStage(stage, path, string(current))
+}
+
+// rebaseStage updates stage to apply on top of current, the content of path
+// now. The stage's transform is re-run against current first; when that is
+// not possible, its Original→Modified edit is 3-way merged onto current. When
+// both fail the stage is left alone and the error wraps core.ErrMergeConflict.
+func (sm *StagingManager) rebaseStage(stage *models.Stage, path, current string) (string, error) {
+	modified, result, transformErr := sm.retransformStage(stage, current)
+	strategy := rebaseTransform
+	if transformErr != nil {
+		merged, conflicts := core.Merge3(stage.Original, current, stage.Modified)
+		if len(conflicts) > 0 {
+			ranges := make([]string, 0, len(conflicts))
+			for _, conflict := range conflicts {
+				ranges = append(ranges, conflict.String())
+			}
+			return "", fmt.Errorf("stage %s cannot be applied: %w in %s: changed since staging at %s, and re-running its transform failed: %v",
+				stage.ID, core.ErrMergeConflict, path, strings.Join(ranges, ", "), transformErr)
+		}
+		modified, strategy = merged, rebaseMerge
+	}
+
+	stage.StructuralDiff = nil
+	if result != nil {
+		// Rebasing never raises the confidence the stage was reviewed at
+		if result.Confidence.Score < stage.ConfidenceScore {
+			stage.ConfidenceScore = result.Confidence.Score
+			stage.ConfidenceLevel = result.Confidence.Level
+			stage.ConfidenceFactors = mustMarshalJSON(result.Confidence.Factors)
+		}
+		if changes, ok := result.Metadata["structural_diff"]; ok {
+			stage.StructuralDiff = mustMarshalJSON(changes)
+		}
+	}
+	stage.Original = current
+	stage.Modified = modified
+	stage.BaseDigest = calculateSHA256(current)
+	stage.AfterDigest = calculateSHA256(modified)
+	stage.Diff = core.UnifiedDiff(current, modified)
+	if len(stage.ScopeAST) > 0 {
+		var scope map[string]any
+		if err := json.Unmarshal(stage.ScopeAST, &scope); err != nil {
+			return "", fmt.Errorf("failed to decode stage scope: %w", err)
+		}
+		if stage.BaseDigest != "" {
+			scope["original_hash"] = stage.BaseDigest
+		} else {
+			delete(scope, "original_hash")
+		}
+		scope["file_size"] = len(modified)
+		scope["rebased"] = strategy
+		stage.ScopeAST = mustMarshalJSON(scope)
+	}
+	return strategy, nil
+}
+
+// retransformStage re-runs the transform recorded on stage against current.
+func (sm *StagingManager) retransformStage(stage *models.Stage, current string) (string, *core.TransformResult, error) {
+	if len(stage.TransformOp) == 0 || string(stage.TransformOp) == "null" {
+		return "", nil, errors.New("no transform recorded")
+	}
+	if sm.providers == nil {
+		return "", nil, errors.New("no providers available")
+	}
+	provider, ok := sm.providers.Get(stage.Language)
+	if !ok {
+		return "", nil, fmt.Errorf("language not supported: %s", stage.Language)
+	}
+	var op core.TransformOp
+	if err := json.Unmarshal(stage.TransformOp, &op); err != nil {
+		return "", nil, fmt.Errorf("failed to decode transform: %w", err)
+	}
+	// The rebased stage keeps a structural diff of its new content
+	op.StructuralDiff = true
+	result := provider.Transform(current, op)
+	if result.Error != nil {
+		return "", nil, result.Error
+	}
+	if result.MatchCount == 0 {
+		return "", nil, errors.New("target no longer matches")
+	}
+	return result.Modified, &result, nil
+}
+
+// saveRebase stores the content, digests, and confidence a rebase gave stage.
+func saveRebase(tx *gorm.DB, stage *models.Stage) error {
+	return tx.Model(&models.Stage{}).Where("id = ?", stage.ID).Updates(map[string]any{
+		"original":           stage.Original,
+		"modified":           stage.Modified,
+		"diff":               stage.Diff,
+		"structural_diff":    stage.StructuralDiff,
+		"base_digest":        stage.BaseDigest,
+		"after_digest":       stage.AfterDigest,
+		"confidence_score":   stage.ConfidenceScore,
+		"confidence_level":   stage.ConfidenceLevel,
+		"confidence_factors": stage.ConfidenceFactors,
+		"scope_ast":          stage.ScopeAST,
+	}).Error
+}
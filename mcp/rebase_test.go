@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/models"
+	"github.com/oxhq/morfx/providers"
+	"github.com/oxhq/morfx/providers/golang"
+)
+
+func TestApplyStageRebasesStaleStage(t *testing.T) {
+	original := "package main\n\nfunc Old() {}\n\nfunc keep() {}\n"
+	modified := "package main\n\nfunc New() {}\n\nfunc keep() {}\n"
+	op := core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "function", Name: "Old"},
+		Replacement: "func New() {}",
+	}
+
+	// stageEdit stages the Old→New replacement of a file, then edits the file
+	// to current as if someone changed it during review.
+	stageEdit := func(t *testing.T, withOp bool, current string) (*StagingManager, string) {
+		t.Helper()
+		registry := providers.NewRegistry()
+		registry.Register(golang.New())
+		sm := NewStagingManager(setupAsyncStagingDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+		sm.SetProviders(registry)
+
+		path := filepath.Join(t.TempDir(), "main.go")
+		stage := &models.Stage{
+			ID:              "stg_rebase",
+			Language:        "go",
+			Operation:       "replace",
+			Original:        original,
+			Modified:        modified,
+			Diff:            core.UnifiedDiff(original, modified),
+			BaseDigest:      calculateSHA256(original),
+			AfterDigest:     calculateSHA256(modified),
+			ConfidenceScore: 0.95,
+			ConfidenceLevel: "high",
+			ScopeAST:        mustMarshalJSON(map[string]any{"file_path": path}),
+			Status:          "pending",
+		}
+		if withOp {
+			stage.TransformOp = mustMarshalJSON(op)
+		}
+		if err := sm.CreateStage(context.Background(), stage); err != nil {
+			t.Fatalf("failed to create stage: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(current), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		return sm, path
+	}
+	readFile := func(t *testing.T, path string) string {
+		t.Helper()
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", path, err)
+		}
+		return string(content)
+	}
+
+	t.Run("re-runs the transform", func(t *testing.T) {
+		// The edit overlaps the staged change, so only re-running it works
+		current := "package main\n\nfunc Old() { println() }\n\nfunc keep() {}\n"
+		sm, path := stageEdit(t, true, current)
+
+		apply, err := sm.ApplyStage(context.Background(), "stg_rebase", false)
+		if err != nil {
+			t.Fatalf("ApplyStage failed: %v", err)
+		}
+		if apply.Rebased != "transform" {
+			t.Fatalf("expected a transform rebase, got %q", apply.Rebased)
+		}
+		content := readFile(t, path)
+		if !strings.Contains(content, "func New() {}") || strings.Contains(content, "Old") {
+			t.Fatalf("expected the replacement re-applied, got:\n%s", content)
+		}
+
+		stage, err := sm.GetStage("stg_rebase")
+		if err != nil {
+			t.Fatalf("failed to load stage: %v", err)
+		}
+		if stage.Original != current || stage.BaseDigest != calculateSHA256(current) || stage.AfterDigest != calculateSHA256(content) {
+			t.Fatalf("expected the stage rebased onto the edited file, got base %q", stage.Original)
+		}
+		if apply.BaseDigest != stage.BaseDigest || apply.AfterDigest != stage.AfterDigest {
+			t.Fatalf("expected the apply to record the rebased digests")
+		}
+	})
+
+	t.Run("merges without a transform", func(t *testing.T) {
+		current := "package main\n\nfunc Old() {}\n\nfunc keep() { println() }\n"
+		sm, path := stageEdit(t, false, current)
+
+		apply, err := sm.ApplyStage(context.Background(), "stg_rebase", false)
+		if err != nil {
+			t.Fatalf("ApplyStage failed: %v", err)
+		}
+		if apply.Rebased != "merge" {
+			t.Fatalf("expected a merge rebase, got %q", apply.Rebased)
+		}
+		if content := readFile(t, path); content != "package main\n\nfunc New() {}\n\nfunc keep() { println() }\n" {
+			t.Fatalf("expected both edits kept, got:\n%s", content)
+		}
+	})
+
+	t.Run("reports a conflict when both fail", func(t *testing.T) {
+		current := "package main\n\nfunc Older() {}\n\nfunc keep() {}\n"
+		sm, path := stageEdit(t, true, current)
+
+		_, err := sm.ApplyStage(context.Background(), "stg_rebase", false)
+		if !errors.Is(err, core.ErrMergeConflict) {
+			t.Fatalf("expected a merge conflict, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "at line 3") || !strings.Contains(err.Error(), "re-running its transform failed") {
+			t.Fatalf("expected the conflict and transform failure reported, got %v", err)
+		}
+		if content := readFile(t, path); content != current {
+			t.Fatalf("expected the file untouched, got:\n%s", content)
+		}
+		stage, err := sm.GetStage("stg_rebase")
+		if err != nil {
+			t.Fatalf("failed to load stage: %v", err)
+		}
+		if stage.Status != "pending" || stage.Original != original {
+			t.Fatalf("expected the stage left pending as staged, got %s", stage.Status)
+		}
+	})
+}
@@ -130,7 +130,7 @@ func classifyToolCategory(name string) string {
 		return "file-transform"
 	case name == "query" || name == "file_query":
 		return "analysis"
-	case name == "apply":
+	case name == "apply" || name == "revert":
 		return "staging"
 	case name == "recipe":
 		return "workflow"
@@ -162,7 +162,7 @@ func toolScope(name string) string {
 
 func toolStability(name string) string {
 	switch name {
-	case "apply":
+	case "apply", "revert":
 		return "beta"
 	default:
 		return "stable"
@@ -170,14 +170,25 @@ func toolStability(name string) string {
 }
 
 var builtinProgressTools = map[string]struct{}{
-	"append":        {},
-	"apply":         {},
-	"delete":        {},
-	"insert_after":  {},
-	"insert_before": {},
-	"query":         {},
-	"recipe":        {},
-	"replace":       {},
+	"add_annotation":    {},
+	"annotate":          {},
+	"append":            {},
+	"apply":             {},
+	"batch":             {},
+	"delete":            {},
+	"edit_tags":         {},
+	"ensure_import":     {},
+	"extract_function":  {},
+	"insert_after":      {},
+	"insert_before":     {},
+	"query":             {},
+	"recipe":            {},
+	"remove_annotation": {},
+	"remove_import":     {},
+	"replace":           {},
+	"revert":            {},
+	"set_docstring":     {},
+	"sort":              {},
 }
 
 func toolSupportsProgress(name string) bool {
@@ -24,11 +24,12 @@ type ResourceContent struct {
 // ResourceDefinitions returns all available resource definitions
 
 var builtinResourceURIs = map[string]struct{}{
-	"morfx://server/info":         {},
-	"morfx://server/capabilities": {},
-	"morfx://providers/languages": {},
-	"morfx://session/current":     {},
-	"morfx://config/settings":     {},
+	"morfx://server/info":            {},
+	"morfx://server/capabilities":    {},
+	"morfx://providers/languages":    {},
+	"morfx://session/current":        {},
+	"morfx://config/settings":        {},
+	"morfx://confidence/calibration": {},
 }
 
 func resourceContentLength(content *ResourceContent) int64 {
@@ -117,6 +118,17 @@ func (s *StdioServer) ResourceDefinitions() []types.ResourceDefinition {
 				"readonly": true,
 			},
 		},
+		{
+			URI:         "morfx://confidence/calibration",
+			Name:        "confidence-calibration",
+			Title:       "Confidence Calibration",
+			Description: "How often applies at each confidence score, language, operation, and factor were later reverted",
+			MimeType:    "application/json",
+			Annotations: map[string]any{
+				"category": "config",
+				"readonly": true,
+			},
+		},
 	}
 
 	for i := range defs {
@@ -190,7 +202,7 @@ func (s *StdioServer) enrichRuntimeResourceMetadata(def *types.ResourceDefinitio
 			def.Annotations["stability"] = "stable"
 		}
 		if def.Size == nil {
-			if def.URI == "morfx://server/capabilities" {
+			if def.URI == "morfx://server/capabilities" || def.URI == "morfx://confidence/calibration" {
 				return
 			}
 			var content *ResourceContent
@@ -270,6 +282,8 @@ func (s *StdioServer) generateResourceContent(uri string) (*ResourceContent, err
 		return s.generateCurrentSession()
 	case "morfx://config/settings":
 		return s.generateConfigSettings()
+	case "morfx://confidence/calibration":
+		return s.generateConfidenceCalibration()
 	default:
 		if res, ok := s.lookupResource(uri); ok {
 			text, err := res.Contents()
@@ -369,7 +383,7 @@ func (s *StdioServer) generateServerCapabilities() (*ResourceContent, error) {
 			"attributes":          commonDSLAttributes(),
 		},
 		"transformations": []string{
-			"query", "replace", "delete", "insert_before", "insert_after", "append",
+			"query", "replace", "delete", "insert_before", "insert_after", "append", "extract_function", "ensure_import", "remove_import", "sort", "add_annotation", "remove_annotation", "edit_tags", "set_docstring", "annotate", "batch",
 		},
 		"file_operations": map[string]any{
 			"supported": true,
@@ -443,7 +457,7 @@ func (s *StdioServer) supportedLanguagePayload() []map[string]any {
 }
 
 func commonDSLAttributes() []string {
-	return []string{"type", "text", "source", "arg", "arg0", "argN", "before", "after"}
+	return []string{"type", "text", "source", "arg", "arg0", "argN", "before", "after", "lines"}
 }
 
 func displayLanguageName(language string) string {
@@ -512,6 +526,7 @@ func (s *StdioServer) generateConfigSettings() (*ResourceContent, error) {
 		"max_stages_per_session":  s.config.MaxStagesPerSession,
 		"max_applies_per_session": s.config.MaxAppliesPerSession,
 		"debug":                   s.config.Debug,
+		"calibrated_scoring":      s.calibrated,
 	}
 
 	if s.config.DatabaseURL != "" {
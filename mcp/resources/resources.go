@@ -215,7 +215,17 @@ func NewTransformationMethodsResource() *BaseResource {
     {"name": "delete", "description": "Delete code elements"},
     {"name": "insert_before", "description": "Insert code before elements"},
     {"name": "insert_after", "description": "Insert code after elements"},
-    {"name": "append", "description": "Append code to elements"}
+    {"name": "append", "description": "Append code to elements"},
+    {"name": "extract_function", "description": "Extract statements into a new function"},
+    {"name": "ensure_import", "description": "Add an import unless it is already present"},
+    {"name": "remove_import", "description": "Remove imported bindings"},
+    {"name": "sort", "description": "Reorder sibling members by name, visibility, kind, or capture"},
+    {"name": "add_annotation", "description": "Attach decorators, attributes, or struct tags"},
+    {"name": "remove_annotation", "description": "Remove decorators, attributes, or struct tags"},
+    {"name": "edit_tags", "description": "Add, remove, or rewrite Go struct tag keys"},
+    {"name": "set_docstring", "description": "Replace or insert Python docstrings"},
+    {"name": "annotate", "description": "Add or replace Python parameter and return type hints"},
+    {"name": "batch", "description": "Apply several operations to one file as a single change"}
   ]
 }`, nil
 		},
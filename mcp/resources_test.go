@@ -411,6 +411,7 @@ func TestGenerateResourceContent_AllResources(t *testing.T) {
 		"morfx://providers/languages",
 		"morfx://session/current",
 		"morfx://config/settings",
+		"morfx://confidence/calibration",
 	}
 
 	for _, uri := range resourceURIs {
@@ -46,6 +46,18 @@ type StdioServer struct {
 	// File processor for filesystem operations
 	fileProcessor *core.FileProcessor
 
+	// Confidence policy applied before staging, or nil
+	scorer core.ConfidenceScorer
+
+	// Whether modified files are type-checked before scoring
+	typeCheck bool
+
+	// Whether scores are calibrated against apply history
+	calibrated bool
+
+	// Verify commands clients may have run
+	verifyPolicy core.VerifyPolicy
+
 	// Session tracking
 	session *models.Session
 
@@ -162,7 +174,10 @@ func NewStdioServer(config Config) (*StdioServer, error) {
 		server.toolRegistry.Register(tool.Name(), tool)
 	}
 
-	rt, err := runtime.Build(runtime.Config{TransactionLogDir: defaultTransactionLogDir()})
+	rt, err := runtime.Build(runtime.Config{
+		TransactionLogDir: defaultTransactionLogDir(),
+		TypeCheck:         config.TypeCheck,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -201,6 +216,21 @@ func NewStdioServer(config Config) (*StdioServer, error) {
 	// Initialize file processor with shared runtime builder
 	server.fileProcessor = rt.FileProcessor
 	server.debugLog("Initialized file processor")
+	if rt.Scorer != nil {
+		server.scorer = rt.Scorer
+		server.debugLog("Loaded confidence policy")
+	}
+	server.typeCheck = rt.TypeCheck
+	server.verifyPolicy = verifyPolicyFromEnv(config.Verify)
+	if server.verifyPolicy.Enabled {
+		server.debugLog("Verify commands enabled (allowlist: %v)", server.verifyPolicy.Allow)
+	}
+	if server.db != nil && (config.Calibrate || calibrateFromEnv()) {
+		server.scorer = newCalibratedScorer(server.db, server.scorer)
+		server.fileProcessor.SetConfidenceScorer(server.scorer)
+		server.calibrated = true
+		server.debugLog("Calibrating confidence against apply history")
+	}
 	if txLogDir := defaultTransactionLogDir(); txLogDir != "" {
 		server.debugLog("Configured file transaction log dir: %s", txLogDir)
 	}
@@ -213,6 +243,7 @@ func NewStdioServer(config Config) (*StdioServer, error) {
 	// Initialize staging manager once safety is available
 	if server.db != nil && server.session != nil {
 		server.staging = NewStagingManager(server.db, config, server.safety)
+		server.staging.SetProviders(server.providers)
 		server.debugLog("Initialized staging manager")
 	} else if server.db != nil {
 		server.debugLog("Skipping staging manager because persistence is not writable")
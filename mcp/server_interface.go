@@ -48,6 +48,12 @@ func (s *StdioServer) GetSessionID() string {
 	return ""
 }
 
+// CheckVerify refuses verify commands the server's verify policy does not
+// allow.
+func (s *StdioServer) CheckVerify(verify *core.VerifyCommand) error {
+	return s.verifyPolicy.Check(verify)
+}
+
 // ReportProgress emits a progress notification if the context carries a token.
 func (s *StdioServer) ReportProgress(ctx context.Context, progress, total float64, message string) {
 	if token, ok := progressTokenFromContext(ctx); ok {
@@ -1152,6 +1152,10 @@ func (a *applyToolServerAdapter) GetSessionID() string {
 	return "test-session"
 }
 
+func (a *applyToolServerAdapter) CheckVerify(verify *core.VerifyCommand) error {
+	return a.inner.CheckVerify(verify)
+}
+
 func (a *applyToolServerAdapter) ReportProgress(ctx context.Context, progress, total float64, message string) {
 	a.inner.ReportProgress(ctx, progress, total, message)
 }
@@ -3,22 +3,28 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 
+	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/internal/securefs"
+	"github.com/oxhq/morfx/mcp/types"
 	"github.com/oxhq/morfx/models"
+	"github.com/oxhq/morfx/providers"
 )
 
 // StagingManager handles staging and applying transformations
 type StagingManager struct {
-	db     *gorm.DB
-	config Config
-	safety *SafetyManager
+	db        *gorm.DB
+	config    Config
+	safety    *SafetyManager
+	providers core.ProviderRegistry
 }
 
 // IsEnabled reports whether staging support is active. The manager is enabled
@@ -36,6 +42,16 @@ func NewStagingManager(db *gorm.DB, config Config, safety *SafetyManager) *Stagi
 	}
 }
 
+// SetProviders lets stale stages be rebased by re-running their transform
+// with the registry's providers. Without it they are only 3-way merged.
+func (sm *StagingManager) SetProviders(registry *providers.Registry) {
+	if registry == nil {
+		sm.providers = nil
+		return
+	}
+	sm.providers = &providerRegistryAdapter{registry}
+}
+
 // CreateStage creates a new staged transformation while honoring cancellation.
 func (sm *StagingManager) CreateStage(ctx context.Context, stage *models.Stage) error {
 	// Validate stage is not nil
@@ -101,6 +117,10 @@ func (sm *StagingManager) GetStage(id string) (*models.Stage, error) {
 }
 
 // ApplyStage applies a staged transformation while honoring cancellation.
+// When the file changed after staging, the stage is first rebased onto its
+// current content: its transform is re-run, or failing that its edit is
+// 3-way merged, and only a conflict in both is reported as an error wrapping
+// core.ErrMergeConflict. The rebased content is stored on the stage.
 func (sm *StagingManager) ApplyStage(ctx context.Context, stageID string, autoApplied bool) (*models.Apply, error) {
 	var (
 		apply      *models.Apply
@@ -128,6 +148,9 @@ func (sm *StagingManager) ApplyStage(ctx context.Context, stageID string, autoAp
 		if stage.Status != "pending" {
 			return fmt.Errorf("stage already %s", stage.Status)
 		}
+		if stage.ChangesetID != "" {
+			return fmt.Errorf("stage %s is part of changeset %s: apply the changeset", stageID, stage.ChangesetID)
+		}
 
 		// Check expiration
 		if time.Now().After(stage.ExpiresAt) {
@@ -152,8 +175,12 @@ func (sm *StagingManager) ApplyStage(ctx context.Context, stageID string, autoAp
 			}
 		}
 
+		var rebased string
 		if !autoApplied {
 			var err error
+			if rebased, err = sm.rebaseStaleStage(&stage); err != nil {
+				return err
+			}
 			stageWrite, err = sm.prepareStageWrite(&stage)
 			if err != nil {
 				return err
@@ -164,8 +191,11 @@ func (sm *StagingManager) ApplyStage(ctx context.Context, stageID string, autoAp
 		apply = &models.Apply{
 			ID:          generateID("apl"),
 			StageID:     stageID,
+			BaseDigest:  stage.BaseDigest,
+			AfterDigest: stage.AfterDigest,
 			AutoApplied: autoApplied,
 			AppliedBy:   "mcp",
+			Rebased:     rebased,
 		}
 
 		if autoApplied {
@@ -215,25 +245,223 @@ func (sm *StagingManager) ApplyStage(ctx context.Context, stageID string, autoAp
 	return apply, nil
 }
 
-func (sm *StagingManager) prepareStageWrite(stage *models.Stage) (*fileWriteGuard, error) {
-	if stage == nil {
-		return nil, fmt.Errorf("stage cannot be nil")
+// ApplyStageVerified applies a stage inside a core transaction and runs
+// verify in the directory of the written file. When verify fails, the file is
+// restored through TransactionManager.RollbackTransaction, the stage is marked
+// failed, and its apply record is marked reverted. Either way the outcome is
+// stored on the stage.
+func (sm *StagingManager) ApplyStageVerified(ctx context.Context, stageID string, verify *core.VerifyCommand) (*models.Apply, *core.VerifyResult, error) {
+	if verify == nil {
+		apply, err := sm.ApplyStage(ctx, stageID, false)
+		return apply, nil, err
+	}
+	if err := verify.Validate(); err != nil {
+		return nil, nil, err
+	}
+	stage, err := sm.GetStage(stageID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stage not found: %w", err)
+	}
+	path, err := stageFilePath(stage)
+	if err != nil {
+		return nil, nil, err
+	}
+	if path == "" {
+		return nil, nil, fmt.Errorf("stage %s has no file to verify", stageID)
 	}
 
-	if len(stage.ScopeAST) == 0 {
-		return nil, nil
+	txManager, _, cleanup, err := beginStageTransaction("Apply stage " + stageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanup()
+	opType := "modify"
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		opType = "create"
+	}
+	if _, err := txManager.AddOperation(opType, path); err != nil {
+		_ = txManager.RollbackTransaction()
+		return nil, nil, err
+	}
+
+	apply, err := sm.ApplyStage(ctx, stageID, false)
+	if err != nil {
+		_ = txManager.RollbackTransaction()
+		return nil, nil, err
+	}
+	if err := txManager.CompleteOperation(path, nil); err != nil {
+		_ = txManager.RollbackTransaction()
+		return nil, nil, err
+	}
+
+	result := verify.Run(ctx, filepath.Dir(path))
+	if result.Passed {
+		if err := txManager.CommitTransaction(); err != nil {
+			return nil, nil, err
+		}
+		if err := sm.db.Model(&models.Stage{}).Where("id = ?", stageID).
+			Update("verification", mustMarshalJSON(result)).Error; err != nil {
+			return apply, &result, fmt.Errorf("failed to record verification: %w", err)
+		}
+		return apply, &result, nil
+	}
+
+	if err := txManager.RollbackTransaction(); err != nil {
+		result.Error = err.Error()
+	} else {
+		result.RolledBack = true
+	}
+	now := time.Now()
+	err = sm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Stage{}).Where("id = ?", stageID).Updates(map[string]any{
+			"status":       "failed",
+			"verification": mustMarshalJSON(result),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Apply{}).Where("id = ?", apply.ID).Updates(map[string]any{
+			"reverted":    true,
+			"reverted_by": "verify",
+			"reverted_at": &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, &result, fmt.Errorf("failed to record verification: %w", err)
+	}
+	return nil, &result, fmt.Errorf("stage %s %w: %s", stageID, core.ErrVerificationFailed, result.Summary())
+}
+
+// beginStageTransaction starts a core transaction for writing staged files,
+// logged under the state directory or, failing that, a temporary directory
+// removed by cleanup. Files written through writer are restored on rollback.
+func beginStageTransaction(description string) (*core.TransactionManager, *core.AtomicWriter, func(), error) {
+	logDir, cleanup := defaultTransactionLogDir(), func() {}
+	if logDir == "" {
+		tempDir, err := os.MkdirTemp("", "morfx-transactions-*")
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to create transaction log directory: %w", err)
+		}
+		logDir, cleanup = tempDir, func() { _ = os.RemoveAll(tempDir) }
+	}
+	atomicConfig := core.DefaultAtomicConfig()
+	atomicConfig.BackupOriginal = false
+	writer := core.NewAtomicWriter(atomicConfig)
+	txManager := core.NewTransactionManager(logDir, writer)
+	if _, err := txManager.BeginTransaction(description); err != nil {
+		cleanup()
+		return nil, nil, nil, err
+	}
+	return txManager, writer, cleanup, nil
+}
+
+// ApplyStageHunks applies only the selected 1-based hunks of a stage's diff,
+// running verify afterwards when set. The stage is narrowed to the selected
+// hunks before it is applied, and the rest of its change becomes a new
+// pending stage based on the partially applied file, which is returned. If
+// the apply fails, the stage is widened back and the remainder discarded.
+func (sm *StagingManager) ApplyStageHunks(ctx context.Context, stageID string, hunks []int, verify *core.VerifyCommand) (*models.Apply, *models.Stage, *core.VerifyResult, error) {
+	stage, err := sm.GetStage(stageID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("stage not found: %w", err)
+	}
+	if stage.Status != "pending" {
+		return nil, nil, nil, fmt.Errorf("stage already %s", stage.Status)
+	}
+	if len(hunks) == 0 {
+		return nil, nil, nil, fmt.Errorf("no hunks selected")
+	}
+	partial, err := core.ApplyHunks(stage.Original, stage.Modified, hunks)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if partial == stage.Modified {
+		apply, result, err := sm.ApplyStageVerified(ctx, stageID, verify)
+		return apply, nil, result, err
+	}
+
+	remainder := *stage
+	remainder.ID = generateID("stg")
+	remainder.Apply = nil
+	remainder.Original = partial
+	remainder.BaseDigest = calculateSHA256(partial)
+	remainder.Diff = core.UnifiedDiff(partial, stage.Modified)
+	remainder.StructuralDiff = nil
+	remainder.Verification = nil
+	// Re-running the transform would redo the whole change, so the split
+	// stages are only ever rebased by merging
+	remainder.TransformOp = nil
+	remainder.CreatedAt = time.Time{}
+	if sm.config.StagingTTL > 0 {
+		remainder.ExpiresAt = time.Now().Add(sm.config.StagingTTL)
+	}
+	if len(stage.ScopeAST) > 0 {
+		var scope map[string]any
+		if err := json.Unmarshal(stage.ScopeAST, &scope); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to decode stage scope: %w", err)
+		}
+		scope["original_hash"] = remainder.BaseDigest
+		scope["split_from"] = stageID
+		remainder.ScopeAST = mustMarshalJSON(scope)
+	}
+
+	narrowed := map[string]any{
+		"modified":        partial,
+		"after_digest":    calculateSHA256(partial),
+		"diff":            core.UnifiedDiff(stage.Original, partial),
+		"structural_diff": nil,
+		"transform_op":    nil,
+	}
+	err = sm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Stage{}).Where("id = ?", stageID).Updates(narrowed).Error; err != nil {
+			return fmt.Errorf("failed to narrow stage: %w", err)
+		}
+		return tx.Create(&remainder).Error
+	})
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
+	apply, result, err := sm.ApplyStageVerified(ctx, stageID, verify)
+	if err != nil {
+		_ = sm.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Stage{}).Where("id = ?", stageID).Updates(map[string]any{
+				"modified":        stage.Modified,
+				"after_digest":    stage.AfterDigest,
+				"diff":            stage.Diff,
+				"structural_diff": stage.StructuralDiff,
+				"transform_op":    stage.TransformOp,
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&models.Stage{}, "id = ?", remainder.ID).Error
+		})
+		return nil, nil, result, err
+	}
+	return apply, &remainder, result, nil
+}
+
+// stageFilePath returns the file a stage writes, or "" for in-memory stages.
+func stageFilePath(stage *models.Stage) (string, error) {
+	if len(stage.ScopeAST) == 0 {
+		return "", nil
+	}
 	var scope map[string]any
 	if err := json.Unmarshal(stage.ScopeAST, &scope); err != nil {
-		return nil, fmt.Errorf("failed to decode stage scope: %w", err)
+		return "", fmt.Errorf("failed to decode stage scope: %w", err)
 	}
+	path, _ := scope["file_path"].(string)
+	return path, nil
+}
 
-	pathVal, ok := scope["file_path"].(string)
-	if !ok || pathVal == "" {
-		return nil, nil
+func (sm *StagingManager) prepareStageWrite(stage *models.Stage) (*fileWriteGuard, error) {
+	if stage == nil {
+		return nil, fmt.Errorf("stage cannot be nil")
+	}
+
+	path, err := stageFilePath(stage)
+	if err != nil || path == "" {
+		return nil, err
 	}
-	path := pathVal
 
 	if stage.Modified == "" {
 		return nil, fmt.Errorf("stage %s has no modified content", stage.ID)
@@ -318,6 +546,188 @@ func (sm *StagingManager) prepareStageWrite(stage *models.Stage) (*fileWriteGuar
 	}, nil
 }
 
+// RevertStage undoes an applied stage. When the file still has the content
+// the stage wrote, the stage's original content is restored; otherwise the
+// stage's edit is undone with a 3-way merge that keeps later changes, and
+// overlapping changes fail with core.ErrMergeConflict without touching the
+// file. The apply is marked reverted by revertedBy and the stage reverted.
+func (sm *StagingManager) RevertStage(ctx context.Context, stageID, revertedBy string) (*types.StageRevert, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stage, err := sm.GetStage(stageID)
+	if err != nil {
+		return nil, fmt.Errorf("stage not found: %w", err)
+	}
+	if stage.Status != "applied" {
+		return nil, fmt.Errorf("stage %s is %s, not applied", stageID, stage.Status)
+	}
+	var apply models.Apply
+	if err := sm.db.First(&apply, "stage_id = ?", stageID).Error; err != nil {
+		return nil, fmt.Errorf("apply record not found for stage %s: %w", stageID, err)
+	}
+	if apply.Reverted {
+		return nil, fmt.Errorf("stage %s already reverted", stageID)
+	}
+	path, err := stageFilePath(stage)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, fmt.Errorf("stage %s has no file to revert", stageID)
+	}
+
+	current, err := securefs.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("stage %s cannot be reverted: %w: %s no longer exists", stageID, core.ErrMergeConflict, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	afterDigest := apply.AfterDigest
+	if afterDigest == "" {
+		afterDigest = stage.AfterDigest
+	}
+	revert := &types.StageRevert{StageID: stageID, ApplyID: apply.ID, FilePath: path}
+	content := stage.Original
+	// A stage that created its file is undone by removing it
+	remove := stage.Original == "" && stage.BaseDigest == ""
+	if calculateSHA256(string(current)) != afterDigest {
+		merged, conflicts := core.Merge3(stage.Modified, string(current), stage.Original)
+		if len(conflicts) > 0 {
+			ranges := make([]string, 0, len(conflicts))
+			for _, conflict := range conflicts {
+				ranges = append(ranges, conflict.String())
+			}
+			return nil, fmt.Errorf("stage %s cannot be reverted: %w in %s: changed since apply at %s",
+				stageID, core.ErrMergeConflict, path, strings.Join(ranges, ", "))
+		}
+		content, remove, revert.Merged = merged, remove && merged == "", true
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	guard, err := sm.writeRevert(stage, path, current, content, remove)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = sm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Apply{}).Where("id = ?", apply.ID).Updates(map[string]any{
+			"reverted":    true,
+			"reverted_by": revertedBy,
+			"reverted_at": &now,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to record revert: %w", err)
+		}
+		if err := tx.Model(&models.Stage{}).Where("id = ?", stageID).Update("status", "reverted").Error; err != nil {
+			return fmt.Errorf("failed to update stage: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		_ = guard.Rollback()
+		return nil, err
+	}
+	guard.Commit()
+	return revert, nil
+}
+
+// writeRevert replaces the current content of path, or removes the file,
+// returning a guard that puts current back. Like prepareStageWrite it goes
+// through the safety manager when there is one, holding the revert to the
+// limits and confidence the stage was applied under.
+func (sm *StagingManager) writeRevert(stage *models.Stage, path string, current []byte, content string, remove bool) (*fileWriteGuard, error) {
+	filePerm := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		filePerm = info.Mode().Perm()
+	}
+	restore := func() error {
+		return securefs.WriteFile(path, current, filePerm)
+	}
+
+	if sm.safety != nil {
+		op := &SafetyOperation{
+			Files: []SafetyFile{{
+				Path:       path,
+				Size:       int64(len(content)),
+				Confidence: stage.ConfidenceScore,
+			}},
+			GlobalConfidence: stage.ConfidenceScore,
+		}
+		if err := sm.safety.ValidateOperation(op); err != nil {
+			return nil, err
+		}
+		if err := sm.safety.ValidateFileIntegrity([]FileIntegrityCheck{{
+			Path:         path,
+			ExpectedHash: calculateSHA256(string(current)),
+		}}); err != nil {
+			return nil, err
+		}
+
+		if !remove {
+			handle, err := sm.safety.AtomicWrite(path, content)
+			if err != nil {
+				return nil, err
+			}
+			return &fileWriteGuard{
+				commitFn: func() {
+					if handle != nil {
+						handle.Commit()
+					}
+				},
+				rollbackFn: func() error {
+					// The handle's own rollback removes files it has no
+					// backup of, so current is written back after it
+					var err error
+					if handle != nil {
+						err = handle.Rollback()
+					}
+					return errors.Join(err, restore())
+				},
+			}, nil
+		}
+	}
+
+	if remove {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	} else if err := securefs.WriteFile(path, []byte(content), filePerm); err != nil {
+		return nil, err
+	}
+	return &fileWriteGuard{
+		commitFn:   func() {},
+		rollbackFn: restore,
+	}, nil
+}
+
+// ListRevertibleStages lists applied stages that have not been reverted,
+// most recently applied first, optionally limited to a session and to limit
+// stages.
+func (sm *StagingManager) ListRevertibleStages(sessionID string, limit int) ([]models.Stage, error) {
+	query := sm.db.Model(&models.Stage{}).
+		Select("stages.*").
+		Joins("JOIN applies ON applies.stage_id = stages.id").
+		Where("stages.status = ? AND applies.reverted = ?", "applied", false)
+	if sessionID != "" {
+		query = query.Where("stages.session_id = ?", sessionID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var stages []models.Stage
+	err := query.Order("applies.applied_at DESC").Order("stages.applied_at DESC").Find(&stages).Error
+	return stages, err
+}
+
 // ListPendingStages lists all pending stages for a session
 func (sm *StagingManager) ListPendingStages(sessionID string) ([]models.Stage, error) {
 	var stages []models.Stage
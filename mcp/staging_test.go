@@ -9,6 +9,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/models"
 )
 
@@ -390,7 +392,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Auto-migrate both Stage and Apply models
-	err = db.AutoMigrate(&models.Stage{}, &models.Apply{}, &models.Session{})
+	err = db.AutoMigrate(&models.Stage{}, &models.Apply{}, &models.Changeset{}, &models.Session{})
 	if err != nil {
 		t.Fatalf("Failed to migrate models: %v", err)
 	}
@@ -538,3 +540,322 @@ func BenchmarkGetStage(b *testing.B) {
 		sm.GetStage("bench-get-test")
 	}
 }
+
+func TestApplyStageVerifiedRollsBackOnFailure(t *testing.T) {
+	t.Parallel()
+	db := setupTestDB(t)
+	sm := NewStagingManager(db, Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "verify.go")
+	original := "package main\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatalf("failed to seed file: %v", err)
+	}
+	scopeJSON, err := json.Marshal(map[string]any{"file_path": path})
+	if err != nil {
+		t.Fatalf("failed to marshal scope: %v", err)
+	}
+	stage := &models.Stage{
+		ID:              "verify-apply",
+		Language:        "go",
+		Original:        original,
+		Modified:        "package main\n// broken\n",
+		Operation:       "replace",
+		Status:          "pending",
+		BaseDigest:      calculateSHA256(original),
+		ConfidenceScore: 0.95,
+		ConfidenceLevel: "high",
+		ScopeAST:        datatypes.JSON(scopeJSON),
+	}
+	if err := sm.CreateStage(context.Background(), stage); err != nil {
+		t.Fatalf("failed to create stage: %v", err)
+	}
+
+	verify := &core.VerifyCommand{Command: "grep -q broken verify.go && echo build failed && exit 1"}
+	_, result, err := sm.ApplyStageVerified(context.Background(), stage.ID, verify)
+	if !errors.Is(err, core.ErrVerificationFailed) {
+		t.Fatalf("expected ErrVerificationFailed, got %v", err)
+	}
+	if result == nil || !result.RolledBack || !strings.Contains(result.Output, "build failed") {
+		t.Fatalf("expected a rolled back result with output, got %+v", result)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(content) != original {
+		t.Fatalf("expected the original content to be restored, got %q", content)
+	}
+
+	stored, err := sm.GetStage(stage.ID)
+	if err != nil {
+		t.Fatalf("failed to reload stage: %v", err)
+	}
+	if stored.Status != "failed" {
+		t.Fatalf("expected stage to be marked failed, got %q", stored.Status)
+	}
+	var recorded core.VerifyResult
+	if err := json.Unmarshal(stored.Verification, &recorded); err != nil || recorded.ExitCode != 1 {
+		t.Fatalf("expected the verification to be stored, got %s (%v)", stored.Verification, err)
+	}
+
+	var apply models.Apply
+	if err := db.Where("stage_id = ?", stage.ID).First(&apply).Error; err != nil {
+		t.Fatalf("failed to load apply record: %v", err)
+	}
+	if !apply.Reverted || apply.RevertedBy != "verify" {
+		t.Fatalf("expected the apply record to be marked reverted, got %+v", apply)
+	}
+}
+
+// applyTestStage stages and applies a change from original to modified in a
+// new file under a temp dir, returning the stage and the file path.
+func applyTestStage(t *testing.T, sm *StagingManager, id, original, modified string) (*models.Stage, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), id+".go")
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatalf("failed to seed file: %v", err)
+	}
+	scopeJSON, err := json.Marshal(map[string]any{"file_path": path})
+	if err != nil {
+		t.Fatalf("failed to marshal scope: %v", err)
+	}
+	stage := &models.Stage{
+		ID:              id,
+		SessionID:       "revert-session",
+		Language:        "go",
+		Original:        original,
+		Modified:        modified,
+		Operation:       "replace",
+		Status:          "pending",
+		BaseDigest:      calculateSHA256(original),
+		AfterDigest:     calculateSHA256(modified),
+		ConfidenceScore: 0.95,
+		ConfidenceLevel: "high",
+		ScopeAST:        datatypes.JSON(scopeJSON),
+	}
+	if err := sm.CreateStage(context.Background(), stage); err != nil {
+		t.Fatalf("failed to create stage: %v", err)
+	}
+	if _, err := sm.ApplyStage(context.Background(), id, false); err != nil {
+		t.Fatalf("failed to apply stage: %v", err)
+	}
+	return stage, path
+}
+
+func TestRevertStage(t *testing.T) {
+	t.Parallel()
+	original := "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n"
+	modified := "package main\n\nfunc a() { println() }\n\nfunc b() {}\n\nfunc c() {}\n"
+
+	t.Run("restores unchanged file", func(t *testing.T) {
+		sm := NewStagingManager(setupTestDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+		stage, path := applyTestStage(t, sm, "revert-restore", original, modified)
+
+		revert, err := sm.RevertStage(context.Background(), stage.ID, "test")
+		if err != nil {
+			t.Fatalf("RevertStage returned error: %v", err)
+		}
+		if revert.Merged {
+			t.Fatalf("expected a plain restore, got %+v", revert)
+		}
+		if content, _ := os.ReadFile(path); string(content) != original {
+			t.Fatalf("expected original content, got %q", content)
+		}
+
+		var apply models.Apply
+		if err := sm.db.First(&apply, "stage_id = ?", stage.ID).Error; err != nil {
+			t.Fatalf("failed to load apply: %v", err)
+		}
+		if !apply.Reverted || apply.RevertedBy != "test" || apply.RevertedAt == nil || apply.AfterDigest != stage.AfterDigest {
+			t.Fatalf("expected the revert to be recorded, got %+v", apply)
+		}
+		if stored, _ := sm.GetStage(stage.ID); stored.Status != "reverted" {
+			t.Fatalf("expected stage to be marked reverted, got %q", stored.Status)
+		}
+		if _, err := sm.RevertStage(context.Background(), stage.ID, "test"); err == nil {
+			t.Fatal("expected a second revert to fail")
+		}
+	})
+
+	t.Run("merges later edits", func(t *testing.T) {
+		sm := NewStagingManager(setupTestDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+		stage, path := applyTestStage(t, sm, "revert-merge", original, modified)
+		edited := strings.Replace(modified, "func c() {}", "func c() { return }", 1)
+		if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+			t.Fatalf("failed to edit file: %v", err)
+		}
+
+		revert, err := sm.RevertStage(context.Background(), stage.ID, "test")
+		if err != nil {
+			t.Fatalf("RevertStage returned error: %v", err)
+		}
+		want := strings.Replace(original, "func c() {}", "func c() { return }", 1)
+		if content, _ := os.ReadFile(path); !revert.Merged || string(content) != want {
+			t.Fatalf("expected the stage undone and the later edit kept, got %q (%+v)", content, revert)
+		}
+	})
+
+	t.Run("reports conflicts", func(t *testing.T) {
+		sm := NewStagingManager(setupTestDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+		stage, path := applyTestStage(t, sm, "revert-conflict", original, modified)
+		edited := strings.Replace(modified, "println()", "panic(1)", 1)
+		if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+			t.Fatalf("failed to edit file: %v", err)
+		}
+
+		_, err := sm.RevertStage(context.Background(), stage.ID, "test")
+		if !errors.Is(err, core.ErrMergeConflict) || !strings.Contains(err.Error(), "line 3") {
+			t.Fatalf("expected a conflict at line 3, got %v", err)
+		}
+		if content, _ := os.ReadFile(path); string(content) != edited {
+			t.Fatalf("expected the file to be left alone, got %q", content)
+		}
+		if stored, _ := sm.GetStage(stage.ID); stored.Status != "applied" {
+			t.Fatalf("expected stage to stay applied, got %q", stored.Status)
+		}
+	})
+
+	t.Run("checks safety limits", func(t *testing.T) {
+		sm := NewStagingManager(setupTestDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+		stage, path := applyTestStage(t, sm, "revert-limits", original, modified)
+		sm.safety.config.MaxFileSize = 10
+
+		_, err := sm.RevertStage(context.Background(), stage.ID, "test")
+		var mcpErr *MCPError
+		if !errors.As(err, &mcpErr) || mcpErr.Code != FileTooLarge {
+			t.Fatalf("expected a file size error, got %v", err)
+		}
+		if content, _ := os.ReadFile(path); string(content) != modified {
+			t.Fatalf("expected the file to be left alone, got %q", content)
+		}
+	})
+
+	t.Run("puts the file back when recording fails", func(t *testing.T) {
+		sm := NewStagingManager(setupTestDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+		stage, path := applyTestStage(t, sm, "revert-record", original, modified)
+		if err := sm.db.Callback().Update().Before("gorm:update").Register("fail_revert", func(db *gorm.DB) {
+			_ = db.AddError(errors.New("database is read-only"))
+		}); err != nil {
+			t.Fatalf("failed to register callback: %v", err)
+		}
+
+		if _, err := sm.RevertStage(context.Background(), stage.ID, "test"); err == nil {
+			t.Fatal("expected the revert to fail")
+		}
+		if content, _ := os.ReadFile(path); string(content) != modified {
+			t.Fatalf("expected the applied content back, got %q", content)
+		}
+		if pending := sm.safety.txLog.GetPendingTransactions(); len(pending) != 0 {
+			t.Fatalf("expected the revert's write to be rolled back, got %d pending", len(pending))
+		}
+	})
+}
+
+func TestListRevertibleStages(t *testing.T) {
+	t.Parallel()
+	sm := NewStagingManager(setupTestDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+	applyTestStage(t, sm, "revert-first", "package a\n", "package b\n")
+	time.Sleep(10 * time.Millisecond)
+	applyTestStage(t, sm, "revert-second", "package a\n", "package c\n")
+
+	stages, err := sm.ListRevertibleStages("revert-session", 1)
+	if err != nil {
+		t.Fatalf("ListRevertibleStages returned error: %v", err)
+	}
+	if len(stages) != 1 || stages[0].ID != "revert-second" || stages[0].Modified != "package c\n" {
+		t.Fatalf("expected the newest apply first, got %+v", stages)
+	}
+	if stages, _ := sm.ListRevertibleStages("other-session", 0); len(stages) != 0 {
+		t.Fatalf("expected no stages for another session, got %+v", stages)
+	}
+}
+
+func TestApplyStageHunks(t *testing.T) {
+	t.Parallel()
+	original := "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n\nfunc d() {}\n\nfunc e() {}\n"
+	modified := "package main\n\nfunc a() { println() }\n\nfunc b() {}\n\nfunc c() {}\n\nfunc d() {}\n\nfunc e() { println() }\n"
+	partial := "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n\nfunc d() {}\n\nfunc e() { println() }\n"
+
+	stageFile := func(t *testing.T, sm *StagingManager, id string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), id+".go")
+		if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+			t.Fatalf("failed to seed file: %v", err)
+		}
+		scopeJSON, err := json.Marshal(map[string]any{"file_path": path})
+		if err != nil {
+			t.Fatalf("failed to marshal scope: %v", err)
+		}
+		stage := &models.Stage{
+			ID:              id,
+			SessionID:       "hunk-session",
+			Language:        "go",
+			Original:        original,
+			Modified:        modified,
+			Diff:            core.UnifiedDiff(original, modified),
+			Operation:       "replace",
+			Status:          "pending",
+			BaseDigest:      calculateSHA256(original),
+			AfterDigest:     calculateSHA256(modified),
+			ConfidenceScore: 0.95,
+			ConfidenceLevel: "high",
+			ScopeAST:        datatypes.JSON(scopeJSON),
+		}
+		if err := sm.CreateStage(context.Background(), stage); err != nil {
+			t.Fatalf("failed to create stage: %v", err)
+		}
+		return path
+	}
+
+	t.Run("stages the remainder", func(t *testing.T) {
+		sm := NewStagingManager(setupTestDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+		path := stageFile(t, sm, "hunk-partial")
+
+		_, remainder, _, err := sm.ApplyStageHunks(context.Background(), "hunk-partial", []int{2}, nil)
+		if err != nil {
+			t.Fatalf("ApplyStageHunks failed: %v", err)
+		}
+		if content, _ := os.ReadFile(path); string(content) != partial {
+			t.Fatalf("expected only hunk 2 applied, got:\n%s", content)
+		}
+		applied, err := sm.GetStage("hunk-partial")
+		if err != nil {
+			t.Fatalf("failed to load stage: %v", err)
+		}
+		if applied.Status != "applied" || applied.Modified != partial {
+			t.Fatalf("expected stage narrowed to the applied hunk, got %s", applied.Status)
+		}
+		if remainder == nil || remainder.Status != "pending" || remainder.Original != partial {
+			t.Fatalf("expected a pending remainder based on the partial file, got %+v", remainder)
+		}
+
+		if _, err := sm.ApplyStage(context.Background(), remainder.ID, false); err != nil {
+			t.Fatalf("failed to apply remainder: %v", err)
+		}
+		if content, _ := os.ReadFile(path); string(content) != modified {
+			t.Fatalf("expected remainder to complete the change, got:\n%s", content)
+		}
+	})
+
+	t.Run("rejects unknown hunks", func(t *testing.T) {
+		sm := NewStagingManager(setupTestDB(t), Config{StagingTTL: time.Hour}, NewSafetyManager(DefaultConfig().Safety))
+		path := stageFile(t, sm, "hunk-range")
+
+		if _, _, _, err := sm.ApplyStageHunks(context.Background(), "hunk-range", []int{3}, nil); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Fatalf("expected out of range error, got %v", err)
+		}
+		stage, err := sm.GetStage("hunk-range")
+		if err != nil {
+			t.Fatalf("failed to load stage: %v", err)
+		}
+		if stage.Status != "pending" || stage.Modified != modified {
+			t.Fatalf("expected stage left untouched, got %s", stage.Status)
+		}
+		if content, _ := os.ReadFile(path); string(content) != original {
+			t.Fatalf("expected file untouched, got:\n%s", content)
+		}
+	})
+}
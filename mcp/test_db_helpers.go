@@ -25,7 +25,7 @@ func setupAsyncStagingDB(t *testing.T) *gorm.DB {
 		_ = sqlDB.Close()
 	})
 
-	if err := db.AutoMigrate(&models.Session{}, &models.Stage{}, &models.Apply{}); err != nil {
+	if err := db.AutoMigrate(&models.Session{}, &models.Stage{}, &models.Apply{}, &models.Changeset{}); err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
 
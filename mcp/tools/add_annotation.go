@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+)
+
+// AddAnnotationTool attaches decorators, attributes, and struct tags
+type AddAnnotationTool struct {
+	*BaseTool
+	server types.ServerInterface
+}
+
+// NewAddAnnotationTool creates a new add annotation tool
+func NewAddAnnotationTool(server types.ServerInterface) *AddAnnotationTool {
+	tool := &AddAnnotationTool{
+		server: server,
+	}
+
+	tool.BaseTool = &BaseTool{
+		name:        "add_annotation",
+		description: "Attach a decorator, PHP attribute, or Go struct tag to each matched declaration. ${name} and DSL captures in the annotation expand per target with optional case transforms, as in json:\"${name|snake}\". With if_absent, targets that already carry an annotation of the same name are skipped.",
+		inputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"language":   CommonSchemas.Language,
+				"source":     CommonSchemas.Source,
+				"path":       CommonSchemas.Path,
+				"target":     CommonSchemas.Target,
+				"target_dsl": CommonSchemas.TargetDSL,
+				"annotation": map[string]any{"type": "string", "description": "Annotation to add, such as @deprecated, #[Route('/users')], or json:\"${name|snake}\""},
+				"if_absent":  CommonSchemas.IfAbsent,
+				"format":     CommonSchemas.Format,
+			},
+			"required": []string{"language", "annotation"},
+			"oneOf": []map[string]any{
+				{"required": []string{"source"}},
+				{"required": []string{"path"}},
+			},
+		},
+		handler: tool.handle,
+	}
+
+	return tool
+}
+
+// handle executes the add annotation tool
+func (t *AddAnnotationTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var args struct {
+		Language   string          `json:"language"`
+		Source     string          `json:"source"`
+		Path       string          `json:"path"`
+		Target     json.RawMessage `json:"target"`
+		TargetDSL  string          `json:"target_dsl,omitempty"`
+		Annotation string          `json:"annotation"`
+		IfAbsent   bool            `json:"if_absent,omitempty"`
+		Format     bool            `json:"format,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid add_annotation parameters", err)
+	}
+
+	// Validate that exactly one of source or path is provided
+	if (args.Source == "" && args.Path == "") || (args.Source != "" && args.Path != "") {
+		return nil, types.NewMCPError(types.InvalidParams, "Exactly one of 'source' or 'path' must be provided", nil)
+	}
+
+	if strings.TrimSpace(args.Annotation) == "" {
+		return nil, types.NewMCPError(types.InvalidParams, "annotation is required", nil)
+	}
+
+	notifyProgress(ctx, t.server, 5, 100, "validating")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get source code
+	var source string
+	if args.Path != "" {
+		content, err := os.ReadFile(args.Path)
+		if err != nil {
+			return nil, types.WrapError(types.FileSystemError, "Failed to read file", err)
+		}
+		source = string(content)
+		notifyProgress(ctx, t.server, 15, 100, "loaded file")
+	} else {
+		source = args.Source
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get provider
+	provider, exists := t.server.GetProviders().Get(args.Language)
+	if !exists {
+		return nil, types.NewMCPError(types.LanguageNotFound, "Language not supported", nil)
+	}
+	notifyProgress(ctx, t.server, 25, 100, "resolved provider")
+
+	// Parse target
+	target, err := parseRequiredQuery(args.Target, args.TargetDSL, "target")
+	if err != nil {
+		return nil, err
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Execute transformation
+	op := core.TransformOp{
+		Method:     "add_annotation",
+		Target:     target,
+		Annotation: args.Annotation,
+		IfAbsent:   args.IfAbsent,
+		Format:     args.Format,
+	}
+
+	result := provider.Transform(source, op)
+	if result.Error != nil {
+		return nil, types.WrapError(types.TransformFailed, "Add annotation operation failed", result.Error)
+	}
+	notifyProgress(ctx, t.server, 70, 100, "transformed source")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	notifyProgress(ctx, t.server, 90, 100, "finalizing")
+
+	return t.server.FinalizeTransform(ctx, types.TransformRequest{
+		Language:       args.Language,
+		Operation:      "add_annotation",
+		Target:         target,
+		TargetJSON:     args.Target,
+		Path:           args.Path,
+		OriginalSource: source,
+		Result:         result,
+		ResponseText:   t.formatResponse(result, args.Path),
+		Op:             &op,
+	})
+}
+
+// formatResponse formats the add annotation result
+func (t *AddAnnotationTool) formatResponse(result core.TransformResult, path string) string {
+	if result.Error != nil {
+		return "Add annotation operation failed: " + result.Error.Error()
+	}
+
+	response := "✅ Add annotation operation completed successfully\n\n"
+
+	if path != "" {
+		response += "📄 File: " + path + "\n\n"
+	}
+
+	if added, ok := result.Metadata["annotations_added"].(int); ok {
+		response += fmt.Sprintf("Annotations added: %d\n", added)
+	}
+	if skipped, ok := result.Metadata["annotations_skipped"].(int); ok && skipped > 0 {
+		response += fmt.Sprintf("Already present: %d\n", skipped)
+	}
+
+	response += fmt.Sprintf("\nConfidence: %.1f%%", result.Confidence.Score*100)
+
+	return response
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+)
+
+// AnnotateTool adds and replaces Python type hints
+type AnnotateTool struct {
+	*BaseTool
+	server types.ServerInterface
+}
+
+// NewAnnotateTool creates a new annotate tool
+func NewAnnotateTool(server types.ServerInterface) *AnnotateTool {
+	tool := &AnnotateTool{
+		server: server,
+	}
+
+	tool.BaseTool = &BaseTool{
+		name:        "annotate",
+		description: "Add or replace type hints on each matched Python function by parameter name, with \"return\" for the return type. Defaults are respaced as PEP 8 asks (b=1 becomes b: int = 1), and *args and **kwargs can be named with or without their stars. A name no matched function has is an error; with if_absent, existing hints are kept.",
+		inputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"language":   CommonSchemas.Language,
+				"source":     CommonSchemas.Source,
+				"path":       CommonSchemas.Path,
+				"target":     CommonSchemas.Target,
+				"target_dsl": CommonSchemas.TargetDSL,
+				"type_hints": CommonSchemas.TypeHints,
+				"if_absent":  CommonSchemas.IfAbsent,
+				"format":     CommonSchemas.Format,
+			},
+			"required": []string{"language", "type_hints"},
+			"oneOf": []map[string]any{
+				{"required": []string{"source"}},
+				{"required": []string{"path"}},
+			},
+		},
+		handler: tool.handle,
+	}
+
+	return tool
+}
+
+// handle executes the annotate tool
+func (t *AnnotateTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var args struct {
+		Language  string            `json:"language"`
+		Source    string            `json:"source"`
+		Path      string            `json:"path"`
+		Target    json.RawMessage   `json:"target"`
+		TargetDSL string            `json:"target_dsl,omitempty"`
+		TypeHints map[string]string `json:"type_hints"`
+		IfAbsent  bool              `json:"if_absent,omitempty"`
+		Format    bool              `json:"format,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid annotate parameters", err)
+	}
+
+	// Validate that exactly one of source or path is provided
+	if (args.Source == "" && args.Path == "") || (args.Source != "" && args.Path != "") {
+		return nil, types.NewMCPError(types.InvalidParams, "Exactly one of 'source' or 'path' must be provided", nil)
+	}
+
+	if len(args.TypeHints) == 0 {
+		return nil, types.NewMCPError(types.InvalidParams, "type_hints is required", nil)
+	}
+	for name, hint := range args.TypeHints {
+		if strings.TrimSpace(hint) == "" {
+			return nil, types.NewMCPError(types.InvalidParams, "Empty type hint", map[string]any{"parameter": name})
+		}
+	}
+
+	notifyProgress(ctx, t.server, 5, 100, "validating")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get source code
+	var source string
+	if args.Path != "" {
+		content, err := os.ReadFile(args.Path)
+		if err != nil {
+			return nil, types.WrapError(types.FileSystemError, "Failed to read file", err)
+		}
+		source = string(content)
+		notifyProgress(ctx, t.server, 15, 100, "loaded file")
+	} else {
+		source = args.Source
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get provider
+	provider, exists := t.server.GetProviders().Get(args.Language)
+	if !exists {
+		return nil, types.NewMCPError(types.LanguageNotFound, "Language not supported", nil)
+	}
+	notifyProgress(ctx, t.server, 25, 100, "resolved provider")
+
+	// Parse target
+	target, err := parseRequiredQuery(args.Target, args.TargetDSL, "target")
+	if err != nil {
+		return nil, err
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Execute transformation
+	op := core.TransformOp{
+		Method:    "annotate",
+		Target:    target,
+		TypeHints: args.TypeHints,
+		IfAbsent:  args.IfAbsent,
+		Format:    args.Format,
+	}
+
+	result := provider.Transform(source, op)
+	if result.Error != nil {
+		return nil, types.WrapError(types.TransformFailed, "Annotate operation failed", result.Error)
+	}
+	notifyProgress(ctx, t.server, 70, 100, "transformed source")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	notifyProgress(ctx, t.server, 90, 100, "finalizing")
+
+	return t.server.FinalizeTransform(ctx, types.TransformRequest{
+		Language:       args.Language,
+		Operation:      "annotate",
+		Target:         target,
+		TargetJSON:     args.Target,
+		Path:           args.Path,
+		OriginalSource: source,
+		Result:         result,
+		ResponseText:   t.formatResponse(result, args.Path),
+		Op:             &op,
+	})
+}
+
+// formatResponse formats the annotate result
+func (t *AnnotateTool) formatResponse(result core.TransformResult, path string) string {
+	if result.Error != nil {
+		return "Annotate operation failed: " + result.Error.Error()
+	}
+
+	response := "✅ Annotate operation completed successfully\n\n"
+
+	if path != "" {
+		response += "📄 File: " + path + "\n\n"
+	}
+
+	if added, ok := result.Metadata["hints_added"].(int); ok {
+		response += fmt.Sprintf("Hints added: %d\n", added)
+	}
+	if replaced, ok := result.Metadata["hints_replaced"].(int); ok {
+		response += fmt.Sprintf("Hints replaced: %d\n", replaced)
+	}
+	if skipped, ok := result.Metadata["hints_skipped"].(int); ok && skipped > 0 {
+		response += fmt.Sprintf("Unchanged: %d\n", skipped)
+	}
+
+	response += fmt.Sprintf("\nConfidence: %.1f%%", result.Confidence.Score*100)
+
+	return response
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestAddAnnotationTool_Execute(t *testing.T) {
+	server := newMockServer()
+	tool := NewAddAnnotationTool(server)
+
+	result, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "go",
+		"source":     "package main\n\ntype User struct {\n\tName string\n}\n",
+		"target_dsl": "struct:User > field:*",
+		"annotation": `json:"${name|snake}"`,
+	}))
+	assertNoError(t, err)
+	if !hasContentArray(result) {
+		t.Fatalf("expected content array, got %#v", result)
+	}
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "go",
+		"source":     "package main\n",
+		"target_dsl": "field:*",
+	}))
+	assertError(t, err, "annotation")
+}
+
+func TestRemoveAnnotationTool_Execute(t *testing.T) {
+	server := newMockServer()
+	tool := NewRemoveAnnotationTool(server)
+
+	result, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "typescript",
+		"source":     "class A {\n  @Deprecated()\n  f() {}\n}\n",
+		"target_dsl": "decorator:Deprecated",
+	}))
+	assertNoError(t, err)
+	if !hasContentArray(result) {
+		t.Fatalf("expected content array, got %#v", result)
+	}
+}
+
+func TestAnnotationTools_FormatResponseCounts(t *testing.T) {
+	server := newMockServer()
+	added := NewAddAnnotationTool(server).formatResponse(core.TransformResult{
+		Confidence: core.ConfidenceScore{Score: 1},
+		Metadata:   map[string]any{"annotations_added": 2, "annotations_skipped": 1},
+	}, "user.go")
+	for _, want := range []string{"📄 File: user.go", "Annotations added: 2", "Already present: 1"} {
+		if !strings.Contains(added, want) {
+			t.Fatalf("response missing %q:\n%s", want, added)
+		}
+	}
+
+	removed := NewRemoveAnnotationTool(server).formatResponse(core.TransformResult{
+		Confidence: core.ConfidenceScore{Score: 1},
+		Metadata:   map[string]any{"annotations_removed": 3},
+	}, "")
+	if !strings.Contains(removed, "Annotations removed: 3") {
+		t.Fatalf("response missing removal count:\n%s", removed)
+	}
+}
@@ -43,7 +43,10 @@ func NewAppendTool(server types.ServerInterface) *AppendTool {
 						"name": map[string]any{"type": "string"},
 					},
 				},
-				"target_dsl": CommonSchemas.TargetDSL,
+				"target_dsl":       CommonSchemas.TargetDSL,
+				"organize_imports": CommonSchemas.OrganizeImports,
+				"format":           CommonSchemas.Format,
+				"if_absent":        CommonSchemas.IfAbsent,
 			},
 			"required": []string{"language", "content"},
 			"oneOf": []map[string]any{
@@ -63,12 +66,15 @@ func (t *AppendTool) handle(ctx context.Context, params json.RawMessage) (any, e
 		ctx = context.Background()
 	}
 	var args struct {
-		Language  string          `json:"language"`
-		Source    string          `json:"source"`
-		Path      string          `json:"path"`
-		Target    json.RawMessage `json:"target,omitempty"`
-		TargetDSL string          `json:"target_dsl,omitempty"`
-		Content   string          `json:"content"`
+		Language        string          `json:"language"`
+		Source          string          `json:"source"`
+		Path            string          `json:"path"`
+		Target          json.RawMessage `json:"target,omitempty"`
+		TargetDSL       string          `json:"target_dsl,omitempty"`
+		Content         string          `json:"content"`
+		OrganizeImports bool            `json:"organize_imports,omitempty"`
+		Format          bool            `json:"format,omitempty"`
+		IfAbsent        bool            `json:"if_absent,omitempty"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
@@ -119,8 +125,11 @@ func (t *AppendTool) handle(ctx context.Context, params json.RawMessage) (any, e
 
 	// Build transform operation
 	op := core.TransformOp{
-		Method:  "append",
-		Content: args.Content,
+		Method:          "append",
+		Content:         args.Content,
+		OrganizeImports: args.OrganizeImports,
+		Format:          args.Format,
+		IfAbsent:        args.IfAbsent,
 	}
 
 	// Parse optional target
@@ -155,6 +164,7 @@ func (t *AppendTool) handle(ctx context.Context, params json.RawMessage) (any, e
 		Result:         result,
 		ResponseText:   t.formatResponse(result, args.Path),
 		Content:        args.Content,
+		Op:             &op,
 	})
 }
 
@@ -174,6 +184,7 @@ func (t *AppendTool) formatResponse(result core.TransformResult, path string) st
 	if result.MatchCount > 0 {
 		response += fmt.Sprintf("  %d locations modified\n", result.MatchCount)
 	}
+	response += formatAlreadyPresent(result)
 
 	response += fmt.Sprintf("\nConfidence: %.1f%%", result.Confidence.Score*100)
 
@@ -5,8 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/mcp/types"
+	"github.com/oxhq/morfx/models"
 	"gorm.io/gorm"
 )
 
@@ -40,6 +45,20 @@ func NewApplyTool(server types.ServerInterface) *ApplyTool {
 					"type":        "boolean",
 					"description": "Apply the most recent pending stage",
 				},
+				"changeset": map[string]any{
+					"type":        "string",
+					"description": "Changeset ID to apply; writes every file of the changeset or none of them",
+				},
+				"hunks": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "integer", "minimum": 1},
+					"description": "Apply only these 1-based hunks of the stage's diff (with id or latest); the rest stays pending as a new stage",
+				},
+				"select_hunks": map[string]any{
+					"type":        "boolean",
+					"description": "Ask the client to pick hunks through elicitation (with id or latest)",
+				},
+				"verify": CommonSchemas.Verify,
 			},
 			"required": []string{},
 		},
@@ -52,14 +71,26 @@ func NewApplyTool(server types.ServerInterface) *ApplyTool {
 // handle executes the apply tool
 func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
 	var args struct {
-		ID     string `json:"id,omitempty"`
-		All    bool   `json:"all,omitempty"`
-		Latest bool   `json:"latest,omitempty"`
+		ID     string              `json:"id,omitempty"`
+		All    bool                `json:"all,omitempty"`
+		Latest bool                `json:"latest,omitempty"`
+		Verify *core.VerifyCommand `json:"verify,omitempty"`
+
+		Changeset string `json:"changeset,omitempty"`
+
+		Hunks       []int `json:"hunks,omitempty"`
+		SelectHunks bool  `json:"select_hunks,omitempty"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
 		return nil, types.WrapError(types.InvalidParams, "Invalid apply parameters", err)
 	}
+	if err := args.Verify.Validate(); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid verify", err)
+	}
+	if err := t.server.CheckVerify(args.Verify); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Verify not allowed", err)
+	}
 
 	notifyProgress(ctx, t.server, 5, 100, "validating")
 	if err := isCancelled(ctx); err != nil {
@@ -83,6 +114,32 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 	if !staging.IsEnabled() {
 		return nil, types.NewMCPError(types.InvalidParams, "staging is not enabled", nil)
 	}
+	partial := len(args.Hunks) > 0 || args.SelectHunks
+	if partial {
+		if args.All || args.Changeset != "" {
+			return nil, types.NewMCPError(types.InvalidParams,
+				"hunks can only be selected for a single stage: use 'id' or 'latest'",
+				nil)
+		}
+		if _, ok := stagingRaw.(types.StagingHunkApplier); !ok {
+			return nil, types.NewMCPError(types.InvalidParams,
+				"Staging manager does not support applying hunks",
+				nil)
+		}
+	}
+	if args.Changeset != "" {
+		if _, ok := stagingRaw.(types.StagingChangesets); !ok {
+			return nil, types.NewMCPError(types.InvalidParams,
+				"Staging manager does not support changesets",
+				nil)
+		}
+	} else if args.Verify != nil {
+		if _, ok := stagingRaw.(types.StagingVerifier); !ok {
+			return nil, types.NewMCPError(types.InvalidParams,
+				"Staging manager does not support verify commands",
+				nil)
+		}
+	}
 
 	sessionID := t.server.GetSessionID()
 
@@ -93,6 +150,46 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 
 	mode := ""
 	appliedIDs := make([]string, 0)
+	verifications := make(map[string]*core.VerifyResult)
+	remainders := make(map[string]string)
+	// Stages whose file changed since staging, by how they were rebased
+	rebased := make(map[string]string)
+	recordApply := func(stageID string, applied *models.Apply) {
+		if applied != nil && applied.Rebased != "" {
+			rebased[stageID] = applied.Rebased
+		}
+	}
+	apply := func(stageID string) error {
+		if partial {
+			hunks := args.Hunks
+			if args.SelectHunks {
+				var err error
+				if hunks, err = t.selectHunks(ctx, staging, stageID); err != nil {
+					return err
+				}
+			}
+			applied, remainder, result, err := stagingRaw.(types.StagingHunkApplier).ApplyStageHunks(ctx, stageID, hunks, args.Verify)
+			recordApply(stageID, applied)
+			if result != nil {
+				verifications[stageID] = result
+			}
+			if remainder != nil {
+				remainders[stageID] = remainder.ID
+			}
+			return err
+		}
+		if args.Verify == nil {
+			applied, err := staging.ApplyStage(ctx, stageID, false)
+			recordApply(stageID, applied)
+			return err
+		}
+		applied, result, err := stagingRaw.(types.StagingVerifier).ApplyStageVerified(ctx, stageID, args.Verify)
+		recordApply(stageID, applied)
+		if result != nil {
+			verifications[stageID] = result
+		}
+		return err
+	}
 
 	paramCount := 0
 	if args.ID != "" {
@@ -104,9 +201,12 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 	if args.Latest {
 		paramCount++
 	}
+	if args.Changeset != "" {
+		paramCount++
+	}
 	if paramCount > 1 {
 		return nil, types.NewMCPError(types.InvalidParams,
-			"conflicting parameters: specify only one of 'id', 'all', or 'latest'",
+			"conflicting parameters: specify only one of 'id', 'all', 'latest', or 'changeset'",
 			nil)
 	}
 	if paramCount == 0 {
@@ -154,12 +254,62 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 			return nil, err
 		}
 
-		if _, err := staging.ApplyStage(ctx, args.ID, false); err != nil {
+		if err := apply(args.ID); err != nil {
+			if errors.Is(err, errNoHunksSelected) {
+				return noHunksSelectedResponse(mode, args.ID), nil
+			}
+			if errors.Is(err, core.ErrVerificationFailed) {
+				return verificationFailedResponse(mode, appliedIDs, args.ID, verifications), nil
+			}
 			return nil, err
 		}
 		appliedIDs = append(appliedIDs, args.ID)
 		summary = map[string]any{"mode": mode, "stageId": args.ID}
 
+	case args.Changeset != "":
+		mode = "changeset"
+		notifyProgress(ctx, t.server, 60, 100, "applying changeset")
+		stager := stagingRaw.(types.StagingChangesets)
+		changeset, err := stager.GetChangeset(args.Changeset)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, types.NewMCPError(types.InvalidParams,
+					"changeset not found: "+args.Changeset,
+					nil)
+			}
+			return nil, types.WrapError(types.InvalidParams, "failed to load changeset", err)
+		}
+		if changeset.SessionID != "" && changeset.SessionID != sessionID {
+			return nil, types.NewMCPError(types.InvalidParams,
+				fmt.Sprintf("changeset %s belongs to a different session", args.Changeset),
+				nil)
+		}
+		if changeset.Status != "pending" {
+			return nil, types.NewMCPError(types.InvalidParams,
+				fmt.Sprintf("changeset already %s", changeset.Status),
+				nil)
+		}
+
+		if err := t.server.ConfirmApply(ctx, fmt.Sprintf("Apply changeset %s (%d file(s))", args.Changeset, len(changeset.Stages))); err != nil {
+			return nil, err
+		}
+
+		applied, result, err := stager.ApplyChangeset(ctx, args.Changeset, args.Verify)
+		if result != nil {
+			verifications[args.Changeset] = result
+		}
+		if errors.Is(err, core.ErrVerificationFailed) {
+			return verificationFailedResponse(mode, appliedIDs, args.Changeset, verifications), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		appliedIDs = stageIDsOf(applied.Stages)
+		for _, stage := range applied.Stages {
+			recordApply(stage.ID, stage.Apply)
+		}
+		summary = map[string]any{"mode": mode, "changesetId": args.Changeset}
+
 	case args.All:
 		mode = "all"
 		notifyProgress(ctx, t.server, 60, 100, "applying all stages")
@@ -168,6 +318,7 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 		}
 
 		stages, err := staging.ListPendingStages(sessionID)
+		stages = looseStages(stages)
 		if err != nil || len(stages) == 0 {
 			return nil, types.NewMCPError(types.InvalidParams, "no stages available", nil)
 		}
@@ -175,7 +326,11 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 			if err := isCancelled(ctx); err != nil {
 				return nil, err
 			}
-			if _, err := staging.ApplyStage(ctx, stage.ID, false); err == nil {
+			err := apply(stage.ID)
+			if errors.Is(err, core.ErrVerificationFailed) {
+				return verificationFailedResponse(mode, appliedIDs, stage.ID, verifications), nil
+			}
+			if err == nil {
 				appliedIDs = append(appliedIDs, stage.ID)
 			}
 		}
@@ -185,6 +340,7 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 		mode = "latest"
 		notifyProgress(ctx, t.server, 60, 100, "applying latest stage")
 		stages, err := staging.ListPendingStages(sessionID)
+		stages = looseStages(stages)
 		if err != nil || len(stages) == 0 {
 			return nil, types.NewMCPError(types.InvalidParams, "no stages available", nil)
 		}
@@ -192,7 +348,13 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 		if err := t.server.ConfirmApply(ctx, fmt.Sprintf("Apply latest stage %s", stageID)); err != nil {
 			return nil, err
 		}
-		if _, err := staging.ApplyStage(ctx, stageID, false); err != nil {
+		if err := apply(stageID); err != nil {
+			if errors.Is(err, errNoHunksSelected) {
+				return noHunksSelectedResponse(mode, stageID), nil
+			}
+			if errors.Is(err, core.ErrVerificationFailed) {
+				return verificationFailedResponse(mode, appliedIDs, stageID, verifications), nil
+			}
 			return nil, err
 		}
 		appliedIDs = append(appliedIDs, stageID)
@@ -209,6 +371,18 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 	if mode == "all" {
 		structured["appliedCount"] = len(appliedIDs)
 	}
+	if len(verifications) > 0 {
+		structured["verification"] = verifications
+	}
+	if len(remainders) > 0 {
+		structured["remainder"] = remainders
+	}
+	if len(rebased) > 0 {
+		structured["rebased"] = rebased
+	}
+	if mode == "changeset" {
+		structured["changeset"] = args.Changeset
+	}
 
 	sampling, err := t.sampleApply(ctx, summary)
 	if err != nil {
@@ -228,6 +402,18 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 		message = "Applied latest stage: " + appliedIDs[0]
 	case "all":
 		message = fmt.Sprintf("Applied %d stage(s)", len(appliedIDs))
+	case "changeset":
+		message = fmt.Sprintf("Applied changeset %s: %d file(s)", args.Changeset, len(appliedIDs))
+		message += formatVerification(verifications[args.Changeset])
+	}
+	for _, id := range appliedIDs {
+		if remainder, ok := remainders[id]; ok {
+			message += fmt.Sprintf("\nRemaining hunks staged as %s", remainder)
+		}
+		if strategy, ok := rebased[id]; ok {
+			message += fmt.Sprintf("\n%s changed since staging; rebased by %s", id, strategy)
+		}
+		message += formatVerification(verifications[id])
 	}
 
 	return map[string]any{
@@ -237,6 +423,115 @@ func (t *ApplyTool) handle(ctx context.Context, params json.RawMessage) (any, er
 	}, nil
 }
 
+// looseStages drops stages that belong to a changeset, which are only
+// applied together through 'changeset'.
+func looseStages(stages []models.Stage) []models.Stage {
+	return slices.DeleteFunc(stages, func(stage models.Stage) bool { return stage.ChangesetID != "" })
+}
+
+// errNoHunksSelected is returned when the client declines to pick hunks.
+var errNoHunksSelected = errors.New("no hunks selected")
+
+// selectHunks asks the client which hunks of a stage to apply through
+// elicitation.
+func (t *ApplyTool) selectHunks(ctx context.Context, staging types.StagingStore, stageID string) ([]int, error) {
+	stage, err := staging.GetStage(stageID)
+	if err != nil {
+		return nil, types.WrapError(types.InvalidParams, "failed to load stage", err)
+	}
+	hunks := core.DiffHunks(stage.Original, stage.Modified)
+	if len(hunks) == 0 {
+		return nil, types.NewMCPError(types.InvalidParams, fmt.Sprintf("stage %s has no hunks to select", stageID), nil)
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "Stage %s has %d hunk(s). Which should be applied? The rest stay pending as a new stage.\n", stageID, len(hunks))
+	for _, hunk := range hunks {
+		fmt.Fprintf(&message, "\nHunk %d (+%d -%d)\n%s", hunk.Index, hunk.Added, hunk.Removed, hunk.Text)
+	}
+	result, err := t.server.RequestElicitation(ctx, map[string]any{
+		"message": message.String(),
+		"requestedSchema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"hunks": map[string]any{
+					"type":        "string",
+					"title":       "Hunks",
+					"description": "Comma-separated hunk numbers, such as 1,3",
+				},
+			},
+			"required": []string{"hunks"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, types.NewMCPError(types.InvalidParams,
+			"client does not support elicitation: pass 'hunks' instead",
+			nil)
+	}
+	if action, _ := result["action"].(string); action != "accept" {
+		return nil, errNoHunksSelected
+	}
+	content, _ := result["content"].(map[string]any)
+	raw, _ := content["hunks"].(string)
+	var selected []int
+	for _, field := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' }) {
+		index, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, types.NewMCPError(types.InvalidParams, fmt.Sprintf("invalid hunk number %q", field), nil)
+		}
+		selected = append(selected, index)
+	}
+	if len(selected) == 0 {
+		return nil, errNoHunksSelected
+	}
+	return selected, nil
+}
+
+// noHunksSelectedResponse reports that nothing was applied because no hunks
+// were picked; the stage stays pending.
+func noHunksSelectedResponse(mode, stageID string) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{
+			"type": "text",
+			"text": fmt.Sprintf("No hunks selected; stage %s left pending", stageID),
+		}},
+		"applied":           []string{},
+		"structuredContent": map[string]any{"mode": mode, "applied": []string{}, "pending": stageID},
+	}
+}
+
+// verificationFailedResponse reports a stage or changeset whose verify
+// command failed. Its writes have been rolled back and it is marked failed;
+// stages applied before it stay applied.
+func verificationFailedResponse(mode string, appliedIDs []string, failedID string, verifications map[string]*core.VerifyResult) map[string]any {
+	message := fmt.Sprintf("Stage %s failed verification", failedID)
+	if mode == "changeset" {
+		message = fmt.Sprintf("Changeset %s failed verification; none of its files were kept", failedID)
+	}
+	if len(appliedIDs) > 0 {
+		message += fmt.Sprintf(" after applying %d stage(s)", len(appliedIDs))
+	}
+	message += "\n" + formatVerification(verifications[failedID])
+
+	structured := map[string]any{
+		"mode":         mode,
+		"applied":      append([]string{}, appliedIDs...),
+		"failed":       failedID,
+		"verification": verifications,
+	}
+	return map[string]any{
+		"content":           []map[string]any{{"type": "text", "text": message}},
+		"applied":           appliedIDs,
+		"failed":            failedID,
+		"verification":      verifications[failedID],
+		"structuredContent": structured,
+		"isError":           true,
+	}
+}
+
 func (t *ApplyTool) sampleApply(ctx context.Context, summary map[string]any) (map[string]any, error) {
 	// Skip sampling for now — most MCP clients don't support sampling/createMessage
 	// and will cause the server to hang waiting for a response.
@@ -2,8 +2,13 @@ package tools
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/models"
 )
 
 func TestApplyTool_Execute(t *testing.T) {
@@ -268,3 +273,238 @@ func TestApplyTool_InvalidJSON(t *testing.T) {
 		t.Error("Expected error for invalid JSON")
 	}
 }
+
+// verifyingStaging fails verification for the stages in fail.
+type verifyingStaging struct {
+	*mockStaging
+	fail map[string]bool
+}
+
+func (m *verifyingStaging) ApplyStageVerified(ctx context.Context, stageID string, verify *core.VerifyCommand) (*models.Apply, *core.VerifyResult, error) {
+	if !m.fail[stageID] {
+		apply, err := m.ApplyStage(ctx, stageID, false)
+		return apply, &core.VerifyResult{Command: verify.Command, Passed: true}, err
+	}
+	result := &core.VerifyResult{Command: verify.Command, ExitCode: 1, Output: "FAIL pkg", RolledBack: true}
+	return nil, result, fmt.Errorf("stage %s %w: %s", stageID, core.ErrVerificationFailed, result.Summary())
+}
+
+func TestApplyTool_VerifyFailure(t *testing.T) {
+	server := newMockServer()
+	server.verifyPolicy.Enabled = true
+	staging := &verifyingStaging{
+		mockStaging: &mockStaging{enabled: true, stages: make(map[string]any)},
+		fail:        map[string]bool{"stage1": true},
+	}
+	server.staging = staging
+	staging.AddStage("stage1", map[string]any{"id": "stage1"})
+	tool := NewApplyTool(server)
+
+	result, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"id":     "stage1",
+		"verify": map[string]any{"command": "go test ./...", "timeout": "30s"},
+	}))
+	assertNoError(t, err)
+
+	resultMap := result.(map[string]any)
+	if resultMap["isError"] != true || resultMap["failed"] != "stage1" {
+		t.Fatalf("expected a failed verification response, got %+v", resultMap)
+	}
+	verification, ok := resultMap["verification"].(*core.VerifyResult)
+	if !ok || verification.Passed {
+		t.Fatalf("expected the failed verification result, got %#v", resultMap["verification"])
+	}
+	if len(toStringSlice(resultMap["applied"])) != 0 {
+		t.Fatalf("expected nothing applied, got %v", resultMap["applied"])
+	}
+	if text := extractContentText(t, resultMap); !strings.Contains(text, "FAIL pkg") || !strings.Contains(text, "rolled back") {
+		t.Fatalf("expected output and rollback in text, got %q", text)
+	}
+}
+
+func TestApplyTool_VerifyRequiresVerifier(t *testing.T) {
+	server := newMockServer()
+	server.verifyPolicy.Enabled = true
+	setStaging(server, true)
+	addTestStage(server, "stage1", map[string]any{"id": "stage1"})
+	tool := NewApplyTool(server)
+
+	_, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"id":     "stage1",
+		"verify": map[string]any{"command": "go test ./..."},
+	}))
+	assertError(t, err, "does not support verify")
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"verify": map[string]any{"command": ""},
+	}))
+	assertError(t, err, "Invalid verify")
+}
+
+func TestApplyTool_VerifyNeedsServerOptIn(t *testing.T) {
+	server := newMockServer()
+	staging := &verifyingStaging{mockStaging: &mockStaging{enabled: true, stages: make(map[string]any)}}
+	server.staging = staging
+	staging.AddStage("stage1", map[string]any{"id": "stage1"})
+	tool := NewApplyTool(server)
+
+	verify := func(command string) error {
+		_, err := tool.handle(context.Background(), createTestParams(map[string]any{
+			"id":     "stage1",
+			"verify": map[string]any{"command": command, "dir": "/"},
+		}))
+		return err
+	}
+	assertError(t, verify("go test ./..."), "Verify not allowed")
+
+	server.verifyPolicy = core.VerifyPolicy{Enabled: true, Allow: []string{"go test"}}
+	assertError(t, verify("rm -rf ."), "Verify not allowed")
+	assertError(t, verify("go test ./... && rm -rf ."), "Verify not allowed")
+	assertNoError(t, verify("go test ./..."))
+}
+
+// hunkStaging records the hunks applied and leaves a remainder stage.
+type hunkStaging struct {
+	*mockStaging
+	hunks []int
+}
+
+func (m *hunkStaging) GetStage(stageID string) (*models.Stage, error) {
+	stage, err := m.mockStaging.GetStage(stageID)
+	if err != nil {
+		return nil, err
+	}
+	stage.Original = "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	stage.Modified = "A\nb\nc\nd\ne\nf\ng\nh\ni\nJ\n"
+	return stage, nil
+}
+
+func (m *hunkStaging) ApplyStageHunks(ctx context.Context, stageID string, hunks []int, verify *core.VerifyCommand) (*models.Apply, *models.Stage, *core.VerifyResult, error) {
+	m.hunks = hunks
+	apply, err := m.ApplyStage(ctx, stageID, false)
+	return apply, &models.Stage{ID: stageID + "-rest", Status: "pending"}, nil, err
+}
+
+func newHunkServer() (*mockServer, *hunkStaging) {
+	server := newMockServer()
+	staging := &hunkStaging{mockStaging: &mockStaging{enabled: true, stages: make(map[string]any)}}
+	staging.AddStage("stage1", map[string]any{"id": "stage1"})
+	server.staging = staging
+	return server, staging
+}
+
+func TestApplyTool_Hunks(t *testing.T) {
+	server, staging := newHunkServer()
+
+	result, err := NewApplyTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"id":    "stage1",
+		"hunks": []int{2},
+	}))
+	assertNoError(t, err)
+
+	if fmt.Sprint(staging.hunks) != "[2]" {
+		t.Fatalf("expected hunk 2 to be applied, got %v", staging.hunks)
+	}
+	resultMap := result.(map[string]any)
+	remainders, _ := resultMap["structuredContent"].(map[string]any)["remainder"].(map[string]string)
+	if remainders["stage1"] != "stage1-rest" {
+		t.Fatalf("expected the remainder stage to be reported, got %+v", resultMap["structuredContent"])
+	}
+	if text := extractContentText(t, resultMap); !strings.Contains(text, "Remaining hunks staged as stage1-rest") {
+		t.Fatalf("expected the remainder in text, got %q", text)
+	}
+}
+
+func TestApplyTool_SelectHunks(t *testing.T) {
+	server, staging := newHunkServer()
+	server.elicitationResult = map[string]any{"action": "accept", "content": map[string]any{"hunks": "1, 2"}}
+
+	_, err := NewApplyTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"id":           "stage1",
+		"select_hunks": true,
+	}))
+	assertNoError(t, err)
+
+	if fmt.Sprint(staging.hunks) != "[1 2]" {
+		t.Fatalf("expected the elicited hunks to be applied, got %v", staging.hunks)
+	}
+	if len(server.elicitationRequests) != 1 || !strings.Contains(server.elicitationRequests[0]["message"].(string), "Hunk 2 (+1 -1)") {
+		t.Fatalf("expected the hunks to be listed in the elicitation, got %+v", server.elicitationRequests)
+	}
+}
+
+func TestApplyTool_SelectHunksDeclined(t *testing.T) {
+	server, staging := newHunkServer()
+	server.elicitationResult = map[string]any{"action": "decline"}
+
+	result, err := NewApplyTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"id":           "stage1",
+		"select_hunks": true,
+	}))
+	assertNoError(t, err)
+
+	if staging.hunks != nil {
+		t.Fatalf("expected nothing applied, got %v", staging.hunks)
+	}
+	if text := extractContentText(t, result.(map[string]any)); !strings.Contains(text, "left pending") {
+		t.Fatalf("expected the stage to be left pending, got %q", text)
+	}
+}
+
+func TestApplyTool_HunksValidation(t *testing.T) {
+	server, _ := newHunkServer()
+	_, err := NewApplyTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"all":   true,
+		"hunks": []int{1},
+	}))
+	assertError(t, err, "single stage")
+
+	plain := newMockServer()
+	setStaging(plain, true)
+	addTestStage(plain, "stage1", map[string]any{"id": "stage1"})
+	_, err = NewApplyTool(plain).handle(context.Background(), createTestParams(map[string]any{
+		"id":    "stage1",
+		"hunks": []int{1},
+	}))
+	assertError(t, err, "does not support applying hunks")
+
+	_, err = NewApplyTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"id":           "stage1",
+		"select_hunks": true,
+	}))
+	assertError(t, err, "does not support elicitation")
+}
+
+// rebaseStaging is a mock staging manager whose stages were edited since
+// staging and are rebased by merging when applied
+type rebaseStaging struct {
+	*mockStaging
+}
+
+func (m *rebaseStaging) ApplyStage(ctx context.Context, stageID string, autoApplied bool) (*models.Apply, error) {
+	apply, err := m.mockStaging.ApplyStage(ctx, stageID, autoApplied)
+	if apply != nil {
+		apply.Rebased = "merge"
+	}
+	return apply, err
+}
+
+func TestApplyTool_ReportsRebase(t *testing.T) {
+	server := newMockServer()
+	staging := &rebaseStaging{mockStaging: &mockStaging{enabled: true, stages: make(map[string]any)}}
+	staging.AddStage("stage1", map[string]any{"id": "stage1"})
+	server.staging = staging
+
+	result, err := NewApplyTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"id": "stage1",
+	}))
+	assertNoError(t, err)
+
+	if text := extractContentText(t, result); !strings.Contains(text, "stage1 changed since staging; rebased by merge") {
+		t.Fatalf("expected the rebase reported, got:\n%s", text)
+	}
+	rebased, _ := result.(map[string]any)["structuredContent"].(map[string]any)["rebased"].(map[string]string)
+	if rebased["stage1"] != "merge" {
+		t.Fatalf("expected structured rebase, got %+v", rebased)
+	}
+}
@@ -3,14 +3,17 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/mcp/types"
 )
 
-const dslSelectorDescription = "Morfx DSL selector for read tools. Use this instead of query when matching nested AST structure. Syntax: kind:name with * wildcard and $capture patterns. Operators: ! not, > contains descendant, >> direct semantic child, & and, | or, parentheses for grouping. Use attributes as key=value or shorthand type. Common attributes: arg, arg0, source, text, before, after. Common selectors: func, def, function, method, class, struct, interface, field, call, return, assignment, condition, block, loop, import. Examples: func:* > call:os.Getenv; class:* >> method:render; call:$client.$method; call:fetch arg0=\"/api/user\"; struct:* > field:Secret type=string; (func:* | method:*) > call:fetch."
+const dslSelectorDescription = "Morfx DSL selector for read tools. Use this instead of query when matching nested AST structure. Syntax: kind:name with * wildcard and $capture patterns. Operators: ! not, > contains descendant, >> direct semantic child, & and, | or, parentheses for grouping. Use attributes as key=value or shorthand type. Common attributes: arg, arg0, source, text, before, after, lines (a..b source line range). Common selectors: func, def, function, method, class, struct, interface, field, call, return, assignment, condition, block, loop, import. Examples: func:* > call:os.Getenv; class:* >> method:render; call:$client.$method; call:fetch arg0=\"/api/user\"; struct:* > field:Secret type=string; (func:* | method:*) > call:fetch."
 
-const targetDSLSelectorDescription = "Morfx target_dsl selector for mutation tools. Use this instead of target when matching nested AST structure. Syntax: kind:name with * wildcard and $capture patterns. Operators: ! not, > contains descendant, >> direct semantic child, & and, | or, parentheses for grouping. Use attributes as key=value or shorthand type. Common attributes: arg, arg0, source, text, before, after. Common selectors: func, def, function, method, class, struct, interface, field, call, return, assignment, condition, block, loop, import. Examples: func:Legacy*; func:* > call:os.Getenv; class:* >> method:render; call:fetch arg0=\"/api/user\"; struct:* > field:Secret type=string."
+const targetDSLSelectorDescription = "Morfx target_dsl selector for mutation tools. Use this instead of target when matching nested AST structure. Syntax: kind:name with * wildcard and $capture patterns. Operators: ! not, > contains descendant, >> direct semantic child, & and, | or, parentheses for grouping. Use attributes as key=value or shorthand type. Common attributes: arg, arg0, source, text, before, after, lines (a..b source line range). Common selectors: func, def, function, method, class, struct, interface, field, call, return, assignment, condition, block, loop, import. Examples: func:Legacy*; func:* > call:os.Getenv; class:* >> method:render; call:fetch arg0=\"/api/user\"; struct:* > field:Secret type=string."
 
 // BaseTool provides common tool functionality
 type BaseTool struct {
@@ -80,14 +83,26 @@ func (b *ToolBuilder) Build() types.Tool {
 
 // CommonSchemas provides reusable schema definitions
 var CommonSchemas = struct {
-	Language    map[string]any
-	Source      map[string]any
-	Path        map[string]any
-	Query       map[string]any
-	DSL         map[string]any
-	Replacement map[string]any
-	Target      map[string]any
-	TargetDSL   map[string]any
+	Language        map[string]any
+	Source          map[string]any
+	Path            map[string]any
+	Query           map[string]any
+	DSL             map[string]any
+	Replacement     map[string]any
+	Target          map[string]any
+	TargetDSL       map[string]any
+	OrganizeImports map[string]any
+	Format          map[string]any
+	Overlap         map[string]any
+	IfAbsent        map[string]any
+	ExpectMatches   map[string]any
+	Attached        map[string]any
+	StructuralDiff  map[string]any
+	SortBy          map[string]any
+	SortOrder       map[string]any
+	TypeHints       map[string]any
+	Stage           map[string]any
+	Verify          map[string]any
 }{
 	Language: map[string]any{
 		"type":        "string",
@@ -139,6 +154,90 @@ var CommonSchemas = struct {
 		"type":        "string",
 		"description": targetDSLSelectorDescription,
 	},
+	OrganizeImports: map[string]any{
+		"type":        "boolean",
+		"description": "After the edit, remove imports it left unused and add standard library imports it needs",
+	},
+	Format: map[string]any{
+		"type":        "boolean",
+		"description": "Run the language formatter (gofmt, black, prettier, php-cs-fixer) over the result; falls back to unformatted output if it fails",
+	},
+	Overlap: map[string]any{
+		"type":        "string",
+		"enum":        []string{"outermost", "innermost", "error"},
+		"description": "Which match wins when matches nest or overlap, such as a class and one of its methods (default outermost); error refuses the edit",
+	},
+	IfAbsent: map[string]any{
+		"type":        "boolean",
+		"description": "Skip targets where equivalent content already exists at the insertion point, ignoring formatting and comments, so re-runs do not duplicate it",
+	},
+	Attached: map[string]any{
+		"type":        "string",
+		"enum":        []string{"include", "exclude"},
+		"description": "Whether the edited range covers the target's doc comments, decorators or attributes, and trailing same-line comment (default include for delete, exclude for replace)",
+	},
+	StructuralDiff: map[string]any{
+		"type":        "boolean",
+		"description": "Report the node-level edits to each modified file, such as inserted, moved, or renamed declarations, in structural_diff",
+	},
+	ExpectMatches: map[string]any{
+		"type":        []string{"integer", "string"},
+		"description": "Abort before anything is written unless the match count is exactly this number or inside a range such as \"1..5\"; the error lists every matched location",
+	},
+	SortBy: map[string]any{
+		"type":        "string",
+		"description": "Sort key: name (default), visibility, kind, or a DSL capture such as $name",
+	},
+	SortOrder: map[string]any{
+		"type":        "array",
+		"items":       map[string]any{"type": "string"},
+		"description": "Rank order for visibility or kind sorts, such as [\"public\", \"protected\", \"private\"]; unlisted values sort last",
+	},
+	TypeHints: map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "string"},
+		"description":          "Type hints by parameter name, with \"return\" for the return type, such as {\"user_id\": \"int\", \"return\": \"User | None\"}",
+	},
+	Stage: map[string]any{
+		"type":        "boolean",
+		"description": "Stage every edited file as one changeset instead of writing; apply it with apply {changeset}, which writes all files or none",
+	},
+	Verify: map[string]any{
+		"type":        "object",
+		"description": "Command run after files are written, such as go test ./pkg/...; a non-zero exit or timeout rolls the write back and returns the captured output. Refused unless the server enables verify commands (morfx mcp --verify) and allows this one",
+		"properties": map[string]any{
+			"command": map[string]any{"type": "string", "description": "Shell command (sh -c, or cmd /C on Windows)"},
+			"timeout": map[string]any{"type": "string", "description": "Go duration such as \"90s\"; defaults to 5m"},
+			"dir":     map[string]any{"type": "string", "description": "Working directory; defaults to the scope path or the file's directory"},
+		},
+		"required": []string{"command"},
+	},
+}
+
+// verifyTimeout extends a tool's base timeout by the verify command's own.
+func verifyTimeout(base time.Duration, verify *core.VerifyCommand) time.Duration {
+	if verify == nil {
+		return base
+	}
+	return base + verify.TimeoutDuration()
+}
+
+// formatVerification renders a verify command's outcome for tool responses.
+func formatVerification(result *core.VerifyResult) string {
+	if result == nil {
+		return ""
+	}
+	if result.Passed {
+		return fmt.Sprintf("\n✅ Verified: %s (%dms)\n", result.Command, result.DurationMs)
+	}
+	text := fmt.Sprintf("\n❌ Verification failed: %s\n", result.Summary())
+	if result.RolledBack {
+		text += "↩️  Changes rolled back.\n"
+	}
+	if output := strings.TrimSpace(result.Output); output != "" {
+		text += "\n" + output + "\n"
+	}
+	return text
 }
 
 func parseRequiredQuery(raw json.RawMessage, dsl, label string) (core.AgentQuery, error) {
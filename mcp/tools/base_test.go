@@ -43,9 +43,13 @@ type mockServer struct {
 	staging          any
 	safety           any
 	sessionID        string
+	verifyPolicy     core.VerifyPolicy
 	samplingRequests []map[string]any
 	samplingResults  []map[string]any
 	samplingErr      error
+
+	elicitationRequests []map[string]any
+	elicitationResult   map[string]any
 }
 
 func newMockServer() *mockServer {
@@ -56,7 +60,7 @@ func newMockServer() *mockServer {
 	coreRegistry := newMockRegistry()
 
 	// Register mock providers in both registries
-	providers := []string{"go", "javascript", "typescript", "php"}
+	providers := []string{"go", "javascript", "typescript", "php", "python"}
 	for _, lang := range providers {
 		mock := &mockProvider{language: lang}
 		providerRegistry.Register(mock)
@@ -96,6 +100,10 @@ func (m *mockServer) GetSessionID() string {
 	return m.sessionID
 }
 
+func (m *mockServer) CheckVerify(verify *core.VerifyCommand) error {
+	return m.verifyPolicy.Check(verify)
+}
+
 func (m *mockServer) ReportProgress(ctx context.Context, progress, total float64, message string) {}
 
 func (m *mockServer) ConfirmApply(ctx context.Context, summary string) error {
@@ -116,7 +124,8 @@ func (m *mockServer) RequestSampling(ctx context.Context, params map[string]any)
 }
 
 func (m *mockServer) RequestElicitation(ctx context.Context, params map[string]any) (map[string]any, error) {
-	return nil, nil
+	m.elicitationRequests = append(m.elicitationRequests, params)
+	return m.elicitationResult, nil
 }
 
 func (m *mockServer) FinalizeTransform(ctx context.Context, req types.TransformRequest) (map[string]any, error) {
@@ -0,0 +1,195 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+)
+
+// BatchTool applies several operations to one source as a single change
+type BatchTool struct {
+	*BaseTool
+	server types.ServerInterface
+}
+
+// NewBatchTool creates a new batch tool
+func NewBatchTool(server types.ServerInterface) *BatchTool {
+	tool := &BatchTool{
+		server: server,
+	}
+
+	tool.BaseTool = &BaseTool{
+		name:        "batch",
+		description: "Apply an ordered list of operations to one file or source. Each step re-resolves its target against the result of the previous step; the batch produces one diff, one combined confidence, and one stage.",
+		inputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"language": CommonSchemas.Language,
+				"source":   CommonSchemas.Source,
+				"path":     CommonSchemas.Path,
+				"ops": map[string]any{
+					"type":        "array",
+					"description": "Operations applied in order",
+					"minItems":    1,
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"method": map[string]any{
+								"type": "string",
+								"enum": []string{
+									"replace", "delete", "insert_before", "insert_after", "append",
+									"extract_function", "ensure_import", "remove_import", "sort",
+									"add_annotation", "remove_annotation", "add_tag", "remove_tag", "rewrite_tag",
+									"set_docstring", "annotate",
+								},
+							},
+							"target":         CommonSchemas.Target,
+							"target_dsl":     CommonSchemas.TargetDSL,
+							"replacement":    CommonSchemas.Replacement,
+							"content":        map[string]any{"type": "string", "description": "Content for insert and append steps, or the docstring text for set_docstring steps"},
+							"import":         map[string]any{"type": "string", "description": "Import for ensure_import steps"},
+							"function_name":  map[string]any{"type": "string", "description": "Function name for extract_function steps"},
+							"sort_by":        CommonSchemas.SortBy,
+							"sort_order":     CommonSchemas.SortOrder,
+							"annotation":     map[string]any{"type": "string", "description": "Annotation or struct tag template for add and rewrite steps, or the name pattern for remove steps"},
+							"tag_key":        map[string]any{"type": "string", "description": "Struct tag key for rewrite_tag steps"},
+							"type_hints":     CommonSchemas.TypeHints,
+							"overlap":        CommonSchemas.Overlap,
+							"attached":       CommonSchemas.Attached,
+							"if_absent":      CommonSchemas.IfAbsent,
+							"expect_matches": CommonSchemas.ExpectMatches,
+						},
+						"required": []string{"method"},
+					},
+				},
+				"organize_imports": CommonSchemas.OrganizeImports,
+				"format":           CommonSchemas.Format,
+			},
+			"required": []string{"language", "ops"},
+			"oneOf": []map[string]any{
+				{"required": []string{"source"}},
+				{"required": []string{"path"}},
+			},
+		},
+		handler: tool.handle,
+	}
+
+	return tool
+}
+
+// handle executes the batch tool
+func (t *BatchTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var args struct {
+		Language        string          `json:"language"`
+		Source          string          `json:"source"`
+		Path            string          `json:"path"`
+		Ops             json.RawMessage `json:"ops"`
+		OrganizeImports bool            `json:"organize_imports,omitempty"`
+		Format          bool            `json:"format,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid batch parameters", err)
+	}
+
+	// Validate that exactly one of source or path is provided
+	if (args.Source == "" && args.Path == "") || (args.Source != "" && args.Path != "") {
+		return nil, types.NewMCPError(types.InvalidParams, "Exactly one of 'source' or 'path' must be provided", nil)
+	}
+
+	var ops []core.BatchOp
+	if len(args.Ops) > 0 {
+		if err := json.Unmarshal(args.Ops, &ops); err != nil {
+			return nil, types.WrapError(types.InvalidParams, "Invalid batch ops", err)
+		}
+	}
+	op, err := core.BatchTransformOp(ops)
+	if err != nil {
+		return nil, types.NewMCPError(types.InvalidParams, "Invalid batch ops: "+err.Error(), nil)
+	}
+	op.OrganizeImports = args.OrganizeImports
+	op.Format = args.Format
+
+	notifyProgress(ctx, t.server, 5, 100, "validating")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get source code
+	var source string
+	if args.Path != "" {
+		content, err := os.ReadFile(args.Path)
+		if err != nil {
+			return nil, types.WrapError(types.FileSystemError, "Failed to read file", err)
+		}
+		source = string(content)
+		notifyProgress(ctx, t.server, 15, 100, "loaded file")
+	} else {
+		source = args.Source
+	}
+
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get provider
+	provider, exists := t.server.GetProviders().Get(args.Language)
+	if !exists {
+		return nil, types.NewMCPError(types.LanguageNotFound, "Language not supported", nil)
+	}
+
+	notifyProgress(ctx, t.server, 25, 100, "resolved provider")
+
+	result := provider.Transform(source, op)
+	if result.Error != nil {
+		return nil, types.WrapError(types.TransformFailed, "Batch operation failed", result.Error)
+	}
+
+	notifyProgress(ctx, t.server, 70, 100, "transformed source")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	notifyProgress(ctx, t.server, 90, 100, "finalizing")
+
+	return t.server.FinalizeTransform(ctx, types.TransformRequest{
+		Language:       args.Language,
+		Operation:      "batch",
+		TargetJSON:     args.Ops,
+		Path:           args.Path,
+		OriginalSource: source,
+		Result:         result,
+		ResponseText:   t.formatResponse(result, args.Path),
+		Op:             &op,
+	})
+}
+
+// formatResponse formats the batch result
+func (t *BatchTool) formatResponse(result core.TransformResult, path string) string {
+	response := "✅ Batch operation completed successfully\n\n"
+
+	if path != "" {
+		response += "📄 File: " + path + "\n\n"
+	}
+
+	steps, _ := result.Metadata["steps"].([]map[string]any)
+	for i, step := range steps {
+		score, _ := step["confidence"].(float64)
+		response += fmt.Sprintf("%d. %s: %v matches (%s)\n", i+1, step["method"], step["matches"], formatConfidence(score))
+	}
+
+	if result.Diff != "" {
+		response += "\nChanges:\n" + result.Diff + "\n"
+	}
+
+	response += "\nConfidence: " + formatConfidence(result.Confidence.Score)
+
+	return response
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestBatchTool_Execute(t *testing.T) {
+	server := newMockServer()
+	tool := NewBatchTool(server)
+
+	result, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"language": "go",
+		"source":   "package main\n\nfunc Old() {}\n",
+		"ops": []map[string]any{
+			{"method": "replace", "target_dsl": "func:Old", "replacement": "func New() {}"},
+			{"method": "insert_after", "target": map[string]any{"type": "function", "name": "New"}, "content": "func Next() {}"},
+		},
+	}))
+	assertNoError(t, err)
+	if !hasContentArray(result) {
+		t.Fatalf("expected content array, got %#v", result)
+	}
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"language": "go",
+		"source":   "package main\n",
+		"ops":      []map[string]any{},
+	}))
+	assertError(t, err, "ops")
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"language": "go",
+		"source":   "package main\n",
+		"ops":      []map[string]any{{"method": "replace", "target_dsl": "func:Old"}},
+	}))
+	assertError(t, err, "replacement")
+}
+
+func TestBatchTool_FormatResponseListsSteps(t *testing.T) {
+	tool := NewBatchTool(newMockServer())
+	text := tool.formatResponse(core.TransformResult{
+		Diff:       "--- original\n+++ modified\n",
+		Confidence: core.ConfidenceScore{Score: 0.9},
+		Metadata: map[string]any{"steps": []map[string]any{
+			{"method": "replace", "matches": 1, "confidence": 0.95},
+			{"method": "delete", "matches": 2, "confidence": 0.9},
+		}},
+	}, "main.go")
+	for _, want := range []string{"📄 File: main.go", "1. replace: 1 matches", "2. delete: 2 matches"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("response missing %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+	"github.com/oxhq/morfx/models"
+)
+
+// changesetStager returns the server's staging manager when it can stage
+// changesets, for tools asked to stage instead of write.
+func changesetStager(server types.ServerInterface) (types.StagingChangesets, error) {
+	stagingRaw := server.GetStaging()
+	if stagingRaw == nil {
+		return nil, types.NewMCPError(types.InvalidParams,
+			"Staging not available",
+			map[string]any{"reason": "Database connection required for staging"})
+	}
+	if toggle, ok := stagingRaw.(types.StagingToggle); ok && !toggle.IsEnabled() {
+		return nil, types.NewMCPError(types.InvalidParams, "staging is not enabled", nil)
+	}
+	stager, ok := stagingRaw.(types.StagingChangesets)
+	if !ok {
+		return nil, types.NewMCPError(types.InvalidParams,
+			"Staging manager does not support changesets",
+			nil)
+	}
+	return stager, nil
+}
+
+// stageChangeset stages changes as one changeset, or returns nil when there
+// is nothing to stage.
+func stageChangeset(ctx context.Context, server types.ServerInterface, stager types.StagingChangesets, operation, description string, changes []core.FileChange) (*models.Changeset, error) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	changeset, err := stager.CreateChangeset(ctx, server.GetSessionID(), operation, description, changes)
+	if err != nil {
+		return nil, types.WrapError(types.FileSystemError, "failed to stage changeset", err)
+	}
+	return changeset, nil
+}
+
+// formatChangeset renders a staged changeset for tool responses.
+func formatChangeset(changeset *models.Changeset) string {
+	if changeset == nil {
+		return ""
+	}
+	return fmt.Sprintf("\n📋 Staged %d file(s) as changeset %s (confidence %.2f)\nUse the apply tool with 'changeset' to write them together.",
+		len(changeset.Stages), changeset.ID, changeset.ConfidenceScore)
+}
@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/models"
+)
+
+// changesetStaging is a mock staging manager that can stage changesets
+type changesetStaging struct {
+	*mockStaging
+	changesets map[string]*models.Changeset
+	created    []core.FileChange
+	applied    string
+}
+
+func (m *changesetStaging) CreateChangeset(ctx context.Context, sessionID, operation, description string, changes []core.FileChange) (*models.Changeset, error) {
+	m.created = changes
+	changeset := &models.Changeset{
+		ID:              fmt.Sprintf("chg_%d", len(m.changesets)+1),
+		SessionID:       sessionID,
+		Operation:       operation,
+		Description:     description,
+		Status:          "pending",
+		ConfidenceScore: 0.9,
+	}
+	for i, change := range changes {
+		changeset.Stages = append(changeset.Stages, models.Stage{
+			ID:          fmt.Sprintf("%s_stg%d", changeset.ID, i),
+			ChangesetID: changeset.ID,
+			Modified:    change.Modified,
+			Status:      "pending",
+		})
+	}
+	m.changesets[changeset.ID] = changeset
+	return changeset, nil
+}
+
+func (m *changesetStaging) GetChangeset(changesetID string) (*models.Changeset, error) {
+	changeset, ok := m.changesets[changesetID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return changeset, nil
+}
+
+func (m *changesetStaging) ApplyChangeset(ctx context.Context, changesetID string, verify *core.VerifyCommand) (*models.Changeset, *core.VerifyResult, error) {
+	changeset, err := m.GetChangeset(changesetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	m.applied = changesetID
+	changeset.Status = "applied"
+	return changeset, nil, nil
+}
+
+func newChangesetServer() (*mockServer, *changesetStaging) {
+	server := newMockServer()
+	staging := &changesetStaging{
+		mockStaging: &mockStaging{enabled: true, stages: make(map[string]any)},
+		changesets:  make(map[string]*models.Changeset),
+	}
+	server.staging = staging
+	return server, staging
+}
+
+func writeChangesetFiles(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package main\n\nfunc Old() {}\n"), 0o644); err != nil {
+			t.Fatalf("failed to seed %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestFileToolsStage(t *testing.T) {
+	server, staging := newChangesetServer()
+	dir := writeChangesetFiles(t)
+
+	result, err := NewFileReplaceTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"scope":       map[string]any{"path": dir, "include": []string{"*.go"}},
+		"target_dsl":  "func:Old",
+		"replacement": "func New() {}",
+		"stage":       true,
+	}))
+	assertNoError(t, err)
+
+	if len(staging.created) != 2 {
+		t.Fatalf("expected both files staged, got %d", len(staging.created))
+	}
+	for _, change := range staging.created {
+		if change.Original != "package main\n\nfunc Old() {}\n" || change.Modified != "func New() {}" {
+			t.Fatalf("unexpected staged change for %s: %+v", change.Path, change)
+		}
+		content, err := os.ReadFile(change.Path)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", change.Path, err)
+		}
+		if !strings.Contains(string(content), "func Old()") {
+			t.Fatalf("staging must not write %s, got:\n%s", change.Path, content)
+		}
+	}
+
+	response := result.(map[string]any)
+	if response["changeset"] != "chg_1" {
+		t.Fatalf("expected changeset in response, got %v", response["changeset"])
+	}
+	text := extractContentText(t, result)
+	if !strings.Contains(text, "[STAGED]") || !strings.Contains(text, "as changeset chg_1") {
+		t.Fatalf("expected staged wording in response:\n%s", text)
+	}
+
+	_, err = NewFileDeleteTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"scope":      map[string]any{"path": dir, "include": []string{"*.go"}},
+		"target_dsl": "func:Old",
+		"stage":      true,
+	}))
+	assertNoError(t, err)
+	if len(staging.changesets) != 2 || staging.changesets["chg_2"].Operation != "file_delete" {
+		t.Fatalf("expected file_delete to stage its own changeset, got %+v", staging.changesets)
+	}
+}
+
+func TestFileToolsStageValidation(t *testing.T) {
+	dir := writeChangesetFiles(t)
+
+	server, _ := newChangesetServer()
+	server.verifyPolicy.Enabled = true
+	_, err := NewFileReplaceTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"scope":       map[string]any{"path": dir},
+		"target_dsl":  "func:Old",
+		"replacement": "func New() {}",
+		"stage":       true,
+		"verify":      map[string]any{"command": "go test ./..."},
+	}))
+	assertError(t, err, "verify cannot be combined with stage")
+
+	server = newMockServer()
+	setStaging(server, true)
+	_, err = NewFileDeleteTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"scope":      map[string]any{"path": dir},
+		"target_dsl": "func:Old",
+		"stage":      true,
+	}))
+	assertError(t, err, "does not support changesets")
+}
+
+func TestApplyTool_Changeset(t *testing.T) {
+	server, staging := newChangesetServer()
+	changeset, _ := staging.CreateChangeset(context.Background(), "mock-session", "recipe", "recipe rename", []core.FileChange{
+		{Path: "a.go", Modified: "a"},
+		{Path: "b.go", Modified: "b"},
+	})
+
+	result, err := NewApplyTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"changeset": changeset.ID,
+	}))
+	assertNoError(t, err)
+
+	if staging.applied != changeset.ID {
+		t.Fatalf("expected changeset %s applied, got %q", changeset.ID, staging.applied)
+	}
+	if text := extractContentText(t, result); !strings.Contains(text, "Applied changeset chg_1: 2 file(s)") {
+		t.Fatalf("unexpected response:\n%s", text)
+	}
+	structured := result.(map[string]any)["structuredContent"].(map[string]any)
+	if structured["changeset"] != changeset.ID || len(structured["applied"].([]string)) != 2 {
+		t.Fatalf("expected both stages reported, got %+v", structured)
+	}
+
+	_, err = NewApplyTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"changeset": changeset.ID,
+	}))
+	assertError(t, err, "changeset already applied")
+}
+
+func TestApplyTool_ChangesetValidation(t *testing.T) {
+	server, _ := newChangesetServer()
+	tool := NewApplyTool(server)
+
+	_, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"changeset": "chg_missing",
+	}))
+	assertError(t, err, "changeset not found")
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"changeset": "chg_1",
+		"hunks":     []int{1},
+	}))
+	assertError(t, err, "hunks can only be selected for a single stage")
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"changeset": "chg_1",
+		"latest":    true,
+	}))
+	assertError(t, err, "specify only one of")
+
+	server = newMockServer()
+	setStaging(server, true)
+	_, err = NewApplyTool(server).handle(context.Background(), createTestParams(map[string]any{
+		"changeset": "chg_1",
+	}))
+	assertError(t, err, "does not support changesets")
+}
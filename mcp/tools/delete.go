@@ -28,11 +28,15 @@ func NewDeleteTool(server types.ServerInterface) *DeleteTool {
 		inputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"language":   CommonSchemas.Language,
-				"source":     CommonSchemas.Source,
-				"path":       CommonSchemas.Path,
-				"target":     CommonSchemas.Target,
-				"target_dsl": CommonSchemas.TargetDSL,
+				"language":         CommonSchemas.Language,
+				"source":           CommonSchemas.Source,
+				"path":             CommonSchemas.Path,
+				"target":           CommonSchemas.Target,
+				"target_dsl":       CommonSchemas.TargetDSL,
+				"organize_imports": CommonSchemas.OrganizeImports,
+				"format":           CommonSchemas.Format,
+				"overlap":          CommonSchemas.Overlap,
+				"attached":         CommonSchemas.Attached,
 			},
 			"required": []string{"language"},
 			"oneOf": []map[string]any{
@@ -52,11 +56,15 @@ func (t *DeleteTool) handle(ctx context.Context, params json.RawMessage) (any, e
 		ctx = context.Background()
 	}
 	var args struct {
-		Language  string          `json:"language"`
-		Source    string          `json:"source"`
-		Path      string          `json:"path"`
-		Target    json.RawMessage `json:"target"`
-		TargetDSL string          `json:"target_dsl,omitempty"`
+		Language        string          `json:"language"`
+		Source          string          `json:"source"`
+		Path            string          `json:"path"`
+		Target          json.RawMessage `json:"target"`
+		TargetDSL       string          `json:"target_dsl,omitempty"`
+		OrganizeImports bool            `json:"organize_imports,omitempty"`
+		Format          bool            `json:"format,omitempty"`
+		Overlap         string          `json:"overlap,omitempty"`
+		Attached        string          `json:"attached,omitempty"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
@@ -107,8 +115,12 @@ func (t *DeleteTool) handle(ctx context.Context, params json.RawMessage) (any, e
 
 	// Execute transformation
 	op := core.TransformOp{
-		Method: "delete",
-		Target: target,
+		Method:          "delete",
+		Target:          target,
+		OrganizeImports: args.OrganizeImports,
+		Format:          args.Format,
+		Overlap:         args.Overlap,
+		Attached:        args.Attached,
 	}
 
 	result := provider.Transform(source, op)
@@ -131,6 +143,7 @@ func (t *DeleteTool) handle(ctx context.Context, params json.RawMessage) (any, e
 		OriginalSource: source,
 		Result:         result,
 		ResponseText:   t.formatResponse(result, args.Path),
+		Op:             &op,
 	})
 }
 
@@ -149,6 +162,7 @@ func (t *DeleteTool) formatResponse(result core.TransformResult, path string) st
 	if result.MatchCount > 0 {
 		response += fmt.Sprintf("Deletions made: %d\n", result.MatchCount)
 	}
+	response += formatDroppedMatches(result)
 
 	response += fmt.Sprintf("\nConfidence: %.1f%%", result.Confidence.Score*100)
 
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestSetDocstringTool_Execute(t *testing.T) {
+	tool := NewSetDocstringTool(newMockServer())
+
+	result, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "python",
+		"source":     "def load(path):\n    return path\n",
+		"target_dsl": "def:load",
+		"docstring":  "Load a file.",
+	}))
+	assertNoError(t, err)
+	if !hasContentArray(result) {
+		t.Fatalf("expected content array, got %#v", result)
+	}
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "python",
+		"source":     "def load(path):\n    return path\n",
+		"target_dsl": "def:load",
+	}))
+	assertError(t, err, "docstring")
+}
+
+func TestAnnotateTool_Execute(t *testing.T) {
+	tool := NewAnnotateTool(newMockServer())
+
+	result, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "python",
+		"source":     "def load(path):\n    return path\n",
+		"target_dsl": "def:load",
+		"type_hints": map[string]any{"path": "str", "return": "str"},
+	}))
+	assertNoError(t, err)
+	if !hasContentArray(result) {
+		t.Fatalf("expected content array, got %#v", result)
+	}
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "python",
+		"source":     "def load(path):\n    return path\n",
+		"target_dsl": "def:load",
+	}))
+	assertError(t, err, "type_hints")
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "python",
+		"source":     "def load(path):\n    return path\n",
+		"target_dsl": "def:load",
+		"type_hints": map[string]any{"path": " "},
+	}))
+	assertError(t, err, "Empty type hint")
+}
+
+func TestDocstringTools_FormatResponseCounts(t *testing.T) {
+	server := newMockServer()
+	docstrings := NewSetDocstringTool(server).formatResponse(core.TransformResult{
+		Confidence: core.ConfidenceScore{Score: 1},
+		Metadata:   map[string]any{"docstrings_replaced": 1, "docstrings_added": 2, "docstrings_skipped": 0},
+	}, "repo.py")
+	for _, want := range []string{"📄 File: repo.py", "Docstrings replaced: 1", "Docstrings added: 2"} {
+		if !strings.Contains(docstrings, want) {
+			t.Fatalf("response missing %q:\n%s", want, docstrings)
+		}
+	}
+
+	hints := NewAnnotateTool(server).formatResponse(core.TransformResult{
+		Confidence: core.ConfidenceScore{Score: 1},
+		Metadata:   map[string]any{"hints_added": 3, "hints_replaced": 1, "hints_skipped": 2},
+	}, "")
+	for _, want := range []string{"Hints added: 3", "Hints replaced: 1", "Unchanged: 2"} {
+		if !strings.Contains(hints, want) {
+			t.Fatalf("response missing %q:\n%s", want, hints)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+)
+
+// tagActions maps edit_tags actions to transform methods
+var tagActions = map[string]string{
+	"add":     "add_tag",
+	"remove":  "remove_tag",
+	"rewrite": "rewrite_tag",
+}
+
+// EditTagsTool adds, removes, and rewrites struct tag keys
+type EditTagsTool struct {
+	*BaseTool
+	server types.ServerInterface
+}
+
+// NewEditTagsTool creates a new edit tags tool
+func NewEditTagsTool(server types.ServerInterface) *EditTagsTool {
+	tool := &EditTagsTool{
+		server: server,
+	}
+
+	tool.BaseTool = &BaseTool{
+		name:        "edit_tags",
+		description: "Add, remove, or rewrite struct tag keys on matched fields, keeping the other keys in order and the tag column aligned. Values can be generated from ${name} with case transforms; a rewrite also sees the old value as ${value}, so json → yaml:\"${value}\" migrates a tag. Select fields by tag with attributes such as field:* tag.json=*.",
+		inputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"language":   CommonSchemas.Language,
+				"source":     CommonSchemas.Source,
+				"path":       CommonSchemas.Path,
+				"target":     CommonSchemas.Target,
+				"target_dsl": CommonSchemas.TargetDSL,
+				"action": map[string]any{
+					"type":        "string",
+					"enum":        []string{"add", "remove", "rewrite"},
+					"description": "add sets keys, remove drops keys matching a pattern, rewrite replaces one key",
+				},
+				"annotation": map[string]any{"type": "string", "description": "Tag template for add and rewrite, such as json:\"${name|snake}\", or the key pattern for remove"},
+				"tag_key":    map[string]any{"type": "string", "description": "Key to rewrite, such as json"},
+				"if_absent":  CommonSchemas.IfAbsent,
+				"format":     CommonSchemas.Format,
+			},
+			"required": []string{"language", "action", "annotation"},
+			"oneOf": []map[string]any{
+				{"required": []string{"source"}},
+				{"required": []string{"path"}},
+			},
+		},
+		handler: tool.handle,
+	}
+
+	return tool
+}
+
+// handle executes the edit tags tool
+func (t *EditTagsTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var args struct {
+		Language   string          `json:"language"`
+		Source     string          `json:"source"`
+		Path       string          `json:"path"`
+		Target     json.RawMessage `json:"target"`
+		TargetDSL  string          `json:"target_dsl,omitempty"`
+		Action     string          `json:"action"`
+		Annotation string          `json:"annotation"`
+		TagKey     string          `json:"tag_key,omitempty"`
+		IfAbsent   bool            `json:"if_absent,omitempty"`
+		Format     bool            `json:"format,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid edit_tags parameters", err)
+	}
+
+	// Validate that exactly one of source or path is provided
+	if (args.Source == "" && args.Path == "") || (args.Source != "" && args.Path != "") {
+		return nil, types.NewMCPError(types.InvalidParams, "Exactly one of 'source' or 'path' must be provided", nil)
+	}
+
+	method, ok := tagActions[args.Action]
+	if !ok {
+		return nil, types.NewMCPError(types.InvalidParams, "Invalid action", map[string]any{
+			"action": args.Action,
+			"valid":  []string{"add", "remove", "rewrite"},
+		})
+	}
+	if strings.TrimSpace(args.Annotation) == "" {
+		return nil, types.NewMCPError(types.InvalidParams, "annotation is required", nil)
+	}
+	if method == "rewrite_tag" && strings.TrimSpace(args.TagKey) == "" {
+		return nil, types.NewMCPError(types.InvalidParams, "tag_key is required for rewrite", nil)
+	}
+
+	notifyProgress(ctx, t.server, 5, 100, "validating")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get source code
+	var source string
+	if args.Path != "" {
+		content, err := os.ReadFile(args.Path)
+		if err != nil {
+			return nil, types.WrapError(types.FileSystemError, "Failed to read file", err)
+		}
+		source = string(content)
+		notifyProgress(ctx, t.server, 15, 100, "loaded file")
+	} else {
+		source = args.Source
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get provider
+	provider, exists := t.server.GetProviders().Get(args.Language)
+	if !exists {
+		return nil, types.NewMCPError(types.LanguageNotFound, "Language not supported", nil)
+	}
+	notifyProgress(ctx, t.server, 25, 100, "resolved provider")
+
+	// Parse target
+	target, err := parseRequiredQuery(args.Target, args.TargetDSL, "target")
+	if err != nil {
+		return nil, err
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Execute transformation
+	op := core.TransformOp{
+		Method:     method,
+		Target:     target,
+		Annotation: args.Annotation,
+		TagKey:     args.TagKey,
+		IfAbsent:   args.IfAbsent,
+		Format:     args.Format,
+	}
+
+	result := provider.Transform(source, op)
+	if result.Error != nil {
+		return nil, types.WrapError(types.TransformFailed, "Edit tags operation failed", result.Error)
+	}
+	notifyProgress(ctx, t.server, 70, 100, "transformed source")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	notifyProgress(ctx, t.server, 90, 100, "finalizing")
+
+	return t.server.FinalizeTransform(ctx, types.TransformRequest{
+		Language:       args.Language,
+		Operation:      method,
+		Target:         target,
+		TargetJSON:     args.Target,
+		Path:           args.Path,
+		OriginalSource: source,
+		Result:         result,
+		ResponseText:   t.formatResponse(result, args.Path),
+		Op:             &op,
+	})
+}
+
+// formatResponse formats the edit tags result
+func (t *EditTagsTool) formatResponse(result core.TransformResult, path string) string {
+	if result.Error != nil {
+		return "Edit tags operation failed: " + result.Error.Error()
+	}
+
+	response := "✅ Edit tags operation completed successfully\n\n"
+
+	if path != "" {
+		response += "📄 File: " + path + "\n\n"
+	}
+
+	for _, field := range []struct{ key, label string }{
+		{"annotations_added", "Fields tagged"},
+		{"annotations_removed", "Fields untagged"},
+		{"tags_rewritten", "Tags rewritten"},
+		{"annotations_skipped", "Unchanged"},
+		{"tags_skipped", "Unchanged"},
+	} {
+		if count, ok := result.Metadata[field.key].(int); ok {
+			response += fmt.Sprintf("%s: %d\n", field.label, count)
+		}
+	}
+	if skipped, ok := result.Metadata["fields_skipped"].([]string); ok {
+		response += "Skipped:\n"
+		for _, reason := range skipped {
+			response += "  - " + reason + "\n"
+		}
+	}
+
+	response += fmt.Sprintf("\nConfidence: %.1f%%", result.Confidence.Score*100)
+
+	return response
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestEditTagsTool_Execute(t *testing.T) {
+	server := newMockServer()
+	tool := NewEditTagsTool(server)
+
+	result, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "go",
+		"source":     "package main\n\ntype User struct {\n\tName string `json:\"name\"`\n}\n",
+		"target_dsl": "field:* tag.json=*",
+		"action":     "rewrite",
+		"tag_key":    "json",
+		"annotation": `yaml:"${value}"`,
+	}))
+	assertNoError(t, err)
+	if !hasContentArray(result) {
+		t.Fatalf("expected content array, got %#v", result)
+	}
+}
+
+func TestEditTagsTool_ValidatesParams(t *testing.T) {
+	tool := NewEditTagsTool(newMockServer())
+	base := map[string]any{
+		"language":   "go",
+		"source":     "package main\n",
+		"target_dsl": "field:*",
+		"annotation": `db:"${name|snake}"`,
+	}
+	with := func(extra map[string]any) map[string]any {
+		params := make(map[string]any, len(base)+len(extra))
+		for k, v := range base {
+			params[k] = v
+		}
+		for k, v := range extra {
+			params[k] = v
+		}
+		return params
+	}
+
+	_, err := tool.handle(context.Background(), createTestParams(with(map[string]any{"action": "rename"})))
+	assertError(t, err, "Invalid action")
+
+	_, err = tool.handle(context.Background(), createTestParams(with(map[string]any{"action": "rewrite"})))
+	assertError(t, err, "tag_key")
+
+	_, err = tool.handle(context.Background(), createTestParams(with(map[string]any{"action": "add", "annotation": " "})))
+	assertError(t, err, "annotation")
+}
+
+func TestEditTagsTool_FormatResponseCounts(t *testing.T) {
+	response := NewEditTagsTool(newMockServer()).formatResponse(core.TransformResult{
+		Confidence: core.ConfidenceScore{Score: 1},
+		Metadata:   map[string]any{"tags_rewritten": 2, "tags_skipped": 1},
+	}, "user.go")
+	for _, want := range []string{"📄 File: user.go", "Tags rewritten: 2", "Unchanged: 1"} {
+		if !strings.Contains(response, want) {
+			t.Fatalf("response missing %q:\n%s", want, response)
+		}
+	}
+}
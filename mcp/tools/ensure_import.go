@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+)
+
+// EnsureImportTool adds an import unless it is already present
+type EnsureImportTool struct {
+	*BaseTool
+	server types.ServerInterface
+}
+
+// NewEnsureImportTool creates a new ensure import tool
+func NewEnsureImportTool(server types.ServerInterface) *EnsureImportTool {
+	tool := &EnsureImportTool{
+		server: server,
+	}
+
+	tool.BaseTool = &BaseTool{
+		name:        "ensure_import",
+		description: "Add an import written in the language's own syntax, such as \"log/slog\", from typing import Any, import { x } from 'y', or use App\\Models\\User. Existing imports are merged into and never duplicated.",
+		inputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"language": CommonSchemas.Language,
+				"source":   CommonSchemas.Source,
+				"path":     CommonSchemas.Path,
+				"import": map[string]any{
+					"type":        "string",
+					"description": "Import to add",
+				},
+				"format": CommonSchemas.Format,
+			},
+			"required": []string{"language", "import"},
+			"oneOf": []map[string]any{
+				{"required": []string{"source"}},
+				{"required": []string{"path"}},
+			},
+		},
+		handler: tool.handle,
+	}
+
+	return tool
+}
+
+// handle executes the ensure import tool
+func (t *EnsureImportTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var args struct {
+		Language string `json:"language"`
+		Source   string `json:"source"`
+		Path     string `json:"path"`
+		Import   string `json:"import"`
+		Format   bool   `json:"format,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid ensure_import parameters", err)
+	}
+
+	// Validate that exactly one of source or path is provided
+	if (args.Source == "" && args.Path == "") || (args.Source != "" && args.Path != "") {
+		return nil, types.NewMCPError(types.InvalidParams, "Exactly one of 'source' or 'path' must be provided", nil)
+	}
+	if args.Import == "" {
+		return nil, types.NewMCPError(types.InvalidParams, "Missing required 'import'", nil)
+	}
+
+	notifyProgress(ctx, t.server, 5, 100, "validating")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get source code
+	var source string
+	if args.Path != "" {
+		content, err := os.ReadFile(args.Path)
+		if err != nil {
+			return nil, types.WrapError(types.FileSystemError, "Failed to read file", err)
+		}
+		source = string(content)
+		notifyProgress(ctx, t.server, 15, 100, "loaded file")
+	} else {
+		source = args.Source
+	}
+
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get provider
+	provider, exists := t.server.GetProviders().Get(args.Language)
+	if !exists {
+		return nil, types.NewMCPError(types.LanguageNotFound, "Language not supported", nil)
+	}
+
+	notifyProgress(ctx, t.server, 25, 100, "resolved provider")
+
+	// Execute transformation
+	op := core.TransformOp{
+		Method:  "ensure_import",
+		Content: args.Import,
+		Format:  args.Format,
+	}
+
+	result := provider.Transform(source, op)
+	if result.Error != nil {
+		return nil, types.WrapError(types.TransformFailed, "Ensure import operation failed", result.Error)
+	}
+
+	notifyProgress(ctx, t.server, 70, 100, "transformed source")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	notifyProgress(ctx, t.server, 90, 100, "finalizing")
+
+	return t.server.FinalizeTransform(ctx, types.TransformRequest{
+		Language:       args.Language,
+		Operation:      "ensure_import",
+		Path:           args.Path,
+		OriginalSource: source,
+		Result:         result,
+		ResponseText:   t.formatResponse(result, args.Import, args.Path),
+		Op:             &op,
+	})
+}
+
+// formatResponse formats the ensure import result
+func (t *EnsureImportTool) formatResponse(result core.TransformResult, spec, path string) string {
+	response := "✅ Ensure import operation completed successfully\n\n"
+
+	if path != "" {
+		response += "📄 File: " + path + "\n\n"
+	}
+
+	if result.MatchCount == 0 {
+		response += "Import already present: " + spec + "\n"
+	} else {
+		response += "Added import: " + spec + "\n"
+	}
+
+	if result.Diff != "" {
+		response += "\nChanges:\n" + result.Diff + "\n"
+	}
+
+	response += "\nConfidence: " + formatConfidence(result.Confidence.Score)
+
+	return response
+}
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+)
+
+// ExtractFunctionTool moves a statement range into a new function
+type ExtractFunctionTool struct {
+	*BaseTool
+	server types.ServerInterface
+}
+
+// NewExtractFunctionTool creates a new extract function tool
+func NewExtractFunctionTool(server types.ServerInterface) *ExtractFunctionTool {
+	tool := &ExtractFunctionTool{
+		server: server,
+	}
+
+	tool.BaseTool = &BaseTool{
+		name:        "extract_function",
+		description: "Extract statements into a new function. Select the enclosing function with target_dsl plus lines=a..b, or target a single statement. Parameters and results are inferred from local variables.",
+		inputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"language":         CommonSchemas.Language,
+				"source":           CommonSchemas.Source,
+				"path":             CommonSchemas.Path,
+				"target":           CommonSchemas.Target,
+				"target_dsl":       CommonSchemas.TargetDSL,
+				"organize_imports": CommonSchemas.OrganizeImports,
+				"format":           CommonSchemas.Format,
+				"function_name": map[string]any{
+					"type":        "string",
+					"description": "Name of the new function (defaults to extracted)",
+				},
+			},
+			"required": []string{"language"},
+			"oneOf": []map[string]any{
+				{"required": []string{"source"}},
+				{"required": []string{"path"}},
+			},
+		},
+		handler: tool.handle,
+	}
+
+	return tool
+}
+
+// handle executes the extract function tool
+func (t *ExtractFunctionTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var args struct {
+		Language        string          `json:"language"`
+		Source          string          `json:"source"`
+		Path            string          `json:"path"`
+		Target          json.RawMessage `json:"target"`
+		TargetDSL       string          `json:"target_dsl,omitempty"`
+		FunctionName    string          `json:"function_name,omitempty"`
+		OrganizeImports bool            `json:"organize_imports,omitempty"`
+		Format          bool            `json:"format,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid extract_function parameters", err)
+	}
+
+	// Validate that exactly one of source or path is provided
+	if (args.Source == "" && args.Path == "") || (args.Source != "" && args.Path != "") {
+		return nil, types.NewMCPError(types.InvalidParams, "Exactly one of 'source' or 'path' must be provided", nil)
+	}
+
+	notifyProgress(ctx, t.server, 5, 100, "validating")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get source code
+	var source string
+	if args.Path != "" {
+		content, err := os.ReadFile(args.Path)
+		if err != nil {
+			return nil, types.WrapError(types.FileSystemError, "Failed to read file", err)
+		}
+		source = string(content)
+		notifyProgress(ctx, t.server, 15, 100, "loaded file")
+	} else {
+		source = args.Source
+	}
+
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get provider
+	provider, exists := t.server.GetProviders().Get(args.Language)
+	if !exists {
+		return nil, types.NewMCPError(types.LanguageNotFound, "Language not supported", nil)
+	}
+
+	notifyProgress(ctx, t.server, 25, 100, "resolved provider")
+
+	// Parse target
+	target, err := parseRequiredQuery(args.Target, args.TargetDSL, "target")
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute transformation
+	op := core.TransformOp{
+		Method:          "extract_function",
+		Target:          target,
+		FunctionName:    args.FunctionName,
+		OrganizeImports: args.OrganizeImports,
+		Format:          args.Format,
+	}
+
+	result := provider.Transform(source, op)
+	if result.Error != nil {
+		return nil, types.WrapError(types.TransformFailed, "Extract function operation failed", result.Error)
+	}
+
+	notifyProgress(ctx, t.server, 70, 100, "transformed source")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	notifyProgress(ctx, t.server, 90, 100, "finalizing")
+
+	return t.server.FinalizeTransform(ctx, types.TransformRequest{
+		Language:       args.Language,
+		Operation:      "extract_function",
+		Target:         target,
+		TargetJSON:     args.Target,
+		Path:           args.Path,
+		OriginalSource: source,
+		Result:         result,
+		ResponseText:   t.formatResponse(result, args.Path),
+		Op:             &op,
+	})
+}
+
+// formatResponse formats the extraction result
+func (t *ExtractFunctionTool) formatResponse(result core.TransformResult, path string) string {
+	response := "✅ Extract function operation completed successfully\n\n"
+
+	if path != "" {
+		response += "📄 File: " + path + "\n\n"
+	}
+
+	if name, ok := result.Metadata["function"].(string); ok {
+		response += "Function: " + name + "\n"
+	}
+	if params, ok := result.Metadata["parameters"].([]string); ok {
+		response += fmt.Sprintf("Parameters: %s\n", strings.Join(params, ", "))
+	}
+	if results, ok := result.Metadata["results"].([]string); ok {
+		response += fmt.Sprintf("Results: %s\n", strings.Join(results, ", "))
+	}
+
+	if result.Diff != "" {
+		response += "\nChanges:\n" + result.Diff + "\n"
+	}
+
+	response += "\nConfidence: " + formatConfidence(result.Confidence.Score)
+
+	return response
+}
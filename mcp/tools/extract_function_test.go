@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestExtractFunctionTool_Execute(t *testing.T) {
+	server := newMockServer()
+	tool := NewExtractFunctionTool(server)
+
+	params := createTestParams(map[string]any{
+		"language":      "go",
+		"source":        "package main\n\nfunc main() {\n\tx := 1\n\tprintln(x)\n}\n",
+		"target_dsl":    "func:main lines=4",
+		"function_name": "setup",
+	})
+	result, err := tool.handle(context.Background(), params)
+	assertNoError(t, err)
+	if !hasContentArray(result) {
+		t.Fatalf("expected content array, got %#v", result)
+	}
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "go",
+		"target_dsl": "func:main lines=4",
+	}))
+	assertError(t, err, "source")
+}
+
+func TestExtractFunctionTool_Schema(t *testing.T) {
+	tool := NewExtractFunctionTool(newMockServer())
+	if tool.Name() != "extract_function" {
+		t.Fatalf("expected name extract_function, got %s", tool.Name())
+	}
+	schema := tool.InputSchema()
+	properties := schema["properties"].(map[string]any)
+	for _, field := range []string{"target_dsl", "function_name"} {
+		if _, ok := properties[field]; !ok {
+			t.Fatalf("schema missing %s", field)
+		}
+	}
+	if required := schema["required"].([]string); !slices.Contains(required, "language") {
+		t.Fatalf("language should be required, got %v", required)
+	}
+}
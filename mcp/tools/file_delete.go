@@ -8,6 +8,7 @@ import (
 
 	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/mcp/types"
+	"github.com/oxhq/morfx/models"
 )
 
 // FileDeleteTool handles deletion across multiple files
@@ -44,8 +45,15 @@ func NewFileDeleteTool(server types.ServerInterface) *FileDeleteTool {
 					},
 					"required": []string{"path"},
 				},
-				"target":     CommonSchemas.Target,
-				"target_dsl": CommonSchemas.TargetDSL,
+				"target":           CommonSchemas.Target,
+				"target_dsl":       CommonSchemas.TargetDSL,
+				"organize_imports": CommonSchemas.OrganizeImports,
+				"format":           CommonSchemas.Format,
+				"overlap":          CommonSchemas.Overlap,
+				"attached":         CommonSchemas.Attached,
+				"structural_diff":  CommonSchemas.StructuralDiff,
+				"expect_matches":   CommonSchemas.ExpectMatches,
+				"stage":            CommonSchemas.Stage,
 				"dry_run": map[string]any{
 					"type":        "boolean",
 					"description": "Preview changes without applying",
@@ -69,16 +77,33 @@ func (t *FileDeleteTool) handle(ctx context.Context, params json.RawMessage) (an
 		ctx = context.Background()
 	}
 	var args struct {
-		Scope     core.FileScope  `json:"scope"`
-		Target    json.RawMessage `json:"target"`
-		TargetDSL string          `json:"target_dsl,omitempty"`
-		DryRun    bool            `json:"dry_run"`
-		Backup    bool            `json:"backup"`
+		Scope           core.FileScope  `json:"scope"`
+		Target          json.RawMessage `json:"target"`
+		TargetDSL       string          `json:"target_dsl,omitempty"`
+		DryRun          bool            `json:"dry_run"`
+		Backup          bool            `json:"backup"`
+		OrganizeImports bool            `json:"organize_imports,omitempty"`
+		Format          bool            `json:"format,omitempty"`
+		Overlap         string          `json:"overlap,omitempty"`
+		Attached        string          `json:"attached,omitempty"`
+		StructuralDiff  bool            `json:"structural_diff,omitempty"`
+		ExpectMatches   core.MatchRange `json:"expect_matches,omitempty"`
+		Stage           bool            `json:"stage,omitempty"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
 		return nil, types.WrapError(types.InvalidParams, "Invalid file delete parameters", err)
 	}
+	if err := args.ExpectMatches.Validate(); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid expect_matches", err)
+	}
+	var stager types.StagingChangesets
+	if args.Stage && !args.DryRun {
+		var err error
+		if stager, err = changesetStager(t.server); err != nil {
+			return nil, err
+		}
+	}
 	notifyProgress(ctx, t.server, 5, 100, "validating")
 	if err := isCancelled(ctx); err != nil {
 		return nil, err
@@ -97,12 +122,18 @@ func (t *FileDeleteTool) handle(ctx context.Context, params json.RawMessage) (an
 	// Create transform operation
 	fileOp := core.FileTransformOp{
 		TransformOp: core.TransformOp{
-			Method: "delete",
-			Target: target,
+			Method:          "delete",
+			Target:          target,
+			OrganizeImports: args.OrganizeImports,
+			Format:          args.Format,
+			Overlap:         args.Overlap,
+			Attached:        args.Attached,
+			StructuralDiff:  args.StructuralDiff,
+			ExpectMatches:   args.ExpectMatches,
 		},
 		Scope:    args.Scope,
-		DryRun:   args.DryRun,
-		Backup:   args.Backup,
+		DryRun:   args.DryRun || stager != nil,
+		Backup:   args.Backup && stager == nil,
 		Parallel: true,
 	}
 	notifyProgress(ctx, t.server, 35, 100, "prepared operation")
@@ -124,30 +155,46 @@ func (t *FileDeleteTool) handle(ctx context.Context, params json.RawMessage) (an
 		return nil, err
 	}
 
+	var changeset *models.Changeset
+	if stager != nil {
+		description := fmt.Sprintf("file_delete over %s", args.Scope.Path)
+		if changeset, err = stageChangeset(ctx, t.server, stager, "file_delete", description, core.FileChanges(result)); err != nil {
+			return nil, err
+		}
+	}
+
 	// Format response
-	return map[string]any{
+	response := map[string]any{
 		"content": []map[string]any{
 			{
 				"type": "text",
-				"text": t.formatResponse(result, args.DryRun),
+				"text": t.formatResponse(result, args.DryRun, changeset),
 			},
 		},
 		"files_processed": result.FilesScanned,
 		"files_modified":  result.FilesModified,
 		"dry_run":         args.DryRun,
-	}, nil
+	}
+	if changeset != nil {
+		response["changeset"] = changeset.ID
+	}
+	return response, nil
 }
 
 // formatResponse formats the file delete results
-func (t *FileDeleteTool) formatResponse(result *core.FileTransformResult, dryRun bool) string {
+func (t *FileDeleteTool) formatResponse(result *core.FileTransformResult, dryRun bool, changeset *models.Changeset) string {
 	mode := ""
 	if dryRun {
 		mode = " [DRY RUN]"
+	} else if changeset != nil {
+		mode = " [STAGED]"
 	}
+	// A staged run writes nothing either
+	preview := dryRun || changeset != nil
 
 	response := fmt.Sprintf("✅ File delete operation completed%s\n\n", mode)
 	response += fmt.Sprintf("Files scanned: %d\n", result.FilesScanned)
-	if dryRun {
+	if preview {
 		response += fmt.Sprintf("Files that would be modified: %d\n", result.FilesModified)
 	} else {
 		response += fmt.Sprintf("Files modified: %d\n", result.FilesModified)
@@ -155,7 +202,7 @@ func (t *FileDeleteTool) formatResponse(result *core.FileTransformResult, dryRun
 	response += fmt.Sprintf("Total deletions: %d\n", result.TotalMatches)
 
 	if len(result.Files) > 0 {
-		if dryRun {
+		if preview {
 			response += "\nAffected files:\n"
 		} else {
 			response += "\nModified files:\n"
@@ -174,6 +221,8 @@ func (t *FileDeleteTool) formatResponse(result *core.FileTransformResult, dryRun
 		}
 	}
 
+	response += formatChangeset(changeset)
+
 	if dryRun {
 		response += "\n⚠️  This was a dry run. No files were actually modified."
 	}
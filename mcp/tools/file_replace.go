@@ -8,6 +8,7 @@ import (
 
 	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/mcp/types"
+	"github.com/oxhq/morfx/models"
 )
 
 // FileReplaceTool handles replacement across multiple files
@@ -49,9 +50,17 @@ func NewFileReplaceTool(server types.ServerInterface) *FileReplaceTool {
 					},
 					"required": []string{"path"},
 				},
-				"target":      CommonSchemas.Target,
-				"target_dsl":  CommonSchemas.TargetDSL,
-				"replacement": CommonSchemas.Replacement,
+				"target":           CommonSchemas.Target,
+				"target_dsl":       CommonSchemas.TargetDSL,
+				"organize_imports": CommonSchemas.OrganizeImports,
+				"format":           CommonSchemas.Format,
+				"overlap":          CommonSchemas.Overlap,
+				"attached":         CommonSchemas.Attached,
+				"structural_diff":  CommonSchemas.StructuralDiff,
+				"expect_matches":   CommonSchemas.ExpectMatches,
+				"stage":            CommonSchemas.Stage,
+				"replacement":      CommonSchemas.Replacement,
+				"verify":           CommonSchemas.Verify,
 				"dry_run": map[string]any{
 					"type":        "boolean",
 					"description": "Preview changes without applying",
@@ -75,17 +84,46 @@ func (t *FileReplaceTool) handle(ctx context.Context, params json.RawMessage) (a
 		ctx = context.Background()
 	}
 	var args struct {
-		Scope       core.FileScope  `json:"scope"`
-		Target      json.RawMessage `json:"target"`
-		TargetDSL   string          `json:"target_dsl,omitempty"`
-		Replacement string          `json:"replacement"`
-		DryRun      bool            `json:"dry_run"`
-		Backup      bool            `json:"backup"`
+		Scope           core.FileScope      `json:"scope"`
+		Target          json.RawMessage     `json:"target"`
+		TargetDSL       string              `json:"target_dsl,omitempty"`
+		Replacement     string              `json:"replacement"`
+		DryRun          bool                `json:"dry_run"`
+		Backup          bool                `json:"backup"`
+		OrganizeImports bool                `json:"organize_imports,omitempty"`
+		Format          bool                `json:"format,omitempty"`
+		Overlap         string              `json:"overlap,omitempty"`
+		Attached        string              `json:"attached,omitempty"`
+		StructuralDiff  bool                `json:"structural_diff,omitempty"`
+		ExpectMatches   core.MatchRange     `json:"expect_matches,omitempty"`
+		Stage           bool                `json:"stage,omitempty"`
+		Verify          *core.VerifyCommand `json:"verify,omitempty"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
 		return nil, types.WrapError(types.InvalidParams, "Invalid file replace parameters", err)
 	}
+	if err := args.ExpectMatches.Validate(); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid expect_matches", err)
+	}
+	if err := args.Verify.Validate(); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid verify", err)
+	}
+	if err := t.server.CheckVerify(args.Verify); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Verify not allowed", err)
+	}
+	if args.Stage && args.Verify != nil {
+		return nil, types.NewMCPError(types.InvalidParams,
+			"verify cannot be combined with stage: pass verify to apply with the changeset",
+			nil)
+	}
+	var stager types.StagingChangesets
+	if args.Stage && !args.DryRun {
+		var err error
+		if stager, err = changesetStager(t.server); err != nil {
+			return nil, err
+		}
+	}
 	notifyProgress(ctx, t.server, 5, 100, "validating")
 	if err := isCancelled(ctx); err != nil {
 		return nil, err
@@ -104,14 +142,21 @@ func (t *FileReplaceTool) handle(ctx context.Context, params json.RawMessage) (a
 	// Create transform operation
 	fileOp := core.FileTransformOp{
 		TransformOp: core.TransformOp{
-			Method:      "replace",
-			Target:      target,
-			Replacement: args.Replacement,
+			Method:          "replace",
+			Target:          target,
+			Replacement:     args.Replacement,
+			OrganizeImports: args.OrganizeImports,
+			Format:          args.Format,
+			Overlap:         args.Overlap,
+			Attached:        args.Attached,
+			StructuralDiff:  args.StructuralDiff,
+			ExpectMatches:   args.ExpectMatches,
 		},
 		Scope:    args.Scope,
-		DryRun:   args.DryRun,
-		Backup:   args.Backup,
+		DryRun:   args.DryRun || stager != nil,
+		Backup:   args.Backup && stager == nil,
 		Parallel: true,
+		Verify:   args.Verify,
 	}
 	notifyProgress(ctx, t.server, 35, 100, "prepared operation")
 	if err := isCancelled(ctx); err != nil {
@@ -119,7 +164,7 @@ func (t *FileReplaceTool) handle(ctx context.Context, params json.RawMessage) (a
 	}
 
 	// Execute with timeout
-	opCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	opCtx, cancel := context.WithTimeout(ctx, verifyTimeout(60*time.Second, args.Verify))
 	defer cancel()
 
 	fileProcessor := t.server.GetFileProcessor()
@@ -132,30 +177,50 @@ func (t *FileReplaceTool) handle(ctx context.Context, params json.RawMessage) (a
 		return nil, err
 	}
 
+	var changeset *models.Changeset
+	if stager != nil {
+		description := fmt.Sprintf("file_replace over %s", args.Scope.Path)
+		if changeset, err = stageChangeset(ctx, t.server, stager, "file_replace", description, core.FileChanges(result)); err != nil {
+			return nil, err
+		}
+	}
+
 	// Format response
-	return map[string]any{
+	response := map[string]any{
 		"content": []map[string]any{
 			{
 				"type": "text",
-				"text": t.formatResponse(result, args.DryRun),
+				"text": t.formatResponse(result, args.DryRun, changeset),
 			},
 		},
 		"files_processed": result.FilesScanned,
 		"files_modified":  result.FilesModified,
 		"dry_run":         args.DryRun,
-	}, nil
+	}
+	if changeset != nil {
+		response["changeset"] = changeset.ID
+	}
+	if result.Verification != nil {
+		response["verification"] = result.Verification
+		response["isError"] = !result.Verification.Passed
+	}
+	return response, nil
 }
 
 // formatResponse formats the file replace results
-func (t *FileReplaceTool) formatResponse(result *core.FileTransformResult, dryRun bool) string {
+func (t *FileReplaceTool) formatResponse(result *core.FileTransformResult, dryRun bool, changeset *models.Changeset) string {
 	mode := ""
 	if dryRun {
 		mode = " [DRY RUN]"
+	} else if changeset != nil {
+		mode = " [STAGED]"
 	}
+	// A staged run writes nothing either
+	preview := dryRun || changeset != nil
 
 	response := fmt.Sprintf("✅ File replace operation completed%s\n\n", mode)
 	response += fmt.Sprintf("Files scanned: %d\n", result.FilesScanned)
-	if dryRun {
+	if preview {
 		response += fmt.Sprintf("Files that would be modified: %d\n", result.FilesModified)
 	} else {
 		response += fmt.Sprintf("Files modified: %d\n", result.FilesModified)
@@ -163,7 +228,7 @@ func (t *FileReplaceTool) formatResponse(result *core.FileTransformResult, dryRu
 	response += fmt.Sprintf("Total matches: %d\n", result.TotalMatches)
 
 	if len(result.Files) > 0 {
-		if dryRun {
+		if preview {
 			response += "\nAffected files:\n"
 		} else {
 			response += "\nModified files:\n"
@@ -182,6 +247,10 @@ func (t *FileReplaceTool) formatResponse(result *core.FileTransformResult, dryRu
 		}
 	}
 
+	response += formatVerification(result.Verification)
+
+	response += formatChangeset(changeset)
+
 	if dryRun {
 		response += "\n⚠️  This was a dry run. No files were actually modified."
 	}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnsureImportTool_Execute(t *testing.T) {
+	server := newMockServer()
+	tool := NewEnsureImportTool(server)
+
+	result, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"language": "go",
+		"source":   "package main\n\nfunc main() {}\n",
+		"import":   `"fmt"`,
+	}))
+	assertNoError(t, err)
+	if !hasContentArray(result) {
+		t.Fatalf("expected content array, got %#v", result)
+	}
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"language": "go",
+		"source":   "package main\n",
+	}))
+	assertError(t, err, "import")
+}
+
+func TestRemoveImportTool_Execute(t *testing.T) {
+	server := newMockServer()
+	tool := NewRemoveImportTool(server)
+
+	result, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "go",
+		"source":     "package main\n\nimport \"fmt\"\n\nfunc main() {}\n",
+		"target_dsl": "import:fmt",
+	}))
+	assertNoError(t, err)
+	if !hasContentArray(result) {
+		t.Fatalf("expected content array, got %#v", result)
+	}
+}
+
+func TestOrganizeImportsSchema(t *testing.T) {
+	server := newMockServer()
+	for _, tool := range []interface{ InputSchema() map[string]any }{
+		NewReplaceTool(server), NewDeleteTool(server), NewInsertBeforeTool(server),
+		NewInsertAfterTool(server), NewAppendTool(server), NewExtractFunctionTool(server),
+		NewFileReplaceTool(server), NewFileDeleteTool(server),
+	} {
+		properties := tool.InputSchema()["properties"].(map[string]any)
+		if _, ok := properties["organize_imports"]; !ok {
+			t.Fatalf("schema %v missing organize_imports", properties)
+		}
+	}
+}
@@ -28,11 +28,14 @@ func NewInsertAfterTool(server types.ServerInterface) *InsertAfterTool {
 		inputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"language":   CommonSchemas.Language,
-				"source":     CommonSchemas.Source,
-				"path":       CommonSchemas.Path,
-				"target":     CommonSchemas.Target,
-				"target_dsl": CommonSchemas.TargetDSL,
+				"language":         CommonSchemas.Language,
+				"source":           CommonSchemas.Source,
+				"path":             CommonSchemas.Path,
+				"target":           CommonSchemas.Target,
+				"target_dsl":       CommonSchemas.TargetDSL,
+				"organize_imports": CommonSchemas.OrganizeImports,
+				"format":           CommonSchemas.Format,
+				"if_absent":        CommonSchemas.IfAbsent,
 				"content": map[string]any{
 					"type":        "string",
 					"description": "Code to insert",
@@ -56,12 +59,15 @@ func (t *InsertAfterTool) handle(ctx context.Context, params json.RawMessage) (a
 		ctx = context.Background()
 	}
 	var args struct {
-		Language  string          `json:"language"`
-		Source    string          `json:"source"`
-		Path      string          `json:"path"`
-		Target    json.RawMessage `json:"target"`
-		TargetDSL string          `json:"target_dsl,omitempty"`
-		Content   string          `json:"content"`
+		Language        string          `json:"language"`
+		Source          string          `json:"source"`
+		Path            string          `json:"path"`
+		Target          json.RawMessage `json:"target"`
+		TargetDSL       string          `json:"target_dsl,omitempty"`
+		Content         string          `json:"content"`
+		OrganizeImports bool            `json:"organize_imports,omitempty"`
+		Format          bool            `json:"format,omitempty"`
+		IfAbsent        bool            `json:"if_absent,omitempty"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
@@ -111,9 +117,12 @@ func (t *InsertAfterTool) handle(ctx context.Context, params json.RawMessage) (a
 
 	// Execute transformation
 	op := core.TransformOp{
-		Method:  "insert_after",
-		Target:  target,
-		Content: args.Content,
+		Method:          "insert_after",
+		Target:          target,
+		Content:         args.Content,
+		OrganizeImports: args.OrganizeImports,
+		Format:          args.Format,
+		IfAbsent:        args.IfAbsent,
 	}
 
 	result := provider.Transform(source, op)
@@ -137,6 +146,7 @@ func (t *InsertAfterTool) handle(ctx context.Context, params json.RawMessage) (a
 		Result:         result,
 		ResponseText:   t.formatResponse(result, args.Path),
 		Content:        args.Content,
+		Op:             &op,
 	})
 }
 
@@ -156,6 +166,7 @@ func (t *InsertAfterTool) formatResponse(result core.TransformResult, path strin
 	if result.MatchCount > 0 {
 		response += fmt.Sprintf("  %d locations modified\n", result.MatchCount)
 	}
+	response += formatAlreadyPresent(result)
 
 	response += fmt.Sprintf("\nConfidence: %.1f%%", result.Confidence.Score*100)
 
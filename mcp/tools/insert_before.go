@@ -28,11 +28,14 @@ func NewInsertBeforeTool(server types.ServerInterface) *InsertBeforeTool {
 		inputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"language":   CommonSchemas.Language,
-				"source":     CommonSchemas.Source,
-				"path":       CommonSchemas.Path,
-				"target":     CommonSchemas.Target,
-				"target_dsl": CommonSchemas.TargetDSL,
+				"language":         CommonSchemas.Language,
+				"source":           CommonSchemas.Source,
+				"path":             CommonSchemas.Path,
+				"target":           CommonSchemas.Target,
+				"target_dsl":       CommonSchemas.TargetDSL,
+				"organize_imports": CommonSchemas.OrganizeImports,
+				"format":           CommonSchemas.Format,
+				"if_absent":        CommonSchemas.IfAbsent,
 				"content": map[string]any{
 					"type":        "string",
 					"description": "Code to insert",
@@ -56,12 +59,15 @@ func (t *InsertBeforeTool) handle(ctx context.Context, params json.RawMessage) (
 		ctx = context.Background()
 	}
 	var args struct {
-		Language  string          `json:"language"`
-		Source    string          `json:"source"`
-		Path      string          `json:"path"`
-		Target    json.RawMessage `json:"target"`
-		TargetDSL string          `json:"target_dsl,omitempty"`
-		Content   string          `json:"content"`
+		Language        string          `json:"language"`
+		Source          string          `json:"source"`
+		Path            string          `json:"path"`
+		Target          json.RawMessage `json:"target"`
+		TargetDSL       string          `json:"target_dsl,omitempty"`
+		Content         string          `json:"content"`
+		OrganizeImports bool            `json:"organize_imports,omitempty"`
+		Format          bool            `json:"format,omitempty"`
+		IfAbsent        bool            `json:"if_absent,omitempty"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
@@ -111,9 +117,12 @@ func (t *InsertBeforeTool) handle(ctx context.Context, params json.RawMessage) (
 
 	// Execute transformation
 	op := core.TransformOp{
-		Method:  "insert_before",
-		Target:  target,
-		Content: args.Content,
+		Method:          "insert_before",
+		Target:          target,
+		Content:         args.Content,
+		OrganizeImports: args.OrganizeImports,
+		Format:          args.Format,
+		IfAbsent:        args.IfAbsent,
 	}
 
 	result := provider.Transform(source, op)
@@ -137,6 +146,7 @@ func (t *InsertBeforeTool) handle(ctx context.Context, params json.RawMessage) (
 		Result:         result,
 		ResponseText:   t.formatResponse(result, args.Path),
 		Content:        args.Content,
+		Op:             &op,
 	})
 }
 
@@ -156,8 +166,19 @@ func (t *InsertBeforeTool) formatResponse(result core.TransformResult, path stri
 	if result.MatchCount > 0 {
 		response += fmt.Sprintf("  %d locations modified\n", result.MatchCount)
 	}
+	response += formatAlreadyPresent(result)
 
 	response += fmt.Sprintf("\nConfidence: %.1f%%", result.Confidence.Score*100)
 
 	return response
 }
+
+// formatAlreadyPresent reports targets skipped by if_absent
+func formatAlreadyPresent(result core.TransformResult) string {
+	for _, factor := range result.Confidence.Factors {
+		if factor.Name == "already_present" {
+			return "  " + factor.Reason + "\n"
+		}
+	}
+	return ""
+}
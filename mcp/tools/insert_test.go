@@ -4,8 +4,10 @@ import (
 	"context"
 	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 
+	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/mcp/types"
 )
 
@@ -314,3 +316,25 @@ func TestInsertTools_WithStaging(t *testing.T) {
 		})
 	}
 }
+
+func TestInsertTools_IfAbsent(t *testing.T) {
+	server := newMockServer()
+	for _, tool := range []interface{ InputSchema() map[string]any }{
+		NewInsertBeforeTool(server), NewInsertAfterTool(server), NewAppendTool(server),
+	} {
+		properties := tool.InputSchema()["properties"].(map[string]any)
+		if _, ok := properties["if_absent"]; !ok {
+			t.Fatalf("schema missing if_absent: %v", properties)
+		}
+	}
+
+	text := NewAppendTool(server).formatResponse(core.TransformResult{
+		Confidence: core.ConfidenceScore{Score: 1, Factors: []core.ConfidenceFactor{{
+			Name:   "already_present",
+			Reason: "Equivalent content already present at 1 of 1 targets, nothing inserted",
+		}}},
+	}, "")
+	if !strings.Contains(text, "already present at 1 of 1 targets") {
+		t.Fatalf("expected skipped targets in response:\n%s", text)
+	}
+}
@@ -3,12 +3,14 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/mcp/types"
+	"github.com/oxhq/morfx/models"
 )
 
 // RecipeTool runs named repeatable transformations through the MCP surface.
@@ -42,6 +44,8 @@ func NewRecipeTool(server types.ServerInterface) *RecipeTool {
 					"type":        "number",
 					"description": "Default confidence gate for all steps",
 				},
+				"stage":  CommonSchemas.Stage,
+				"verify": CommonSchemas.Verify,
 				"steps": map[string]any{
 					"type":        "array",
 					"description": "Recipe rules to execute in order",
@@ -56,8 +60,12 @@ func NewRecipeTool(server types.ServerInterface) *RecipeTool {
 							"target_dsl":     CommonSchemas.TargetDSL,
 							"replacement":    CommonSchemas.Replacement,
 							"content":        map[string]any{"type": "string"},
+							"annotation":     map[string]any{"type": "string"},
+							"tag_key":        map[string]any{"type": "string"},
+							"type_hints":     map[string]any{"type": "object"},
 							"min_confidence": map[string]any{"type": "number"},
 							"backup":         map[string]any{"type": "boolean"},
+							"verify":         CommonSchemas.Verify,
 						},
 						"required": []string{"name", "method", "scope"},
 					},
@@ -78,26 +86,54 @@ func (t *RecipeTool) handle(ctx context.Context, params json.RawMessage) (any, e
 	if err := json.Unmarshal(params, &recipe); err != nil {
 		return nil, types.WrapError(types.InvalidParams, "Invalid recipe parameters", err)
 	}
+	verifies := []*core.VerifyCommand{recipe.Verify}
+	for _, step := range recipe.Steps {
+		verifies = append(verifies, step.Verify)
+	}
+	for _, verify := range verifies {
+		if err := t.server.CheckVerify(verify); err != nil {
+			return nil, types.WrapError(types.InvalidParams, "Verify not allowed", err)
+		}
+	}
+
+	var stager types.StagingChangesets
+	if recipe.Stage && !recipe.DryRun {
+		var err error
+		if stager, err = changesetStager(t.server); err != nil {
+			return nil, err
+		}
+	}
 
 	notifyProgress(ctx, t.server, 5, 100, "validating recipe")
 	if err := isCancelled(ctx); err != nil {
 		return nil, err
 	}
 
-	opCtx, cancel := context.WithTimeout(ctx, 120*time.Second)
+	opCtx, cancel := context.WithTimeout(ctx, 120*time.Second+core.RecipeVerifyTimeout(recipe))
 	defer cancel()
 
 	result, err := core.ExecuteRecipe(opCtx, t.server.GetFileProcessor(), recipe)
-	if err != nil {
+	if err != nil && !(errors.Is(err, core.ErrVerificationFailed) && result != nil) {
 		return nil, types.WrapError(types.TransformFailed, "Recipe failed", err)
 	}
 
+	var changeset *models.Changeset
+	if stager != nil {
+		description := "recipe " + recipe.Name
+		if recipe.Description != "" {
+			description += ": " + recipe.Description
+		}
+		if changeset, err = stageChangeset(ctx, t.server, stager, "recipe", description, result.Changes); err != nil {
+			return nil, err
+		}
+	}
+
 	notifyProgress(ctx, t.server, 100, 100, "recipe completed")
 
-	return map[string]any{
+	response := map[string]any{
 		"content": []map[string]any{{
 			"type": "text",
-			"text": formatRecipeToolResponse(result),
+			"text": formatRecipeToolResponse(result) + formatChangeset(changeset),
 		}},
 		"name":            result.Name,
 		"dry_run":         result.DryRun,
@@ -107,7 +143,18 @@ func (t *RecipeTool) handle(ctx context.Context, params json.RawMessage) (any, e
 		"matches":         result.TotalMatches,
 		"transaction_ids": result.TransactionIDs,
 		"steps":           result.Steps,
-	}, nil
+	}
+	if changeset != nil {
+		response["changeset"] = changeset.ID
+	}
+	if result.FailedStep != "" {
+		response["failed_step"] = result.FailedStep
+	}
+	if result.Verification != nil {
+		response["verification"] = result.Verification
+		response["isError"] = true
+	}
+	return response, nil
 }
 
 func formatRecipeToolResponse(result *core.RecipeResult) string {
@@ -120,10 +167,19 @@ func formatRecipeToolResponse(result *core.RecipeResult) string {
 	if result.DryRun {
 		mode = " [DRY RUN]"
 		modifiedLabel = "Files that would be modified"
+	} else if len(result.Changes) > 0 {
+		mode = " [STAGED]"
+		modifiedLabel = "Files that would be modified"
 	}
 
 	var builder strings.Builder
-	fmt.Fprintf(&builder, "Recipe %s completed%s\n\n", result.Name, mode)
+	if result.FailedStep != "" {
+		fmt.Fprintf(&builder, "Recipe %s stopped at step %s\n\n", result.Name, result.FailedStep)
+	} else if result.Verification != nil {
+		fmt.Fprintf(&builder, "Recipe %s failed verification and was rolled back\n\n", result.Name)
+	} else {
+		fmt.Fprintf(&builder, "Recipe %s completed%s\n\n", result.Name, mode)
+	}
 	fmt.Fprintf(&builder, "Steps run: %d\n", result.StepsRun)
 	fmt.Fprintf(&builder, "Files scanned: %d\n", result.FilesScanned)
 	fmt.Fprintf(&builder, "%s: %d\n", modifiedLabel, result.FilesModified)
@@ -153,6 +209,8 @@ func formatRecipeToolResponse(result *core.RecipeResult) string {
 		}
 	}
 
+	builder.WriteString(formatVerification(result.Verification))
+
 	if result.DryRun {
 		builder.WriteString("\nThis was a dry run. No files were modified.\n")
 	}
@@ -87,10 +87,21 @@ func RegisterAll(server types.ServerInterface) {
 	Registry.Register("insert_before", NewInsertBeforeTool(server))
 	Registry.Register("insert_after", NewInsertAfterTool(server))
 	Registry.Register("append", NewAppendTool(server))
+	Registry.Register("extract_function", NewExtractFunctionTool(server))
+	Registry.Register("ensure_import", NewEnsureImportTool(server))
+	Registry.Register("remove_import", NewRemoveImportTool(server))
+	Registry.Register("sort", NewSortTool(server))
+	Registry.Register("add_annotation", NewAddAnnotationTool(server))
+	Registry.Register("remove_annotation", NewRemoveAnnotationTool(server))
+	Registry.Register("edit_tags", NewEditTagsTool(server))
+	Registry.Register("set_docstring", NewSetDocstringTool(server))
+	Registry.Register("annotate", NewAnnotateTool(server))
+	Registry.Register("batch", NewBatchTool(server))
 	Registry.Register("recipe", NewRecipeTool(server))
 
 	// Staging tools
 	Registry.Register("apply", NewApplyTool(server))
+	Registry.Register("revert", NewRevertTool(server))
 }
 
 // Get retrieves a tool by name
@@ -25,7 +25,17 @@ func TestRegistry_Init(t *testing.T) {
 		"replace", "file_replace",
 		"delete", "file_delete",
 		"insert_before", "insert_after",
-		"append", "apply", "recipe",
+		"append", "apply", "revert", "recipe",
+		"extract_function",
+		"ensure_import",
+		"remove_import",
+		"sort",
+		"add_annotation",
+		"remove_annotation",
+		"edit_tags",
+		"set_docstring",
+		"annotate",
+		"batch",
 	}
 
 	for _, name := range expectedTools {
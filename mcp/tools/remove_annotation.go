@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+)
+
+// RemoveAnnotationTool removes decorators, attributes, and struct tags
+type RemoveAnnotationTool struct {
+	*BaseTool
+	server types.ServerInterface
+}
+
+// NewRemoveAnnotationTool creates a new remove annotation tool
+func NewRemoveAnnotationTool(server types.ServerInterface) *RemoveAnnotationTool {
+	tool := &RemoveAnnotationTool{
+		server: server,
+	}
+
+	tool.BaseTool = &BaseTool{
+		name:        "remove_annotation",
+		description: "Remove the decorators, PHP attributes, or Go struct tag keys whose name matches annotation from each matched declaration. A decorator: or attribute: target is removed directly when annotation is omitted.",
+		inputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"language":   CommonSchemas.Language,
+				"source":     CommonSchemas.Source,
+				"path":       CommonSchemas.Path,
+				"target":     CommonSchemas.Target,
+				"target_dsl": CommonSchemas.TargetDSL,
+				"annotation": map[string]any{"type": "string", "description": "Name pattern of the annotations to remove, such as deprecated, Route, or json"},
+				"format":     CommonSchemas.Format,
+			},
+			"required": []string{"language"},
+			"oneOf": []map[string]any{
+				{"required": []string{"source"}},
+				{"required": []string{"path"}},
+			},
+		},
+		handler: tool.handle,
+	}
+
+	return tool
+}
+
+// handle executes the remove annotation tool
+func (t *RemoveAnnotationTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var args struct {
+		Language   string          `json:"language"`
+		Source     string          `json:"source"`
+		Path       string          `json:"path"`
+		Target     json.RawMessage `json:"target"`
+		TargetDSL  string          `json:"target_dsl,omitempty"`
+		Annotation string          `json:"annotation,omitempty"`
+		Format     bool            `json:"format,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid remove_annotation parameters", err)
+	}
+
+	// Validate that exactly one of source or path is provided
+	if (args.Source == "" && args.Path == "") || (args.Source != "" && args.Path != "") {
+		return nil, types.NewMCPError(types.InvalidParams, "Exactly one of 'source' or 'path' must be provided", nil)
+	}
+
+	notifyProgress(ctx, t.server, 5, 100, "validating")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get source code
+	var source string
+	if args.Path != "" {
+		content, err := os.ReadFile(args.Path)
+		if err != nil {
+			return nil, types.WrapError(types.FileSystemError, "Failed to read file", err)
+		}
+		source = string(content)
+		notifyProgress(ctx, t.server, 15, 100, "loaded file")
+	} else {
+		source = args.Source
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get provider
+	provider, exists := t.server.GetProviders().Get(args.Language)
+	if !exists {
+		return nil, types.NewMCPError(types.LanguageNotFound, "Language not supported", nil)
+	}
+	notifyProgress(ctx, t.server, 25, 100, "resolved provider")
+
+	// Parse target
+	target, err := parseRequiredQuery(args.Target, args.TargetDSL, "target")
+	if err != nil {
+		return nil, err
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Execute transformation
+	op := core.TransformOp{
+		Method:     "remove_annotation",
+		Target:     target,
+		Annotation: args.Annotation,
+		Format:     args.Format,
+	}
+
+	result := provider.Transform(source, op)
+	if result.Error != nil {
+		return nil, types.WrapError(types.TransformFailed, "Remove annotation operation failed", result.Error)
+	}
+	notifyProgress(ctx, t.server, 70, 100, "transformed source")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	notifyProgress(ctx, t.server, 90, 100, "finalizing")
+
+	return t.server.FinalizeTransform(ctx, types.TransformRequest{
+		Language:       args.Language,
+		Operation:      "remove_annotation",
+		Target:         target,
+		TargetJSON:     args.Target,
+		Path:           args.Path,
+		OriginalSource: source,
+		Result:         result,
+		ResponseText:   t.formatResponse(result, args.Path),
+		Op:             &op,
+	})
+}
+
+// formatResponse formats the remove annotation result
+func (t *RemoveAnnotationTool) formatResponse(result core.TransformResult, path string) string {
+	if result.Error != nil {
+		return "Remove annotation operation failed: " + result.Error.Error()
+	}
+
+	response := "✅ Remove annotation operation completed successfully\n\n"
+
+	if path != "" {
+		response += "📄 File: " + path + "\n\n"
+	}
+
+	if removed, ok := result.Metadata["annotations_removed"].(int); ok {
+		response += fmt.Sprintf("Annotations removed: %d\n", removed)
+	}
+
+	response += fmt.Sprintf("\nConfidence: %.1f%%", result.Confidence.Score*100)
+
+	return response
+}
@@ -29,12 +29,16 @@ func NewReplaceTool(server types.ServerInterface) *ReplaceTool {
 		inputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"language":    CommonSchemas.Language,
-				"source":      CommonSchemas.Source,
-				"path":        CommonSchemas.Path,
-				"target":      CommonSchemas.Target,
-				"target_dsl":  CommonSchemas.TargetDSL,
-				"replacement": CommonSchemas.Replacement,
+				"language":         CommonSchemas.Language,
+				"source":           CommonSchemas.Source,
+				"path":             CommonSchemas.Path,
+				"target":           CommonSchemas.Target,
+				"target_dsl":       CommonSchemas.TargetDSL,
+				"organize_imports": CommonSchemas.OrganizeImports,
+				"format":           CommonSchemas.Format,
+				"overlap":          CommonSchemas.Overlap,
+				"attached":         CommonSchemas.Attached,
+				"replacement":      CommonSchemas.Replacement,
 			},
 			"required": []string{"language", "replacement"},
 			"oneOf": []map[string]any{
@@ -55,12 +59,16 @@ func (t *ReplaceTool) handle(ctx context.Context, params json.RawMessage) (any,
 		ctx = context.Background()
 	}
 	var args struct {
-		Language    string          `json:"language"`
-		Source      string          `json:"source"`
-		Path        string          `json:"path"`
-		Target      json.RawMessage `json:"target"`
-		TargetDSL   string          `json:"target_dsl,omitempty"`
-		Replacement string          `json:"replacement"`
+		Language        string          `json:"language"`
+		Source          string          `json:"source"`
+		Path            string          `json:"path"`
+		Target          json.RawMessage `json:"target"`
+		TargetDSL       string          `json:"target_dsl,omitempty"`
+		Replacement     string          `json:"replacement"`
+		OrganizeImports bool            `json:"organize_imports,omitempty"`
+		Format          bool            `json:"format,omitempty"`
+		Overlap         string          `json:"overlap,omitempty"`
+		Attached        string          `json:"attached,omitempty"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
@@ -110,9 +118,13 @@ func (t *ReplaceTool) handle(ctx context.Context, params json.RawMessage) (any,
 
 	// Execute transformation
 	op := core.TransformOp{
-		Method:      "replace",
-		Target:      target,
-		Replacement: args.Replacement,
+		Method:          "replace",
+		Target:          target,
+		Replacement:     args.Replacement,
+		OrganizeImports: args.OrganizeImports,
+		Format:          args.Format,
+		Overlap:         args.Overlap,
+		Attached:        args.Attached,
 	}
 
 	result := provider.Transform(source, op)
@@ -136,6 +148,7 @@ func (t *ReplaceTool) handle(ctx context.Context, params json.RawMessage) (any,
 		OriginalSource: source,
 		Result:         result,
 		ResponseText:   t.formatResponse(result, args.Path),
+		Op:             &op,
 	})
 }
 
@@ -154,6 +167,7 @@ func (t *ReplaceTool) formatResponse(result core.TransformResult, path string) s
 	if result.MatchCount > 0 {
 		response += fmt.Sprintf("Replacements made: %d\n", result.MatchCount)
 	}
+	response += formatDroppedMatches(result)
 
 	if result.Diff != "" {
 		response += "\nChanges:\n" + result.Diff + "\n"
@@ -164,6 +178,19 @@ func (t *ReplaceTool) formatResponse(result core.TransformResult, path string) s
 	return response
 }
 
+// formatDroppedMatches lists matches skipped because they overlapped another
+func formatDroppedMatches(result core.TransformResult) string {
+	dropped, _ := result.Metadata["dropped_matches"].([]core.Match)
+	if len(dropped) == 0 {
+		return ""
+	}
+	response := fmt.Sprintf("Overlapping matches skipped: %d\n", len(dropped))
+	for _, match := range dropped {
+		response += fmt.Sprintf("  - %s %s (line %d)\n", match.Type, match.Name, match.Location.Line)
+	}
+	return response
+}
+
 // formatConfidence formats confidence score as visual indicator
 func formatConfidence(confidence float64) string {
 	bars := int(confidence * 10)
@@ -4,7 +4,11 @@ import (
 	"context"
 	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
 )
 
 func TestReplaceTool_Execute(t *testing.T) {
@@ -207,3 +211,50 @@ func TestReplaceTool_Schema(t *testing.T) {
 		t.Error("Schema should have 'target_dsl' property")
 	}
 }
+
+func TestReplaceTool_ReportsDroppedMatches(t *testing.T) {
+	tool := NewReplaceTool(newMockServer())
+	text := tool.formatResponse(core.TransformResult{
+		MatchCount: 1,
+		Metadata: map[string]any{"dropped_matches": []core.Match{
+			{Type: "method", Name: "get_handler", Location: core.Location{Line: 2}},
+		}},
+	}, "")
+	if !strings.Contains(text, "Overlapping matches skipped: 1") || !strings.Contains(text, "method get_handler (line 2)") {
+		t.Fatalf("expected dropped matches in response:\n%s", text)
+	}
+}
+
+func TestOverlapSchema(t *testing.T) {
+	server := newMockServer()
+	for _, tool := range []interface{ InputSchema() map[string]any }{
+		NewReplaceTool(server), NewDeleteTool(server), NewFileReplaceTool(server), NewFileDeleteTool(server),
+	} {
+		properties := tool.InputSchema()["properties"].(map[string]any)
+		if _, ok := properties["overlap"]; !ok {
+			t.Fatalf("schema missing overlap: %v", properties)
+		}
+	}
+}
+
+func TestFileToolsExpectMatches(t *testing.T) {
+	server := newMockServer()
+	for _, tool := range []interface {
+		InputSchema() map[string]any
+		Handler() types.ToolHandler
+	}{NewFileReplaceTool(server), NewFileDeleteTool(server)} {
+		properties := tool.InputSchema()["properties"].(map[string]any)
+		if _, ok := properties["expect_matches"]; !ok {
+			t.Fatalf("schema missing expect_matches: %v", properties)
+		}
+
+		params := createTestParams(map[string]any{
+			"scope":          map[string]any{"path": t.TempDir()},
+			"target_dsl":     "func:Old*",
+			"replacement":    "func New() {}",
+			"expect_matches": "two",
+		})
+		_, err := tool.Handler()(context.Background(), params)
+		assertError(t, err, "Invalid expect_matches")
+	}
+}
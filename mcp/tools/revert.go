@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+	"github.com/oxhq/morfx/models"
+	"gorm.io/gorm"
+)
+
+// RevertTool handles undoing applied transformations
+type RevertTool struct {
+	*BaseTool
+	server types.ServerInterface
+}
+
+// NewRevertTool creates a new revert tool
+func NewRevertTool(server types.ServerInterface) *RevertTool {
+	tool := &RevertTool{
+		server: server,
+	}
+
+	tool.BaseTool = &BaseTool{
+		name:        "revert",
+		description: "Revert applied transformations, restoring pre-apply content or merging out the change when the file has been edited since",
+		inputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"id": map[string]any{
+					"type":        "string",
+					"description": "Specific applied stage ID to revert",
+				},
+				"session": map[string]any{
+					"type":        "boolean",
+					"description": "Revert every applied stage in the current session, newest first",
+				},
+				"last": map[string]any{
+					"type":        "integer",
+					"description": "Revert the last N applied stages in the current session, newest first",
+					"minimum":     1,
+				},
+			},
+			"required": []string{},
+		},
+		handler: tool.handle,
+	}
+
+	return tool
+}
+
+// handle executes the revert tool
+func (t *RevertTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
+	var args struct {
+		ID      string `json:"id,omitempty"`
+		Session bool   `json:"session,omitempty"`
+		Last    int    `json:"last,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid revert parameters", err)
+	}
+	if args.Last < 0 {
+		return nil, types.NewMCPError(types.InvalidParams, "last must be positive", nil)
+	}
+
+	notifyProgress(ctx, t.server, 5, 100, "validating")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	stagingRaw := t.server.GetStaging()
+	if stagingRaw == nil {
+		return nil, types.NewMCPError(types.InvalidParams,
+			"Staging not available",
+			map[string]any{"reason": "Database connection required for staging"})
+	}
+	staging, ok := stagingRaw.(types.StagingManager)
+	if !ok || !staging.IsEnabled() {
+		return nil, types.NewMCPError(types.InvalidParams, "staging is not enabled", nil)
+	}
+	reverter, ok := stagingRaw.(types.StagingReverter)
+	if !ok {
+		return nil, types.NewMCPError(types.InvalidParams,
+			"Staging manager does not support reverts",
+			nil)
+	}
+
+	paramCount := 0
+	if args.ID != "" {
+		paramCount++
+	}
+	if args.Session {
+		paramCount++
+	}
+	if args.Last > 0 {
+		paramCount++
+	}
+	if paramCount > 1 {
+		return nil, types.NewMCPError(types.InvalidParams,
+			"conflicting parameters: specify only one of 'id', 'session', or 'last'",
+			nil)
+	}
+	if paramCount == 0 {
+		args.Last = 1
+	}
+
+	sessionID := t.server.GetSessionID()
+	notifyProgress(ctx, t.server, 30, 100, "selecting stages")
+
+	var stageIDs []string
+	mode := "last"
+	switch {
+	case args.ID != "":
+		mode = "single"
+		stage, err := staging.GetStage(args.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, types.NewMCPError(types.InvalidParams, "stage not found: "+args.ID, nil)
+			}
+			return nil, types.WrapError(types.InvalidParams, "failed to load stage", err)
+		}
+		if stage.Status != "applied" {
+			return nil, types.NewMCPError(types.InvalidParams,
+				fmt.Sprintf("stage %s is %s, not applied", args.ID, stage.Status),
+				nil)
+		}
+		stageIDs = []string{args.ID}
+
+	default:
+		limit := args.Last
+		if args.Session {
+			mode, limit = "session", 0
+		}
+		if sessionID == "" {
+			return nil, types.NewMCPError(types.InvalidParams,
+				"staging session unavailable",
+				map[string]any{"reason": "server did not negotiate persistence"})
+		}
+		stages, err := reverter.ListRevertibleStages(sessionID, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list applied stages: %w", err)
+		}
+		if len(stages) == 0 {
+			return nil, types.NewMCPError(types.InvalidParams, "no applied stages to revert", nil)
+		}
+		stageIDs = stageIDsOf(stages)
+	}
+
+	if err := t.server.ConfirmApply(ctx, fmt.Sprintf("Revert %d stage(s)", len(stageIDs))); err != nil {
+		return nil, err
+	}
+
+	notifyProgress(ctx, t.server, 60, 100, "reverting")
+	reverted := make([]*types.StageRevert, 0, len(stageIDs))
+	for _, stageID := range stageIDs {
+		if err := isCancelled(ctx); err != nil {
+			return nil, err
+		}
+		revert, err := reverter.RevertStage(ctx, stageID, "mcp")
+		if errors.Is(err, core.ErrMergeConflict) {
+			return revertConflictResponse(mode, reverted, stageID, err), nil
+		}
+		if err != nil {
+			return nil, types.WrapError(types.FileSystemError, "failed to revert stage "+stageID, err)
+		}
+		reverted = append(reverted, revert)
+	}
+
+	notifyProgress(ctx, t.server, 90, 100, "completed")
+
+	return map[string]any{
+		"content":  []map[string]any{{"type": "text", "text": formatReverts(reverted)}},
+		"reverted": revertedIDs(reverted),
+		"structuredContent": map[string]any{
+			"mode":     mode,
+			"reverted": reverted,
+		},
+	}, nil
+}
+
+// revertConflictResponse reports a stage that could not be reverted because
+// its file changed in the same place since it was applied. Stages reverted
+// before it stay reverted.
+func revertConflictResponse(mode string, reverted []*types.StageRevert, failedID string, err error) map[string]any {
+	message := fmt.Sprintf("Stage %s was not reverted", failedID)
+	if len(reverted) > 0 {
+		message += fmt.Sprintf(" after reverting %d stage(s)", len(reverted))
+	}
+	message += ": " + err.Error()
+
+	return map[string]any{
+		"content":  []map[string]any{{"type": "text", "text": message}},
+		"reverted": revertedIDs(reverted),
+		"failed":   failedID,
+		"structuredContent": map[string]any{
+			"mode":     mode,
+			"reverted": reverted,
+			"failed":   failedID,
+			"error":    err.Error(),
+		},
+		"isError": true,
+	}
+}
+
+func formatReverts(reverted []*types.StageRevert) string {
+	message := fmt.Sprintf("Reverted %d stage(s)", len(reverted))
+	for _, revert := range reverted {
+		how := "restored"
+		if revert.Merged {
+			how = "merged with later edits"
+		}
+		message += fmt.Sprintf("\n↩️  %s: %s (%s)", revert.StageID, revert.FilePath, how)
+	}
+	return message
+}
+
+func revertedIDs(reverted []*types.StageRevert) []string {
+	ids := make([]string, 0, len(reverted))
+	for _, revert := range reverted {
+		ids = append(ids, revert.StageID)
+	}
+	return ids
+}
+
+func stageIDsOf(stages []models.Stage) []string {
+	ids := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		ids = append(ids, stage.ID)
+	}
+	return ids
+}
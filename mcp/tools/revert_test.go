@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+	"github.com/oxhq/morfx/models"
+)
+
+// revertingStaging holds applied stages, newest first, and fails to revert
+// the stages in conflict.
+type revertingStaging struct {
+	*mockStaging
+	applied  []string
+	conflict map[string]bool
+	reverted []string
+}
+
+func newRevertingStaging(applied ...string) *revertingStaging {
+	return &revertingStaging{
+		mockStaging: &mockStaging{enabled: true, stages: make(map[string]any)},
+		applied:     applied,
+		conflict:    make(map[string]bool),
+	}
+}
+
+func (m *revertingStaging) GetStage(stageID string) (*models.Stage, error) {
+	for _, id := range m.applied {
+		if id == stageID {
+			return &models.Stage{ID: stageID, Status: "applied", SessionID: "mock-session"}, nil
+		}
+	}
+	return nil, fmt.Errorf("stage not found")
+}
+
+func (m *revertingStaging) ListRevertibleStages(sessionID string, limit int) ([]models.Stage, error) {
+	var stages []models.Stage
+	for _, id := range m.applied {
+		if limit > 0 && len(stages) == limit {
+			break
+		}
+		stages = append(stages, models.Stage{ID: id, Status: "applied", SessionID: sessionID})
+	}
+	return stages, nil
+}
+
+func (m *revertingStaging) RevertStage(ctx context.Context, stageID, revertedBy string) (*types.StageRevert, error) {
+	if m.conflict[stageID] {
+		return nil, fmt.Errorf("stage %s cannot be reverted: %w in a.go: changed since apply at line 3", stageID, core.ErrMergeConflict)
+	}
+	m.reverted = append(m.reverted, stageID)
+	return &types.StageRevert{StageID: stageID, ApplyID: "apply-" + stageID, FilePath: stageID + ".go"}, nil
+}
+
+func TestRevertTool_Modes(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   []string
+	}{
+		{name: "default reverts the last stage", params: map[string]any{}, want: []string{"stage3"}},
+		{name: "by id", params: map[string]any{"id": "stage2"}, want: []string{"stage2"}},
+		{name: "last n", params: map[string]any{"last": 2}, want: []string{"stage3", "stage2"}},
+		{name: "session", params: map[string]any{"session": true}, want: []string{"stage3", "stage2", "stage1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMockServer()
+			staging := newRevertingStaging("stage3", "stage2", "stage1")
+			server.staging = staging
+
+			result, err := NewRevertTool(server).handle(context.Background(), createTestParams(tt.params))
+			assertNoError(t, err)
+
+			if got := toStringSlice(result.(map[string]any)["reverted"]); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("reverted = %v, want %v", got, tt.want)
+			}
+			if strings.Join(staging.reverted, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("staging reverted %v, want %v", staging.reverted, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevertTool_Conflict(t *testing.T) {
+	server := newMockServer()
+	staging := newRevertingStaging("stage3", "stage2", "stage1")
+	staging.conflict["stage2"] = true
+	server.staging = staging
+
+	result, err := NewRevertTool(server).handle(context.Background(), createTestParams(map[string]any{"session": true}))
+	assertNoError(t, err)
+
+	resultMap := result.(map[string]any)
+	if resultMap["isError"] != true || resultMap["failed"] != "stage2" {
+		t.Fatalf("expected a conflict response for stage2, got %+v", resultMap)
+	}
+	if got := toStringSlice(resultMap["reverted"]); len(got) != 1 || got[0] != "stage3" {
+		t.Fatalf("expected only stage3 reverted, got %v", got)
+	}
+	if text := extractContentText(t, resultMap); !strings.Contains(text, "line 3") {
+		t.Fatalf("expected the conflict location in text, got %q", text)
+	}
+}
+
+func TestRevertTool_InvalidParams(t *testing.T) {
+	server := newMockServer()
+	server.staging = newRevertingStaging("stage1")
+	tool := NewRevertTool(server)
+
+	_, err := tool.handle(context.Background(), createTestParams(map[string]any{"id": "stage1", "last": 2}))
+	assertError(t, err, "conflicting parameters")
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{"id": "missing"}))
+	assertError(t, err, "failed to load stage")
+
+	server.staging = &mockStaging{enabled: true, stages: make(map[string]any)}
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{"id": "stage1"}))
+	assertError(t, err, "does not support reverts")
+}
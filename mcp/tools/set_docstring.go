@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+)
+
+// SetDocstringTool writes Python docstrings
+type SetDocstringTool struct {
+	*BaseTool
+	server types.ServerInterface
+}
+
+// NewSetDocstringTool creates a new set docstring tool
+func NewSetDocstringTool(server types.ServerInterface) *SetDocstringTool {
+	tool := &SetDocstringTool{
+		server: server,
+	}
+
+	tool.BaseTool = &BaseTool{
+		name:        "set_docstring",
+		description: "Set the docstring of each matched Python function or class, replacing the one it has or inserting one as the first statement. Plain text is quoted and indented to match the body, with multi-line text laid out as PEP 257 describes. Target docstrings directly with docstring:name; with if_absent, existing docstrings are kept.",
+		inputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"language":   CommonSchemas.Language,
+				"source":     CommonSchemas.Source,
+				"path":       CommonSchemas.Path,
+				"target":     CommonSchemas.Target,
+				"target_dsl": CommonSchemas.TargetDSL,
+				"docstring":  map[string]any{"type": "string", "description": "Docstring text, plain or already quoted"},
+				"if_absent":  CommonSchemas.IfAbsent,
+				"format":     CommonSchemas.Format,
+			},
+			"required": []string{"language", "docstring"},
+			"oneOf": []map[string]any{
+				{"required": []string{"source"}},
+				{"required": []string{"path"}},
+			},
+		},
+		handler: tool.handle,
+	}
+
+	return tool
+}
+
+// handle executes the set docstring tool
+func (t *SetDocstringTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var args struct {
+		Language  string          `json:"language"`
+		Source    string          `json:"source"`
+		Path      string          `json:"path"`
+		Target    json.RawMessage `json:"target"`
+		TargetDSL string          `json:"target_dsl,omitempty"`
+		Docstring string          `json:"docstring"`
+		IfAbsent  bool            `json:"if_absent,omitempty"`
+		Format    bool            `json:"format,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid set_docstring parameters", err)
+	}
+
+	// Validate that exactly one of source or path is provided
+	if (args.Source == "" && args.Path == "") || (args.Source != "" && args.Path != "") {
+		return nil, types.NewMCPError(types.InvalidParams, "Exactly one of 'source' or 'path' must be provided", nil)
+	}
+
+	if strings.TrimSpace(args.Docstring) == "" {
+		return nil, types.NewMCPError(types.InvalidParams, "docstring is required", nil)
+	}
+
+	notifyProgress(ctx, t.server, 5, 100, "validating")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get source code
+	var source string
+	if args.Path != "" {
+		content, err := os.ReadFile(args.Path)
+		if err != nil {
+			return nil, types.WrapError(types.FileSystemError, "Failed to read file", err)
+		}
+		source = string(content)
+		notifyProgress(ctx, t.server, 15, 100, "loaded file")
+	} else {
+		source = args.Source
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get provider
+	provider, exists := t.server.GetProviders().Get(args.Language)
+	if !exists {
+		return nil, types.NewMCPError(types.LanguageNotFound, "Language not supported", nil)
+	}
+	notifyProgress(ctx, t.server, 25, 100, "resolved provider")
+
+	// Parse target
+	target, err := parseRequiredQuery(args.Target, args.TargetDSL, "target")
+	if err != nil {
+		return nil, err
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Execute transformation
+	op := core.TransformOp{
+		Method:   "set_docstring",
+		Target:   target,
+		Content:  args.Docstring,
+		IfAbsent: args.IfAbsent,
+		Format:   args.Format,
+	}
+
+	result := provider.Transform(source, op)
+	if result.Error != nil {
+		return nil, types.WrapError(types.TransformFailed, "Set docstring operation failed", result.Error)
+	}
+	notifyProgress(ctx, t.server, 70, 100, "transformed source")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	notifyProgress(ctx, t.server, 90, 100, "finalizing")
+
+	return t.server.FinalizeTransform(ctx, types.TransformRequest{
+		Language:       args.Language,
+		Operation:      "set_docstring",
+		Target:         target,
+		TargetJSON:     args.Target,
+		Path:           args.Path,
+		OriginalSource: source,
+		Result:         result,
+		ResponseText:   t.formatResponse(result, args.Path),
+		Op:             &op,
+	})
+}
+
+// formatResponse formats the set docstring result
+func (t *SetDocstringTool) formatResponse(result core.TransformResult, path string) string {
+	if result.Error != nil {
+		return "Set docstring operation failed: " + result.Error.Error()
+	}
+
+	response := "✅ Set docstring operation completed successfully\n\n"
+
+	if path != "" {
+		response += "📄 File: " + path + "\n\n"
+	}
+
+	if replaced, ok := result.Metadata["docstrings_replaced"].(int); ok {
+		response += fmt.Sprintf("Docstrings replaced: %d\n", replaced)
+	}
+	if added, ok := result.Metadata["docstrings_added"].(int); ok {
+		response += fmt.Sprintf("Docstrings added: %d\n", added)
+	}
+	if skipped, ok := result.Metadata["docstrings_skipped"].(int); ok && skipped > 0 {
+		response += fmt.Sprintf("Unchanged: %d\n", skipped)
+	}
+
+	response += fmt.Sprintf("\nConfidence: %.1f%%", result.Confidence.Score*100)
+
+	return response
+}
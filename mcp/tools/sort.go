@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/mcp/types"
+)
+
+// SortTool reorders sibling members
+type SortTool struct {
+	*BaseTool
+	server types.ServerInterface
+}
+
+// NewSortTool creates a new sort tool
+func NewSortTool(server types.ServerInterface) *SortTool {
+	tool := &SortTool{
+		server: server,
+	}
+
+	tool.BaseTool = &BaseTool{
+		name:        "sort",
+		description: "Reorder sibling members in place by name, visibility, kind, or a DSL capture. A containment target such as class:User > method:* sorts the methods inside User; comments, decorators, and trailing comments move with their member while separators and blank lines stay put.",
+		inputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"language":   CommonSchemas.Language,
+				"source":     CommonSchemas.Source,
+				"path":       CommonSchemas.Path,
+				"target":     CommonSchemas.Target,
+				"target_dsl": CommonSchemas.TargetDSL,
+				"sort_by":    CommonSchemas.SortBy,
+				"sort_order": CommonSchemas.SortOrder,
+				"format":     CommonSchemas.Format,
+			},
+			"required": []string{"language"},
+			"oneOf": []map[string]any{
+				{"required": []string{"source"}},
+				{"required": []string{"path"}},
+			},
+		},
+		handler: tool.handle,
+	}
+
+	return tool
+}
+
+// handle executes the sort tool
+func (t *SortTool) handle(ctx context.Context, params json.RawMessage) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var args struct {
+		Language  string          `json:"language"`
+		Source    string          `json:"source"`
+		Path      string          `json:"path"`
+		Target    json.RawMessage `json:"target"`
+		TargetDSL string          `json:"target_dsl,omitempty"`
+		SortBy    string          `json:"sort_by,omitempty"`
+		SortOrder []string        `json:"sort_order,omitempty"`
+		Format    bool            `json:"format,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid sort parameters", err)
+	}
+
+	// Validate that exactly one of source or path is provided
+	if (args.Source == "" && args.Path == "") || (args.Source != "" && args.Path != "") {
+		return nil, types.NewMCPError(types.InvalidParams, "Exactly one of 'source' or 'path' must be provided", nil)
+	}
+
+	if err := core.ValidateSortBy(args.SortBy); err != nil {
+		return nil, types.WrapError(types.InvalidParams, "Invalid sort_by", err)
+	}
+
+	notifyProgress(ctx, t.server, 5, 100, "validating")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get source code
+	var source string
+	if args.Path != "" {
+		content, err := os.ReadFile(args.Path)
+		if err != nil {
+			return nil, types.WrapError(types.FileSystemError, "Failed to read file", err)
+		}
+		source = string(content)
+		notifyProgress(ctx, t.server, 15, 100, "loaded file")
+	} else {
+		source = args.Source
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get provider
+	provider, exists := t.server.GetProviders().Get(args.Language)
+	if !exists {
+		return nil, types.NewMCPError(types.LanguageNotFound, "Language not supported", nil)
+	}
+	notifyProgress(ctx, t.server, 25, 100, "resolved provider")
+
+	// Parse target
+	target, err := parseRequiredQuery(args.Target, args.TargetDSL, "target")
+	if err != nil {
+		return nil, err
+	}
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	// Execute transformation
+	op := core.TransformOp{
+		Method:    "sort",
+		Target:    target,
+		SortBy:    args.SortBy,
+		SortOrder: args.SortOrder,
+		Format:    args.Format,
+	}
+
+	result := provider.Transform(source, op)
+	if result.Error != nil {
+		return nil, types.WrapError(types.TransformFailed, "Sort operation failed", result.Error)
+	}
+	notifyProgress(ctx, t.server, 70, 100, "transformed source")
+	if err := isCancelled(ctx); err != nil {
+		return nil, err
+	}
+
+	notifyProgress(ctx, t.server, 90, 100, "finalizing")
+
+	return t.server.FinalizeTransform(ctx, types.TransformRequest{
+		Language:       args.Language,
+		Operation:      "sort",
+		Target:         target,
+		TargetJSON:     args.Target,
+		Path:           args.Path,
+		OriginalSource: source,
+		Result:         result,
+		ResponseText:   t.formatResponse(result, args.Path),
+		Op:             &op,
+	})
+}
+
+// formatResponse formats the sort result
+func (t *SortTool) formatResponse(result core.TransformResult, path string) string {
+	if result.Error != nil {
+		return "Sort operation failed: " + result.Error.Error()
+	}
+
+	response := "✅ Sort operation completed successfully\n\n"
+
+	if path != "" {
+		response += "📄 File: " + path + "\n\n"
+	}
+
+	if result.MatchCount > 0 {
+		response += fmt.Sprintf("Members matched: %d\n", result.MatchCount)
+	}
+	if sorted, ok := result.Metadata["sorted"].([]map[string]any); ok {
+		for _, group := range sorted {
+			response += fmt.Sprintf("Sorted %s (line %v): %v\n", group["container"], group["line"], group["order"])
+		}
+	}
+
+	response += fmt.Sprintf("\nConfidence: %.1f%%", result.Confidence.Score*100)
+
+	return response
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestSortTool_Execute(t *testing.T) {
+	server := newMockServer()
+	tool := NewSortTool(server)
+
+	result, err := tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "go",
+		"source":     "package main\n\ntype S struct {\n\tZeta int\n\talpha int\n}\n",
+		"target_dsl": "struct:S > field:*",
+		"sort_by":    "visibility",
+		"sort_order": []string{"private", "public"},
+	}))
+	assertNoError(t, err)
+	if !hasContentArray(result) {
+		t.Fatalf("expected content array, got %#v", result)
+	}
+
+	_, err = tool.handle(context.Background(), createTestParams(map[string]any{
+		"language":   "go",
+		"source":     "package main\n\ntype S struct {\n\tZeta int\n\talpha int\n}\n",
+		"target_dsl": "struct:S > field:*",
+		"sort_by":    "size",
+	}))
+	assertError(t, err, "sort_by")
+}
+
+func TestSortTool_FormatResponseListsOrder(t *testing.T) {
+	tool := NewSortTool(newMockServer())
+	text := tool.formatResponse(core.TransformResult{
+		MatchCount: 2,
+		Confidence: core.ConfidenceScore{Score: 1},
+		Metadata: map[string]any{"sorted": []map[string]any{
+			{"container": "field_declaration_list", "line": 3, "order": []string{"alpha", "Zeta"}},
+		}},
+	}, "main.go")
+	for _, want := range []string{"📄 File: main.go", "Members matched: 2", "Sorted field_declaration_list (line 3): [alpha Zeta]"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("response missing %q:\n%s", want, text)
+		}
+	}
+}
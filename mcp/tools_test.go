@@ -21,7 +21,7 @@ func TestGetToolDefinitions(t *testing.T) {
 	expectedTools := []string{
 		"query", "file_query", "replace", "file_replace",
 		"delete", "file_delete", "insert_before", "insert_after",
-		"apply", "append", "recipe",
+		"apply", "revert", "append", "recipe", "extract_function", "ensure_import", "remove_import", "sort", "add_annotation", "remove_annotation", "edit_tags", "set_docstring", "annotate", "batch",
 	}
 
 	if len(tools) != len(expectedTools) {
@@ -311,7 +311,7 @@ func TestRegisterBuiltinTools(t *testing.T) {
 	expectedTools := []string{
 		"query", "file_query", "replace", "file_replace",
 		"delete", "file_delete", "insert_before", "insert_after",
-		"apply", "append", "recipe",
+		"apply", "revert", "append", "recipe", "extract_function", "ensure_import", "remove_import", "sort", "add_annotation", "remove_annotation", "edit_tags", "set_docstring", "annotate", "batch",
 	}
 
 	registered := server.toolRegistry.Names()
@@ -443,7 +443,7 @@ func TestToolSchemaValidation(t *testing.T) {
 			// instead of top-level 'language'. Other tools should have 'language'
 			// parameter except apply.
 			isFileTool := strings.HasPrefix(tool.Name, "file_")
-			isWorkflowTool := tool.Name == "apply" || tool.Name == "recipe"
+			isWorkflowTool := tool.Name == "apply" || tool.Name == "revert" || tool.Name == "recipe"
 			if !isWorkflowTool && !isFileTool {
 				if _, hasLanguage := propertiesMap["language"]; !hasLanguage {
 					t.Error("Non-file tool should have 'language' parameter")
@@ -481,7 +481,7 @@ func TestToolSchemaValidation(t *testing.T) {
 					t.Error("File tool should have 'scope' parameter")
 				}
 				// File tools don't require language parameter at top level
-			} else if tool.Name != "apply" && tool.Name != "recipe" {
+			} else if tool.Name != "apply" && tool.Name != "revert" && tool.Name != "recipe" {
 				// Non-file tools typically need language parameter
 				if _, hasLanguage := propertiesMap["language"]; !hasLanguage {
 					t.Logf("Tool %s missing 'language' parameter (might be expected)", tool.Name)
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/oxhq/morfx/core"
 	"github.com/oxhq/morfx/mcp/types"
 	"github.com/oxhq/morfx/models"
 	"gorm.io/datatypes"
@@ -40,6 +41,44 @@ func (s *StdioServer) FinalizeTransform(ctx context.Context, req types.Transform
 
 	fileMode := req.Path != ""
 	responseText := req.ResponseText
+
+	if s.typeCheck {
+		if provider, ok := s.providers.Get(req.Language); ok {
+			if checker, ok := provider.(core.TypeChecker); ok {
+				if typeErrors := core.ApplyTypeCheck(checker, req.Path, req.OriginalSource, &req.Result); len(typeErrors) > 0 {
+					responseText += fmt.Sprintf("\n🔎 Type check found %d new error(s):", len(typeErrors))
+					for _, typeErr := range typeErrors {
+						responseText += "\n  " + typeErr
+					}
+				}
+			}
+		}
+	}
+
+	if fileMode && s.fileProcessor != nil {
+		references := s.fileProcessor.ApplyReferenceCheck(ctx, core.ReferenceScope(req.Path), req.Language, req.Path, &req.Result)
+		if len(references) > 0 {
+			responseText += fmt.Sprintf("\n🔗 %d remaining reference(s) to deleted or changed declarations:", len(references))
+			for _, reference := range references[:min(len(references), 10)] {
+				responseText += "\n  " + reference.String()
+			}
+			if len(references) > 10 {
+				responseText += fmt.Sprintf("\n  ... and %d more", len(references)-10)
+			}
+		}
+	}
+
+	if s.scorer != nil {
+		providerScore := req.Result.Confidence.Score
+		req.Result.Confidence = s.scorer.Score(core.ScoreContext{
+			Language: req.Language,
+			Method:   req.Operation,
+			Path:     req.Path,
+		}, req.Result.Confidence)
+		if req.Result.Confidence.Score != providerScore {
+			responseText += fmt.Sprintf("\n⚖️ Confidence policy: %.2f → %.2f", providerScore, req.Result.Confidence.Score)
+		}
+	}
 	shouldAutoApply := s.config.AutoApplyEnabled && req.Result.Confidence.Score >= s.config.AutoApplyThreshold
 
 	originalHash := ""
@@ -98,6 +137,7 @@ func (s *StdioServer) FinalizeTransform(ctx context.Context, req types.Transform
 	// Try staging path first
 	staged := false
 	if s.staging != nil {
+		s.attachStructuralDiff(&req)
 		stage := s.buildStage(req, originalHash)
 		if err := s.staging.CreateStage(ctx, stage); err != nil {
 			s.debugLog("Staging failed, will fallback to direct write: %v", err)
@@ -196,10 +236,42 @@ func (s *StdioServer) FinalizeTransform(ctx context.Context, req types.Transform
 	if req.Result.Diff != "" {
 		resp["diff"] = req.Result.Diff
 	}
+	if changes, ok := req.Result.Metadata["structural_diff"]; ok {
+		resp["structural_diff"] = changes
+	}
+	if typeErrors, ok := req.Result.Metadata["type_errors"]; ok {
+		resp["type_errors"] = typeErrors
+	}
+	if references, ok := req.Result.Metadata["dangling_references"]; ok {
+		resp["dangling_references"] = references
+	}
 
 	return resp, nil
 }
 
+// attachStructuralDiff adds the structural diff a stage records to the
+// result, unless the transform already reported one. Transforms only
+// compute it when asked, so direct writes and previews skip the work.
+func (s *StdioServer) attachStructuralDiff(req *types.TransformRequest) {
+	if _, ok := req.Result.Metadata["structural_diff"]; ok || req.Result.Modified == "" || s.providers == nil {
+		return
+	}
+	provider, ok := s.providers.Get(req.Language)
+	if !ok {
+		return
+	}
+	differ, ok := provider.(core.StructuralDiffer)
+	if !ok {
+		return
+	}
+	if changes := differ.StructuralDiff(req.OriginalSource, req.Result.Modified); len(changes) > 0 {
+		if req.Result.Metadata == nil {
+			req.Result.Metadata = make(map[string]any)
+		}
+		req.Result.Metadata["structural_diff"] = changes
+	}
+}
+
 func (s *StdioServer) buildStage(req types.TransformRequest, originalHash string) *models.Stage {
 	targetJSON := req.TargetJSON
 	if len(targetJSON) == 0 {
@@ -231,6 +303,13 @@ func (s *StdioServer) buildStage(req types.TransformRequest, originalHash string
 		ConfidenceFactors: mustMarshalJSON(req.Result.Confidence.Factors),
 	}
 
+	if changes, ok := req.Result.Metadata["structural_diff"]; ok {
+		stage.StructuralDiff = mustMarshalJSON(changes)
+	}
+	if req.Op != nil {
+		stage.TransformOp = mustMarshalJSON(req.Op)
+	}
+
 	if s.session != nil {
 		stage.SessionID = s.session.ID
 	}
@@ -2,9 +2,11 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/oxhq/morfx/core"
@@ -127,3 +129,331 @@ func TestFinalizeTransform_StagingWithoutAutoApply(t *testing.T) {
 		t.Fatalf("expected file to remain unchanged, got %s", string(contents))
 	}
 }
+
+func TestFinalizeTransform_RecordsStructuralDiff(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config := DefaultConfig()
+	config.DatabaseURL = filepath.Join(tmpDir, "morfx.db")
+	config.AutoApplyThreshold = 0.9
+	config.LogWriter = io.Discard
+
+	server, err := NewStdioServer(config)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+
+	changes := []core.StructuralChange{{
+		Change:  "inserted",
+		Kind:    "function",
+		Path:    "function extra",
+		Line:    5,
+		Summary: "inserted function extra",
+	}}
+	req := types.TransformRequest{
+		Language:       "go",
+		Operation:      "insert_after",
+		Target:         core.AgentQuery{Type: "function", Name: "demo"},
+		OriginalSource: "package main\n\nfunc demo() {}\n",
+		Result: core.TransformResult{
+			Modified:   "package main\n\nfunc demo() {}\n\nfunc extra() {}\n",
+			Confidence: core.ConfidenceScore{Score: 0.5, Level: "medium"},
+			MatchCount: 1,
+			Metadata:   map[string]any{"structural_diff": changes},
+		},
+	}
+
+	resp, err := server.FinalizeTransform(context.Background(), req)
+	if err != nil {
+		t.Fatalf("finalize transform failed: %v", err)
+	}
+	if got, ok := resp["structural_diff"].([]core.StructuralChange); !ok || len(got) != 1 {
+		t.Fatalf("expected structural_diff in response, got %#v", resp["structural_diff"])
+	}
+
+	id, _ := resp["id"].(string)
+	stage, err := server.staging.GetStage(id)
+	if err != nil {
+		t.Fatalf("failed to load stage %q: %v", id, err)
+	}
+	var recorded []core.StructuralChange
+	if err := json.Unmarshal(stage.StructuralDiff, &recorded); err != nil {
+		t.Fatalf("stage structural diff is not valid JSON: %v", err)
+	}
+	if len(recorded) != 1 || recorded[0] != changes[0] {
+		t.Fatalf("expected the stage to record %+v, got %+v", changes, recorded)
+	}
+}
+
+func TestFinalizeTransform_ComputesStructuralDiffOnlyForStages(t *testing.T) {
+	original := "package main\n\nfunc demo() {}\n"
+	req := types.TransformRequest{
+		Language:       "go",
+		Operation:      "insert_after",
+		Target:         core.AgentQuery{Type: "function", Name: "demo"},
+		OriginalSource: original,
+		Result: core.TransformResult{
+			Modified:   original + "\nfunc extra() {}\n",
+			Confidence: core.ConfidenceScore{Score: 0.5, Level: "medium"},
+			MatchCount: 1,
+		},
+	}
+
+	stateless := DefaultConfig()
+	stateless.DatabaseURL = "skip"
+	stateless.LogWriter = io.Discard
+	server, err := NewStdioServer(stateless)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	resp, err := server.FinalizeTransform(context.Background(), req)
+	if err != nil {
+		t.Fatalf("finalize transform failed: %v", err)
+	}
+	if _, ok := resp["structural_diff"]; ok {
+		t.Fatalf("expected no structural diff without staging, got %#v", resp["structural_diff"])
+	}
+
+	staging := DefaultConfig()
+	staging.DatabaseURL = filepath.Join(t.TempDir(), "morfx.db")
+	staging.LogWriter = io.Discard
+	server, err = NewStdioServer(staging)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	resp, err = server.FinalizeTransform(context.Background(), req)
+	if err != nil {
+		t.Fatalf("finalize transform failed: %v", err)
+	}
+	id, _ := resp["id"].(string)
+	stage, err := server.staging.GetStage(id)
+	if err != nil {
+		t.Fatalf("failed to load stage %q: %v", id, err)
+	}
+	var recorded []core.StructuralChange
+	if err := json.Unmarshal(stage.StructuralDiff, &recorded); err != nil {
+		t.Fatalf("stage structural diff is not valid JSON: %v", err)
+	}
+	if len(recorded) != 1 || recorded[0].Summary != "inserted function extra" {
+		t.Fatalf("expected the stage to record the inserted function, got %+v", recorded)
+	}
+}
+
+func TestFinalizeTransform_RecordsTransformOp(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config := DefaultConfig()
+	config.DatabaseURL = filepath.Join(tmpDir, "morfx.db")
+	config.AutoApplyThreshold = 0.9
+	config.LogWriter = io.Discard
+
+	server, err := NewStdioServer(config)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+
+	op := core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "function", Name: "demo"},
+		Replacement: "func demo() { run() }",
+	}
+	resp, err := server.FinalizeTransform(context.Background(), types.TransformRequest{
+		Language:       "go",
+		Operation:      "replace",
+		Target:         op.Target,
+		OriginalSource: "package main\n\nfunc demo() {}\n",
+		Result: core.TransformResult{
+			Modified:   "package main\n\nfunc demo() { run() }\n",
+			Confidence: core.ConfidenceScore{Score: 0.5, Level: "medium"},
+			MatchCount: 1,
+		},
+		Op: &op,
+	})
+	if err != nil {
+		t.Fatalf("finalize transform failed: %v", err)
+	}
+
+	id, _ := resp["id"].(string)
+	stage, err := server.staging.GetStage(id)
+	if err != nil {
+		t.Fatalf("failed to load stage %q: %v", id, err)
+	}
+	var recorded core.TransformOp
+	if err := json.Unmarshal(stage.TransformOp, &recorded); err != nil {
+		t.Fatalf("stage transform is not valid JSON: %v", err)
+	}
+	if recorded.Method != op.Method || recorded.Replacement != op.Replacement || recorded.Target.Name != "demo" {
+		t.Fatalf("expected the stage to record %+v, got %+v", op, recorded)
+	}
+}
+
+func TestFinalizeTransform_AppliesConfidencePolicy(t *testing.T) {
+	config := DefaultConfig()
+	config.DatabaseURL = "skip"
+	config.AutoApplyThreshold = 0.8
+	config.LogWriter = io.Discard
+
+	server, err := NewStdioServer(config)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	server.scorer = &core.ConfidencePolicy{Paths: []core.PathRule{{Pattern: "api/**", Impact: -0.3, Name: "public_api"}}}
+
+	apiDir := filepath.Join(t.TempDir(), "api")
+	if err := os.MkdirAll(apiDir, 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	targetFile := filepath.Join(apiDir, "handler.go")
+	original := "package api\n\nfunc greet() string { return \"world\" }\n"
+	if err := os.WriteFile(targetFile, []byte(original), 0o644); err != nil {
+		t.Fatalf("failed to write original file: %v", err)
+	}
+
+	resp, err := server.FinalizeTransform(context.Background(), types.TransformRequest{
+		Language:       "go",
+		Operation:      "replace",
+		Target:         core.AgentQuery{Type: "function", Name: "greet"},
+		Path:           targetFile,
+		OriginalSource: original,
+		Result: core.TransformResult{
+			Modified:   "package api\n\nfunc greet() string { return \"universe\" }\n",
+			Confidence: core.ConfidenceScore{Score: 0.95, Level: "high"},
+			MatchCount: 1,
+		},
+		ResponseText: "test response",
+	})
+	if err != nil {
+		t.Fatalf("finalize transform failed: %v", err)
+	}
+
+	if score, _ := resp["confidence"].(float64); score < 0.64 || score > 0.66 {
+		t.Fatalf("expected the policy to lower confidence to 0.65, got %v", resp["confidence"])
+	}
+	if status, _ := resp["result"].(string); status == "applied" {
+		t.Fatal("expected the rescored transform to fall below the auto-apply threshold")
+	}
+	content, _ := resp["content"].([]map[string]any)
+	if len(content) == 0 || !strings.Contains(content[0]["text"].(string), "Confidence policy: 0.95 → 0.65") {
+		t.Fatalf("expected the response to explain the rescoring, got %v", resp["content"])
+	}
+	if contents, _ := os.ReadFile(targetFile); string(contents) != original {
+		t.Fatalf("expected file to remain unchanged, got %s", contents)
+	}
+}
+
+func TestFinalizeTransform_TypeCheckLowersConfidence(t *testing.T) {
+	config := DefaultConfig()
+	config.DatabaseURL = "skip"
+	config.AutoApplyThreshold = 0.85
+	config.TypeCheck = true
+	config.LogWriter = io.Discard
+
+	server, err := NewStdioServer(config)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+
+	targetFile := filepath.Join(t.TempDir(), "greet.go")
+	original := "package main\n\nfunc greet() string { return \"world\" }\n"
+	if err := os.WriteFile(targetFile, []byte(original), 0o644); err != nil {
+		t.Fatalf("failed to write original file: %v", err)
+	}
+
+	resp, err := server.FinalizeTransform(context.Background(), types.TransformRequest{
+		Language:       "go",
+		Operation:      "replace",
+		Target:         core.AgentQuery{Type: "function", Name: "greet"},
+		Path:           targetFile,
+		OriginalSource: original,
+		Result: core.TransformResult{
+			Modified:   "package main\n\nfunc greet() string { return world }\n",
+			Confidence: core.ConfidenceScore{Score: 0.95, Level: "high"},
+			MatchCount: 1,
+		},
+		ResponseText: "test response",
+	})
+	if err != nil {
+		t.Fatalf("finalize transform failed: %v", err)
+	}
+
+	typeErrors, _ := resp["type_errors"].([]string)
+	if len(typeErrors) != 1 || !strings.Contains(typeErrors[0], "undefined: world") {
+		t.Fatalf("expected the undefined identifier to be reported, got %#v", resp["type_errors"])
+	}
+	if score, _ := resp["confidence"].(float64); score > 0.56 {
+		t.Fatalf("expected type errors to lower confidence, got %v", resp["confidence"])
+	}
+	if status, _ := resp["result"].(string); status == "applied" {
+		t.Fatal("expected an uncompilable edit not to be auto-applied")
+	}
+	if contents, _ := os.ReadFile(targetFile); string(contents) != original {
+		t.Fatalf("expected file to remain unchanged, got %s", contents)
+	}
+}
+
+func TestFinalizeTransform_DanglingReferencesBlockAutoApply(t *testing.T) {
+	config := DefaultConfig()
+	config.DatabaseURL = "skip"
+	config.AutoApplyThreshold = 0.85
+	config.LogWriter = io.Discard
+
+	server, err := NewStdioServer(config)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+
+	dir := t.TempDir()
+	targetFile := filepath.Join(dir, "helper.go")
+	original := "package main\n\nfunc helper() int { return 1 }\n"
+	files := map[string]string{
+		"go.mod":    "module example.com/app\n",
+		"helper.go": original,
+		"main.go":   "package main\n\nfunc main() { _ = helper() }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	provider, ok := server.providers.Get("go")
+	if !ok {
+		t.Fatal("go provider not registered")
+	}
+	target := core.AgentQuery{Type: "function", Name: "helper"}
+	result := provider.Transform(original, core.TransformOp{Method: "delete", Target: target})
+	if result.Error != nil {
+		t.Fatalf("delete failed: %v", result.Error)
+	}
+
+	resp, err := server.FinalizeTransform(context.Background(), types.TransformRequest{
+		Language:       "go",
+		Operation:      "delete",
+		Target:         target,
+		Path:           targetFile,
+		OriginalSource: original,
+		Result:         result,
+		ResponseText:   "test response",
+	})
+	if err != nil {
+		t.Fatalf("finalize transform failed: %v", err)
+	}
+
+	references, _ := resp["dangling_references"].([]core.DanglingReference)
+	if len(references) != 1 || filepath.Base(references[0].FilePath) != "main.go" || references[0].Line != 3 {
+		t.Fatalf("expected the call in main.go to be reported, got %#v", resp["dangling_references"])
+	}
+	if status, _ := resp["result"].(string); status == "applied" {
+		t.Fatal("expected a delete with remaining callers not to be auto-applied")
+	}
+	if contents, _ := os.ReadFile(targetFile); string(contents) != original {
+		t.Fatalf("expected file to remain unchanged, got %s", contents)
+	}
+}
@@ -20,6 +20,7 @@ type ServerInterface interface {
 	GetStaging() any
 	GetSafety() any
 	GetSessionID() string
+	CheckVerify(verify *core.VerifyCommand) error
 	ReportProgress(ctx context.Context, progress, total float64, message string)
 	ConfirmApply(ctx context.Context, summary string) error
 	RequestSampling(ctx context.Context, params map[string]any) (map[string]any, error)
@@ -34,6 +35,43 @@ type StagingStore interface {
 	ApplyStage(ctx context.Context, stageID string, autoApplied bool) (*models.Apply, error)
 }
 
+// StagingVerifier is implemented by staging managers that can run a verify
+// command after applying a stage and roll the write back when it fails.
+type StagingVerifier interface {
+	ApplyStageVerified(ctx context.Context, stageID string, verify *core.VerifyCommand) (*models.Apply, *core.VerifyResult, error)
+}
+
+// StagingHunkApplier is implemented by staging managers that can apply some
+// hunks of a stage and keep the rest as a new pending stage, which is
+// returned when any hunks remain.
+type StagingHunkApplier interface {
+	ApplyStageHunks(ctx context.Context, stageID string, hunks []int, verify *core.VerifyCommand) (*models.Apply, *models.Stage, *core.VerifyResult, error)
+}
+
+// StagingReverter is implemented by staging managers that can undo applied
+// stages.
+type StagingReverter interface {
+	RevertStage(ctx context.Context, stageID, revertedBy string) (*StageRevert, error)
+	ListRevertibleStages(sessionID string, limit int) ([]models.Stage, error)
+}
+
+// StageRevert describes a reverted stage. Merged is set when the file had
+// changed since the apply and the stage's edit was undone by a 3-way merge.
+type StageRevert struct {
+	StageID  string `json:"stage_id"`
+	ApplyID  string `json:"apply_id"`
+	FilePath string `json:"file_path"`
+	Merged   bool   `json:"merged"`
+}
+
+// StagingChangesets is implemented by staging managers that stage the files
+// of a multi-file operation as one changeset and apply them all or none.
+type StagingChangesets interface {
+	CreateChangeset(ctx context.Context, sessionID, operation, description string, changes []core.FileChange) (*models.Changeset, error)
+	GetChangeset(changesetID string) (*models.Changeset, error)
+	ApplyChangeset(ctx context.Context, changesetID string, verify *core.VerifyCommand) (*models.Changeset, *core.VerifyResult, error)
+}
+
 // StagingToggle allows staged operations to advertise whether they are active.
 type StagingToggle interface {
 	IsEnabled() bool
@@ -72,6 +110,9 @@ type TransformRequest struct {
 	Result         core.TransformResult `json:"result"`
 	ResponseText   string               `json:"response_text"`
 	Content        string               `json:"content,omitempty"`
+	// Op is the transform that produced Result; staging keeps it so a stale
+	// stage can be rebased by re-running it
+	Op *core.TransformOp `json:"op,omitempty"`
 }
 
 // PromptArgument represents an argument for a prompt
@@ -8,8 +8,9 @@ import (
 
 // Stage represents a pending code transformation
 type Stage struct {
-	ID        string `gorm:"primaryKey;type:varchar(20)"`
-	SessionID string `gorm:"type:varchar(20);index"`
+	ID          string `gorm:"primaryKey;type:varchar(20)"`
+	SessionID   string `gorm:"type:varchar(20);index"`
+	ChangesetID string `gorm:"type:varchar(20);index"` // set when the stage is one file of a changeset
 
 	// Operation details
 	Language  string `gorm:"type:varchar(50);not null"`
@@ -20,12 +21,19 @@ type Stage struct {
 	TargetName  string         `gorm:"type:varchar(255)"` // name pattern
 	TargetQuery datatypes.JSON `gorm:"type:jsonb"`        // full query object
 
+	// Transform that produced Modified (core.TransformOp), re-run against the
+	// file's current content when it changed after staging
+	TransformOp datatypes.JSON `gorm:"type:jsonb"`
+
 	// Content
 	Original string `gorm:"type:text"`
 	Modified string `gorm:"type:text"`
 	Content  string `gorm:"type:text"` // For insert operations
 	Diff     string `gorm:"type:text"`
 
+	// Node-level edits (core.StructuralChange) for reviewers
+	StructuralDiff datatypes.JSON `gorm:"type:jsonb"`
+
 	// Checksums for validation
 	BaseDigest  string `gorm:"type:varchar(64)"` // SHA256 of original
 	AfterDigest string `gorm:"type:varchar(64)"` // SHA256 of modified
@@ -38,8 +46,12 @@ type Stage struct {
 	// Scope AST for advanced operations
 	ScopeAST datatypes.JSON `gorm:"type:jsonb"`
 
+	// Outcome of the verify command run when the stage was applied
+	// (core.VerifyResult), kept on failure with the captured output
+	Verification datatypes.JSON `gorm:"type:jsonb"`
+
 	// Status tracking
-	Status    string    `gorm:"type:varchar(20);default:'pending'"`
+	Status    string    `gorm:"type:varchar(20);default:'pending'"` // pending, applied, expired, failed, or reverted
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	ExpiresAt time.Time `gorm:"index"`
 	AppliedAt *time.Time
@@ -62,6 +74,10 @@ type Apply struct {
 	AppliedBy   string    `gorm:"type:varchar(100)"` // User or "auto"
 	AppliedAt   time.Time `gorm:"autoCreateTime"`
 
+	// How the stage was rebased when its file changed after staging:
+	// "transform" (re-run) or "merge" (3-way), empty when it was not stale
+	Rebased string `gorm:"type:varchar(20)"`
+
 	// Revert tracking
 	Reverted   bool   `gorm:"default:false"`
 	RevertedBy string `gorm:"type:varchar(100)"`
@@ -71,6 +87,37 @@ type Apply struct {
 	Stage Stage `gorm:"foreignKey:StageID"`
 }
 
+// Changeset groups the per-file stages of one multi-file operation, such as
+// file_replace or a recipe, so they are applied together or not at all
+type Changeset struct {
+	ID        string `gorm:"primaryKey;type:varchar(20)"`
+	SessionID string `gorm:"type:varchar(20);index"`
+
+	// Operation details
+	Operation   string `gorm:"type:varchar(50);not null"` // file_replace, file_delete, recipe
+	Description string `gorm:"type:text"`
+
+	// File path to SHA256 of its original content, checked before applying
+	Digests datatypes.JSON `gorm:"type:jsonb"`
+
+	// Confidence scoring, from the least confident file
+	ConfidenceScore float64 `gorm:"type:decimal(3,2)"`
+	ConfidenceLevel string  `gorm:"type:varchar(10)"`
+
+	// Outcome of the verify command run when the changeset was applied
+	Verification datatypes.JSON `gorm:"type:jsonb"`
+
+	// Status tracking
+	Status    string    `gorm:"type:varchar(20);default:'pending'"` // pending, applied, expired, or failed
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	ExpiresAt time.Time `gorm:"index"`
+	AppliedAt *time.Time
+
+	// Stages matched by Stage.ChangesetID; not a foreign key, so stages
+	// outside any changeset keep an empty ChangesetID
+	Stages []Stage `gorm:"-"`
+}
+
 // Session tracks a complete Morfx transformation session
 type Session struct {
 	ID        string    `gorm:"primaryKey;type:varchar(20)"`
@@ -86,6 +133,7 @@ type Session struct {
 }
 
 // TableName customizations for cleaner names
-func (Stage) TableName() string   { return "stages" }
-func (Apply) TableName() string   { return "applies" }
-func (Session) TableName() string { return "sessions" }
+func (Stage) TableName() string     { return "stages" }
+func (Apply) TableName() string     { return "applies" }
+func (Changeset) TableName() string { return "changesets" }
+func (Session) TableName() string   { return "sessions" }
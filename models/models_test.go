@@ -23,6 +23,11 @@ func TestApplyTableName(t *testing.T) {
 	assert.Equal(t, "applies", apply.TableName())
 }
 
+func TestChangesetTableName(t *testing.T) {
+	changeset := Changeset{}
+	assert.Equal(t, "changesets", changeset.TableName())
+}
+
 func TestSessionTableName(t *testing.T) {
 	session := Session{}
 	assert.Equal(t, "sessions", session.TableName())
@@ -800,7 +805,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	require.NoError(t, err)
 
 	// Run migrations
-	err = db.AutoMigrate(&Stage{}, &Apply{}, &Session{})
+	err = db.AutoMigrate(&Stage{}, &Apply{}, &Changeset{}, &Session{})
 	require.NoError(t, err)
 
 	return db
@@ -0,0 +1,219 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// SnippetPrefixConfig lets language configs name the text a snippet needs in
+// front of it to parse as code, such as PHP's opening tag.
+type SnippetPrefixConfig interface {
+	SnippetPrefix() string
+}
+
+// insertsContent reports whether method adds op.Content next to or inside
+// its targets, so that if_absent applies.
+func insertsContent(method string) bool {
+	return method == "insert_before" || method == "insert_after" || method == "append"
+}
+
+// contentTokens parses content on its own and returns its normalized tokens.
+func (p *Provider) contentTokens(content string) []string {
+	prefix := ""
+	if config, ok := p.config.(SnippetPrefixConfig); ok {
+		prefix = config.SnippetPrefix()
+	}
+	snippet := []byte(prefix + content)
+	root, err := sitter.ParseCtx(context.Background(), snippet, p.config.GetLanguage())
+	if err != nil || root == nil {
+		return nil
+	}
+	var tokens []string
+	collectTokens(root, string(snippet), uint32(len(prefix)), &tokens)
+	return tokens
+}
+
+// collectTokens appends the tokens of node that start at or after offset.
+// Comments, blank tokens, and statement semicolons are dropped, and string
+// literals are kept whole, so formatting and comments do not affect equality.
+func collectTokens(node *sitter.Node, source string, offset uint32, tokens *[]string) {
+	if node == nil || node.EndByte() <= offset || strings.Contains(node.Type(), "comment") {
+		return
+	}
+	if node.ChildCount() == 0 || (node.IsNamed() && strings.Contains(node.Type(), "string")) {
+		if node.StartByte() < offset {
+			return
+		}
+		text := strings.TrimSpace(node.Content([]byte(source)))
+		if text != "" && text != ";" {
+			*tokens = append(*tokens, text)
+		}
+		return
+	}
+	for i := 0; i < int(node.ChildCount()); i++ {
+		collectTokens(node.Child(i), source, offset, tokens)
+	}
+}
+
+func nodeTokens(node *sitter.Node, source string) []string {
+	var tokens []string
+	collectTokens(node, source, 0, &tokens)
+	return tokens
+}
+
+// hasTokenRun reports whether consecutive nodes from children[from:to] spell
+// want. Runs must start at from when anchorStart is set and end at to when
+// anchorEnd is set.
+func hasTokenRun(children []*sitter.Node, source string, want []string, from, to int, anchorStart, anchorEnd bool) bool {
+	if len(want) == 0 {
+		return false
+	}
+	tokens := make([][]string, len(children))
+	for i := from; i < to; i++ {
+		tokens[i] = nodeTokens(children[i], source)
+	}
+	for start := from; start < to; start++ {
+		if anchorStart && start > from {
+			break
+		}
+		var run []string
+		for end := start; end < to; end++ {
+			run = append(run, tokens[end]...)
+			if len(run) > len(want) {
+				break
+			}
+			if len(run) == len(want) && slices.Equal(run, want) && (!anchorEnd || onlyBlankAfter(tokens, end+1, to)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func onlyBlankAfter(tokens [][]string, from, to int) bool {
+	for i := from; i < to; i++ {
+		if len(tokens[i]) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func nodeChildren(node *sitter.Node) []*sitter.Node {
+	children := make([]*sitter.Node, 0, node.ChildCount())
+	for i := 0; i < int(node.ChildCount()); i++ {
+		children = append(children, node.Child(i))
+	}
+	return children
+}
+
+// contentPresent reports whether want already sits where method would put
+// it: right before target, including leading children such as attributes
+// and decorators; right after it; or, for append, anywhere inside it or right
+// after it, where appends without a smart placement land.
+func contentPresent(method string, target *sitter.Node, source string, want []string) bool {
+	if target == nil {
+		return false
+	}
+	if method == "append" && containsTokenRun(target, source, want) {
+		return true
+	}
+	if method == "insert_before" {
+		children := nodeChildren(target)
+		if hasTokenRun(children, source, want, 0, len(children)-1, true, false) {
+			return true
+		}
+	}
+
+	// Inserted text becomes a sibling of the outermost node sharing the edge
+	// it was inserted at, such as a Go type_declaration around its type_spec.
+	node := target
+	for parent := node.Parent(); parent != nil && parent.Parent() != nil; parent = node.Parent() {
+		if (method == "insert_before" && parent.StartByte() != node.StartByte()) ||
+			(method != "insert_before" && parent.EndByte() != node.EndByte()) {
+			break
+		}
+		node = parent
+	}
+	parent := node.Parent()
+	if parent == nil {
+		return false
+	}
+	siblings := nodeChildren(parent)
+	index := slices.IndexFunc(siblings, func(sibling *sitter.Node) bool {
+		return sibling.StartByte() == node.StartByte() && sibling.EndByte() == node.EndByte() && sibling.Type() == node.Type()
+	})
+	if index < 0 {
+		return false
+	}
+	if method == "insert_before" {
+		return hasTokenRun(siblings, source, want, 0, index, false, true)
+	}
+	return hasTokenRun(siblings, source, want, index+1, len(siblings), true, false)
+}
+
+// containsTokenRun reports whether any node inside root has consecutive
+// children spelling want.
+func containsTokenRun(root *sitter.Node, source string, want []string) bool {
+	if root.ChildCount() == 0 {
+		return false
+	}
+	children := nodeChildren(root)
+	if hasTokenRun(children, source, want, 0, len(children), false, false) {
+		return true
+	}
+	for _, child := range children {
+		if containsTokenRun(child, source, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropPresentTargets removes the targets that already have op.Content at the
+// insertion point.
+func (p *Provider) dropPresentTargets(source string, targets []Target, op core.TransformOp) ([]Target, int) {
+	want := p.contentTokens(op.Content)
+	if len(want) == 0 {
+		return targets, 0
+	}
+	remaining := make([]Target, 0, len(targets))
+	for _, target := range targets {
+		if contentPresent(op.Method, target.Node, source, want) {
+			continue
+		}
+		remaining = append(remaining, target)
+	}
+	return remaining, len(targets) - len(remaining)
+}
+
+// alreadyPresentResult is the no-op result of an if_absent insertion whose
+// content exists at every destination.
+func alreadyPresentResult(source string, skipped int) core.TransformResult {
+	return core.TransformResult{
+		Modified: source,
+		Confidence: core.ConfidenceScore{
+			Score: 1.0,
+			Level: "high",
+			Factors: []core.ConfidenceFactor{{
+				Name:   "already_present",
+				Impact: 0.0,
+				Reason: fmt.Sprintf("Equivalent content already present at %d of %d targets, nothing inserted", skipped, skipped),
+			}},
+		},
+	}
+}
+
+func presentFactor(skipped int) core.ConfidenceFactor {
+	return core.ConfidenceFactor{
+		Name:   "already_present",
+		Impact: 0.0,
+		Reason: fmt.Sprintf("Skipped %d targets that already contain equivalent content", skipped),
+	}
+}
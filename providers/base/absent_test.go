@@ -0,0 +1,80 @@
+package base
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformIfAbsentSkipsEquivalentContent(t *testing.T) {
+	provider := newTestProvider()
+	source := "package main\n\nfunc A() {}\n\n// B is already here, formatted differently.\nfunc B()  {\n\treturn\n}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:   "insert_after",
+		Target:   core.AgentQuery{Type: "function", Name: "A"},
+		Content:  "func B() { return }",
+		IfAbsent: true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if result.Modified != source || result.Diff != "" || result.MatchCount != 0 {
+		t.Fatalf("expected no-op, got match count %d:\n%s", result.MatchCount, result.Modified)
+	}
+	if result.Confidence.Score != 1.0 || result.Confidence.Factors[0].Name != "already_present" {
+		t.Fatalf("unexpected confidence %+v", result.Confidence)
+	}
+}
+
+func TestTransformIfAbsentInsertsDifferentContent(t *testing.T) {
+	provider := newTestProvider()
+	source := "package main\n\nfunc A() {}\n\nfunc B() {}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:   "insert_after",
+		Target:   core.AgentQuery{Type: "function", Name: "A"},
+		Content:  "func B() { return }",
+		IfAbsent: true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if strings.Count(result.Modified, "func B()") != 2 {
+		t.Fatalf("expected content with a different body to be inserted:\n%s", result.Modified)
+	}
+}
+
+func TestTransformIfAbsentIsIdempotent(t *testing.T) {
+	provider := newTestProvider()
+	source := "package main\n\ntype Config struct {\n\tHost string\n}\n\nfunc Run() {}\n"
+
+	cases := []core.TransformOp{
+		{Method: "insert_before", Target: core.AgentQuery{Type: "function", Name: "Run"}, Content: "// Run runs.\nvar ready = true"},
+		{Method: "insert_after", Target: core.AgentQuery{Type: "function", Name: "Run"}, Content: "func Stop() {}"},
+		{Method: "append", Content: "func Tail() {}"},
+	}
+	for _, op := range cases {
+		op.IfAbsent = true
+		first := provider.Transform(source, op)
+		if first.Error != nil || first.Modified == source {
+			t.Fatalf("%s: expected first run to insert, got %v", op.Method, first.Error)
+		}
+		second := provider.Transform(first.Modified, op)
+		if second.Error != nil || second.Modified != first.Modified {
+			t.Fatalf("%s: expected second run to be a no-op, got %v:\n%s", op.Method, second.Error, second.Modified)
+		}
+	}
+}
+
+func TestTransformWithoutIfAbsentDuplicates(t *testing.T) {
+	provider := newTestProvider()
+	op := core.TransformOp{Method: "append", Content: "func Tail() {}"}
+
+	first := provider.Transform("package main\n", op)
+	second := provider.Transform(first.Modified, op)
+	if strings.Count(second.Modified, "func Tail()") != 2 {
+		t.Fatalf("expected plain append to keep duplicating:\n%s", second.Modified)
+	}
+}
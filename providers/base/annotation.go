@@ -0,0 +1,335 @@
+package base
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// Edit replaces source[Start:End] with Text.
+type Edit struct {
+	Start, End uint32
+	Text       string
+}
+
+// AnnotationConfig lets a language config manage annotations that are not
+// decorator nodes, such as Go struct tags. It receives every target of
+// add_annotation, remove_annotation, and the struct tag methods add_tag,
+// remove_tag, and rewrite_tag, so it can keep edits to one declaration
+// together. Annotation templates are expanded with AnnotationVars.
+type AnnotationConfig interface {
+	EditAnnotations(source string, targets []Target, op core.TransformOp) (string, map[string]any, error)
+}
+
+// AnnotationVars returns the placeholder values an annotation template sees
+// for target: its name and any DSL captures.
+func AnnotationVars(target Target) map[string]string {
+	vars := map[string]string{"name": target.Name}
+	maps.Copy(vars, target.Captures)
+	return vars
+}
+
+// doAddAnnotation attaches op.Annotation to every target. ${name} and DSL
+// captures in the annotation are expanded per target, so
+// json:"${name|snake}" gives each field its own tag.
+func (p *Provider) doAddAnnotation(source string, targets []Target, op core.TransformOp) (string, map[string]any, error) {
+	if strings.TrimSpace(op.Annotation) == "" {
+		return source, nil, fmt.Errorf("annotation is required")
+	}
+	if config, ok := p.config.(AnnotationConfig); ok {
+		return config.EditAnnotations(source, targets, op)
+	}
+
+	var edits []Edit
+	skipped := 0
+	for _, target := range targets {
+		annotation, err := core.ExpandTemplate(op.Annotation, AnnotationVars(target))
+		if err != nil {
+			return source, nil, err
+		}
+		targetEdits := addDecorator(source, target, annotation, op.IfAbsent, p.matchesPattern)
+		if len(targetEdits) == 0 {
+			skipped++
+		}
+		edits = append(edits, targetEdits...)
+	}
+
+	modified, err := ApplyEdits(source, edits)
+	if err != nil {
+		return source, nil, err
+	}
+	return modified, map[string]any{"annotations_added": len(targets) - skipped, "annotations_skipped": skipped}, nil
+}
+
+// doEditTags runs the struct tag methods, which only languages with an
+// AnnotationConfig support.
+func (p *Provider) doEditTags(source string, targets []Target, op core.TransformOp) (string, map[string]any, error) {
+	config, ok := p.config.(AnnotationConfig)
+	if !ok {
+		return source, nil, fmt.Errorf("%s is not supported for %s", op.Method, p.config.Language())
+	}
+	if strings.TrimSpace(op.Annotation) == "" {
+		return source, nil, fmt.Errorf("annotation is required")
+	}
+	if op.Method == "rewrite_tag" && strings.TrimSpace(op.TagKey) == "" {
+		return source, nil, fmt.Errorf("tag_key is required")
+	}
+	return config.EditAnnotations(source, targets, op)
+}
+
+// skippedFieldsFactor lowers confidence for a tag edit that left some of
+// its fields alone, since the result is not the whole edit asked for.
+func skippedFieldsFactor(skipped []string) core.ConfidenceFactor {
+	return core.ConfidenceFactor{
+		Name:   "fields_skipped",
+		Impact: -0.1,
+		Reason: fmt.Sprintf("Skipped %d fields: %s", len(skipped), strings.Join(skipped, "; ")),
+	}
+}
+
+// doRemoveAnnotations removes the annotations named by op.Annotation from
+// every target. A target that is itself a decorator or attribute, as
+// selected by decorator:deprecated, is removed when the name is omitted.
+func (p *Provider) doRemoveAnnotations(source string, targets []Target, op core.TransformOp) (string, map[string]any, error) {
+	pattern := strings.TrimSpace(op.Annotation)
+	if config, ok := p.config.(AnnotationConfig); ok {
+		if pattern == "" {
+			return source, nil, fmt.Errorf("annotation is required")
+		}
+		return config.EditAnnotations(source, targets, op)
+	}
+
+	var edits []Edit
+	for _, target := range targets {
+		var items []*sitter.Node
+		if target.Node != nil && isAnnotationItem(target.Node) {
+			items = []*sitter.Node{target.Node}
+		} else if pattern == "" {
+			return source, nil, fmt.Errorf("annotation is required unless the target is a decorator or attribute")
+		} else {
+			items = annotationItems(source, annotationHost(source, target.Node))
+		}
+		var matched []*sitter.Node
+		for _, item := range items {
+			if pattern == "" || p.matchesPattern(annotationName(item.Content([]byte(source))), annotationName(pattern)) {
+				matched = append(matched, item)
+			}
+		}
+		edits = append(edits, removeAnnotationItems(source, matched)...)
+	}
+	if len(edits) == 0 {
+		return source, nil, fmt.Errorf("%w: no annotation matches %q", core.ErrNoMatchesFound, pattern)
+	}
+
+	modified, err := ApplyEdits(source, edits)
+	if err != nil {
+		return source, nil, err
+	}
+	return modified, map[string]any{"annotations_removed": len(edits)}, nil
+}
+
+// addDecorator inserts annotation after the decorators already on the
+// target, on its own line when the declaration starts a line and inline
+// otherwise.
+func addDecorator(source string, target Target, annotation string, ifAbsent bool, match func(name, pattern string) bool) []Edit {
+	if target.Node == nil {
+		return nil
+	}
+	host := annotationHost(source, target.Node)
+	if ifAbsent {
+		name := annotationName(annotation)
+		for _, item := range annotationItems(source, host) {
+			if match(annotationName(item.Content([]byte(source))), name) {
+				return nil
+			}
+		}
+	}
+
+	anchor := annotationAnchor(source, host)
+	prefix := source[lineStartOffset(source, int(anchor)):anchor]
+	text := strings.TrimSpace(annotation) + " "
+	if strings.TrimSpace(prefix) == "" {
+		text = strings.TrimSpace(annotation) + "\n" + prefix
+	}
+	return []Edit{{Start: anchor, End: anchor, Text: text}}
+}
+
+// annotationHost is the node whose decorators a target carries: the whole
+// declaration, including wrappers such as Python's decorated_definition.
+func annotationHost(source string, node *sitter.Node) *sitter.Node {
+	return declarationWrapper(source, enclosingDeclaration(node))
+}
+
+// annotationAnchor returns where a new annotation goes: after the
+// decorators that lead host and before its first keyword or name.
+func annotationAnchor(source string, host *sitter.Node) uint32 {
+	for i := 0; i < int(host.ChildCount()); i++ {
+		child := host.Child(i)
+		if decoratorTypes[child.Type()] || strings.Contains(child.Type(), "comment") || blankToken(source, child) {
+			continue
+		}
+		return child.StartByte()
+	}
+	return host.StartByte()
+}
+
+// annotationItems returns the decorators and PHP attributes that belong to
+// host, whether the grammar nests them inside the declaration or places
+// them before it as siblings.
+func annotationItems(source string, host *sitter.Node) []*sitter.Node {
+	start, end := attachedStart(source, host), annotationAnchor(source, host)
+	scope := host
+	if host.Parent() != nil {
+		scope = host.Parent()
+	}
+
+	var items []*sitter.Node
+	var walk func(*sitter.Node)
+	walk = func(node *sitter.Node) {
+		if node.EndByte() <= start || node.StartByte() >= end {
+			return
+		}
+		if isAnnotationItem(node) {
+			items = append(items, node)
+			return
+		}
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			walk(node.NamedChild(i))
+		}
+	}
+	walk(scope)
+	return items
+}
+
+func isAnnotationItem(node *sitter.Node) bool {
+	if node.Type() == "decorator" {
+		return true
+	}
+	return node.Type() == "attribute" && node.Parent() != nil && node.Parent().Type() == "attribute_group"
+}
+
+// annotationName reduces an annotation to the name it is matched by:
+// "@app.route('/')" is app.route and "#[Route('/x')]" is Route.
+func annotationName(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "@")
+	text = strings.TrimPrefix(text, "#[")
+	if i := strings.IndexAny(text, "(]"); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
+
+// removeAnnotationItems returns the edits that drop items. A PHP attribute
+// takes its comma along unless every attribute in its #[...] group goes, in
+// which case the group goes as a whole. Annotations that sit on their own
+// line take the line with them.
+func removeAnnotationItems(source string, items []*sitter.Node) []Edit {
+	var edits []Edit
+	groups := make(map[[2]uint32][]*sitter.Node)
+	var order []*sitter.Node
+	for _, item := range items {
+		if item.Type() != "attribute" {
+			edits = append(edits, removeLine(source, item.StartByte(), item.EndByte()))
+			continue
+		}
+		group := item.Parent()
+		key := [2]uint32{group.StartByte(), group.EndByte()}
+		if _, ok := groups[key]; !ok {
+			order = append(order, group)
+		}
+		groups[key] = append(groups[key], item)
+	}
+
+	for _, group := range order {
+		removed := groups[[2]uint32{group.StartByte(), group.EndByte()}]
+		total := 0
+		for i := 0; i < int(group.NamedChildCount()); i++ {
+			if group.NamedChild(i).Type() == "attribute" {
+				total++
+			}
+		}
+		if len(removed) == total {
+			edits = append(edits, removeLine(source, group.StartByte(), group.EndByte()))
+			continue
+		}
+		for _, item := range removed {
+			start, end := item.StartByte(), item.EndByte()
+			if next := item.NextSibling(); next != nil && next.Type() == "," {
+				end = next.EndByte()
+				for int(end) < len(source) && source[end] == ' ' {
+					end++
+				}
+			} else if prev := item.PrevSibling(); prev != nil && prev.Type() == "," {
+				start = prev.StartByte()
+			}
+			edits = append(edits, Edit{Start: start, End: end})
+		}
+	}
+	return edits
+}
+
+// removeLine deletes [start, end), widened to the whole line when nothing
+// else is on it, or over the spaces after it when it shares the line.
+func removeLine(source string, start, end uint32) Edit {
+	if lineStart, lineEnd, ok := wholeLines(source, start, end); ok {
+		return Edit{Start: lineStart, End: lineEnd}
+	}
+	lineEnd := end
+	for int(lineEnd) < len(source) && (source[lineEnd] == ' ' || source[lineEnd] == '\t') {
+		lineEnd++
+	}
+	return Edit{Start: start, End: lineEnd}
+}
+
+// wholeLines widens [start, end) over the lines it sits on, their newline
+// included, and reports whether nothing else is on them.
+func wholeLines(source string, start, end uint32) (uint32, uint32, bool) {
+	lineStart := uint32(lineStartOffset(source, int(start)))
+	lineEnd := end
+	for int(lineEnd) < len(source) && (source[lineEnd] == ' ' || source[lineEnd] == '\t' || source[lineEnd] == '\r') {
+		lineEnd++
+	}
+	if strings.TrimSpace(source[lineStart:start]) != "" || (int(lineEnd) < len(source) && source[lineEnd] != '\n') {
+		return start, end, false
+	}
+	if int(lineEnd) < len(source) {
+		lineEnd++
+	}
+	return lineStart, lineEnd, true
+}
+
+// ApplyEdits applies non-overlapping edits to source. Duplicate edits, as
+// produced when two targets resolve to one node, are applied once; inserts
+// at the same offset keep their order.
+func ApplyEdits(source string, edits []Edit) (string, error) {
+	sorted := make([]Edit, 0, len(edits))
+	seen := make(map[Edit]bool)
+	for _, edit := range edits {
+		if !seen[edit] {
+			seen[edit] = true
+			sorted = append(sorted, edit)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Start < sorted[i-1].End {
+			return source, fmt.Errorf("conflicting edits at bytes %d and %d", sorted[i-1].Start, sorted[i].Start)
+		}
+	}
+
+	var out strings.Builder
+	last := uint32(0)
+	for _, edit := range sorted {
+		out.WriteString(source[last:edit.Start])
+		out.WriteString(edit.Text)
+		last = edit.End
+	}
+	out.WriteString(source[last:])
+	return out.String(), nil
+}
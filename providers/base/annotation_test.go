@@ -0,0 +1,100 @@
+package base
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestAnnotationName(t *testing.T) {
+	cases := map[string]string{
+		"@deprecated":        "deprecated",
+		"@app.route('/')":    "app.route",
+		"#[Route('/x')]":     "Route",
+		"#[Deprecated]":      "Deprecated",
+		"Route('/x', 'GET')": "Route",
+		"  @Input()  ":       "Input",
+	}
+	for text, want := range cases {
+		if got := annotationName(text); got != want {
+			t.Errorf("annotationName(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestApplyEditsDedupesAndRejectsConflicts(t *testing.T) {
+	source := "abcdef"
+	got, err := ApplyEdits(source, []Edit{
+		{Start: 4, End: 5, Text: "E"},
+		{Start: 1, End: 1, Text: "+"},
+		{Start: 1, End: 1, Text: "+"},
+		{Start: 1, End: 1, Text: "!"},
+	})
+	if err != nil {
+		t.Fatalf("applyEdits returned error: %v", err)
+	}
+	if got != "a+!bcdEf" {
+		t.Fatalf("unexpected result %q", got)
+	}
+
+	if _, err := ApplyEdits(source, []Edit{{Start: 1, End: 4}, {Start: 3, End: 5}}); err == nil {
+		t.Fatal("expected overlapping edits to fail")
+	}
+}
+
+func TestRemoveLineTakesWholeLines(t *testing.T) {
+	source := "x\n    @dec\n    def f(): pass\n"
+	start := uint32(strings.Index(source, "@dec"))
+	edit := removeLine(source, start, start+4)
+	if got := source[:edit.Start] + source[edit.End:]; got != "x\n    def f(): pass\n" {
+		t.Fatalf("unexpected result %q", got)
+	}
+
+	inline := "@Input() name: string"
+	edit = removeLine(inline, 0, 8)
+	if got := inline[:edit.Start] + inline[edit.End:]; got != "name: string" {
+		t.Fatalf("unexpected inline result %q", got)
+	}
+}
+
+func TestTransformAddAnnotationRequiresText(t *testing.T) {
+	provider := newTestProvider()
+
+	result := provider.Transform("package main\n\nfunc Foo() {}\n", core.TransformOp{
+		Method: "add_annotation",
+		Target: core.AgentQuery{Type: "function", Name: "Foo"},
+	})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "annotation is required") {
+		t.Fatalf("expected annotation is required, got %v", result.Error)
+	}
+}
+
+func TestTransformAddAnnotationExpandsName(t *testing.T) {
+	provider := newTestProvider()
+
+	result := provider.Transform("package main\n\nfunc FooBar() {}\n", core.TransformOp{
+		Method:     "add_annotation",
+		Target:     core.AgentQuery{Type: "function", Name: "FooBar"},
+		Annotation: "//go:generate ${name|kebab}",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "//go:generate foo-bar\nfunc FooBar() {}") {
+		t.Fatalf("expected the expanded annotation before the function:\n%s", result.Modified)
+	}
+}
+
+func TestTransformEditTagsRequiresAnnotationConfig(t *testing.T) {
+	provider := newTestProvider()
+
+	result := provider.Transform("package main\n\nfunc Foo() {}\n", core.TransformOp{
+		Method:     "add_tag",
+		Target:     core.AgentQuery{Type: "function", Name: "Foo"},
+		Annotation: `json:"foo"`,
+	})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "add_tag is not supported") {
+		t.Fatalf("expected add_tag to be unsupported, got %v", result.Error)
+	}
+}
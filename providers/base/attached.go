@@ -0,0 +1,187 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Attached policies decide whether replace and delete also cover the doc
+// comments, decorators, and trailing comment that belong to a target.
+const (
+	AttachedInclude = "include"
+	AttachedExclude = "exclude"
+)
+
+// decoratorTypes are leading nodes that annotate the declaration after them.
+var decoratorTypes = map[string]bool{
+	"decorator":      true, // Python, TypeScript, JavaScript
+	"attribute_list": true, // PHP
+}
+
+// includesAttached resolves the attached policy for method. Deletes take
+// their comments and decorators along by default so nothing is left
+// dangling; replacements keep them unless asked.
+func includesAttached(method, policy string) (bool, error) {
+	switch policy {
+	case AttachedInclude:
+		return true, nil
+	case AttachedExclude:
+		return false, nil
+	case "":
+		return method == "delete", nil
+	default:
+		return false, fmt.Errorf("unknown attached policy %q (want include or exclude)", policy)
+	}
+}
+
+// withAttached widens each declaration target over its leading doc comments
+// and decorators and a comment trailing it on the same line. Expressions and
+// statements keep their own range.
+func withAttached(source string, targets []Target) []Target {
+	widened := make([]Target, len(targets))
+	for i, target := range targets {
+		if target.Node != nil && isDeclaration(target.Node.Type()) {
+			node := declarationWrapper(source, target.Node)
+			target.StartByte = attachedStart(source, node)
+			target.EndByte = attachedEnd(source, node)
+		}
+		widened[i] = target
+	}
+	return widened
+}
+
+// withDecorators widens each declaration target over the decorators
+// directly in front of it, leaving its comments alone. A replacement that
+// brings its own decorators uses it so the old ones are not kept as well.
+func withDecorators(source string, targets []Target) []Target {
+	widened := make([]Target, len(targets))
+	for i, target := range targets {
+		if target.Node != nil && isDeclaration(target.Node.Type()) {
+			target.StartByte = leadingStart(source, target.Node, decorates)
+		}
+		widened[i] = target
+	}
+	return widened
+}
+
+// isDeclaration reports whether nodeType declares something that can carry
+// doc comments and decorators, such as a function, class, field, or type.
+func isDeclaration(nodeType string) bool {
+	return strings.Contains(nodeType, "declaration") || strings.Contains(nodeType, "definition") ||
+		strings.HasSuffix(nodeType, "_spec") || strings.HasSuffix(nodeType, "_signature") ||
+		nodeType == "export_statement"
+}
+
+// declarationWrapper climbs to parents that only add keywords, modifiers,
+// or a closing semicolon around node, such as a Go type_declaration around
+// its single type_spec, a TypeScript export_statement, or a PHP
+// property_declaration, so the declaration goes away as a whole.
+func declarationWrapper(source string, node *sitter.Node) *sitter.Node {
+	for parent := node.Parent(); parent != nil && parent.Parent() != nil; parent = node.Parent() {
+		rest := strings.TrimSpace(source[node.EndByte():parent.EndByte()])
+		if !isDeclaration(parent.Type()) || (rest != "" && rest != ";") || namedChildrenExcept(parent, decoratorTypes) != 1 {
+			break
+		}
+		node = parent
+	}
+	return node
+}
+
+// namedChildrenExcept counts the named children of node, leaving out
+// comments, modifiers, and the given types.
+func namedChildrenExcept(node *sitter.Node, skip map[string]bool) int {
+	count := 0
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		childType := node.NamedChild(i).Type()
+		if !skip[childType] && !strings.Contains(childType, "comment") && !strings.Contains(childType, "modifier") {
+			count++
+		}
+	}
+	return count
+}
+
+// attachedStart walks back over comments and decorators that directly
+// precede node. When they run up to the start of the parent, as with a
+// Python decorated_definition or the first member of a block, it keeps
+// looking in front of the parent.
+func attachedStart(source string, node *sitter.Node) uint32 {
+	return leadingStart(source, node, attachesTo)
+}
+
+// leadingStart walks back from node over the nodes attaches accepts, as
+// attachedStart does.
+func leadingStart(source string, node *sitter.Node, attaches func(string, *sitter.Node, uint32) bool) uint32 {
+	start := node.StartByte()
+	current := node
+	for {
+		prev := previousToken(source, current)
+		for prev != nil && attaches(source, prev, start) {
+			start = prev.StartByte()
+			current = prev
+			prev = previousToken(source, current)
+		}
+		parent := current.Parent()
+		if prev != nil || parent == nil || parent.Parent() == nil || parent.StartByte() != start {
+			return start
+		}
+		current = parent
+	}
+}
+
+// previousToken returns the sibling before node, skipping the newline
+// tokens some grammars emit between statements.
+func previousToken(source string, node *sitter.Node) *sitter.Node {
+	prev := node.PrevSibling()
+	for prev != nil && blankToken(source, prev) {
+		prev = prev.PrevSibling()
+	}
+	return prev
+}
+
+func blankToken(source string, node *sitter.Node) bool {
+	return !node.IsNamed() && strings.TrimSpace(source[node.StartByte():node.EndByte()]) == ""
+}
+
+// attachesTo reports whether node is a comment or decorator that belongs to
+// the declaration starting at start. Comments must sit on their own line
+// with no blank line before the declaration.
+func attachesTo(source string, node *sitter.Node, start uint32) bool {
+	gap := source[node.EndByte():start]
+	if strings.TrimSpace(gap) != "" {
+		return false
+	}
+	if decoratorTypes[node.Type()] {
+		return true
+	}
+	if !strings.Contains(node.Type(), "comment") || strings.Count(gap, "\n") > 1 {
+		return false
+	}
+	lineStart := lineStartOffset(source, int(node.StartByte()))
+	return strings.TrimSpace(source[lineStart:node.StartByte()]) == ""
+}
+
+// decorates reports whether node is a decorator directly in front of the
+// declaration starting at start.
+func decorates(source string, node *sitter.Node, start uint32) bool {
+	return decoratorTypes[node.Type()] && strings.TrimSpace(source[node.EndByte():start]) == ""
+}
+
+// attachedEnd extends node over a comment that follows it on the same line.
+// The comment may be a sibling of an ancestor that ends where node ends.
+func attachedEnd(source string, node *sitter.Node) uint32 {
+	end := node.EndByte()
+	for current := node; current.Parent() != nil; current = current.Parent() {
+		if next := current.NextSibling(); next != nil {
+			if strings.Contains(next.Type(), "comment") && strings.Trim(source[end:next.StartByte()], " \t") == "" {
+				return next.EndByte()
+			}
+			return end
+		}
+		if current.Parent().EndByte() != end {
+			return end
+		}
+	}
+	return end
+}
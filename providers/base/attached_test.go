@@ -0,0 +1,107 @@
+package base
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+const attachedSource = "package main\n\nfunc Bar() {}\n\n// Foo does things.\n// More detail.\nfunc Foo() {} // trailing\n\n// Unrelated note.\n\nfunc Baz() {}\n"
+
+func TestTransformDeleteIncludesAttachedComments(t *testing.T) {
+	provider := newTestProvider()
+
+	result := provider.Transform(attachedSource, core.TransformOp{
+		Method: "delete",
+		Target: core.AgentQuery{Type: "function", Name: "Foo"},
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	for _, gone := range []string{"Foo does things", "More detail", "trailing", "func Foo"} {
+		if strings.Contains(result.Modified, gone) {
+			t.Errorf("expected %q to be deleted:\n%s", gone, result.Modified)
+		}
+	}
+	for _, kept := range []string{"func Bar() {}", "func Baz() {}"} {
+		if !strings.Contains(result.Modified, kept) {
+			t.Errorf("expected %q to survive:\n%s", kept, result.Modified)
+		}
+	}
+}
+
+func TestTransformDeleteSkipsDetachedComments(t *testing.T) {
+	provider := newTestProvider()
+
+	// A blank line separates the note from Baz, so it is not Baz's doc.
+	result := provider.Transform(attachedSource, core.TransformOp{
+		Method: "delete",
+		Target: core.AgentQuery{Type: "function", Name: "Baz"},
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "// Unrelated note.") {
+		t.Fatalf("expected the detached comment to survive:\n%s", result.Modified)
+	}
+}
+
+func TestTransformDeleteAttachedExclude(t *testing.T) {
+	provider := newTestProvider()
+
+	result := provider.Transform(attachedSource, core.TransformOp{
+		Method:   "delete",
+		Target:   core.AgentQuery{Type: "function", Name: "Foo"},
+		Attached: AttachedExclude,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if strings.Contains(result.Modified, "func Foo") || !strings.Contains(result.Modified, "// Foo does things.") {
+		t.Fatalf("expected only the function to be deleted:\n%s", result.Modified)
+	}
+}
+
+func TestTransformReplaceAttached(t *testing.T) {
+	provider := newTestProvider()
+	replacement := "// Foo is new.\nfunc Foo() { return }"
+
+	kept := provider.Transform(attachedSource, core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "function", Name: "Foo"},
+		Replacement: "func Foo() { return }",
+	})
+	if kept.Error != nil {
+		t.Fatalf("Transform returned error: %v", kept.Error)
+	}
+	if !strings.Contains(kept.Modified, "// More detail.\nfunc Foo() { return } // trailing") {
+		t.Fatalf("expected replace to keep attached comments by default:\n%s", kept.Modified)
+	}
+
+	replaced := provider.Transform(attachedSource, core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "function", Name: "Foo"},
+		Replacement: replacement,
+		Attached:    AttachedInclude,
+	})
+	if replaced.Error != nil {
+		t.Fatalf("Transform returned error: %v", replaced.Error)
+	}
+	if !strings.Contains(replaced.Modified, "func Bar() {}\n\n"+replacement+"\n\n// Unrelated note.") {
+		t.Fatalf("expected the comments to be replaced with the function:\n%s", replaced.Modified)
+	}
+}
+
+func TestTransformAttachedRejectsUnknownPolicy(t *testing.T) {
+	provider := newTestProvider()
+
+	result := provider.Transform(attachedSource, core.TransformOp{
+		Method:   "delete",
+		Target:   core.AgentQuery{Type: "function", Name: "Foo"},
+		Attached: "all",
+	})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "unknown attached policy") {
+		t.Fatalf("expected unknown policy error, got %v", result.Error)
+	}
+}
@@ -0,0 +1,98 @@
+package base
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// transformBatch applies op.Ops in order to the in-memory source. Every step
+// resolves its target against the output of the previous one, and the batch
+// reports a single diff against the original source. The combined confidence
+// is that of the weakest step and carries its factors; every step's factors
+// are kept for review in the steps metadata.
+func (p *Provider) transformBatch(parser *parserAdapter, source string, op core.TransformOp) core.TransformResult {
+	if len(op.Ops) == 0 {
+		return core.TransformResult{Error: fmt.Errorf("batch requires at least one operation")}
+	}
+
+	var confidence core.ConfidenceScore
+	weakest := math.Inf(1)
+	steps := make([]map[string]any, 0, len(op.Ops))
+	modified := source
+	matchCount := 0
+
+	for i, step := range op.Ops {
+		if step.Method == "batch" {
+			return core.TransformResult{Error: fmt.Errorf("step %d: batches cannot be nested", i+1)}
+		}
+
+		result := p.transform(parser, modified, step)
+		if result.Error != nil {
+			return core.TransformResult{Error: fmt.Errorf("step %d (%s): %w", i+1, step.Method, result.Error)}
+		}
+
+		factors := make([]core.ConfidenceFactor, 0, len(result.Confidence.Factors))
+		sum := 1.0
+		for _, factor := range result.Confidence.Factors {
+			factor.Reason = fmt.Sprintf("step %d (%s): %s", i+1, step.Method, factor.Reason)
+			factors = append(factors, factor)
+			sum += factor.Impact
+		}
+		if result.Confidence.Score < weakest {
+			// Batch factors add to the step's sum before it was clamped
+			weakest = result.Confidence.Score
+			confidence = core.ConfidenceScore{Score: sum, Factors: append([]core.ConfidenceFactor(nil), factors...)}
+		}
+		steps = append(steps, map[string]any{
+			"method":     step.Method,
+			"matches":    result.MatchCount,
+			"confidence": result.Confidence.Score,
+			"factors":    factors,
+			"changed":    result.Modified != modified,
+		})
+
+		matchCount += result.MatchCount
+		modified = result.Modified
+	}
+
+	metadata := map[string]any{"steps": steps}
+
+	if op.OrganizeImports {
+		var (
+			removed, added []string
+			err            error
+		)
+		modified, removed, added, err = p.organizeImports(parser, source, modified)
+		if err != nil {
+			return core.TransformResult{Error: err}
+		}
+		if len(removed) > 0 || len(added) > 0 {
+			metadata["imports_removed"] = removed
+			metadata["imports_added"] = added
+			confidence.Factors = append(confidence.Factors, core.ConfidenceFactor{
+				Name:   "organized_imports",
+				Impact: 0.0,
+				Reason: fmt.Sprintf("Removed %d unused and added %d missing imports", len(removed), len(added)),
+			})
+		}
+	}
+
+	if op.Format {
+		var factor core.ConfidenceFactor
+		modified, factor = p.format(modified)
+		confidence.Factors = append(confidence.Factors, factor)
+		confidence.Score += factor.Impact
+	}
+
+	clampConfidence(&confidence)
+
+	return core.TransformResult{
+		Modified:   modified,
+		Diff:       p.generateDiff(source, modified),
+		Confidence: confidence,
+		MatchCount: matchCount,
+		Metadata:   metadata,
+	}
+}
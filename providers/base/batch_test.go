@@ -0,0 +1,116 @@
+package base
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+const batchTestSource = "package main\n\nfunc Old() {}\n\nfunc Keep() {}\n"
+
+func TestTransformBatchAppliesStepsInOrder(t *testing.T) {
+	provider := newTestProvider()
+
+	result := provider.Transform(batchTestSource, core.TransformOp{
+		Method: "batch",
+		Ops: []core.TransformOp{
+			{
+				Method:      "replace",
+				Target:      core.AgentQuery{Type: "function", Name: "Old"},
+				Replacement: "func New() {}",
+			},
+			{
+				// Resolves against the output of the first step
+				Method:  "insert_after",
+				Target:  core.AgentQuery{Type: "function", Name: "New"},
+				Content: "\nfunc Helper() {}",
+			},
+			{
+				Method: "delete",
+				Target: core.AgentQuery{Type: "function", Name: "Keep"},
+			},
+		},
+	})
+	if result.Error != nil {
+		t.Fatalf("batch returned error: %v", result.Error)
+	}
+
+	want := "package main\n\nfunc New() {}\n\nfunc Helper() {}\n"
+	if result.Modified != want {
+		t.Fatalf("unexpected batch output:\n%q", result.Modified)
+	}
+	if result.MatchCount != 3 {
+		t.Fatalf("expected 3 matches, got %d", result.MatchCount)
+	}
+	if strings.Count(result.Diff, "--- original") != 1 {
+		t.Fatalf("expected a single diff against the original, got:\n%s", result.Diff)
+	}
+	steps, ok := result.Metadata["steps"].([]map[string]any)
+	if !ok || len(steps) != 3 || steps[1]["method"] != "insert_after" {
+		t.Fatalf("unexpected steps metadata: %#v", result.Metadata["steps"])
+	}
+	for _, factor := range result.Confidence.Factors {
+		if !strings.HasPrefix(factor.Reason, "step ") {
+			t.Fatalf("expected step-prefixed factor, got %+v", factor)
+		}
+	}
+}
+
+func TestTransformBatchUsesWeakestStepConfidence(t *testing.T) {
+	provider := newTestProvider()
+	steps := []core.TransformOp{
+		{Method: "replace", Target: core.AgentQuery{Type: "function", Name: "Old"}, Replacement: "func Old() {}"},
+		{Method: "replace", Target: core.AgentQuery{Type: "function", Name: "*"}, Replacement: "func F() {}"},
+	}
+
+	weakest := 1.0
+	source := batchTestSource
+	for _, step := range steps {
+		result := provider.Transform(source, step)
+		weakest = min(weakest, result.Confidence.Score)
+		source = result.Modified
+	}
+
+	result := provider.Transform(batchTestSource, core.TransformOp{Method: "batch", Ops: steps})
+	if result.Error != nil {
+		t.Fatalf("batch returned error: %v", result.Error)
+	}
+	if result.Confidence.Score != weakest {
+		t.Fatalf("expected combined confidence %.3f, got %.3f", weakest, result.Confidence.Score)
+	}
+}
+
+func TestTransformBatchFailsOnStepError(t *testing.T) {
+	provider := newTestProvider()
+
+	result := provider.Transform(batchTestSource, core.TransformOp{
+		Method: "batch",
+		Ops: []core.TransformOp{
+			{Method: "delete", Target: core.AgentQuery{Type: "function", Name: "Old"}},
+			{Method: "delete", Target: core.AgentQuery{Type: "function", Name: "Old"}},
+		},
+	})
+	if result.Error == nil {
+		t.Fatal("expected error when a step no longer matches")
+	}
+	if !errors.Is(result.Error, core.ErrNoMatchesFound) || !strings.HasPrefix(result.Error.Error(), "step 2 (delete)") {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.Modified != "" {
+		t.Fatalf("failed batch should not return partial output, got %q", result.Modified)
+	}
+}
+
+func TestTransformBatchRejectsEmptyAndNested(t *testing.T) {
+	provider := newTestProvider()
+
+	if result := provider.Transform(batchTestSource, core.TransformOp{Method: "batch"}); result.Error == nil {
+		t.Fatal("expected error for empty batch")
+	}
+	nested := core.TransformOp{Method: "batch", Ops: []core.TransformOp{{Method: "batch"}}}
+	if result := provider.Transform(batchTestSource, nested); result.Error == nil {
+		t.Fatal("expected error for nested batch")
+	}
+}
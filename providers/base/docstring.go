@@ -0,0 +1,50 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// DocstringConfig lets a language config write docstrings, the string
+// statement that opens a function or class body in Python. SetDocstring
+// replaces each target's docstring with op.Content, or inserts one when the
+// target has none; with op.IfAbsent, existing docstrings are kept.
+type DocstringConfig interface {
+	SetDocstring(source string, targets []Target, op core.TransformOp) (string, map[string]any, error)
+}
+
+// TypeHintConfig lets a language config add or replace parameter and
+// return type hints on function targets. op.TypeHints maps parameter names
+// to types, with "return" naming the return type; with op.IfAbsent,
+// existing hints are kept.
+type TypeHintConfig interface {
+	AnnotateTypes(source string, targets []Target, op core.TransformOp) (string, map[string]any, error)
+}
+
+// doSetDocstring runs set_docstring, which only languages with a
+// DocstringConfig support.
+func (p *Provider) doSetDocstring(source string, targets []Target, op core.TransformOp) (string, map[string]any, error) {
+	config, ok := p.config.(DocstringConfig)
+	if !ok {
+		return source, nil, fmt.Errorf("%s is not supported for %s", op.Method, p.config.Language())
+	}
+	if strings.TrimSpace(op.Content) == "" {
+		return source, nil, fmt.Errorf("content is required")
+	}
+	return config.SetDocstring(source, targets, op)
+}
+
+// doAnnotate runs annotate, which only languages with a TypeHintConfig
+// support.
+func (p *Provider) doAnnotate(source string, targets []Target, op core.TransformOp) (string, map[string]any, error) {
+	config, ok := p.config.(TypeHintConfig)
+	if !ok {
+		return source, nil, fmt.Errorf("%s is not supported for %s", op.Method, p.config.Language())
+	}
+	if len(op.TypeHints) == 0 {
+		return source, nil, fmt.Errorf("type_hints is required")
+	}
+	return config.AnnotateTypes(source, targets, op)
+}
@@ -0,0 +1,31 @@
+package base
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformDocstringAndTypeHintsNeedConfig(t *testing.T) {
+	provider := newTestProvider()
+	target := core.AgentQuery{Type: "function", Name: "Foo"}
+
+	docstring := provider.Transform("package main\n\nfunc Foo() {}\n", core.TransformOp{
+		Method:  "set_docstring",
+		Target:  target,
+		Content: "Foo does things.",
+	})
+	if docstring.Error == nil || !strings.Contains(docstring.Error.Error(), "set_docstring is not supported") {
+		t.Fatalf("expected set_docstring to be unsupported, got %v", docstring.Error)
+	}
+
+	hints := provider.Transform("package main\n\nfunc Foo() {}\n", core.TransformOp{
+		Method:    "annotate",
+		Target:    target,
+		TypeHints: map[string]string{"return": "int"},
+	})
+	if hints.Error == nil || !strings.Contains(hints.Error.Error(), "annotate is not supported") {
+		t.Fatalf("expected annotate to be unsupported, got %v", hints.Error)
+	}
+}
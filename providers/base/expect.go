@@ -0,0 +1,13 @@
+package base
+
+import "github.com/oxhq/morfx/core"
+
+// checkExpectedMatches enforces an expect_matches guard, listing every
+// matched target when the count is off.
+func checkExpectedMatches(expected core.MatchRange, targets []Target) error {
+	locations := make([]string, 0, len(targets))
+	for _, target := range targets {
+		locations = append(locations, describeTarget(target))
+	}
+	return expected.Check(len(targets), locations)
+}
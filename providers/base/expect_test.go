@@ -0,0 +1,48 @@
+package base
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformExpectMatchesAbortsOnMismatch(t *testing.T) {
+	provider := newTestProvider()
+	source := "package main\n\nfunc HandleA() {}\n\nfunc HandleB() {}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:        "delete",
+		Target:        core.AgentQuery{Type: "function", Name: "Handle*"},
+		ExpectMatches: "1",
+	})
+	if !errors.Is(result.Error, core.ErrUnexpectedMatchCount) {
+		t.Fatalf("expected ErrUnexpectedMatchCount, got %v", result.Error)
+	}
+	for _, want := range []string{"expected 1, matched 2", "HandleA (line 3)", "HandleB (line 5)"} {
+		if !strings.Contains(result.Error.Error(), want) {
+			t.Errorf("error %q does not mention %q", result.Error, want)
+		}
+	}
+	if result.Modified != "" {
+		t.Fatalf("expected no output on a failed guard, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformExpectMatchesAllowsRange(t *testing.T) {
+	provider := newTestProvider()
+	source := "package main\n\nfunc HandleA() {}\n\nfunc HandleB() {}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:        "delete",
+		Target:        core.AgentQuery{Type: "function", Name: "Handle*"},
+		ExpectMatches: "1..2",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if result.MatchCount != 2 || strings.Contains(result.Modified, "Handle") {
+		t.Fatalf("expected both functions deleted, got %d matches:\n%s", result.MatchCount, result.Modified)
+	}
+}
@@ -0,0 +1,403 @@
+package base
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// ExtractFunctionConfig lets language configs support the extract_function
+// transform. The base provider selects the statements and computes data flow;
+// the config knows which identifiers bind variables and how to spell the
+// extracted function and its call site.
+type ExtractFunctionConfig interface {
+	// ExtractionBindings returns identifier nodes under node that declare or
+	// assign a variable, including function parameters.
+	ExtractionBindings(node *sitter.Node, source string) []*sitter.Node
+	// ExtractionReferences returns every identifier node under node that
+	// names a variable, bindings included.
+	ExtractionReferences(node *sitter.Node, source string) []*sitter.Node
+	// RenderExtraction returns the new top-level function and the unindented
+	// statements that replace the extracted range.
+	RenderExtraction(source string, spec ExtractionSpec) (function string, call string, err error)
+}
+
+// ExtractionSpec describes a statement range being extracted into a function.
+type ExtractionSpec struct {
+	Name       string
+	Body       string         // extracted statements, dedented
+	Statements []*sitter.Node // extracted statements in source order
+	Enclosing  *sitter.Node   // function the statements are extracted from
+	Params     []ExtractedVariable
+	Results    []ExtractedVariable
+	IndentUnit string
+	Async      bool
+}
+
+// ExtractedVariable is a parameter or result of an extracted function.
+type ExtractedVariable struct {
+	Name     string
+	Declared bool         // declared before the extracted range
+	Binding  *sitter.Node // first binding of the variable
+}
+
+const defaultExtractedFunctionName = "extracted"
+
+func (p *Provider) doExtractFunction(source string, root *sitter.Node, targets []Target, op core.TransformOp) (string, map[string]any, error) {
+	extractor, ok := p.config.(ExtractFunctionConfig)
+	if !ok {
+		return source, nil, fmt.Errorf("extract_function is not supported for %s", p.config.Language())
+	}
+	name := strings.TrimSpace(op.FunctionName)
+	if name == "" {
+		name = defaultExtractedFunctionName
+	}
+	if existing := p.findTargets(root, source, core.AgentQuery{Type: "function", Name: name}); len(existing) > 0 {
+		return source, nil, fmt.Errorf("function %q already exists", name)
+	}
+
+	var statements []*sitter.Node
+	for _, target := range targets {
+		selected, err := extractionStatements(target, op.Target.Attributes["lines"])
+		if err != nil {
+			return source, nil, err
+		}
+		if statements != nil && (selected[0] != statements[0] || len(selected) != len(statements)) {
+			return source, nil, fmt.Errorf("extract_function needs exactly one statement range, found %d targets", len(targets))
+		}
+		statements = selected
+	}
+	if err := checkExtractable(statements); err != nil {
+		return source, nil, err
+	}
+
+	enclosing := outermostFunction(statements[0])
+	if enclosing == nil {
+		return source, nil, fmt.Errorf("extract_function requires statements inside a function")
+	}
+	topLevel := enclosing
+	for topLevel.Parent() != nil && topLevel.Parent().Parent() != nil {
+		topLevel = topLevel.Parent()
+	}
+
+	start := lineStartOffset(source, int(statements[0].StartByte()))
+	end := int(statements[len(statements)-1].EndByte())
+	params, results := analyzeExtraction(extractor, enclosing, source, statements)
+
+	spec := ExtractionSpec{
+		Name:       name,
+		Body:       Dedent(source[start:end]),
+		Statements: statements,
+		Enclosing:  enclosing,
+		Params:     params,
+		Results:    results,
+		IndentUnit: p.indentUnit(source),
+		Async:      containsAwait(statements),
+	}
+	function, call, err := extractor.RenderExtraction(source, spec)
+	if err != nil {
+		return source, nil, err
+	}
+
+	indent := p.getIndentation(source, statements[0])
+	insertAt := int(topLevel.EndByte())
+	modified := source[:start] + IndentLines(call, indent) + source[end:insertAt] +
+		"\n\n" + strings.TrimRight(function, "\n") + source[insertAt:]
+
+	metadata := map[string]any{
+		"function":   name,
+		"parameters": variableNames(params),
+		"results":    variableNames(results),
+	}
+	return modified, metadata, nil
+}
+
+// extractionStatements resolves the statements to extract: the sibling
+// statements covered by a lines=a..b range inside the target, or the
+// statement holding the target itself.
+func extractionStatements(target Target, lines string) ([]*sitter.Node, error) {
+	if target.Node == nil {
+		return nil, fmt.Errorf("extract_function target has no syntax node")
+	}
+	if strings.TrimSpace(lines) == "" {
+		node := target.Node
+		for node.Parent() != nil && !isStatementContainer(node.Parent().Type()) {
+			node = node.Parent()
+		}
+		if node.Parent() == nil {
+			return nil, fmt.Errorf("target is not inside a statement block")
+		}
+		return []*sitter.Node{node}, nil
+	}
+
+	first, last, ok := parseLineRange(lines)
+	if !ok {
+		return nil, fmt.Errorf("invalid lines range %q, expected a..b", lines)
+	}
+	startRow, endRow := uint32(first-1), uint32(last-1)
+
+	container := target.Node
+	for {
+		var next *sitter.Node
+		for i := 0; i < int(container.NamedChildCount()); i++ {
+			child := container.NamedChild(i)
+			if child.StartPoint().Row <= startRow && child.EndPoint().Row >= endRow &&
+				(isStatementContainer(child.Type()) || child.StartPoint().Row < startRow || child.EndPoint().Row > endRow) {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		container = next
+	}
+	if !isStatementContainer(container.Type()) {
+		return nil, fmt.Errorf("lines %s do not select whole statements", lines)
+	}
+
+	var statements []*sitter.Node
+	for i := 0; i < int(container.NamedChildCount()); i++ {
+		child := container.NamedChild(i)
+		childStart, childEnd := child.StartPoint().Row, child.EndPoint().Row
+		if childEnd < startRow || childStart > endRow {
+			continue
+		}
+		if childStart < startRow || childEnd > endRow {
+			return nil, fmt.Errorf("lines %s split a statement at line %d", lines, childStart+1)
+		}
+		statements = append(statements, child)
+	}
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("lines %s contain no statements", lines)
+	}
+	return statements, nil
+}
+
+// parseLineRange parses "a..b" or a single line number, 1-based and inclusive.
+func parseLineRange(value string) (int, int, bool) {
+	value = stripAttributeQuotes(value)
+	from, to, found := strings.Cut(value, "..")
+	if !found {
+		to = from
+	}
+	first, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, false
+	}
+	last, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil || first < 1 || last < first {
+		return 0, 0, false
+	}
+	return first, last, true
+}
+
+// matchLineRange reports whether node spans the whole lines=a..b range.
+func matchLineRange(node *sitter.Node, value string) bool {
+	first, last, ok := parseLineRange(value)
+	if !ok || node == nil {
+		return false
+	}
+	return int(node.StartPoint().Row)+1 <= first && int(node.EndPoint().Row)+1 >= last
+}
+
+func isStatementContainer(nodeType string) bool {
+	switch nodeType {
+	case "block", "statement_block", "compound_statement":
+		return true
+	default:
+		return false
+	}
+}
+
+func isFunctionBoundary(nodeType string) bool {
+	switch nodeType {
+	case "function_declaration", "method_declaration", "func_literal",
+		"function_definition", "lambda",
+		"function_expression", "function", "arrow_function", "method_definition",
+		"generator_function_declaration", "generator_function":
+		return true
+	default:
+		return false
+	}
+}
+
+func isLoop(nodeType string) bool {
+	switch nodeType {
+	case "for_statement", "for_in_statement", "while_statement", "do_statement":
+		return true
+	default:
+		return false
+	}
+}
+
+func isLoopOrSwitch(nodeType string) bool {
+	switch nodeType {
+	case "switch_statement", "expression_switch_statement", "type_switch_statement", "select_statement":
+		return true
+	default:
+		return isLoop(nodeType)
+	}
+}
+
+func outermostFunction(node *sitter.Node) *sitter.Node {
+	var found *sitter.Node
+	for current := node.Parent(); current != nil; current = current.Parent() {
+		if isFunctionBoundary(current.Type()) {
+			found = current
+		}
+	}
+	return found
+}
+
+// checkExtractable rejects statements whose control flow escapes the range.
+func checkExtractable(statements []*sitter.Node) error {
+	var problem error
+	var walk func(node *sitter.Node, inLoop bool)
+	walk = func(node *sitter.Node, inLoop bool) {
+		if problem != nil || isFunctionBoundary(node.Type()) {
+			return
+		}
+		switch node.Type() {
+		case "return_statement", "defer_statement", "yield", "yield_expression", "goto_statement", "fallthrough_statement":
+			problem = fmt.Errorf("cannot extract %s at line %d: it would change control flow", node.Type(), node.StartPoint().Row+1)
+			return
+		case "break_statement", "continue_statement":
+			if !inLoop {
+				problem = fmt.Errorf("cannot extract %s at line %d: its loop is outside the range", node.Type(), node.StartPoint().Row+1)
+				return
+			}
+		}
+		inLoop = inLoop || isLoopOrSwitch(node.Type())
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			walk(node.NamedChild(i), inLoop)
+		}
+	}
+	for _, statement := range statements {
+		walk(statement, false)
+	}
+	return problem
+}
+
+func containsAwait(statements []*sitter.Node) bool {
+	var walk func(*sitter.Node) bool
+	walk = func(node *sitter.Node) bool {
+		if isFunctionBoundary(node.Type()) {
+			return false
+		}
+		if node.Type() == "await" || node.Type() == "await_expression" {
+			return true
+		}
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			if walk(node.NamedChild(i)) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, statement := range statements {
+		if walk(statement) {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeExtraction computes the parameters (variables declared before the
+// range that it reads or assigns) and results (variables the range assigns
+// that are read after it) of an extraction.
+func analyzeExtraction(extractor ExtractFunctionConfig, enclosing *sitter.Node, source string, statements []*sitter.Node) ([]ExtractedVariable, []ExtractedVariable) {
+	rangeStart := statements[0].StartByte()
+	rangeEnd := statements[len(statements)-1].EndByte()
+	inRange := func(node *sitter.Node) bool {
+		return node.StartByte() >= rangeStart && node.EndByte() <= rangeEnd
+	}
+
+	// Inside a loop, values assigned in the range flow into the next
+	// iteration, so reads anywhere in that loop count as later reads.
+	laterFrom := rangeEnd
+	for node := statements[0].Parent(); node != nil && node != enclosing; node = node.Parent() {
+		if isLoop(node.Type()) {
+			laterFrom = node.StartByte()
+		}
+	}
+
+	declaredBefore := make(map[string]*sitter.Node)
+	boundInRange := make(map[string]*sitter.Node)
+	var bindingOrder []string
+	bindingOffsets := make(map[uint32]struct{})
+	for _, binding := range extractor.ExtractionBindings(enclosing, source) {
+		name := nodeContent(binding, source)
+		bindingOffsets[binding.StartByte()] = struct{}{}
+		switch {
+		case binding.EndByte() <= rangeStart:
+			if _, exists := declaredBefore[name]; !exists {
+				declaredBefore[name] = binding
+			}
+		case inRange(binding):
+			if _, exists := boundInRange[name]; !exists {
+				boundInRange[name] = binding
+				bindingOrder = append(bindingOrder, name)
+			}
+		}
+	}
+
+	readInRange := make(map[string]struct{})
+	readLater := make(map[string]struct{})
+	var referenceOrder []string
+	for _, reference := range extractor.ExtractionReferences(enclosing, source) {
+		name := nodeContent(reference, source)
+		if inRange(reference) {
+			if _, isBinding := bindingOffsets[reference.StartByte()]; !isBinding {
+				if _, exists := readInRange[name]; !exists {
+					readInRange[name] = struct{}{}
+					referenceOrder = append(referenceOrder, name)
+				}
+			}
+			continue
+		}
+		if reference.StartByte() >= laterFrom {
+			readLater[name] = struct{}{}
+		}
+	}
+
+	var results []ExtractedVariable
+	for _, name := range bindingOrder {
+		if _, later := readLater[name]; !later {
+			continue
+		}
+		binding, declared := declaredBefore[name]
+		if !declared {
+			binding = boundInRange[name]
+		}
+		results = append(results, ExtractedVariable{Name: name, Declared: declared, Binding: binding})
+	}
+
+	var params []ExtractedVariable
+	seen := make(map[string]struct{})
+	for _, name := range append(referenceOrder, bindingOrder...) {
+		if _, done := seen[name]; done {
+			continue
+		}
+		binding, declared := declaredBefore[name]
+		if !declared {
+			continue
+		}
+		seen[name] = struct{}{}
+		params = append(params, ExtractedVariable{Name: name, Declared: true, Binding: binding})
+	}
+
+	return params, results
+}
+
+func variableNames(variables []ExtractedVariable) []string {
+	names := make([]string, 0, len(variables))
+	for _, variable := range variables {
+		names = append(names, variable.Name)
+	}
+	return names
+}
@@ -0,0 +1,173 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oxhq/morfx/core"
+)
+
+const defaultFormatTimeout = 10 * time.Second
+
+// FormatConfig lets language configs format source in-process.
+type FormatConfig interface {
+	// Format returns source formatted with the language's canonical style.
+	Format(source string) (string, error)
+	// FormatterName names the formatter in confidence factors.
+	FormatterName() string
+}
+
+// FormatCommandConfig lets language configs name an external formatter.
+// The command reads source on stdin and writes the result to stdout, unless
+// an argument is the {file} placeholder, in which case the command rewrites a
+// temporary file in place.
+type FormatCommandConfig interface {
+	FormatCommand() []string
+}
+
+// formatCommand resolves the external formatter for the language. The
+// MORFX_FORMAT_<LANGUAGE> environment variable overrides the config's
+// default; "off" disables formatting.
+func (p *Provider) formatCommand() ([]string, bool) {
+	key := "MORFX_FORMAT_" + strings.ToUpper(p.config.Language())
+	if value, ok := os.LookupEnv(key); ok {
+		value = strings.TrimSpace(value)
+		if value == "" || value == "off" {
+			return nil, true
+		}
+		return strings.Fields(value), true
+	}
+	if commander, ok := p.config.(FormatCommandConfig); ok {
+		return commander.FormatCommand(), false
+	}
+	return nil, false
+}
+
+func resolveFormatTimeout() time.Duration {
+	value := os.Getenv("MORFX_FORMAT_TIMEOUT")
+	if value == "" {
+		return defaultFormatTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return defaultFormatTimeout
+	}
+	return timeout
+}
+
+// format runs the configured formatter over modified. On any failure the
+// unformatted source is returned; the factor records what happened either way.
+func (p *Provider) format(modified string) (string, core.ConfidenceFactor) {
+	command, overridden := p.formatCommand()
+	if formatter, ok := p.config.(FormatConfig); ok && !overridden {
+		formatted, err := formatter.Format(modified)
+		return formatResult(modified, formatted, formatter.FormatterName(), err)
+	}
+	if len(command) == 0 {
+		return modified, core.ConfidenceFactor{
+			Name:   "formatted",
+			Impact: 0.0,
+			Reason: fmt.Sprintf("No formatter configured for %s, output left unformatted", p.config.Language()),
+		}
+	}
+	formatted, err := runFormatCommand(command, modified, p.config.Extensions(), resolveFormatTimeout())
+	return formatResult(modified, formatted, filepath.Base(command[0]), err)
+}
+
+func formatResult(modified, formatted, name string, err error) (string, core.ConfidenceFactor) {
+	if err != nil {
+		return modified, core.ConfidenceFactor{
+			Name:   "formatted",
+			Impact: -0.05,
+			Reason: fmt.Sprintf("%s failed, output left unformatted: %v", name, err),
+		}
+	}
+	if formatted == modified {
+		return modified, core.ConfidenceFactor{
+			Name:   "formatted",
+			Impact: 0.0,
+			Reason: fmt.Sprintf("Output already matches %s", name),
+		}
+	}
+	return formatted, core.ConfidenceFactor{
+		Name:   "formatted",
+		Impact: 0.0,
+		Reason: fmt.Sprintf("Formatted with %s", name),
+	}
+}
+
+func runFormatCommand(command []string, source string, extensions []string, timeout time.Duration) (string, error) {
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return "", fmt.Errorf("%s not found", command[0])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	args := append([]string(nil), command[1:]...)
+	var tempPath string
+	for i, arg := range args {
+		if arg != "{file}" {
+			continue
+		}
+		if tempPath == "" {
+			extension := ""
+			if len(extensions) > 0 {
+				extension = extensions[0]
+			}
+			file, err := os.CreateTemp("", "morfx-format-*"+extension)
+			if err != nil {
+				return "", err
+			}
+			tempPath = file.Name()
+			defer os.Remove(tempPath)
+			_, writeErr := file.WriteString(source)
+			closeErr := file.Close()
+			if err := errors.Join(writeErr, closeErr); err != nil {
+				return "", err
+			}
+		}
+		args[i] = tempPath
+	}
+
+	cmd := exec.CommandContext(ctx, command[0], args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if tempPath == "" {
+		cmd.Stdin = strings.NewReader(source)
+	}
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("timed out after %s", timeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, firstLine(message))
+		}
+		return "", err
+	}
+
+	if tempPath != "" {
+		content, err := os.ReadFile(tempPath)
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}
+	if stdout.Len() == 0 && source != "" {
+		return "", fmt.Errorf("formatter produced no output")
+	}
+	return stdout.String(), nil
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
@@ -0,0 +1,137 @@
+package base
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// formatCommandConfig adds an external formatter to mockConfig
+type formatCommandConfig struct {
+	mockConfig
+	command []string
+}
+
+func (c *formatCommandConfig) FormatCommand() []string {
+	return c.command
+}
+
+func formatTestProvider(command ...string) *Provider {
+	return New(&formatCommandConfig{
+		mockConfig: mockConfig{language: "fake", extensions: []string{".go"}},
+		command:    command,
+	})
+}
+
+func formattedFactor(t *testing.T, confidence core.ConfidenceScore) core.ConfidenceFactor {
+	t.Helper()
+	for _, factor := range confidence.Factors {
+		if factor.Name == "formatted" {
+			return factor
+		}
+	}
+	t.Fatalf("expected formatted factor, got %+v", confidence.Factors)
+	return core.ConfidenceFactor{}
+}
+
+func replaceMainOp() core.TransformOp {
+	return core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "function", Name: "main"},
+		Replacement: "func main()   {}",
+		Format:      true,
+	}
+}
+
+const formatTestSource = "package main\n\nfunc main() {\n}\n"
+
+func TestTransformFormatWithStdinCommand(t *testing.T) {
+	provider := formatTestProvider("sed", "s/   / /")
+
+	result := provider.Transform(formatTestSource, replaceMainOp())
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "func main() {}") {
+		t.Fatalf("expected formatter output, got:\n%s", result.Modified)
+	}
+	if strings.Contains(result.Diff, "main()   {}") {
+		t.Fatalf("expected diff computed on formatted output, got:\n%s", result.Diff)
+	}
+	factor := formattedFactor(t, result.Confidence)
+	if factor.Impact != 0 || factor.Reason != "Formatted with sed" {
+		t.Fatalf("unexpected formatted factor %+v", factor)
+	}
+}
+
+func TestTransformFormatWithFileCommand(t *testing.T) {
+	provider := formatTestProvider("sed", "-i", "s/   / /", "{file}")
+
+	result := provider.Transform(formatTestSource, replaceMainOp())
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "func main() {}") {
+		t.Fatalf("expected file formatter output, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformFormatFallsBackWhenCommandMissing(t *testing.T) {
+	provider := formatTestProvider("morfx-missing-formatter")
+
+	result := provider.Transform(formatTestSource, replaceMainOp())
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "func main()   {}") {
+		t.Fatalf("expected unformatted fallback, got:\n%s", result.Modified)
+	}
+	factor := formattedFactor(t, result.Confidence)
+	if factor.Impact >= 0 || !strings.Contains(factor.Reason, "not found") {
+		t.Fatalf("expected failure factor, got %+v", factor)
+	}
+}
+
+func TestTransformFormatTimesOut(t *testing.T) {
+	t.Setenv("MORFX_FORMAT_TIMEOUT", "50ms")
+	provider := formatTestProvider("sleep", "5")
+
+	result := provider.Transform(formatTestSource, replaceMainOp())
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	factor := formattedFactor(t, result.Confidence)
+	if !strings.Contains(factor.Reason, "timed out after 50ms") {
+		t.Fatalf("expected timeout factor, got %+v", factor)
+	}
+}
+
+func TestTransformFormatEnvironmentOverride(t *testing.T) {
+	provider := formatTestProvider("morfx-missing-formatter")
+
+	t.Setenv("MORFX_FORMAT_FAKE", "sed s/___/_/")
+	result := provider.Transform(formatTestSource, replaceMainOp())
+	if factor := formattedFactor(t, result.Confidence); factor.Reason != "Output already matches sed" {
+		t.Fatalf("expected override command to run, got %+v", factor)
+	}
+
+	t.Setenv("MORFX_FORMAT_FAKE", "off")
+	result = provider.Transform(formatTestSource, replaceMainOp())
+	if factor := formattedFactor(t, result.Confidence); !strings.Contains(factor.Reason, "No formatter configured") {
+		t.Fatalf("expected formatting disabled, got %+v", factor)
+	}
+}
+
+func TestTransformWithoutFormatSkipsFormatter(t *testing.T) {
+	provider := formatTestProvider("morfx-missing-formatter")
+	op := replaceMainOp()
+	op.Format = false
+
+	result := provider.Transform(formatTestSource, op)
+	for _, factor := range result.Confidence.Factors {
+		if factor.Name == "formatted" {
+			t.Fatalf("formatter should not run without format, got %+v", factor)
+		}
+	}
+}
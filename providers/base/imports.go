@@ -0,0 +1,170 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// ImportConfig lets language configs manage import declarations for the
+// ensure_import and remove_import transforms and the organize_imports option.
+type ImportConfig interface {
+	// EnsureImport adds the import written in spec, using the language's own
+	// import syntax, unless an equivalent import already exists.
+	EnsureImport(source string, root *sitter.Node, spec string) (string, error)
+	// RemoveImports removes the imported bindings held by targets and drops
+	// declarations left empty.
+	RemoveImports(source string, root *sitter.Node, targets []Target) (string, error)
+	// UnusedImports returns one target per imported binding that the source
+	// never references.
+	UnusedImports(source string, root *sitter.Node) []Target
+	// MissingImports returns import specs for references that resolve to a
+	// well-known module, such as a standard library package, without an import.
+	MissingImports(source string, root *sitter.Node) []string
+}
+
+func (p *Provider) importConfig() (ImportConfig, error) {
+	importer, ok := p.config.(ImportConfig)
+	if !ok {
+		return nil, fmt.Errorf("import management is not supported for %s", p.config.Language())
+	}
+	return importer, nil
+}
+
+// ensureImport applies the ensure_import transform, which needs no target.
+func (p *Provider) ensureImport(parser *parserAdapter, source string, op core.TransformOp) core.TransformResult {
+	importer, err := p.importConfig()
+	if err != nil {
+		return core.TransformResult{Error: err}
+	}
+	spec := strings.TrimSpace(op.Content)
+	if spec == "" {
+		return core.TransformResult{Error: fmt.Errorf("ensure_import requires content with the import to add")}
+	}
+
+	tree := parser.Parse([]byte(source))
+	if tree == nil {
+		return core.TransformResult{Error: fmt.Errorf("failed to parse source")}
+	}
+	defer tree.Close()
+
+	modified, err := importer.EnsureImport(source, tree.RootNode(), spec)
+	if err != nil {
+		return core.TransformResult{Error: err}
+	}
+
+	confidence := core.ConfidenceScore{Score: 1.0, Level: "high"}
+	if modified != source && op.Format {
+		var factor core.ConfidenceFactor
+		modified, factor = p.format(modified)
+		confidence.Factors = append(confidence.Factors, factor)
+		confidence.Score += factor.Impact
+	}
+	if modified == source {
+		confidence.Factors = append(confidence.Factors, core.ConfidenceFactor{
+			Name:   "import_present",
+			Impact: 0.0,
+			Reason: "Import already present, nothing to change",
+		})
+		return core.TransformResult{Modified: source, Confidence: confidence}
+	}
+
+	p.adjustConfidence(&confidence, op, source, modified, nil)
+	return core.TransformResult{
+		Modified:   modified,
+		Diff:       p.generateDiff(source, modified),
+		Confidence: confidence,
+		MatchCount: 1,
+	}
+}
+
+func (p *Provider) doRemoveImports(source string, root *sitter.Node, targets []Target) (string, error) {
+	importer, err := p.importConfig()
+	if err != nil {
+		return source, err
+	}
+	return importer.RemoveImports(source, root, targets)
+}
+
+// organizeImports prunes imports that became unused and adds imports that
+// became missing between original and modified. Imports that were already
+// unused or missing before the transform are left alone.
+func (p *Provider) organizeImports(parser *parserAdapter, original, modified string) (string, []string, []string, error) {
+	importer, err := p.importConfig()
+	if err != nil {
+		return modified, nil, nil, err
+	}
+
+	before := parser.Parse([]byte(original))
+	if before == nil {
+		return modified, nil, nil, fmt.Errorf("failed to parse source")
+	}
+	unusedBefore := make(map[string]struct{})
+	for _, target := range importer.UnusedImports(original, before.RootNode()) {
+		unusedBefore[importTargetKey(target, original)] = struct{}{}
+	}
+	missingBefore := make(map[string]struct{})
+	for _, spec := range importer.MissingImports(original, before.RootNode()) {
+		missingBefore[spec] = struct{}{}
+	}
+	before.Close()
+
+	var removed, added []string
+
+	after := parser.Parse([]byte(modified))
+	if after == nil {
+		return modified, nil, nil, fmt.Errorf("failed to parse transformed source")
+	}
+	var stale []Target
+	for _, target := range importer.UnusedImports(modified, after.RootNode()) {
+		if _, existed := unusedBefore[importTargetKey(target, modified)]; !existed {
+			stale = append(stale, target)
+			removed = append(removed, target.Name)
+		}
+	}
+	if len(stale) > 0 {
+		modified, err = importer.RemoveImports(modified, after.RootNode(), stale)
+		if err != nil {
+			after.Close()
+			return modified, nil, nil, err
+		}
+	}
+	after.Close()
+
+	for _, spec := range p.missingImportsOf(parser, importer, modified) {
+		if _, existed := missingBefore[spec]; existed {
+			continue
+		}
+		tree := parser.Parse([]byte(modified))
+		if tree == nil {
+			return modified, removed, added, fmt.Errorf("failed to parse transformed source")
+		}
+		updated, err := importer.EnsureImport(modified, tree.RootNode(), spec)
+		tree.Close()
+		if err != nil {
+			return modified, removed, added, err
+		}
+		if updated != modified {
+			modified = updated
+			added = append(added, spec)
+		}
+	}
+
+	return modified, removed, added, nil
+}
+
+func (p *Provider) missingImportsOf(parser *parserAdapter, importer ImportConfig, source string) []string {
+	tree := parser.Parse([]byte(source))
+	if tree == nil {
+		return nil
+	}
+	defer tree.Close()
+	return importer.MissingImports(source, tree.RootNode())
+}
+
+func importTargetKey(target Target, source string) string {
+	return target.Name + "|" + nodeContent(target.Node, source)
+}
@@ -0,0 +1,313 @@
+package base
+
+import (
+	"context"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// IndentUnitConfig lets language configs name the indentation unit to use
+// when a source has no indented lines to detect it from.
+type IndentUnitConfig interface {
+	IndentUnit() string
+}
+
+// indentUnit reports the indentation unit of source, falling back to the
+// language's conventional unit and then to a tab.
+func (p *Provider) indentUnit(source string) string {
+	if unit, ok := detectIndentUnit(source); ok {
+		return unit
+	}
+	if config, ok := p.config.(IndentUnitConfig); ok {
+		return config.IndentUnit()
+	}
+	return "\t"
+}
+
+// reindent normalizes a multi-line snippet to the file's indentation unit and
+// indents every line after the first with indent. The first line is left to
+// the caller, which places it after existing indentation or a prefix. Lines
+// that start inside a string literal are program data and kept as written.
+func (p *Provider) reindent(source, text, indent string) string {
+	if !strings.Contains(text, "\n") {
+		return text
+	}
+	literal := p.literalLines(text)
+	lines := strings.Split(normalizeSnippet(text, p.indentUnit(source), literal), "\n")
+	indentLines(lines, 1, indent, literal)
+	return strings.Join(lines, "\n")
+}
+
+// literalLines parses text on its own and returns the indexes of its lines
+// that start inside a multi-line string literal, such as a Python
+// triple-quoted string, a Go raw string, or a template literal.
+func (p *Provider) literalLines(text string) map[int]bool {
+	prefix := ""
+	if config, ok := p.config.(SnippetPrefixConfig); ok {
+		prefix = config.SnippetPrefix()
+	}
+	root, err := sitter.ParseCtx(context.Background(), []byte(prefix+text), p.config.GetLanguage())
+	if err != nil || root == nil {
+		return nil
+	}
+	literal := make(map[int]bool)
+	collectLiteralLines(root, uint32(len(prefix)), strings.Count(prefix, "\n"), literal)
+	return literal
+}
+
+// collectLiteralLines marks the rows after the first of each multi-line
+// string literal under node that starts at or after offset, shifted up by
+// skipRows.
+func collectLiteralLines(node *sitter.Node, offset uint32, skipRows int, literal map[int]bool) {
+	if node == nil || node.EndByte() <= offset || node.StartPoint().Row == node.EndPoint().Row {
+		return
+	}
+	if node.IsNamed() && node.StartByte() >= offset && isStringLiteral(node.Type()) {
+		for row := int(node.StartPoint().Row) + 1; row <= int(node.EndPoint().Row); row++ {
+			literal[row-skipRows] = true
+		}
+		return
+	}
+	for i := 0; i < int(node.ChildCount()); i++ {
+		collectLiteralLines(node.Child(i), offset, skipRows, literal)
+	}
+}
+
+// isStringLiteral reports whether nodeType is a string literal, including
+// PHP heredocs and nowdocs.
+func isStringLiteral(nodeType string) bool {
+	return strings.Contains(nodeType, "string") || nodeType == "heredoc" || nodeType == "nowdoc"
+}
+
+// DetectIndentUnit reports the indentation unit used by source: a tab when
+// indented lines start with tabs, otherwise the smallest run of leading spaces.
+// It falls back to a tab for sources without indented lines.
+func DetectIndentUnit(source string) string {
+	unit, _ := detectIndentUnit(source)
+	return unit
+}
+
+// detectIndentUnit is DetectIndentUnit that also reports whether source had
+// any indented line to detect from.
+func detectIndentUnit(source string) (string, bool) {
+	tabs, spaces := 0, 0
+	smallest := 0
+	for line := range strings.SplitSeq(source, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		switch line[0] {
+		case '\t':
+			tabs++
+		case ' ':
+			spaces++
+			width := len(line) - len(strings.TrimLeft(line, " "))
+			if smallest == 0 || width < smallest {
+				smallest = width
+			}
+		}
+	}
+	if spaces > tabs && smallest > 0 {
+		return strings.Repeat(" ", smallest), true
+	}
+	return "\t", tabs > 0
+}
+
+// Dedent removes the longest whitespace prefix shared by every non-blank line.
+func Dedent(text string) string {
+	lines := strings.Split(text, "\n")
+	dedentLines(lines, nil)
+	return strings.Join(lines, "\n")
+}
+
+// dedentLines is Dedent over lines, leaving the literal lines alone.
+func dedentLines(lines []string, literal map[int]bool) {
+	prefix := ""
+	first := true
+	for i, line := range lines {
+		if literal[i] || strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix = indent
+			first = false
+			continue
+		}
+		prefix = commonPrefix(prefix, indent)
+	}
+	if prefix == "" {
+		return
+	}
+	for i, line := range lines {
+		switch {
+		case literal[i]:
+		case strings.TrimSpace(line) == "":
+			lines[i] = ""
+		default:
+			lines[i] = strings.TrimPrefix(line, prefix)
+		}
+	}
+}
+
+// IndentLines prefixes every non-blank line of text with indent.
+func IndentLines(text, indent string) string {
+	if indent == "" {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	indentLines(lines, 0, indent, nil)
+	return strings.Join(lines, "\n")
+}
+
+// indentLines is IndentLines over lines[from:], leaving the literal lines
+// alone.
+func indentLines(lines []string, from int, indent string, literal map[int]bool) {
+	if indent == "" {
+		return
+	}
+	for i := from; i < len(lines); i++ {
+		switch {
+		case literal[i]:
+		case strings.TrimSpace(lines[i]) == "":
+			lines[i] = ""
+		default:
+			lines[i] = indent + lines[i]
+		}
+	}
+}
+
+// NormalizeSnippet rewrites the indentation of a multi-line snippet so that
+// its first line sits at column zero and nested lines use unit. A snippet
+// whose first line was trimmed, as in a node's text, may carry its original
+// indentation on the remaining lines only; that indentation is inferred from
+// the closing line or from the opener ending the first line.
+func NormalizeSnippet(text, unit string) string {
+	return normalizeSnippet(text, unit, nil)
+}
+
+// normalizeSnippet is NormalizeSnippet leaving the literal lines alone.
+func normalizeSnippet(text, unit string, literal map[int]bool) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) < 2 {
+		return text
+	}
+
+	if leadingWhitespace(lines[0]) != "" {
+		dedentLines(lines, literal)
+	} else if base := snippetBaseIndent(lines, unit, literal); base != "" {
+		for i := 1; i < len(lines); i++ {
+			if !literal[i] {
+				lines[i] = strings.TrimPrefix(lines[i], base)
+			}
+		}
+	}
+
+	code := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if !literal[i] {
+			code = append(code, line)
+		}
+	}
+	from, ok := detectIndentUnit(strings.Join(code, "\n"))
+	if !ok || from == unit || unit == "" || continuesBracket(lines[0]) {
+		return strings.Join(lines, "\n")
+	}
+	for i, line := range lines {
+		indent := leadingWhitespace(line)
+		if indent == "" || literal[i] {
+			continue
+		}
+		levels := 0
+		rest := indent
+		for strings.HasPrefix(rest, from) {
+			rest = rest[len(from):]
+			levels++
+		}
+		lines[i] = strings.Repeat(unit, levels) + rest + line[len(indent):]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// snippetBaseIndent infers the indentation the unindented first line of a
+// snippet originally had, given the indentation of the lines after it.
+func snippetBaseIndent(lines []string, unit string, literal map[int]bool) string {
+	common := ""
+	first := true
+	last := ""
+	for i, line := range lines {
+		if i == 0 || literal[i] || strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := leadingWhitespace(line)
+		if first {
+			common = indent
+			first = false
+		} else {
+			common = commonPrefix(common, indent)
+		}
+		last = line
+	}
+	if common == "" {
+		return ""
+	}
+
+	// A closing line sits at the level of the first line.
+	trimmedLast := strings.TrimLeft(last, " \t")
+	if strings.HasPrefix(trimmedLast, "}") || strings.HasPrefix(trimmedLast, ")") || strings.HasPrefix(trimmedLast, "]") {
+		return leadingWhitespace(last)
+	}
+
+	// Lines after an opener sit one level deeper than the first line.
+	opener := strings.TrimRight(lines[0], " \t")
+	if strings.HasSuffix(opener, ":") || strings.HasSuffix(opener, "{") ||
+		strings.HasSuffix(opener, "(") || strings.HasSuffix(opener, "[") {
+		if strings.HasSuffix(common, unit) {
+			return strings.TrimSuffix(common, unit)
+		}
+		if common[0] == '\t' {
+			return common[1:]
+		}
+		return ""
+	}
+
+	// Lines continuing an open bracket keep their alignment.
+	if continuesBracket(opener) {
+		return ""
+	}
+	return common
+}
+
+// continuesBracket reports whether line leaves a bracket open mid-line, so
+// the lines after it are aligned continuations rather than nested blocks.
+func continuesBracket(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	if line == "" || strings.ContainsAny(line[len(line)-1:], "([{") {
+		return false
+	}
+	return strings.Count(line, "(")+strings.Count(line, "[")+strings.Count(line, "{") >
+		strings.Count(line, ")")+strings.Count(line, "]")+strings.Count(line, "}")
+}
+
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+func commonPrefix(a, b string) string {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return a[:i]
+		}
+	}
+	return a[:n]
+}
+
+// lineStartOffset returns the byte offset of the start of the line containing offset.
+func lineStartOffset(source string, offset int) int {
+	if offset > len(source) {
+		offset = len(source)
+	}
+	return strings.LastIndex(source[:offset], "\n") + 1
+}
@@ -0,0 +1,70 @@
+package base
+
+import "testing"
+
+func TestDetectIndentUnit(t *testing.T) {
+	cases := map[string]string{
+		"func main() {\n\treturn\n}\n":                     "\t",
+		"def run():\n    x = 1\n    if x:\n        pass\n": "    ",
+		"class A {\n  run() {\n    go();\n  }\n}\n":        "  ",
+		"x = 1\n": "\t",
+	}
+	for source, want := range cases {
+		if got := DetectIndentUnit(source); got != want {
+			t.Fatalf("DetectIndentUnit(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestDedentAndIndentLines(t *testing.T) {
+	text := "\t\tif ok {\n\t\t\trun()\n\n\t\t}"
+	dedented := Dedent(text)
+	if dedented != "if ok {\n\trun()\n\n}" {
+		t.Fatalf("unexpected dedent result: %q", dedented)
+	}
+	if indented := IndentLines(dedented, "  "); indented != "  if ok {\n  \trun()\n\n  }" {
+		t.Fatalf("unexpected indent result: %q", indented)
+	}
+}
+
+func TestParseLineRange(t *testing.T) {
+	cases := []struct {
+		value       string
+		first, last int
+		ok          bool
+	}{
+		{"3..7", 3, 7, true},
+		{"4", 4, 4, true},
+		{`"2..2"`, 2, 2, true},
+		{"7..3", 0, 0, false},
+		{"0..2", 0, 0, false},
+		{"a..b", 0, 0, false},
+	}
+	for _, tc := range cases {
+		first, last, ok := parseLineRange(tc.value)
+		if ok != tc.ok || first != tc.first || last != tc.last {
+			t.Fatalf("parseLineRange(%q) = %d, %d, %v", tc.value, first, last, ok)
+		}
+	}
+}
+
+func TestNormalizeSnippet(t *testing.T) {
+	cases := []struct {
+		name, text, unit, want string
+	}{
+		{"single line", "return 1", "    ", "return 1"},
+		{"indented first line", "    def run(self):\n        return 1", "    ", "def run(self):\n    return 1"},
+		{"relative body", "def run(self):\n  return 1", "    ", "def run(self):\n    return 1"},
+		{"absolute body after opener", "def run(self):\n        return 1", "    ", "def run(self):\n    return 1"},
+		{"absolute closer", "func run() {\n\t\tgo()\n\t}", "\t", "func run() {\n\tgo()\n}"},
+		{"spaces to tabs", "func run() {\n    if ok {\n        go()\n    }\n}", "\t", "func run() {\n\tif ok {\n\t\tgo()\n\t}\n}"},
+		{"tabs to spaces", "run() {\n\tgo();\n}", "  ", "run() {\n  go();\n}"},
+		{"statement sequence", "a = 1\n    b = 2", "    ", "a = 1\nb = 2"},
+		{"alignment kept", "call(a,\n     b)", "\t", "call(a,\n     b)"},
+	}
+	for _, tc := range cases {
+		if got := NormalizeSnippet(tc.text, tc.unit); got != tc.want {
+			t.Fatalf("%s: NormalizeSnippet(%q) = %q, want %q", tc.name, tc.text, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package base
+
+import (
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// selectsMembers reports whether method resolves its target with
+// memberTargets.
+func selectsMembers(method string) bool {
+	switch method {
+	case "sort", "add_annotation", "remove_annotation", "add_tag", "remove_tag", "rewrite_tag",
+		"set_docstring", "annotate":
+		return true
+	default:
+		return false
+	}
+}
+
+// memberTargets resolves targets for methods that act on members. A
+// containment selector such as "class:A > method:*" names the container on
+// the left and the members on the right, so it yields the methods inside A
+// rather than A itself; a plain selector yields every match.
+func (p *Provider) memberTargets(root *sitter.Node, source string, query core.AgentQuery) []Target {
+	if strings.EqualFold(query.Operator, "OR") {
+		var members []Target
+		seen := make(map[[2]uint32]bool)
+		for _, operand := range query.Operands {
+			for _, member := range p.memberTargets(root, source, operand) {
+				key := [2]uint32{member.StartByte, member.EndByte}
+				if !seen[key] {
+					seen[key] = true
+					members = append(members, member)
+				}
+			}
+		}
+		return members
+	}
+	if query.Contains == nil || query.Operator != "" {
+		return p.findTargets(root, source, query)
+	}
+
+	var members []Target
+	for _, container := range p.findTargets(root, source, query) {
+		if !query.ContainsDirect {
+			members = append(members, p.findTargetsBelow(container.Node, source, *query.Contains)...)
+			continue
+		}
+		for _, child := range directSemanticChildren(container.Node) {
+			members = append(members, p.candidateTargets(child, source, *query.Contains)...)
+		}
+	}
+	return members
+}
+
+// enclosingDeclaration returns the declaration a target names when the
+// target is only the leading part of it, such as the variable of a PHP
+// property declaration. Other targets are returned as they are.
+func enclosingDeclaration(node *sitter.Node) *sitter.Node {
+	if isDeclaration(node.Type()) {
+		return node
+	}
+	for current := node; current.Parent() != nil; current = current.Parent() {
+		parent := current.Parent()
+		if firstMember(parent) != current {
+			return node
+		}
+		if isDeclaration(parent.Type()) {
+			return parent
+		}
+	}
+	return node
+}
+
+// firstMember returns the first named child of node that is not a
+// decorator, modifier, or comment.
+func firstMember(node *sitter.Node) *sitter.Node {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if !decoratorTypes[child.Type()] && !strings.Contains(child.Type(), "modifier") && !strings.Contains(child.Type(), "comment") {
+			return child
+		}
+	}
+	return nil
+}
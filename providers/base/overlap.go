@@ -0,0 +1,116 @@
+package base
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// Overlap policies decide which of several overlapping targets an edit keeps.
+const (
+	OverlapOutermost = "outermost"
+	OverlapInnermost = "innermost"
+	OverlapError     = "error"
+)
+
+// splicesTargets reports whether method rewrites target byte ranges in place,
+// where overlapping ranges would corrupt the output.
+func splicesTargets(method string) bool {
+	return method == "replace" || method == "delete" || method == "sort"
+}
+
+// resolveOverlaps applies the overlap policy to targets. It returns the
+// targets to edit in source order and the ones dropped in their favour.
+// Targets that only partially overlap are treated like nested ones: the
+// larger range is the outer one.
+func resolveOverlaps(targets []Target, policy string) ([]Target, []Target, error) {
+	switch policy {
+	case "", OverlapOutermost, OverlapInnermost, OverlapError:
+	default:
+		return nil, nil, fmt.Errorf("unknown overlap policy %q (want outermost, innermost, or error)", policy)
+	}
+
+	if policy == OverlapError {
+		if conflicts := overlappingPairs(targets); len(conflicts) > 0 {
+			return nil, nil, fmt.Errorf("%w: %s", core.ErrOverlappingTargets, strings.Join(conflicts, "; "))
+		}
+		return targets, nil, nil
+	}
+
+	order := make([]Target, len(targets))
+	copy(order, targets)
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i].EndByte-order[i].StartByte, order[j].EndByte-order[j].StartByte
+		if policy == OverlapInnermost {
+			return a < b
+		}
+		return a > b
+	})
+
+	var kept, dropped []Target
+	for _, target := range order {
+		conflict := false
+		for _, other := range kept {
+			if targetsOverlap(target, other) {
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			dropped = append(dropped, target)
+		} else {
+			kept = append(kept, target)
+		}
+	}
+	if len(dropped) == 0 {
+		return targets, nil, nil
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].StartByte < kept[j].StartByte })
+	sort.SliceStable(dropped, func(i, j int) bool { return dropped[i].StartByte < dropped[j].StartByte })
+	return kept, dropped, nil
+}
+
+func targetsOverlap(a, b Target) bool {
+	return a.StartByte < b.EndByte && b.StartByte < a.EndByte
+}
+
+func overlappingPairs(targets []Target) []string {
+	var pairs []string
+	for i := range targets {
+		for j := i + 1; j < len(targets); j++ {
+			if targetsOverlap(targets[i], targets[j]) {
+				pairs = append(pairs, describeTarget(targets[i])+" overlaps "+describeTarget(targets[j]))
+			}
+		}
+	}
+	return pairs
+}
+
+func describeTarget(target Target) string {
+	line := target.Line + 1
+	if target.Node != nil {
+		if end := target.Node.EndPoint().Row + 1; end != line {
+			return fmt.Sprintf("%s %s (lines %d-%d)", target.Type, target.Name, line, end)
+		}
+	}
+	return fmt.Sprintf("%s %s (line %d)", target.Type, target.Name, line)
+}
+
+// overlapFactor records the matches dropped by the overlap policy.
+func overlapFactor(policy string, dropped []Target) core.ConfidenceFactor {
+	if policy == "" {
+		policy = OverlapOutermost
+	}
+	names := make([]string, 0, len(dropped))
+	for _, target := range dropped {
+		names = append(names, describeTarget(target))
+	}
+	return core.ConfidenceFactor{
+		Name:   "overlapping_targets",
+		Impact: -0.1,
+		Reason: fmt.Sprintf("Dropped %d overlapping matches (%s wins): %s", len(dropped), policy, strings.Join(names, ", ")),
+	}
+}
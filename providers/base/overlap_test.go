@@ -0,0 +1,78 @@
+package base
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func overlapTarget(name string, start, end uint32) Target {
+	target := Target{}
+	target.Type = "function"
+	target.Name = name
+	target.StartByte = start
+	target.EndByte = end
+	return target
+}
+
+func targetNames(targets []Target) string {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestResolveOverlapsPolicies(t *testing.T) {
+	targets := []Target{
+		overlapTarget("outer", 0, 100),
+		overlapTarget("inner", 10, 20),
+		overlapTarget("sibling", 30, 40),
+		overlapTarget("apart", 120, 130),
+	}
+
+	cases := []struct {
+		policy, kept, dropped string
+	}{
+		{"", "outer,apart", "inner,sibling"},
+		{OverlapOutermost, "outer,apart", "inner,sibling"},
+		{OverlapInnermost, "inner,sibling,apart", "outer"},
+	}
+	for _, tc := range cases {
+		kept, dropped, err := resolveOverlaps(targets, tc.policy)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.policy, err)
+		}
+		if targetNames(kept) != tc.kept || targetNames(dropped) != tc.dropped {
+			t.Fatalf("%q: kept %s dropped %s", tc.policy, targetNames(kept), targetNames(dropped))
+		}
+	}
+}
+
+func TestResolveOverlapsKeepsDisjointTargets(t *testing.T) {
+	targets := []Target{overlapTarget("b", 20, 30), overlapTarget("a", 0, 10)}
+	kept, dropped, err := resolveOverlaps(targets, OverlapOutermost)
+	if err != nil || len(dropped) != 0 || targetNames(kept) != "b,a" {
+		t.Fatalf("disjoint targets should pass through unchanged, got %s / %s / %v",
+			targetNames(kept), targetNames(dropped), err)
+	}
+}
+
+func TestResolveOverlapsErrorPolicy(t *testing.T) {
+	_, _, err := resolveOverlaps([]Target{
+		overlapTarget("outer", 0, 100),
+		overlapTarget("inner", 10, 20),
+	}, OverlapError)
+	if !errors.Is(err, core.ErrOverlappingTargets) {
+		t.Fatalf("expected ErrOverlappingTargets, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "function outer (line 1) overlaps function inner (line 1)") {
+		t.Fatalf("expected conflicting targets in error, got %v", err)
+	}
+
+	if _, _, err := resolveOverlaps(nil, "widest"); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+}
@@ -2,6 +2,7 @@ package base
 
 import (
 	"fmt"
+	"maps"
 	"math"
 	"path"
 	"regexp"
@@ -159,13 +160,50 @@ func (p *Provider) Query(source string, query core.AgentQuery) core.QueryResult
 	}
 }
 
-// Transform applies a transformation operation
+// Transform applies a transformation operation. When op.StructuralDiff is
+// set, results that change the source carry a structural diff in
+// Metadata["structural_diff"]; deletes and replaces also list the
+// declarations whose callers may break in Metadata["affected_names"].
 func (p *Provider) Transform(source string, op core.TransformOp) core.TransformResult {
-	op.Target = p.normalizeQuery(op.Target)
-
 	parser := p.borrowParser()
 	defer p.releaseParser(parser)
 
+	result := p.transform(parser, source, op)
+	if result.Error != nil {
+		return result
+	}
+	if op.StructuralDiff {
+		if changes := p.structuralDiff(parser, source, result.Modified); len(changes) > 0 {
+			if result.Metadata == nil {
+				result.Metadata = make(map[string]any)
+			}
+			result.Metadata["structural_diff"] = changes
+		}
+	}
+	if op.Method == "delete" || op.Method == "replace" {
+		if names := p.affectedNames(parser, source, result.Modified); len(names) > 0 {
+			if result.Metadata == nil {
+				result.Metadata = make(map[string]any)
+			}
+			result.Metadata["affected_names"] = names
+		}
+	}
+	return result
+}
+
+func (p *Provider) transform(parser *parserAdapter, source string, op core.TransformOp) core.TransformResult {
+	op.Target = p.normalizeQuery(op.Target)
+
+	// ensure_import works on the file's import section and needs no target
+	if op.Method == "ensure_import" {
+		return p.ensureImport(parser, source, op)
+	}
+
+	// batch re-enters transform once per step on the evolving source
+	if op.Method == "batch" {
+		return p.transformBatch(parser, source, op)
+	}
+
 	tree, hit := p.cache.GetOrParse(parser, []byte(source))
 	if tree == nil {
 		err := fmt.Errorf("failed to parse source")
@@ -179,6 +217,11 @@ func (p *Provider) Transform(source string, op core.TransformOp) core.TransformR
 	// For append without a target, use root node directly
 	if op.Method == "append" && op.Target.Type == "" && op.Target.Name == "" {
 		root := tree.RootNode()
+		if op.IfAbsent {
+			if _, skipped := p.dropPresentTargets(source, []Target{NewTarget(root, "", "")}, op); skipped > 0 {
+				return alreadyPresentResult(source, skipped)
+			}
+		}
 		confidence := core.ConfidenceScore{
 			Score: 1.0,
 			Level: "high",
@@ -204,19 +247,80 @@ func (p *Provider) Transform(source string, op core.TransformOp) core.TransformR
 	}
 
 	// Find targets
-	matches := p.findTargets(tree.RootNode(), source, op.Target)
+	var matches []Target
+	if selectsMembers(op.Method) {
+		matches = p.memberTargets(tree.RootNode(), source, op.Target)
+	} else {
+		matches = p.findTargets(tree.RootNode(), source, op.Target)
+	}
 	if len(matches) == 0 {
 		return core.TransformResult{
 			Error: core.ErrNoMatchesFound,
 		}
 	}
 
+	// Overlapping ranges would corrupt in-place splicing
+	var dropped []Target
+	if splicesTargets(op.Method) {
+		include, err := includesAttached(op.Method, op.Attached)
+		if err != nil {
+			return core.TransformResult{Error: err}
+		}
+		if include {
+			matches = withAttached(source, matches)
+		} else if op.Method == "replace" && p.leadsWithDecorator(op.Replacement) {
+			matches = withDecorators(source, matches)
+		}
+		matches, dropped, err = resolveOverlaps(matches, op.Overlap)
+		if err != nil {
+			return core.TransformResult{Error: err}
+		}
+	}
+
+	// A selector broader or narrower than expected aborts before any edit
+	if op.ExpectMatches != "" {
+		if err := checkExpectedMatches(op.ExpectMatches, matches); err != nil {
+			return core.TransformResult{Error: err}
+		}
+	}
+
+	// Skip destinations that already hold the content
+	skipped := 0
+	if op.IfAbsent && insertsContent(op.Method) {
+		matches, skipped = p.dropPresentTargets(source, matches, op)
+		if len(matches) == 0 {
+			return alreadyPresentResult(source, skipped)
+		}
+	}
+
 	// Calculate confidence
 	confidence := p.calculateConfidence(op, matches, source)
+	if skipped > 0 {
+		confidence.Factors = append(confidence.Factors, presentFactor(skipped))
+	}
+	if checksSnippetKind(op.Method) {
+		if reason := p.snippetKindMismatch(source, tree.RootNode(), op, matches); reason != "" {
+			factor := snippetKindFactor(reason)
+			confidence.Factors = append(confidence.Factors, factor)
+			confidence.Score += factor.Impact
+		}
+	}
 	var (
-		modified string
-		err      error
+		modified   string
+		metadata   map[string]any
+		opMetadata map[string]any
+		err        error
 	)
+	if len(dropped) > 0 {
+		factor := overlapFactor(op.Overlap, dropped)
+		confidence.Factors = append(confidence.Factors, factor)
+		confidence.Score += factor.Impact
+		droppedMatches := make([]core.Match, 0, len(dropped))
+		for _, target := range dropped {
+			droppedMatches = append(droppedMatches, p.targetToMatch(source, target))
+		}
+		metadata = map[string]any{"dropped_matches": droppedMatches}
+	}
 
 	switch op.Method {
 	case "replace":
@@ -229,6 +333,30 @@ func (p *Provider) Transform(source string, op core.TransformOp) core.TransformR
 		modified, err = p.doInsertAfter(source, matches, op.Content)
 	case "append":
 		modified, err = p.doAppendToTarget(source, matches, op.Content)
+	case "extract_function":
+		modified, opMetadata, err = p.doExtractFunction(source, tree.RootNode(), matches, op)
+	case "remove_import":
+		modified, err = p.doRemoveImports(source, tree.RootNode(), matches)
+	case "sort":
+		modified, opMetadata, err = p.doSort(source, matches, op)
+	case "add_annotation":
+		modified, opMetadata, err = p.doAddAnnotation(source, matches, op)
+		if skipped, _ := opMetadata["annotations_skipped"].(int); skipped > 0 {
+			confidence.Factors = append(confidence.Factors, presentFactor(skipped))
+		}
+	case "remove_annotation":
+		modified, opMetadata, err = p.doRemoveAnnotations(source, matches, op)
+	case "add_tag", "remove_tag", "rewrite_tag":
+		modified, opMetadata, err = p.doEditTags(source, matches, op)
+		if skipped, _ := opMetadata["fields_skipped"].([]string); len(skipped) > 0 {
+			factor := skippedFieldsFactor(skipped)
+			confidence.Factors = append(confidence.Factors, factor)
+			confidence.Score += factor.Impact
+		}
+	case "set_docstring":
+		modified, opMetadata, err = p.doSetDocstring(source, matches, op)
+	case "annotate":
+		modified, opMetadata, err = p.doAnnotate(source, matches, op)
 	default:
 		return core.TransformResult{
 			Error: fmt.Errorf("unknown transform method: %s", op.Method),
@@ -238,6 +366,40 @@ func (p *Provider) Transform(source string, op core.TransformOp) core.TransformR
 	if err != nil {
 		return core.TransformResult{Error: err}
 	}
+	// Keep dropped_matches alongside what the operation reports
+	if len(opMetadata) > 0 {
+		if metadata == nil {
+			metadata = make(map[string]any, len(opMetadata))
+		}
+		maps.Copy(metadata, opMetadata)
+	}
+
+	if op.OrganizeImports && op.Method != "remove_import" {
+		var removed, added []string
+		modified, removed, added, err = p.organizeImports(parser, source, modified)
+		if err != nil {
+			return core.TransformResult{Error: err}
+		}
+		if len(removed) > 0 || len(added) > 0 {
+			if metadata == nil {
+				metadata = make(map[string]any)
+			}
+			metadata["imports_removed"] = removed
+			metadata["imports_added"] = added
+			confidence.Factors = append(confidence.Factors, core.ConfidenceFactor{
+				Name:   "organized_imports",
+				Impact: 0.0,
+				Reason: fmt.Sprintf("Removed %d unused and added %d missing imports", len(removed), len(added)),
+			})
+		}
+	}
+
+	if op.Format {
+		var factor core.ConfidenceFactor
+		modified, factor = p.format(modified)
+		confidence.Factors = append(confidence.Factors, factor)
+		confidence.Score += factor.Impact
+	}
 
 	// Generate diff
 	diff := p.generateDiff(source, modified)
@@ -249,6 +411,7 @@ func (p *Provider) Transform(source string, op core.TransformOp) core.TransformR
 		Diff:       diff,
 		Confidence: confidence,
 		MatchCount: len(matches), // Now shows actual match count including expansions
+		Metadata:   metadata,
 	}
 }
 
@@ -564,6 +727,10 @@ func (p *Provider) matchesAttributes(target Target, source string, attributes ma
 			if !p.matchesSiblingPredicate(target.Node, source, value, false) {
 				return false
 			}
+		case key == "lines":
+			if !matchLineRange(target.Node, value) {
+				return false
+			}
 		default:
 			providerAttributes[key] = value
 		}
@@ -861,17 +1028,70 @@ func (p *Provider) doReplace(source string, targets []Target, replacement string
 			continue
 		}
 
-		before := result[:startPos]
-		after := result[endPos:]
-		result = before + replacement + after
+		edit := p.spliceEdit(source, target, "replace", replacement)
+		result = result[:edit.Start] + edit.Text + result[edit.End:]
 	}
 
 	return result, nil
 }
 
-// doDelete performs deletion transformation
+// doDelete removes each target. A target alone on its lines takes them
+// along, newline included, so no blank or indentation-only line is left,
+// and the blank lines around it merge into the larger of the two runs, or
+// go away at the start of a block and the end of the file.
 func (p *Provider) doDelete(source string, targets []Target) (string, error) {
-	return p.doReplace(source, targets, "")
+	if len(targets) == 0 {
+		return source, fmt.Errorf("no targets to delete")
+	}
+
+	edits := make([]Edit, 0, len(targets))
+	limit := uint32(len(source))
+	for _, target := range sortTargetsDescending(targets) {
+		start, end, whole := wholeLines(source, target.StartByte, target.EndByte)
+		before := blankLinesBefore(source, start)
+		firstInBlock := before == 0 && opensBlock(source, start)
+		for ; whole && (before > 0 || firstInBlock); before-- {
+			next, nextEnd, blank := wholeLines(source, end, end)
+			if !blank || next != end || nextEnd == end {
+				break
+			}
+			end = nextEnd
+		}
+		for whole && int(end) == len(source) && blankLinesBefore(source, start) > 0 {
+			start = uint32(lineStartOffset(source, int(start)-1))
+		}
+		if len(edits) > 0 && edits[len(edits)-1] == (Edit{Start: start, End: end}) {
+			continue
+		}
+		// Blank lines taken along stop where the next deletion starts
+		end = min(end, limit)
+		limit = start
+		edits = append(edits, Edit{Start: start, End: end})
+	}
+	return ApplyEdits(source, edits)
+}
+
+// opensBlock reports whether the line ending at lineStart opens a block,
+// such as a Go function's brace or a Python class header.
+func opensBlock(source string, lineStart uint32) bool {
+	if lineStart == 0 {
+		return false
+	}
+	line := strings.TrimSpace(source[lineStartOffset(source, int(lineStart)-1):lineStart])
+	return strings.HasSuffix(line, "{") || strings.HasSuffix(line, ":")
+}
+
+// blankLinesBefore counts the blank lines that end at lineStart.
+func blankLinesBefore(source string, lineStart uint32) int {
+	count := 0
+	for end := int(lineStart); end > 0; count++ {
+		prev := lineStartOffset(source, end-1)
+		if strings.TrimSpace(source[prev:end]) != "" {
+			break
+		}
+		end = prev
+	}
+	return count
 }
 
 // doInsertBefore performs insertion before target
@@ -893,14 +1113,8 @@ func (p *Provider) doInsertBefore(source string, targets []Target, content strin
 			continue
 		}
 
-		before := result[:startPos]
-		after := result[startPos:]
-
-		// Preserve indentation
-		indent := p.getIndentation(source, target.Node)
-		contentWithIndent := indent + content + "\n"
-
-		result = before + contentWithIndent + after
+		edit := p.spliceEdit(source, target, "insert_before", content)
+		result = result[:edit.Start] + edit.Text + result[edit.End:]
 	}
 
 	return result, nil
@@ -925,17 +1139,41 @@ func (p *Provider) doInsertAfter(source string, targets []Target, content string
 			continue
 		}
 
-		before := result[:endPos]
-		after := result[endPos:]
+		edit := p.spliceEdit(source, target, "insert_after", content)
+		result = result[:edit.Start] + edit.Text + result[edit.End:]
+	}
+
+	return result, nil
+}
 
-		// Preserve indentation
+// spliceEdit returns the edit replace, insert_before, or insert_after makes
+// at one target, with text reindented to the target's column.
+func (p *Provider) spliceEdit(source string, target Target, method, text string) Edit {
+	switch method {
+	case "insert_before":
+		// The target keeps its indentation; the content takes it over
 		indent := p.getIndentation(source, target.Node)
-		contentWithIndent := "\n" + indent + content
-
-		result = before + contentWithIndent + after
+		return Edit{Start: target.StartByte, End: target.StartByte, Text: p.reindent(source, text, indent) + "\n" + indent}
+	case "insert_after":
+		indent := p.getIndentation(source, target.Node)
+		return Edit{Start: target.EndByte, End: target.EndByte, Text: trimLeadingBlankLines("\n" + indent + p.reindent(source, text, indent))}
+	default:
+		indent := leadingWhitespace(source[lineStartOffset(source, int(target.StartByte)):])
+		return Edit{Start: target.StartByte, End: target.EndByte, Text: p.reindent(source, text, indent)}
 	}
+}
 
-	return result, nil
+// trimLeadingBlankLines empties the blank lines text opens with, so
+// indentation carried in front of content that starts with a blank line
+// does not leave a whitespace-only line behind.
+func trimLeadingBlankLines(text string) string {
+	lead := len(text) - len(strings.TrimLeft(text, " \t\r\n"))
+	lastNewline := strings.LastIndex(text[:lead], "\n")
+	if lastNewline < 0 {
+		return text
+	}
+	blank := strings.NewReplacer(" ", "", "\t", "").Replace(text[:lastNewline+1])
+	return blank + text[lastNewline+1:]
 }
 
 // doAppendToTarget appends content to the end of target scope
@@ -1008,8 +1246,14 @@ func (p *Provider) calculateConfidence(
 	score := 1.0
 	factors := []core.ConfidenceFactor{}
 
-	// Factor 1: Number of targets
-	if len(targets) == 1 {
+	// Factor 1: Number of targets; a sort moves members rather than editing them
+	if op.Method == "sort" {
+		factors = append(factors, core.ConfidenceFactor{
+			Name:   "reorder_members",
+			Impact: 0.0,
+			Reason: fmt.Sprintf("Reorders %d members without changing their text", len(targets)),
+		})
+	} else if len(targets) == 1 {
 		score += 0.1
 		factors = append(factors, core.ConfidenceFactor{
 			Name:   "single_target",
@@ -1057,6 +1301,13 @@ func (p *Provider) calculateConfidence(
 				})
 			}
 		}
+	case "extract_function":
+		score -= 0.1
+		factors = append(factors, core.ConfidenceFactor{
+			Name:   "extract_function",
+			Impact: -0.1,
+			Reason: "Parameters and results are inferred from local data flow",
+		})
 	}
 
 	// Factor 3: Pattern specificity
@@ -1069,13 +1320,8 @@ func (p *Provider) calculateConfidence(
 		})
 	}
 
-	// Normalize score
-	if score < 0 {
-		score = 0
-	} else if score > 1 {
-		score = 1
-	}
-
+	// Left unclamped: later factors add to the sum, and adjustConfidence
+	// clamps it once so the factors always add up to the score
 	return core.ConfidenceScore{
 		Score:   score,
 		Level:   confidenceLevel(score),
@@ -0,0 +1,177 @@
+package base
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// signatureFields are the children of a declaration that callers depend on
+// besides its name.
+var signatureFields = []string{"type_parameters", "parameters", "result", "return_type"}
+
+// affectedNames returns the names of declarations in original that modified
+// no longer declares, or declares with different parameters or results, so
+// references elsewhere may no longer resolve or compile. It returns nil when
+// either side does not parse.
+func (p *Provider) affectedNames(parser *parserAdapter, original, modified string) []string {
+	if original == modified {
+		return nil
+	}
+	before := parser.Parse([]byte(original))
+	if before == nil {
+		return nil
+	}
+	defer before.Close()
+	after := parser.Parse([]byte(modified))
+	if after == nil {
+		return nil
+	}
+	defer after.Close()
+	if before.RootNode().HasError() || after.RootNode().HasError() {
+		return nil
+	}
+
+	d := &structDiffer{p: p, old: original, new: modified, kinds: p.declarationNodeKinds()}
+	oldSignatures := signaturesByName(d.collect(before.RootNode(), original), original)
+	newSignatures := signaturesByName(d.collect(after.RootNode(), modified), modified)
+
+	var names []string
+	for name, signatures := range oldSignatures {
+		slices.Sort(signatures)
+		current := newSignatures[name]
+		slices.Sort(current)
+		if !slices.Equal(signatures, current) && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// signaturesByName lists, for each declared name under root, the signature
+// of every declaration with that name.
+func signaturesByName(root *declaration, source string) map[string][]string {
+	signatures := make(map[string][]string)
+	var walk func(decl *declaration)
+	walk = func(decl *declaration) {
+		for _, child := range decl.children {
+			signatures[child.name] = append(signatures[child.name], child.kind+" "+declarationSignature(child.node, source))
+			walk(child)
+		}
+	}
+	walk(root)
+	return signatures
+}
+
+func declarationSignature(node *sitter.Node, source string) string {
+	var parts []string
+	for _, field := range signatureFields {
+		if child := node.ChildByFieldName(field); child != nil {
+			parts = append(parts, strings.Join(strings.Fields(nodeText(child, source)), " "))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// PackageReferenceConfig lets language configs scope references to the
+// packages they are declared in. See core.PackageReferenceFinder for the
+// contract.
+type PackageReferenceConfig interface {
+	ReferencePackage(path, source string) (string, string, error)
+	FindQualifiedReferences(source, importPath, packageName, name string) []core.Location
+}
+
+// ReferencePackage implements core.PackageReferenceFinder for configs that
+// support it.
+func (p *Provider) ReferencePackage(path, source string) (string, string, error) {
+	config, ok := p.config.(PackageReferenceConfig)
+	if !ok {
+		return "", "", fmt.Errorf("%w for %s", core.ErrPackageScopeUnsupported, p.config.Language())
+	}
+	return config.ReferencePackage(path, source)
+}
+
+// IsExported implements core.PackageReferenceFinder. Without packages every
+// name is visible everywhere.
+func (p *Provider) IsExported(name string) bool {
+	if _, ok := p.config.(PackageReferenceConfig); ok {
+		return p.config.IsExported(name)
+	}
+	return true
+}
+
+// FindQualifiedReferences implements core.PackageReferenceFinder.
+func (p *Provider) FindQualifiedReferences(source, importPath, packageName, name string) []core.Location {
+	if config, ok := p.config.(PackageReferenceConfig); ok {
+		return config.FindQualifiedReferences(source, importPath, packageName, name)
+	}
+	return nil
+}
+
+// FindReferences returns the identifiers in source spelled name, leaving out
+// comments, strings, and the names of declarations themselves.
+func (p *Provider) FindReferences(source, name string) []core.Location {
+	if name == "" || !strings.Contains(source, name) {
+		return nil
+	}
+	parser := p.borrowParser()
+	defer p.releaseParser(parser)
+
+	tree := parser.Parse([]byte(source))
+	if tree == nil {
+		return nil
+	}
+	defer tree.Close()
+
+	kinds := p.declarationNodeKinds()
+	var locations []core.Location
+	var walk func(node *sitter.Node)
+	walk = func(node *sitter.Node) {
+		if node.NamedChildCount() == 0 {
+			if isIdentifierNode(node) && nodeText(node, source) == name && !declaresName(node, kinds) {
+				start := node.StartPoint()
+				locations = append(locations, core.Location{Line: int(start.Row) + 1, Column: int(start.Column) + 1})
+			}
+			return
+		}
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			walk(node.NamedChild(i))
+		}
+	}
+	walk(tree.RootNode())
+	return locations
+}
+
+// isIdentifierNode reports whether node is an identifier that can refer to
+// a declaration: Go, Python, and TypeScript name their identifier nodes
+// *identifier, PHP uses name. Package names, PHP namespace segments, and
+// PHP variables are left out.
+func isIdentifierNode(node *sitter.Node) bool {
+	if node.Type() == "package_identifier" {
+		return false
+	}
+	if strings.HasSuffix(node.Type(), "identifier") {
+		return true
+	}
+	if node.Type() != "name" {
+		return false
+	}
+	parent := node.Parent()
+	return parent == nil || (parent.Type() != "variable_name" && parent.Type() != "namespace_name")
+}
+
+// declaresName reports whether node is the name of the declaration it
+// belongs to.
+func declaresName(node *sitter.Node, kinds map[string][]string) bool {
+	parent := node.Parent()
+	if parent == nil || len(kinds[parent.Type()]) == 0 {
+		return false
+	}
+	nameNode := parent.ChildByFieldName("name")
+	return nameNode != nil && nameNode.StartByte() == node.StartByte() && nameNode.EndByte() == node.EndByte()
+}
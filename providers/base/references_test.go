@@ -0,0 +1,71 @@
+package base
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformReportsAffectedNames(t *testing.T) {
+	provider := newTestProvider()
+	source := "package main\n\nfunc helper(a int) int { return a }\n\nfunc Run() { helper(1) }\n"
+
+	tests := []struct {
+		name string
+		op   core.TransformOp
+		want []string
+	}{
+		{
+			name: "delete",
+			op:   core.TransformOp{Method: "delete", Target: core.AgentQuery{Type: "function", Name: "helper"}},
+			want: []string{"helper"},
+		},
+		{
+			name: "rename",
+			op:   core.TransformOp{Method: "replace", Target: core.AgentQuery{Type: "function", Name: "helper"}, Replacement: "func assist(a int) int { return a }"},
+			want: []string{"helper"},
+		},
+		{
+			name: "signature",
+			op:   core.TransformOp{Method: "replace", Target: core.AgentQuery{Type: "function", Name: "helper"}, Replacement: "func helper(a, b int) int { return a + b }"},
+			want: []string{"helper"},
+		},
+		{
+			name: "body only",
+			op:   core.TransformOp{Method: "replace", Target: core.AgentQuery{Type: "function", Name: "helper"}, Replacement: "func helper(a int) int { return a * 2 }"},
+		},
+		{
+			name: "insert",
+			op:   core.TransformOp{Method: "insert_after", Target: core.AgentQuery{Type: "function", Name: "Run"}, Content: "func Other() {}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := provider.Transform(source, tt.op)
+			if result.Error != nil {
+				t.Fatalf("Transform returned error: %v", result.Error)
+			}
+			got, _ := result.Metadata["affected_names"].([]string)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("affected_names = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindReferencesSkipsDeclarationsAndComments(t *testing.T) {
+	provider := newTestProvider()
+	if got := provider.FindReferences("package main\n\nfunc main() {}\n", "main"); len(got) != 0 {
+		t.Fatalf("expected the package clause not to count as a reference, got %+v", got)
+	}
+
+	source := "package main\n\n// helper does things\nfunc helper() {}\n\nfunc Run() {\n\thelper()\n\t_ = \"helper\"\n\tf := helper\n\t_ = f\n}\n"
+
+	got := provider.FindReferences(source, "helper")
+	want := []core.Location{{Line: 7, Column: 2}, {Line: 9, Column: 7}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("FindReferences = %+v, want %+v", got, want)
+	}
+}
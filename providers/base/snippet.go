@@ -0,0 +1,216 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// snippetCheckLimit caps how many targets of one operation have their
+// snippet parsed in place; the snippet is the same for all of them.
+const snippetCheckLimit = 16
+
+// checksSnippetKind reports whether method splices a snippet whose kind can
+// be checked against its target.
+func checksSnippetKind(method string) bool {
+	return method == "replace" || method == "insert_before" || method == "insert_after"
+}
+
+// snippetKindMismatch splices the snippet at each target on its own, in the
+// real surrounding code, and reparses the result. A replacement must parse as
+// the kind of node it replaces, so a method cannot silently become a
+// statement; inserted content must parse as whole siblings of its target
+// rather than merging into a neighbour. Snippets that do not parse at all
+// are left to post-validation. It returns a description of the first
+// mismatch, or "" when every checked target passes.
+func (p *Provider) snippetKindMismatch(source string, root *sitter.Node, op core.TransformOp, targets []Target) string {
+	if root == nil || root.HasError() {
+		return ""
+	}
+	text := op.Replacement
+	if op.Method != "replace" {
+		text = op.Content
+	}
+
+	for i, target := range targets {
+		if i == snippetCheckLimit {
+			break
+		}
+		if target.Node == nil {
+			continue
+		}
+		edit := p.spliceEdit(source, target, op.Method, text)
+		trial := source[:edit.Start] + edit.Text + source[edit.End:]
+		trialRoot, err := sitter.ParseCtx(context.Background(), []byte(trial), p.config.GetLanguage())
+		if err != nil || trialRoot == nil || trialRoot.HasError() {
+			continue
+		}
+
+		start, end := trimmedRange(trial, edit.Start, edit.Start+uint32(len(edit.Text)))
+		if start >= end {
+			continue
+		}
+		parent, nodes := coveringRun(trialRoot, start, end)
+		line := target.Node.StartPoint().Row + 1
+		if parent == nil {
+			return fmt.Sprintf("%s snippet at line %d does not parse as whole nodes in place", op.Method, line)
+		}
+
+		if op.Method != "replace" {
+			if want := siblingParent(target.Node); want != nil && want.Type() != parent.Type() {
+				return fmt.Sprintf("%s content at line %d merges into a %s instead of standing beside the %s", op.Method, line, parent.Type(), target.Node.Type())
+			}
+			continue
+		}
+		accepted := p.acceptedKinds(target)
+		for i, node := range nodes {
+			if !node.IsNamed() || strings.Contains(node.Type(), "comment") {
+				continue
+			}
+			// Decorators may lead the declaration they belong to
+			if decoratorTypes[node.Type()] && accepted["decorated_definition"] && i < len(nodes)-1 {
+				continue
+			}
+			kinds := sameRangeChain(node)
+			// A body holding only the snippet, like the Python block of a
+			// one-statement class, wraps it as the target's parent did.
+			if len(kinds) > 1 && target.Node.Parent() != nil && kinds[0] == target.Node.Parent().Type() {
+				kinds = kinds[1:]
+			}
+			if !slices.ContainsFunc(kinds, func(kind string) bool { return accepted[kind] }) {
+				return fmt.Sprintf("replacement for %s at line %d parses as %s", target.Node.Type(), line, kinds[0])
+			}
+		}
+	}
+	return ""
+}
+
+// snippetKindFactor is the confidence factor for a snippet whose kind does
+// not match its target.
+func snippetKindFactor(reason string) core.ConfidenceFactor {
+	return core.ConfidenceFactor{
+		Name:   "snippet_kind_mismatch",
+		Impact: -0.3,
+		Reason: reason,
+	}
+}
+
+// acceptedKinds returns the node types a replacement for target may parse
+// as: the types its query maps to and those of the target node and its
+// same-range descendants. Same-range ancestors are left out, since a block
+// holding only the target would otherwise accept anything. A declaration
+// may also come back wrapped with its decorators, as a Python
+// decorated_definition.
+func (p *Provider) acceptedKinds(target Target) map[string]bool {
+	accepted := make(map[string]bool)
+	if isDeclaration(target.Node.Type()) {
+		accepted["decorated_definition"] = true
+	}
+	if target.Type != "" {
+		for _, kind := range p.config.MapQueryTypeToNodeTypes(target.Type) {
+			accepted[kind] = true
+		}
+	}
+	for _, kind := range sameRangeChain(target.Node) {
+		accepted[kind] = true
+	}
+	return accepted
+}
+
+// leadsWithDecorator reports whether snippet opens with a decorator, such as
+// a Python function replaced together with its decorators.
+func (p *Provider) leadsWithDecorator(snippet string) bool {
+	node, err := sitter.ParseCtx(context.Background(), []byte(snippet), p.config.GetLanguage())
+	if err != nil || node == nil {
+		return false
+	}
+	for node.NamedChildCount() > 0 {
+		node = firstNamedNonComment(node)
+		if node == nil {
+			return false
+		}
+		if decoratorTypes[node.Type()] {
+			return true
+		}
+	}
+	return false
+}
+
+// firstNamedNonComment returns the first named child of node that is not a
+// comment.
+func firstNamedNonComment(node *sitter.Node) *sitter.Node {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if child := node.NamedChild(i); !strings.Contains(child.Type(), "comment") {
+			return child
+		}
+	}
+	return nil
+}
+
+// sameRangeChain returns the types of node and of the single named
+// descendants that span exactly its range, such as a call inside an
+// expression statement.
+func sameRangeChain(node *sitter.Node) []string {
+	kinds := []string{node.Type()}
+	for node.NamedChildCount() == 1 {
+		child := node.NamedChild(0)
+		if child.StartByte() != node.StartByte() || child.EndByte() != node.EndByte() {
+			break
+		}
+		kinds = append(kinds, child.Type())
+		node = child
+	}
+	return kinds
+}
+
+// coveringRun finds the highest node whose children, taken as a contiguous
+// run, span exactly [start, end). It returns that parent and the run, or nil
+// when the range cuts through a node.
+func coveringRun(node *sitter.Node, start, end uint32) (*sitter.Node, []*sitter.Node) {
+	for {
+		var run []*sitter.Node
+		var inside *sitter.Node
+		for i := 0; i < int(node.ChildCount()); i++ {
+			child := node.Child(i)
+			switch {
+			case child.StartByte() >= start && child.EndByte() <= end:
+				run = append(run, child)
+			case child.StartByte() <= start && child.EndByte() >= end:
+				inside = child
+			}
+		}
+		if len(run) > 0 && run[0].StartByte() == start && run[len(run)-1].EndByte() == end {
+			return node, run
+		}
+		if inside == nil {
+			return nil, nil
+		}
+		node = inside
+	}
+}
+
+// siblingParent returns the node whose children a target sits among,
+// skipping wrappers that span exactly the target.
+func siblingParent(node *sitter.Node) *sitter.Node {
+	parent := node.Parent()
+	for parent != nil && parent.StartByte() == node.StartByte() && parent.EndByte() == node.EndByte() {
+		node, parent = parent, parent.Parent()
+	}
+	return parent
+}
+
+// trimmedRange narrows [start, end) of source past surrounding whitespace.
+func trimmedRange(source string, start, end uint32) (uint32, uint32) {
+	for start < end && strings.ContainsRune(" \t\r\n", rune(source[start])) {
+		start++
+	}
+	for end > start && strings.ContainsRune(" \t\r\n", rune(source[end-1])) {
+		end--
+	}
+	return start, end
+}
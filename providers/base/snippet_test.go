@@ -0,0 +1,117 @@
+package base
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func snippetFactor(result core.TransformResult) *core.ConfidenceFactor {
+	for i, factor := range result.Confidence.Factors {
+		if factor.Name == "snippet_kind_mismatch" {
+			return &result.Confidence.Factors[i]
+		}
+	}
+	return nil
+}
+
+func TestTransformFlagsReplacementOfAnotherKind(t *testing.T) {
+	provider := newTestProvider()
+	source := "package main\n\nfunc Foo() {}\n"
+	target := core.AgentQuery{Type: "function", Name: "Foo"}
+
+	same := provider.Transform(source, core.TransformOp{Method: "replace", Target: target, Replacement: "func Foo() int { return 1 }"})
+	if same.Error != nil {
+		t.Fatalf("Transform returned error: %v", same.Error)
+	}
+	if factor := snippetFactor(same); factor != nil {
+		t.Fatalf("unexpected mismatch for a function replacing a function: %+v", factor)
+	}
+
+	other := provider.Transform(source, core.TransformOp{Method: "replace", Target: target, Replacement: "var Foo = 1"})
+	if other.Error != nil {
+		t.Fatalf("Transform returned error: %v", other.Error)
+	}
+	factor := snippetFactor(other)
+	if factor == nil || factor.Impact >= 0 || !strings.Contains(factor.Reason, "function_declaration at line 3 parses as var_declaration") {
+		t.Fatalf("expected a snippet_kind_mismatch factor, got %+v", other.Confidence.Factors)
+	}
+	if other.Confidence.Score >= same.Confidence.Score {
+		t.Fatalf("expected the mismatch to lower confidence: %.2f >= %.2f", other.Confidence.Score, same.Confidence.Score)
+	}
+}
+
+func TestConfidencePolicyRescoresClampedProviderScore(t *testing.T) {
+	// single_target takes the sum over 1.0 before the mismatch lowers it
+	result := newTestProvider().Transform("package main\n\nfunc foo() {}\n", core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "function", Name: "foo"},
+		Replacement: "var foo = 1",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	factorSum := func(confidence core.ConfidenceScore) float64 {
+		sum := 1.0
+		for _, factor := range confidence.Factors {
+			sum += factor.Impact
+		}
+		return math.Max(0, math.Min(1, sum))
+	}
+	if snippetFactor(result) == nil || math.Abs(result.Confidence.Score-factorSum(result.Confidence)) > 1e-9 {
+		t.Fatalf("expected the factors to add up to %.3f, got %+v", result.Confidence.Score, result.Confidence.Factors)
+	}
+
+	policy := &core.ConfidencePolicy{Factors: map[string]core.FactorRule{"single_target": {Disabled: true}}}
+	got := policy.Score(core.ScoreContext{Language: "go", Method: "replace"}, result.Confidence)
+	if want := result.Confidence.Score - 0.1; math.Abs(got.Score-want) > 1e-9 {
+		t.Fatalf("expected disabling single_target to take off its 0.1, got %.3f want %.3f", got.Score, want)
+	}
+	if math.Abs(got.Score-factorSum(got)) > 1e-9 {
+		t.Fatalf("expected the reported factors to explain %.3f, got %+v", got.Score, got.Factors)
+	}
+}
+
+func TestTransformLeavesUnparseableSnippetsToPostValidation(t *testing.T) {
+	provider := newTestProvider()
+	result := provider.Transform("package main\n\nfunc Foo() {}\n", core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "function", Name: "Foo"},
+		Replacement: "func Foo( {",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if factor := snippetFactor(result); factor != nil {
+		t.Fatalf("expected only post-validation to report a syntax error, got %+v", factor)
+	}
+}
+
+func TestCoveringRun(t *testing.T) {
+	provider := newTestProvider()
+	source := "package main\n\nfunc A() {}\n\nfunc B() {}\n"
+	parser := provider.borrowParser()
+	defer provider.releaseParser(parser)
+	tree, _ := provider.cache.GetOrParse(parser, []byte(source))
+	defer tree.Close()
+	root := tree.RootNode()
+
+	start := uint32(strings.Index(source, "func A"))
+	end := uint32(len(strings.TrimRight(source, "\n")))
+	parent, run := coveringRun(root, start, end)
+	named := 0
+	for _, node := range run {
+		if node.IsNamed() {
+			named++
+		}
+	}
+	if parent == nil || parent.Type() != "source_file" || named != 2 {
+		t.Fatalf("expected both functions under source_file, got %v with %d named nodes", parent, named)
+	}
+
+	if parent, _ := coveringRun(root, start+2, end); parent != nil {
+		t.Fatalf("expected a range cutting through a node to fail, got %s", parent.Type())
+	}
+}
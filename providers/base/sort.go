@@ -0,0 +1,232 @@
+package base
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// defaultVisibilityOrder ranks members when sort_order is not given.
+var defaultVisibilityOrder = []string{"public", "protected", "private"}
+
+// VisibilityConfig lets language configs report a member's visibility for
+// sorting when it is not spelled with a public/protected/private modifier,
+// such as Go's exported names or Python's underscore prefixes.
+type VisibilityConfig interface {
+	MemberVisibility(node *sitter.Node, source string) string
+}
+
+// sortMember is one matched member and the text that moves with it: the
+// body with its leading comments and decorators, and a comment trailing it
+// on the same line, kept apart from any separator in between.
+type sortMember struct {
+	target             Target
+	name               string
+	start, end         uint32
+	tailStart, tailEnd uint32
+	container          *sitter.Node
+	key                string
+	rank               int
+}
+
+// doSort reorders matched members within each container, leaving separators,
+// blank lines, and unmatched siblings where they are.
+func (p *Provider) doSort(source string, targets []Target, op core.TransformOp) (string, map[string]any, error) {
+	if err := core.ValidateSortBy(op.SortBy); err != nil {
+		return source, nil, err
+	}
+
+	var groups [][]sortMember
+	index := make(map[[2]uint32]int)
+	for _, target := range targets {
+		member := p.newSortMember(source, target, op)
+		if member.container == nil {
+			continue
+		}
+		key := [2]uint32{member.container.StartByte(), member.container.EndByte()}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], member)
+	}
+
+	var edits []Edit
+	orders := make([]map[string]any, 0, len(groups))
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		sort.SliceStable(members, func(i, j int) bool { return members[i].start < members[j].start })
+		for i := 1; i < len(members); i++ {
+			if members[i].start < members[i-1].tailEnd {
+				return source, nil, fmt.Errorf("cannot sort overlapping members %s and %s", describeTarget(members[i-1].target), describeTarget(members[i].target))
+			}
+		}
+
+		sorted := slices.Clone(members)
+		sort.SliceStable(sorted, func(i, j int) bool { return lessSortMember(sorted[i], sorted[j]) })
+
+		names := make([]string, 0, len(sorted))
+		for i, slot := range members {
+			member := sorted[i]
+			edits = append(edits,
+				Edit{Start: slot.start, End: slot.end, Text: source[member.start:member.end]},
+				Edit{Start: slot.tailStart, End: slot.tailEnd, Text: source[member.tailStart:member.tailEnd]},
+			)
+			names = append(names, member.name)
+		}
+		orders = append(orders, map[string]any{
+			"container": members[0].container.Type(),
+			"line":      int(members[0].container.StartPoint().Row) + 1,
+			"order":     names,
+		})
+	}
+
+	result, err := ApplyEdits(source, edits)
+	if err != nil {
+		return source, nil, err
+	}
+	return result, map[string]any{"sorted": orders}, nil
+}
+
+func (p *Provider) newSortMember(source string, target Target, op core.TransformOp) sortMember {
+	member := sortMember{target: target, start: target.StartByte, end: target.EndByte}
+	if target.Node == nil {
+		return member
+	}
+
+	node := declarationWrapper(source, enclosingDeclaration(target.Node))
+	member.container = node.Parent()
+	member.name = memberName(source, target)
+	member.start = attachedStart(source, node)
+	member.end, member.tailStart, member.tailEnd = memberEnd(source, node)
+
+	switch {
+	case op.SortBy == core.SortByVisibility:
+		member.key = p.memberVisibility(node, source, member.name)
+		order := op.SortOrder
+		if len(order) == 0 {
+			order = defaultVisibilityOrder
+		}
+		member.rank = rankIn(order, member.key)
+	case op.SortBy == core.SortByKind:
+		order := op.SortOrder
+		if len(order) == 0 {
+			order = selectorKinds(op.Target)
+		}
+		member.rank = rankIn(order, target.Type, target.NodeType)
+		member.key = target.Type
+	case strings.HasPrefix(op.SortBy, "$"):
+		member.key = target.Captures[strings.TrimPrefix(op.SortBy, "$")]
+	default:
+		member.key = member.name
+	}
+	return member
+}
+
+// memberName is the name a member sorts by. Members such as object pairs
+// have no declared name, so their key is used instead.
+func memberName(source string, target Target) string {
+	if target.Name != "" && target.Name != "anonymous" {
+		return target.Name
+	}
+	for _, field := range []string{"key", "name"} {
+		if child := target.Node.ChildByFieldName(field); child != nil {
+			return strings.Trim(child.Content([]byte(source)), "\"'`")
+		}
+	}
+	return strings.TrimSpace(target.Node.Content([]byte(source)))
+}
+
+// memberEnd returns where a member's body ends, including a terminating
+// semicolon, and the range after it and any comma separator that holds a
+// comment on the same line. Separators stay in place; the comment moves with
+// the member.
+func memberEnd(source string, node *sitter.Node) (uint32, uint32, uint32) {
+	end := node.EndByte()
+	next := node.NextSibling()
+	if next != nil && next.Type() == ";" && next.StartByte() == end {
+		end = next.EndByte()
+		next = next.NextSibling()
+	}
+	tail := end
+	if next != nil && next.Type() == "," && next.StartByte() == end {
+		tail = next.EndByte()
+		next = next.NextSibling()
+	}
+	if next != nil && strings.Contains(next.Type(), "comment") && strings.Trim(source[tail:next.StartByte()], " \t") == "" {
+		return end, tail, next.EndByte()
+	}
+	return end, tail, tail
+}
+
+func lessSortMember(a, b sortMember) bool {
+	if a.rank != b.rank {
+		return a.rank < b.rank
+	}
+	if a.key == b.key {
+		return false
+	}
+	if lower, otherLower := strings.ToLower(a.key), strings.ToLower(b.key); lower != otherLower {
+		return lower < otherLower
+	}
+	return a.key < b.key
+}
+
+// rankIn returns the position of the first of values found in order, or
+// len(order) so unlisted values sort last.
+func rankIn(order []string, values ...string) int {
+	for i, item := range order {
+		for _, value := range values {
+			if value != "" && strings.EqualFold(item, value) {
+				return i
+			}
+		}
+	}
+	return len(order)
+}
+
+// selectorKinds lists the query types of an OR selector in the order they
+// were written, so "field:* | method:*" puts fields first.
+func selectorKinds(query core.AgentQuery) []string {
+	if !strings.EqualFold(query.Operator, "OR") {
+		if query.Contains != nil {
+			return selectorKinds(*query.Contains)
+		}
+		return []string{query.Type}
+	}
+	var kinds []string
+	for _, operand := range query.Operands {
+		kinds = append(kinds, selectorKinds(operand)...)
+	}
+	return kinds
+}
+
+// memberVisibility reads a public/protected/private modifier from node. It
+// falls back to the language config, then to "#private" names, then to
+// public.
+func (p *Provider) memberVisibility(node *sitter.Node, source, name string) string {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if strings.Contains(child.Type(), "visibility_modifier") || strings.Contains(child.Type(), "accessibility_modifier") {
+			return strings.ToLower(strings.TrimSpace(child.Content([]byte(source))))
+		}
+	}
+	if config, ok := p.config.(VisibilityConfig); ok {
+		if visibility := config.MemberVisibility(node, source); visibility != "" {
+			return visibility
+		}
+	}
+	if strings.HasPrefix(name, "#") {
+		return "private"
+	}
+	return "public"
+}
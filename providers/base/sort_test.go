@@ -0,0 +1,87 @@
+package base
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+const sortSource = "package main\n\n// Zed is last.\nfunc Zed() {} // z\n\nfunc alpha() {}\n\n// Mid is between.\nfunc Mid() {}\n"
+
+func TestTransformSortByName(t *testing.T) {
+	provider := newTestProvider()
+
+	result := provider.Transform(sortSource, core.TransformOp{
+		Method: "sort",
+		Target: core.AgentQuery{Type: "function", Name: "*"},
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	want := "package main\n\nfunc alpha() {}\n\n// Mid is between.\nfunc Mid() {}\n\n// Zed is last.\nfunc Zed() {} // z\n"
+	if result.Modified != want {
+		t.Fatalf("unexpected order:\n%s", result.Modified)
+	}
+
+	sorted, ok := result.Metadata["sorted"].([]map[string]any)
+	if !ok || len(sorted) != 1 {
+		t.Fatalf("expected one sorted group, got %#v", result.Metadata)
+	}
+	if order := sorted[0]["order"].([]string); strings.Join(order, ",") != "alpha,Mid,Zed" {
+		t.Fatalf("unexpected reported order %v", order)
+	}
+}
+
+func TestTransformSortKeepsSortedSourceUnchanged(t *testing.T) {
+	provider := newTestProvider()
+	source := "package main\n\nfunc A() {}\n\nfunc B() {}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method: "sort",
+		Target: core.AgentQuery{Type: "function", Name: "*"},
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if result.Modified != source {
+		t.Fatalf("expected no change:\n%s", result.Modified)
+	}
+}
+
+func TestTransformSortRejectsUnknownKey(t *testing.T) {
+	provider := newTestProvider()
+
+	result := provider.Transform(sortSource, core.TransformOp{
+		Method: "sort",
+		Target: core.AgentQuery{Type: "function", Name: "*"},
+		SortBy: "size",
+	})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "unknown sort key") {
+		t.Fatalf("expected an unknown sort key error, got %v", result.Error)
+	}
+}
+
+func TestLessSortMemberRanksBeforeNames(t *testing.T) {
+	private := sortMember{key: "a", rank: 1}
+	public := sortMember{key: "b", rank: 0}
+	if !lessSortMember(public, private) {
+		t.Fatal("expected the lower rank to sort first")
+	}
+	if !lessSortMember(sortMember{key: "alpha"}, sortMember{key: "Beta"}) {
+		t.Fatal("expected names to compare case-insensitively")
+	}
+	if got := rankIn([]string{"public", "private"}, "protected"); got != 2 {
+		t.Fatalf("expected unlisted values to rank last, got %d", got)
+	}
+}
+
+func TestSelectorKindsFollowsOperandOrder(t *testing.T) {
+	query := core.AgentQuery{Operator: "OR", Operands: []core.AgentQuery{
+		{Type: "class", Name: "A", Contains: &core.AgentQuery{Type: "field"}},
+		{Type: "class", Name: "A", Contains: &core.AgentQuery{Type: "method"}},
+	}}
+	if got := strings.Join(selectorKinds(query), ","); got != "field,method" {
+		t.Fatalf("unexpected kinds %q", got)
+	}
+}
@@ -0,0 +1,702 @@
+package base
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// declarationKinds are the query types the structural diff tracks as named
+// declarations, in the order used to pick a label when a node type maps to
+// more than one of them.
+var declarationKinds = []string{
+	"class", "struct", "interface", "trait", "enum", "type",
+	"property", "field", "constant", "variable", "method", "function",
+}
+
+// containerKinds are the declarations whose members are tracked one by one.
+var containerKinds = map[string]bool{"class": true, "struct": true, "interface": true, "trait": true, "enum": true}
+
+// declaration is a named node in a tree being diffed structurally. The
+// file itself is the root declaration and only containers have members, so
+// edits inside a function body are described against the function.
+type declaration struct {
+	node     *sitter.Node
+	kind     string
+	name     string
+	key      string
+	parent   *declaration
+	children []*declaration
+}
+
+func (d *declaration) describe() string {
+	if d.parent == nil {
+		return "top-level code"
+	}
+	return d.kind + " " + d.name
+}
+
+// path joins the declarations enclosing d, outermost first.
+func (d *declaration) path() string {
+	var parts []string
+	for ; d != nil && d.parent != nil; d = d.parent {
+		parts = append(parts, d.describe())
+	}
+	slices.Reverse(parts)
+	return strings.Join(parts, " > ")
+}
+
+// where names the declaration d belongs to, for "inserted method X in class Y".
+func (d *declaration) where() string {
+	if d.parent == nil || d.parent.parent == nil {
+		return ""
+	}
+	return " in " + d.parent.describe()
+}
+
+// StructuralDiff implements core.StructuralDiffer.
+func (p *Provider) StructuralDiff(original, modified string) []core.StructuralChange {
+	parser := p.borrowParser()
+	defer p.releaseParser(parser)
+	return p.structuralDiff(parser, original, modified)
+}
+
+// structuralDiff reports the node-level edits that turn original into
+// modified: declarations inserted, deleted, renamed, moved or reordered, and,
+// inside changed declarations, renamed parameters, changed return types and
+// changed call arguments. It returns nil when either side does not parse.
+func (p *Provider) structuralDiff(parser *parserAdapter, original, modified string) []core.StructuralChange {
+	if original == modified {
+		return nil
+	}
+	before := parser.Parse([]byte(original))
+	if before == nil {
+		return nil
+	}
+	defer before.Close()
+	after := parser.Parse([]byte(modified))
+	if after == nil {
+		return nil
+	}
+	defer after.Close()
+	if before.RootNode().HasError() || after.RootNode().HasError() {
+		return nil
+	}
+
+	d := &structDiffer{p: p, old: original, new: modified, kinds: p.declarationNodeKinds()}
+	d.compare(d.collect(before.RootNode(), original), d.collect(after.RootNode(), modified))
+	d.pairMoves()
+	return d.changes
+}
+
+// declarationNodeKinds maps node types to the declaration kinds they can
+// stand for in this language. Where methods share the node type of
+// functions, as in Python, declarationLabel tells them apart by container.
+func (p *Provider) declarationNodeKinds() map[string][]string {
+	functions := p.config.MapQueryTypeToNodeTypes("function")
+	methods := p.config.MapQueryTypeToNodeTypes("method")
+	sharedMethods := len(functions) > 0 && !slices.ContainsFunc(functions, func(nodeType string) bool {
+		return !slices.Contains(methods, nodeType)
+	})
+
+	kinds := make(map[string][]string)
+	for _, kind := range declarationKinds {
+		if kind == "method" && sharedMethods {
+			continue
+		}
+		for _, nodeType := range p.config.MapQueryTypeToNodeTypes(kind) {
+			kinds[nodeType] = append(kinds[nodeType], kind)
+		}
+	}
+	return kinds
+}
+
+type structDiffer struct {
+	p        *Provider
+	old, new string
+	kinds    map[string][]string
+	changes  []core.StructuralChange
+	// inserted and deleted are the changes that pairMoves may turn into
+	// moves, by index into changes
+	inserted, deleted []changedDeclaration
+}
+
+type changedDeclaration struct {
+	index int
+	decl  *declaration
+}
+
+func (d *structDiffer) add(change, summary string, decl *declaration) {
+	d.changes = append(d.changes, core.StructuralChange{
+		Change:  change,
+		Kind:    decl.kind,
+		Path:    decl.path(),
+		Line:    decl.node.StartPoint().Row + 1,
+		Summary: summary,
+	})
+}
+
+// collect builds the declaration tree under root.
+func (d *structDiffer) collect(root *sitter.Node, source string) *declaration {
+	file := &declaration{node: root, kind: "file"}
+	d.collectInto(root, file, source)
+	return file
+}
+
+// collectInto adds the declarations under node to owner, numbering
+// duplicates such as overloads so each key is unique.
+func (d *structDiffer) collectInto(node *sitter.Node, owner *declaration, source string) {
+	seen := make(map[string]int)
+	var walk func(node *sitter.Node)
+	walk = func(node *sitter.Node) {
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			child := node.NamedChild(i)
+			decl := d.declarationAt(child, owner, source)
+			if decl == nil {
+				walk(child)
+				continue
+			}
+			base := decl.kind + " " + decl.name
+			seen[base]++
+			decl.key = base
+			if seen[base] > 1 {
+				decl.key = fmt.Sprintf("%s#%d", base, seen[base])
+			}
+			owner.children = append(owner.children, decl)
+			if containerKinds[decl.kind] {
+				d.collectInto(child, decl, source)
+			}
+		}
+	}
+	walk(node)
+}
+
+// declarationAt returns node as a member of owner, or nil when node is not
+// a named declaration.
+func (d *structDiffer) declarationAt(node *sitter.Node, owner *declaration, source string) *declaration {
+	candidates := d.kinds[node.Type()]
+	if len(candidates) == 0 {
+		return nil
+	}
+	name := d.p.config.ExtractNodeName(node, source)
+	if name == "" || strings.Contains(name, "\n") {
+		return nil
+	}
+	kind := declarationLabel(node, candidates, containerKinds[owner.kind])
+	return &declaration{node: node, kind: kind, name: name, parent: owner}
+}
+
+// declarationLabel picks the kind a node is reported as when its type maps
+// to several: Go's type_spec is a struct or an interface by its type, and a
+// function inside a container is a method.
+func declarationLabel(node *sitter.Node, candidates []string, inContainer bool) string {
+	kind := candidates[0]
+	if typeNode := node.ChildByFieldName("type"); typeNode != nil && len(candidates) > 1 {
+		kind = ""
+		for _, candidate := range candidates {
+			if strings.HasPrefix(typeNode.Type(), candidate) {
+				kind = candidate
+				break
+			}
+		}
+		if kind == "" {
+			kind = candidates[0]
+			if slices.Contains(candidates, "type") {
+				kind = "type"
+			}
+		}
+	}
+	if kind == "function" && inContainer {
+		return "method"
+	}
+	return kind
+}
+
+// compare reports the edits between two versions of the same declaration.
+func (d *structDiffer) compare(before, after *declaration) {
+	d.compareOwn(before, after)
+
+	oldByKey := make(map[string]*declaration, len(before.children))
+	for _, child := range before.children {
+		oldByKey[child.key] = child
+	}
+	newKeys := make(map[string]bool, len(after.children))
+	for _, child := range after.children {
+		newKeys[child.key] = true
+	}
+
+	var deleted []*declaration
+	for _, child := range before.children {
+		if !newKeys[child.key] {
+			deleted = append(deleted, child)
+		}
+	}
+	for _, child := range after.children {
+		old, ok := oldByKey[child.key]
+		switch {
+		case ok:
+			if d.old[old.node.StartByte():old.node.EndByte()] != d.new[child.node.StartByte():child.node.EndByte()] {
+				d.compare(old, child)
+			}
+		default:
+			if i := d.renamedFrom(deleted, child); i >= 0 {
+				d.add("renamed", fmt.Sprintf("renamed %s %s to %s%s", child.kind, deleted[i].name, child.name, child.where()), child)
+				deleted = slices.Delete(deleted, i, i+1)
+				continue
+			}
+			d.add("inserted", "inserted "+child.describe()+child.where(), child)
+			d.inserted = append(d.inserted, changedDeclaration{len(d.changes) - 1, child})
+		}
+	}
+	for _, child := range deleted {
+		d.add("deleted", "deleted "+child.describe()+child.where(), child)
+		d.deleted = append(d.deleted, changedDeclaration{len(d.changes) - 1, child})
+	}
+}
+
+// pairMoves reports a declaration deleted from one container and inserted
+// unchanged in another, such as a method moved between classes, as one
+// move rather than a delete and an insert.
+func (d *structDiffer) pairMoves() {
+	dropped := make(map[int]bool)
+	for _, inserted := range d.inserted {
+		for _, deleted := range d.deleted {
+			if dropped[deleted.index] || !d.moved(deleted.decl, inserted.decl) {
+				continue
+			}
+			from, to := deleted.decl, inserted.decl
+			change := &d.changes[inserted.index]
+			change.Change = "moved"
+			change.Summary = fmt.Sprintf("moved %s %s from %s to %s", from.kind, from.name, from.parent.describe(), to.parent.describe())
+			dropped[deleted.index] = true
+			break
+		}
+	}
+	if len(dropped) == 0 {
+		return
+	}
+	kept := d.changes[:0]
+	for i, change := range d.changes {
+		if !dropped[i] {
+			kept = append(kept, change)
+		}
+	}
+	d.changes = kept
+}
+
+// moved reports whether after is before in another place: the same name
+// and, reindentation aside, the same code. A function moved into a class
+// becomes a method, so those kinds pair up.
+func (d *structDiffer) moved(before, after *declaration) bool {
+	callable := map[string]bool{"function": true, "method": true}
+	if before.name != after.name || (before.kind != after.kind && !(callable[before.kind] && callable[after.kind])) {
+		return false
+	}
+	return strings.Join(strings.Fields(nodeText(before.node, d.old)), " ") == strings.Join(strings.Fields(nodeText(after.node, d.new)), " ")
+}
+
+// renamedFrom returns the index of the deleted declaration that decl is a
+// pure rename of, or -1.
+func (d *structDiffer) renamedFrom(deleted []*declaration, decl *declaration) int {
+	text := d.new[decl.node.StartByte():decl.node.EndByte()]
+	for i, old := range deleted {
+		if old.kind != decl.kind {
+			continue
+		}
+		oldText := d.old[old.node.StartByte():old.node.EndByte()]
+		if strings.Replace(oldText, old.name, decl.name, 1) == text {
+			return i
+		}
+	}
+	return -1
+}
+
+// compareOwn reports the edits to a declaration's own code, with its member
+// declarations left out since compare reports those one by one.
+func (d *structDiffer) compareOwn(before, after *declaration) {
+	oldOwn, newOwn := ownText(before, d.old), ownText(after, d.new)
+	if oldOwn == newOwn {
+		return
+	}
+	if skeleton(oldOwn) == skeleton(newOwn) {
+		switch {
+		case reordered(before, after):
+			d.add("reordered", "reordered members of "+after.describe(), after)
+		case layout(oldOwn, sharedKeys(before, after)) != layout(newOwn, sharedKeys(before, after)):
+			summary := "moved code between members of " + after.describe()
+			if after.parent == nil {
+				summary = "moved top-level code between declarations"
+			}
+			d.add("moved", summary, after)
+		}
+		return
+	}
+
+	d.describeChanges(before, after)
+}
+
+// describeChanges reports the changes between two versions of a
+// declaration's own code: its parameters, its return type, the calls it
+// makes, and anything else as a changed body.
+func (d *structDiffer) describeChanges(before, after *declaration) {
+	count := len(d.changes)
+	d.compareParameters(before, after)
+	d.compareField(before, after, "return type", "result", "return_type")
+	signature := len(d.changes) > count
+
+	count = len(d.changes)
+	d.compareCalls(before, after)
+	if len(d.changes) > count {
+		return
+	}
+	if !signature || skeleton(bodyText(before, d.old)) != skeleton(bodyText(after, d.new)) {
+		summary := "changed " + after.describe()
+		if after.parent != nil && (after.kind == "function" || after.kind == "method") {
+			summary = "changed body of " + after.describe()
+		}
+		if !signature && sameLines(bodyText(before, d.old), bodyText(after, d.new)) {
+			d.add("moved", "moved lines within "+after.describe()+after.where(), after)
+			return
+		}
+		d.add("modified", summary+after.where(), after)
+	}
+}
+
+// compareParameters reports added, removed, renamed and changed parameters.
+func (d *structDiffer) compareParameters(before, after *declaration) {
+	oldList, newList := before.node.ChildByFieldName("parameters"), after.node.ChildByFieldName("parameters")
+	if oldList == nil || newList == nil {
+		return
+	}
+	oldParams, newParams := namedChildren(oldList), namedChildren(newList)
+	if len(oldParams) == len(newParams) {
+		for i := range oldParams {
+			oldText, newText := nodeText(oldParams[i], d.old), nodeText(newParams[i], d.new)
+			if oldText == newText {
+				continue
+			}
+			oldName, newName := identifierText(oldParams[i], d.old), identifierText(newParams[i], d.new)
+			if oldName != "" && newName != "" && oldName != newName && strings.Replace(oldText, oldName, newName, 1) == newText {
+				d.add("modified", fmt.Sprintf("renamed parameter %s to %s in %s", oldName, newName, after.describe()), after)
+				continue
+			}
+			d.add("modified", fmt.Sprintf("changed parameter %d of %s from %s to %s", i+1, after.describe(), quoted(oldText), quoted(newText)), after)
+		}
+		return
+	}
+
+	oldNames, newNames := parameterNames(oldParams, d.old), parameterNames(newParams, d.new)
+	for _, name := range newNames {
+		if !slices.Contains(oldNames, name) {
+			d.add("modified", fmt.Sprintf("added parameter %s to %s", name, after.describe()), after)
+		}
+	}
+	for _, name := range oldNames {
+		if !slices.Contains(newNames, name) {
+			d.add("modified", fmt.Sprintf("removed parameter %s from %s", name, after.describe()), after)
+		}
+	}
+}
+
+// compareField reports a change to the child in the first of fields that
+// either version has, such as a return type.
+func (d *structDiffer) compareField(before, after *declaration, label string, fields ...string) {
+	for _, field := range fields {
+		oldNode, newNode := before.node.ChildByFieldName(field), after.node.ChildByFieldName(field)
+		if oldNode == nil && newNode == nil {
+			continue
+		}
+		switch {
+		case oldNode == nil:
+			d.add("modified", fmt.Sprintf("added %s %s to %s", label, quoted(nodeText(newNode, d.new)), after.describe()), after)
+		case newNode == nil:
+			d.add("modified", fmt.Sprintf("removed %s from %s", label, after.describe()), after)
+		case nodeText(oldNode, d.old) != nodeText(newNode, d.new):
+			d.add("modified", fmt.Sprintf("changed %s of %s from %s to %s", label, after.describe(), quoted(nodeText(oldNode, d.old)), quoted(nodeText(newNode, d.new))), after)
+		}
+		return
+	}
+}
+
+// compareCalls matches the calls in each version's own code by callee and
+// order, reporting added and removed calls and changed arguments.
+func (d *structDiffer) compareCalls(before, after *declaration) {
+	callTypes := d.p.config.MapQueryTypeToNodeTypes("call")
+	if len(callTypes) == 0 {
+		return
+	}
+	oldCalls, oldOrder := d.callsByCallee(before, d.old, callTypes)
+	newCalls, newOrder := d.callsByCallee(after, d.new, callTypes)
+
+	for _, callee := range newOrder {
+		olds, news := oldCalls[callee], newCalls[callee]
+		if len(olds) != len(news) {
+			continue
+		}
+		for i := range news {
+			d.compareArguments(olds[i], news[i], callee, after)
+		}
+	}
+	for _, callee := range newOrder {
+		if extra := len(newCalls[callee]) - len(oldCalls[callee]); extra > 0 {
+			d.add("modified", fmt.Sprintf("added %s to %s in %s", plural(extra, "call"), callee, after.describe()), after)
+		}
+	}
+	for _, callee := range oldOrder {
+		if missing := len(oldCalls[callee]) - len(newCalls[callee]); missing > 0 {
+			d.add("modified", fmt.Sprintf("removed %s to %s from %s", plural(missing, "call"), callee, after.describe()), after)
+		}
+	}
+}
+
+func (d *structDiffer) compareArguments(oldCall, newCall *sitter.Node, callee string, after *declaration) {
+	oldArgs, newArgs := callArguments(oldCall), callArguments(newCall)
+	if len(oldArgs) != len(newArgs) {
+		d.add("modified", fmt.Sprintf("changed call to %s in %s from %d to %d arguments", callee, after.describe(), len(oldArgs), len(newArgs)), after)
+		return
+	}
+	for i := range newArgs {
+		oldText, newText := nodeText(oldArgs[i], d.old), nodeText(newArgs[i], d.new)
+		if oldText == newText {
+			continue
+		}
+		// A nested call with the same callee reports its own arguments.
+		if d.calleeOf(oldArgs[i], d.old) != "" && d.calleeOf(oldArgs[i], d.old) == d.calleeOf(newArgs[i], d.new) {
+			continue
+		}
+		d.add("modified", fmt.Sprintf("changed argument %d of call to %s in %s from %s to %s", i+1, callee, after.describe(), quoted(oldText), quoted(newText)), after)
+	}
+}
+
+// callsByCallee collects the calls in decl's own code grouped by callee,
+// with the callees in order of first appearance.
+func (d *structDiffer) callsByCallee(decl *declaration, source string, callTypes []string) (map[string][]*sitter.Node, []string) {
+	calls := make(map[string][]*sitter.Node)
+	var order []string
+	var walk func(node *sitter.Node)
+	walk = func(node *sitter.Node) {
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			child := node.NamedChild(i)
+			if isMemberNode(decl, child) {
+				continue
+			}
+			if slices.Contains(callTypes, child.Type()) {
+				if callee := d.calleeOf(child, source); callee != "" {
+					if _, ok := calls[callee]; !ok {
+						order = append(order, callee)
+					}
+					calls[callee] = append(calls[callee], child)
+				}
+			}
+			walk(child)
+		}
+	}
+	walk(decl.node)
+	return calls, order
+}
+
+// calleeOf names the function a call node calls, or "" when node is not a
+// call.
+func (d *structDiffer) calleeOf(node *sitter.Node, source string) string {
+	if !slices.Contains(d.p.config.MapQueryTypeToNodeTypes("call"), node.Type()) {
+		return ""
+	}
+	for _, field := range []string{"function", "constructor", "name"} {
+		if callee := node.ChildByFieldName(field); callee != nil {
+			return nodeText(callee, source)
+		}
+	}
+	if name := d.p.config.ExtractNodeName(node, source); !strings.Contains(name, "\n") {
+		return name
+	}
+	return ""
+}
+
+func callArguments(call *sitter.Node) []*sitter.Node {
+	if args := call.ChildByFieldName("arguments"); args != nil {
+		return namedChildren(args)
+	}
+	return nil
+}
+
+// ownText returns decl's source with each member declaration, along with
+// the decorators in front of it, replaced by a placeholder naming it.
+func ownText(decl *declaration, source string) string {
+	var out strings.Builder
+	pos := decl.node.StartByte()
+	for _, child := range decl.children {
+		// A member's decorators move with it, so they are not the owner's code
+		out.WriteString(source[pos:max(pos, leadingStart(source, child.node, decorates))])
+		out.WriteString("\x00" + child.key + "\x00")
+		pos = child.node.EndByte()
+	}
+	out.WriteString(source[pos:decl.node.EndByte()])
+	return out.String()
+}
+
+// bodyText is decl's own text without its parameters and return type.
+func bodyText(decl *declaration, source string) string {
+	text := ownText(decl, source)
+	for _, field := range []string{"parameters", "result", "return_type"} {
+		if node := decl.node.ChildByFieldName(field); node != nil {
+			text = strings.Replace(text, nodeText(node, source), "", 1)
+		}
+	}
+	return text
+}
+
+// skeleton drops member placeholders and collapses whitespace, so that
+// insertions, deletions, moves and reformatting compare equal.
+func skeleton(own string) string {
+	var out strings.Builder
+	for i, part := range strings.Split(own, "\x00") {
+		if i%2 == 0 {
+			out.WriteString(part)
+			out.WriteString(" ")
+		}
+	}
+	return strings.Join(strings.Fields(out.String()), " ")
+}
+
+// layout is own with whitespace collapsed and only the placeholders of
+// the members in keep, so that it differs between versions when code moved
+// past a member both have.
+func layout(own string, keep map[string]bool) string {
+	var out strings.Builder
+	for i, part := range strings.Split(own, "\x00") {
+		if i%2 == 0 {
+			out.WriteString(part + " ")
+		} else if keep[part] {
+			out.WriteString("\x00" + part + "\x00 ")
+		}
+	}
+	return strings.Join(strings.Fields(out.String()), " ")
+}
+
+// sharedKeys returns the keys of the members both versions have.
+func sharedKeys(before, after *declaration) map[string]bool {
+	keys := make(map[string]bool)
+	for _, child := range before.children {
+		keys[child.key] = false
+	}
+	for _, child := range after.children {
+		if _, ok := keys[child.key]; ok {
+			keys[child.key] = true
+		}
+	}
+	return keys
+}
+
+// sameLines reports whether a and b hold the same lines, ignoring
+// indentation and blank lines, in some other order.
+func sameLines(a, b string) bool {
+	lines := func(text string) []string {
+		var out []string
+		for _, line := range strings.Split(text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				out = append(out, line)
+			}
+		}
+		slices.Sort(out)
+		return out
+	}
+	return slices.Equal(lines(a), lines(b))
+}
+
+// reordered reports whether the members both versions share appear in a
+// different order.
+func reordered(before, after *declaration) bool {
+	shared := make(map[string]bool)
+	for _, child := range after.children {
+		shared[child.key] = true
+	}
+	var oldOrder []string
+	for _, child := range before.children {
+		if shared[child.key] {
+			oldOrder = append(oldOrder, child.key)
+		}
+	}
+	kept := make(map[string]bool, len(oldOrder))
+	for _, key := range oldOrder {
+		kept[key] = true
+	}
+	var newOrder []string
+	for _, child := range after.children {
+		if kept[child.key] {
+			newOrder = append(newOrder, child.key)
+		}
+	}
+	return !slices.Equal(oldOrder, newOrder)
+}
+
+func isMemberNode(decl *declaration, node *sitter.Node) bool {
+	for _, child := range decl.children {
+		if child.node.Equal(node) {
+			return true
+		}
+	}
+	return false
+}
+
+func namedChildren(node *sitter.Node) []*sitter.Node {
+	var children []*sitter.Node
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if child := node.NamedChild(i); !strings.Contains(child.Type(), "comment") {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// identifierText returns the first identifier in node, which for a
+// parameter is the name it binds.
+func identifierText(node *sitter.Node, source string) string {
+	if strings.HasSuffix(node.Type(), "identifier") || node.Type() == "variable_name" {
+		return nodeText(node, source)
+	}
+	for _, field := range []string{"name", "pattern"} {
+		if child := node.ChildByFieldName(field); child != nil {
+			return identifierText(child, source)
+		}
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if name := identifierText(node.NamedChild(i), source); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func parameterNames(params []*sitter.Node, source string) []string {
+	names := make([]string, 0, len(params))
+	for _, param := range params {
+		if name := identifierText(param, source); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func nodeText(node *sitter.Node, source string) string {
+	return source[node.StartByte():node.EndByte()]
+}
+
+// quoted renders code for a summary on one line, shortened when long.
+func quoted(code string) string {
+	code = strings.Join(strings.Fields(code), " ")
+	if len(code) > 40 {
+		code = code[:37] + "..."
+	}
+	return fmt.Sprintf("%q", code)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "a " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
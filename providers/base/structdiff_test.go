@@ -0,0 +1,168 @@
+package base
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func structuralSummaries(t *testing.T, result core.TransformResult) []string {
+	t.Helper()
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	changes, _ := result.Metadata["structural_diff"].([]core.StructuralChange)
+	summaries := make([]string, 0, len(changes))
+	for _, change := range changes {
+		summaries = append(summaries, change.Summary)
+	}
+	return summaries
+}
+
+func TestTransformReportsStructuralDiff(t *testing.T) {
+	provider := newTestProvider()
+	source := "package main\n\nfunc Foo(a int) {}\n\nfunc Bar() {}\n"
+
+	tests := []struct {
+		name string
+		op   core.TransformOp
+		want []string
+	}{
+		{
+			name: "insert",
+			op:   core.TransformOp{Method: "insert_after", Target: core.AgentQuery{Type: "function", Name: "Bar"}, Content: "func Baz() {}"},
+			want: []string{"inserted function Baz"},
+		},
+		{
+			name: "delete",
+			op:   core.TransformOp{Method: "delete", Target: core.AgentQuery{Type: "function", Name: "Bar"}},
+			want: []string{"deleted function Bar"},
+		},
+		{
+			name: "rename",
+			op:   core.TransformOp{Method: "replace", Target: core.AgentQuery{Type: "function", Name: "Bar"}, Replacement: "func Qux() {}"},
+			want: []string{"renamed function Bar to Qux"},
+		},
+		{
+			name: "parameter rename",
+			op:   core.TransformOp{Method: "replace", Target: core.AgentQuery{Type: "function", Name: "Foo"}, Replacement: "func Foo(b int) {}"},
+			want: []string{"renamed parameter a to b in function Foo"},
+		},
+		{
+			name: "signature",
+			op:   core.TransformOp{Method: "replace", Target: core.AgentQuery{Type: "function", Name: "Foo"}, Replacement: "func Foo(a int, b string) error { return nil }"},
+			want: []string{"added parameter b to function Foo", `added return type "error" to function Foo`, "changed body of function Foo"},
+		},
+		{
+			name: "body",
+			op:   core.TransformOp{Method: "replace", Target: core.AgentQuery{Type: "function", Name: "Bar"}, Replacement: "func Bar() { x := 1; _ = x }"},
+			want: []string{"changed body of function Bar"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := tt.op
+			op.StructuralDiff = true
+			got := structuralSummaries(t, provider.Transform(source, op))
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("structural diff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformOmitsStructuralDiffWithoutChanges(t *testing.T) {
+	provider := newTestProvider()
+	result := provider.Transform("package main\n\nfunc Foo() {}\n", core.TransformOp{
+		Method:         "replace",
+		Target:         core.AgentQuery{Type: "function", Name: "Foo"},
+		Replacement:    "func Foo() {}",
+		StructuralDiff: true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if _, ok := result.Metadata["structural_diff"]; ok {
+		t.Fatalf("expected no structural diff for an unchanged source, got %v", result.Metadata["structural_diff"])
+	}
+}
+
+func TestTransformSkipsStructuralDiffUnlessAsked(t *testing.T) {
+	provider := newTestProvider()
+	result := provider.Transform("package main\n\nfunc Foo() {}\n", core.TransformOp{
+		Method:  "insert_after",
+		Target:  core.AgentQuery{Type: "function", Name: "Foo"},
+		Content: "func Bar() {}",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if _, ok := result.Metadata["structural_diff"]; ok {
+		t.Fatalf("expected no structural diff without structural_diff, got %v", result.Metadata["structural_diff"])
+	}
+}
+
+func TestStructuralDiffReportsMoves(t *testing.T) {
+	provider := newTestProvider()
+	tests := []struct {
+		name, original, modified string
+		want                     []string
+	}{
+		{
+			name:     "lines within a body",
+			original: "package main\n\nfunc Foo() {\n\ta := 1\n\tb := 2\n\t_, _ = a, b\n}\n",
+			modified: "package main\n\nfunc Foo() {\n\tb := 2\n\ta := 1\n\t_, _ = a, b\n}\n",
+			want:     []string{"moved lines within function Foo"},
+		},
+		{
+			name:     "code between declarations",
+			original: "package main\n\nfunc Foo() {}\n\nimport \"fmt\"\n",
+			modified: "package main\n\nimport \"fmt\"\n\nfunc Foo() {}\n",
+			want:     []string{"moved top-level code between declarations"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, change := range provider.StructuralDiff(tt.original, tt.modified) {
+				if change.Change != "moved" {
+					t.Errorf("expected a move, got %+v", change)
+				}
+				got = append(got, change.Summary)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("structural diff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchReportsOneStructuralDiff(t *testing.T) {
+	provider := newTestProvider()
+	result := provider.Transform("package main\n\nfunc Foo() {}\n", core.TransformOp{
+		Method:         "batch",
+		StructuralDiff: true,
+		Ops: []core.TransformOp{
+			{Method: "insert_after", Target: core.AgentQuery{Type: "function", Name: "Foo"}, Content: "func Bar() {}"},
+			{Method: "delete", Target: core.AgentQuery{Type: "function", Name: "Foo"}},
+		},
+	})
+	// Diffed against the original, the two steps amount to a rename.
+	want := []string{"renamed function Foo to Bar"}
+	if got := structuralSummaries(t, result); !slices.Equal(got, want) {
+		t.Fatalf("structural diff = %q, want %q", got, want)
+	}
+}
+
+func TestSkeletonIgnoresMembersAndWhitespace(t *testing.T) {
+	a := "type S struct {\n\t\x00field A\x00\n}"
+	b := "type S struct {\n\t\x00field B\x00\n\t\x00field A\x00\n}"
+	if skeleton(a) != skeleton(b) {
+		t.Fatalf("skeleton(%q) = %q, want %q", b, skeleton(b), skeleton(a))
+	}
+	if skeleton(a) == skeleton("type S interface {\n}") {
+		t.Fatal("expected a changed keyword to change the skeleton")
+	}
+}
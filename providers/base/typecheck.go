@@ -0,0 +1,22 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// TypeCheckConfig lets language configs type-check an edit against the rest
+// of its package. See core.TypeChecker for the contract.
+type TypeCheckConfig interface {
+	TypeCheck(path, original, modified string) ([]string, error)
+}
+
+// TypeCheck implements core.TypeChecker for configs that support it.
+func (p *Provider) TypeCheck(path, original, modified string) ([]string, error) {
+	checker, ok := p.config.(TypeCheckConfig)
+	if !ok {
+		return nil, fmt.Errorf("%w for %s", core.ErrTypeCheckUnsupported, p.config.Language())
+	}
+	return checker.TypeCheck(path, original, modified)
+}
@@ -1,6 +1,7 @@
 package golang
 
 import (
+	"go/format"
 	"path"
 	"strings"
 
@@ -253,7 +254,11 @@ func insertTopLevelBlock(source string, offset int, content string, ensureBlank
 		insertion += "\n"
 	}
 
-	if len(after) > 0 && after[0] != '\n' {
+	switch {
+	case strings.HasPrefix(after, "\n\n"):
+		// The blank line that followed the anchor now follows the block
+		insertion = strings.TrimSuffix(insertion, "\n")
+	case len(after) > 0 && after[0] != '\n':
 		insertion += "\n"
 	}
 
@@ -430,6 +435,12 @@ func (c *Config) ValidateQueryAttributes(target base.Target, source string, attr
 		return true
 	}
 
+	for key, pattern := range attributes {
+		if tagKey, ok := strings.CutPrefix(key, "tag."); ok && !c.matchesTag(target, source, tagKey, pattern) {
+			return false
+		}
+	}
+
 	typePattern := strings.TrimSpace(attributes["type"])
 	if typePattern == "" {
 		return true
@@ -449,6 +460,21 @@ func (c *Config) ValidateQueryAttributes(target base.Target, source string, attr
 	return matched
 }
 
+// matchesTag reports whether a struct field's tag has key with a value
+// matching pattern, so field:* tag.json=* selects fields with a json tag.
+func (c *Config) matchesTag(target base.Target, source, key, pattern string) bool {
+	_, pairs, err := fieldTag(source, target)
+	if err != nil {
+		return false
+	}
+	i := tagIndex(pairs, key)
+	if i < 0 {
+		return false
+	}
+	matched, err := path.Match(strings.Trim(pattern, `"'`), pairs[i].value)
+	return err == nil && matched
+}
+
 func (c *Config) extractTargetType(node *sitter.Node, source string) string {
 	if node == nil {
 		return ""
@@ -526,11 +552,7 @@ func (c *Config) expandShortVarDeclaration(node *sitter.Node, source string, que
 func (c *Config) expandImportDeclaration(node *sitter.Node, source string, query core.AgentQuery) []base.Target {
 	var matches []base.Target
 
-	for i := 0; i < int(node.ChildCount()); i++ {
-		child := node.Child(i)
-		if child.Type() != "import_spec" {
-			continue
-		}
+	for _, child := range goImportSpecs(node) {
 		var name string
 		if nameNode := child.ChildByFieldName("name"); nameNode != nil {
 			name = source[nameNode.StartByte():nameNode.EndByte()]
@@ -557,3 +579,25 @@ func (c *Config) expandImportDeclaration(node *sitter.Node, source string, query
 
 	return matches
 }
+
+// Format formats Go source in-process with go/format.
+func (c *Config) Format(source string) (string, error) {
+	formatted, err := format.Source([]byte(source))
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
+
+// FormatterName names the in-process Go formatter.
+func (c *Config) FormatterName() string {
+	return "gofmt"
+}
+
+// MemberVisibility reports exported names as public and the rest as private.
+func (c *Config) MemberVisibility(node *sitter.Node, source string) string {
+	if c.IsExported(c.ExtractNodeName(node, source)) {
+		return "public"
+	}
+	return "private"
+}
@@ -0,0 +1,176 @@
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	base "github.com/oxhq/morfx/providers/base"
+)
+
+// ExtractionBindings returns identifiers declared or assigned under node.
+func (c *Config) ExtractionBindings(node *sitter.Node, source string) []*sitter.Node {
+	var bindings []*sitter.Node
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "parameter_declaration", "variadic_parameter_declaration", "var_spec", "const_spec":
+			for i := 0; i < int(n.ChildCount()); i++ {
+				if n.FieldNameForChild(i) == "name" {
+					bindings = appendGoIdentifier(bindings, n.Child(i), source)
+				}
+			}
+		case "short_var_declaration", "assignment_statement", "range_clause", "receive_statement":
+			if left := n.ChildByFieldName("left"); left != nil {
+				bindings = appendGoIdentifierList(bindings, left, source)
+			}
+		case "type_switch_statement":
+			if alias := n.ChildByFieldName("alias"); alias != nil {
+				bindings = appendGoIdentifierList(bindings, alias, source)
+			}
+		case "inc_statement", "dec_statement":
+			bindings = appendGoIdentifier(bindings, n.NamedChild(0), source)
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(node)
+	return bindings
+}
+
+// ExtractionReferences returns identifiers under node that name variables.
+func (c *Config) ExtractionReferences(node *sitter.Node, source string) []*sitter.Node {
+	var references []*sitter.Node
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		if n.Type() == "identifier" && !isGoCompositeKey(n) {
+			references = appendGoIdentifier(references, n, source)
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(node)
+	return references
+}
+
+func appendGoIdentifierList(nodes []*sitter.Node, list *sitter.Node, source string) []*sitter.Node {
+	if list.Type() != "expression_list" {
+		return appendGoIdentifier(nodes, list, source)
+	}
+	for i := 0; i < int(list.NamedChildCount()); i++ {
+		nodes = appendGoIdentifier(nodes, list.NamedChild(i), source)
+	}
+	return nodes
+}
+
+func appendGoIdentifier(nodes []*sitter.Node, node *sitter.Node, source string) []*sitter.Node {
+	if node == nil || node.Type() != "identifier" || source[node.StartByte():node.EndByte()] == "_" {
+		return nodes
+	}
+	return append(nodes, node)
+}
+
+// isGoCompositeKey reports whether ident is the key of a keyed composite
+// literal element, such as Name in T{Name: v}.
+func isGoCompositeKey(ident *sitter.Node) bool {
+	element := ident.Parent()
+	if element == nil || element.Type() != "literal_element" {
+		return false
+	}
+	keyed := element.Parent()
+	return keyed != nil && keyed.Type() == "keyed_element" && keyed.NamedChild(0) == element
+}
+
+// RenderExtraction renders the extracted Go function and its call site.
+func (c *Config) RenderExtraction(source string, spec base.ExtractionSpec) (string, string, error) {
+	checked, _ := typeCheckSource(source)
+	typeOf := func(variable base.ExtractedVariable) (string, error) {
+		if checked != nil && variable.Binding != nil {
+			if t := checked.typeAt(int(variable.Binding.StartByte())); t != nil && t.String() != "invalid type" {
+				return checked.typeString(t), nil
+			}
+		}
+		if declared := goDeclaredType(variable.Binding, source); declared != "" {
+			return declared, nil
+		}
+		return "", fmt.Errorf("cannot determine the type of %q; declare it with an explicit type", variable.Name)
+	}
+
+	params := make([]string, 0, len(spec.Params))
+	args := make([]string, 0, len(spec.Params))
+	for _, param := range spec.Params {
+		typ, err := typeOf(param)
+		if err != nil {
+			return "", "", err
+		}
+		params = append(params, param.Name+" "+typ)
+		args = append(args, param.Name)
+	}
+
+	resultTypes := make([]string, 0, len(spec.Results))
+	resultNames := make([]string, 0, len(spec.Results))
+	var predeclared []string
+	for _, result := range spec.Results {
+		typ, err := typeOf(result)
+		if err != nil {
+			return "", "", err
+		}
+		resultTypes = append(resultTypes, typ)
+		resultNames = append(resultNames, result.Name)
+		if !result.Declared {
+			predeclared = append(predeclared, "var "+result.Name+" "+typ)
+		}
+	}
+
+	var function strings.Builder
+	fmt.Fprintf(&function, "func %s(%s)", spec.Name, strings.Join(params, ", "))
+	switch len(resultTypes) {
+	case 0:
+	case 1:
+		function.WriteString(" " + resultTypes[0])
+	default:
+		function.WriteString(" (" + strings.Join(resultTypes, ", ") + ")")
+	}
+	function.WriteString(" {\n")
+	function.WriteString(base.IndentLines(spec.Body, "\t"))
+	if len(resultNames) > 0 {
+		function.WriteString("\n\treturn " + strings.Join(resultNames, ", "))
+	}
+	function.WriteString("\n}\n")
+
+	invocation := spec.Name + "(" + strings.Join(args, ", ") + ")"
+	var call string
+	switch {
+	case len(resultNames) == 0:
+		call = invocation
+	case len(predeclared) == len(resultNames):
+		call = strings.Join(resultNames, ", ") + " := " + invocation
+	default:
+		// Mixing new and existing variables with := could shadow the existing
+		// ones, so declare the new ones first and assign all of them.
+		lines := append(predeclared, strings.Join(resultNames, ", ")+" = "+invocation)
+		call = strings.Join(lines, "\n")
+	}
+	return function.String(), call, nil
+}
+
+// goDeclaredType returns the explicit type written next to a binding, if any.
+func goDeclaredType(binding *sitter.Node, source string) string {
+	if binding == nil || binding.Parent() == nil {
+		return ""
+	}
+	switch parent := binding.Parent(); parent.Type() {
+	case "parameter_declaration", "var_spec", "const_spec":
+		if typeNode := parent.ChildByFieldName("type"); typeNode != nil {
+			return source[typeNode.StartByte():typeNode.EndByte()]
+		}
+	case "variadic_parameter_declaration":
+		if typeNode := parent.ChildByFieldName("type"); typeNode != nil {
+			return "[]" + source[typeNode.StartByte():typeNode.EndByte()]
+		}
+	}
+	return ""
+}
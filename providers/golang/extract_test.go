@@ -0,0 +1,114 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformExtractFunctionByLines(t *testing.T) {
+	provider := New()
+	source := `package main
+
+import "strings"
+
+func Process(items []string, sep string) (int, string) {
+	total := 0
+	var parts []string
+	for _, item := range items {
+		total += len(item)
+		parts = append(parts, item)
+	}
+	label := strings.Join(parts, sep)
+	return total, label
+}
+`
+
+	query, err := core.ParseDSL("func:Process lines=6..11")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:       "extract_function",
+		Target:       query,
+		FunctionName: "collect",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+
+	if !strings.Contains(result.Modified, "\ttotal, parts := collect(items)\n\tlabel := strings.Join(parts, sep)") {
+		t.Fatalf("expected call site to replace the statements, got:\n%s", result.Modified)
+	}
+	if !strings.Contains(result.Modified, "func collect(items []string) (int, []string) {\n\ttotal := 0\n") {
+		t.Fatalf("expected typed extracted function, got:\n%s", result.Modified)
+	}
+	if !strings.Contains(result.Modified, "\treturn total, parts\n}\n") {
+		t.Fatalf("expected extracted function to return used-after variables, got:\n%s", result.Modified)
+	}
+	if validation := provider.Validate(result.Modified); !validation.Valid {
+		t.Fatalf("extracted source does not parse: %v", validation.Errors)
+	}
+	if params := result.Metadata["parameters"]; len(params.([]string)) != 1 {
+		t.Fatalf("expected one parameter in metadata, got %+v", result.Metadata)
+	}
+}
+
+func TestTransformExtractFunctionAssignsExistingVariables(t *testing.T) {
+	provider := New()
+	source := `package main
+
+func Sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+`
+
+	query, err := core.ParseDSL("for:*")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:       "extract_function",
+		Target:       query,
+		FunctionName: "accumulate",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "\ttotal = accumulate(values, total)\n") {
+		t.Fatalf("expected assignment to existing variable, got:\n%s", result.Modified)
+	}
+	if !strings.Contains(result.Modified, "func accumulate(values []int, total int) int {") {
+		t.Fatalf("expected extracted signature, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformExtractFunctionRejectsReturn(t *testing.T) {
+	provider := New()
+	source := `package main
+
+func Check(v int) int {
+	if v > 0 {
+		return v
+	}
+	return 0
+}
+`
+
+	query, err := core.ParseDSL("func:Check lines=4..6")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{Method: "extract_function", Target: query})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "control flow") {
+		t.Fatalf("expected control flow error, got %v", result.Error)
+	}
+}
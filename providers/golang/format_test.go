@@ -0,0 +1,111 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformFormatsWithGofmt(t *testing.T) {
+	provider := New()
+	source := "package main\n\nfunc run() {\n}\n"
+
+	query, err := core.ParseDSL("func:run")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{
+		Method:      "replace",
+		Target:      query,
+		Replacement: "func run() {\n    if true {\n  println( \"x\" )\n    }\n}",
+		Format:      true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	want := "func run() {\n\tif true {\n\t\tprintln(\"x\")\n\t}\n}\n"
+	if !strings.HasSuffix(result.Modified, want) {
+		t.Fatalf("expected gofmt output, got:\n%s", result.Modified)
+	}
+	if !strings.Contains(result.Diff, "+\tif true {") {
+		t.Fatalf("expected diff of formatted output, got:\n%s", result.Diff)
+	}
+
+	var found bool
+	for _, factor := range result.Confidence.Factors {
+		if factor.Name == "formatted" {
+			found = true
+			if factor.Reason != "Formatted with gofmt" {
+				t.Fatalf("unexpected formatted factor %+v", factor)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected formatted factor, got %+v", result.Confidence.Factors)
+	}
+}
+
+func TestTransformFormatFallsBackOnSyntaxError(t *testing.T) {
+	provider := New()
+	source := "package main\n\nfunc run() {\n}\n"
+
+	query, err := core.ParseDSL("func:run")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{
+		Method:      "replace",
+		Target:      query,
+		Replacement: "func run() {\n  if {\n}",
+		Format:      true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "\tif {\n}") {
+		t.Fatalf("expected unformatted fallback, got:\n%s", result.Modified)
+	}
+	for _, factor := range result.Confidence.Factors {
+		if factor.Name == "formatted" && strings.HasPrefix(factor.Reason, "gofmt failed") {
+			return
+		}
+	}
+	t.Fatalf("expected gofmt failure factor, got %+v", result.Confidence.Factors)
+}
+
+func TestTransformReplaceUsesTabs(t *testing.T) {
+	provider := New()
+	source := "package main\n\ntype Server struct{}\n\nfunc (s *Server) Run() {\n\ts.start()\n}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "method", Name: "Run"},
+		Replacement: "func (s *Server) Run() {\n    if s != nil {\n        s.start()\n    }\n}",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	want := "func (s *Server) Run() {\n\tif s != nil {\n\t\ts.start()\n\t}\n}\n"
+	if !strings.HasSuffix(result.Modified, want) {
+		t.Fatalf("expected tab indentation, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformReplaceKeepsRawStrings(t *testing.T) {
+	provider := New()
+	source := "package main\n\ntype Server struct{}\n\nfunc (s *Server) Usage() string {\n\treturn \"\"\n}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "method", Name: "Usage"},
+		Replacement: "func (s *Server) Usage() string {\n    return `usage:\n    run\nstop`\n}",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	want := "func (s *Server) Usage() string {\n\treturn `usage:\n    run\nstop`\n}\n"
+	if !strings.HasSuffix(result.Modified, want) {
+		t.Fatalf("expected raw string contents kept, got %q", result.Modified)
+	}
+}
@@ -0,0 +1,396 @@
+package golang
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	base "github.com/oxhq/morfx/providers/base"
+)
+
+// standardPackages maps package names to standard library import paths for
+// resolving missing imports. Ambiguous names use the path goimports prefers.
+var standardPackages = map[string]string{
+	"adler32": "hash/adler32", "aes": "crypto/aes", "ascii85": "encoding/ascii85",
+	"asn1": "encoding/asn1", "ast": "go/ast", "atomic": "sync/atomic",
+	"base32": "encoding/base32", "base64": "encoding/base64", "big": "math/big",
+	"binary": "encoding/binary", "bits": "math/bits", "bufio": "bufio",
+	"build": "go/build", "bytes": "bytes", "bzip2": "compress/bzip2",
+	"cipher": "crypto/cipher", "cmp": "cmp", "cmplx": "math/cmplx",
+	"constant": "go/constant", "context": "context", "cookiejar": "net/http/cookiejar",
+	"crc32": "hash/crc32", "crc64": "hash/crc64", "crypto": "crypto",
+	"csv": "encoding/csv", "debug": "runtime/debug", "des": "crypto/des",
+	"doc": "go/doc", "ecdsa": "crypto/ecdsa", "ed25519": "crypto/ed25519",
+	"elliptic": "crypto/elliptic", "embed": "embed", "errgroup": "golang.org/x/sync/errgroup",
+	"errors": "errors", "exec": "os/exec", "expvar": "expvar",
+	"filepath": "path/filepath", "flag": "flag", "flate": "compress/flate",
+	"fmt": "fmt", "fnv": "hash/fnv", "format": "go/format",
+	"fs": "io/fs", "gob": "encoding/gob", "gzip": "compress/gzip",
+	"hash": "hash", "heap": "container/heap", "hex": "encoding/hex",
+	"hmac": "crypto/hmac", "html": "html", "http": "net/http",
+	"httptest": "net/http/httptest", "httptrace": "net/http/httptrace", "httputil": "net/http/httputil",
+	"image": "image", "importer": "go/importer", "io": "io",
+	"iter": "iter", "json": "encoding/json", "list": "container/list",
+	"log": "log", "maps": "maps", "mail": "net/mail",
+	"math": "math", "md5": "crypto/md5", "mime": "mime",
+	"multipart": "mime/multipart", "net": "net", "netip": "net/netip",
+	"os": "os", "parser": "go/parser", "path": "path",
+	"pem": "encoding/pem", "pprof": "runtime/pprof", "quotedprintable": "mime/quotedprintable",
+	"rand": "math/rand", "reflect": "reflect", "regexp": "regexp",
+	"ring": "container/ring", "rpc": "net/rpc", "rsa": "crypto/rsa",
+	"runtime": "runtime", "scanner": "text/scanner", "sha1": "crypto/sha1",
+	"sha256": "crypto/sha256", "sha512": "crypto/sha512", "signal": "os/signal",
+	"slices": "slices", "slog": "log/slog", "smtp": "net/smtp",
+	"sort": "sort", "sql": "database/sql", "strconv": "strconv",
+	"strings": "strings", "subtle": "crypto/subtle", "sync": "sync",
+	"syscall": "syscall", "tabwriter": "text/tabwriter", "tar": "archive/tar",
+	"template": "text/template", "testing": "testing", "textproto": "net/textproto",
+	"time": "time", "tls": "crypto/tls", "token": "go/token",
+	"trace": "runtime/trace", "types": "go/types", "unicode": "unicode",
+	"unsafe": "unsafe", "url": "net/url", "user": "os/user",
+	"utf16": "unicode/utf16", "utf8": "unicode/utf8", "x509": "crypto/x509",
+	"xml": "encoding/xml", "zip": "archive/zip", "zlib": "compress/zlib",
+}
+
+// goImport is one import_spec of a source file.
+type goImport struct {
+	node  *sitter.Node
+	alias string
+	path  string
+}
+
+// binding returns the identifier the import introduces in the file.
+func (i goImport) binding() string {
+	if i.alias != "" {
+		return i.alias
+	}
+	return importPathPackageName(i.path)
+}
+
+// importPathPackageName guesses the package name of an import path the way
+// goimports does: the last element, skipping major version suffixes and
+// go- prefixes or -go suffixes.
+func importPathPackageName(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) > 1 && name[0] == 'v' {
+		if _, err := strconv.Atoi(name[1:]); err == nil {
+			name = parts[len(parts)-2]
+		}
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	if dot := strings.Index(name, "."); dot > 0 {
+		name = name[:dot]
+	}
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+func goImports(root *sitter.Node, source string) []goImport {
+	var imports []goImport
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		declaration := root.NamedChild(i)
+		if declaration.Type() != "import_declaration" {
+			continue
+		}
+		for _, spec := range goImportSpecs(declaration) {
+			imports = append(imports, newGoImport(spec, source))
+		}
+	}
+	return imports
+}
+
+func goImportSpecs(declaration *sitter.Node) []*sitter.Node {
+	var specs []*sitter.Node
+	for i := 0; i < int(declaration.NamedChildCount()); i++ {
+		child := declaration.NamedChild(i)
+		switch child.Type() {
+		case "import_spec":
+			specs = append(specs, child)
+		case "import_spec_list":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if spec := child.NamedChild(j); spec.Type() == "import_spec" {
+					specs = append(specs, spec)
+				}
+			}
+		}
+	}
+	return specs
+}
+
+func newGoImport(spec *sitter.Node, source string) goImport {
+	imported := goImport{node: spec}
+	if nameNode := spec.ChildByFieldName("name"); nameNode != nil {
+		imported.alias = source[nameNode.StartByte():nameNode.EndByte()]
+	}
+	if pathNode := spec.ChildByFieldName("path"); pathNode != nil {
+		imported.path = strings.Trim(source[pathNode.StartByte():pathNode.EndByte()], "\"`")
+	}
+	return imported
+}
+
+// qualifierNames returns the identifiers used as package qualifiers, as in
+// fmt.Println or http.Handler.
+func qualifierNames(root *sitter.Node, source string) map[string]struct{} {
+	used := make(map[string]struct{})
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "import_declaration":
+			return
+		case "selector_expression":
+			if operand := n.ChildByFieldName("operand"); operand != nil && operand.Type() == "identifier" {
+				used[source[operand.StartByte():operand.EndByte()]] = struct{}{}
+			}
+		case "qualified_type":
+			if pkg := n.ChildByFieldName("package"); pkg != nil {
+				used[source[pkg.StartByte():pkg.EndByte()]] = struct{}{}
+			}
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(root)
+	return used
+}
+
+// UnusedImports returns imports whose package name is never used as a qualifier.
+func (c *Config) UnusedImports(source string, root *sitter.Node) []base.Target {
+	used := qualifierNames(root, source)
+	var unused []base.Target
+	for _, imported := range goImports(root, source) {
+		name := imported.binding()
+		if name == "_" || name == "." {
+			continue
+		}
+		if _, ok := used[name]; !ok {
+			unused = append(unused, base.NewTarget(imported.node, "import", imported.path))
+		}
+	}
+	return unused
+}
+
+// MissingImports returns standard library paths for qualifiers without an import.
+func (c *Config) MissingImports(source string, root *sitter.Node) []string {
+	declared := make(map[string]struct{})
+	for _, imported := range goImports(root, source) {
+		declared[imported.binding()] = struct{}{}
+	}
+	for _, binding := range c.ExtractionBindings(root, source) {
+		declared[source[binding.StartByte():binding.EndByte()]] = struct{}{}
+	}
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		if name := c.ExtractNodeName(root.NamedChild(i), source); name != "" {
+			declared[name] = struct{}{}
+		}
+	}
+
+	var missing []string
+	for name := range qualifierNames(root, source) {
+		if _, ok := declared[name]; ok {
+			continue
+		}
+		if importPath, ok := standardPackages[name]; ok {
+			missing = append(missing, strconv.Quote(importPath))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// parseGoImportSpec accepts "path", path, alias "path", or import alias "path".
+func parseGoImportSpec(spec string) (string, string, error) {
+	spec = strings.TrimSpace(spec)
+	spec = strings.TrimSpace(strings.TrimPrefix(spec, "import "))
+	if strings.HasPrefix(spec, "(") {
+		return "", "", fmt.Errorf("ensure_import takes a single import, got a group")
+	}
+	quote := strings.IndexAny(spec, "\"`")
+	if quote < 0 {
+		if spec == "" || strings.ContainsAny(spec, " \t\n") {
+			return "", "", fmt.Errorf("invalid Go import %q", spec)
+		}
+		return "", spec, nil
+	}
+	alias := strings.TrimSpace(spec[:quote])
+	importPath := strings.Trim(strings.TrimSpace(spec[quote:]), "\"`")
+	if importPath == "" {
+		return "", "", fmt.Errorf("invalid Go import %q", spec)
+	}
+	return alias, importPath, nil
+}
+
+func formatGoImportSpec(alias, importPath string) string {
+	if alias != "" {
+		return alias + " " + strconv.Quote(importPath)
+	}
+	return strconv.Quote(importPath)
+}
+
+func isStandardImportPath(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// EnsureImport adds a Go import, merging it into the existing import group
+// in sorted position when there is one.
+func (c *Config) EnsureImport(source string, root *sitter.Node, spec string) (string, error) {
+	alias, importPath, err := parseGoImportSpec(spec)
+	if err != nil {
+		return source, err
+	}
+	imports := goImports(root, source)
+	for _, imported := range imports {
+		if imported.path == importPath && imported.alias == alias {
+			return source, nil
+		}
+	}
+	line := formatGoImportSpec(alias, importPath)
+
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		declaration := root.NamedChild(i)
+		if declaration.Type() != "import_declaration" {
+			continue
+		}
+		for j := 0; j < int(declaration.NamedChildCount()); j++ {
+			if list := declaration.NamedChild(j); list.Type() == "import_spec_list" {
+				return insertIntoImportList(source, list, importPath, line), nil
+			}
+		}
+	}
+
+	if len(imports) > 0 {
+		// Turn the last single-line import into a sorted group.
+		last := imports[len(imports)-1]
+		declaration := last.node.Parent()
+		lines := []string{source[last.node.StartByte():last.node.EndByte()], line}
+		slices.SortStableFunc(lines, func(a, b string) int {
+			return strings.Compare(specPathOf(a), specPathOf(b))
+		})
+		group := "import (\n\t" + strings.Join(lines, "\n\t") + "\n)"
+		return source[:declaration.StartByte()] + group + source[declaration.EndByte():], nil
+	}
+
+	if modified, ok := c.insertAfterLastImport(source, root, line); ok {
+		return modified, nil
+	}
+	return source, fmt.Errorf("cannot place import: source has no package clause")
+}
+
+func specPathOf(spec string) string {
+	if quote := strings.IndexAny(spec, "\"`"); quote >= 0 {
+		return strings.Trim(spec[quote:], "\"`")
+	}
+	return spec
+}
+
+// insertIntoImportList inserts line among the specs of the same kind
+// (standard library or not), keeping them sorted.
+func insertIntoImportList(source string, list *sitter.Node, importPath, line string) string {
+	standard := isStandardImportPath(importPath)
+	var lastSameKind *sitter.Node
+	for i := 0; i < int(list.NamedChildCount()); i++ {
+		spec := list.NamedChild(i)
+		if spec.Type() != "import_spec" {
+			continue
+		}
+		existing := newGoImport(spec, source).path
+		if isStandardImportPath(existing) != standard {
+			continue
+		}
+		if existing > importPath {
+			start := strings.LastIndex(source[:spec.StartByte()], "\n") + 1
+			indent := source[start:spec.StartByte()]
+			return source[:start] + indent + line + "\n" + source[start:]
+		}
+		lastSameKind = spec
+	}
+
+	if lastSameKind != nil {
+		end := int(lastSameKind.EndByte())
+		start := strings.LastIndex(source[:lastSameKind.StartByte()], "\n") + 1
+		indent := source[start:lastSameKind.StartByte()]
+		if newline := strings.IndexByte(source[end:], '\n'); newline >= 0 {
+			end += newline
+		}
+		return source[:end] + "\n" + indent + line + source[end:]
+	}
+
+	// No spec of the same kind: start a new block before the closing paren.
+	closing := int(list.EndByte()) - 1
+	lineStart := strings.LastIndex(source[:closing], "\n") + 1
+	separator := ""
+	if list.NamedChildCount() > 0 {
+		separator = "\n"
+	}
+	return source[:lineStart] + separator + "\t" + line + "\n" + source[lineStart:]
+}
+
+// RemoveImports deletes the targeted import specs, dropping import
+// declarations that end up empty.
+func (c *Config) RemoveImports(source string, root *sitter.Node, targets []base.Target) (string, error) {
+	type removal struct{ start, end int }
+	var removals []removal
+	removedByDeclaration := make(map[*sitter.Node]int)
+	declarations := make(map[*sitter.Node]*sitter.Node)
+
+	for _, target := range targets {
+		node := target.Node
+		if node == nil {
+			continue
+		}
+		declaration := node
+		for declaration != nil && declaration.Type() != "import_declaration" {
+			declaration = declaration.Parent()
+		}
+		if declaration == nil {
+			return source, fmt.Errorf("target %q is not an import", target.Name)
+		}
+		if node == declaration {
+			removedByDeclaration[declaration] = len(goImportSpecs(declaration))
+		} else {
+			removedByDeclaration[declaration]++
+			declarations[node] = declaration
+		}
+	}
+
+	whole := make(map[*sitter.Node]bool)
+	for declaration, count := range removedByDeclaration {
+		if count >= len(goImportSpecs(declaration)) {
+			whole[declaration] = true
+			removals = append(removals, removal{int(declaration.StartByte()), int(declaration.EndByte())})
+		}
+	}
+	for spec, declaration := range declarations {
+		if whole[declaration] {
+			continue
+		}
+		start := strings.LastIndex(source[:spec.StartByte()], "\n") + 1
+		end := int(spec.EndByte())
+		if newline := strings.IndexByte(source[end:], '\n'); newline >= 0 {
+			end += newline + 1
+		}
+		removals = append(removals, removal{start, end})
+	}
+
+	sort.Slice(removals, func(i, j int) bool { return removals[i].start > removals[j].start })
+	result := source
+	for _, r := range removals {
+		before, after := result[:r.start], result[r.end:]
+		if strings.HasSuffix(before, "\n") && strings.HasPrefix(after, "\n") {
+			// Dropping a whole declaration: also drop its line break and
+			// avoid leaving a double blank line.
+			after = after[1:]
+			if strings.HasSuffix(before, "\n\n") && strings.HasPrefix(after, "\n") {
+				after = after[1:]
+			}
+		}
+		result = before + after
+	}
+	return result, nil
+}
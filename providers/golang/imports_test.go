@@ -0,0 +1,220 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformEnsureImportMergesIntoGroup(t *testing.T) {
+	provider := New()
+	source := `package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/acme/lib"
+)
+
+func main() {
+	fmt.Println(strings.ToUpper(lib.Name))
+}
+`
+
+	result := provider.Transform(source, core.TransformOp{Method: "ensure_import", Content: `"os"`})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	want := strings.Replace(source, "\t\"fmt\"\n", "\t\"fmt\"\n\t\"os\"\n", 1)
+	if result.Modified != want {
+		t.Fatalf("expected sorted import group, got:\n%s", result.Modified)
+	}
+	if result.MatchCount != 1 {
+		t.Fatalf("expected one change, got %d", result.MatchCount)
+	}
+
+	again := provider.Transform(result.Modified, core.TransformOp{Method: "ensure_import", Content: `import "os"`})
+	if again.Error != nil {
+		t.Fatalf("Transform returned error: %v", again.Error)
+	}
+	if again.Modified != result.Modified || again.MatchCount != 0 {
+		t.Fatalf("expected ensure_import to be idempotent, got:\n%s", again.Modified)
+	}
+}
+
+func TestTransformEnsureImportGroupsSingleImport(t *testing.T) {
+	provider := New()
+	source := `package main
+
+import "fmt"
+
+func main() {}
+`
+
+	result := provider.Transform(source, core.TransformOp{Method: "ensure_import", Content: `errs "errors"`})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	want := "package main\n\nimport (\n\terrs \"errors\"\n\t\"fmt\"\n)\n\nfunc main() {}\n"
+	if result.Modified != want {
+		t.Fatalf("expected single import converted to a group, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformEnsureImportIntoSingleSpecGroup(t *testing.T) {
+	provider := New()
+	source := "package main\n\nimport (\n\t\"fmt\"\n)\n\nfunc main() {}\n"
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{`"errors"`, "package main\n\nimport (\n\t\"errors\"\n\t\"fmt\"\n)\n\nfunc main() {}\n"},
+		{`"strings"`, "package main\n\nimport (\n\t\"fmt\"\n\t\"strings\"\n)\n\nfunc main() {}\n"},
+		{`"github.com/acme/lib"`, "package main\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/acme/lib\"\n)\n\nfunc main() {}\n"},
+	}
+	for _, tt := range tests {
+		result := provider.Transform(source, core.TransformOp{Method: "ensure_import", Content: tt.spec})
+		if result.Error != nil {
+			t.Fatalf("Transform(%s) returned error: %v", tt.spec, result.Error)
+		}
+		if result.Modified != tt.want {
+			t.Errorf("Transform(%s) = %q, want %q", tt.spec, result.Modified, tt.want)
+		}
+	}
+}
+
+func TestTransformEnsureImportWithoutImports(t *testing.T) {
+	provider := New()
+	source := "package main\n\nfunc main() {}\n"
+
+	result := provider.Transform(source, core.TransformOp{Method: "ensure_import", Content: "fmt"})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if want := "package main\n\nimport \"fmt\"\n\nfunc main() {}\n"; result.Modified != want {
+		t.Fatalf("expected import after package clause, got %q", result.Modified)
+	}
+}
+
+func TestTransformRemoveImport(t *testing.T) {
+	provider := New()
+	source := `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Println()
+}
+`
+
+	query, err := core.ParseDSL("import:os")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{Method: "remove_import", Target: query})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "import (\n\t\"fmt\"\n)") || strings.Contains(result.Modified, `"os"`) {
+		t.Fatalf("expected os import removed, got:\n%s", result.Modified)
+	}
+
+	query, err = core.ParseDSL("import:fmt")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result = provider.Transform(result.Modified, core.TransformOp{Method: "remove_import", Target: query})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if strings.Contains(result.Modified, "import") {
+		t.Fatalf("expected empty import declaration removed, got:\n%s", result.Modified)
+	}
+	if strings.Contains(result.Modified, "\n\n\n") {
+		t.Fatalf("expected no doubled blank lines, got:\n%q", result.Modified)
+	}
+}
+
+func TestTransformOrganizeImportsAfterReplace(t *testing.T) {
+	provider := New()
+	source := `package main
+
+import (
+	"log"
+	"os"
+)
+
+var _ = os.Args
+
+func report(msg string) {
+	log.Printf("%s", msg)
+}
+`
+
+	query, err := core.ParseDSL("func:report")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{
+		Method:          "replace",
+		Target:          query,
+		Replacement:     "func report(msg string) {\n\tslog.Info(msg)\n}",
+		OrganizeImports: true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if strings.Contains(result.Modified, "\"log\"\n") {
+		t.Fatalf("expected unused log import removed, got:\n%s", result.Modified)
+	}
+	if !strings.Contains(result.Modified, "\"log/slog\"") || !strings.Contains(result.Modified, "\"os\"") {
+		t.Fatalf("expected log/slog added and os kept, got:\n%s", result.Modified)
+	}
+	if removed, _ := result.Metadata["imports_removed"].([]string); len(removed) != 1 || removed[0] != "log" {
+		t.Fatalf("expected imports_removed [log], got %#v", result.Metadata["imports_removed"])
+	}
+	if added, _ := result.Metadata["imports_added"].([]string); len(added) != 1 || added[0] != `"log/slog"` {
+		t.Fatalf("expected imports_added [\"log/slog\"], got %#v", result.Metadata["imports_added"])
+	}
+}
+
+func TestTransformOrganizeImportsKeepsPreexistingUnused(t *testing.T) {
+	provider := New()
+	source := `package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func greet() {
+	fmt.Println("hi")
+}
+`
+
+	query, err := core.ParseDSL("func:greet")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{
+		Method:          "replace",
+		Target:          query,
+		Replacement:     "func greet() {\n\tfmt.Println(\"hello\")\n}",
+		OrganizeImports: true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, `"strings"`) {
+		t.Fatalf("expected already-unused import left alone, got:\n%s", result.Modified)
+	}
+	if _, ok := result.Metadata["imports_removed"]; ok {
+		t.Fatalf("expected no import changes, got %#v", result.Metadata)
+	}
+}
@@ -452,3 +452,131 @@ func main() {
 	t.Logf("Invalid code validation - Valid: %t, Errors: %v",
 		invalidResult.Valid, invalidResult.Errors)
 }
+
+func TestGoProviderDeleteTakesDocComment(t *testing.T) {
+	provider := New()
+	source := "package main\n\n// Config holds settings.\ntype Config struct {\n\t// Name is shown in logs.\n\tName string `json:\"name\"` // required\n\tPort int\n}\n"
+
+	field := provider.Transform(source, core.TransformOp{
+		Method: "delete",
+		Target: core.AgentQuery{Type: "field", Name: "Name"},
+	})
+	if field.Error != nil {
+		t.Fatalf("Transform failed: %v", field.Error)
+	}
+	if strings.Contains(field.Modified, "shown in logs") || strings.Contains(field.Modified, "required") || !strings.Contains(field.Modified, "Port int") {
+		t.Fatalf("expected the field and its comments to be deleted:\n%s", field.Modified)
+	}
+
+	// The struct target is the type_spec; its type keyword and doc go too.
+	decl := provider.Transform(source, core.TransformOp{
+		Method: "delete",
+		Target: core.AgentQuery{Type: "struct", Name: "Config"},
+	})
+	if decl.Error != nil {
+		t.Fatalf("Transform failed: %v", decl.Error)
+	}
+	if strings.TrimSpace(decl.Modified) != "package main" {
+		t.Fatalf("expected only the package clause to remain:\n%s", decl.Modified)
+	}
+}
+
+func TestGoProviderSortStructFields(t *testing.T) {
+	provider := New()
+	source := "package main\n\ntype S struct {\n\t// Zeta doc\n\tZeta int // z\n\talpha string\n\tBeta bool `json:\"b\"`\n}\n"
+	target, err := core.ParseDSL("struct:S > field:*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	byName := provider.Transform(source, core.TransformOp{Method: "sort", Target: target})
+	if byName.Error != nil {
+		t.Fatalf("Transform failed: %v", byName.Error)
+	}
+	want := "package main\n\ntype S struct {\n\talpha string\n\tBeta bool `json:\"b\"`\n\t// Zeta doc\n\tZeta int // z\n}\n"
+	if byName.Modified != want {
+		t.Fatalf("unexpected name order:\n%s", byName.Modified)
+	}
+
+	// Unexported fields count as private; ties keep their order.
+	byVisibility := provider.Transform(source, core.TransformOp{
+		Method:    "sort",
+		Target:    target,
+		SortBy:    core.SortByVisibility,
+		SortOrder: []string{"private", "public"},
+	})
+	if byVisibility.Error != nil {
+		t.Fatalf("Transform failed: %v", byVisibility.Error)
+	}
+	if !strings.Contains(byVisibility.Modified, "{\n\talpha string\n\t// Zeta doc\n\tZeta int // z\n\tBeta bool `json:\"b\"`\n}") {
+		t.Fatalf("unexpected visibility order:\n%s", byVisibility.Modified)
+	}
+}
+
+func TestGoProviderSortImportsAndCaptures(t *testing.T) {
+	provider := New()
+
+	imports := provider.Transform("package main\n\nimport (\n\t\"os\"\n\t\"fmt\" // printing\n\t\"bytes\"\n)\n", core.TransformOp{
+		Method: "sort",
+		Target: core.AgentQuery{Type: "import", Name: "*"},
+	})
+	if imports.Error != nil {
+		t.Fatalf("Transform failed: %v", imports.Error)
+	}
+	if want := "import (\n\t\"bytes\"\n\t\"fmt\" // printing\n\t\"os\"\n)"; !strings.Contains(imports.Modified, want) {
+		t.Fatalf("unexpected import order:\n%s", imports.Modified)
+	}
+
+	target, err := core.ParseDSL("func:Handle$x")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+	captured := provider.Transform("package main\n\nfunc HandleZ() {}\n\nfunc HandleA() {}\n", core.TransformOp{
+		Method: "sort",
+		Target: target,
+		SortBy: "$x",
+	})
+	if captured.Error != nil {
+		t.Fatalf("Transform failed: %v", captured.Error)
+	}
+	if captured.Modified != "package main\n\nfunc HandleA() {}\n\nfunc HandleZ() {}\n" {
+		t.Fatalf("unexpected capture order:\n%s", captured.Modified)
+	}
+}
+
+func TestGoProvider_StructuralDiffReportsMethodEdits(t *testing.T) {
+	provider := New()
+	source := "package p\n\nimport \"fmt\"\n\ntype S struct {\n\tA int\n}\n\nfunc (s *S) Run(a int, b string) {\n\tfmt.Println(a, b)\n}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:         "replace",
+		Target:         core.AgentQuery{Type: "method", Name: "Run"},
+		Replacement:    "func (s *S) Run(x int, b string) {\n\tfmt.Println(x, b)\n}",
+		StructuralDiff: true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	changes, _ := result.Metadata["structural_diff"].([]core.StructuralChange)
+	want := []core.StructuralChange{
+		{Change: "modified", Kind: "method", Path: "method Run", Line: 9, Summary: "renamed parameter a to x in method Run"},
+		{Change: "modified", Kind: "method", Path: "method Run", Line: 9, Summary: `changed argument 1 of call to fmt.Println in method Run from "a" to "x"`},
+	}
+	if !slices.Equal(changes, want) {
+		t.Fatalf("structural diff = %+v, want %+v", changes, want)
+	}
+
+	field := provider.Transform(source, core.TransformOp{
+		Method:         "replace",
+		Target:         core.AgentQuery{Type: "struct", Name: "S"},
+		Replacement:    "S struct {\n\tA int\n\tB string\n}",
+		StructuralDiff: true,
+	})
+	if field.Error != nil {
+		t.Fatalf("Transform failed: %v", field.Error)
+	}
+	changes, _ = field.Metadata["structural_diff"].([]core.StructuralChange)
+	if len(changes) != 1 || changes[0].Summary != "inserted field B in struct S" || changes[0].Path != "struct S > field B" {
+		t.Fatalf("expected an inserted field, got %+v", changes)
+	}
+}
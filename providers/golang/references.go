@@ -0,0 +1,119 @@
+package golang
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/golang"
+
+	"github.com/oxhq/morfx/core"
+)
+
+// ReferencePackage implements base.PackageReferenceConfig. The import path
+// comes from the nearest go.mod and is empty for a main package or a file
+// outside any module, which no other package can import.
+func (c *Config) ReferencePackage(filePath, source string) (string, string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filePath, source, parser.PackageClauseOnly)
+	if err != nil {
+		return "", "", err
+	}
+	name := strings.TrimSuffix(file.Name.Name, "_test")
+	dir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return "", name, err
+	}
+	mod := findGoModule(dir)
+	if mod == nil || name == "main" {
+		return "", name, nil
+	}
+	rel, err := filepath.Rel(mod.dir, dir)
+	if err != nil {
+		return "", name, err
+	}
+	return path.Join(mod.path, filepath.ToSlash(rel)), name, nil
+}
+
+// FindQualifiedReferences implements base.PackageReferenceConfig. It finds
+// pkg.name selectors and qualified types, where pkg is the name source
+// imports importPath under, and bare uses of name after a dot import.
+func (c *Config) FindQualifiedReferences(source, importPath, packageName, name string) []core.Location {
+	if !strings.Contains(source, importPath) || !strings.Contains(source, name) {
+		return nil
+	}
+	root, err := sitter.ParseCtx(context.Background(), []byte(source), golang.GetLanguage())
+	if err != nil || root == nil {
+		return nil
+	}
+
+	qualifiers := make(map[string]bool)
+	for _, imported := range goImports(root, source) {
+		if imported.path != importPath || imported.alias == "_" {
+			continue
+		}
+		if imported.alias != "" {
+			qualifiers[imported.alias] = true
+		} else {
+			qualifiers[packageName] = true
+		}
+	}
+	if len(qualifiers) == 0 {
+		return nil
+	}
+
+	text := func(node *sitter.Node) string {
+		if node == nil {
+			return ""
+		}
+		return source[node.StartByte():node.EndByte()]
+	}
+	var locations []core.Location
+	found := func(node *sitter.Node) {
+		start := node.StartPoint()
+		locations = append(locations, core.Location{Line: int(start.Row) + 1, Column: int(start.Column) + 1})
+	}
+	var walk func(node *sitter.Node)
+	walk = func(node *sitter.Node) {
+		switch node.Type() {
+		case "import_declaration":
+			return
+		case "selector_expression":
+			if field := node.ChildByFieldName("field"); qualifiers[text(node.ChildByFieldName("operand"))] && text(field) == name {
+				found(field)
+			}
+		case "qualified_type":
+			if typeName := node.ChildByFieldName("name"); qualifiers[text(node.ChildByFieldName("package"))] && text(typeName) == name {
+				found(typeName)
+			}
+		case "identifier", "type_identifier":
+			if qualifiers["."] && text(node) == name && !isQualifiedName(node) {
+				found(node)
+			}
+		}
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			walk(node.NamedChild(i))
+		}
+	}
+	walk(root)
+	return locations
+}
+
+// isQualifiedName reports whether node is the right-hand side of a selector
+// or qualified type, as Close in f.Close.
+func isQualifiedName(node *sitter.Node) bool {
+	parent := node.Parent()
+	if parent == nil {
+		return false
+	}
+	switch parent.Type() {
+	case "selector_expression":
+		return parent.ChildByFieldName("field") == node
+	case "qualified_type":
+		return parent.ChildByFieldName("name") == node
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package golang
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestReferencePackageUsesModulePath(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "internal", "store")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("failed to create dirs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n"), 0o644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+
+	config := &Config{}
+	importPath, name, err := config.ReferencePackage(filepath.Join(dir, "store_test.go"), "package store_test\n")
+	if err != nil || importPath != "example.com/app/internal/store" || name != "store" {
+		t.Fatalf("ReferencePackage = %q, %q, %v", importPath, name, err)
+	}
+	if importPath, _, _ := config.ReferencePackage(filepath.Join(root, "main.go"), "package main\n"); importPath != "" {
+		t.Fatalf("expected a main package to have no import path, got %q", importPath)
+	}
+}
+
+func TestFindQualifiedReferences(t *testing.T) {
+	config := &Config{}
+	const importPath = "example.com/app/store"
+
+	tests := []struct {
+		name   string
+		source string
+		want   []core.Location
+	}{
+		{
+			name:   "default name",
+			source: "package main\n\nimport \"example.com/app/store\"\n\nfunc main() {\n\tvar _ store.New\n\tstore.New()\n\tf.New()\n}\n",
+			want:   []core.Location{{Line: 6, Column: 14}, {Line: 7, Column: 8}},
+		},
+		{
+			name:   "alias",
+			source: "package main\n\nimport db \"example.com/app/store\"\n\nfunc main() {\n\tdb.New()\n\tstore.New()\n}\n",
+			want:   []core.Location{{Line: 6, Column: 5}},
+		},
+		{
+			name:   "dot import",
+			source: "package main\n\nimport . \"example.com/app/store\"\n\nfunc main() {\n\tNew()\n\tf.New()\n}\n",
+			want:   []core.Location{{Line: 6, Column: 2}},
+		},
+		{
+			name:   "other package",
+			source: "package main\n\nimport \"example.com/app/other/store\"\n\nfunc main() {\n\tstore.New()\n\tNew()\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := config.FindQualifiedReferences(tt.source, importPath, "store", "New"); !slices.Equal(got, tt.want) {
+				t.Fatalf("FindQualifiedReferences = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
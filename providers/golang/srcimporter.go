@@ -0,0 +1,281 @@
+package golang
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// sourceImporter type-checks imported packages from source without running
+// the go command. Import paths resolve against GOROOT, the enclosing module,
+// its vendor directory, and the module cache at the versions go.mod requires,
+// so nothing is downloaded. Function bodies of imported packages are skipped.
+type sourceImporter struct {
+	fset     *token.FileSet
+	ctxt     build.Context
+	module   *goModule
+	modCache string
+	packages map[string]*types.Package
+	loading  map[string]bool
+}
+
+func newSourceImporter(fset *token.FileSet, dir string) *sourceImporter {
+	return &sourceImporter{
+		fset:     fset,
+		ctxt:     build.Default,
+		module:   findGoModule(dir),
+		modCache: moduleCacheDir(),
+		packages: make(map[string]*types.Package),
+		loading:  make(map[string]bool),
+	}
+}
+
+func (im *sourceImporter) Import(path string) (*types.Package, error) {
+	return im.ImportFrom(path, "", 0)
+}
+
+func (im *sourceImporter) ImportFrom(path, fromDir string, _ types.ImportMode) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+	dir, ok := im.resolve(path, fromDir)
+	if !ok {
+		return nil, fmt.Errorf("cannot find package %q offline", path)
+	}
+	if pkg, ok := im.packages[dir]; ok {
+		return pkg, nil
+	}
+	if im.loading[dir] {
+		return nil, fmt.Errorf("import cycle through %q", path)
+	}
+	im.loading[dir] = true
+	defer delete(im.loading, dir)
+
+	files, err := im.parseDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	config := types.Config{
+		Importer:         im,
+		IgnoreFuncBodies: true,
+		FakeImportC:      true,
+		Error:            func(error) {}, // imported packages are not ours to report on
+	}
+	pkg, _ := config.Check(path, im.fset, files, nil)
+	if pkg == nil {
+		return nil, fmt.Errorf("cannot type-check package %q", path)
+	}
+	pkg.MarkComplete()
+	im.packages[dir] = pkg
+	return pkg, nil
+}
+
+// resolve maps an import path to the directory holding its sources.
+func (im *sourceImporter) resolve(path, fromDir string) (string, bool) {
+	goroot := filepath.Join(im.ctxt.GOROOT, "src")
+	if within(fromDir, goroot) {
+		if dir := filepath.Join(goroot, "vendor", path); isDir(dir) {
+			return dir, true
+		}
+	}
+	if dir := filepath.Join(goroot, path); isStdPath(path) && isDir(dir) {
+		return dir, true
+	}
+	mod := im.module
+	if mod == nil {
+		return "", false
+	}
+	if rest, ok := cutModulePath(path, mod.path); ok {
+		return filepath.Join(mod.dir, rest), true
+	}
+	if dir := filepath.Join(mod.dir, "vendor", path); isDir(dir) {
+		return dir, true
+	}
+
+	best := ""
+	for modPath := range mod.requires {
+		if _, ok := cutModulePath(path, modPath); ok && len(modPath) > len(best) {
+			best = modPath
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	rest, _ := cutModulePath(path, best)
+	if replacement, ok := mod.replaces[best]; ok {
+		if filepath.IsAbs(replacement) || strings.HasPrefix(replacement, ".") {
+			if !filepath.IsAbs(replacement) {
+				replacement = filepath.Join(mod.dir, replacement)
+			}
+			return filepath.Join(replacement, rest), true
+		}
+		fields := strings.Fields(replacement)
+		if len(fields) != 2 {
+			return "", false
+		}
+		best, mod = fields[0], &goModule{requires: map[string]string{fields[0]: fields[1]}}
+	}
+	if im.modCache == "" {
+		return "", false
+	}
+	escaped, ok := escapeModulePath(best)
+	if !ok {
+		return "", false
+	}
+	dir := filepath.Join(im.modCache, escaped+"@"+mod.requires[best], rest)
+	return dir, isDir(dir)
+}
+
+// parseDir parses the files of the package in dir that the default build
+// context selects.
+func (im *sourceImporter) parseDir(dir string) ([]*ast.File, error) {
+	info, err := im.ctxt.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	names := append(append([]string(nil), info.GoFiles...), info.CgoFiles...)
+	files := make([]*ast.File, 0, len(names))
+	for _, name := range names {
+		file, err := parser.ParseFile(im.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// goModule is the part of a go.mod file the importer needs.
+type goModule struct {
+	dir      string
+	path     string
+	requires map[string]string // module path to version
+	replaces map[string]string // module path to a directory or "path version"
+}
+
+// findGoModule reads the go.mod file in dir or its nearest parent.
+func findGoModule(dir string) *goModule {
+	for dir != "" {
+		if mod := readGoModule(filepath.Join(dir, "go.mod")); mod != nil {
+			mod.dir = dir
+			return mod
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return nil
+}
+
+func readGoModule(path string) *goModule {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	mod := &goModule{requires: make(map[string]string), replaces: make(map[string]string)}
+	block := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		switch {
+		case fields[0] == "module" && len(fields) == 2:
+			mod.path = strings.Trim(fields[1], `"`)
+		case fields[0] == "require" && len(fields) == 3:
+			mod.requires[fields[1]] = fields[2]
+		case fields[0] == "replace":
+			arrow := slices.Index(fields, "=>")
+			if arrow < 2 || arrow == len(fields)-1 {
+				continue
+			}
+			mod.replaces[fields[1]] = strings.Join(fields[arrow+1:], " ")
+		}
+	}
+	if mod.path == "" {
+		return nil
+	}
+	return mod
+}
+
+func moduleCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) == 0 || gopath[0] == "" {
+		return ""
+	}
+	return filepath.Join(gopath[0], "pkg", "mod")
+}
+
+// escapeModulePath applies the module cache's case encoding, which writes
+// each upper-case letter as '!' followed by its lower-case form.
+func escapeModulePath(path string) (string, bool) {
+	var b strings.Builder
+	for _, r := range path {
+		if r == '!' || r >= unicode.MaxASCII {
+			return "", false
+		}
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), true
+}
+
+// cutModulePath returns the package directory of path within module modPath.
+func cutModulePath(path, modPath string) (string, bool) {
+	if path == modPath {
+		return "", true
+	}
+	rest, ok := strings.CutPrefix(path, modPath+"/")
+	return filepath.FromSlash(rest), ok
+}
+
+// isStdPath reports whether path looks like a standard library import: its
+// first element has no dot.
+func isStdPath(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+func within(dir, root string) bool {
+	rel, err := filepath.Rel(root, dir)
+	return dir != "" && err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
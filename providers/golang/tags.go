@@ -0,0 +1,376 @@
+package golang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/format"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/golang"
+
+	"github.com/oxhq/morfx/core"
+	"github.com/oxhq/morfx/providers/base"
+)
+
+// errTagCollision reports a rewrite whose new key already holds a
+// different value on the same field.
+var errTagCollision = errors.New("struct tag key collision")
+
+// tagPair is one key:"value" entry of a struct tag.
+type tagPair struct {
+	key, value string
+}
+
+// parseStructTag splits a struct tag body such as json:"id" db:"user_id"
+// into its pairs, keeping their order.
+func parseStructTag(tag string) ([]tagPair, error) {
+	var pairs []tagPair
+	rest := strings.TrimSpace(tag)
+	for rest != "" {
+		colon := strings.Index(rest, ":")
+		if colon <= 0 || colon+1 >= len(rest) || rest[colon+1] != '"' {
+			return nil, fmt.Errorf("invalid struct tag %q (want key:\"value\" pairs)", tag)
+		}
+		key := rest[:colon]
+		if strings.ContainsAny(key, " \t\"`") {
+			return nil, fmt.Errorf("invalid struct tag key %q", key)
+		}
+		quoted, err := strconv.QuotedPrefix(rest[colon+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid struct tag value for %q: %w", key, err)
+		}
+		value, _ := strconv.Unquote(quoted)
+		pairs = append(pairs, tagPair{key: key, value: value})
+		rest = strings.TrimLeft(rest[colon+1+len(quoted):], " \t")
+	}
+	return pairs, nil
+}
+
+func formatStructTag(pairs []tagPair) string {
+	parts := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		parts = append(parts, pair.key+":"+strconv.Quote(pair.value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// fieldTag returns the tag node of a struct field and its parsed pairs.
+func fieldTag(source string, target base.Target) (*sitter.Node, []tagPair, error) {
+	if target.Node == nil || target.Node.Type() != "field_declaration" {
+		return nil, nil, fmt.Errorf("struct tags apply to struct fields, not %s", target.Type)
+	}
+	tag := target.Node.ChildByFieldName("tag")
+	if tag == nil {
+		return nil, nil, nil
+	}
+	literal := source[tag.StartByte():tag.EndByte()]
+	body, err := strconv.Unquote(literal)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid struct tag %s: %w", literal, err)
+	}
+	pairs, err := parseStructTag(body)
+	return tag, pairs, err
+}
+
+// fieldNames returns the names a field declaration declares, such as A and
+// B for A, B int. Embedded fields have none.
+func fieldNames(source string, field *sitter.Node) []string {
+	var names []string
+	for i := 0; i < int(field.NamedChildCount()); i++ {
+		if child := field.NamedChild(i); child.Type() == "field_identifier" {
+			names = append(names, source[child.StartByte():child.EndByte()])
+		}
+	}
+	return names
+}
+
+// EditAnnotations edits the struct tags of field targets for
+// add_annotation/add_tag, remove_annotation/remove_tag, and rewrite_tag.
+// Declarations that were gofmt-clean are realigned afterwards, so tag
+// columns stay lined up. A declaration naming several fields is only tagged
+// when every name gets the same tag, and a rewrite never overwrites another
+// key's value; fields that fail either are left alone and listed in
+// fields_skipped while the rest are edited.
+func (c *Config) EditAnnotations(source string, targets []base.Target, op core.TransformOp) (string, map[string]any, error) {
+	var edits []base.Edit
+	var skippedFields []string
+	changed := 0
+	seen := make(map[uint32]bool)
+	for _, target := range targets {
+		if target.Node != nil {
+			if seen[target.Node.StartByte()] {
+				continue
+			}
+			seen[target.Node.StartByte()] = true
+		}
+		tag, pairs, err := fieldTag(source, target)
+		if err != nil {
+			return source, nil, err
+		}
+
+		edit := func(vars map[string]string) ([]tagPair, error) {
+			switch op.Method {
+			case "add_annotation", "add_tag":
+				return addTagPairs(pairs, op.Annotation, vars, op.IfAbsent)
+			case "remove_annotation", "remove_tag":
+				return removeTagPairs(pairs, op.Annotation), nil
+			case "rewrite_tag":
+				return rewriteTagPair(pairs, op.TagKey, op.Annotation, vars)
+			default:
+				return nil, fmt.Errorf("unsupported struct tag method: %s", op.Method)
+			}
+		}
+		names := fieldNames(source, target.Node)
+		skip := func(reason string) {
+			label := strings.Join(names, ", ")
+			if label == "" {
+				label = target.Name
+			}
+			skippedFields = append(skippedFields, fmt.Sprintf("field %s at line %d: %s", label, target.Node.StartPoint().Row+1, reason))
+		}
+		updated, err := edit(base.AnnotationVars(target))
+		if errors.Is(err, errTagCollision) {
+			skip(err.Error())
+			continue
+		}
+		if err != nil {
+			return source, nil, err
+		}
+		// A, B int shares one tag, so it cannot hold one per name
+		same := true
+		for _, name := range names[min(1, len(names)):] {
+			vars := base.AnnotationVars(target)
+			vars["name"] = name
+			other, err := edit(vars)
+			if err != nil && !errors.Is(err, errTagCollision) {
+				return source, nil, err
+			}
+			if err != nil || !slices.Equal(other, updated) {
+				same = false
+				break
+			}
+		}
+		if !same {
+			skip("needs a different tag for each of its names; declare them separately")
+			continue
+		}
+		if updated == nil {
+			continue
+		}
+		changed++
+		edits = append(edits, tagEdit(source, target.Node, tag, updated))
+	}
+
+	metadata := tagMetadata(op.Method, changed, len(seen)-changed-len(skippedFields))
+	if len(skippedFields) > 0 {
+		metadata["fields_skipped"] = skippedFields
+	}
+	if len(edits) == 0 {
+		if op.Method == "remove_annotation" || op.Method == "remove_tag" {
+			return source, nil, fmt.Errorf("%w: no struct tag key matches %q", core.ErrNoMatchesFound, op.Annotation)
+		}
+		return source, metadata, nil
+	}
+	modified, err := realignDeclarations(source, edits)
+	if err != nil {
+		return source, nil, err
+	}
+	return modified, metadata, nil
+}
+
+func tagMetadata(method string, changed, skipped int) map[string]any {
+	switch method {
+	case "add_annotation", "add_tag":
+		return map[string]any{"annotations_added": changed, "annotations_skipped": skipped}
+	case "remove_annotation", "remove_tag":
+		return map[string]any{"annotations_removed": changed}
+	default:
+		return map[string]any{"tags_rewritten": changed, "tags_skipped": skipped}
+	}
+}
+
+// addTagPairs sets the keys in the expanded template, updating keys that
+// are already there unless ifAbsent is set. It returns nil when nothing
+// changes.
+func addTagPairs(pairs []tagPair, template string, vars map[string]string, ifAbsent bool) ([]tagPair, error) {
+	added, err := expandTagTemplate(template, vars)
+	if err != nil {
+		return nil, err
+	}
+	updated := slices.Clone(pairs)
+	changed := false
+	for _, pair := range added {
+		i := tagIndex(updated, pair.key)
+		switch {
+		case i < 0:
+			updated = append(updated, pair)
+			changed = true
+		case !ifAbsent && updated[i].value != pair.value:
+			updated[i].value = pair.value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	return updated, nil
+}
+
+// removeTagPairs drops the keys matching pattern. It returns nil when none
+// match.
+func removeTagPairs(pairs []tagPair, pattern string) []tagPair {
+	kept := make([]tagPair, 0, len(pairs))
+	for _, pair := range pairs {
+		if matched, _ := path.Match(strings.TrimSpace(pattern), pair.key); !matched {
+			kept = append(kept, pair)
+		}
+	}
+	if len(kept) == len(pairs) {
+		return nil
+	}
+	return kept
+}
+
+// rewriteTagPair replaces key, in place, with the pairs from the expanded
+// template, which sees the old value as ${value}: rewriting json with
+// yaml:"${value}" migrates a tag, and json with json:"${name|camel}"
+// regenerates it. Other keys keep their order. It returns nil when the
+// field has no such key, and errTagCollision when the template sets another
+// key the field already holds with a different value.
+func rewriteTagPair(pairs []tagPair, key, template string, vars map[string]string) ([]tagPair, error) {
+	i := tagIndex(pairs, key)
+	if i < 0 {
+		return nil, nil
+	}
+	vars["value"] = pairs[i].value
+	replacement, err := expandTagTemplate(template, vars)
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range replacement {
+		if j := tagIndex(pairs, pair.key); j >= 0 && j != i && pairs[j].value != pair.value {
+			return nil, fmt.Errorf("%w: rewriting %s would overwrite %s:%q", errTagCollision, key, pair.key, pairs[j].value)
+		}
+	}
+
+	updated := make([]tagPair, 0, len(pairs)+len(replacement))
+	for j, pair := range pairs {
+		switch {
+		case j == i:
+			updated = append(updated, replacement...)
+		case tagIndex(replacement, pair.key) < 0:
+			updated = append(updated, pair)
+		}
+	}
+	if slices.Equal(updated, pairs) {
+		return nil, nil
+	}
+	return updated, nil
+}
+
+func expandTagTemplate(template string, vars map[string]string) ([]tagPair, error) {
+	expanded, err := core.ExpandTemplate(template, vars)
+	if err != nil {
+		return nil, err
+	}
+	return parseStructTag(strings.Trim(strings.TrimSpace(expanded), "`"))
+}
+
+// tagEdit writes pairs as the field's tag, adding the tag after the type or
+// dropping it along with the space before it when no pairs are left.
+func tagEdit(source string, field, tag *sitter.Node, pairs []tagPair) base.Edit {
+	text := "`" + formatStructTag(pairs) + "`"
+	switch {
+	case tag != nil && len(pairs) == 0:
+		start := tag.StartByte()
+		for start > 0 && (source[start-1] == ' ' || source[start-1] == '\t') {
+			start--
+		}
+		return base.Edit{Start: start, End: tag.EndByte()}
+	case tag != nil:
+		return base.Edit{Start: tag.StartByte(), End: tag.EndByte(), Text: text}
+	}
+	end := field.EndByte()
+	if typeNode := field.ChildByFieldName("type"); typeNode != nil {
+		end = typeNode.EndByte()
+	}
+	return base.Edit{Start: end, End: end, Text: " " + text}
+}
+
+// realignDeclarations applies edits one top-level declaration at a time.
+// A declaration that gofmt would leave unchanged is formatted again after
+// the edit, realigning its field types and tags; others keep their layout.
+func realignDeclarations(source string, edits []base.Edit) (string, error) {
+	root, err := sitter.ParseCtx(context.Background(), []byte(source), golang.GetLanguage())
+	if err != nil {
+		return source, err
+	}
+
+	var declEdits []base.Edit
+	byDecl := make(map[uint32][]base.Edit)
+	var decls []*sitter.Node
+	for _, edit := range edits {
+		decl := topLevelDeclaration(root, edit.Start)
+		if decl == nil {
+			declEdits = append(declEdits, edit)
+			continue
+		}
+		if _, ok := byDecl[decl.StartByte()]; !ok {
+			decls = append(decls, decl)
+		}
+		byDecl[decl.StartByte()] = append(byDecl[decl.StartByte()], base.Edit{
+			Start: edit.Start - decl.StartByte(),
+			End:   edit.End - decl.StartByte(),
+			Text:  edit.Text,
+		})
+	}
+
+	for _, decl := range decls {
+		original := source[decl.StartByte():decl.EndByte()]
+		updated, err := base.ApplyEdits(original, byDecl[decl.StartByte()])
+		if err != nil {
+			return source, err
+		}
+		if formatted, ok := formatDeclaration(original); ok && formatted == original {
+			if realigned, ok := formatDeclaration(updated); ok {
+				updated = realigned
+			}
+		}
+		declEdits = append(declEdits, base.Edit{Start: decl.StartByte(), End: decl.EndByte(), Text: updated})
+	}
+	return base.ApplyEdits(source, declEdits)
+}
+
+func topLevelDeclaration(root *sitter.Node, offset uint32) *sitter.Node {
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		child := root.NamedChild(i)
+		if child.StartByte() <= offset && offset <= child.EndByte() {
+			return child
+		}
+	}
+	return nil
+}
+
+// formatDeclaration runs gofmt over a single top-level declaration.
+func formatDeclaration(decl string) (string, bool) {
+	const header = "package p\n\n"
+	formatted, err := format.Source([]byte(header + decl))
+	if err != nil {
+		return decl, false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(string(formatted), header), "\n"), true
+}
+
+func tagIndex(pairs []tagPair, key string) int {
+	for i, pair := range pairs {
+		if pair.key == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,251 @@
+package golang
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestParseStructTag(t *testing.T) {
+	pairs, err := parseStructTag(`json:"id,omitempty" db:"user_id"`)
+	if err != nil {
+		t.Fatalf("parseStructTag returned error: %v", err)
+	}
+	if len(pairs) != 2 || pairs[0] != (tagPair{"json", "id,omitempty"}) || pairs[1] != (tagPair{"db", "user_id"}) {
+		t.Fatalf("unexpected pairs %+v", pairs)
+	}
+	if got := formatStructTag(pairs); got != `json:"id,omitempty" db:"user_id"` {
+		t.Fatalf("unexpected format %q", got)
+	}
+	for _, bad := range []string{`json`, `json:id`, `:"x"`, `json:"x`} {
+		if _, err := parseStructTag(bad); err == nil {
+			t.Errorf("parseStructTag(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestGoProviderAddAnnotationSetsStructTags(t *testing.T) {
+	provider := New()
+	source := "package main\n\ntype User struct {\n\tUserID int `db:\"uid\" json:\"old\"`\n\tName string\n}\n"
+	target, err := core.ParseDSL("struct:User > field:*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:     "add_annotation",
+		Target:     target,
+		Annotation: `json:"${name|snake}"`,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	want := "\tUserID int `db:\"uid\" json:\"user_id\"`\n\tName string `json:\"name\"`\n"
+	if !strings.Contains(result.Modified, want) {
+		t.Fatalf("unexpected tags:\n%s", result.Modified)
+	}
+
+	// if_absent keeps keys that are already set.
+	kept := provider.Transform(source, core.TransformOp{
+		Method:     "add_annotation",
+		Target:     target,
+		Annotation: `json:"${name|snake}"`,
+		IfAbsent:   true,
+	})
+	if kept.Error != nil {
+		t.Fatalf("Transform failed: %v", kept.Error)
+	}
+	if !strings.Contains(kept.Modified, "`db:\"uid\" json:\"old\"`") || !strings.Contains(kept.Modified, "Name string `json:\"name\"`") {
+		t.Fatalf("expected the existing json key to be kept:\n%s", kept.Modified)
+	}
+}
+
+func TestGoProviderRemoveAnnotationDropsStructTagKeys(t *testing.T) {
+	provider := New()
+	source := "package main\n\ntype User struct {\n\tID   int    `db:\"id\" json:\"id\"`\n\tName string `json:\"name\"`\n}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:     "remove_annotation",
+		Target:     core.AgentQuery{Type: "field", Name: "*"},
+		Annotation: "json",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	// The struct was gofmt-clean, so the tag column is realigned.
+	if !strings.Contains(result.Modified, "\tID   int `db:\"id\"`\n\tName string\n") {
+		t.Fatalf("unexpected tags:\n%s", result.Modified)
+	}
+
+	notField := provider.Transform("package main\n\nfunc Foo() {}\n", core.TransformOp{
+		Method:     "add_annotation",
+		Target:     core.AgentQuery{Type: "function", Name: "Foo"},
+		Annotation: `json:"x"`,
+	})
+	if notField.Error == nil || !strings.Contains(notField.Error.Error(), "struct tags apply to struct fields") {
+		t.Fatalf("expected a struct field error, got %v", notField.Error)
+	}
+}
+
+func TestGoProviderRewriteTagMigratesAndRegenerates(t *testing.T) {
+	provider := New()
+	source := "package main\n\ntype User struct {\n\tID        int    `json:\"id\" db:\"id\"`\n\tFirstName string `json:\"first\"`\n\tEmail     string\n}\n"
+	tagged, err := core.ParseDSL("field:* tag.json=*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	migrated := provider.Transform(source, core.TransformOp{
+		Method:     "rewrite_tag",
+		Target:     tagged,
+		TagKey:     "json",
+		Annotation: `yaml:"${value}"`,
+	})
+	if migrated.Error != nil {
+		t.Fatalf("Transform failed: %v", migrated.Error)
+	}
+	want := "\tID        int    `yaml:\"id\" db:\"id\"`\n\tFirstName string `yaml:\"first\"`\n\tEmail     string\n"
+	if !strings.Contains(migrated.Modified, want) {
+		t.Fatalf("unexpected migration:\n%s", migrated.Modified)
+	}
+	if migrated.Metadata["tags_rewritten"] != 2 {
+		t.Fatalf("expected two rewritten tags, got %v", migrated.Metadata)
+	}
+
+	first, err := core.ParseDSL("field:* tag.json=first")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+	regenerated := provider.Transform(source, core.TransformOp{
+		Method:     "rewrite_tag",
+		Target:     first,
+		TagKey:     "json",
+		Annotation: `json:"${name|snake}"`,
+	})
+	if regenerated.Error != nil {
+		t.Fatalf("Transform failed: %v", regenerated.Error)
+	}
+	if regenerated.MatchCount != 1 || !strings.Contains(regenerated.Modified, "FirstName string `json:\"first_name\"`") {
+		t.Fatalf("unexpected regeneration (%d matches):\n%s", regenerated.MatchCount, regenerated.Modified)
+	}
+}
+
+func TestGoProviderAddTagRealignsOnlyFormattedStructs(t *testing.T) {
+	provider := New()
+	target := core.AgentQuery{Type: "field", Name: "*"}
+
+	formatted := provider.Transform("package main\n\ntype User struct {\n\tID   int\n\tName string `json:\"name\"`\n}\n", core.TransformOp{
+		Method:     "add_tag",
+		Target:     target,
+		Annotation: `db:"${name|snake}"`,
+	})
+	if formatted.Error != nil {
+		t.Fatalf("Transform failed: %v", formatted.Error)
+	}
+	want := "\tID   int    `db:\"id\"`\n\tName string `json:\"name\" db:\"name\"`\n"
+	if !strings.Contains(formatted.Modified, want) {
+		t.Fatalf("expected a realigned tag column:\n%s", formatted.Modified)
+	}
+
+	// A struct gofmt would change keeps its hand layout.
+	unformatted := provider.Transform("package main\n\ntype User struct {\n\tID int\n\tName  string\n}\n", core.TransformOp{
+		Method:     "add_tag",
+		Target:     target,
+		Annotation: `db:"${name|snake}"`,
+	})
+	if unformatted.Error != nil {
+		t.Fatalf("Transform failed: %v", unformatted.Error)
+	}
+	if !strings.Contains(unformatted.Modified, "\tID int `db:\"id\"`\n\tName  string `db:\"name\"`\n") {
+		t.Fatalf("expected the layout to be kept:\n%s", unformatted.Modified)
+	}
+}
+
+func TestGoProviderAddTagSkipsMultiNameFieldsNeedingPerNameTags(t *testing.T) {
+	provider := New()
+	source := "package main\n\ntype Point struct {\n\tX, Y int\n\tZ    int\n}\n"
+	target := core.AgentQuery{Type: "field", Name: "*"}
+
+	// One tag cannot be yaml:"x" for X and yaml:"y" for Y; Z is still tagged.
+	result := provider.Transform(source, core.TransformOp{
+		Method:     "add_tag",
+		Target:     target,
+		Annotation: `yaml:"${name|lower}"`,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "\tX, Y int\n\tZ    int `yaml:\"z\"`\n") {
+		t.Fatalf("expected only Z to be tagged:\n%s", result.Modified)
+	}
+	skipped, _ := result.Metadata["fields_skipped"].([]string)
+	if len(skipped) != 1 || !strings.Contains(skipped[0], "field X, Y at line 4") || !strings.Contains(skipped[0], "declare them separately") {
+		t.Fatalf("expected X, Y to be reported as skipped, got %v", result.Metadata)
+	}
+	if !slices.ContainsFunc(result.Confidence.Factors, func(factor core.ConfidenceFactor) bool { return factor.Name == "fields_skipped" }) {
+		t.Fatalf("expected a fields_skipped factor, got %+v", result.Confidence.Factors)
+	}
+
+	shared := provider.Transform(source, core.TransformOp{
+		Method:     "add_tag",
+		Target:     target,
+		Annotation: `json:"-"`,
+	})
+	if shared.Error != nil {
+		t.Fatalf("Transform failed: %v", shared.Error)
+	}
+	if !strings.Contains(shared.Modified, "\tX, Y int `json:\"-\"`\n") || shared.Metadata["fields_skipped"] != nil {
+		t.Fatalf("expected the shared tag:\n%s", shared.Modified)
+	}
+}
+
+func TestGoProviderRewriteTagReportsKeyCollisions(t *testing.T) {
+	provider := New()
+	source := "package main\n\ntype User struct {\n\tID   int    `json:\"id\" yaml:\"identifier\"`\n\tName string `json:\"name\"`\n}\n"
+	tagged, err := core.ParseDSL("field:* tag.json=*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:     "rewrite_tag",
+		Target:     tagged,
+		TagKey:     "json",
+		Annotation: `yaml:"${value}"`,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	want := "\tID   int    `json:\"id\" yaml:\"identifier\"`\n\tName string `yaml:\"name\"`\n"
+	if !strings.Contains(result.Modified, want) {
+		t.Fatalf("expected ID to keep its yaml value:\n%s", result.Modified)
+	}
+	skipped, _ := result.Metadata["fields_skipped"].([]string)
+	if len(skipped) != 1 || !strings.Contains(skipped[0], `would overwrite yaml:"identifier"`) {
+		t.Fatalf("expected the collision to be reported, got %v", result.Metadata)
+	}
+	if result.Metadata["tags_rewritten"] != 1 {
+		t.Fatalf("expected one rewritten tag, got %v", result.Metadata)
+	}
+}
+
+func TestGoProviderSelectsFieldsByTag(t *testing.T) {
+	provider := New()
+	source := "package main\n\ntype User struct {\n\tID    int    `json:\"id\"`\n\tEmail string `json:\"email,omitempty\" db:\"email\"`\n\tNotes string\n}\n"
+	for dsl, want := range map[string]int{
+		"field:* tag.json=*":                   2,
+		"field:* tag.db=*":                     1,
+		"field:* tag.json=\"email,omitempty\"": 1,
+		"field:* tag.json=nothing":             0,
+	} {
+		query, err := core.ParseDSL(dsl)
+		if err != nil {
+			t.Fatalf("ParseDSL(%q) failed: %v", dsl, err)
+		}
+		if got := provider.Query(source, query); len(got.Matches) != want {
+			t.Errorf("%s matched %d fields, want %d", dsl, len(got.Matches), want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+)
+
+// typeCheckResult holds go/types information for a single Go source file.
+type typeCheckResult struct {
+	fset   *token.FileSet
+	file   *ast.File
+	pkg    *types.Package
+	info   *types.Info
+	errors []types.Error
+}
+
+// typeCheckSource parses and type-checks source as a standalone package.
+// Standard library imports resolve from GOROOT sources, so no network or
+// build cache is needed; other imports are reported as errors and their
+// objects get invalid types.
+func typeCheckSource(source string) (*typeCheckResult, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "source.go", source, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &typeCheckResult{
+		fset: fset,
+		file: file,
+		info: &types.Info{
+			Types: make(map[ast.Expr]types.TypeAndValue),
+			Defs:  make(map[*ast.Ident]types.Object),
+			Uses:  make(map[*ast.Ident]types.Object),
+		},
+	}
+	config := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			if typeErr, ok := err.(types.Error); ok {
+				result.errors = append(result.errors, typeErr)
+			}
+		},
+	}
+	result.pkg, _ = config.Check(file.Name.Name, fset, []*ast.File{file}, result.info)
+	return result, nil
+}
+
+// typeAt returns the type of the identifier starting at the byte offset.
+func (r *typeCheckResult) typeAt(offset int) types.Type {
+	var found types.Type
+	ast.Inspect(r.file, func(node ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		ident, ok := node.(*ast.Ident)
+		if !ok || r.fset.Position(ident.Pos()).Offset != offset {
+			return true
+		}
+		if obj := r.info.ObjectOf(ident); obj != nil {
+			found = obj.Type()
+		}
+		return false
+	})
+	return found
+}
+
+// typeString renders t relative to the checked package.
+func (r *typeCheckResult) typeString(t types.Type) string {
+	return types.TypeString(t, func(pkg *types.Package) string {
+		if pkg == r.pkg {
+			return ""
+		}
+		return pkg.Name()
+	})
+}
+
+// TypeCheck implements base.TypeCheckConfig. It type-checks the package that
+// contains path once with original and once with modified in place of the
+// file, and reports the errors only the modified package has. Sibling files
+// are read from disk; imports resolve offline through a sourceImporter.
+func (c *Config) TypeCheck(path, original, modified string) ([]string, error) {
+	fset := token.NewFileSet()
+	dir := ""
+	if path != "" {
+		dir = filepath.Dir(path)
+	}
+	im := newSourceImporter(fset, dir)
+
+	siblings, pkgPath, err := packageSiblings(im, path)
+	if err != nil {
+		return nil, err
+	}
+	before, err := checkPackage(im, pkgPath, path, original, siblings)
+	if err != nil {
+		return nil, err
+	}
+	after, err := checkPackage(im, pkgPath, path, modified, siblings)
+	if err != nil {
+		return nil, err
+	}
+	return introducedErrors(before, after), nil
+}
+
+// packageError is a type or syntax error keyed by file and message, so the
+// same error before and after an edit compares equal although lines moved.
+type packageError struct {
+	key      string
+	position string
+}
+
+// packageSiblings parses the other files of the package containing path that
+// the default build context selects alongside it.
+func packageSiblings(im *sourceImporter, path string) ([]*ast.File, string, error) {
+	if path == "" {
+		return nil, "", nil
+	}
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	pkgPath := ""
+	if im.module != nil {
+		if rel, err := filepath.Rel(im.module.dir, dir); err == nil && rel != ".." && !filepath.IsAbs(rel) {
+			pkgPath = filepath.ToSlash(filepath.Join(im.module.path, rel))
+		}
+	}
+
+	info, err := im.ctxt.ImportDir(dir, 0)
+	if err != nil {
+		// A directory without other buildable files leaves the file on its own.
+		return nil, pkgPath, nil
+	}
+	var names []string
+	switch {
+	case slices.Contains(info.GoFiles, name), slices.Contains(info.CgoFiles, name):
+		names = slices.Concat(info.GoFiles, info.CgoFiles)
+	case slices.Contains(info.TestGoFiles, name):
+		names = slices.Concat(info.GoFiles, info.CgoFiles, info.TestGoFiles)
+	case slices.Contains(info.XTestGoFiles, name):
+		names = info.XTestGoFiles
+		if pkgPath != "" {
+			pkgPath += "_test"
+		}
+	}
+
+	var files []*ast.File
+	for _, sibling := range names {
+		if sibling == name {
+			continue
+		}
+		file, err := parser.ParseFile(im.fset, filepath.Join(dir, sibling), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, "", fmt.Errorf("parse %s: %w", sibling, err)
+		}
+		files = append(files, file)
+	}
+	return files, pkgPath, nil
+}
+
+// checkPackage type-checks source as the file at path together with its
+// siblings and returns every error found. Syntax errors are returned in place
+// of type errors.
+func checkPackage(im *sourceImporter, pkgPath, path, source string, siblings []*ast.File) ([]packageError, error) {
+	filename := path
+	if filename == "" {
+		filename = "source.go"
+	}
+	file, err := parser.ParseFile(im.fset, filename, source, parser.SkipObjectResolution)
+	if err != nil {
+		var list scanner.ErrorList
+		if !errors.As(err, &list) {
+			return nil, err
+		}
+		found := make([]packageError, 0, len(list))
+		for _, syntaxErr := range list {
+			found = append(found, newPackageError(syntaxErr.Pos, syntaxErr.Msg))
+		}
+		return found, nil
+	}
+	if pkgPath == "" {
+		pkgPath = file.Name.Name
+	}
+
+	var found []packageError
+	config := types.Config{
+		Importer:    im,
+		FakeImportC: true,
+		Error: func(err error) {
+			if typeErr, ok := err.(types.Error); ok {
+				found = append(found, newPackageError(typeErr.Fset.Position(typeErr.Pos), typeErr.Msg))
+			}
+		},
+	}
+	_, _ = config.Check(pkgPath, im.fset, append([]*ast.File{file}, siblings...), nil)
+	return found, nil
+}
+
+func newPackageError(pos token.Position, msg string) packageError {
+	name := filepath.Base(pos.Filename)
+	return packageError{
+		key:      name + ": " + msg,
+		position: fmt.Sprintf("%s:%d:%d: %s", name, pos.Line, pos.Column, msg),
+	}
+}
+
+// introducedErrors returns the errors in after beyond those in before,
+// counting repeats so a second copy of an existing error is still reported.
+func introducedErrors(before, after []packageError) []string {
+	seen := make(map[string]int, len(before))
+	for _, err := range before {
+		seen[err.key]++
+	}
+	var introduced []string
+	for _, err := range after {
+		if seen[err.key] > 0 {
+			seen[err.key]--
+			continue
+		}
+		introduced = append(introduced, err.position)
+	}
+	return introduced
+}
@@ -0,0 +1,141 @@
+package golang
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTypeCheckModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":           "module example.com/calc\n\ngo 1.22\n",
+		"calc/add.go":      "package calc\n\nimport \"strconv\"\n\nfunc Add(a, b int) int { return a + b }\n\nfunc Format(n int) string { return strconv.Itoa(n) }\n",
+		"calc/use.go":      "package calc\n\nfunc Sum() int { return Add(1, 2) }\n",
+		"cmd/main.go":      "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/calc/calc\"\n)\n\nfunc main() { fmt.Println(calc.Add(1, 2)) }\n",
+		"broken/broken.go": "package broken\n\nfunc Broken() int { return missing }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestTypeCheckReportsIntroducedErrors(t *testing.T) {
+	dir := writeTypeCheckModule(t)
+	provider := New()
+	addPath := filepath.Join(dir, "calc", "add.go")
+	original, _ := os.ReadFile(addPath)
+
+	tests := []struct {
+		name     string
+		path     string
+		original string
+		modified string
+		want     []string
+	}{
+		{
+			name:     "undefined identifier",
+			path:     addPath,
+			original: string(original),
+			modified: strings.Replace(string(original), "return a + b", "return a + c", 1),
+			want:     []string{"add.go:5:37: undefined: c"},
+		},
+		{
+			name:     "unused import",
+			path:     addPath,
+			original: string(original),
+			modified: strings.Replace(string(original), "return strconv.Itoa(n)", `return ""`, 1),
+			want:     []string{`add.go:3:8: "strconv" imported and not used`},
+		},
+		{
+			name:     "signature mismatch breaks a sibling caller",
+			path:     addPath,
+			original: string(original),
+			modified: strings.Replace(string(original), "Add(a, b int)", "Add(a, b, c int)", 1),
+			want:     []string{"use.go:3:33: not enough arguments in call to Add\n\thave (number, number)\n\twant (int, int, int)"},
+		},
+		{
+			name:     "module-local imports resolve from source",
+			path:     filepath.Join(dir, "cmd", "main.go"),
+			original: "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/calc/calc\"\n)\n\nfunc main() { fmt.Println(calc.Add(1, 2)) }\n",
+			modified: "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/calc/calc\"\n)\n\nfunc main() { fmt.Println(calc.Add(\"1\", 2)) }\n",
+			want:     []string{`main.go:9:36: cannot use "1" (untyped string constant) as int value in argument to calc.Add`},
+		},
+		{
+			name:     "valid edit",
+			path:     addPath,
+			original: string(original),
+			modified: strings.Replace(string(original), "return a + b", "return b + a", 1),
+		},
+		{
+			name:     "existing errors are not reported",
+			path:     filepath.Join(dir, "broken", "broken.go"),
+			original: "package broken\n\nfunc Broken() int { return missing }\n",
+			modified: "package broken\n\n// Broken is still broken.\nfunc Broken() int { return missing }\n",
+		},
+		{
+			name:     "in-memory source",
+			original: "package main\n\nfunc main() {}\n",
+			modified: "package main\n\nfunc main() { x := 1 }\n",
+			want:     []string{"source.go:3:15: declared and not used: x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.TypeCheck(tt.path, tt.original, tt.modified)
+			if err != nil {
+				t.Fatalf("TypeCheck returned error: %v", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("TypeCheck = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadGoModule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go.mod")
+	content := `module example.com/app // the app
+
+go 1.22
+
+require github.com/Foo/bar v1.2.0
+
+require (
+	example.com/lib v0.3.0
+	example.com/fork v1.0.0 // indirect
+)
+
+replace example.com/lib => ../lib
+
+replace (
+	example.com/fork v1.0.0 => example.com/upstream v1.1.0
+)
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	mod := readGoModule(path)
+	if mod == nil || mod.path != "example.com/app" {
+		t.Fatalf("readGoModule = %+v, want module example.com/app", mod)
+	}
+	if mod.requires["github.com/Foo/bar"] != "v1.2.0" || mod.requires["example.com/fork"] != "v1.0.0" {
+		t.Fatalf("requires = %v", mod.requires)
+	}
+	if mod.replaces["example.com/lib"] != "../lib" || mod.replaces["example.com/fork"] != "example.com/upstream v1.1.0" {
+		t.Fatalf("replaces = %v", mod.replaces)
+	}
+	if escaped, _ := escapeModulePath("github.com/Foo/bar"); escaped != "github.com/!foo/bar" {
+		t.Fatalf("escapeModulePath = %q", escaped)
+	}
+}
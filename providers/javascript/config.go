@@ -262,30 +262,9 @@ func (c *Config) expandVariableDeclarator(node *sitter.Node, source string, quer
 
 func (c *Config) expandImportStatement(node *sitter.Node, source string, query core.AgentQuery) []base.Target {
 	var matches []base.Target
-	// Try to capture each imported binding or namespace
-	for i := 0; i < int(node.ChildCount()); i++ {
-		child := node.Child(i)
-		switch child.Type() {
-		case "import_specifier":
-			// import { a as b } from 'x'
-			if alias := child.ChildByFieldName("alias"); alias != nil {
-				name := source[alias.StartByte():alias.EndByte()]
-				matches = append(matches, base.NewTarget(child, query.Type, name))
-				continue
-			}
-			if nameNode := child.ChildByFieldName("name"); nameNode != nil {
-				name := source[nameNode.StartByte():nameNode.EndByte()]
-				matches = append(matches, base.NewTarget(child, query.Type, name))
-				continue
-			}
-		case "namespace_import":
-			// import * as ns from 'x'
-			if nameNode := child.ChildByFieldName("name"); nameNode != nil {
-				name := source[nameNode.StartByte():nameNode.EndByte()]
-				matches = append(matches, base.NewTarget(child, query.Type, name))
-				continue
-			}
-		}
+	// Capture each default, namespace, or named binding
+	for _, binding := range importBindings(node, source) {
+		matches = append(matches, base.NewTarget(binding.node, query.Type, binding.name))
 	}
 	if len(matches) == 0 {
 		name := c.ExtractNodeName(node, source)
@@ -384,3 +363,14 @@ func (c *Config) nameFieldNode(node *sitter.Node, fields ...string) *sitter.Node
 	}
 	return nil
 }
+
+// FormatCommand returns the default external JavaScript formatter.
+func (c *Config) FormatCommand() []string {
+	return []string{"prettier", "--stdin-filepath", "morfx.js"}
+}
+
+// IndentUnit returns the conventional JavaScript indentation for sources that
+// have no indented lines yet.
+func (c *Config) IndentUnit() string {
+	return "  "
+}
@@ -0,0 +1,53 @@
+package javascript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformEnsureImportInsertsAfterDirectives(t *testing.T) {
+	provider := New()
+	source := `'use strict';
+
+module.exports = () => readFile('a');
+`
+
+	result := provider.Transform(source, core.TransformOp{Method: "ensure_import", Content: "import { readFile } from 'fs'"})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.HasPrefix(result.Modified, "'use strict';\n\nimport { readFile } from 'fs';\n\nmodule.exports") {
+		t.Fatalf("expected import after the directive, got:\n%s", result.Modified)
+	}
+
+	result = provider.Transform(result.Modified, core.TransformOp{Method: "ensure_import", Content: "import fs, { writeFile } from 'fs'"})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "import fs, { readFile, writeFile } from 'fs';\n") {
+		t.Fatalf("expected default and named bindings merged, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformRemoveImportNamespace(t *testing.T) {
+	provider := New()
+	source := `import * as path from 'path';
+import './polyfill.js';
+
+export const cwd = process.cwd();
+`
+
+	query, err := core.ParseDSL("import:path")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{Method: "remove_import", Target: query})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if result.Modified != "import './polyfill.js';\n\nexport const cwd = process.cwd();\n" {
+		t.Fatalf("expected namespace import removed and side-effect import kept, got:\n%s", result.Modified)
+	}
+}
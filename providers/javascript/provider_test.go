@@ -216,6 +216,24 @@ function greet(name) {
 	}
 }
 
+func TestJavaScriptProvider_Transform_ReindentKeepsTemplateLiterals(t *testing.T) {
+	provider := New()
+	source := "class Page {\n  render() {\n    return '';\n  }\n}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "method", Name: "render"},
+		Replacement: "render() {\n    return `<p>\n    ${this.title}\n</p>`;\n}",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	want := "class Page {\n  render() {\n    return `<p>\n    ${this.title}\n</p>`;\n  }\n}\n"
+	if result.Modified != want {
+		t.Fatalf("expected template contents kept, got %q", result.Modified)
+	}
+}
+
 func TestJavaScriptProvider_Validate(t *testing.T) {
 	provider := New()
 
@@ -378,3 +396,30 @@ const MyClass = class TestClass {
 		t.Error("Expected to find at least one variable")
 	}
 }
+
+func TestJavaScriptProvider_InsertMergingIntoNeighbourIsFlagged(t *testing.T) {
+	provider := New()
+	target := core.AgentQuery{Type: "call", Name: "foo"}
+	mismatch := func(result core.TransformResult) bool {
+		return slices.ContainsFunc(result.Confidence.Factors, func(factor core.ConfidenceFactor) bool {
+			return factor.Name == "snippet_kind_mismatch"
+		})
+	}
+
+	// Without a semicolon, "(bar)" continues the call on the line above.
+	merged := provider.Transform("foo(1)\nbaz()\n", core.TransformOp{Method: "insert_after", Target: target, Content: "(bar)"})
+	if merged.Error != nil {
+		t.Fatalf("Transform failed: %v", merged.Error)
+	}
+	if !mismatch(merged) {
+		t.Fatalf("expected a snippet_kind_mismatch factor, got %+v", merged.Confidence.Factors)
+	}
+
+	separate := provider.Transform("foo(1)\nbaz()\n", core.TransformOp{Method: "insert_after", Target: target, Content: "bar();"})
+	if separate.Error != nil {
+		t.Fatalf("Transform failed: %v", separate.Error)
+	}
+	if mismatch(separate) {
+		t.Fatalf("unexpected mismatch for a separate statement: %+v", separate.Confidence.Factors)
+	}
+}
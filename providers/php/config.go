@@ -93,6 +93,8 @@ func (c *Config) aliasMap() map[string][]string {
 		"for":           {"for_statement", "foreach_statement"},
 		"foreach":       {"foreach_statement"},
 		"enum":          {"enum_declaration"},
+		"attribute":     {"attribute"},
+		"decorator":     {"attribute"},
 		"array":         {"array_creation_expression"},
 		"array_element": {"array_element_initializer"},
 		"array_item":    {"array_element_initializer"},
@@ -417,26 +419,8 @@ func (c *Config) expandNamespaceUse(node *sitter.Node, source string, query core
 	var matches []base.Target
 
 	// Handle grouped and multiple clauses: use A\B, C\D as Alias;
-	for i := 0; i < int(node.ChildCount()); i++ {
-		child := node.Child(i)
-		switch child.Type() {
-		case "namespace_use_clause":
-			// Prefer alias, otherwise name
-			if alias := child.ChildByFieldName("alias"); alias != nil {
-				name := source[alias.StartByte():alias.EndByte()]
-				matches = append(matches, base.NewTarget(alias, query.Type, name))
-				continue
-			}
-			if nameNode := child.ChildByFieldName("name"); nameNode != nil {
-				name := source[nameNode.StartByte():nameNode.EndByte()]
-				matches = append(matches, base.NewTarget(nameNode, query.Type, name))
-				continue
-			}
-		case "qualified_name":
-			// Simple single-clause use
-			name := source[child.StartByte():child.EndByte()]
-			matches = append(matches, base.NewTarget(child, query.Type, name))
-		}
+	for _, use := range phpUseClauses(node, source) {
+		matches = append(matches, base.NewTarget(use.clause, query.Type, use.binding()))
 	}
 
 	// Fallback: single match for the whole declaration
@@ -447,3 +431,19 @@ func (c *Config) expandNamespaceUse(node *sitter.Node, source string, query core
 
 	return matches
 }
+
+// FormatCommand returns the default external PHP formatter.
+func (c *Config) FormatCommand() []string {
+	return []string{"php-cs-fixer", "fix", "--quiet", "{file}"}
+}
+
+// IndentUnit returns the conventional PHP indentation for sources that
+// have no indented lines yet.
+func (c *Config) IndentUnit() string {
+	return "    "
+}
+
+// SnippetPrefix opens PHP mode so bare snippets parse as code.
+func (c *Config) SnippetPrefix() string {
+	return "<?php\n"
+}
@@ -0,0 +1,304 @@
+package php
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	base "github.com/oxhq/morfx/providers/base"
+)
+
+// phpUse is one clause of a use declaration.
+type phpUse struct {
+	clause *sitter.Node
+	kind   string // empty, function, or const
+	path   string
+	alias  string
+}
+
+// binding returns the alias, or the last segment of the imported name.
+func (u phpUse) binding() string {
+	if u.alias != "" {
+		return u.alias
+	}
+	return u.path[strings.LastIndex(u.path, `\`)+1:]
+}
+
+// useKind returns the function or const keyword of a use declaration.
+func useKind(declaration *sitter.Node) string {
+	for i := 0; i < int(declaration.ChildCount()); i++ {
+		switch child := declaration.Child(i); child.Type() {
+		case "function", "const":
+			return child.Type()
+		}
+	}
+	return ""
+}
+
+func phpUseClauses(declaration *sitter.Node, source string) []phpUse {
+	kind := useKind(declaration)
+	var uses []phpUse
+	prefix := ""
+	for i := 0; i < int(declaration.NamedChildCount()); i++ {
+		child := declaration.NamedChild(i)
+		switch child.Type() {
+		case "namespace_name":
+			prefix = nodeText(child, source) + `\`
+		case "namespace_use_clause":
+			uses = append(uses, newPHPUse(child, kind, "", source))
+		case "namespace_use_group":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if clause := child.NamedChild(j); clause.Type() == "namespace_use_group_clause" {
+					uses = append(uses, newPHPUse(clause, kind, prefix, source))
+				}
+			}
+		}
+	}
+	return uses
+}
+
+func newPHPUse(clause *sitter.Node, kind, prefix, source string) phpUse {
+	use := phpUse{clause: clause, kind: kind}
+	for i := 0; i < int(clause.NamedChildCount()); i++ {
+		child := clause.NamedChild(i)
+		switch child.Type() {
+		case "qualified_name", "namespace_name", "name":
+			use.path = prefix + strings.TrimPrefix(nodeText(child, source), `\`)
+		case "namespace_aliasing_clause":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if alias := child.NamedChild(j); alias.Type() == "name" {
+					use.alias = nodeText(alias, source)
+				}
+			}
+		}
+	}
+	return use
+}
+
+func nodeText(node *sitter.Node, source string) string {
+	return source[node.StartByte():node.EndByte()]
+}
+
+// useDeclarations returns use declarations at file level and inside
+// namespace blocks, in source order.
+func useDeclarations(root *sitter.Node) []*sitter.Node {
+	var declarations []*sitter.Node
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			child := n.NamedChild(i)
+			switch child.Type() {
+			case "namespace_use_declaration":
+				declarations = append(declarations, child)
+			case "namespace_definition", "compound_statement":
+				walk(child)
+			}
+		}
+	}
+	walk(root)
+	return declarations
+}
+
+// UnusedImports returns use clauses whose binding is never referenced in
+// code or doc comments. PHP names are matched case-insensitively.
+func (c *Config) UnusedImports(source string, root *sitter.Node) []base.Target {
+	used := make(map[string]struct{})
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "namespace_use_declaration":
+			return
+		case "namespace_definition":
+			if body := n.ChildByFieldName("body"); body != nil {
+				walk(body)
+			}
+			return
+		case "name":
+			used[strings.ToLower(nodeText(n, source))] = struct{}{}
+		case "comment":
+			for _, word := range strings.FieldsFunc(nodeText(n, source), func(r rune) bool {
+				return r != '_' && (r < '0' || r > '9') && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z')
+			}) {
+				used[strings.ToLower(word)] = struct{}{}
+			}
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(root)
+
+	var unused []base.Target
+	for _, declaration := range useDeclarations(root) {
+		for _, use := range phpUseClauses(declaration, source) {
+			if _, ok := used[strings.ToLower(use.binding())]; !ok {
+				unused = append(unused, base.NewTarget(use.clause, "import", use.binding()))
+			}
+		}
+	}
+	return unused
+}
+
+// MissingImports returns nil: PHP resolves unimported names against the
+// current namespace, so a missing use cannot be told apart from a local class.
+func (c *Config) MissingImports(source string, root *sitter.Node) []string {
+	return nil
+}
+
+// EnsureImport adds a use declaration. spec may be written as a full
+// declaration ("use function Foo\bar;") or as a bare name with optional alias.
+func (c *Config) EnsureImport(source string, root *sitter.Node, spec string) (string, error) {
+	clause := strings.TrimSpace(spec)
+	clause = strings.TrimSpace(strings.TrimSuffix(clause, ";"))
+	clause = strings.TrimSpace(strings.TrimPrefix(clause, "use "))
+	kind := ""
+	for _, keyword := range []string{"function", "const"} {
+		if strings.HasPrefix(clause, keyword+" ") {
+			kind = keyword
+			clause = strings.TrimSpace(strings.TrimPrefix(clause, keyword))
+		}
+	}
+	clause = strings.TrimPrefix(clause, `\`)
+	path, alias := clause, ""
+	if before, after, found := strings.Cut(clause, " as "); found {
+		path, alias = strings.TrimSpace(before), strings.TrimSpace(after)
+	}
+	if path == "" || strings.ContainsAny(path, " {},;") || strings.ContainsAny(alias, ` \{},;`) {
+		return source, fmt.Errorf("invalid PHP use %q", spec)
+	}
+
+	declarations := useDeclarations(root)
+	for _, declaration := range declarations {
+		for _, use := range phpUseClauses(declaration, source) {
+			if use.kind == kind && strings.EqualFold(use.path, path) && use.alias == alias {
+				return source, nil
+			}
+		}
+	}
+
+	line := "use "
+	if kind != "" {
+		line += kind + " "
+	}
+	line += path
+	if alias != "" {
+		line += " as " + alias
+	}
+	line += ";"
+
+	if len(declarations) > 0 {
+		last := declarations[len(declarations)-1]
+		lineStart := strings.LastIndex(source[:last.StartByte()], "\n") + 1
+		indent := source[lineStart:last.StartByte()]
+		end := int(last.EndByte())
+		return source[:end] + "\n" + indent + line + source[end:], nil
+	}
+
+	anchor := phpUseAnchor(root)
+	if anchor == nil {
+		return source, fmt.Errorf("cannot place use declaration: source has no <?php tag")
+	}
+	end := int(anchor.EndByte())
+	rest := strings.TrimLeft(source[end:], "\n")
+	separator := "\n"
+	if strings.TrimSpace(rest) != "" {
+		separator = "\n\n"
+	}
+	return source[:end] + "\n\n" + line + separator + rest, nil
+}
+
+// phpUseAnchor returns the node use declarations follow when a file has
+// none yet: the first statement-form namespace declaration or the php tag.
+func phpUseAnchor(root *sitter.Node) *sitter.Node {
+	var tag *sitter.Node
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		switch child := root.NamedChild(i); child.Type() {
+		case "php_tag":
+			if tag == nil {
+				tag = child
+			}
+		case "namespace_definition":
+			if child.ChildByFieldName("body") == nil {
+				return child
+			}
+		}
+	}
+	return tag
+}
+
+// RemoveImports removes use clauses, rewriting declarations that keep other
+// clauses and deleting declarations left empty.
+func (c *Config) RemoveImports(source string, root *sitter.Node, targets []base.Target) (string, error) {
+	removed := make(map[*sitter.Node]map[*sitter.Node]bool)
+	var declarations []*sitter.Node
+	for _, target := range targets {
+		if target.Node == nil {
+			continue
+		}
+		node := target.Node
+		for node != nil && node.Type() != "namespace_use_clause" && node.Type() != "namespace_use_group_clause" &&
+			node.Type() != "namespace_use_declaration" {
+			node = node.Parent()
+		}
+		declaration := node
+		for declaration != nil && declaration.Type() != "namespace_use_declaration" {
+			declaration = declaration.Parent()
+		}
+		if declaration == nil {
+			return source, fmt.Errorf("target %q is not a use declaration", target.Name)
+		}
+		if removed[declaration] == nil {
+			removed[declaration] = make(map[*sitter.Node]bool)
+			declarations = append(declarations, declaration)
+		}
+		removed[declaration][node] = true
+	}
+
+	sort.Slice(declarations, func(i, j int) bool { return declarations[i].StartByte() > declarations[j].StartByte() })
+	result := source
+	for _, declaration := range declarations {
+		var kept []string
+		if !removed[declaration][declaration] {
+			for _, use := range phpUseClauses(declaration, source) {
+				if !removed[declaration][use.clause] {
+					kept = append(kept, nodeText(use.clause, source))
+				}
+			}
+		}
+		start, end := int(declaration.StartByte()), int(declaration.EndByte())
+		if len(kept) > 0 {
+			result = result[:start] + renderUse(declaration, source, kept) + result[end:]
+			continue
+		}
+		lineStart := strings.LastIndex(result[:start], "\n") + 1
+		if strings.TrimSpace(result[lineStart:start]) == "" {
+			if newline := strings.IndexByte(result[end:], '\n'); newline >= 0 && strings.TrimSpace(result[end:end+newline]) == "" {
+				start = lineStart
+				end += newline + 1
+			}
+		}
+		before, after := result[:start], result[end:]
+		if strings.HasSuffix(before, "\n\n") && strings.HasPrefix(after, "\n") {
+			after = after[1:]
+		}
+		result = before + after
+	}
+	return result, nil
+}
+
+// renderUse rebuilds declaration with the given clause texts, keeping a
+// group prefix and the function or const keyword.
+func renderUse(declaration *sitter.Node, source string, clauses []string) string {
+	rendered := "use "
+	if kind := useKind(declaration); kind != "" {
+		rendered += kind + " "
+	}
+	for i := 0; i < int(declaration.NamedChildCount()); i++ {
+		if prefix := declaration.NamedChild(i); prefix.Type() == "namespace_name" {
+			return rendered + nodeText(prefix, source) + `\{` + strings.Join(clauses, ", ") + "};"
+		}
+	}
+	return rendered + strings.Join(clauses, ", ") + ";"
+}
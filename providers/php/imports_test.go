@@ -0,0 +1,110 @@
+package php
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformEnsureImportAddsUse(t *testing.T) {
+	provider := New()
+	source := `<?php
+
+namespace App\Http;
+
+use App\Models\User;
+
+class Controller {}
+`
+
+	result := provider.Transform(source, core.TransformOp{Method: "ensure_import", Content: `use Illuminate\Support\Str;`})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "use App\\Models\\User;\nuse Illuminate\\Support\\Str;\n") {
+		t.Fatalf("expected use after the last use, got:\n%s", result.Modified)
+	}
+
+	again := provider.Transform(result.Modified, core.TransformOp{Method: "ensure_import", Content: `\App\Models\User`})
+	if again.Error != nil {
+		t.Fatalf("Transform returned error: %v", again.Error)
+	}
+	if again.Modified != result.Modified || again.MatchCount != 0 {
+		t.Fatalf("expected existing use to be left alone, got:\n%s", again.Modified)
+	}
+}
+
+func TestTransformEnsureImportAfterNamespace(t *testing.T) {
+	provider := New()
+	source := "<?php\n\nnamespace App;\n\nfunction run() {}\n"
+
+	result := provider.Transform(source, core.TransformOp{Method: "ensure_import", Content: `function Str\slug`})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if result.Modified != "<?php\n\nnamespace App;\n\nuse function Str\\slug;\n\nfunction run() {}\n" {
+		t.Fatalf("expected use after the namespace, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformRemoveImportFromGroup(t *testing.T) {
+	provider := New()
+	source := `<?php
+
+use App\Models\{Post, User as Member};
+use App\Support\Str;
+
+class Page {}
+`
+
+	query, err := core.ParseDSL("import:Member")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{Method: "remove_import", Target: query})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "use App\\Models\\{Post};\n") {
+		t.Fatalf("expected aliased clause removed from the group, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformOrganizeImportsKeepsDocblockUses(t *testing.T) {
+	provider := New()
+	source := `<?php
+
+use App\Models\User;
+use App\Support\Collection;
+use App\Support\Logger;
+
+class Repo {
+    /** @return Collection */
+    public function all() {
+        Logger::info('all');
+        return User::all();
+    }
+}
+`
+
+	query, err := core.ParseDSL("method:all")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{
+		Method:          "replace",
+		Target:          query,
+		Replacement:     "public function all() {\n        return User::all();\n    }",
+		OrganizeImports: true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if strings.Contains(result.Modified, "Logger") {
+		t.Fatalf("expected unused Logger use removed, got:\n%s", result.Modified)
+	}
+	if !strings.Contains(result.Modified, "use App\\Support\\Collection;") {
+		t.Fatalf("expected Collection kept for its docblock reference, got:\n%s", result.Modified)
+	}
+}
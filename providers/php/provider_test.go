@@ -1044,3 +1044,105 @@ class PublicAPI {
 		t.Errorf("Expected reduced confidence for wildcard pattern, got %f", result4.Confidence.Score)
 	}
 }
+
+func TestPHPProvider_Transform_IfAbsent(t *testing.T) {
+	provider := New()
+	source := "<?php\nclass Handler {\n    public function run() {}\n}\n"
+
+	cases := []core.TransformOp{
+		{Method: "insert_before", Target: core.AgentQuery{Type: "class", Name: "Handler"}, Content: "#[Route('/run')]"},
+		{Method: "append", Target: core.AgentQuery{Type: "class", Name: "Handler"}, Content: "public function stop() {}"},
+	}
+	for _, op := range cases {
+		op.IfAbsent = true
+		first := provider.Transform(source, op)
+		if first.Error != nil || first.Modified == source {
+			t.Fatalf("%s: expected first run to insert, got %v", op.Method, first.Error)
+		}
+		second := provider.Transform(first.Modified, op)
+		if second.Error != nil || second.Modified != first.Modified {
+			t.Fatalf("%s: expected re-run to be a no-op, got:\n%s", op.Method, second.Modified)
+		}
+	}
+}
+
+func TestPHPProvider_Transform_DeleteTakesAttributes(t *testing.T) {
+	provider := New()
+	source := "<?php\nclass Routes {\n    /** Lists users. */\n    #[Route('/users')]\n    public function index() {} // legacy\n\n    public function show() {}\n}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method: "delete",
+		Target: core.AgentQuery{Type: "method", Name: "index"},
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	for _, gone := range []string{"Lists users", "#[Route", "index", "legacy"} {
+		if strings.Contains(result.Modified, gone) {
+			t.Errorf("expected %q to be deleted:\n%s", gone, result.Modified)
+		}
+	}
+	if !strings.Contains(result.Modified, "public function show() {}") {
+		t.Fatalf("expected show to survive:\n%s", result.Modified)
+	}
+}
+
+func TestPHPProvider_Transform_SortMembersByVisibility(t *testing.T) {
+	provider := New()
+	source := "<?php\nclass A {\n    private $z;\n    /** doc */\n    public function b() {}\n    protected function c() {}\n    public $a;\n}\n"
+	target, err := core.ParseDSL("class:A > method:* | class:A > property:*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{Method: "sort", Target: target, SortBy: core.SortByVisibility})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	// Members of equal visibility keep their relative order.
+	want := "<?php\nclass A {\n    /** doc */\n    public function b() {}\n    public $a;\n    protected function c() {}\n    private $z;\n}\n"
+	if result.Modified != want {
+		t.Fatalf("unexpected order:\n%s", result.Modified)
+	}
+}
+
+func TestPHPProvider_Transform_AddAndRemoveAttributes(t *testing.T) {
+	provider := New()
+	source := "<?php\nclass A {\n    #[Route('/x'), Deprecated]\n    #[Other]\n    public function b() {}\n    public function c() {}\n}\n"
+
+	added := provider.Transform(source, core.TransformOp{
+		Method:     "add_annotation",
+		Target:     core.AgentQuery{Type: "method", Name: "*"},
+		Annotation: "#[Route('/${name}')]",
+		IfAbsent:   true,
+	})
+	if added.Error != nil {
+		t.Fatalf("Transform failed: %v", added.Error)
+	}
+	if !strings.Contains(added.Modified, "    #[Route('/c')]\n    public function c() {}") || strings.Count(added.Modified, "Route") != 2 {
+		t.Fatalf("expected only c to gain a route:\n%s", added.Modified)
+	}
+
+	removed := provider.Transform(source, core.TransformOp{
+		Method:     "remove_annotation",
+		Target:     core.AgentQuery{Type: "method", Name: "b"},
+		Annotation: "Route",
+	})
+	if removed.Error != nil {
+		t.Fatalf("Transform failed: %v", removed.Error)
+	}
+	if !strings.Contains(removed.Modified, "    #[Deprecated]\n    #[Other]\n    public function b()") {
+		t.Fatalf("expected Route to leave its group:\n%s", removed.Modified)
+	}
+
+	selected := provider.Transform(source, core.TransformOp{
+		Method: "remove_annotation",
+		Target: core.AgentQuery{Type: "attribute", Name: "Other"},
+	})
+	if selected.Error != nil {
+		t.Fatalf("Transform failed: %v", selected.Error)
+	}
+	if strings.Contains(selected.Modified, "Other") || !strings.Contains(selected.Modified, "    #[Route('/x'), Deprecated]\n    public function b()") {
+		t.Fatalf("expected the #[Other] line to be removed:\n%s", selected.Modified)
+	}
+}
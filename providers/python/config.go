@@ -73,6 +73,7 @@ func (c *Config) aliasMap() map[string][]string {
 		"loop":       {"for_statement", "while_statement"},
 		"for":        {"for_statement"},
 		"decorator":  {"decorator"},
+		"docstring":  {"expression_statement"},
 		"lambda":     {"lambda"},
 		"comment":    {"comment"},
 		"comments":   {"comment"},
@@ -136,6 +137,11 @@ func (c *Config) ExtractNodeName(node *sitter.Node, source string) string {
 		}
 	case "return_statement":
 		return "return"
+	case "expression_statement":
+		// A docstring is named after the function or class it documents.
+		if owner := docstringOwner(node); owner != nil {
+			return c.ExtractNodeName(owner, source)
+		}
 	case "comment":
 		return c.commentSummary(source[node.StartByte():node.EndByte()])
 	}
@@ -169,6 +175,15 @@ func (c *Config) commentSummary(raw string) string {
 	return strings.TrimSpace(strings.TrimPrefix(trimmed, "*"))
 }
 
+// ValidateQueryNode keeps docstring queries to the string statements that
+// open a function or class body.
+func (c *Config) ValidateQueryNode(node *sitter.Node, source, queryType string) bool {
+	if queryType == "docstring" {
+		return isDocstring(node)
+	}
+	return true
+}
+
 // ValidateAssignment ensures assignments are actual variable definitions, not attribute assignments
 func (c *Config) ValidateAssignment(node *sitter.Node, source, queryType string) bool {
 	if (node.Type() != "assignment" && node.Type() != "augmented_assignment") || c.canonicalAssignmentQuery(queryType) != "variable" {
@@ -326,7 +341,7 @@ func (c *Config) expandImportFrom(node *sitter.Node, source string, query core.A
 				continue
 			}
 		}
-		if child.Type() == "identifier" {
+		if child.Type() == "identifier" || (child.Type() == "dotted_name" && node.FieldNameForChild(i) == "name") {
 			name := source[child.StartByte():child.EndByte()]
 			matches = append(matches, base.NewTarget(child, query.Type, name))
 		}
@@ -337,3 +352,30 @@ func (c *Config) expandImportFrom(node *sitter.Node, source string, query core.A
 	}
 	return matches
 }
+
+// FormatCommand returns the default external Python formatter.
+func (c *Config) FormatCommand() []string {
+	return []string{"black", "--quiet", "-"}
+}
+
+// IndentUnit returns the conventional Python indentation for sources that
+// have no indented lines yet.
+func (c *Config) IndentUnit() string {
+	return "    "
+}
+
+// MemberVisibility follows the underscore conventions: dunder methods are
+// public, a double underscore prefix is private, and a single one protected.
+func (c *Config) MemberVisibility(node *sitter.Node, source string) string {
+	name := c.ExtractNodeName(node, source)
+	switch {
+	case strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__"):
+		return "public"
+	case strings.HasPrefix(name, "__"):
+		return "private"
+	case strings.HasPrefix(name, "_"):
+		return "protected"
+	default:
+		return "public"
+	}
+}
@@ -0,0 +1,231 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+	base "github.com/oxhq/morfx/providers/base"
+)
+
+// isDocstring reports whether node is the string statement that opens the
+// body of a function or class.
+func isDocstring(node *sitter.Node) bool {
+	return docstringOwner(node) != nil
+}
+
+// docstringOwner returns the function or class whose docstring node is, or
+// nil when node is not a docstring.
+func docstringOwner(node *sitter.Node) *sitter.Node {
+	if node == nil || node.Type() != "expression_statement" || node.NamedChildCount() != 1 {
+		return nil
+	}
+	if kind := node.NamedChild(0).Type(); kind != "string" && kind != "concatenated_string" {
+		return nil
+	}
+	body := node.Parent()
+	if body == nil || body.Type() != "block" || firstStatement(body) != node {
+		return nil
+	}
+	owner := body.Parent()
+	if owner == nil || !isDocumentable(owner) {
+		return nil
+	}
+	return owner
+}
+
+func isDocumentable(node *sitter.Node) bool {
+	switch node.Type() {
+	case "function_definition", "async_function_definition", "class_definition":
+		return true
+	default:
+		return false
+	}
+}
+
+// firstStatement returns the first named child of block that is not a
+// comment.
+func firstStatement(block *sitter.Node) *sitter.Node {
+	for i := 0; i < int(block.NamedChildCount()); i++ {
+		if child := block.NamedChild(i); child.Type() != "comment" {
+			return child
+		}
+	}
+	return nil
+}
+
+// definitionNode resolves a target to the function or class it names: a
+// docstring names its owner and a decorated definition the definition.
+func definitionNode(node *sitter.Node) *sitter.Node {
+	if node == nil {
+		return nil
+	}
+	if owner := docstringOwner(node); owner != nil {
+		return owner
+	}
+	if node.Type() == "decorated_definition" {
+		node = node.ChildByFieldName("definition")
+	}
+	if node != nil && isDocumentable(node) {
+		return node
+	}
+	return nil
+}
+
+// SetDocstring writes op.Content as the docstring of each function or class
+// target, quoted and indented to match its body. Existing docstrings are
+// replaced in place and keep single quotes if they used them; targets
+// without one get it as their first statement.
+func (c *Config) SetDocstring(source string, targets []base.Target, op core.TransformOp) (string, map[string]any, error) {
+	var edits []base.Edit
+	replaced, added, skipped := 0, 0, 0
+	seen := make(map[uint32]bool)
+	for _, target := range targets {
+		owner := definitionNode(target.Node)
+		if owner == nil {
+			return source, nil, fmt.Errorf("docstrings apply to functions and classes, not %s", target.Type)
+		}
+		if seen[owner.StartByte()] {
+			continue
+		}
+		seen[owner.StartByte()] = true
+		body := owner.ChildByFieldName("body")
+		if body == nil {
+			continue
+		}
+		indent := c.bodyIndent(source, owner, body)
+
+		if existing := firstStatement(body); existing != nil && isDocstring(existing) {
+			literal := existing.NamedChild(0)
+			old := source[literal.StartByte():literal.EndByte()]
+			text := quoteDocstring(op.Content, indent, docstringDelimiter(old))
+			if op.IfAbsent || text == old {
+				skipped++
+				continue
+			}
+			edits = append(edits, base.Edit{Start: literal.StartByte(), End: literal.EndByte(), Text: text})
+			replaced++
+			continue
+		}
+
+		text := quoteDocstring(op.Content, indent, `"""`)
+		if colon := body.PrevSibling(); colon != nil && sameLine(source, colon.EndByte(), body.StartByte()) {
+			// def f(): pass becomes a block so the docstring can lead it.
+			edits = append(edits, base.Edit{Start: colon.EndByte(), End: body.StartByte(), Text: "\n" + indent + text + "\n" + indent})
+		} else {
+			edits = append(edits, base.Edit{Start: body.StartByte(), End: body.StartByte(), Text: text + "\n" + indent})
+		}
+		added++
+	}
+
+	metadata := map[string]any{"docstrings_replaced": replaced, "docstrings_added": added, "docstrings_skipped": skipped}
+	modified, err := base.ApplyEdits(source, edits)
+	if err != nil {
+		return source, nil, err
+	}
+	return modified, metadata, nil
+}
+
+// bodyIndent is the indentation of body's statements, or one unit deeper
+// than owner when the body shares the definition's line.
+func (c *Config) bodyIndent(source string, owner, body *sitter.Node) string {
+	if prefix := lineBefore(source, body.StartByte()); strings.TrimSpace(prefix) == "" {
+		return prefix
+	}
+	unit := c.IndentUnit()
+	if strings.Contains(source, "\n\t") {
+		unit = "\t"
+	}
+	prefix := lineBefore(source, owner.StartByte())
+	return prefix[:len(prefix)-len(strings.TrimLeft(prefix, " \t"))] + unit
+}
+
+func lineBefore(source string, offset uint32) string {
+	start := strings.LastIndex(source[:offset], "\n") + 1
+	return source[start:offset]
+}
+
+func sameLine(source string, from, to uint32) bool {
+	return !strings.Contains(source[from:to], "\n")
+}
+
+// docstringDelimiter returns the quotes an existing docstring uses, so a
+// replacement keeps single-quoted docstrings single-quoted.
+func docstringDelimiter(literal string) string {
+	if strings.HasPrefix(strings.TrimLeft(literal, "rRuU"), "'''") {
+		return "'''"
+	}
+	return `"""`
+}
+
+// quoteDocstring renders text as a docstring literal for a body at indent.
+// Text that is already quoted is unwrapped first. A single line stays on one
+// line; longer text keeps its summary on the opening line, indents the rest,
+// and closes on a line of its own, as PEP 257 lays out. Backslashes make a
+// raw string unless the text ends in one, and quotes that would end the
+// literal early are escaped.
+func quoteDocstring(text, indent, delimiter string) string {
+	lines := docstringLines(text)
+
+	body := strings.Join(lines, "\n")
+	other := map[string]string{`"""`: "'''", "'''": `"""`}[delimiter]
+	if strings.Contains(body, delimiter) && !strings.Contains(body, other) {
+		delimiter = other
+	}
+	quote := delimiter[:1]
+	// A raw string cannot end in a backslash, which would escape the
+	// closing quotes
+	escape := strings.Contains(body, delimiter) || strings.HasSuffix(body, quote) || strings.HasSuffix(body, `\`)
+	prefix := ""
+	switch {
+	case escape:
+		for i, line := range lines {
+			line = strings.ReplaceAll(line, `\`, `\\`)
+			lines[i] = strings.ReplaceAll(line, delimiter, `\`+delimiter)
+		}
+		if last := lines[len(lines)-1]; strings.HasSuffix(last, quote) {
+			lines[len(lines)-1] = last[:len(last)-1] + `\` + quote
+		}
+	case strings.Contains(body, `\`):
+		prefix = "r"
+	}
+
+	if len(lines) == 1 {
+		return prefix + delimiter + lines[0] + delimiter
+	}
+	var out strings.Builder
+	out.WriteString(prefix + delimiter + lines[0])
+	for _, line := range lines[1:] {
+		out.WriteString("\n")
+		if line != "" {
+			out.WriteString(indent + line)
+		}
+	}
+	out.WriteString("\n" + indent + delimiter)
+	return out.String()
+}
+
+// docstringLines splits docstring text into lines with the first line
+// trimmed and the rest dedented, unwrapping quotes the text already has.
+func docstringLines(text string) []string {
+	text = strings.TrimSpace(text)
+	for _, delimiter := range []string{`"""`, "'''"} {
+		inner := strings.TrimLeft(text, "rRuU")
+		if len(inner) >= 6 && strings.HasPrefix(inner, delimiter) && strings.HasSuffix(inner, delimiter) {
+			text = strings.TrimSpace(inner[3 : len(inner)-3])
+			break
+		}
+	}
+	lines := strings.Split(text, "\n")
+	lines[0] = strings.TrimSpace(lines[0])
+	if len(lines) > 1 {
+		rest := strings.Split(strings.TrimRight(base.Dedent(strings.Join(lines[1:], "\n")), " \t\n"), "\n")
+		for i, line := range rest {
+			rest[i] = strings.TrimRight(line, " \t")
+		}
+		lines = append(lines[:1], rest...)
+	}
+	return lines
+}
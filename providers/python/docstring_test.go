@@ -0,0 +1,171 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+const docstringSource = `class Repo:
+    '''Stores users.'''
+
+    def find(self, user_id):
+        """Find a user."""
+        return None
+
+    def save(self, user): pass
+
+def helper(x):
+    return x
+`
+
+func TestDocstringSelector(t *testing.T) {
+	provider := New()
+	for dsl, want := range map[string][]string{
+		"docstring:*":    {"Repo", "find"},
+		"docstring:find": {"find"},
+		"docstring:save": nil,
+	} {
+		query, err := core.ParseDSL(dsl)
+		if err != nil {
+			t.Fatalf("ParseDSL(%q) failed: %v", dsl, err)
+		}
+		result := provider.Query(docstringSource, query)
+		var names []string
+		for _, match := range result.Matches {
+			names = append(names, match.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("%s matched %v, want %v", dsl, names, want)
+		}
+	}
+
+	// A string statement later in the body is not a docstring.
+	result := provider.Query("def f():\n    x = 1\n    \"\"\"Not a docstring.\"\"\"\n", core.AgentQuery{Type: "docstring", Name: "*"})
+	if len(result.Matches) != 0 {
+		t.Fatalf("expected no docstring matches, got %+v", result.Matches)
+	}
+}
+
+func TestDeleteDocstringRemovesWholeLine(t *testing.T) {
+	provider := New()
+	tests := []struct {
+		name, source, target, want string
+	}{
+		{
+			name:   "method",
+			source: docstringSource,
+			target: "find",
+			want:   "class Repo:\n    '''Stores users.'''\n\n    def find(self, user_id):\n        return None\n\n    def save(self, user): pass\n\ndef helper(x):\n    return x\n",
+		},
+		{
+			name:   "class",
+			source: docstringSource,
+			target: "Repo",
+			want:   "class Repo:\n    def find(self, user_id):\n        \"\"\"Find a user.\"\"\"\n        return None\n\n    def save(self, user): pass\n\ndef helper(x):\n    return x\n",
+		},
+		{
+			name:   "function",
+			source: "def f():\n    \"\"\"Doc.\"\"\"\n\n    return 1\n",
+			target: "f",
+			want:   "def f():\n    return 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := provider.Transform(tt.source, core.TransformOp{
+				Method: "delete",
+				Target: core.AgentQuery{Type: "docstring", Name: tt.target},
+			})
+			if result.Error != nil {
+				t.Fatalf("Transform failed: %v", result.Error)
+			}
+			if result.Modified != tt.want {
+				t.Fatalf("unexpected output:\n%q", result.Modified)
+			}
+		})
+	}
+}
+
+func TestSetDocstringReplacesAndInserts(t *testing.T) {
+	provider := New()
+	query, err := core.ParseDSL("class:Repo > def:*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform(docstringSource, core.TransformOp{
+		Method:  "set_docstring",
+		Target:  query,
+		Content: "Repository method.\n\n    Details line.\n",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	for _, want := range []string{
+		"    def find(self, user_id):\n        \"\"\"Repository method.\n\n        Details line.\n        \"\"\"\n        return None\n",
+		"    def save(self, user):\n        \"\"\"Repository method.\n\n        Details line.\n        \"\"\"\n        pass\n",
+		"    '''Stores users.'''\n",
+	} {
+		if !strings.Contains(result.Modified, want) {
+			t.Fatalf("missing %q in:\n%s", want, result.Modified)
+		}
+	}
+	if result.Metadata["docstrings_replaced"] != 1 || result.Metadata["docstrings_added"] != 1 {
+		t.Fatalf("unexpected metadata %v", result.Metadata)
+	}
+	if validation := provider.Validate(result.Modified); !validation.Valid {
+		t.Fatalf("result does not parse: %v\n%s", validation.Errors, result.Modified)
+	}
+
+	kept := provider.Transform(docstringSource, core.TransformOp{
+		Method:   "set_docstring",
+		Target:   core.AgentQuery{Type: "function", Name: "*"},
+		Content:  "Generated.",
+		IfAbsent: true,
+	})
+	if kept.Error != nil {
+		t.Fatalf("Transform failed: %v", kept.Error)
+	}
+	if !strings.Contains(kept.Modified, `"""Find a user."""`) || !strings.Contains(kept.Modified, "def helper(x):\n    \"\"\"Generated.\"\"\"\n    return x") {
+		t.Fatalf("expected only missing docstrings to be added:\n%s", kept.Modified)
+	}
+}
+
+func TestSetDocstringOnDocstringTargetKeepsQuotes(t *testing.T) {
+	provider := New()
+	result := provider.Transform(docstringSource, core.TransformOp{
+		Method:  "set_docstring",
+		Target:  core.AgentQuery{Type: "docstring", Name: "Repo"},
+		Content: "Stores and loads users.",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "class Repo:\n    '''Stores and loads users.'''\n") {
+		t.Fatalf("unexpected docstring:\n%s", result.Modified)
+	}
+}
+
+func TestQuoteDocstring(t *testing.T) {
+	cases := []struct {
+		text, delimiter, want string
+	}{
+		{"Summary.", `"""`, `"""Summary."""`},
+		{`"""Already quoted."""`, `"""`, `"""Already quoted."""`},
+		{"Summary.\n\n  Indented body.\n", `"""`, "\"\"\"Summary.\n\n    Indented body.\n    \"\"\""},
+		{`Uses """ inside.`, `"""`, `'''Uses """ inside.'''`},
+		{`Mixes """ and '''.`, `"""`, `"""Mixes \""" and '''."""`},
+		{`Ends with "quote"`, `"""`, `"""Ends with "quote\""""`},
+		{`Matches \d+.`, `"""`, `r"""Matches \d+."""`},
+		{`Ends with backslash \`, `"""`, `"""Ends with backslash \\"""`},
+		{`Splits on \n and ends with \`, `"""`, `"""Splits on \\n and ends with \\"""`},
+	}
+	for _, tc := range cases {
+		if got := quoteDocstring(tc.text, "    ", tc.delimiter); got != tc.want {
+			t.Errorf("quoteDocstring(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package python
+
+import (
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	base "github.com/oxhq/morfx/providers/base"
+)
+
+// ExtractionBindings returns identifiers assigned or declared under node.
+func (c *Config) ExtractionBindings(node *sitter.Node, source string) []*sitter.Node {
+	var bindings []*sitter.Node
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "assignment", "augmented_assignment", "for_statement":
+			bindings = appendPythonTargets(bindings, n.ChildByFieldName("left"))
+		case "named_expression", "default_parameter", "typed_default_parameter":
+			bindings = appendPythonTargets(bindings, n.ChildByFieldName("name"))
+		case "as_pattern_target", "list_splat_pattern", "dictionary_splat_pattern", "typed_parameter":
+			bindings = appendPythonTargets(bindings, n.NamedChild(0))
+		case "parameters", "lambda_parameters":
+			for i := 0; i < int(n.NamedChildCount()); i++ {
+				if child := n.NamedChild(i); child.Type() == "identifier" {
+					bindings = append(bindings, child)
+				}
+			}
+		case "function_definition", "class_definition":
+			if node != n {
+				bindings = appendPythonTargets(bindings, n.ChildByFieldName("name"))
+			}
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(node)
+	return bindings
+}
+
+func appendPythonTargets(nodes []*sitter.Node, target *sitter.Node) []*sitter.Node {
+	if target == nil {
+		return nodes
+	}
+	switch target.Type() {
+	case "identifier":
+		return append(nodes, target)
+	case "pattern_list", "tuple_pattern", "list_pattern", "expression_list", "tuple", "list", "list_splat_pattern", "parenthesized_expression":
+		for i := 0; i < int(target.NamedChildCount()); i++ {
+			nodes = appendPythonTargets(nodes, target.NamedChild(i))
+		}
+	}
+	return nodes
+}
+
+// ExtractionReferences returns identifiers under node that name variables.
+func (c *Config) ExtractionReferences(node *sitter.Node, source string) []*sitter.Node {
+	var references []*sitter.Node
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		if n.Type() == "identifier" && !isPythonMemberName(n) {
+			references = append(references, n)
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(node)
+	return references
+}
+
+// isPythonMemberName reports whether ident names an attribute or keyword
+// argument rather than a variable.
+func isPythonMemberName(ident *sitter.Node) bool {
+	parent := ident.Parent()
+	if parent == nil {
+		return false
+	}
+	switch parent.Type() {
+	case "attribute":
+		return parent.ChildByFieldName("attribute") == ident
+	case "keyword_argument":
+		return parent.ChildByFieldName("name") == ident
+	}
+	return false
+}
+
+// RenderExtraction renders the extracted Python function and its call site.
+func (c *Config) RenderExtraction(source string, spec base.ExtractionSpec) (string, string, error) {
+	params := make([]string, 0, len(spec.Params))
+	for _, param := range spec.Params {
+		params = append(params, param.Name)
+	}
+	results := make([]string, 0, len(spec.Results))
+	for _, result := range spec.Results {
+		results = append(results, result.Name)
+	}
+
+	// PEP 8 separates top-level definitions with two blank lines.
+	var function strings.Builder
+	function.WriteString("\n")
+	if spec.Async {
+		function.WriteString("async ")
+	}
+	function.WriteString("def " + spec.Name + "(" + strings.Join(params, ", ") + "):\n")
+	function.WriteString(base.IndentLines(spec.Body, spec.IndentUnit))
+	if len(results) > 0 {
+		function.WriteString("\n" + spec.IndentUnit + "return " + strings.Join(results, ", "))
+	}
+	function.WriteString("\n")
+
+	call := spec.Name + "(" + strings.Join(params, ", ") + ")"
+	if spec.Async {
+		call = "await " + call
+	}
+	if len(results) > 0 {
+		call = strings.Join(results, ", ") + " = " + call
+	}
+	return function.String(), call, nil
+}
@@ -0,0 +1,70 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformExtractFunctionByLines(t *testing.T) {
+	provider := New()
+	source := `class Report:
+    def render(self, rows):
+        header = self.title.upper()
+        body = []
+        for row in rows:
+            body.append(str(row))
+        text = "\n".join(body)
+        return header + text
+`
+
+	query, err := core.ParseDSL("def:render lines=4..7")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:       "extract_function",
+		Target:       query,
+		FunctionName: "render_body",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+
+	expectedCall := "        header = self.title.upper()\n        text = render_body(rows)\n        return header + text\n"
+	if !strings.Contains(result.Modified, expectedCall) {
+		t.Fatalf("expected call site, got:\n%s", result.Modified)
+	}
+	expectedFunction := "\n\n\ndef render_body(rows):\n    body = []\n    for row in rows:\n        body.append(str(row))\n    text = \"\\n\".join(body)\n    return text\n"
+	if !strings.HasSuffix(result.Modified, expectedFunction) {
+		t.Fatalf("expected module-level function, got:\n%s", result.Modified)
+	}
+	if validation := provider.Validate(result.Modified); !validation.Valid {
+		t.Fatalf("extracted source does not parse: %v", validation.Errors)
+	}
+}
+
+func TestTransformExtractFunctionPassesSelf(t *testing.T) {
+	provider := New()
+	source := `class Counter:
+    def bump(self, step):
+        self.value += step
+        self.calls += 1
+`
+
+	query, err := core.ParseDSL("def:bump lines=3..4")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{Method: "extract_function", Target: query, FunctionName: "apply_step"})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "        apply_step(self, step)\n") ||
+		!strings.Contains(result.Modified, "def apply_step(self, step):\n    self.value += step\n") {
+		t.Fatalf("expected self to become a parameter, got:\n%s", result.Modified)
+	}
+}
@@ -0,0 +1,420 @@
+package python
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	base "github.com/oxhq/morfx/providers/base"
+)
+
+// standardModules lists standard library modules that MissingImports may add
+// when the source uses them as module.attribute without an import.
+var standardModules = map[string]struct{}{
+	"abc": {}, "argparse": {}, "asyncio": {}, "atexit": {}, "base64": {}, "bisect": {},
+	"calendar": {}, "codecs": {}, "collections": {}, "contextlib": {}, "copy": {}, "csv": {},
+	"dataclasses": {}, "datetime": {}, "decimal": {}, "difflib": {}, "enum": {}, "fnmatch": {},
+	"fractions": {}, "functools": {}, "gc": {}, "getpass": {}, "glob": {}, "gzip": {},
+	"hashlib": {}, "heapq": {}, "hmac": {}, "html": {}, "http": {}, "importlib": {},
+	"inspect": {}, "io": {}, "ipaddress": {}, "itertools": {}, "json": {}, "locale": {},
+	"logging": {}, "math": {}, "numbers": {}, "operator": {}, "os": {}, "pathlib": {},
+	"pickle": {}, "platform": {}, "pprint": {}, "queue": {}, "random": {}, "re": {},
+	"secrets": {}, "select": {}, "shlex": {}, "shutil": {}, "signal": {}, "socket": {},
+	"sqlite3": {}, "stat": {}, "statistics": {}, "string": {}, "struct": {}, "subprocess": {},
+	"sys": {}, "tarfile": {}, "tempfile": {}, "textwrap": {}, "threading": {}, "time": {},
+	"traceback": {}, "types": {}, "typing": {}, "unittest": {}, "urllib": {}, "uuid": {},
+	"warnings": {}, "weakref": {}, "xml": {}, "zipfile": {}, "zlib": {},
+}
+
+// pythonImport is one imported name of an import or from-import statement.
+type pythonImport struct {
+	statement *sitter.Node
+	entry     *sitter.Node
+	name      string
+	alias     string
+}
+
+// binding returns the identifier the import introduces: the alias, the
+// imported name, or the first package of a dotted plain import.
+func (i pythonImport) binding() string {
+	if i.alias != "" {
+		return i.alias
+	}
+	if i.statement.Type() == "import_statement" {
+		first, _, _ := strings.Cut(i.name, ".")
+		return first
+	}
+	return i.name
+}
+
+func pythonImportEntries(statement *sitter.Node, source string) []pythonImport {
+	var entries []pythonImport
+	for i := 0; i < int(statement.NamedChildCount()); i++ {
+		child := statement.NamedChild(i)
+		entry := pythonImport{statement: statement, entry: child}
+		switch child.Type() {
+		case "aliased_import":
+			if name := child.ChildByFieldName("name"); name != nil {
+				entry.name = source[name.StartByte():name.EndByte()]
+			}
+			if alias := child.ChildByFieldName("alias"); alias != nil {
+				entry.alias = source[alias.StartByte():alias.EndByte()]
+			}
+		case "dotted_name":
+			if statement.ChildByFieldName("module_name") == child {
+				continue
+			}
+			entry.name = source[child.StartByte():child.EndByte()]
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// pythonImports returns the imported names of top-level and nested imports.
+func pythonImports(root *sitter.Node, source string) []pythonImport {
+	var imports []pythonImport
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "import_statement", "import_from_statement":
+			imports = append(imports, pythonImportEntries(n, source)...)
+			return
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(root)
+	return imports
+}
+
+// usedNames returns the identifiers referenced outside import statements.
+func (c *Config) usedNames(root *sitter.Node, source string) map[string]struct{} {
+	used := make(map[string]struct{})
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "import_statement", "import_from_statement", "future_import_statement":
+			return
+		case "identifier":
+			if !isPythonMemberName(n) {
+				used[source[n.StartByte():n.EndByte()]] = struct{}{}
+			}
+		case "string":
+			// Names listed in __all__ count as used.
+			if content := strings.Trim(source[n.StartByte():n.EndByte()], "'\""); isExportList(n, source) {
+				used[content] = struct{}{}
+			}
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(root)
+	return used
+}
+
+func isExportList(str *sitter.Node, source string) bool {
+	for node := str.Parent(); node != nil; node = node.Parent() {
+		if node.Type() == "assignment" {
+			left := node.ChildByFieldName("left")
+			return left != nil && source[left.StartByte():left.EndByte()] == "__all__"
+		}
+		if node.Type() != "list" && node.Type() != "tuple" {
+			return false
+		}
+	}
+	return false
+}
+
+// UnusedImports returns imported names the module never references.
+func (c *Config) UnusedImports(source string, root *sitter.Node) []base.Target {
+	used := c.usedNames(root, source)
+	var unused []base.Target
+	for _, imported := range pythonImports(root, source) {
+		if _, ok := used[imported.binding()]; !ok {
+			unused = append(unused, base.NewTarget(imported.entry, "import", imported.binding()))
+		}
+	}
+	return unused
+}
+
+// MissingImports returns "import module" specs for standard library modules
+// used as module.attribute without being imported or bound locally.
+func (c *Config) MissingImports(source string, root *sitter.Node) []string {
+	bound := make(map[string]struct{})
+	for _, imported := range pythonImports(root, source) {
+		bound[imported.binding()] = struct{}{}
+	}
+	for _, binding := range c.ExtractionBindings(root, source) {
+		bound[source[binding.StartByte():binding.EndByte()]] = struct{}{}
+	}
+
+	missing := make(map[string]struct{})
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		if n.Type() == "attribute" {
+			if object := n.ChildByFieldName("object"); object != nil && object.Type() == "identifier" {
+				name := source[object.StartByte():object.EndByte()]
+				_, isBound := bound[name]
+				if _, standard := standardModules[name]; standard && !isBound {
+					missing["import "+name] = struct{}{}
+				}
+			}
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(root)
+
+	specs := make([]string, 0, len(missing))
+	for spec := range missing {
+		specs = append(specs, spec)
+	}
+	sort.Strings(specs)
+	return specs
+}
+
+// EnsureImport adds "import x [as y]" or "from m import a [as b]". A bare
+// module name is treated as "import name". Names missing from an existing
+// from-import of the same module are merged into it.
+func (c *Config) EnsureImport(source string, root *sitter.Node, spec string) (string, error) {
+	spec = strings.TrimSpace(spec)
+	if !strings.HasPrefix(spec, "import ") && !strings.HasPrefix(spec, "from ") {
+		spec = "import " + spec
+	}
+	specRoot, err := sitter.ParseCtx(context.Background(), []byte(spec), c.GetLanguage())
+	if err != nil {
+		return source, fmt.Errorf("failed to parse import %q: %w", spec, err)
+	}
+	statement := specRoot.NamedChild(0)
+	if specRoot.HasError() || statement == nil || specRoot.NamedChildCount() != 1 ||
+		(statement.Type() != "import_statement" && statement.Type() != "import_from_statement") {
+		return source, fmt.Errorf("invalid Python import %q", spec)
+	}
+
+	wanted := pythonImportEntries(statement, spec)
+	existing := pythonImports(root, source)
+
+	if statement.Type() == "import_from_statement" {
+		module := statement.ChildByFieldName("module_name")
+		moduleName := spec[module.StartByte():module.EndByte()]
+		for i := 0; i < int(root.NamedChildCount()); i++ {
+			candidate := root.NamedChild(i)
+			if candidate.Type() != "import_from_statement" {
+				continue
+			}
+			candidateModule := candidate.ChildByFieldName("module_name")
+			if candidateModule == nil || source[candidateModule.StartByte():candidateModule.EndByte()] != moduleName {
+				continue
+			}
+			if hasWildcardImport(candidate) {
+				return source, nil
+			}
+			entries := pythonImportEntries(candidate, source)
+			kept := importEntryTexts(entries, source)
+			added := false
+			for _, entry := range wanted {
+				if !containsPythonImport(entries, entry) {
+					kept = append(kept, spec[entry.entry.StartByte():entry.entry.EndByte()])
+					added = true
+				}
+			}
+			if !added {
+				return source, nil
+			}
+			rebuilt := renderPythonImport(candidate, source, kept)
+			return source[:candidate.StartByte()] + rebuilt + source[candidate.EndByte():], nil
+		}
+		for _, entry := range wanted {
+			if !containsPythonImport(fromModule(existing, moduleName, source), entry) {
+				return insertPythonImport(source, root, spec), nil
+			}
+		}
+		return source, nil
+	}
+
+	var lines []string
+	for _, entry := range wanted {
+		if containsPythonImport(plainImports(existing), entry) {
+			continue
+		}
+		lines = append(lines, "import "+spec[entry.entry.StartByte():entry.entry.EndByte()])
+	}
+	if len(lines) == 0 {
+		return source, nil
+	}
+	return insertPythonImport(source, root, strings.Join(lines, "\n")), nil
+}
+
+func hasWildcardImport(statement *sitter.Node) bool {
+	for i := 0; i < int(statement.NamedChildCount()); i++ {
+		if statement.NamedChild(i).Type() == "wildcard_import" {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPythonImport(entries []pythonImport, wanted pythonImport) bool {
+	for _, entry := range entries {
+		if entry.name == wanted.name && entry.alias == wanted.alias {
+			return true
+		}
+	}
+	return false
+}
+
+func plainImports(imports []pythonImport) []pythonImport {
+	var plain []pythonImport
+	for _, imported := range imports {
+		if imported.statement.Type() == "import_statement" {
+			plain = append(plain, imported)
+		}
+	}
+	return plain
+}
+
+func fromModule(imports []pythonImport, module, source string) []pythonImport {
+	var matched []pythonImport
+	for _, imported := range imports {
+		if moduleNode := imported.statement.ChildByFieldName("module_name"); moduleNode != nil &&
+			source[moduleNode.StartByte():moduleNode.EndByte()] == module {
+			matched = append(matched, imported)
+		}
+	}
+	return matched
+}
+
+func importEntryTexts(entries []pythonImport, source string) []string {
+	texts := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		texts = append(texts, source[entry.entry.StartByte():entry.entry.EndByte()])
+	}
+	return texts
+}
+
+// renderPythonImport rebuilds statement with the given entries, keeping a
+// parenthesized multi-line layout when the original used one.
+func renderPythonImport(statement *sitter.Node, source string, entries []string) string {
+	prefix := "import "
+	if statement.Type() == "import_from_statement" {
+		module := statement.ChildByFieldName("module_name")
+		prefix = "from " + source[module.StartByte():module.EndByte()] + " import "
+	}
+	original := source[statement.StartByte():statement.EndByte()]
+	if statement.Type() == "import_from_statement" && strings.Contains(original, "(") && strings.Contains(original, "\n") {
+		indent := "    "
+		if first := pythonImportEntries(statement, source); len(first) > 0 {
+			lineStart := strings.LastIndex(source[:first[0].entry.StartByte()], "\n") + 1
+			indent = source[lineStart:first[0].entry.StartByte()]
+		}
+		return prefix + "(\n" + indent + strings.Join(entries, ",\n"+indent) + ",\n)"
+	}
+	return prefix + strings.Join(entries, ", ")
+}
+
+// insertPythonImport places lines after the last top-level import, or after
+// the module docstring and leading comments when there are no imports.
+func insertPythonImport(source string, root *sitter.Node, lines string) string {
+	var anchor *sitter.Node
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		switch child := root.NamedChild(i); child.Type() {
+		case "import_statement", "import_from_statement", "future_import_statement":
+			anchor = child
+		}
+	}
+	if anchor != nil {
+		end := int(anchor.EndByte())
+		return source[:end] + "\n" + lines + source[end:]
+	}
+
+	offset := 0
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		child := root.NamedChild(i)
+		isDocstring := i == 0 && child.Type() == "expression_statement" &&
+			child.NamedChildCount() == 1 && child.NamedChild(0).Type() == "string"
+		if child.Type() != "comment" && !isDocstring {
+			break
+		}
+		offset = int(child.EndByte())
+	}
+	if offset == 0 {
+		rest := source
+		separator := "\n"
+		if strings.TrimSpace(rest) != "" {
+			separator = "\n\n"
+		}
+		return lines + separator + strings.TrimLeft(rest, "\n")
+	}
+	rest := strings.TrimLeft(source[offset:], "\n")
+	separator := "\n"
+	if strings.TrimSpace(rest) != "" {
+		separator = "\n\n"
+	}
+	return source[:offset] + "\n\n" + lines + separator + rest
+}
+
+// RemoveImports removes imported names, rewriting statements that keep
+// other names and deleting statements left empty.
+func (c *Config) RemoveImports(source string, root *sitter.Node, targets []base.Target) (string, error) {
+	removed := make(map[*sitter.Node]map[*sitter.Node]bool)
+	var statements []*sitter.Node
+	for _, target := range targets {
+		if target.Node == nil {
+			continue
+		}
+		statement := target.Node
+		for statement != nil && statement.Type() != "import_statement" && statement.Type() != "import_from_statement" {
+			statement = statement.Parent()
+		}
+		if statement == nil {
+			return source, fmt.Errorf("target %q is not an import", target.Name)
+		}
+		if removed[statement] == nil {
+			removed[statement] = make(map[*sitter.Node]bool)
+			statements = append(statements, statement)
+		}
+		removed[statement][target.Node] = true
+	}
+
+	sort.Slice(statements, func(i, j int) bool { return statements[i].StartByte() > statements[j].StartByte() })
+	result := source
+	for _, statement := range statements {
+		var kept []string
+		if !removed[statement][statement] {
+			for _, entry := range pythonImportEntries(statement, source) {
+				if !removed[statement][entry.entry] {
+					kept = append(kept, source[entry.entry.StartByte():entry.entry.EndByte()])
+				}
+			}
+		}
+		start, end := int(statement.StartByte()), int(statement.EndByte())
+		if len(kept) > 0 {
+			result = result[:start] + renderPythonImport(statement, source, kept) + result[end:]
+			continue
+		}
+		lineStart := strings.LastIndex(result[:start], "\n") + 1
+		if strings.TrimSpace(result[lineStart:start]) == "" {
+			start = lineStart
+			if newline := strings.IndexByte(result[end:], '\n'); newline >= 0 && strings.TrimSpace(result[end:end+newline]) == "" {
+				end += newline + 1
+			}
+		}
+		before, after := result[:start], result[end:]
+		if start == 0 {
+			after = strings.TrimLeft(after, "\n")
+		} else if strings.HasSuffix(before, "\n\n") && strings.HasPrefix(after, "\n") {
+			after = after[1:]
+		}
+		result = before + after
+	}
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package python
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformEnsureImportMergesFromImport(t *testing.T) {
+	provider := New()
+	source := `"""Helpers."""
+
+import os
+from typing import List
+
+
+def names() -> List[str]:
+    return os.listdir(".")
+`
+
+	result := provider.Transform(source, core.TransformOp{Method: "ensure_import", Content: "from typing import Dict, List"})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "from typing import List, Dict\n") {
+		t.Fatalf("expected Dict merged into existing from-import, got:\n%s", result.Modified)
+	}
+
+	result = provider.Transform(result.Modified, core.TransformOp{Method: "ensure_import", Content: "json"})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "from typing import List, Dict\nimport json\n") {
+		t.Fatalf("expected import after the last import, got:\n%s", result.Modified)
+	}
+
+	again := provider.Transform(result.Modified, core.TransformOp{Method: "ensure_import", Content: "import os"})
+	if again.Error != nil {
+		t.Fatalf("Transform returned error: %v", again.Error)
+	}
+	if again.Modified != result.Modified || again.MatchCount != 0 {
+		t.Fatalf("expected existing import to be left alone, got:\n%s", again.Modified)
+	}
+}
+
+func TestTransformEnsureImportAfterDocstring(t *testing.T) {
+	provider := New()
+	source := `"""Module docs."""
+
+
+def run():
+    pass
+`
+
+	result := provider.Transform(source, core.TransformOp{Method: "ensure_import", Content: "import sys"})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.HasPrefix(result.Modified, "\"\"\"Module docs.\"\"\"\n\nimport sys\n\ndef run():") {
+		t.Fatalf("expected import placed after the docstring, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformRemoveImportName(t *testing.T) {
+	provider := New()
+	source := `from typing import Dict, List, Optional
+import os
+
+value: Dict[str, int] = {}
+`
+
+	query, err := core.ParseDSL("import:List")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{Method: "remove_import", Target: query})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.HasPrefix(result.Modified, "from typing import Dict, Optional\nimport os\n") {
+		t.Fatalf("expected List removed from the from-import, got:\n%s", result.Modified)
+	}
+
+	query, err = core.ParseDSL("import:os")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result = provider.Transform(result.Modified, core.TransformOp{Method: "remove_import", Target: query})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if strings.Contains(result.Modified, "import os") {
+		t.Fatalf("expected os import statement removed, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformOrganizeImportsAfterReplace(t *testing.T) {
+	provider := New()
+	source := `import os
+import re
+
+
+def load():
+    return os.getenv("CONFIG")
+
+
+def check(text):
+    return re.match("x", text)
+`
+
+	query, err := core.ParseDSL("def:load")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{
+		Method:          "replace",
+		Target:          query,
+		Replacement:     "def load():\n    return json.loads(\"{}\")",
+		OrganizeImports: true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if strings.Contains(result.Modified, "import os") {
+		t.Fatalf("expected unused os import removed, got:\n%s", result.Modified)
+	}
+	if !strings.Contains(result.Modified, "import re\nimport json\n") {
+		t.Fatalf("expected json import added after re, got:\n%s", result.Modified)
+	}
+}
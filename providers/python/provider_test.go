@@ -218,6 +218,117 @@ def greet(name):
 	}
 }
 
+func TestPythonProvider_Transform_ReindentsMethodBody(t *testing.T) {
+	provider := New()
+	source := "class Greeter:\n    def greet(self, name):\n        return name\n\n    def wave(self):\n        pass\n"
+
+	cases := []struct {
+		method  string
+		snippet string
+		want    string
+	}{
+		{"replace", "def greet(self, name):\n  if name:\n    return name\n  return 'stranger'",
+			"class Greeter:\n    def greet(self, name):\n        if name:\n            return name\n        return 'stranger'\n\n    def wave(self):\n        pass\n"},
+		{"insert_before", "def hello(self):\n    return 'hi'",
+			"class Greeter:\n    def hello(self):\n        return 'hi'\n    def greet(self, name):\n        return name\n\n    def wave(self):\n        pass\n"},
+		{"insert_after", "  def bye(self):\n    return 'bye'",
+			"class Greeter:\n    def greet(self, name):\n        return name\n    def bye(self):\n        return 'bye'\n\n    def wave(self):\n        pass\n"},
+		// A leading blank line stays empty instead of holding the indentation
+		{"insert_after", "\ndef bye(self):\n    return 'bye'",
+			"class Greeter:\n    def greet(self, name):\n        return name\n\n    def bye(self):\n        return 'bye'\n\n    def wave(self):\n        pass\n"},
+	}
+	for _, tc := range cases {
+		result := provider.Transform(source, core.TransformOp{
+			Method:      tc.method,
+			Target:      core.AgentQuery{Type: "method", Name: "greet"},
+			Content:     tc.snippet,
+			Replacement: tc.snippet,
+		})
+		if result.Error != nil {
+			t.Fatalf("%s failed: %v", tc.method, result.Error)
+		}
+		if result.Modified != tc.want {
+			t.Fatalf("%s: unexpected indentation:\n%s", tc.method, result.Modified)
+		}
+	}
+}
+
+func TestPythonProvider_Transform_ReindentKeepsTripleQuotedStrings(t *testing.T) {
+	provider := New()
+	source := "class Greeter:\n    def greet(self):\n        return 'hi'\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:      "replace",
+		Target:      core.AgentQuery{Type: "method", Name: "greet"},
+		Replacement: "def greet(self):\n  return \"\"\"a\nb\n  c\"\"\"",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	want := "class Greeter:\n    def greet(self):\n        return \"\"\"a\nb\n  c\"\"\"\n"
+	if result.Modified != want {
+		t.Fatalf("expected string contents kept, got %q", result.Modified)
+	}
+}
+
+func TestPythonProvider_Transform_OverlappingTargets(t *testing.T) {
+	provider := New()
+	source := "class RequestHandler:\n    def get_handler(self):\n        return 1\n"
+	query, err := core.ParseDSL("class:*Handler | method:*_handler")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:      "replace",
+		Target:      query,
+		Replacement: "def get_handler(self):\n    return 2",
+		Overlap:     "innermost",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	if result.Modified != "class RequestHandler:\n    def get_handler(self):\n        return 2\n" {
+		t.Fatalf("expected only the inner method replaced, got:\n%s", result.Modified)
+	}
+	dropped, ok := result.Metadata["dropped_matches"].([]core.Match)
+	if !ok || len(dropped) != 1 || dropped[0].Name != "RequestHandler" {
+		t.Fatalf("expected the class reported as dropped, got %#v", result.Metadata)
+	}
+	if !slices.ContainsFunc(result.Confidence.Factors, func(f core.ConfidenceFactor) bool {
+		return f.Name == "overlapping_targets"
+	}) {
+		t.Fatalf("expected overlapping_targets factor, got %+v", result.Confidence.Factors)
+	}
+
+	result = provider.Transform(source, core.TransformOp{Method: "delete", Target: query, Overlap: "error"})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "class RequestHandler (lines 1-3) overlaps method get_handler") {
+		t.Fatalf("expected overlap error, got %v", result.Error)
+	}
+}
+
+func TestPythonProvider_Transform_SortReportsDroppedTargets(t *testing.T) {
+	provider := New()
+	source := "class RequestHandler:\n    def put_handler(self):\n        return 2\n\n    def get_handler(self):\n        return 1\n"
+	query, err := core.ParseDSL("class:*Handler | method:*_handler")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{Method: "sort", Target: query, Overlap: "innermost"})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	want := "class RequestHandler:\n    def get_handler(self):\n        return 1\n\n    def put_handler(self):\n        return 2\n"
+	if result.Modified != want {
+		t.Fatalf("expected the methods sorted, got:\n%s", result.Modified)
+	}
+	dropped, ok := result.Metadata["dropped_matches"].([]core.Match)
+	if !ok || len(dropped) != 1 || dropped[0].Name != "RequestHandler" {
+		t.Fatalf("expected the class reported as dropped, got %#v", result.Metadata)
+	}
+}
+
 func TestPythonProvider_Transform_Delete(t *testing.T) {
 	provider := New()
 	source := `
@@ -1425,3 +1536,292 @@ class PublicAPI:
 		)
 	}
 }
+
+func TestPythonProvider_Transform_IfAbsentDecorator(t *testing.T) {
+	provider := New()
+	source := "class Greeter:\n    @cache\n    def greet(self):\n        pass\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:   "insert_before",
+		Target:   core.AgentQuery{Type: "method", Name: "greet"},
+		Content:  "@cache",
+		IfAbsent: true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	if result.Modified != source {
+		t.Fatalf("expected existing decorator to be detected, got:\n%s", result.Modified)
+	}
+
+	result = provider.Transform(source, core.TransformOp{
+		Method:   "insert_before",
+		Target:   core.AgentQuery{Type: "method", Name: "greet"},
+		Content:  "@cache(maxsize=1)",
+		IfAbsent: true,
+	})
+	if result.Error != nil || !strings.Contains(result.Modified, "@cache(maxsize=1)") {
+		t.Fatalf("expected a different decorator to be inserted, got %v:\n%s", result.Error, result.Modified)
+	}
+}
+
+func TestPythonProvider_Transform_DeleteTakesDecorators(t *testing.T) {
+	provider := New()
+	source := "class Greeter:\n    # Cached greeting.\n    @cache\n    @log(level=1)\n    def greet(self):\n        pass\n\n    def wave(self):\n        pass\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method: "delete",
+		Target: core.AgentQuery{Type: "method", Name: "greet"},
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	for _, gone := range []string{"Cached greeting", "@cache", "@log", "greet"} {
+		if strings.Contains(result.Modified, gone) {
+			t.Errorf("expected %q to be deleted:\n%s", gone, result.Modified)
+		}
+	}
+	if !strings.Contains(result.Modified, "def wave(self):") {
+		t.Fatalf("expected wave to survive:\n%s", result.Modified)
+	}
+}
+
+func TestPythonProvider_Transform_SortMethodsByVisibility(t *testing.T) {
+	provider := New()
+	source := "class C:\n    # x doc\n    @property\n    def x(self):\n        pass\n\n    def _b(self):\n        pass\n\n    def __init__(self):\n        pass\n\n    def a(self):\n        pass\n"
+	target, err := core.ParseDSL("class:C > method:*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{Method: "sort", Target: target, SortBy: core.SortByVisibility})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	// Dunder methods are public; a single underscore is protected.
+	want := "class C:\n    # x doc\n    @property\n    def x(self):\n        pass\n\n    def __init__(self):\n        pass\n\n    def a(self):\n        pass\n\n    def _b(self):\n        pass\n"
+	if result.Modified != want {
+		t.Fatalf("unexpected order:\n%s", result.Modified)
+	}
+}
+
+func TestPythonProvider_Transform_AddAndRemoveDecorators(t *testing.T) {
+	provider := New()
+	source := "class C:\n    # Cached.\n    @cache\n    def f(self):\n        pass\n\n    def g(self):\n        pass\n"
+	target, err := core.ParseDSL("class:C > method:*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	added := provider.Transform(source, core.TransformOp{Method: "add_annotation", Target: target, Annotation: "@deprecated"})
+	if added.Error != nil {
+		t.Fatalf("Transform failed: %v", added.Error)
+	}
+	want := "class C:\n    # Cached.\n    @cache\n    @deprecated\n    def f(self):\n        pass\n\n    @deprecated\n    def g(self):\n        pass\n"
+	if added.Modified != want {
+		t.Fatalf("unexpected result:\n%s", added.Modified)
+	}
+
+	removed := provider.Transform(added.Modified, core.TransformOp{Method: "remove_annotation", Target: target, Annotation: "@deprecated"})
+	if removed.Error != nil {
+		t.Fatalf("Transform failed: %v", removed.Error)
+	}
+	if removed.Modified != source {
+		t.Fatalf("expected removal to restore the source:\n%s", removed.Modified)
+	}
+
+	direct := provider.Transform(source, core.TransformOp{Method: "remove_annotation", Target: core.AgentQuery{Type: "decorator", Name: "cache"}})
+	if direct.Error != nil {
+		t.Fatalf("Transform failed: %v", direct.Error)
+	}
+	if strings.Contains(direct.Modified, "@cache") || !strings.Contains(direct.Modified, "    # Cached.\n    def f(self):") {
+		t.Fatalf("expected the decorator line to be removed:\n%s", direct.Modified)
+	}
+}
+
+func TestPythonProvider_ReplaceMethodWithStatementIsFlagged(t *testing.T) {
+	provider := New()
+	source := "class A:\n    def f(self):\n        pass\n"
+	target := core.AgentQuery{Type: "def", Name: "f"}
+
+	statement := provider.Transform(source, core.TransformOp{Method: "replace", Target: target, Replacement: "print(1)"})
+	if statement.Error != nil {
+		t.Fatalf("Transform failed: %v", statement.Error)
+	}
+	flagged := slices.ContainsFunc(statement.Confidence.Factors, func(factor core.ConfidenceFactor) bool {
+		return factor.Name == "snippet_kind_mismatch" && strings.Contains(factor.Reason, "parses as expression_statement")
+	})
+	if !flagged {
+		t.Fatalf("expected a snippet_kind_mismatch factor, got %+v", statement.Confidence.Factors)
+	}
+
+	method := provider.Transform(source, core.TransformOp{Method: "replace", Target: target, Replacement: "def f(self):\n    return 1"})
+	if method.Error != nil {
+		t.Fatalf("Transform failed: %v", method.Error)
+	}
+	if slices.ContainsFunc(method.Confidence.Factors, func(factor core.ConfidenceFactor) bool { return factor.Name == "snippet_kind_mismatch" }) {
+		t.Fatalf("unexpected mismatch for a method replacing a method: %+v", method.Confidence.Factors)
+	}
+}
+
+func TestPythonProvider_ReplaceDecoratedDefinitionWithDecorators(t *testing.T) {
+	provider := New()
+	source := "class A:\n    @property\n    @cached\n    def name(self):\n        return 1\n\n\n@app.route(\"/\")\ndef index():\n    return 2\n"
+
+	tests := []struct {
+		name        string
+		target      string
+		replacement string
+		want        string
+	}{
+		{
+			name:        "method",
+			target:      "name",
+			replacement: "@property\ndef name(self):\n    return 3",
+			want:        "class A:\n    @property\n    def name(self):\n        return 3\n\n\n@app.route(\"/\")\ndef index():\n    return 2\n",
+		},
+		{
+			name:        "function",
+			target:      "index",
+			replacement: "@app.route(\"/home\")\ndef index():\n    return 4",
+			want:        "class A:\n    @property\n    @cached\n    def name(self):\n        return 1\n\n\n@app.route(\"/home\")\ndef index():\n    return 4\n",
+		},
+		{
+			name:        "without decorators",
+			target:      "index",
+			replacement: "def index():\n    return 4",
+			want:        "class A:\n    @property\n    @cached\n    def name(self):\n        return 1\n\n\n@app.route(\"/\")\ndef index():\n    return 4\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := provider.Transform(source, core.TransformOp{
+				Method:      "replace",
+				Target:      core.AgentQuery{Type: "function", Name: tt.target},
+				Replacement: tt.replacement,
+			})
+			if result.Error != nil {
+				t.Fatalf("Transform failed: %v", result.Error)
+			}
+			if result.Modified != tt.want {
+				t.Fatalf("unexpected output:\n%s", result.Modified)
+			}
+			if slices.ContainsFunc(result.Confidence.Factors, func(factor core.ConfidenceFactor) bool { return factor.Name == "snippet_kind_mismatch" }) {
+				t.Fatalf("unexpected mismatch for a decorated definition: %+v", result.Confidence.Factors)
+			}
+		})
+	}
+}
+
+func TestPythonProvider_DeleteDecoratedDefinitionRemovesWholeLines(t *testing.T) {
+	provider := New()
+	source := "class A:\n    x = 1\n\n    @property\n    @cached\n    def name(self):\n        return 1\n\n    def other(self):\n        pass\n\n\n@app.route(\"/\")\ndef index():\n    return 2\n\n\ndef last():\n    pass\n"
+
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{
+			name:   "method",
+			target: "name",
+			want:   "class A:\n    x = 1\n\n    def other(self):\n        pass\n\n\n@app.route(\"/\")\ndef index():\n    return 2\n\n\ndef last():\n    pass\n",
+		},
+		{
+			name:   "function",
+			target: "index",
+			want:   "class A:\n    x = 1\n\n    @property\n    @cached\n    def name(self):\n        return 1\n\n    def other(self):\n        pass\n\n\ndef last():\n    pass\n",
+		},
+		{
+			name:   "last function",
+			target: "last",
+			want:   "class A:\n    x = 1\n\n    @property\n    @cached\n    def name(self):\n        return 1\n\n    def other(self):\n        pass\n\n\n@app.route(\"/\")\ndef index():\n    return 2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := provider.Transform(source, core.TransformOp{
+				Method:   "delete",
+				Target:   core.AgentQuery{Type: "function", Name: tt.target},
+				Attached: "include",
+			})
+			if result.Error != nil {
+				t.Fatalf("Transform failed: %v", result.Error)
+			}
+			if result.Modified != tt.want {
+				t.Fatalf("unexpected output:\n%q", result.Modified)
+			}
+		})
+	}
+}
+
+func TestPythonProvider_StructuralDiffMovesDecoratedMethodAlone(t *testing.T) {
+	provider := New()
+	original := "class A:\n    @property\n    def a(self):\n        return 1\n\n    def k(self):\n        pass\n\n\nclass B:\n    def b(self):\n        pass\n"
+	modified := "class A:\n    def k(self):\n        pass\n\n\nclass B:\n    def b(self):\n        pass\n\n    @property\n    def a(self):\n        return 1\n"
+
+	changes := provider.StructuralDiff(original, modified)
+	if len(changes) != 1 || changes[0].Summary != "moved method a from class A to class B" {
+		t.Fatalf("expected only the move, got %+v", changes)
+	}
+}
+
+func TestPythonProvider_StructuralDiffNamesMethodsAndCalls(t *testing.T) {
+	provider := New()
+	source := "class Y:\n    def b(self, n):\n        return foo(n, 2)\n\n    def a(self):\n        pass\n"
+
+	tests := []struct {
+		name string
+		op   core.TransformOp
+		want []string
+	}{
+		{
+			name: "inserted method",
+			op:   core.TransformOp{Method: "insert_after", Target: core.AgentQuery{Type: "method", Name: "a"}, Content: "def x(self):\n    pass"},
+			want: []string{"inserted method x in class Y"},
+		},
+		{
+			name: "call argument",
+			op:   core.TransformOp{Method: "replace", Target: core.AgentQuery{Type: "method", Name: "b"}, Replacement: "def b(self, n):\n        return foo(n, 3)"},
+			want: []string{`changed argument 2 of call to foo in method b from "2" to "3"`},
+		},
+		{
+			name: "reordered",
+			op:   core.TransformOp{Method: "sort", Target: core.AgentQuery{Type: "method", Name: "*"}},
+			want: []string{"reordered members of class Y"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := tt.op
+			op.StructuralDiff = true
+			result := provider.Transform(source, op)
+			if result.Error != nil {
+				t.Fatalf("Transform failed: %v", result.Error)
+			}
+			changes, _ := result.Metadata["structural_diff"].([]core.StructuralChange)
+			var got []string
+			for _, change := range changes {
+				got = append(got, change.Summary)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("structural diff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPythonProvider_StructuralDiffReportsMovedMethods(t *testing.T) {
+	provider := New()
+	original := "class A:\n    def f(self):\n        return 1\n\n    def g(self):\n        pass\n\n\nclass B:\n    def h(self):\n        pass\n"
+	modified := "class A:\n    def g(self):\n        pass\n\n\nclass B:\n    def h(self):\n        pass\n\n    def f(self):\n        return 1\n"
+
+	changes := provider.StructuralDiff(original, modified)
+	want := core.StructuralChange{Change: "moved", Kind: "method", Path: "class B > method f", Line: 10, Summary: "moved method f from class A to class B"}
+	if len(changes) != 1 || changes[0] != want {
+		t.Fatalf("structural diff = %+v, want %+v", changes, want)
+	}
+}
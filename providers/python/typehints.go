@@ -0,0 +1,139 @@
+package python
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/oxhq/morfx/core"
+	base "github.com/oxhq/morfx/providers/base"
+)
+
+// AnnotateTypes adds or replaces type hints on each function target.
+// op.TypeHints maps parameter names, with or without their * or ** prefix,
+// to types, and "return" to the return type. A name that no target has is
+// an error, so a typo does not pass silently.
+func (c *Config) AnnotateTypes(source string, targets []base.Target, op core.TransformOp) (string, map[string]any, error) {
+	names := make([]string, 0, len(op.TypeHints))
+	for name, hint := range op.TypeHints {
+		if strings.TrimSpace(hint) == "" {
+			return source, nil, fmt.Errorf("type hint for %q is empty", name)
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var edits []base.Edit
+	added, replaced, skipped := 0, 0, 0
+	found := make(map[string]bool)
+	seen := make(map[uint32]bool)
+	for _, target := range targets {
+		fn := definitionNode(target.Node)
+		if fn == nil || fn.Type() == "class_definition" {
+			return source, nil, fmt.Errorf("type hints apply to functions, not %s", target.Type)
+		}
+		if seen[fn.StartByte()] {
+			continue
+		}
+		seen[fn.StartByte()] = true
+
+		for _, name := range names {
+			hint := strings.TrimSpace(op.TypeHints[name])
+			var (
+				edit      base.Edit
+				typed, ok bool
+			)
+			if name == "return" {
+				edit, typed, ok = returnHint(fn, hint)
+			} else {
+				edit, typed, ok = parameterHint(source, fn, strings.TrimLeft(name, "*"), hint)
+			}
+			if !ok {
+				continue
+			}
+			found[name] = true
+			switch {
+			case typed && (op.IfAbsent || source[edit.Start:edit.End] == edit.Text):
+				skipped++
+			case typed:
+				replaced++
+				edits = append(edits, edit)
+			default:
+				added++
+				edits = append(edits, edit)
+			}
+		}
+	}
+	for _, name := range names {
+		if !found[name] {
+			return source, nil, fmt.Errorf("%w: no matched function has a parameter named %q", core.ErrNoMatchesFound, name)
+		}
+	}
+
+	modified, err := base.ApplyEdits(source, edits)
+	if err != nil {
+		return source, nil, err
+	}
+	return modified, map[string]any{"hints_added": added, "hints_replaced": replaced, "hints_skipped": skipped}, nil
+}
+
+// returnHint returns the edit that sets fn's return type and whether fn
+// already had one.
+func returnHint(fn *sitter.Node, hint string) (base.Edit, bool, bool) {
+	if current := fn.ChildByFieldName("return_type"); current != nil {
+		return base.Edit{Start: current.StartByte(), End: current.EndByte(), Text: hint}, true, true
+	}
+	params := fn.ChildByFieldName("parameters")
+	if params == nil {
+		return base.Edit{}, false, false
+	}
+	return base.Edit{Start: params.EndByte(), End: params.EndByte(), Text: " -> " + hint}, false, true
+}
+
+// parameterHint returns the edit that sets the type of fn's parameter name
+// and whether the parameter was already typed. Defaults are respaced as
+// PEP 8 asks for annotated parameters, so b=1 becomes b: int = 1.
+func parameterHint(source string, fn *sitter.Node, name, hint string) (base.Edit, bool, bool) {
+	params := fn.ChildByFieldName("parameters")
+	if params == nil {
+		return base.Edit{}, false, false
+	}
+	for i := 0; i < int(params.NamedChildCount()); i++ {
+		param := params.NamedChild(i)
+		if parameterName(source, param) != name {
+			continue
+		}
+		switch param.Type() {
+		case "typed_parameter", "typed_default_parameter":
+			if current := param.ChildByFieldName("type"); current != nil {
+				return base.Edit{Start: current.StartByte(), End: current.EndByte(), Text: hint}, true, true
+			}
+		case "default_parameter":
+			nameNode, value := param.ChildByFieldName("name"), param.ChildByFieldName("value")
+			return base.Edit{Start: nameNode.EndByte(), End: value.StartByte(), Text: ": " + hint + " = "}, false, true
+		default:
+			return base.Edit{Start: param.EndByte(), End: param.EndByte(), Text: ": " + hint}, false, true
+		}
+	}
+	return base.Edit{}, false, false
+}
+
+// parameterName returns the bare name a parameter node binds, without its
+// * or ** prefix, or "" for separators.
+func parameterName(source string, param *sitter.Node) string {
+	switch param.Type() {
+	case "identifier":
+		return source[param.StartByte():param.EndByte()]
+	case "default_parameter", "typed_default_parameter":
+		if nameNode := param.ChildByFieldName("name"); nameNode != nil {
+			return source[nameNode.StartByte():nameNode.EndByte()]
+		}
+	case "typed_parameter", "list_splat_pattern", "dictionary_splat_pattern":
+		if param.NamedChildCount() > 0 {
+			return parameterName(source, param.NamedChild(0))
+		}
+	}
+	return ""
+}
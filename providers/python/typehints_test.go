@@ -0,0 +1,80 @@
+package python
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestAnnotateTypesAddsAndReplacesHints(t *testing.T) {
+	provider := New()
+	source := "def load(path, retries=3, timeout: int = 5, *args, strict: bool, **kwargs) -> str:\n    pass\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method: "annotate",
+		Target: core.AgentQuery{Type: "function", Name: "load"},
+		TypeHints: map[string]string{
+			"path":    "Path",
+			"retries": "int",
+			"timeout": "float",
+			"*args":   "str",
+			"kwargs":  "Any",
+			"strict":  "bool",
+			"return":  "bytes",
+		},
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	want := "def load(path: Path, retries: int = 3, timeout: float = 5, *args: str, strict: bool, **kwargs: Any) -> bytes:\n"
+	if !strings.HasPrefix(result.Modified, want) {
+		t.Fatalf("unexpected signature:\n%s", result.Modified)
+	}
+	if result.Metadata["hints_added"] != 4 || result.Metadata["hints_replaced"] != 2 || result.Metadata["hints_skipped"] != 1 {
+		t.Fatalf("unexpected metadata %v", result.Metadata)
+	}
+}
+
+func TestAnnotateTypesIfAbsentAndMissingParameters(t *testing.T) {
+	provider := New()
+	source := "class A:\n    def f(self, x: int):\n        pass\n\n    def g(self, x):\n        pass\n"
+	query, err := core.ParseDSL("class:A > def:*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:    "annotate",
+		Target:    query,
+		TypeHints: map[string]string{"x": "str", "return": "None"},
+		IfAbsent:  true,
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	for _, want := range []string{"def f(self, x: int) -> None:", "def g(self, x: str) -> None:"} {
+		if !strings.Contains(result.Modified, want) {
+			t.Fatalf("missing %q in:\n%s", want, result.Modified)
+		}
+	}
+
+	missing := provider.Transform(source, core.TransformOp{
+		Method:    "annotate",
+		Target:    query,
+		TypeHints: map[string]string{"y": "int"},
+	})
+	if !errors.Is(missing.Error, core.ErrNoMatchesFound) || !strings.Contains(missing.Error.Error(), `"y"`) {
+		t.Fatalf("expected a missing parameter error, got %v", missing.Error)
+	}
+
+	class := provider.Transform(source, core.TransformOp{
+		Method:    "annotate",
+		Target:    core.AgentQuery{Type: "class", Name: "A"},
+		TypeHints: map[string]string{"x": "int"},
+	})
+	if class.Error == nil || !strings.Contains(class.Error.Error(), "type hints apply to functions") {
+		t.Fatalf("expected a function-only error, got %v", class.Error)
+	}
+}
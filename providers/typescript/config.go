@@ -673,27 +673,9 @@ func (c *Config) getArrowFunctionName(node *sitter.Node, source string) string {
 
 func (c *Config) expandImportStatement(node *sitter.Node, source string, query core.AgentQuery) []base.Target {
 	var matches []base.Target
-	for i := 0; i < int(node.ChildCount()); i++ {
-		child := node.Child(i)
-		switch child.Type() {
-		case "import_specifier":
-			if alias := child.ChildByFieldName("alias"); alias != nil {
-				name := source[alias.StartByte():alias.EndByte()]
-				matches = append(matches, base.NewTarget(child, query.Type, name))
-				continue
-			}
-			if nameNode := child.ChildByFieldName("name"); nameNode != nil {
-				name := source[nameNode.StartByte():nameNode.EndByte()]
-				matches = append(matches, base.NewTarget(child, query.Type, name))
-				continue
-			}
-		case "namespace_import":
-			if nameNode := child.ChildByFieldName("name"); nameNode != nil {
-				name := source[nameNode.StartByte():nameNode.EndByte()]
-				matches = append(matches, base.NewTarget(child, query.Type, name))
-				continue
-			}
-		}
+	// Capture each default, namespace, or named binding
+	for _, binding := range importBindings(node, source) {
+		matches = append(matches, base.NewTarget(binding.node, query.Type, binding.name))
 	}
 	if len(matches) == 0 {
 		name := c.ExtractNodeName(node, source)
@@ -725,3 +707,14 @@ func (c *Config) expandExportStatement(node *sitter.Node, source string, query c
 	}
 	return matches
 }
+
+// FormatCommand returns the default external TypeScript formatter.
+func (c *Config) FormatCommand() []string {
+	return []string{"prettier", "--stdin-filepath", "morfx.ts"}
+}
+
+// IndentUnit returns the conventional TypeScript indentation for sources that
+// have no indented lines yet.
+func (c *Config) IndentUnit() string {
+	return "  "
+}
@@ -0,0 +1,202 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	base "github.com/oxhq/morfx/providers/base"
+)
+
+// ExtractionBindings returns identifiers declared or assigned under node.
+func (c *Config) ExtractionBindings(node *sitter.Node, source string) []*sitter.Node {
+	var bindings []*sitter.Node
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "variable_declarator":
+			bindings = appendPatternIdentifiers(bindings, n.ChildByFieldName("name"))
+		case "assignment_expression", "augmented_assignment_expression", "for_in_statement":
+			bindings = appendPatternIdentifiers(bindings, n.ChildByFieldName("left"))
+		case "update_expression":
+			bindings = appendPatternIdentifiers(bindings, n.ChildByFieldName("argument"))
+		case "required_parameter", "optional_parameter":
+			bindings = appendPatternIdentifiers(bindings, n.ChildByFieldName("pattern"))
+		case "catch_clause":
+			bindings = appendPatternIdentifiers(bindings, n.ChildByFieldName("parameter"))
+		case "arrow_function":
+			bindings = appendPatternIdentifiers(bindings, n.ChildByFieldName("parameter"))
+		case "formal_parameters":
+			for i := 0; i < int(n.NamedChildCount()); i++ {
+				if child := n.NamedChild(i); child.Type() == "identifier" {
+					bindings = append(bindings, child)
+				}
+			}
+		case "function_declaration", "generator_function_declaration":
+			if node != n {
+				bindings = appendPatternIdentifiers(bindings, n.ChildByFieldName("name"))
+			}
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(node)
+	return bindings
+}
+
+func appendPatternIdentifiers(nodes []*sitter.Node, pattern *sitter.Node) []*sitter.Node {
+	if pattern == nil {
+		return nodes
+	}
+	switch pattern.Type() {
+	case "identifier", "shorthand_property_identifier_pattern":
+		return append(nodes, pattern)
+	case "pair_pattern":
+		return appendPatternIdentifiers(nodes, pattern.ChildByFieldName("value"))
+	case "assignment_pattern", "object_assignment_pattern":
+		return appendPatternIdentifiers(nodes, pattern.ChildByFieldName("left"))
+	case "object_pattern", "array_pattern", "rest_pattern":
+		for i := 0; i < int(pattern.NamedChildCount()); i++ {
+			nodes = appendPatternIdentifiers(nodes, pattern.NamedChild(i))
+		}
+	}
+	return nodes
+}
+
+// ExtractionReferences returns identifiers under node that name variables.
+func (c *Config) ExtractionReferences(node *sitter.Node, source string) []*sitter.Node {
+	var references []*sitter.Node
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "identifier", "shorthand_property_identifier", "shorthand_property_identifier_pattern":
+			references = append(references, n)
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(node)
+	return references
+}
+
+// RenderExtraction renders the extracted TypeScript function and its call site.
+func (c *Config) RenderExtraction(source string, spec base.ExtractionSpec) (string, string, error) {
+	for _, statement := range spec.Statements {
+		if this := findThis(statement); this != nil {
+			return "", "", fmt.Errorf("cannot extract code using this at line %d into a standalone function", this.StartPoint().Row+1)
+		}
+	}
+
+	params := make([]string, 0, len(spec.Params))
+	args := make([]string, 0, len(spec.Params))
+	for _, param := range spec.Params {
+		declaration := param.Name
+		if annotation := bindingTypeAnnotation(param.Binding, source); annotation != "" {
+			declaration += annotation
+		}
+		params = append(params, declaration)
+		args = append(args, param.Name)
+	}
+	results := make([]string, 0, len(spec.Results))
+	var undeclared []string
+	keyword := "const"
+	for _, result := range spec.Results {
+		results = append(results, result.Name)
+		if !result.Declared {
+			undeclared = append(undeclared, result.Name)
+			if bindingKeyword(result.Binding) != "const" {
+				keyword = "let"
+			}
+		}
+	}
+
+	var function strings.Builder
+	if spec.Async {
+		function.WriteString("async ")
+	}
+	fmt.Fprintf(&function, "function %s(%s) {\n", spec.Name, strings.Join(params, ", "))
+	function.WriteString(base.IndentLines(spec.Body, spec.IndentUnit))
+	switch len(results) {
+	case 0:
+	case 1:
+		function.WriteString("\n" + spec.IndentUnit + "return " + results[0] + ";")
+	default:
+		function.WriteString("\n" + spec.IndentUnit + "return { " + strings.Join(results, ", ") + " };")
+	}
+	function.WriteString("\n}\n")
+
+	invocation := spec.Name + "(" + strings.Join(args, ", ") + ")"
+	if spec.Async {
+		invocation = "await " + invocation
+	}
+	var call string
+	switch {
+	case len(results) == 0:
+		call = invocation + ";"
+	case len(results) == 1 && len(undeclared) == 1:
+		call = keyword + " " + results[0] + " = " + invocation + ";"
+	case len(results) == 1:
+		call = results[0] + " = " + invocation + ";"
+	case len(undeclared) == len(results):
+		call = keyword + " { " + strings.Join(results, ", ") + " } = " + invocation + ";"
+	case len(undeclared) == 0:
+		call = "({ " + strings.Join(results, ", ") + " } = " + invocation + ");"
+	default:
+		call = "let " + strings.Join(undeclared, ", ") + ";\n" +
+			"({ " + strings.Join(results, ", ") + " } = " + invocation + ");"
+	}
+	return function.String(), call, nil
+}
+
+// findThis returns the first use of this that belongs to the enclosing
+// function; arrow functions share it, other nested functions do not.
+func findThis(node *sitter.Node) *sitter.Node {
+	if node.Type() == "this" {
+		return node
+	}
+	switch node.Type() {
+	case "function_declaration", "function_expression", "function", "generator_function_declaration", "class_declaration", "class":
+		return nil
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if found := findThis(node.NamedChild(i)); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// bindingTypeAnnotation returns the ": T" annotation written on a binding.
+func bindingTypeAnnotation(binding *sitter.Node, source string) string {
+	if binding == nil || binding.Parent() == nil {
+		return ""
+	}
+	parent := binding.Parent()
+	switch parent.Type() {
+	case "required_parameter", "optional_parameter", "variable_declarator":
+		if annotation := parent.ChildByFieldName("type"); annotation != nil {
+			return source[annotation.StartByte():annotation.EndByte()]
+		}
+	}
+	return ""
+}
+
+// bindingKeyword returns const, let, or var for a declared binding.
+func bindingKeyword(binding *sitter.Node) string {
+	for node := binding; node != nil; node = node.Parent() {
+		switch node.Type() {
+		case "lexical_declaration":
+			if kind := node.ChildByFieldName("kind"); kind != nil {
+				return kind.Type()
+			}
+		case "variable_declaration":
+			return "var"
+		case "statement_block", "program":
+			return ""
+		}
+	}
+	return ""
+}
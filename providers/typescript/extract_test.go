@@ -0,0 +1,68 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformExtractFunctionByLines(t *testing.T) {
+	provider := New()
+	source := `export function summarize(items: string[], limit: number): string {
+  const visible = items.slice(0, limit);
+  let count = 0;
+  for (const item of visible) {
+    count += item.length;
+  }
+  return visible.join(",") + count;
+}
+`
+
+	query, err := core.ParseDSL("function:summarize lines=2..6")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:       "extract_function",
+		Target:       query,
+		FunctionName: "measure",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+
+	if !strings.Contains(result.Modified, "  let { visible, count } = measure(items, limit);\n  return visible") {
+		t.Fatalf("expected destructured call site, got:\n%s", result.Modified)
+	}
+	if !strings.Contains(result.Modified, "function measure(items: string[], limit: number) {\n  const visible") {
+		t.Fatalf("expected annotated parameters, got:\n%s", result.Modified)
+	}
+	if !strings.Contains(result.Modified, "  return { visible, count };\n}\n") {
+		t.Fatalf("expected object return, got:\n%s", result.Modified)
+	}
+	if validation := provider.Validate(result.Modified); !validation.Valid {
+		t.Fatalf("extracted source does not parse: %v", validation.Errors)
+	}
+}
+
+func TestTransformExtractFunctionRejectsThis(t *testing.T) {
+	provider := New()
+	source := `class Store {
+  save(value: string) {
+    this.items.push(value);
+  }
+}
+`
+
+	query, err := core.ParseDSL("method:save lines=3")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{Method: "extract_function", Target: query})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "this") {
+		t.Fatalf("expected error about this, got %v", result.Error)
+	}
+}
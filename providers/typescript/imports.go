@@ -0,0 +1,365 @@
+package typescript
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	base "github.com/oxhq/morfx/providers/base"
+)
+
+// esImport is one binding introduced by an import statement.
+type esImport struct {
+	node *sitter.Node
+	kind string // default, namespace, or named
+	name string
+}
+
+func importBindings(statement *sitter.Node, source string) []esImport {
+	var bindings []esImport
+	for i := 0; i < int(statement.NamedChildCount()); i++ {
+		clause := statement.NamedChild(i)
+		if clause.Type() != "import_clause" {
+			continue
+		}
+		for j := 0; j < int(clause.NamedChildCount()); j++ {
+			child := clause.NamedChild(j)
+			switch child.Type() {
+			case "identifier":
+				bindings = append(bindings, esImport{node: child, kind: "default", name: nodeText(child, source)})
+			case "namespace_import":
+				for k := 0; k < int(child.NamedChildCount()); k++ {
+					if ident := child.NamedChild(k); ident.Type() == "identifier" {
+						bindings = append(bindings, esImport{node: child, kind: "namespace", name: nodeText(ident, source)})
+					}
+				}
+			case "named_imports":
+				for k := 0; k < int(child.NamedChildCount()); k++ {
+					specifier := child.NamedChild(k)
+					if specifier.Type() != "import_specifier" {
+						continue
+					}
+					local := specifier.ChildByFieldName("alias")
+					if local == nil {
+						local = specifier.ChildByFieldName("name")
+					}
+					if local != nil {
+						bindings = append(bindings, esImport{node: specifier, kind: "named", name: nodeText(local, source)})
+					}
+				}
+			}
+		}
+	}
+	return bindings
+}
+
+func nodeText(node *sitter.Node, source string) string {
+	return source[node.StartByte():node.EndByte()]
+}
+
+// importSource returns the module specifier without quotes.
+func importSource(statement *sitter.Node, source string) string {
+	if module := statement.ChildByFieldName("source"); module != nil {
+		return strings.Trim(nodeText(module, source), "'\"`")
+	}
+	return ""
+}
+
+// isTypeOnlyImport reports whether statement is written as import type.
+func isTypeOnlyImport(statement *sitter.Node) bool {
+	for i := 0; i < int(statement.ChildCount()); i++ {
+		child := statement.Child(i)
+		if child.Type() == "type" {
+			return true
+		}
+		if child.Type() == "import_clause" {
+			return false
+		}
+	}
+	return false
+}
+
+func topLevelImports(root *sitter.Node) []*sitter.Node {
+	var statements []*sitter.Node
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		if child := root.NamedChild(i); child.Type() == "import_statement" {
+			statements = append(statements, child)
+		}
+	}
+	return statements
+}
+
+// UnusedImports returns imported bindings the module never references.
+// Side-effect imports are never reported.
+func (c *Config) UnusedImports(source string, root *sitter.Node) []base.Target {
+	used := make(map[string]struct{})
+	var walk func(*sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "import_statement":
+			return
+		case "identifier", "type_identifier", "shorthand_property_identifier":
+			used[nodeText(n, source)] = struct{}{}
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(root)
+
+	var unused []base.Target
+	for _, statement := range topLevelImports(root) {
+		for _, binding := range importBindings(statement, source) {
+			if _, ok := used[binding.name]; !ok {
+				unused = append(unused, base.NewTarget(binding.node, "import", binding.name))
+			}
+		}
+	}
+	return unused
+}
+
+// MissingImports returns nil: TypeScript modules have no standard library
+// that can be resolved from a bare name.
+func (c *Config) MissingImports(source string, root *sitter.Node) []string {
+	return nil
+}
+
+// EnsureImport adds an import statement, merging its bindings into an
+// existing import of the same module when the two can be combined.
+func (c *Config) EnsureImport(source string, root *sitter.Node, spec string) (string, error) {
+	spec = strings.TrimSpace(spec)
+	specRoot, err := sitter.ParseCtx(context.Background(), []byte(spec), c.GetLanguage())
+	if err != nil {
+		return source, fmt.Errorf("failed to parse import %q: %w", spec, err)
+	}
+	wanted := specRoot.NamedChild(0)
+	if specRoot.HasError() || specRoot.NamedChildCount() != 1 || wanted.Type() != "import_statement" {
+		return source, fmt.Errorf("invalid import statement %q", spec)
+	}
+	module := importSource(wanted, spec)
+	wantedBindings := importBindings(wanted, spec)
+
+	for _, statement := range topLevelImports(root) {
+		if importSource(statement, source) != module || isTypeOnlyImport(statement) != isTypeOnlyImport(wanted) {
+			continue
+		}
+		existing := importBindings(statement, source)
+		if len(wantedBindings) == 0 {
+			return source, nil
+		}
+		merged, ok, changed := mergeImportBindings(existing, wantedBindings, source, spec)
+		if !ok {
+			continue
+		}
+		if !changed {
+			return source, nil
+		}
+		rebuilt := renderImport(statement, source, merged)
+		return source[:statement.StartByte()] + rebuilt + source[statement.EndByte():], nil
+	}
+
+	if !strings.HasSuffix(spec, ";") && usesSemicolons(root) {
+		spec += ";"
+	}
+	return insertImport(source, root, spec), nil
+}
+
+// importParts holds the clause of an import statement being rebuilt.
+type importParts struct {
+	defaultName string
+	namespace   string
+	named       []string
+}
+
+// mergeImportBindings combines the bindings of an existing import with the
+// wanted ones. ok is false when they cannot share one statement.
+func mergeImportBindings(existing, wanted []esImport, source, spec string) (importParts, bool, bool) {
+	parts := partsOf(existing, source)
+	changed := false
+	for _, binding := range wanted {
+		text := nodeText(binding.node, spec)
+		switch binding.kind {
+		case "default":
+			if parts.defaultName == text {
+				continue
+			}
+			if parts.defaultName != "" {
+				return parts, false, false
+			}
+			parts.defaultName = text
+		case "namespace":
+			if parts.namespace == text {
+				continue
+			}
+			if parts.namespace != "" || len(parts.named) > 0 {
+				return parts, false, false
+			}
+			parts.namespace = text
+		case "named":
+			if containsString(parts.named, text) {
+				continue
+			}
+			if parts.namespace != "" {
+				return parts, false, false
+			}
+			parts.named = append(parts.named, text)
+		}
+		changed = true
+	}
+	return parts, true, changed
+}
+
+func partsOf(bindings []esImport, source string) importParts {
+	var parts importParts
+	for _, binding := range bindings {
+		text := nodeText(binding.node, source)
+		switch binding.kind {
+		case "default":
+			parts.defaultName = text
+		case "namespace":
+			parts.namespace = text
+		case "named":
+			parts.named = append(parts.named, text)
+		}
+	}
+	return parts
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// renderImport rebuilds statement with parts, keeping its module specifier,
+// type-only marker, and semicolon.
+func renderImport(statement *sitter.Node, source string, parts importParts) string {
+	var clause []string
+	if parts.defaultName != "" {
+		clause = append(clause, parts.defaultName)
+	}
+	if parts.namespace != "" {
+		clause = append(clause, parts.namespace)
+	}
+	if len(parts.named) > 0 {
+		clause = append(clause, "{ "+strings.Join(parts.named, ", ")+" }")
+	}
+
+	var rendered strings.Builder
+	rendered.WriteString("import ")
+	if isTypeOnlyImport(statement) {
+		rendered.WriteString("type ")
+	}
+	rendered.WriteString(strings.Join(clause, ", "))
+	rendered.WriteString(" from ")
+	rendered.WriteString(nodeText(statement.ChildByFieldName("source"), source))
+	if last := statement.Child(int(statement.ChildCount()) - 1); last != nil && last.Type() == ";" {
+		rendered.WriteString(";")
+	}
+	return rendered.String()
+}
+
+func usesSemicolons(root *sitter.Node) bool {
+	for _, statement := range topLevelImports(root) {
+		last := statement.Child(int(statement.ChildCount()) - 1)
+		return last != nil && last.Type() == ";"
+	}
+	return true
+}
+
+// insertImport places text after the last import, or after leading
+// comments and directives when the module has no imports.
+func insertImport(source string, root *sitter.Node, text string) string {
+	if statements := topLevelImports(root); len(statements) > 0 {
+		end := int(statements[len(statements)-1].EndByte())
+		return source[:end] + "\n" + text + source[end:]
+	}
+	offset := 0
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		child := root.NamedChild(i)
+		isDirective := child.Type() == "expression_statement" &&
+			child.NamedChildCount() == 1 && child.NamedChild(0).Type() == "string"
+		if child.Type() != "comment" && !isDirective {
+			break
+		}
+		offset = int(child.EndByte())
+	}
+	rest := strings.TrimLeft(source[offset:], "\n")
+	separator := "\n"
+	if strings.TrimSpace(rest) != "" {
+		separator = "\n\n"
+	}
+	if offset == 0 {
+		return text + separator + rest
+	}
+	return source[:offset] + "\n\n" + text + separator + rest
+}
+
+// RemoveImports removes imported bindings, rewriting statements that keep
+// other bindings and deleting statements left empty.
+func (c *Config) RemoveImports(source string, root *sitter.Node, targets []base.Target) (string, error) {
+	removed := make(map[*sitter.Node]map[*sitter.Node]bool)
+	var statements []*sitter.Node
+	for _, target := range targets {
+		if target.Node == nil {
+			continue
+		}
+		statement := target.Node
+		for statement != nil && statement.Type() != "import_statement" {
+			statement = statement.Parent()
+		}
+		if statement == nil {
+			return source, fmt.Errorf("target %q is not an import", target.Name)
+		}
+		if removed[statement] == nil {
+			removed[statement] = make(map[*sitter.Node]bool)
+			statements = append(statements, statement)
+		}
+		removed[statement][target.Node] = true
+	}
+
+	sort.Slice(statements, func(i, j int) bool { return statements[i].StartByte() > statements[j].StartByte() })
+	result := source
+	for _, statement := range statements {
+		var kept []esImport
+		if !removed[statement][statement] {
+			for _, binding := range importBindings(statement, source) {
+				if !removed[statement][binding.node] {
+					kept = append(kept, binding)
+				}
+			}
+		}
+		start, end := int(statement.StartByte()), int(statement.EndByte())
+		if len(kept) > 0 {
+			result = result[:start] + renderImport(statement, source, partsOf(kept, source)) + result[end:]
+			continue
+		}
+		result = deleteStatementLine(result, start, end)
+	}
+	return result, nil
+}
+
+// deleteStatementLine removes source[start:end] together with its line
+// when nothing else shares the line, without leaving a double blank line.
+func deleteStatementLine(source string, start, end int) string {
+	lineStart := strings.LastIndex(source[:start], "\n") + 1
+	if strings.TrimSpace(source[lineStart:start]) == "" {
+		if newline := strings.IndexByte(source[end:], '\n'); newline >= 0 && strings.TrimSpace(source[end:end+newline]) == "" {
+			start = lineStart
+			end += newline + 1
+		}
+	}
+	before, after := source[:start], source[end:]
+	if start == 0 {
+		after = strings.TrimLeft(after, "\n")
+	} else if strings.HasSuffix(before, "\n\n") && strings.HasPrefix(after, "\n") {
+		after = after[1:]
+	}
+	return before + after
+}
@@ -0,0 +1,111 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxhq/morfx/core"
+)
+
+func TestTransformEnsureImportMergesNamedImports(t *testing.T) {
+	provider := New()
+	source := `import { useState } from 'react';
+import type { Props } from './types';
+
+export const view = (p: Props) => useState(p);
+`
+
+	result := provider.Transform(source, core.TransformOp{Method: "ensure_import", Content: `import { useEffect, useState } from "react"`})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.HasPrefix(result.Modified, "import { useState, useEffect } from 'react';\n") {
+		t.Fatalf("expected useEffect merged into the react import, got:\n%s", result.Modified)
+	}
+
+	result = provider.Transform(result.Modified, core.TransformOp{Method: "ensure_import", Content: `import * as path from "node:path"`})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.Contains(result.Modified, "import type { Props } from './types';\nimport * as path from \"node:path\";\n") {
+		t.Fatalf("expected new import after the last import, got:\n%s", result.Modified)
+	}
+
+	again := provider.Transform(result.Modified, core.TransformOp{Method: "ensure_import", Content: `import { useState } from 'react';`})
+	if again.Error != nil {
+		t.Fatalf("Transform returned error: %v", again.Error)
+	}
+	if again.Modified != result.Modified || again.MatchCount != 0 {
+		t.Fatalf("expected existing binding to be left alone, got:\n%s", again.Modified)
+	}
+}
+
+func TestTransformEnsureImportRejectsNonImport(t *testing.T) {
+	provider := New()
+	result := provider.Transform("export const a = 1;\n", core.TransformOp{Method: "ensure_import", Content: "const x = 1"})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "invalid import statement") {
+		t.Fatalf("expected invalid import error, got %v", result.Error)
+	}
+}
+
+func TestTransformRemoveImportSpecifier(t *testing.T) {
+	provider := New()
+	source := `import React, { useMemo, useState } from 'react';
+import { join } from 'path';
+
+export const x = useState(React);
+`
+
+	query, err := core.ParseDSL("import:useMemo")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{Method: "remove_import", Target: query})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.HasPrefix(result.Modified, "import React, { useState } from 'react';\n") {
+		t.Fatalf("expected useMemo removed, got:\n%s", result.Modified)
+	}
+
+	query, err = core.ParseDSL("import:join")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result = provider.Transform(result.Modified, core.TransformOp{Method: "remove_import", Target: query})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if strings.Contains(result.Modified, "'path'") {
+		t.Fatalf("expected empty import statement removed, got:\n%s", result.Modified)
+	}
+}
+
+func TestTransformOrganizeImportsRemovesStaleBinding(t *testing.T) {
+	provider := New()
+	source := `import { format, parse } from 'date-fns';
+
+export function show(d: Date): string {
+  return format(d, 'yyyy');
+}
+
+export function read(s: string): Date {
+  return parse(s, 'yyyy', new Date());
+}
+`
+
+	query, err := core.ParseDSL("function:read")
+	if err != nil {
+		t.Fatalf("ParseDSL returned error: %v", err)
+	}
+	result := provider.Transform(source, core.TransformOp{Method: "delete", Target: query, OrganizeImports: true})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	if !strings.HasPrefix(result.Modified, "import { format } from 'date-fns';\n") {
+		t.Fatalf("expected parse import pruned, got:\n%s", result.Modified)
+	}
+	if removed, _ := result.Metadata["imports_removed"].([]string); len(removed) != 1 || removed[0] != "parse" {
+		t.Fatalf("expected imports_removed [parse], got %#v", result.Metadata["imports_removed"])
+	}
+}
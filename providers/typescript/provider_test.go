@@ -240,6 +240,24 @@ function greet(name: string): string {
 	}
 }
 
+func TestTypeScriptProvider_Transform_ReindentKeepsTemplateLiterals(t *testing.T) {
+	provider := New()
+	source := "class Page {\n  render(): string {\n    return '';\n  }\n}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method:  "insert_after",
+		Target:  core.AgentQuery{Type: "method", Name: "render"},
+		Content: "footer(): string {\n    return `<footer>\n    ${this.year}\n</footer>`;\n}",
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform returned error: %v", result.Error)
+	}
+	want := "  footer(): string {\n    return `<footer>\n    ${this.year}\n</footer>`;\n  }\n}\n"
+	if !strings.HasSuffix(result.Modified, want) {
+		t.Fatalf("expected template contents kept, got %q", result.Modified)
+	}
+}
+
 func TestTypeScriptProvider_Transform_Delete(t *testing.T) {
 	provider := New()
 	source := `
@@ -1113,3 +1131,88 @@ export class PublicAPI {
 		t.Errorf("Expected higher confidence for modifying private method, got %f", result2.Confidence.Score)
 	}
 }
+
+func TestTypeScriptProvider_Transform_DeleteTakesDecorators(t *testing.T) {
+	provider := New()
+	source := "export class UsersController {\n  /** Lists users. */\n  @Get()\n  list() {}\n\n  show() {}\n}\n"
+
+	result := provider.Transform(source, core.TransformOp{
+		Method: "delete",
+		Target: core.AgentQuery{Type: "method", Name: "list"},
+	})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	for _, gone := range []string{"Lists users", "@Get", "list()"} {
+		if strings.Contains(result.Modified, gone) {
+			t.Errorf("expected %q to be deleted:\n%s", gone, result.Modified)
+		}
+	}
+	if !strings.Contains(result.Modified, "show() {}") {
+		t.Fatalf("expected show to survive:\n%s", result.Modified)
+	}
+}
+
+func TestTypeScriptProvider_Transform_SortMembersByKind(t *testing.T) {
+	provider := New()
+	source := "class A {\n  /** z */\n  @Get()\n  zed() {}\n  private b = 1; // bee\n  alpha() {}\n}\n"
+	target, err := core.ParseDSL("class:A > field:* | class:A > method:*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{Method: "sort", Target: target, SortBy: core.SortByKind})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	// Fields go first, as listed in the selector; methods keep their order.
+	want := "class A {\n  private b = 1; // bee\n  /** z */\n  @Get()\n  zed() {}\n  alpha() {}\n}\n"
+	if result.Modified != want {
+		t.Fatalf("unexpected order:\n%s", result.Modified)
+	}
+}
+
+func TestTypeScriptProvider_Transform_SortObjectKeys(t *testing.T) {
+	provider := New()
+	target, err := core.ParseDSL("object:* > pair:*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform("const o = {\n  zeta: 1, // last\n  alpha: 2,\n  \"mid\": 3\n};\n", core.TransformOp{Method: "sort", Target: target})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	// Commas stay in place; the trailing comment moves with its pair.
+	want := "const o = {\n  alpha: 2,\n  \"mid\": 3,\n  zeta: 1 // last\n};\n"
+	if result.Modified != want {
+		t.Fatalf("unexpected order:\n%s", result.Modified)
+	}
+}
+
+func TestTypeScriptProvider_Transform_AddAnnotationSkipsPresent(t *testing.T) {
+	provider := New()
+	source := "@Injectable()\nexport class A {\n  @Get('/x')\n  f() {}\n  @Input() name: string;\n}\n"
+	target, err := core.ParseDSL("class:A > method:* | class:A > field:*")
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	result := provider.Transform(source, core.TransformOp{Method: "add_annotation", Target: target, Annotation: "@Input()", IfAbsent: true})
+	if result.Error != nil {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+	// The field already has @Input; the method gets it on its own line.
+	want := "@Injectable()\nexport class A {\n  @Get('/x')\n  @Input()\n  f() {}\n  @Input() name: string;\n}\n"
+	if result.Modified != want {
+		t.Fatalf("unexpected result:\n%s", result.Modified)
+	}
+
+	exported := provider.Transform(source, core.TransformOp{Method: "add_annotation", Target: core.AgentQuery{Type: "class", Name: "A"}, Annotation: "@Deprecated()"})
+	if exported.Error != nil {
+		t.Fatalf("Transform failed: %v", exported.Error)
+	}
+	if !strings.HasPrefix(exported.Modified, "@Injectable()\n@Deprecated()\nexport class A {") {
+		t.Fatalf("expected the decorator before export:\n%s", exported.Modified)
+	}
+}